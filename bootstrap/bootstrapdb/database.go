@@ -22,12 +22,12 @@ type Config struct {
 
 // DB contains access to different database tables
 type DB struct {
-	kdb, ndb storage.KeyValueStore
+	kdb, ndb, idb, pdb, fdb storage.KeyValueStore
 }
 
 // New creates a new master database for storage node
 func New(config Config) (*DB, error) {
-	dbs, err := boltdb.NewShared(config.Kademlia, kademlia.KademliaBucket, kademlia.NodeBucket)
+	dbs, err := boltdb.NewShared(config.Kademlia, kademlia.KademliaBucket, kademlia.NodeBucket, kademlia.IdentityBucket, kademlia.PinnedBucket, kademlia.FailureBucket)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +35,9 @@ func New(config Config) (*DB, error) {
 	return &DB{
 		kdb: dbs[0],
 		ndb: dbs[1],
+		idb: dbs[2],
+		pdb: dbs[3],
+		fdb: dbs[4],
 	}, nil
 }
 
@@ -44,6 +47,9 @@ func NewInMemory(storageDir string) (*DB, error) {
 	return &DB{
 		kdb: teststore.New(),
 		ndb: teststore.New(),
+		idb: teststore.New(),
+		pdb: teststore.New(),
+		fdb: teststore.New(),
 	}, nil
 }
 
@@ -55,6 +61,9 @@ func (db *DB) Close() error {
 	return errs.Combine(
 		db.kdb.Close(),
 		db.ndb.Close(),
+		db.idb.Close(),
+		db.pdb.Close(),
+		db.fdb.Close(),
 	)
 }
 
@@ -62,3 +71,18 @@ func (db *DB) Close() error {
 func (db *DB) RoutingTable() (kdb, ndb storage.KeyValueStore) {
 	return db.kdb, db.ndb
 }
+
+// PeerIdentities returns the database for verified peer identities
+func (db *DB) PeerIdentities() storage.KeyValueStore {
+	return db.idb
+}
+
+// PinnedNodes returns the database for pinned nodes
+func (db *DB) PinnedNodes() storage.KeyValueStore {
+	return db.pdb
+}
+
+// DialFailures returns the database for dial failure statistics
+func (db *DB) DialFailures() storage.KeyValueStore {
+	return db.fdb
+}
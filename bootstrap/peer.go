@@ -14,7 +14,10 @@ import (
 	"storj.io/storj/bootstrap/bootstrapweb"
 	"storj.io/storj/bootstrap/bootstrapweb/bootstrapserver"
 	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/lifecycle"
+	"storj.io/storj/internal/sync2"
 	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/overlay"
@@ -35,6 +38,9 @@ type DB interface {
 
 	// TODO: use better interfaces
 	RoutingTable() (kdb, ndb storage.KeyValueStore)
+	PeerIdentities() storage.KeyValueStore
+	PinnedNodes() storage.KeyValueStore
+	DialFailures() storage.KeyValueStore
 }
 
 // Config is all the configuration parameters for a Bootstrap Node
@@ -42,6 +48,8 @@ type Config struct {
 	Identity identity.Config
 
 	Server   server.Config
+	Egress   transport.EgressConfig
+	Events   eventlog.Config
 	Kademlia kademlia.Config
 
 	Web bootstrapserver.Config
@@ -62,10 +70,16 @@ type Peer struct {
 	DB       DB
 
 	Transport transport.Client
+	Bandwidth *transport.Bandwidth
+	Events    *eventlog.Sink
 
 	Server *server.Server
 
 	Version *version.Service
+	// NextRuns reports the next runs of the periodic loops
+	NextRuns sync2.NextRuns
+	// Services are the background services, run by Run and closed by Close
+	Services *lifecycle.Group
 
 	// services and endpoints
 	Kademlia struct {
@@ -89,6 +103,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 		Log:      log,
 		Identity: full,
 		DB:       db,
+		Services: lifecycle.NewGroup(log.Named("services")),
 	}
 
 	var err error
@@ -99,7 +114,10 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 			peer.Log.Sugar().Debugf("Binary Version: %s with CommitHash %s, built at %s as Release %v",
 				versionInfo.Version.String(), versionInfo.CommitHash, versionInfo.Timestamp.String(), versionInfo.Release)
 		}
-		peer.Version = version.NewService(config.Version, versionInfo, "Bootstrap")
+		peer.Version = version.NewService(log.Named("version"), config.Version, versionInfo, "Bootstrap")
+		peer.Version.Loop.SetSplay(sync2.NewSplay(peer.Identity.ID.Bytes(), "version"))
+		peer.NextRuns.Add("version", peer.Version.Loop)
+		peer.Services.Add("version", peer.Version)
 	}
 
 	{ // setup listener and server
@@ -109,19 +127,36 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 			return nil, errs.Combine(err, peer.Close())
 		}
 
-		peer.Transport = transport.NewClient(options)
+		peer.Events, err = eventlog.Open(peer.Log.Named("events"), config.Events)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+
+		egress, err := transport.NewEgressPolicy(config.Egress)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		proxy, err := transport.NewProxy(config.Egress.ProxyURL)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.Bandwidth = transport.NewBandwidth()
+		peer.Transport = transport.NewClient(options).WithEvents(peer.Events).WithEgressPolicy(egress).WithProxy(proxy).WithBandwidth(peer.Bandwidth)
 
-		peer.Server, err = server.New(options, sc.Address, sc.PrivateAddress, nil)
+		peer.Server, err = server.New(peer.Log.Named("server"), options, sc.Address, sc.PrivateAddress, nil)
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		if sc.WrapListener != nil {
+			peer.Server.WrapListener(sc.WrapListener)
+		}
 	}
 
 	{ // setup kademlia
 		config := config.Kademlia
 		// TODO: move this setup logic into kademlia package
 		if config.ExternalAddress == "" {
-			config.ExternalAddress = peer.Addr()
+			config.ExternalAddress = peer.Server.ExternalAddr()
 		}
 
 		pbVersion, err := versionInfo.Proto()
@@ -149,13 +184,24 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		if err := peer.Kademlia.RoutingTable.LoadPinned(peer.DB.PinnedNodes()); err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
 
 		peer.Transport = peer.Transport.WithObservers(peer.Kademlia.RoutingTable)
 
-		peer.Kademlia.Service, err = kademlia.NewService(peer.Log.Named("kademlia"), peer.Transport, peer.Kademlia.RoutingTable, config)
+		peer.Kademlia.Service, err = kademlia.NewService(peer.Log.Named("kademlia"), peer.Transport, peer.Kademlia.RoutingTable, peer.DB.PeerIdentities(), config)
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		peer.NextRuns.AddFunc(peer.Kademlia.Service.NextRuns)
+		peer.Services.Add("kademlia", peer.Kademlia.Service)
+		peer.Services.Add("kademlia bootstrap", lifecycle.Func(peer.Kademlia.Service.Bootstrap))
+		if err := peer.Kademlia.Service.LoadDialFailures(peer.DB.DialFailures()); err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.Kademlia.Service.SetEventSink(peer.Events)
+		peer.Kademlia.Service.SetFeatures(peer.Version)
 
 		peer.Kademlia.Endpoint = kademlia.NewEndpoint(peer.Log.Named("kademlia:endpoint"), peer.Kademlia.Service, peer.Kademlia.RoutingTable)
 		pb.RegisterNodesServer(peer.Server.GRPC(), peer.Kademlia.Endpoint)
@@ -196,15 +242,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 func (peer *Peer) Run(ctx context.Context) error {
 	group, ctx := errgroup.WithContext(ctx)
 
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Version.Run(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Kademlia.Service.Bootstrap(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Kademlia.Service.Run(ctx))
-	})
+	peer.Services.Start(ctx, group)
 	group.Go(func() error {
 		// TODO: move the message into Server instead
 		// Don't change the format of this comment, it is used to figure out the node id.
@@ -240,13 +278,15 @@ func (peer *Peer) Close() error {
 	}
 
 	// close services in reverse initialization order
-	if peer.Kademlia.Service != nil {
-		errlist.Add(peer.Kademlia.Service.Close())
-	}
+	errlist.Add(peer.Services.Close())
 	if peer.Kademlia.RoutingTable != nil {
 		errlist.Add(peer.Kademlia.RoutingTable.Close())
 	}
 
+	if peer.Events != nil {
+		errlist.Add(peer.Events.Close())
+	}
+
 	return errlist.Err()
 }
 
@@ -17,6 +17,7 @@ import (
 	"storj.io/storj/internal/fpath"
 	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/cfgstruct"
+	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/process"
 )
 
@@ -42,6 +43,7 @@ var (
 
 	confDir     string
 	identityDir string
+	defaults    cfgstruct.BindOpt
 )
 
 const (
@@ -53,7 +55,7 @@ func init() {
 	defaultIdentityDir := fpath.ApplicationDir("storj", "identity", "bootstrap")
 	cfgstruct.SetupFlag(zap.L(), rootCmd, &confDir, "config-dir", defaultConfDir, "main directory for bootstrap configuration")
 	cfgstruct.SetupFlag(zap.L(), rootCmd, &identityDir, "identity-dir", defaultIdentityDir, "main directory for bootstrap identity credentials")
-	defaults := cfgstruct.DefaultsFlag(rootCmd)
+	defaults = cfgstruct.DefaultsFlag(rootCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(setupCmd)
 	cfgstruct.Bind(runCmd.Flags(), &runCfg, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
@@ -108,6 +110,30 @@ func cmdRun(cmd *cobra.Command, args []string) (err error) {
 		return errs.New("Error creating tables for master database on bootstrap: %+v", err)
 	}
 
+	if err := version.SaveLastRun(confDir); err != nil {
+		zap.S().Warn("Failed to record the version used with the configuration: ", err)
+	}
+
+	peer.Server.PrivateHTTP().Handle("/debug/config", cfgstruct.DescribeHandler(map[string]interface{}{
+		"identity": runCfg.Identity,
+		"server":   runCfg.Server,
+		"kademlia": runCfg.Kademlia,
+	}, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir)))
+	process.DebugHandle("/debug/kademlia/workers", peer.Kademlia.Service.Workers())
+	process.DebugHandle("/debug/loops", &peer.NextRuns)
+	process.DebugHandle("/debug/services", peer.Services)
+
+	peer.Kademlia.Service.SetConfigLoader(func() (kademlia.Config, error) {
+		var reloaded bootstrap.Config
+		err := process.ReloadConfig(cmd, &reloaded, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
+		return reloaded.Kademlia, err
+	})
+	process.OnReload(ctx, func() {
+		if err := peer.Kademlia.Service.ReloadConfig(ctx); err != nil {
+			log.Error("failed to reload configuration", zap.Error(err))
+		}
+	})
+
 	runError := peer.Run(ctx)
 	closeError := peer.Close()
 
@@ -0,0 +1,150 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/spf13/cobra"
+
+	"storj.io/storj/pkg/eventlog"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+var (
+	contactHistoryCmd = &cobra.Command{
+		Use:   "contact-history <peer_id>",
+		Short: "show the recent contacts with a peer, side by side with the peer's view when --peer-address is set",
+		Args:  cobra.ExactArgs(1),
+		RunE:  ContactHistory,
+	}
+
+	contactHistoryCfg struct {
+		Limit       int64
+		PeerAddress string
+	}
+)
+
+func init() {
+	kadCmd.AddCommand(contactHistoryCmd)
+	contactHistoryCmd.Flags().Int64Var(&contactHistoryCfg.Limit, "limit", 50, "max number of contacts per side")
+	contactHistoryCmd.Flags().StringVar(&contactHistoryCfg.PeerAddress, "peer-address", "", "inspector address of the peer, for showing its side of the contacts")
+}
+
+// ContactHistory prints the recent contacts with a peer, and the peer's
+// recent contacts with the inspected node when its inspector is reachable.
+func ContactHistory(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+
+	peerID, err := storj.NodeIDFromString(args[0])
+	if err != nil {
+		return ErrArgs.Wrap(err)
+	}
+
+	i, err := NewInspector(*Addr, *IdentityPath)
+	if err != nil {
+		return ErrInspectorDial.Wrap(err)
+	}
+	local, err := i.kadclient.GetContactHistory(ctx, &pb.GetContactHistoryRequest{
+		PeerId: peerID,
+		Limit:  contactHistoryCfg.Limit,
+	})
+	if err != nil {
+		return ErrRequest.Wrap(err)
+	}
+
+	remote := &pb.GetContactHistoryResponse{NodeId: peerID}
+	if contactHistoryCfg.PeerAddress != "" {
+		other, err := NewInspector(contactHistoryCfg.PeerAddress, *IdentityPath)
+		if err != nil {
+			return ErrInspectorDial.Wrap(err)
+		}
+		remote, err = other.kadclient.GetContactHistory(ctx, &pb.GetContactHistoryRequest{
+			PeerId: local.NodeId,
+			Limit:  contactHistoryCfg.Limit,
+		})
+		if err != nil {
+			return ErrRequest.Wrap(err)
+		}
+		if remote.NodeId != peerID {
+			return ErrArgs.New("%s belongs to %s, not %s", contactHistoryCfg.PeerAddress, remote.NodeId, peerID)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\t%s\t%s\n", local.NodeId, remote.NodeId)
+	for _, row := range mergeContactHistories(local.Events, remote.Events) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.time.Format("15:04:05.000"), formatContactEvent(row.local), formatContactEvent(row.remote))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if threshold, _ := ptypes.Duration(local.TenureThreshold); threshold > 0 {
+		fmt.Printf("\ntenure: contacts known for %s are evicted after %d failed checks in a row\n", threshold, local.TenureGrace+1)
+	} else {
+		fmt.Printf("\ntenure: disabled, contacts are evicted on their first failed check\n")
+	}
+	if stats := local.Stats; stats != nil {
+		firstSeen, _ := ptypes.Timestamp(stats.FirstSeen)
+		state := "in the routing table"
+		switch {
+		case stats.Evicted:
+			state = "evicted"
+		case stats.Tenured:
+			state = "tenured"
+		}
+		fmt.Printf("%s: %s, known since %s, %d successes, %d failures (%d in a row)\n", peerID, state,
+			firstSeen.Format(time.RFC3339), stats.Successes, stats.Failures, stats.ConsecutiveFailures)
+	}
+	return nil
+}
+
+// contactHistoryRow is a single event of either side of the contacts.
+type contactHistoryRow struct {
+	time          time.Time
+	local, remote *pb.ContactEvent
+}
+
+// mergeContactHistories orders the events of both sides by time.
+func mergeContactHistories(local, remote []*pb.ContactEvent) []contactHistoryRow {
+	var rows []contactHistoryRow
+	for _, event := range local {
+		at, _ := ptypes.Timestamp(event.Time)
+		rows = append(rows, contactHistoryRow{time: at, local: event})
+	}
+	for _, event := range remote {
+		at, _ := ptypes.Timestamp(event.Time)
+		rows = append(rows, contactHistoryRow{time: at, remote: event})
+	}
+	sort.SliceStable(rows, func(i, k int) bool {
+		return rows[i].time.Before(rows[k].time)
+	})
+	return rows
+}
+
+// formatContactEvent formats event as "-> ping ok (2ms)", with <- for incoming contacts.
+func formatContactEvent(event *pb.ContactEvent) string {
+	if event == nil {
+		return ""
+	}
+
+	arrow := "->"
+	if event.Direction == eventlog.Incoming {
+		arrow = "<-"
+	}
+	result := "ok"
+	if event.ErrorClass != "" {
+		result = event.ErrorClass
+	}
+	duration, _ := ptypes.Duration(event.Duration)
+	return fmt.Sprintf("%s %s %s (%s)", arrow, event.Operation, result, duration.Round(time.Millisecond))
+}
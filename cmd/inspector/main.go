@@ -14,15 +14,19 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	prompt "github.com/segmentio/go-prompt"
 	"github.com/spf13/cobra"
 	"github.com/zeebo/errs"
 
+	"storj.io/storj/pkg/backoff"
 	"storj.io/storj/pkg/eestream"
 	"storj.io/storj/pkg/identity"
+	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/kademlia/routinggraph"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/process"
@@ -54,6 +58,9 @@ var (
 
 	irreparableLimit int32
 
+	// dumpChunkSize is the number of nodes requested at once when dumping the routing table
+	dumpChunkSize = 1000
+
 	// Commander CLI
 	rootCmd = &cobra.Command{
 		Use:   "inspector",
@@ -93,6 +100,12 @@ var (
 		Args:  cobra.MinimumNArgs(1),
 		RunE:  LookupNode,
 	}
+	lookupTraceCmd = &cobra.Command{
+		Use:   "lookup-trace <node_id>",
+		Short: "lookup a node by ID and show the peers asked as a tree",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  LookupTrace,
+	}
 	nodeInfoCmd = &cobra.Command{
 		Use:   "node-info <node_id>",
 		Short: "get node info directly from node",
@@ -104,6 +117,31 @@ var (
 		Short: "dump all nodes in the routing table",
 		RunE:  DumpNodes,
 	}
+	reloadConfigCmd = &cobra.Command{
+		Use:   "reload-config",
+		Short: "reload the kademlia settings that can change without a restart",
+		RunE:  ReloadConfig,
+	}
+	networkSizeCmd = &cobra.Command{
+		Use:   "network-size",
+		Short: "estimate the number of nodes in the network from the routing table",
+		RunE:  NetworkSize,
+	}
+	addressConflictsCmd = &cobra.Command{
+		Use:   "address-conflicts",
+		Short: "list the nodes recently verified at several addresses, which may be used by another node",
+		RunE:  AddressConflicts,
+	}
+	quarantinedCmd = &cobra.Command{
+		Use:   "quarantined",
+		Short: "list the nodes learned through unverified dials, kept out of the routing table until verified",
+		RunE:  QuarantinedNodes,
+	}
+	rpcPoliciesCmd = &cobra.Command{
+		Use:   "rpc-policies",
+		Short: "print the effective timeouts, retries and priorities of the kademlia RPCs",
+		RunE:  RPCPolicies,
+	}
 	drawTableCmd = &cobra.Command{
 		Use:   "routing-graph",
 		Short: "Dumps a graph of the routing table in the dot format",
@@ -201,6 +239,119 @@ func CountNodes(cmd *cobra.Command, args []string) (err error) {
 	return nil
 }
 
+// ReloadConfig makes the node read its configuration again
+func ReloadConfig(cmd *cobra.Command, args []string) (err error) {
+	i, err := NewInspector(*Addr, *IdentityPath)
+	if err != nil {
+		return ErrInspectorDial.Wrap(err)
+	}
+
+	_, err = i.kadclient.ReloadConfig(context.Background(), &pb.ReloadConfigRequest{})
+	if err != nil {
+		return ErrRequest.Wrap(err)
+	}
+
+	fmt.Println("Configuration reloaded")
+	return nil
+}
+
+// NetworkSize prints the number of nodes in the network estimated by the node
+func NetworkSize(cmd *cobra.Command, args []string) (err error) {
+	i, err := NewInspector(*Addr, *IdentityPath)
+	if err != nil {
+		return ErrInspectorDial.Wrap(err)
+	}
+
+	size, err := i.kadclient.NetworkSize(context.Background(), &pb.NetworkSizeRequest{})
+	if err != nil {
+		return ErrRequest.Wrap(err)
+	}
+
+	if size.Exact {
+		fmt.Printf("Network size: %d (every node is in the routing table)\n", size.Estimate)
+		return nil
+	}
+	fmt.Printf("Network size: %d (between %d and %d)\n", size.Estimate, size.Low, size.High)
+	return nil
+}
+
+// AddressConflicts prints the nodes recently verified at several addresses
+func AddressConflicts(cmd *cobra.Command, args []string) (err error) {
+	i, err := NewInspector(*Addr, *IdentityPath)
+	if err != nil {
+		return ErrInspectorDial.Wrap(err)
+	}
+
+	resp, err := i.kadclient.AddressConflicts(context.Background(), &pb.AddressConflictsRequest{})
+	if err != nil {
+		return ErrRequest.Wrap(err)
+	}
+
+	if len(resp.Conflicts) == 0 {
+		fmt.Println("No address conflicts")
+		return nil
+	}
+	printAddress := func(label string, verified *pb.VerifiedAddress) {
+		first, _ := ptypes.Timestamp(verified.GetFirstVerified())
+		last, _ := ptypes.Timestamp(verified.GetLastVerified())
+		fmt.Printf("  %-9s  %s (first verified %s, last verified %s)\n", label, verified.GetAddress().GetAddress(),
+			first.Format(time.RFC3339), last.Format(time.RFC3339))
+	}
+	for _, conflict := range resp.Conflicts {
+		fmt.Println(conflict.NodeId)
+		printAddress("preferred", conflict.Preferred)
+		for _, alternate := range conflict.Alternates {
+			printAddress("alternate", alternate)
+		}
+	}
+	return nil
+}
+
+// RPCPolicies prints the effective policies of the RPCs made by the kademlia dialer
+func RPCPolicies(cmd *cobra.Command, args []string) (err error) {
+	i, err := NewInspector(*Addr, *IdentityPath)
+	if err != nil {
+		return ErrInspectorDial.Wrap(err)
+	}
+
+	resp, err := i.kadclient.RPCPolicies(context.Background(), &pb.RPCPoliciesRequest{})
+	if err != nil {
+		return ErrRequest.Wrap(err)
+	}
+
+	fmt.Printf("%-26s %10s %8s %10s  %s\n", "RPC", "TIMEOUT", "RETRIES", "BACKOFF", "PRIORITY")
+	for _, policy := range resp.Policies {
+		timeout, _ := ptypes.Duration(policy.Timeout)
+		backoff, _ := ptypes.Duration(policy.Backoff)
+		fmt.Printf("%-26s %10v %8d %10v  %s\n", policy.Rpc, timeout, policy.Retries, backoff, policy.Priority)
+	}
+	return nil
+}
+
+// QuarantinedNodes prints the nodes learned through unverified dials that weren't verified yet
+func QuarantinedNodes(cmd *cobra.Command, args []string) (err error) {
+	i, err := NewInspector(*Addr, *IdentityPath)
+	if err != nil {
+		return ErrInspectorDial.Wrap(err)
+	}
+
+	resp, err := i.kadclient.QuarantinedNodes(context.Background(), &pb.QuarantinedNodesRequest{})
+	if err != nil {
+		return ErrRequest.Wrap(err)
+	}
+
+	if len(resp.Nodes) == 0 {
+		fmt.Println("No quarantined nodes")
+		return nil
+	}
+	for _, quarantined := range resp.Nodes {
+		learned, _ := ptypes.Timestamp(quarantined.Learned)
+		fmt.Printf("%s  %-21s %-9s learned %s\n", quarantined.Node.Id, quarantined.Node.GetAddress().GetAddress(),
+			quarantined.Type, learned.Format(time.RFC3339))
+	}
+	return nil
+}
+
 // LookupNode starts a Kademlia lookup for the provided Node ID
 func LookupNode(cmd *cobra.Command, args []string) (err error) {
 	i, err := NewInspector(*Addr, *IdentityPath)
@@ -221,6 +372,28 @@ func LookupNode(cmd *cobra.Command, args []string) (err error) {
 	return nil
 }
 
+// LookupTrace starts a traced Kademlia lookup for the provided Node ID
+func LookupTrace(cmd *cobra.Command, args []string) (err error) {
+	target, err := storj.NodeIDFromString(args[0])
+	if err != nil {
+		return ErrArgs.Wrap(err)
+	}
+
+	i, err := NewInspector(*Addr, *IdentityPath)
+	if err != nil {
+		return ErrInspectorDial.Wrap(err)
+	}
+
+	trace, err := i.kadclient.LookupWithTrace(context.Background(), &pb.LookupWithTraceRequest{
+		Target: target,
+	})
+	if err != nil {
+		return ErrRequest.Wrap(err)
+	}
+
+	return printTrace(os.Stdout, trace)
+}
+
 // NodeInfo get node info directly from the node with provided Node ID
 func NodeInfo(cmd *cobra.Command, args []string) (err error) {
 	i, err := NewInspector(*Addr, *IdentityPath)
@@ -277,15 +450,16 @@ func DumpNodes(cmd *cobra.Command, args []string) (err error) {
 		return ErrInspectorDial.Wrap(err)
 	}
 
-	nodes, err := i.kadclient.FindNear(context.Background(), &pb.FindNearRequest{
-		Start: storj.NodeID{},
-		Limit: 100000,
+	nodes, err := kademlia.DumpSnapshot(context.Background(), i.kadclient, dumpChunkSize, &backoff.Exponential{
+		Base:       time.Second,
+		Max:        10 * time.Second,
+		MaxElapsed: 5 * time.Minute,
 	})
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(prettyPrint(nodes))
+	fmt.Println(prettyPrint(&pb.FindNearResponse{Nodes: nodes}))
 
 	return nil
 }
@@ -352,8 +526,8 @@ func GetStats(cmd *cobra.Command, args []string) (err error) {
 	}
 
 	fmt.Printf("Stats for ID %s:\n", nodeID)
-	fmt.Printf("AuditSuccessRatio: %f, AuditCount: %d, UptimeRatio: %f, UptimeCount: %d,\n",
-		res.AuditRatio, res.AuditCount, res.UptimeRatio, res.UptimeCount)
+	fmt.Printf("AuditSuccessRatio: %f, AuditCount: %d, UptimeRatio: %f, UptimeCount: %d, Reachability: %f,\n",
+		res.AuditRatio, res.AuditCount, res.UptimeRatio, res.UptimeCount, res.Reachability)
 	return nil
 }
 
@@ -388,8 +562,8 @@ func GetCSVStats(cmd *cobra.Command, args []string) (err error) {
 		}
 
 		fmt.Printf("Stats for ID %s:\n", nodeID)
-		fmt.Printf("AuditSuccessRatio: %f, AuditCount: %d, UptimeRatio: %f, UptimeCount: %d,\n",
-			res.AuditRatio, res.AuditCount, res.UptimeRatio, res.UptimeCount)
+		fmt.Printf("AuditSuccessRatio: %f, AuditCount: %d, UptimeRatio: %f, UptimeCount: %d, Reachability: %f,\n",
+			res.AuditRatio, res.AuditCount, res.UptimeRatio, res.UptimeCount, res.Reachability)
 	}
 	return nil
 }
@@ -756,9 +930,15 @@ func init() {
 	kadCmd.AddCommand(countNodeCmd)
 	kadCmd.AddCommand(pingNodeCmd)
 	kadCmd.AddCommand(lookupNodeCmd)
+	kadCmd.AddCommand(lookupTraceCmd)
 	kadCmd.AddCommand(nodeInfoCmd)
 	kadCmd.AddCommand(dumpNodesCmd)
 	kadCmd.AddCommand(drawTableCmd)
+	kadCmd.AddCommand(reloadConfigCmd)
+	kadCmd.AddCommand(networkSizeCmd)
+	kadCmd.AddCommand(addressConflictsCmd)
+	kadCmd.AddCommand(rpcPoliciesCmd)
+	kadCmd.AddCommand(quarantinedCmd)
 
 	statsCmd.AddCommand(getStatsCmd)
 	statsCmd.AddCommand(getCSVStatsCmd)
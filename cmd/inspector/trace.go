@@ -0,0 +1,80 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// printTrace writes the peers asked by a lookup as a tree, every peer below
+// the peer whose response introduced it.
+func printTrace(w io.Writer, trace *pb.LookupWithTraceResponse) error {
+	duration, err := ptypes.Duration(trace.Duration)
+	if err != nil {
+		return err
+	}
+
+	asked := map[storj.NodeID]bool{}
+	for _, hop := range trace.Hops {
+		asked[hop.PeerId] = true
+	}
+	children := map[storj.NodeID][]*pb.LookupHop{}
+	var roots []*pb.LookupHop
+	for _, hop := range trace.Hops {
+		// peers introduced by a peer that isn't recorded are shown as roots
+		if hop.ParentId.IsZero() || !asked[hop.ParentId] {
+			roots = append(roots, hop)
+			continue
+		}
+		children[hop.ParentId] = append(children[hop.ParentId], hop)
+	}
+
+	var printHops func(hops []*pb.LookupHop, depth int) error
+	printHops = func(hops []*pb.LookupHop, depth int) error {
+		for _, hop := range hops {
+			hopDuration, err := ptypes.Duration(hop.Duration)
+			if err != nil {
+				return err
+			}
+			line := fmt.Sprintf("%s%s %s %v learned %d", strings.Repeat("  ", depth), hop.PeerId, hop.Address, hopDuration, hop.LearnedCount)
+			if hop.Error != "" {
+				line += " error: " + hop.Error
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+			if err := printHops(children[hop.PeerId], depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	_, err = fmt.Fprintf(w, "lookup took %v, asked %d peers\n", duration, len(trace.Hops))
+	if err != nil {
+		return err
+	}
+	if err := printHops(roots, 1); err != nil {
+		return err
+	}
+	if trace.DroppedHops > 0 {
+		if _, err := fmt.Fprintf(w, "%d more peers not recorded\n", trace.DroppedHops); err != nil {
+			return err
+		}
+	}
+
+	if trace.Error != "" {
+		_, err = fmt.Fprintln(w, "lookup failed:", trace.Error)
+		return err
+	}
+	_, err = fmt.Fprintln(w, "found:", trace.Node.Id, trace.Node.GetAddress().GetAddress())
+	return err
+}
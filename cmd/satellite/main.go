@@ -18,6 +18,7 @@ import (
 	"storj.io/storj/internal/fpath"
 	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/cfgstruct"
+	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/process"
 	"storj.io/storj/satellite"
 	"storj.io/storj/satellite/satellitedb"
@@ -84,6 +85,7 @@ var (
 	}
 	confDir     string
 	identityDir string
+	defaults    cfgstruct.BindOpt
 )
 
 func init() {
@@ -91,7 +93,7 @@ func init() {
 	defaultIdentityDir := fpath.ApplicationDir("storj", "identity", "satellite")
 	cfgstruct.SetupFlag(zap.L(), rootCmd, &confDir, "config-dir", defaultConfDir, "main directory for satellite configuration")
 	cfgstruct.SetupFlag(zap.L(), rootCmd, &identityDir, "identity-dir", defaultIdentityDir, "main directory for satellite identity credentials")
-	defaults := cfgstruct.DefaultsFlag(rootCmd)
+	defaults = cfgstruct.DefaultsFlag(rootCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(setupCmd)
 	rootCmd.AddCommand(diagCmd)
@@ -146,6 +148,30 @@ func cmdRun(cmd *cobra.Command, args []string) (err error) {
 		return errs.New("Error creating tables for master database on satellite: %+v", err)
 	}
 
+	if err := version.SaveLastRun(confDir); err != nil {
+		zap.S().Warn("Failed to record the version used with the configuration: ", err)
+	}
+
+	peer.Server.PrivateHTTP().Handle("/debug/config", cfgstruct.DescribeHandler(map[string]interface{}{
+		"identity": runCfg.Identity,
+		"server":   runCfg.Server,
+		"kademlia": runCfg.Kademlia,
+	}, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir)))
+	process.DebugHandle("/debug/kademlia/workers", peer.Kademlia.Service.Workers())
+	process.DebugHandle("/debug/loops", &peer.NextRuns)
+	process.DebugHandle("/debug/services", peer.Services)
+
+	peer.Kademlia.Service.SetConfigLoader(func() (kademlia.Config, error) {
+		var reloaded Satellite
+		err := process.ReloadConfig(cmd, &reloaded, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
+		return reloaded.Kademlia, err
+	})
+	process.OnReload(ctx, func() {
+		if err := peer.Kademlia.Service.ReloadConfig(ctx); err != nil {
+			log.Error("failed to reload configuration", zap.Error(err))
+		}
+	})
+
 	runError := peer.Run(ctx)
 	closeError := peer.Close()
 	return errs.Combine(runError, closeError)
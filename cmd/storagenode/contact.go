@@ -0,0 +1,92 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/identity"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/process"
+	"storj.io/storj/pkg/server"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+)
+
+var (
+	contactCmd = &cobra.Command{
+		Use:         "contact <node-url>",
+		Short:       "Check step by step whether a node can be contacted",
+		Args:        cobra.ExactArgs(1),
+		RunE:        cmdContact,
+		Annotations: map[string]string{"type": "helper"},
+	}
+
+	contactCfg struct {
+		Identity identity.Config
+		Server   server.Config
+		Timeout  time.Duration `help:"timeout for each stage of the check" default:"10s"`
+		JSON     bool          `help:"print the results as JSON" default:"false"`
+	}
+)
+
+func cmdContact(cmd *cobra.Command, args []string) (err error) {
+	ctx := process.Ctx(cmd)
+
+	target, err := storj.ParseNodeURL(args[0])
+	if err != nil {
+		return err
+	}
+
+	ident, err := contactCfg.Identity.Load()
+	if err != nil {
+		return err
+	}
+
+	options, err := tlsopts.NewOptions(ident, contactCfg.Server.Config)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errs.Combine(err, options.RevDB.Close()) }()
+
+	dialer := kademlia.NewDialer(zap.L().Named("dialer"), transport.NewClient(options))
+	defer func() { err = errs.Combine(err, dialer.Close()) }()
+
+	stages := kademlia.CheckContact(ctx, dialer, target, contactCfg.Timeout)
+
+	if contactCfg.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(stages); err != nil {
+			return err
+		}
+	} else {
+		for _, stage := range stages {
+			switch {
+			case stage.Skipped:
+				fmt.Printf("SKIP %-8s\n", stage.Name)
+			case stage.Passed:
+				fmt.Printf("PASS %-8s %10v %s\n", stage.Name, stage.Duration.Round(time.Microsecond), stage.Detail)
+			default:
+				fmt.Printf("FAIL %-8s %10v %s\n", stage.Name, stage.Duration.Round(time.Microsecond), stage.Error)
+				fmt.Printf("     likely cause: %s\n", stage.Cause)
+			}
+		}
+	}
+
+	for _, stage := range stages {
+		if !stage.Passed && !stage.Skipped {
+			return errs.New("unable to contact %s: %s failed", target, stage.Name)
+		}
+	}
+	return nil
+}
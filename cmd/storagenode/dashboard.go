@@ -87,6 +87,14 @@ func printDashboard(data *pb.DashboardResponse) error {
 	w := tabwriter.NewWriter(color.Output, 0, 0, 1, ' ', 0)
 	fmt.Fprintf(w, "ID\t%s\n", color.YellowString(data.NodeId.String()))
 
+	switch {
+	case data.GetVersion().GetUnknown():
+		fmt.Fprintf(w, "Update\t%s\n", color.YellowString("unknown, development build or version server unreachable"))
+	case data.GetVersion().GetOutdated():
+		fmt.Fprintf(w, "Update\t%s\n", color.RedString(fmt.Sprintf("%s available, running %s",
+			data.Version.Suggested, data.Version.Current)))
+	}
+
 	lastContacted, err := ptypes.Timestamp(data.LastPinged)
 	if err != nil {
 		lastContacted = time.Time{}
@@ -108,6 +116,25 @@ func printDashboard(data *pb.DashboardResponse) error {
 			time.Since(lastContacted).Truncate(time.Second))))
 	}
 
+	lastCheckin, err := ptypes.Timestamp(data.LastCheckin)
+	if err != nil || lastCheckin.IsZero() {
+		fmt.Fprintf(w, "Last Check-in\t%s\n", color.RedString("NEVER"))
+	} else {
+		fmt.Fprintf(w, "Last Check-in\t%s\n", color.YellowString(fmt.Sprintf("%s ago",
+			time.Since(lastCheckin).Truncate(time.Second))))
+	}
+
+	if data.GetClockSkew() != nil {
+		if skew, err := ptypes.Duration(data.GetClockSkew()); err == nil {
+			ahead := "ahead of"
+			if skew < 0 {
+				ahead, skew = "behind", -skew
+			}
+			fmt.Fprintf(w, "Clock\t%s\n", color.RedString(fmt.Sprintf("%s %s the satellites, check the time synchronization",
+				skew.Truncate(time.Second), ahead)))
+		}
+	}
+
 	uptime, err := ptypes.Duration(data.GetUptime())
 	if err != nil {
 		fmt.Fprintf(w, "Uptime\t%s\n", color.RedString(uptime.Truncate(time.Second).String()))
@@ -140,12 +167,39 @@ func printDashboard(data *pb.DashboardResponse) error {
 		color.Yellow("Loading...\n")
 	}
 
+	if traffic := data.GetBandwidth(); len(traffic) > 0 {
+		w = tabwriter.NewWriter(color.Output, 0, 0, 5, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(w, "\n\t%s\t%s\t\n", color.GreenString("Egress"), color.GreenString("Ingress"))
+		for _, label := range traffic {
+			fmt.Fprintf(w, "%s\t%s\t%s\t (since start)\n", strings.Title(label.GetLabel()),
+				color.WhiteString(memory.Size(label.GetEgress()).Base10String()),
+				color.WhiteString(memory.Size(label.GetIngress()).Base10String()))
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+	}
+
 	w = tabwriter.NewWriter(color.Output, 0, 0, 1, ' ', 0)
 	// TODO: Get addresses from server data
 	fmt.Fprintf(w, "\nBootstrap\t%s\n", color.WhiteString(data.GetBootstrapAddress()))
 	fmt.Fprintf(w, "Internal\t%s\n", color.WhiteString(dashboardCfg.Address))
 	fmt.Fprintf(w, "External\t%s\n", color.WhiteString(data.GetExternalAddress()))
+	if observed := data.GetObservedAddress(); observed != "" {
+		fmt.Fprintf(w, "\t%s\n", color.RedString(fmt.Sprintf("you advertise %s but connect from %s",
+			data.GetExternalAddress(), observed)))
+	}
+	for _, satelliteID := range data.AddressUnreachable {
+		fmt.Fprintf(w, "\t%s\n", color.RedString(fmt.Sprintf("satellite %s could not reach this address", satelliteID)))
+	}
 	fmt.Fprintf(w, "\nNeighborhood Size %+v\n", whiteInt(data.GetNodeConnections()))
+	if size := data.GetNetworkSize(); size != nil {
+		if size.Exact {
+			fmt.Fprintf(w, "Network Size %+v\n", whiteInt(size.Estimate))
+		} else {
+			fmt.Fprintf(w, "Network Size ~%+v (%d to %d)\n", whiteInt(size.Estimate), size.Low, size.High)
+		}
+	}
 	if err = w.Flush(); err != nil {
 		return err
 	}
@@ -19,9 +19,11 @@ import (
 	"storj.io/storj/internal/memory"
 	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/cfgstruct"
+	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/process"
 	"storj.io/storj/pkg/storj"
 	"storj.io/storj/storagenode"
+	"storj.io/storj/storagenode/selftest"
 	"storj.io/storj/storagenode/storagenodedb"
 )
 
@@ -77,6 +79,7 @@ var (
 	defaultDiagDir string
 	confDir        string
 	identityDir    string
+	defaults       cfgstruct.BindOpt
 	useColor       bool
 )
 
@@ -91,18 +94,24 @@ func init() {
 	defaultDiagDir = filepath.Join(defaultConfDir, "storage")
 	cfgstruct.SetupFlag(zap.L(), rootCmd, &confDir, "config-dir", defaultConfDir, "main directory for storagenode configuration")
 	cfgstruct.SetupFlag(zap.L(), rootCmd, &identityDir, "identity-dir", defaultIdentityDir, "main directory for storagenode identity credentials")
-	defaults := cfgstruct.DefaultsFlag(rootCmd)
+	defaults = cfgstruct.DefaultsFlag(rootCmd)
 	rootCmd.PersistentFlags().BoolVar(&useColor, "color", false, "use color in user interface")
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(setupCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(diagCmd)
 	rootCmd.AddCommand(dashboardCmd)
+	rootCmd.AddCommand(reachabilityCmd)
+	diagCmd.AddCommand(contactCmd)
+	diagCmd.AddCommand(selftestCmd)
 	cfgstruct.Bind(runCmd.Flags(), &runCfg, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
 	cfgstruct.BindSetup(setupCmd.Flags(), &setupCfg, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
 	cfgstruct.BindSetup(configCmd.Flags(), &setupCfg, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
 	cfgstruct.Bind(diagCmd.Flags(), &diagCfg, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
+	cfgstruct.Bind(contactCmd.Flags(), &contactCfg, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
+	cfgstruct.Bind(selftestCmd.Flags(), &selftestCfg, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
 	cfgstruct.Bind(dashboardCmd.Flags(), &dashboardCfg, defaults, cfgstruct.ConfDir(defaultDiagDir))
+	cfgstruct.Bind(reachabilityCmd.Flags(), &reachabilityCfg, defaults, cfgstruct.ConfDir(defaultDiagDir))
 }
 
 func databaseConfig(config storagenode.Config) storagenodedb.Config {
@@ -131,6 +140,18 @@ func cmdRun(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
+	if runCfg.SelfTest.AtStartup {
+		steps := selftest.Run(ctx, log.Named("selftest"), runCfg.SelfTest, selfTestSetup(runCfg.Config))
+		for _, step := range steps {
+			if !step.Passed && !step.Skipped {
+				log.Error("self-test failed", zap.String("step", step.Name), zap.String("error", step.Error), zap.String("advice", step.Advice))
+			}
+		}
+		if err := selftest.Err(steps); err != nil {
+			return err
+		}
+	}
+
 	db, err := storagenodedb.New(log.Named("db"), databaseConfig(runCfg.Config))
 	if err != nil {
 		return errs.New("Error starting master database on storagenode: %+v", err)
@@ -161,6 +182,30 @@ func cmdRun(cmd *cobra.Command, args []string) (err error) {
 		return errs.New("Error creating tables for master database on storagenode: %+v", err)
 	}
 
+	if err := version.SaveLastRun(confDir); err != nil {
+		zap.S().Warn("Failed to record the version used with the configuration: ", err)
+	}
+
+	peer.Server.PrivateHTTP().Handle("/debug/config", cfgstruct.DescribeHandler(map[string]interface{}{
+		"identity": runCfg.Identity,
+		"server":   runCfg.Server,
+		"kademlia": runCfg.Kademlia,
+	}, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir)))
+	process.DebugHandle("/debug/kademlia/workers", peer.Kademlia.Service.Workers())
+	process.DebugHandle("/debug/loops", &peer.NextRuns)
+	process.DebugHandle("/debug/services", peer.Services)
+
+	peer.Kademlia.Service.SetConfigLoader(func() (kademlia.Config, error) {
+		var reloaded StorageNodeFlags
+		err := process.ReloadConfig(cmd, &reloaded, defaults, cfgstruct.ConfDir(confDir), cfgstruct.IdentityDir(identityDir))
+		return reloaded.Kademlia, err
+	})
+	process.OnReload(ctx, func() {
+		if err := peer.Kademlia.Service.ReloadConfig(ctx); err != nil {
+			log.Error("failed to reload configuration", zap.Error(err))
+		}
+	})
+
 	runError := peer.Run(ctx)
 	closeError := peer.Close()
 
@@ -0,0 +1,62 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/spf13/cobra"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/process"
+)
+
+var (
+	reachabilityCmd = &cobra.Command{
+		Use:         "reachability",
+		Short:       "Print the daily reachability of each satellite",
+		RunE:        cmdReachability,
+		Annotations: map[string]string{"type": "helper"},
+	}
+
+	reachabilityCfg struct {
+		Address string `default:"127.0.0.1:7778" help:"address for dashboard service"`
+		Days    int    `default:"7" help:"number of most recent days to print, 0 for all the kept days"`
+	}
+)
+
+func cmdReachability(cmd *cobra.Command, args []string) (err error) {
+	ctx := process.Ctx(cmd)
+
+	client, err := newDashboardClient(ctx, reachabilityCfg.Address)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.client.Reachability(ctx, &pb.ReachabilityRequest{Days: int32(reachabilityCfg.Days)})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tSATELLITE\tATTEMPTS\tSUCCESSES\tDOWNTIME WINDOWS\tDOWNTIME\tAVAILABILITY")
+	for _, rollup := range resp.Rollups {
+		day, err := ptypes.Timestamp(rollup.Day)
+		if err != nil {
+			return err
+		}
+		downtime, err := ptypes.Duration(rollup.Downtime)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%v\t%.2f%%\n",
+			day.Format("2006-01-02"), rollup.SatelliteId, rollup.Attempts, rollup.Successes,
+			rollup.DowntimeWindows, downtime.Round(time.Second), rollup.Availability)
+	}
+	return w.Flush()
+}
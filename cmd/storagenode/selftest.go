@@ -0,0 +1,72 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/process"
+	"storj.io/storj/storagenode"
+	"storj.io/storj/storagenode/selftest"
+)
+
+var (
+	selftestCmd = &cobra.Command{
+		Use:         "selftest",
+		Short:       "Check the identity, TLS configuration and version check before starting the node",
+		RunE:        cmdSelfTest,
+		Annotations: map[string]string{"type": "helper"},
+	}
+
+	selftestCfg struct {
+		storagenode.Config
+		JSON bool `help:"print the results as JSON" default:"false"`
+	}
+)
+
+// selfTestSetup returns the setup of the storage node configured by config.
+func selfTestSetup(config storagenode.Config) selftest.Setup {
+	return selftest.Setup{
+		Identity:    config.Identity,
+		TLS:         config.Server.Config,
+		Kademlia:    config.Kademlia,
+		Version:     config.Version,
+		VersionInfo: version.Build,
+	}
+}
+
+func cmdSelfTest(cmd *cobra.Command, args []string) (err error) {
+	ctx := process.Ctx(cmd)
+
+	steps := selftest.Run(ctx, zap.L().Named("selftest"), selftestCfg.SelfTest, selfTestSetup(selftestCfg.Config))
+
+	if selftestCfg.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(steps); err != nil {
+			return err
+		}
+	} else {
+		for _, step := range steps {
+			switch {
+			case step.Skipped:
+				fmt.Printf("SKIP %-9s\n", step.Name)
+			case step.Passed:
+				fmt.Printf("PASS %-9s %10v %s\n", step.Name, step.Duration.Round(time.Microsecond), step.Detail)
+			default:
+				fmt.Printf("FAIL %-9s %10v %s\n", step.Name, step.Duration.Round(time.Microsecond), step.Error)
+				fmt.Printf("     to fix: %s\n", step.Advice)
+			}
+		}
+	}
+
+	return selftest.Err(steps)
+}
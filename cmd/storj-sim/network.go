@@ -219,6 +219,7 @@ func newNetwork(flags *Flags) (*Processes, error) {
 			"--kademlia.bootstrap-addr", bootstrap.Address,
 			"--kademlia.operator.email", "bootstrap@example.com",
 			"--kademlia.operator.wallet", "0x0123456789012345678901234567890123456789",
+			"--kademlia.dialer.allow-private-addresses=true",
 
 			"--server.extensions.revocation=false",
 			"--server.use-peer-ca-whitelist=false",
@@ -271,6 +272,7 @@ func newNetwork(flags *Flags) (*Processes, error) {
 				"--server.private-address", net.JoinHostPort(host, port(satellitePeer, i, privateGRPC)),
 
 				"--kademlia.bootstrap-addr", bootstrap.Address,
+				"--kademlia.dialer.allow-private-addresses=true",
 
 				"--server.extensions.revocation=false",
 				"--server.use-peer-ca-whitelist=false",
@@ -414,6 +416,7 @@ func newNetwork(flags *Flags) (*Processes, error) {
 				"--kademlia.bootstrap-addr", bootstrap.Address,
 				"--kademlia.operator.email", fmt.Sprintf("storage%d@example.com", i),
 				"--kademlia.operator.wallet", "0x0123456789012345678901234567890123456789",
+				"--kademlia.dialer.allow-private-addresses=true",
 
 				"--server.extensions.revocation=false",
 				"--server.use-peer-ca-whitelist=false",
@@ -0,0 +1,209 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// testplanet-run starts an in-process network of bootstrap, satellite and
+// storage nodes for manual testing, without writing a Go test.
+//
+// The network runs until interrupted:
+//
+//	go run ./cmd/testplanet-run -storagenodes 8
+//
+// Each node is printed with its node URL, which can be given to tools such as
+// `storagenode diag contact`, and its private address for inspectors.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"storj.io/storj/bootstrap"
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/server"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/satellite"
+	"storj.io/storj/storagenode"
+)
+
+// networks are the simulated network profiles applied to incoming connections of all nodes
+var networks = map[string]transport.SimulatedNetwork{
+	"local": {},
+	"lan": {
+		Latency:          time.Millisecond,
+		AcceptLatency:    time.Millisecond,
+		HandshakeLatency: 2 * time.Millisecond,
+	},
+	"wan": {
+		Latency:          40 * time.Millisecond,
+		BytesPerSecond:   10 * memory.MB,
+		AcceptLatency:    10 * time.Millisecond,
+		HandshakeLatency: 80 * time.Millisecond,
+	},
+	"slow": {
+		Latency:          200 * time.Millisecond,
+		BytesPerSecond:   memory.MB,
+		AcceptLatency:    100 * time.Millisecond,
+		HandshakeLatency: 500 * time.Millisecond,
+	},
+}
+
+// Flags contains the configuration of the network
+type Flags struct {
+	Satellites   int
+	StorageNodes int
+	Uplinks      int
+	Host         string
+	Seed         int64
+	Network      string
+	Whitelist    bool
+	LogLevel     string
+
+	// kademlia and contact settings, zero keeps the testplanet defaults
+	Alpha          int
+	BucketSize     int
+	ContactTimeout time.Duration
+}
+
+func main() {
+	var flags Flags
+	flag.IntVar(&flags.Satellites, "satellites", 1, "number of satellites to start")
+	flag.IntVar(&flags.StorageNodes, "storagenodes", 4, "number of storage nodes to start")
+	flag.IntVar(&flags.Uplinks, "uplinks", 0, "number of uplinks to create")
+	flag.StringVar(&flags.Host, "host", "127.0.0.1", "IP address all nodes listen on")
+	flag.Int64Var(&flags.Seed, "seed", 0, "seed for choosing the node identities, the same seed gives the same node IDs")
+	flag.StringVar(&flags.Network, "network", "local", "simulated network profile for incoming connections: "+strings.Join(networkNames(), ", "))
+	flag.BoolVar(&flags.Whitelist, "whitelist", false, "only accept peers signed by the planet CA, which rejects identities of external tools")
+	flag.StringVar(&flags.LogLevel, "log-level", "warn", "level of the node logs")
+	flag.IntVar(&flags.Alpha, "kademlia.alpha", 0, "kademlia alpha of all nodes")
+	flag.IntVar(&flags.BucketSize, "kademlia.bucket-size", 0, "kademlia k, the size of each bucket, of all nodes")
+	flag.DurationVar(&flags.ContactTimeout, "contact.timeout", 0, "timeout for a single check-in of storage nodes with satellites")
+	flag.Parse()
+
+	if err := run(flags); err != nil {
+		fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(flags Flags) (err error) {
+	network, ok := networks[flags.Network]
+	if !ok {
+		return errs.New("unknown network profile %q, expected one of %s", flags.Network, strings.Join(networkNames(), ", "))
+	}
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(flags.LogLevel)); err != nil {
+		return err
+	}
+	logConfig := zap.NewDevelopmentConfig()
+	logConfig.Level = zap.NewAtomicLevelAt(level)
+	log, err := logConfig.Build()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = log.Sync() }()
+
+	version := storj.LatestIDVersion()
+	planet, err := testplanet.NewCustom(log, testplanet.Config{
+		SatelliteCount:   flags.Satellites,
+		StorageNodeCount: flags.StorageNodes,
+		UplinkCount:      flags.Uplinks,
+		Identities:       testidentity.NewPregeneratedSignedIdentities(version).Shuffled(flags.Seed),
+		IdentityVersion:  &version,
+		Reconfigure:      flags.reconfigure(&network),
+		Host:             flags.Host,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { err = errs.Combine(err, planet.Shutdown()) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	fmt.Println("starting network...")
+	planet.Start(ctx)
+	printNodes(planet)
+
+	sig := <-signals
+	fmt.Printf("got %v, shutting down\n", sig)
+	return nil
+}
+
+// reconfigure returns the testplanet configuration changes for the flags.
+func (flags *Flags) reconfigure(network *transport.SimulatedNetwork) testplanet.Reconfigure {
+	configure := func(serverConfig *server.Config, kademliaConfig *kademlia.Config) {
+		if !flags.Whitelist {
+			serverConfig.UsePeerCAWhitelist = false
+		}
+		if *network != (transport.SimulatedNetwork{}) {
+			serverConfig.WrapListener = network.NewListener
+		}
+		if flags.Alpha > 0 {
+			kademliaConfig.Alpha = flags.Alpha
+		}
+		if flags.BucketSize > 0 {
+			kademliaConfig.BucketSize = flags.BucketSize
+		}
+	}
+
+	return testplanet.Reconfigure{
+		Bootstrap: func(index int, config *bootstrap.Config) {
+			configure(&config.Server, &config.Kademlia)
+		},
+		Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+			configure(&config.Server, &config.Kademlia)
+		},
+		StorageNode: func(index int, config *storagenode.Config) {
+			configure(&config.Server, &config.Kademlia)
+			if flags.ContactTimeout > 0 {
+				config.Contact.Timeout = flags.ContactTimeout
+			}
+		},
+	}
+}
+
+// printNodes prints the node URL and private address of every node.
+func printNodes(planet *testplanet.Planet) {
+	printNode := func(name string, id storj.NodeID, addr, privateAddr string) {
+		url := storj.NodeURL{ID: id, Address: addr}
+		fmt.Printf("%-14s %s\n%-14s private %s\n", name, url.String(), "", privateAddr)
+	}
+
+	printNode("bootstrap", planet.Bootstrap.ID(), planet.Bootstrap.Addr(), planet.Bootstrap.PrivateAddr())
+	for i, peer := range planet.Satellites {
+		printNode(fmt.Sprintf("satellite/%d", i), peer.ID(), peer.Addr(), peer.PrivateAddr())
+	}
+	for i, peer := range planet.StorageNodes {
+		printNode(fmt.Sprintf("storagenode/%d", i), peer.ID(), peer.Addr(), peer.PrivateAddr())
+	}
+	fmt.Println("network is running, press Ctrl+C to stop")
+}
+
+// networkNames returns the names of the network profiles in order.
+func networkNames() []string {
+	var names []string
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
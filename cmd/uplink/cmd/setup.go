@@ -220,32 +220,25 @@ func ApplyDefaultHostAndPortToAddr(address, defaultAddress string) (string, erro
 		return "", Error.Wrap(err)
 	}
 
-	addressParts := strings.Split(address, ":")
-	numberOfParts := len(addressParts)
-
-	if numberOfParts > 1 && len(addressParts[0]) > 0 && len(addressParts[1]) > 0 {
-		// address is host:port so skip applying any defaults.
-		return address, nil
+	if address == "" {
+		return defaultAddress, nil
 	}
 
-	// We are missing a host:port part. Figure out which part we are missing.
-	indexOfPortSeparator := strings.Index(address, ":")
-	lengthOfFirstPart := len(addressParts[0])
-
-	if indexOfPortSeparator < 0 {
-		if lengthOfFirstPart == 0 {
-			// address is blank.
-			return defaultAddress, nil
-		}
-		// address is host
-		return net.JoinHostPort(addressParts[0], defaultPort), nil
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// address is host without a port, such as "host", "::1" or "[::1]"
+		host, port = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]"), ""
 	}
 
-	if indexOfPortSeparator == 0 {
+	if host == "" {
 		// address is :1234
-		return net.JoinHostPort(defaultHost, addressParts[1]), nil
+		host = defaultHost
+	}
+	if port == "" {
+		// address is host or host:
+		port = defaultPort
 	}
 
-	// address is host:
-	return net.JoinHostPort(addressParts[0], defaultPort), nil
+	// JoinHostPort adds the brackets required by IPv6 hosts
+	return net.JoinHostPort(host, port), nil
 }
@@ -115,3 +115,21 @@ func TestDefaultPortAppliedToSatelliteAddrWithPortColonButNoPort(t *testing.T) {
 	assert.Equal(t, "ahost:7777", setupCmd.Flags().Lookup("satellite-addr").Value.String(),
 		"satellite-addr should contain default port when no port specified")
 }
+
+func TestDefaultPortAppliedToIPv6SatelliteAddr(t *testing.T) {
+	for input, expected := range map[string]string{
+		"::1":         "[::1]:7777",
+		"[::1]":       "[::1]:7777",
+		"[::1]:":      "[::1]:7777",
+		"[::1]:7778":  "[::1]:7778",
+		"[fd00::2]:0": "[fd00::2]:0",
+	} {
+		address, err := cmd.ApplyDefaultHostAndPortToAddr(input, "localhost:7777")
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, address, input)
+	}
+
+	address, err := cmd.ApplyDefaultHostAndPortToAddr(":7778", "[::1]:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, "[::1]:7778", address, "default IPv6 host should keep its brackets")
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package errs2
+
+import (
+	"context"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/zeebo/errs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DialOutcome is the classified result of dialing a node.
+type DialOutcome int
+
+const (
+	// DialSuccess is a successful dial
+	DialSuccess DialOutcome = iota
+	// DialCanceled is a dial canceled by the dialing side
+	DialCanceled
+	// DialTimeout is a dial that didn't complete in time
+	DialTimeout
+	// DialRefused is a dial that the remote host actively refused
+	DialRefused
+	// DialFailure is any other failed dial
+	DialFailure
+)
+
+// String returns the short name of the outcome, which is empty for a success.
+func (outcome DialOutcome) String() string {
+	switch outcome {
+	case DialSuccess:
+		return ""
+	case DialCanceled:
+		return "canceled"
+	case DialTimeout:
+		return "timeout"
+	case DialRefused:
+		return "refused"
+	default:
+		return "error"
+	}
+}
+
+// ClassifyDial returns the outcome of a dial that returned err.
+//
+// Only the types of the errors in the chain are inspected: wrapped errors,
+// grpc status codes, grpc connection errors, which keep the error of the
+// dial, and network errors.
+func ClassifyDial(err error) DialOutcome {
+	if err == nil {
+		return DialSuccess
+	}
+
+	for err != nil {
+		switch err {
+		case context.Canceled:
+			return DialCanceled
+		case context.DeadlineExceeded:
+			return DialTimeout
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return DialTimeout
+		}
+		if grpcErr, ok := status.FromError(err); ok {
+			switch grpcErr.Code() {
+			case codes.Canceled:
+				return DialCanceled
+			case codes.DeadlineExceeded:
+				return DialTimeout
+			}
+		}
+
+		switch e := err.(type) {
+		case interface{ Origin() error }:
+			err = e.Origin()
+		case *net.OpError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		case syscall.Errno:
+			if e == syscall.ECONNREFUSED {
+				return DialRefused
+			}
+			return DialFailure
+		default:
+			unwrapped := errs.Unwrap(err)
+			if unwrapped == err {
+				return DialFailure
+			}
+			err = unwrapped
+		}
+	}
+	return DialFailure
+}
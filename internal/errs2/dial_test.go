@@ -0,0 +1,57 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package errs2_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeebo/errs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/errs2"
+)
+
+// connectionError keeps the error of the dial, like the connection errors of grpc.
+type connectionError struct{ err error }
+
+func (e connectionError) Error() string { return "connection error: " + e.err.Error() }
+func (e connectionError) Origin() error { return e.err }
+
+func TestClassifyDial(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	class := errs.Class("wrapped")
+	wrap := class.Wrap
+
+	for _, tt := range []struct {
+		err      error
+		expected errs2.DialOutcome
+		class    string
+	}{
+		{nil, errs2.DialSuccess, ""},
+		{context.Canceled, errs2.DialCanceled, "canceled"},
+		{wrap(context.Canceled), errs2.DialCanceled, "canceled"},
+		{status.Error(codes.Canceled, "context canceled"), errs2.DialCanceled, "canceled"},
+		{context.DeadlineExceeded, errs2.DialTimeout, "timeout"},
+		{wrap(context.DeadlineExceeded), errs2.DialTimeout, "timeout"},
+		{status.Error(codes.DeadlineExceeded, "context deadline exceeded"), errs2.DialTimeout, "timeout"},
+		{refused, errs2.DialRefused, "refused"},
+		{wrap(refused), errs2.DialRefused, "refused"},
+		{wrap(connectionError{refused}), errs2.DialRefused, "refused"},
+		{os.NewSyscallError("connect", syscall.ECONNRESET), errs2.DialFailure, "error"},
+		// messages are not matched
+		{errors.New("dial tcp 127.0.0.1:1: connect: connection refused"), errs2.DialFailure, "error"},
+		{errors.New("tls: bad certificate"), errs2.DialFailure, "error"},
+	} {
+		outcome := errs2.ClassifyDial(tt.err)
+		assert.Equal(t, tt.expected, outcome, "%v", tt.err)
+		assert.Equal(t, tt.class, outcome.String(), "%v", tt.err)
+	}
+}
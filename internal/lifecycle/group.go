@@ -0,0 +1,245 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package lifecycle runs the background services of a peer.
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/sync2"
+	"storj.io/storj/pkg/backoff"
+)
+
+// Transient is the class of errors after which a service is run again,
+// every other error stops the peer.
+var Transient = errs.Class("transient")
+
+// Service is a background component of a peer. Run runs it until ctx is
+// canceled, Close releases its resources and is called once, whether Run
+// was called or not.
+//
+// A service returning a Transient error from Run is run again, hence Run
+// must be able to run several times. Failures a service can recover from on
+// its own, such as a failed iteration of a loop, are better logged and
+// retried internally.
+type Service interface {
+	Run(ctx context.Context) error
+	Close() error
+}
+
+// Func is a Service without resources to close.
+type Func func(ctx context.Context) error
+
+// Run runs fn.
+func (fn Func) Run(ctx context.Context) error { return fn(ctx) }
+
+// Close does nothing.
+func (fn Func) Close() error { return nil }
+
+// State is the state of a service of a group.
+type State string
+
+const (
+	// Created means the service hasn't been started.
+	Created = State("created")
+	// Running means Run of the service is running.
+	Running = State("running")
+	// Restarting means the service failed transiently and is run again after a delay.
+	Restarting = State("restarting")
+	// Stopped means Run of the service returned without an error, or was canceled.
+	Stopped = State("stopped")
+	// Failed means the service failed and stopped the peer.
+	Failed = State("failed")
+	// Closed means the service has been closed.
+	Closed = State("closed")
+)
+
+// stableRun is how long a service runs before its earlier transient
+// failures are forgotten, and it's restarted without delay again
+const stableRun = time.Minute
+
+// Status is the status of a service of a group.
+type Status struct {
+	Name      string    `json:"name"`
+	State     State     `json:"state"`
+	Since     time.Time `json:"since"`
+	LastError string    `json:"last_error,omitempty"`
+	Restarts  int       `json:"restarts"`
+}
+
+// Group runs the services of a peer in its errgroup and closes them in
+// reverse order.
+type Group struct {
+	log *zap.Logger
+	// Restart decides the delay before running a service again after
+	// consecutive transient failures, a service it gives up on fails.
+	Restart backoff.Strategy
+
+	mu       sync.Mutex
+	services []*entry
+	closed   bool
+}
+
+type entry struct {
+	name    string
+	service Service
+	status  Status
+}
+
+// NewGroup returns a group of services logging to log.
+func NewGroup(log *zap.Logger) *Group {
+	return &Group{
+		log:     log,
+		Restart: &backoff.Exponential{Base: time.Second, Max: time.Minute, Jitter: 0.5},
+	}
+}
+
+// Add adds the service called name to the group, it must be called before
+// Start.
+func (group *Group) Add(name string, service Service) {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	group.services = append(group.services, &entry{
+		name:    name,
+		service: service,
+		status:  Status{Name: name, State: Created, Since: time.Now()},
+	})
+}
+
+// Start runs the services in runs until ctx is canceled. The first service
+// failing with an error that isn't Transient returns it to runs.
+func (group *Group) Start(ctx context.Context, runs *errgroup.Group) {
+	group.mu.Lock()
+	services := append([]*entry(nil), group.services...)
+	group.mu.Unlock()
+
+	for _, service := range services {
+		service := service
+		runs.Go(func() error {
+			return group.run(ctx, service)
+		})
+	}
+}
+
+// run runs service until it stops or fails with an error that isn't Transient.
+func (group *Group) run(ctx context.Context, service *entry) error {
+	var failures int
+	var firstFailure time.Time
+	for {
+		group.update(service, Running, nil)
+		started := time.Now()
+		err := errs2.IgnoreCanceled(service.service.Run(ctx))
+		if err == nil {
+			group.update(service, Stopped, nil)
+			return nil
+		}
+
+		if !Transient.Has(err) {
+			group.log.Error("service failed", zap.String("service", service.name), zap.Error(err))
+			group.update(service, Failed, err)
+			return err
+		}
+		if ctx.Err() != nil {
+			// the service failed because it's stopping, it's not restarted
+			group.update(service, Stopped, nil)
+			return nil
+		}
+
+		if time.Since(started) >= stableRun {
+			failures = 0
+		}
+		if failures == 0 {
+			firstFailure = time.Now()
+		}
+		failures++
+		delay, ok := group.Restart.Delay(failures, time.Since(firstFailure))
+		if !ok {
+			group.log.Error("service failed too often", zap.String("service", service.name), zap.Error(err))
+			group.update(service, Failed, err)
+			return err
+		}
+
+		group.log.Warn("service failed, restarting", zap.String("service", service.name), zap.Duration("delay", delay), zap.Error(err))
+		group.update(service, Restarting, err)
+		if !sync2.Sleep(ctx, delay) {
+			group.update(service, Stopped, nil)
+			return nil
+		}
+	}
+}
+
+// update sets the state of service, err is its last error when not nil.
+func (group *Group) update(service *entry, state State, err error) {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	if state == Restarting {
+		service.status.Restarts++
+	}
+	if err != nil {
+		service.status.LastError = err.Error()
+	}
+	service.status.State = state
+	service.status.Since = time.Now()
+}
+
+// Close closes the services in reverse order, the order of their
+// dependencies. It's called once, after Start returned or without Start.
+func (group *Group) Close() error {
+	group.mu.Lock()
+	if group.closed {
+		group.mu.Unlock()
+		return nil
+	}
+	group.closed = true
+	services := append([]*entry(nil), group.services...)
+	group.mu.Unlock()
+
+	var errlist errs.Group
+	for i := len(services) - 1; i >= 0; i-- {
+		errlist.Add(services[i].service.Close())
+		group.update(services[i], Closed, nil)
+	}
+	return errlist.Err()
+}
+
+// Statuses returns the status of each service, in the order they were added.
+func (group *Group) Statuses() []Status {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	statuses := make([]Status, 0, len(group.services))
+	for _, service := range group.services {
+		statuses = append(statuses, service.status)
+	}
+	return statuses
+}
+
+// Status returns the status of the service called name, false when there's
+// no such service.
+func (group *Group) Status(name string) (Status, bool) {
+	for _, status := range group.Statuses() {
+		if status.Name == name {
+			return status, true
+		}
+	}
+	return Status{}, false
+}
+
+// ServeHTTP writes the status of the services as JSON.
+func (group *Group) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(group.Statuses()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package lifecycle_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
+
+	"storj.io/storj/internal/lifecycle"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/backoff"
+)
+
+// closer is a service recording the order it was closed in.
+type closer struct {
+	lifecycle.Func
+	name   string
+	closed *[]string
+}
+
+func (service closer) Close() error {
+	*service.closed = append(*service.closed, service.name)
+	return nil
+}
+
+func TestGroup(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	group := lifecycle.NewGroup(zaptest.NewLogger(t))
+	group.Restart = backoff.Constant{Interval: time.Millisecond}
+
+	fatal := errors.New("fatal")
+	transient := make(chan struct{})
+	runs := 0
+	group.Add("flaky", lifecycle.Func(func(ctx context.Context) error {
+		runs++
+		if runs <= 3 {
+			return lifecycle.Transient.New("run %d", runs)
+		}
+		close(transient)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	group.Add("waiting", lifecycle.Func(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	group.Add("fatal", lifecycle.Func(func(ctx context.Context) error {
+		select {
+		case <-transient:
+			return fatal
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}))
+
+	running, runningCtx := errgroup.WithContext(ctx)
+	group.Start(runningCtx, running)
+	require.Equal(t, fatal, running.Wait())
+
+	flaky, ok := group.Status("flaky")
+	require.True(t, ok)
+	assert.Equal(t, lifecycle.Stopped, flaky.State)
+	assert.Equal(t, 3, flaky.Restarts)
+	assert.Equal(t, "transient: run 3", flaky.LastError)
+
+	waiting, ok := group.Status("waiting")
+	require.True(t, ok)
+	assert.Equal(t, lifecycle.Stopped, waiting.State)
+	assert.Equal(t, 0, waiting.Restarts)
+	assert.Empty(t, waiting.LastError)
+
+	failed, ok := group.Status("fatal")
+	require.True(t, ok)
+	assert.Equal(t, lifecycle.Failed, failed.State)
+	assert.Equal(t, "fatal", failed.LastError)
+
+	recorder := httptest.NewRecorder()
+	group.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/services", nil))
+	var statuses []lifecycle.Status
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &statuses))
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "flaky", statuses[0].Name)
+	assert.Equal(t, 3, statuses[0].Restarts)
+	assert.Equal(t, lifecycle.Failed, statuses[2].State)
+}
+
+// attempts is a restart strategy giving up after a number of restarts.
+type attempts int
+
+func (max attempts) Delay(failures int, elapsed time.Duration) (time.Duration, bool) {
+	return time.Millisecond, failures <= int(max)
+}
+
+func TestGroupGivesUp(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	group := lifecycle.NewGroup(zaptest.NewLogger(t))
+	group.Restart = attempts(2)
+
+	group.Add("failing", lifecycle.Func(func(ctx context.Context) error {
+		return lifecycle.Transient.New("failing")
+	}))
+
+	var running errgroup.Group
+	group.Start(ctx, &running)
+	assert.True(t, lifecycle.Transient.Has(running.Wait()))
+
+	status, ok := group.Status("failing")
+	require.True(t, ok)
+	assert.Equal(t, lifecycle.Failed, status.State)
+	assert.Equal(t, 2, status.Restarts)
+}
+
+func TestGroupStopping(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	group := lifecycle.NewGroup(zaptest.NewLogger(t))
+	group.Add("stopping", lifecycle.Func(func(ctx context.Context) error {
+		<-ctx.Done()
+		return lifecycle.Transient.New("connection closed")
+	}))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	var running errgroup.Group
+	group.Start(runCtx, &running)
+	cancel()
+	require.NoError(t, running.Wait())
+
+	status, ok := group.Status("stopping")
+	require.True(t, ok)
+	assert.Equal(t, lifecycle.Stopped, status.State)
+	assert.Equal(t, 0, status.Restarts)
+}
+
+func TestGroupClose(t *testing.T) {
+	var closed []string
+	group := lifecycle.NewGroup(zaptest.NewLogger(t))
+	for _, name := range []string{"first", "second", "third"} {
+		group.Add(name, closer{name: name, closed: &closed})
+	}
+
+	require.NoError(t, group.Close())
+	require.NoError(t, group.Close())
+	assert.Equal(t, []string{"third", "second", "first"}, closed)
+	for _, status := range group.Statuses() {
+		assert.Equal(t, lifecycle.Closed, status.State)
+	}
+}
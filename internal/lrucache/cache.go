@@ -0,0 +1,153 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package lrucache implements a map with a maximum number of entries.
+package lrucache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a concurrency safe map that evicts the least recently used entry
+// when adding an entry would exceed its capacity.
+type Cache struct {
+	capacity int
+
+	mu        sync.Mutex
+	order     *list.List // most recently used first
+	entries   map[interface{}]*list.Element
+	evictions int64
+}
+
+type entry struct {
+	key   interface{}
+	value interface{}
+}
+
+// New creates a cache holding at most capacity entries, capacity must be positive.
+func New(capacity int) *Cache {
+	if capacity <= 0 {
+		panic("lrucache: capacity must be positive")
+	}
+	return &Cache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[interface{}]*list.Element),
+	}
+}
+
+// Get returns the value of key and marks it as recently used.
+func (cache *Cache) Get(key interface{}) (value interface{}, ok bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return element.Value.(*entry).value, true
+}
+
+// Peek returns the value of key without marking it as recently used.
+func (cache *Cache) Peek(key interface{}) (value interface{}, ok bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return element.Value.(*entry).value, true
+}
+
+// Add sets the value of key, evicting the least recently used entry when the cache is full.
+func (cache *Cache) Add(key, value interface{}) {
+	cache.Update(key, func(interface{}, bool) interface{} { return value })
+}
+
+// Update sets the value of key to the result of update, which is called with
+// the current value while the cache is locked.
+func (cache *Cache) Update(key interface{}, update func(value interface{}, ok bool) interface{}) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.entries[key]; ok {
+		current := element.Value.(*entry)
+		current.value = update(current.value, true)
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	for cache.order.Len() >= cache.capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*entry).key)
+		cache.evictions++
+	}
+	cache.entries[key] = cache.order.PushFront(&entry{key: key, value: update(nil, false)})
+}
+
+// Remove deletes key from the cache.
+func (cache *Cache) Remove(key interface{}) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.entries[key]; ok {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+	}
+}
+
+// RemoveOldest deletes the least recently used entry, it returns false when the cache is empty.
+func (cache *Cache) RemoveOldest() bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	oldest := cache.order.Back()
+	if oldest == nil {
+		return false
+	}
+	cache.order.Remove(oldest)
+	delete(cache.entries, oldest.Value.(*entry).key)
+	return true
+}
+
+// Len returns the number of entries.
+func (cache *Cache) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.order.Len()
+}
+
+// Evictions returns the number of entries evicted to make room for others.
+func (cache *Cache) Evictions() int64 {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.evictions
+}
+
+// Each calls fn for every entry, from the most to the least recently used,
+// the cache must not be used by fn.
+func (cache *Cache) Each(fn func(key, value interface{})) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for element := cache.order.Front(); element != nil; element = element.Next() {
+		current := element.Value.(*entry)
+		fn(current.key, current.value)
+	}
+}
+
+// Stats implements monkit.StatSource, reporting the number of entries, the
+// capacity and the evictions of the cache.
+func (cache *Cache) Stats(cb func(name string, val float64)) {
+	cache.mu.Lock()
+	entries, evictions := cache.order.Len(), cache.evictions
+	cache.mu.Unlock()
+
+	cb("entries", float64(entries))
+	cb("capacity", float64(cache.capacity))
+	cb("evictions", float64(evictions))
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package lrucache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"storj.io/storj/internal/lrucache"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := lrucache.New(2)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+
+	// using a makes b the least recently used
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Add("c", 3)
+
+	_, ok = cache.Peek("b")
+	assert.False(t, ok)
+	value, ok := cache.Peek("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, int64(1), cache.Evictions())
+
+	var keys []interface{}
+	cache.Each(func(key, value interface{}) { keys = append(keys, key) })
+	assert.Equal(t, []interface{}{"c", "a"}, keys)
+}
+
+func TestCacheUpdate(t *testing.T) {
+	cache := lrucache.New(2)
+	increment := func(value interface{}, ok bool) interface{} {
+		if !ok {
+			return 1
+		}
+		return value.(int) + 1
+	}
+	cache.Update("a", increment)
+	cache.Update("a", increment)
+	value, _ := cache.Get("a")
+	assert.Equal(t, 2, value)
+
+	cache.Add("b", 1)
+	cache.Remove("a")
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+
+	assert.True(t, cache.RemoveOldest())
+	assert.False(t, cache.RemoveOldest())
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, int64(0), cache.Evictions())
+}
+
+func TestCacheStats(t *testing.T) {
+	cache := lrucache.New(2)
+	for _, key := range []string{"a", "b", "c"} {
+		cache.Add(key, 0)
+	}
+
+	stats := map[string]float64{}
+	cache.Stats(func(name string, val float64) { stats[name] = val })
+	assert.Equal(t, map[string]float64{"entries": 2, "capacity": 2, "evictions": 1}, stats)
+}
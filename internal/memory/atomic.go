@@ -0,0 +1,21 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package memory
+
+import "sync/atomic"
+
+// AtomicSize is a Size that can be updated concurrently
+type AtomicSize struct {
+	size int64
+}
+
+// Add adds delta to size and returns the new size
+func (size *AtomicSize) Add(delta Size) Size {
+	return Size(atomic.AddInt64(&size.size, int64(delta)))
+}
+
+// Load returns the size
+func (size *AtomicSize) Load() Size {
+	return Size(atomic.LoadInt64(&size.size))
+}
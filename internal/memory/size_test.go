@@ -4,6 +4,7 @@
 package memory_test
 
 import (
+	"sync"
 	"testing"
 
 	"storj.io/storj/internal/memory"
@@ -156,3 +157,25 @@ func TestInvalidParse(t *testing.T) {
 		}
 	}
 }
+
+func TestAtomicSize(t *testing.T) {
+	var size memory.AtomicSize
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				size.Add(memory.KiB)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := size.Load(); got != 1000*memory.KiB {
+		t.Errorf("invalid size got %d expected %d", got, 1000*memory.KiB)
+	}
+	if got := size.Add(-memory.KiB); got != 999*memory.KiB {
+		t.Errorf("invalid size got %d expected %d", got, 999*memory.KiB)
+	}
+}
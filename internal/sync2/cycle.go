@@ -16,11 +16,16 @@ import (
 // Cycle control methods don't have any effect after the cycle has completed.
 type Cycle struct {
 	interval time.Duration
+	splay    *Splay
 
 	ticker  *time.Ticker
+	timer   *time.Timer // used instead of the ticker with a splay
 	control chan interface{}
 	stop    chan struct{}
 
+	nextMu sync.Mutex
+	next   time.Time
+
 	init sync.Once
 }
 
@@ -45,6 +50,79 @@ func (cycle *Cycle) SetInterval(interval time.Duration) {
 	cycle.interval = interval
 }
 
+// SetSplay spreads the runs with splay, it must be called before starting.
+// The first run is delayed by the splay instead of starting right away.
+func (cycle *Cycle) SetSplay(splay *Splay) {
+	cycle.splay = splay
+}
+
+// NextRun returns when the function runs next, zero when the cycle isn't
+// running or is paused.
+func (cycle *Cycle) NextRun() time.Time {
+	cycle.nextMu.Lock()
+	defer cycle.nextMu.Unlock()
+	return cycle.next
+}
+
+// schedule schedules the next run after delay.
+func (cycle *Cycle) schedule(delay time.Duration) {
+	if cycle.splay == nil {
+		if cycle.ticker != nil {
+			cycle.ticker.Stop()
+		}
+		cycle.ticker = time.NewTicker(delay)
+	} else {
+		if cycle.timer != nil {
+			cycle.timer.Stop()
+		}
+		cycle.timer = time.NewTimer(delay)
+	}
+	cycle.setNext(time.Now().Add(delay))
+}
+
+// pause stops scheduling runs.
+func (cycle *Cycle) pause() {
+	if cycle.splay == nil {
+		cycle.ticker.Stop()
+		// ensure we don't have ticks left
+		select {
+		case <-cycle.ticker.C:
+		default:
+		}
+	} else {
+		cycle.timer.Stop()
+		select {
+		case <-cycle.timer.C:
+		default:
+		}
+	}
+	cycle.setNext(time.Time{})
+}
+
+// ticks returns the channel of the scheduled runs.
+func (cycle *Cycle) ticks() <-chan time.Time {
+	if cycle.splay == nil {
+		return cycle.ticker.C
+	}
+	return cycle.timer.C
+}
+
+// ticked schedules the run after the one that just started.
+func (cycle *Cycle) ticked(interval time.Duration) {
+	if cycle.splay == nil {
+		cycle.setNext(time.Now().Add(interval))
+		return
+	}
+	cycle.schedule(cycle.splay.Next(interval))
+}
+
+// setNext records when the function runs next.
+func (cycle *Cycle) setNext(next time.Time) {
+	cycle.nextMu.Lock()
+	defer cycle.nextMu.Unlock()
+	cycle.next = next
+}
+
 func (cycle *Cycle) initialize() {
 	cycle.init.Do(func() {
 		cycle.stop = make(chan struct{})
@@ -66,11 +144,16 @@ func (cycle *Cycle) Start(ctx context.Context, group *errgroup.Group, fn func(ct
 func (cycle *Cycle) Run(ctx context.Context, fn func(ctx context.Context) error) error {
 	cycle.initialize()
 	defer close(cycle.stop)
+	defer cycle.setNext(time.Time{})
 
 	currentInterval := cycle.interval
-	cycle.ticker = time.NewTicker(currentInterval)
-	if err := fn(ctx); err != nil {
-		return err
+	if cycle.splay == nil {
+		cycle.schedule(currentInterval)
+		if err := fn(ctx); err != nil {
+			return err
+		}
+	} else {
+		cycle.schedule(cycle.splay.First(currentInterval))
 	}
 	for {
 		select {
@@ -84,20 +167,13 @@ func (cycle *Cycle) Run(ctx context.Context, fn func(ctx context.Context) error)
 
 			case cycleChangeInterval:
 				currentInterval = message.Interval
-				cycle.ticker.Stop()
-				cycle.ticker = time.NewTicker(currentInterval)
+				cycle.schedule(cycle.splay.Next(currentInterval))
 
 			case cyclePause:
-				cycle.ticker.Stop()
-				// ensure we don't have ticks left
-				select {
-				case <-cycle.ticker.C:
-				default:
-				}
+				cycle.pause()
 
 			case cycleContinue:
-				cycle.ticker.Stop()
-				cycle.ticker = time.NewTicker(currentInterval)
+				cycle.schedule(cycle.splay.Next(currentInterval))
 
 			case cycleTrigger:
 				// trigger the function
@@ -113,7 +189,8 @@ func (cycle *Cycle) Run(ctx context.Context, fn func(ctx context.Context) error)
 			// handle control messages
 			return ctx.Err()
 
-		case <-cycle.ticker.C:
+		case <-cycle.ticks():
+			cycle.ticked(currentInterval)
 			// trigger the function
 			if err := fn(ctx); err != nil {
 				return err
@@ -4,6 +4,7 @@
 package sync2
 
 import (
+	"context"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -13,6 +14,7 @@ import (
 type Fence struct {
 	status uint32
 	wait   sync.Mutex
+	done   chan struct{}
 }
 
 /*
@@ -42,6 +44,7 @@ func (fence *Fence) init() {
 		// first arriver sets up lock
 		if atomic.CompareAndSwapUint32(&fence.status, statusUninitialized, statusInitializing) {
 			fence.wait.Lock()
+			fence.done = make(chan struct{})
 			atomic.StoreUint32(&fence.status, statusBlocked)
 		} else {
 			runtime.Gosched()
@@ -63,6 +66,22 @@ func (fence *Fence) Wait() {
 	fence.wait.Unlock()
 }
 
+// WaitContext waits for the fence to be released or ctx to be done.
+// Returns whether the fence was released.
+func (fence *Fence) WaitContext(ctx context.Context) bool {
+	// fast-path
+	if fence.Released() {
+		return true
+	}
+	fence.init()
+	select {
+	case <-fence.done:
+		return true
+	case <-ctx.Done():
+		return fence.Released()
+	}
+}
+
 // Released returns whether the fence has been released.
 func (fence *Fence) Released() bool {
 	return atomic.LoadUint32(&fence.status) >= statusReleased
@@ -73,6 +92,7 @@ func (fence *Fence) Release() {
 	fence.init()
 	// the first one releases the status
 	if atomic.CompareAndSwapUint32(&fence.status, statusBlocked, statusReleased) {
+		close(fence.done)
 		fence.wait.Unlock()
 	}
 }
@@ -4,6 +4,7 @@
 package sync2_test
 
 import (
+	"context"
 	"errors"
 	"sync/atomic"
 	"testing"
@@ -46,3 +47,20 @@ func TestFence(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestFenceWaitContext(t *testing.T) {
+	t.Parallel()
+
+	var fence sync2.Fence
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if fence.WaitContext(ctx) {
+		t.Fatal("fence not yet released")
+	}
+
+	fence.Release()
+	if !fence.WaitContext(ctx) {
+		t.Fatal("fence released")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information
+
+package sync2
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// SplayInitial is the fraction of the interval the first run of a loop
+	// is delayed by at most.
+	SplayInitial = 0.5
+	// SplayJitter is the fraction of the interval the later runs of a loop
+	// are moved by at most, earlier or later.
+	SplayJitter = 0.1
+)
+
+// Splay spreads the runs of a periodic loop, so that the loops of many nodes
+// restarting at the same time don't run in waves.
+//
+// The delay of the first run is derived from the seed, such as the node ID,
+// so that it is stable for a node but differs between nodes. The jitter of
+// the later runs is random, seeded the same way. A nil Splay doesn't delay
+// or move any run.
+type Splay struct {
+	// Initial is the fraction of the interval the first run is delayed by at most.
+	Initial float64
+	// Jitter is the fraction of the interval later runs are moved by at most.
+	Jitter float64
+
+	offset float64 // in [0, 1)
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewSplay returns the splay of the loop called name of the node with seed.
+func NewSplay(seed []byte, name string) *Splay {
+	hash := sha256.New()
+	_, _ = hash.Write(seed)
+	_, _ = hash.Write([]byte(name))
+	sum := hash.Sum(nil)
+
+	return &Splay{
+		Initial: SplayInitial,
+		Jitter:  SplayJitter,
+		offset:  float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53),
+		rng:     rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(sum[8:16])))),
+	}
+}
+
+// First returns the delay of the first run of a loop with interval, it is the
+// same for every call.
+func (splay *Splay) First(interval time.Duration) time.Duration {
+	if splay == nil || interval <= 0 {
+		return 0
+	}
+	return time.Duration(splay.offset * splay.Initial * float64(interval))
+}
+
+// Next returns the delay between two runs of a loop with interval.
+func (splay *Splay) Next(interval time.Duration) time.Duration {
+	if splay == nil || interval <= 0 {
+		return interval
+	}
+	jitter := time.Duration(math.Min(splay.Jitter, 1) * float64(interval))
+	return interval - jitter + splay.Random(2*jitter)
+}
+
+// Random returns a random duration less than max, zero when max isn't
+// positive.
+func (splay *Splay) Random(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	if splay == nil {
+		return time.Duration(rand.Int63n(int64(max)))
+	}
+	splay.mu.Lock()
+	defer splay.mu.Unlock()
+	return time.Duration(splay.rng.Int63n(int64(max)))
+}
+
+// NextRuns reports when periodic loops run next, so that their splay can be
+// verified.
+type NextRuns struct {
+	mu      sync.Mutex
+	sources []func() map[string]time.Time
+}
+
+// Add reports the next run of cycle as name.
+func (runs *NextRuns) Add(name string, cycle *Cycle) {
+	runs.AddFunc(func() map[string]time.Time {
+		return map[string]time.Time{name: cycle.NextRun()}
+	})
+}
+
+// AddFunc reports the next runs returned by source, by loop name.
+func (runs *NextRuns) AddFunc(source func() map[string]time.Time) {
+	runs.mu.Lock()
+	defer runs.mu.Unlock()
+	runs.sources = append(runs.sources, source)
+}
+
+// NextRun is when a periodic loop runs next.
+type NextRun struct {
+	Loop string    `json:"loop"`
+	Next time.Time `json:"next"`
+}
+
+// Snapshot returns the next runs of the loops that are scheduled, sorted by
+// loop name.
+func (runs *NextRuns) Snapshot() []NextRun {
+	runs.mu.Lock()
+	sources := append([]func() map[string]time.Time(nil), runs.sources...)
+	runs.mu.Unlock()
+
+	var snapshot []NextRun
+	for _, source := range sources {
+		for loop, next := range source() {
+			if !next.IsZero() {
+				snapshot = append(snapshot, NextRun{Loop: loop, Next: next})
+			}
+		}
+	}
+	sort.Slice(snapshot, func(i, k int) bool { return snapshot[i].Loop < snapshot[k].Loop })
+	return snapshot
+}
+
+// ServeHTTP writes the next runs of the loops as JSON.
+func (runs *NextRuns) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(runs.Snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information
+
+package sync2_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"storj.io/storj/internal/sync2"
+)
+
+func TestSplay(t *testing.T) {
+	const interval = time.Hour
+	maxFirst := time.Duration(sync2.SplayInitial * float64(interval))
+	maxJitter := time.Duration(sync2.SplayJitter * float64(interval))
+
+	a := sync2.NewSplay([]byte("node a"), "loop")
+	b := sync2.NewSplay([]byte("node b"), "loop")
+	other := sync2.NewSplay([]byte("node a"), "other loop")
+
+	for _, splay := range []*sync2.Splay{a, b, other} {
+		first := splay.First(interval)
+		if first < 0 || first > maxFirst {
+			t.Errorf("first run delayed by %v, expected at most %v", first, maxFirst)
+		}
+		for i := 0; i < 100; i++ {
+			next := splay.Next(interval)
+			if next < interval-maxJitter || next > interval+maxJitter {
+				t.Fatalf("next run after %v, expected %v±%v", next, interval, maxJitter)
+			}
+		}
+	}
+
+	if a.First(interval) == b.First(interval) {
+		t.Errorf("nodes a and b start at the same offset %v", a.First(interval))
+	}
+	if a.First(interval) == other.First(interval) {
+		t.Errorf("the loops of node a start at the same offset %v", a.First(interval))
+	}
+	if again := sync2.NewSplay([]byte("node a"), "loop"); again.First(interval) != a.First(interval) {
+		t.Errorf("node a starts at %v after a restart, expected %v", again.First(interval), a.First(interval))
+	}
+
+	var none *sync2.Splay
+	if none.First(interval) != 0 || none.Next(interval) != interval || none.Random(0) != 0 {
+		t.Errorf("nil splay moved the runs")
+	}
+}
+
+func TestCycle_Splay(t *testing.T) {
+	ctx := context.Background()
+
+	const interval = time.Hour
+	cycle := sync2.NewCycle(interval)
+	defer cycle.Close()
+	cycle.SetSplay(sync2.NewSplay([]byte("node"), "loop"))
+
+	var runs sync2.NextRuns
+	runs.Add("loop", cycle)
+
+	count := int64(0)
+	var group errgroup.Group
+	start := time.Now()
+	cycle.Start(ctx, &group, func(ctx context.Context) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+
+	// the first run is delayed instead of starting right away
+	cycle.TriggerWait()
+	if got := atomic.LoadInt64(&count); got != 1 {
+		t.Errorf("expected only the triggered run, got %d runs", got)
+	}
+
+	snapshot := runs.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Loop != "loop" {
+		t.Fatalf("unexpected next runs %v", snapshot)
+	}
+	next := cycle.NextRun().Sub(start)
+	if maxFirst := time.Duration(sync2.SplayInitial * float64(interval)); next < 0 || next > maxFirst {
+		t.Errorf("first run in %v, expected at most %v", next, maxFirst)
+	}
+
+	cycle.Pause()
+	cycle.TriggerWait()
+	if !cycle.NextRun().IsZero() {
+		t.Errorf("paused cycle runs next at %v", cycle.NextRun())
+	}
+
+	cycle.Stop()
+	if err := group.Wait(); err != nil {
+		t.Error(err)
+	}
+	if len(runs.Snapshot()) != 0 {
+		t.Errorf("stopped cycle reported %v", runs.Snapshot())
+	}
+}
@@ -4,6 +4,7 @@
 package sync2
 
 import (
+	"context"
 	"sync"
 )
 
@@ -15,6 +16,7 @@ type WorkGroup struct {
 	initialized bool
 	closed      bool
 	workers     int
+	idle        chan struct{} // closed when workers reaches zero, created by WaitContext
 }
 
 // init initializes work group
@@ -61,6 +63,10 @@ func (group *WorkGroup) Done() {
 	}
 	if group.workers == 0 {
 		group.cond.Broadcast()
+		if group.idle != nil {
+			close(group.idle)
+			group.idle = nil
+		}
 	}
 }
 
@@ -76,6 +82,29 @@ func (group *WorkGroup) Wait() {
 	}
 }
 
+// WaitContext waits for all workers to finish or ctx to be done.
+// Returns whether all workers finished.
+func (group *WorkGroup) WaitContext(ctx context.Context) bool {
+	group.mu.Lock()
+	group.init()
+	if group.workers == 0 {
+		group.mu.Unlock()
+		return true
+	}
+	if group.idle == nil {
+		group.idle = make(chan struct{})
+	}
+	idle := group.idle
+	group.mu.Unlock()
+
+	select {
+	case <-idle:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // Close prevents from new work being started.
 func (group *WorkGroup) Close() {
 	group.mu.Lock()
@@ -4,6 +4,7 @@
 package sync2_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -66,3 +67,19 @@ func TestWaitGroupClose(t *testing.T) {
 		t.Fatalf("waited %s instead of %s", duration, Wait)
 	}
 }
+
+func TestWaitGroupWaitContext(t *testing.T) {
+	t.Parallel()
+
+	var group sync2.WorkGroup
+
+	release := make(chan struct{})
+	require.True(t, group.Go(func() { <-release }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.False(t, group.WaitContext(ctx))
+
+	close(release)
+	require.True(t, group.WaitContext(context.Background()))
+}
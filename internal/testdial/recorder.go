@@ -0,0 +1,157 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package testdial asserts which nodes tests dial.
+package testdial
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+)
+
+// Dial is a dial of a node made through a Recorder.
+type Dial struct {
+	Node    storj.NodeID
+	Address string
+	// Err is the error of the dial, nil when it succeeded
+	Err error
+	// Site and Stack are where the dial was made from, see transport.DialSite
+	Site  string
+	Stack string
+}
+
+// String returns the dial and where it was made from.
+func (dial Dial) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s at %q", dial.Node, dial.Address)
+	if dial.Err != nil {
+		fmt.Fprintf(&b, " (failed: %v)", dial.Err)
+	}
+	site := dial.Site
+	if site == "" {
+		site = "unannotated, see transport.WithDialSite"
+	}
+	fmt.Fprintf(&b, "\n    site: %s", site)
+	if dial.Stack != "" {
+		fmt.Fprintf(&b, "\n    %s", strings.Replace(dial.Stack, "\n", "\n    ", -1))
+	}
+	return b.String()
+}
+
+// Recorder records the dials of nodes made through its Client.
+//
+// Dials denied by the egress policy don't connect and aren't recorded, nor
+// are the dials of addresses, which transport observers don't see.
+type Recorder struct {
+	t testing.TB
+	// Client dials through the recorded transport
+	Client transport.Client
+
+	mu    sync.Mutex
+	dials []Dial
+}
+
+// WithDialRecorder returns a recorder of the dials made through client.
+//
+//	recorder := testdial.WithDialRecorder(t, peer.Transport)
+//	... dial with recorder.Client ...
+//	recorder.ExpectOnly(target.Id)
+func WithDialRecorder(t testing.TB, client transport.Client) *Recorder {
+	recorder := &Recorder{t: t}
+	recorder.Client = client.WithObservers(recorder)
+	return recorder
+}
+
+// ConnSuccess implements transport.Observer.
+func (recorder *Recorder) ConnSuccess(ctx context.Context, node *pb.Node) {
+	recorder.record(ctx, node, nil)
+}
+
+// ConnFailure implements transport.Observer.
+func (recorder *Recorder) ConnFailure(ctx context.Context, node *pb.Node, err error) {
+	if transport.PolicyDenied.Has(err) {
+		return
+	}
+	recorder.record(ctx, node, err)
+}
+
+// record records the dial of node made with ctx.
+func (recorder *Recorder) record(ctx context.Context, node *pb.Node, err error) {
+	site, stack := transport.DialSite(ctx)
+	dial := Dial{Node: node.Id, Address: node.GetAddress().GetAddress(), Err: err, Site: site, Stack: stack}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.dials = append(recorder.dials, dial)
+}
+
+// Dials returns the recorded dials, in the order they were made.
+func (recorder *Recorder) Dials() []Dial {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return append([]Dial(nil), recorder.dials...)
+}
+
+// Count returns the number of recorded dials of id.
+func (recorder *Recorder) Count(id storj.NodeID) int {
+	count := 0
+	for _, dial := range recorder.Dials() {
+		if dial.Node == id {
+			count++
+		}
+	}
+	return count
+}
+
+// Reset forgets the recorded dials.
+func (recorder *Recorder) Reset() {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.dials = nil
+}
+
+// ExpectNone fails the test when any node was dialed.
+func (recorder *Recorder) ExpectNone() bool {
+	recorder.t.Helper()
+	return recorder.ExpectOnly()
+}
+
+// ExpectOnly fails the test when a node other than ids was dialed, listing
+// every unexpected dial and where it was made from.
+func (recorder *Recorder) ExpectOnly(ids ...storj.NodeID) bool {
+	recorder.t.Helper()
+
+	expected := make(map[storj.NodeID]bool, len(ids))
+	for _, id := range ids {
+		expected[id] = true
+	}
+
+	var unexpected []string
+	for _, dial := range recorder.Dials() {
+		if !expected[dial.Node] {
+			unexpected = append(unexpected, "- "+dial.String())
+		}
+	}
+	if len(unexpected) == 0 {
+		return true
+	}
+
+	allowed := "none"
+	if len(ids) > 0 {
+		names := make([]string, 0, len(ids))
+		for _, id := range ids {
+			names = append(names, id.String())
+		}
+		allowed = strings.Join(names, ", ")
+	}
+	recorder.t.Errorf("%d unexpected dials, expected dials of %s:\n%s",
+		len(unexpected), allowed, strings.Join(unexpected, "\n"))
+	return false
+}
@@ -4,7 +4,10 @@
 package testidentity
 
 import (
+	"context"
+	"crypto/x509"
 	"errors"
+	"math/rand"
 
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/storj"
@@ -107,11 +110,66 @@ func NewPregeneratedSigner(version storj.IDVersion) *identity.FullCertificateAut
 	return SignerVersions[version.Number]
 }
 
+// NewSignedIdentities generates a table of count identities of version, signed
+// by signer, for versions without pregenerated identities.
+func NewSignedIdentities(ctx context.Context, version storj.IDVersion, signer *identity.FullCertificateAuthority, count int) (*Identities, error) {
+	list := make([]*identity.FullIdentity, 0, count)
+	for i := 0; i < count; i++ {
+		ca, err := identity.NewCA(ctx, identity.NewCAOptions{
+			VersionNumber: version.Number,
+			Difficulty:    8,
+			Concurrency:   4,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		ca.Cert, err = signer.Sign(ca.Cert)
+		if err != nil {
+			return nil, err
+		}
+		ca.RestChain = []*x509.Certificate{signer.Cert}
+
+		ident, err := ca.NewIdentity()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, ident)
+	}
+	return NewIdentities(list...), nil
+}
+
+// Interleaved creates a table taking the identities from each of tables in
+// turn, until one of them runs out.
+func Interleaved(tables ...*Identities) *Identities {
+	var list []*identity.FullIdentity
+	if len(tables) == 0 {
+		return NewIdentities()
+	}
+	for i := 0; ; i++ {
+		for _, table := range tables {
+			if i >= len(table.list) {
+				return NewIdentities(list...)
+			}
+			list = append(list, table.list[i])
+		}
+	}
+}
+
 // Clone creates a shallow clone of the table.
 func (identities *Identities) Clone() *Identities {
 	return NewIdentities(identities.list...)
 }
 
+// Shuffled creates a clone of the table with the identities in an order determined by seed.
+func (identities *Identities) Shuffled(seed int64) *Identities {
+	list := append([]*identity.FullIdentity(nil), identities.list...)
+	rand.New(rand.NewSource(seed)).Shuffle(len(list), func(i, k int) {
+		list[i], list[k] = list[k], list[i]
+	})
+	return NewIdentities(list...)
+}
+
 // NewIdentity gets a new identity from the list.
 func (identities *Identities) NewIdentity() (*identity.FullIdentity, error) {
 	if identities.next >= len(identities.list) {
@@ -0,0 +1,480 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package testkademlia implements a fake kademlia node for testing the client
+// side of kademlia, such as the Dialer, without running a routing table.
+//
+// The fake serves the Nodes service over loopback TLS with a test identity,
+// so it is dialed by a real transport client, and responds as scripted:
+//
+//	server, err := testkademlia.Start(ctx)
+//	require.NoError(t, err)
+//	defer server.Close()
+//
+//	server.Set(testkademlia.Behavior{Nodes: testkademlia.RandomNodes(5), Order: testkademlia.Farthest})
+//	server.Script(testkademlia.Ping, testkademlia.Behavior{Code: codes.Unavailable})
+package testkademlia
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/identity"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/storj"
+)
+
+// Names of the RPCs of the Nodes service, see Server.Set.
+const (
+	Query       = "Query"
+	QueryStream = "QueryStream"
+	Ping        = "Ping"
+	RequestInfo = "RequestInfo"
+	Leave       = "Leave"
+)
+
+// paddingField is the number of the unknown field padding lookup responses,
+// like a field added by a newer peer.
+const paddingField = 1000
+
+// Order is the order of the nodes in lookup responses.
+type Order int
+
+const (
+	// AsGiven returns the nodes in the order of Behavior.Nodes.
+	AsGiven Order = iota
+	// Closest sorts the nodes by XOR distance to the target, as peers must.
+	Closest
+	// Farthest sorts the farthest nodes first, violating the protocol.
+	Farthest
+)
+
+// Behavior is how the server responds to a call.
+type Behavior struct {
+	// Nodes are returned by lookups, in Order, regardless of the requested
+	// limit. QueryStream sends them in chunks of the requested size, or in a
+	// single response when the size isn't set, and again until the client
+	// cancels when Repeat is set.
+	Nodes  []*pb.Node
+	Order  Order
+	Repeat bool
+	// Padding is added to every lookup response, as an unknown field, to make
+	// it larger than the client accepts.
+	Padding memory.Size
+
+	// Capabilities are returned by Query and Ping.
+	Capabilities uint64
+	// Protocol is advertised in the response header, none is advertised for
+	// ProtocolLegacy, like old peers.
+	Protocol version.Protocol
+	// Info is returned by RequestInfo, an empty response when nil.
+	Info *pb.InfoResponse
+
+	// Delay is waited before responding, unless the call is canceled first.
+	Delay time.Duration
+	// Code fails the call with the status code instead of responding, with
+	// Message, and ErrorCode in the status details when set, as the kademlia
+	// endpoint does.
+	Code      codes.Code
+	Message   string
+	ErrorCode string
+}
+
+// err returns the error the call fails with, nil unless Code is set.
+func (behavior Behavior) err() error {
+	if behavior.Code == codes.OK {
+		return nil
+	}
+	message := behavior.Message
+	if message == "" {
+		message = behavior.Code.String()
+	}
+	st := status.New(behavior.Code, message)
+	if behavior.ErrorCode != "" {
+		detailed, err := st.WithDetails(&wrappers.StringValue{Value: behavior.ErrorCode})
+		if err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+// wait waits for Delay, returning the error of ctx when it's done first.
+func (behavior Behavior) wait(ctx context.Context) error {
+	if behavior.Delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(behavior.Delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return status.Error(codes.Canceled, ctx.Err().Error())
+	}
+}
+
+// nodes returns the nodes of a lookup of target.
+func (behavior Behavior) nodes(target storj.NodeID) []*pb.Node {
+	nodes := append([]*pb.Node(nil), behavior.Nodes...)
+	switch behavior.Order {
+	case Closest:
+		sort.SliceStable(nodes, func(i, k int) bool { return closer(nodes[i].Id, nodes[k].Id, target) })
+	case Farthest:
+		sort.SliceStable(nodes, func(i, k int) bool { return closer(nodes[k].Id, nodes[i].Id, target) })
+	}
+	return nodes
+}
+
+// padding returns the encoded unknown field padding lookup responses.
+func (behavior Behavior) padding() []byte {
+	if behavior.Padding <= 0 {
+		return nil
+	}
+	padding := proto.EncodeVarint(paddingField<<3 | 2)
+	padding = append(padding, proto.EncodeVarint(uint64(behavior.Padding))...)
+	return append(padding, make([]byte, behavior.Padding)...)
+}
+
+// target returns the ID of the node looked up by req.
+func target(req *pb.QueryRequest) storj.NodeID {
+	if req.Target == nil {
+		return storj.NodeID{}
+	}
+	return req.Target.Id
+}
+
+// closer returns whether a is closer to target than b.
+func closer(a, b, target storj.NodeID) bool {
+	for i := range target {
+		x, y := a[i]^target[i], b[i]^target[i]
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+// RandomNodes returns n nodes with random IDs and distinct addresses.
+func RandomNodes(n int) []*pb.Node {
+	nodes := make([]*pb.Node, n)
+	for i := range nodes {
+		var id storj.NodeID
+		_, _ = rand.Read(id[:])
+		nodes[i] = &pb.Node{
+			Id: id,
+			Address: &pb.NodeAddress{
+				Transport: pb.NodeTransport_TCP_TLS_GRPC,
+				Address:   "10." + strconv.Itoa(i>>16&255) + "." + strconv.Itoa(i>>8&255) + "." + strconv.Itoa(i&255) + ":28967",
+			},
+		}
+	}
+	return nodes
+}
+
+// Server is a fake kademlia node serving the Nodes service as scripted.
+type Server struct {
+	identity *identity.FullIdentity
+	addr     string
+	grpc     *grpc.Server
+
+	mu        sync.Mutex
+	behaviors map[string]Behavior
+	scripts   map[string][]Behavior
+	calls     map[string]int
+	active    map[string]int
+	responses map[string]int
+	queries   []*pb.QueryRequest
+}
+
+// Start starts a server on loopback with a new test identity, which accepts
+// peers of any identity version. It stops when Close is called.
+func Start(ctx *testcontext.Context, opts ...grpc.ServerOption) (*Server, error) {
+	ca, err := testidentity.NewTestCA(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ident, err := ca.NewIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return StartAs(ctx, ident, opts...)
+}
+
+// StartAs starts a server like Start presenting ident, such as the identity
+// of another server to simulate a node whose key is used by another node.
+func StartAs(ctx *testcontext.Context, ident *identity.FullIdentity, opts ...grpc.ServerOption) (*Server, error) {
+	tlsOptions, err := tlsopts.NewOptions(ident, tlsopts.Config{PeerIDVersions: "*"})
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		identity:  ident,
+		addr:      listener.Addr().String(),
+		grpc:      grpc.NewServer(append([]grpc.ServerOption{tlsOptions.ServerOption()}, opts...)...),
+		behaviors: map[string]Behavior{},
+		scripts:   map[string][]Behavior{},
+		calls:     map[string]int{},
+		active:    map[string]int{},
+		responses: map[string]int{},
+	}
+	pb.RegisterNodesServer(server.grpc, server)
+
+	ctx.Go(func() error {
+		err := server.grpc.Serve(listener)
+		if err == grpc.ErrServerStopped {
+			err = nil
+		}
+		return err
+	})
+	return server, nil
+}
+
+// Close stops the server, failing the calls in progress.
+func (server *Server) Close() { server.grpc.Stop() }
+
+// Identity returns the identity of the server.
+func (server *Server) Identity() *identity.FullIdentity { return server.identity }
+
+// ID returns the node ID of the server.
+func (server *Server) ID() storj.NodeID { return server.identity.ID }
+
+// Addr returns the address the server listens on.
+func (server *Server) Addr() string { return server.addr }
+
+// Node returns the server as a node to dial.
+func (server *Server) Node() pb.Node {
+	return pb.Node{
+		Id: server.identity.ID,
+		Address: &pb.NodeAddress{
+			Transport: pb.NodeTransport_TCP_TLS_GRPC,
+			Address:   server.addr,
+		},
+	}
+}
+
+// Set makes the server respond to the calls of rpcs, or of every RPC when
+// none are given, as behavior once their scripted calls are used up.
+func (server *Server) Set(behavior Behavior, rpcs ...string) {
+	if len(rpcs) == 0 {
+		rpcs = []string{Query, QueryStream, Ping, RequestInfo, Leave}
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, rpc := range rpcs {
+		server.behaviors[rpc] = behavior
+	}
+}
+
+// Script makes the server respond to the next calls of rpc as behaviors, one
+// call each, before responding as set again.
+func (server *Server) Script(rpc string, behaviors ...Behavior) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.scripts[rpc] = append(server.scripts[rpc], behaviors...)
+}
+
+// Calls returns the number of calls of rpc received.
+func (server *Server) Calls(rpc string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.calls[rpc]
+}
+
+// Active returns the number of calls of rpc in progress.
+func (server *Server) Active(rpc string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.active[rpc]
+}
+
+// Responses returns the number of responses to rpc sent, each chunk of a
+// stream is a response.
+func (server *Server) Responses(rpc string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.responses[rpc]
+}
+
+// Queries returns the requests of the Query and QueryStream calls received.
+func (server *Server) Queries() []*pb.QueryRequest {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]*pb.QueryRequest(nil), server.queries...)
+}
+
+// begin records a call of rpc and returns how to respond to it, done must be
+// called when the call returns.
+func (server *Server) begin(rpc string) (behavior Behavior, done func()) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.calls[rpc]++
+	server.active[rpc]++
+	if script := server.scripts[rpc]; len(script) > 0 {
+		behavior, server.scripts[rpc] = script[0], script[1:]
+	} else {
+		behavior = server.behaviors[rpc]
+	}
+
+	return behavior, func() {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		server.active[rpc]--
+	}
+}
+
+// sent records a response to rpc.
+func (server *Server) sent(rpc string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.responses[rpc]++
+}
+
+// queried records a lookup request.
+func (server *Server) queried(req *pb.QueryRequest) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.queries = append(server.queries, req)
+}
+
+// respond waits and fails as scripted by behavior and advertises its protocol
+// with setHeader.
+func respond(ctx context.Context, behavior Behavior, setHeader func(metadata.MD) error) error {
+	if err := behavior.wait(ctx); err != nil {
+		return err
+	}
+	if err := behavior.err(); err != nil {
+		return err
+	}
+	if behavior.Protocol != version.ProtocolLegacy {
+		_ = setHeader(version.ProtocolHeader(behavior.Protocol))
+	}
+	return nil
+}
+
+// Query implements pb.NodesServer.
+func (server *Server) Query(ctx context.Context, req *pb.QueryRequest) (*pb.QueryResponse, error) {
+	behavior, done := server.begin(Query)
+	defer done()
+	server.queried(req)
+
+	if err := respond(ctx, behavior, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+
+	server.sent(Query)
+	return &pb.QueryResponse{
+		Response:         behavior.nodes(target(req)),
+		Capabilities:     behavior.Capabilities,
+		XXX_unrecognized: behavior.padding(),
+	}, nil
+}
+
+// QueryStream implements pb.NodesServer.
+func (server *Server) QueryStream(req *pb.QueryRequest, stream pb.Nodes_QueryStreamServer) error {
+	behavior, done := server.begin(QueryStream)
+	defer done()
+	server.queried(req)
+
+	ctx := stream.Context()
+	if err := respond(ctx, behavior, stream.SetHeader); err != nil {
+		return err
+	}
+
+	chunks := split(behavior.nodes(target(req)), int(req.ChunkSize))
+	padding := behavior.padding()
+	for {
+		for _, chunk := range chunks {
+			err := stream.Send(&pb.QueryResponse{Response: chunk, XXX_unrecognized: padding})
+			if err != nil {
+				return err
+			}
+			server.sent(QueryStream)
+		}
+		if !behavior.Repeat {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return status.Error(codes.Canceled, err.Error())
+		}
+	}
+}
+
+// split splits nodes into chunks of size, a single chunk when size isn't set.
+func split(nodes []*pb.Node, size int) [][]*pb.Node {
+	if size <= 0 || size >= len(nodes) {
+		return [][]*pb.Node{nodes}
+	}
+	var chunks [][]*pb.Node
+	for len(nodes) > size {
+		chunks = append(chunks, nodes[:size])
+		nodes = nodes[size:]
+	}
+	return append(chunks, nodes)
+}
+
+// Ping implements pb.NodesServer.
+func (server *Server) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	behavior, done := server.begin(Ping)
+	defer done()
+
+	if err := respond(ctx, behavior, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+
+	server.sent(Ping)
+	return &pb.PingResponse{Capabilities: behavior.Capabilities}, nil
+}
+
+// RequestInfo implements pb.NodesServer.
+func (server *Server) RequestInfo(ctx context.Context, req *pb.InfoRequest) (*pb.InfoResponse, error) {
+	behavior, done := server.begin(RequestInfo)
+	defer done()
+
+	if err := respond(ctx, behavior, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+
+	server.sent(RequestInfo)
+	if behavior.Info != nil {
+		return behavior.Info, nil
+	}
+	return &pb.InfoResponse{}, nil
+}
+
+// Leave implements pb.NodesServer.
+func (server *Server) Leave(ctx context.Context, req *pb.LeaveRequest) (*pb.LeaveResponse, error) {
+	behavior, done := server.begin(Leave)
+	defer done()
+
+	if err := respond(ctx, behavior, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+
+	server.sent(Leave)
+	return &pb.LeaveResponse{}, nil
+}
@@ -0,0 +1,209 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information
+
+package testplanet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/storagenode"
+)
+
+// ChaosConfig configures the random restarts of storage nodes by Chaos.
+type ChaosConfig struct {
+	// Interval is how often a storage node is picked for a restart.
+	Interval time.Duration
+	// MaxConcurrentRestarts is how many storage nodes restart at the same
+	// time, defaults to 1.
+	MaxConcurrentRestarts int
+	// Seed seeds the picking of the storage nodes, a random one is used when
+	// zero. It's logged so that a failing run can be repeated.
+	Seed int64
+	// Nodes are the indexes in StorageNodes of the storage nodes that may be
+	// restarted, all of them when empty.
+	Nodes []int
+}
+
+// Chaos restarts random storage nodes every config.Interval until ctx is
+// canceled, then waits for the restarts in progress. A restarted node keeps
+// its identity, database and address.
+//
+// The storage nodes that may be restarted must not be used by the test until
+// Chaos returns, since StorageNodes is updated with the new peers.
+func (planet *Planet) Chaos(ctx context.Context, config ChaosConfig) error {
+	if !planet.started {
+		return errors.New("Start was never called")
+	}
+	if config.Interval <= 0 {
+		return errors.New("chaos interval must be positive")
+	}
+	if config.MaxConcurrentRestarts <= 0 {
+		config.MaxConcurrentRestarts = 1
+	}
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	nodes := config.Nodes
+	if len(nodes) == 0 {
+		for i := range planet.StorageNodes {
+			nodes = append(nodes, i)
+		}
+	}
+
+	log := planet.log.Named("chaos").With(zap.Int64("seed", config.Seed))
+	log.Info("chaos started", zap.Ints("nodes", nodes))
+
+	rng := rand.New(rand.NewSource(config.Seed))
+
+	var mu sync.Mutex
+	restarting := make(map[int]bool)
+
+	var group errgroup.Group
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			err := group.Wait()
+			log.Info("chaos stopped", zap.Error(err))
+			return err
+		case <-ticker.C:
+		}
+
+		mu.Lock()
+		var candidates []int
+		for _, index := range nodes {
+			if !restarting[index] {
+				candidates = append(candidates, index)
+			}
+		}
+		if len(restarting) >= config.MaxConcurrentRestarts || len(candidates) == 0 {
+			mu.Unlock()
+			continue
+		}
+		index := candidates[rng.Intn(len(candidates))]
+		restarting[index] = true
+		mu.Unlock()
+
+		group.Go(func() error {
+			defer func() {
+				mu.Lock()
+				delete(restarting, index)
+				mu.Unlock()
+			}()
+
+			log.Info("restarting storage node", zap.Int("index", index))
+			if err := planet.RestartStorageNode(index); err != nil {
+				log.Error("restarting storage node failed", zap.Int("index", index), zap.Error(err))
+				return fmt.Errorf("storage%d: %v", index, err)
+			}
+			log.Info("restarted storage node", zap.Int("index", index))
+			return nil
+		})
+	}
+}
+
+// RestartStorageNode stops the storage node at index in StorageNodes and
+// starts it again with the same identity, database and address, waiting for
+// it to bootstrap.
+func (planet *Planet) RestartStorageNode(index int) error {
+	if !planet.started {
+		return errors.New("Start was never called")
+	}
+
+	planet.mu.Lock()
+	old := planet.StorageNodes[index]
+	planet.mu.Unlock()
+
+	stopped := planet.findPeer(old)
+	if stopped == nil {
+		return errors.New("unknown storage node")
+	}
+	if err := stopped.Close(); err != nil {
+		return err
+	}
+
+	setup := planet.storageNodeSetups[index]
+	setup.config.Server.Address = old.Addr()
+	setup.config.Server.PrivateAddress = old.PrivateAddr()
+
+	node, err := planet.newStorageNode(setup)
+	if err != nil {
+		return err
+	}
+	node.Kademlia.Service.SetBootstrapNodes([]pb.Node{planet.Bootstrap.Local().Node})
+
+	peer := &closablePeer{name: stopped.name, peer: node}
+	planet.mu.Lock()
+	for i := range planet.peers {
+		if planet.peers[i] == stopped {
+			planet.peers[i] = peer
+		}
+	}
+	planet.StorageNodes[index] = node
+	planet.mu.Unlock()
+
+	return planet.startPeer(planet.ctx, peer)
+}
+
+// WaitForConvergence waits until every storage node finds every other one
+// with a lookup, retrying every interval. It returns the last failure when
+// ctx is done first.
+func (planet *Planet) WaitForConvergence(ctx context.Context, interval time.Duration) error {
+	planet.mu.Lock()
+	nodes := append([]*storagenode.Peer(nil), planet.StorageNodes...)
+	planet.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := converged(ctx, nodes)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// converged checks that every node finds every other one with a lookup.
+func converged(ctx context.Context, nodes []*storagenode.Peer) error {
+	ctx = kademlia.WithFreshLookup(ctx)
+
+	var group errgroup.Group
+	for _, from := range nodes {
+		from := from
+		group.Go(func() error {
+			for _, to := range nodes {
+				if from == to {
+					continue
+				}
+				found, err := from.Kademlia.Service.FindNode(ctx, to.ID())
+				if err != nil {
+					return fmt.Errorf("%s did not find %s: %v", from.ID(), to.ID(), err)
+				}
+				if found.Address.GetAddress() != to.Addr() {
+					return fmt.Errorf("%s found %s at %q instead of %q", from.ID(), to.ID(), found.Address.GetAddress(), to.Addr())
+				}
+			}
+			return nil
+		})
+	}
+	return group.Wait()
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information
+
+package testplanet_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/storagenode"
+)
+
+func TestChaos(t *testing.T) {
+	if testing.Short() {
+		t.Skip("restarts storage nodes for several seconds")
+	}
+
+	ctx := testcontext.NewWithTimeout(t, 3*time.Minute)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.New(t, 1, 8, 0)
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	// the first nodes stay up and run the dialer suite against each other
+	stable := append([]*storagenode.Peer(nil), planet.StorageNodes[:3]...)
+
+	chaosCtx, stopChaos := context.WithTimeout(ctx, 10*time.Second)
+	defer stopChaos()
+
+	var group errgroup.Group
+	group.Go(func() error {
+		return planet.Chaos(chaosCtx, testplanet.ChaosConfig{
+			Interval:              500 * time.Millisecond,
+			MaxConcurrentRestarts: 2,
+			Nodes:                 []int{3, 4, 5, 6, 7},
+		})
+	})
+	group.Go(func() error {
+		for chaosCtx.Err() == nil {
+			if err := dialStable(ctx, t, planet, stable); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, group.Wait())
+
+	convergeCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	require.NoError(t, planet.WaitForConvergence(convergeCtx, time.Second))
+}
+
+// dialStable pings the satellite and the stable storage nodes from each of
+// them, and fetches the identity of the satellite.
+func dialStable(ctx *testcontext.Context, t *testing.T, planet *testplanet.Planet, stable []*storagenode.Peer) error {
+	satellite := planet.Satellites[0]
+
+	var group errgroup.Group
+	for _, self := range stable {
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
+		defer ctx.Check(dialer.Close)
+
+		group.Go(func() error {
+			ident, err := dialer.FetchPeerIdentity(ctx, satellite.Local().Node)
+			if err != nil {
+				return err
+			}
+			if ident.ID != satellite.ID() {
+				return fmt.Errorf("fetched identity %s instead of %s", ident.ID, satellite.ID())
+			}
+			return nil
+		})
+		for _, peer := range stable {
+			peer := peer
+			group.Go(func() error {
+				pinged, err := dialer.PingNode(ctx, peer.Local().Node)
+				if err != nil {
+					return err
+				}
+				if !pinged {
+					return fmt.Errorf("ping to %s should have succeeded", peer.ID())
+				}
+				return nil
+			})
+		}
+	}
+	return group.Wait()
+}
@@ -34,6 +34,7 @@ import (
 	"storj.io/storj/pkg/accounting/tally"
 	"storj.io/storj/pkg/audit"
 	"storj.io/storj/pkg/bwagreement"
+	"storj.io/storj/pkg/cfgstruct"
 	"storj.io/storj/pkg/datarepair/checker"
 	"storj.io/storj/pkg/datarepair/repairer"
 	"storj.io/storj/pkg/discovery"
@@ -52,6 +53,7 @@ import (
 	"storj.io/storj/satellite/metainfo"
 	"storj.io/storj/satellite/satellitedb"
 	"storj.io/storj/storagenode"
+	"storj.io/storj/storagenode/contact"
 	"storj.io/storj/storagenode/orders"
 	"storj.io/storj/storagenode/piecestore"
 	"storj.io/storj/storagenode/storagenodedb"
@@ -76,9 +78,22 @@ type Config struct {
 
 	Identities      *testidentity.Identities
 	IdentityVersion *storj.IDVersion
-	Reconfigure     Reconfigure
+	// IdentityVersions mixes identities of several versions, the peers take
+	// them in turn and accept each other. IdentityVersion defaults to the
+	// first of them.
+	IdentityVersions []storj.IDVersion
+	Reconfigure      Reconfigure
+
+	// Host is the IP address all peers listen on, defaults to 127.0.0.1.
+	Host string
+	// StartConcurrency is how many peers Start starts at once, defaults to
+	// defaultStartConcurrency. 1 starts them one after another.
+	StartConcurrency int
 }
 
+// defaultStartConcurrency is the default of Config.StartConcurrency.
+const defaultStartConcurrency = 8
+
 // Planet is a full storj system setup.
 type Planet struct {
 	log       *zap.Logger
@@ -88,10 +103,15 @@ type Planet struct {
 	started  bool
 	shutdown bool
 
-	peers     []closablePeer
+	peers     []*closablePeer
 	databases []io.Closer
 	uplinks   []*Uplink
 
+	// mu guards peers and StorageNodes while Chaos restarts storage nodes
+	mu sync.Mutex
+	// storageNodeSetups keeps what restarting a storage node needs, by index
+	storageNodeSetups []storageNodeSetup
+
 	Bootstrap      *bootstrap.Peer
 	VersionControl *versioncontrol.Peer
 	Satellites     []*satellite.Peer
@@ -102,10 +122,12 @@ type Planet struct {
 	whitelistPath string // TODO: in-memory
 
 	run    errgroup.Group
+	ctx    context.Context
 	cancel func()
 }
 
 type closablePeer struct {
+	name string
 	peer Peer
 
 	ctx    context.Context
@@ -117,7 +139,10 @@ type closablePeer struct {
 
 // Close closes safely the peer.
 func (peer *closablePeer) Close() error {
-	peer.cancel()
+	if peer.cancel != nil {
+		// the peer was started
+		peer.cancel()
+	}
 	peer.close.Do(func() {
 		peer.err = peer.peer.Close()
 	})
@@ -162,15 +187,63 @@ func NewWithLogger(log *zap.Logger, satelliteCount, storageNodeCount, uplinkCoun
 	})
 }
 
+// spareIdentities is how many identities of a mixed planet are left for tests
+const spareIdentities = 4
+
+// newMixedIdentities returns identities of each of config.IdentityVersions in
+// turn. Versions without pregenerated identities get generated ones, signed
+// by the pregenerated signer of config.IdentityVersion so that the whitelist
+// accepts them.
+func newMixedIdentities(config Config) (*testidentity.Identities, error) {
+	signer := testidentity.NewPregeneratedSigner(*config.IdentityVersion)
+	if signer == nil {
+		return nil, fmt.Errorf("no pregenerated signer for identity version %d", config.IdentityVersion.Number)
+	}
+
+	// the satellites, storage nodes, uplinks and the bootstrap node
+	peers := config.SatelliteCount + config.StorageNodeCount + config.UplinkCount + 1 + spareIdentities
+	count := (peers + len(config.IdentityVersions) - 1) / len(config.IdentityVersions)
+
+	tables := make([]*testidentity.Identities, 0, len(config.IdentityVersions))
+	for _, version := range config.IdentityVersions {
+		if pregenerated, ok := testidentity.SignedIdentityVersions[version.Number]; ok {
+			tables = append(tables, pregenerated.Clone())
+			continue
+		}
+		generated, err := testidentity.NewSignedIdentities(context.Background(), version, signer, count)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, generated)
+	}
+	return testidentity.Interleaved(tables...), nil
+}
+
 // NewCustom creates a new full system with the specified configuration.
 func NewCustom(log *zap.Logger, config Config) (*Planet, error) {
 	if config.IdentityVersion == nil {
 		version := storj.LatestIDVersion()
+		if len(config.IdentityVersions) > 0 {
+			version = config.IdentityVersions[0]
+		}
 		config.IdentityVersion = &version
 	}
+	if config.Identities == nil && len(config.IdentityVersions) > 0 {
+		identities, err := newMixedIdentities(config)
+		if err != nil {
+			return nil, err
+		}
+		config.Identities = identities
+	}
 	if config.Identities == nil {
 		config.Identities = testidentity.NewPregeneratedSignedIdentities(*config.IdentityVersion)
 	}
+	if config.Host == "" {
+		config.Host = "127.0.0.1"
+	}
+	if config.StartConcurrency <= 0 {
+		config.StartConcurrency = defaultStartConcurrency
+	}
 
 	planet := &Planet{
 		log:        log,
@@ -236,36 +309,101 @@ func NewCustom(log *zap.Logger, config Config) (*Planet, error) {
 	return planet, nil
 }
 
-// Start starts all the nodes.
+// Start starts all the nodes in the order they depend on each other: the
+// version control server and the bootstrap node, then the satellites, then
+// the storage nodes. The peers of a stage start concurrently, at most
+// Config.StartConcurrency at a time, and each stage waits for the
+// bootstrapping of the previous one.
+//
+// A peer failing cancels the others, Shutdown returns its error.
 func (planet *Planet) Start(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
-	planet.cancel = cancel
+	planet.ctx, planet.cancel = ctx, cancel
+	planet.started = true
 
 	planet.run.Go(func() error {
-		return planet.VersionControl.Run(ctx)
+		err := planet.VersionControl.Run(ctx)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("versioncontrol: %v", err)
+		}
+		return nil
 	})
 
-	for i := range planet.peers {
-		peer := &planet.peers[i]
-		peer.ctx, peer.cancel = context.WithCancel(ctx)
-		planet.run.Go(func() error {
-			return peer.peer.Run(peer.ctx)
-		})
+	stages := [][]*closablePeer{nil, nil, nil}
+	for _, peer := range planet.peers {
+		switch peer.peer.(type) {
+		case *bootstrap.Peer:
+			stages[0] = append(stages[0], peer)
+		case *satellite.Peer:
+			stages[1] = append(stages[1], peer)
+		default:
+			stages[2] = append(stages[2], peer)
+		}
 	}
 
-	planet.started = true
+	for _, stage := range stages {
+		if err := planet.startPeers(ctx, stage); err != nil {
+			planet.log.Error("start failed", zap.Error(err))
+			return
+		}
+	}
 
-	planet.Bootstrap.Kademlia.Service.WaitForBootstrap()
+	planet.Reconnect(ctx)
+}
 
-	for _, peer := range planet.StorageNodes {
-		peer.Kademlia.Service.WaitForBootstrap()
-	}
+// startPeers starts peers concurrently and waits for them to bootstrap.
+func (planet *Planet) startPeers(ctx context.Context, peers []*closablePeer) error {
+	limit := make(chan struct{}, planet.config.StartConcurrency)
 
-	for _, peer := range planet.Satellites {
-		peer.Kademlia.Service.WaitForBootstrap()
+	var group errgroup.Group
+	for _, peer := range peers {
+		peer := peer
+		group.Go(func() error {
+			select {
+			case limit <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-limit }()
+
+			return planet.startPeer(ctx, peer)
+		})
 	}
+	return group.Wait()
+}
 
-	planet.Reconnect(ctx)
+// startPeer starts peer and waits for it to bootstrap. ctx is canceled when
+// any of the peers fails.
+func (planet *Planet) startPeer(ctx context.Context, peer *closablePeer) error {
+	peer.ctx, peer.cancel = context.WithCancel(ctx)
+	planet.run.Go(func() error {
+		err := peer.peer.Run(peer.ctx)
+		if err != nil {
+			if peer.ctx.Err() == nil {
+				// the peer failed, rather than being stopped
+				planet.cancel()
+			}
+			return fmt.Errorf("%s: %v", peer.name, err)
+		}
+		return nil
+	})
+
+	var service *kademlia.Kademlia
+	switch peer := peer.peer.(type) {
+	case *bootstrap.Peer:
+		service = peer.Kademlia.Service
+	case *satellite.Peer:
+		service = peer.Kademlia.Service
+	case *storagenode.Peer:
+		service = peer.Kademlia.Service
+	default:
+		return nil
+	}
+	if err := service.WaitForBootstrapContext(ctx); err != nil {
+		return fmt.Errorf("%s did not bootstrap: %v", peer.name, err)
+	}
+	return nil
 }
 
 // Reconnect reconnects all nodes with each other.
@@ -280,7 +418,7 @@ func (planet *Planet) Reconnect(ctx context.Context) {
 	for _, storageNode := range planet.StorageNodes {
 		storageNode := storageNode
 		group.Go(func() error {
-			_, err := storageNode.Kademlia.Service.Ping(ctx, planet.Bootstrap.Local().Node)
+			err := planet.WaitForContact(ctx, storageNode.Kademlia.Service, planet.Bootstrap)
 			if err != nil {
 				log.Error("storage node did not find bootstrap", zap.Error(err))
 			}
@@ -292,7 +430,7 @@ func (planet *Planet) Reconnect(ctx context.Context) {
 		satellite := satellite
 		group.Go(func() error {
 			for _, storageNode := range planet.StorageNodes {
-				_, err := satellite.Kademlia.Service.Ping(ctx, storageNode.Local().Node)
+				err := planet.WaitForContact(ctx, satellite.Kademlia.Service, storageNode)
 				if err != nil {
 					log.Error("satellite did not find storage node", zap.Error(err))
 				}
@@ -304,15 +442,36 @@ func (planet *Planet) Reconnect(ctx context.Context) {
 	_ = group.Wait() // none of the goroutines return an error
 }
 
+// reconnectAttempts is the number of pings WaitForContact makes
+const reconnectAttempts = 5
+
+// WaitForContact waits until from reaches to, giving up after a few pings.
+func (planet *Planet) WaitForContact(ctx context.Context, from *kademlia.Kademlia, to Peer) error {
+	_, _, err := kademlia.WaitForNode(ctx, from.Dialer(), to.Local().Node, kademlia.WaitOptions{
+		MaxAttempts: reconnectAttempts,
+	})
+	return err
+}
+
 // StopPeer stops a single peer in the planet
 func (planet *Planet) StopPeer(peer Peer) error {
-	for i := range planet.peers {
-		p := &planet.peers[i]
+	p := planet.findPeer(peer)
+	if p == nil {
+		return errors.New("unknown peer")
+	}
+	return p.Close()
+}
+
+// findPeer returns the closable peer of peer, nil when it's unknown.
+func (planet *Planet) findPeer(peer Peer) *closablePeer {
+	planet.mu.Lock()
+	defer planet.mu.Unlock()
+	for _, p := range planet.peers {
 		if p.peer == peer {
-			return p.Close()
+			return p
 		}
 	}
-	return errors.New("unknown peer")
+	return nil
 }
 
 // Size returns number of nodes in the network
@@ -352,8 +511,7 @@ func (planet *Planet) Shutdown() error {
 		errlist.Add(node.Shutdown())
 	}
 	for i := len(planet.peers) - 1; i >= 0; i-- {
-		peer := &planet.peers[i]
-		errlist.Add(peer.Close())
+		errlist.Add(planet.peers[i].Close())
 	}
 	for _, db := range planet.databases {
 		errlist.Add(db.Close())
@@ -383,8 +541,8 @@ func (planet *Planet) newSatellites(count int) ([]*satellite.Peer, error) {
 	// TODO: move into separate file
 	var xs []*satellite.Peer
 	defer func() {
-		for _, x := range xs {
-			planet.peers = append(planet.peers, closablePeer{peer: x})
+		for i, x := range xs {
+			planet.peers = append(planet.peers, &closablePeer{name: "satellite" + strconv.Itoa(i), peer: x})
 		}
 	}()
 
@@ -421,14 +579,14 @@ func (planet *Planet) newSatellites(count int) ([]*satellite.Peer, error) {
 
 		config := satellite.Config{
 			Server: server.Config{
-				Address:        "127.0.0.1:0",
-				PrivateAddress: "127.0.0.1:0",
+				Address:        planet.address(),
+				PrivateAddress: planet.address(),
 
 				Config: tlsopts.Config{
 					RevocationDBURL:     "bolt://" + filepath.Join(storageDir, "revocation.db"),
 					UsePeerCAWhitelist:  true,
 					PeerCAWhitelistPath: planet.whitelistPath,
-					PeerIDVersions:      "latest",
+					PeerIDVersions:      planet.peerIDVersions("latest"),
 					Extensions: extensions.Config{
 						Revocation:          false,
 						WhitelistSignedLeaf: false,
@@ -439,6 +597,7 @@ func (planet *Planet) newSatellites(count int) ([]*satellite.Peer, error) {
 				Alpha:                5,
 				BootstrapBackoffBase: 500 * time.Millisecond,
 				BootstrapBackoffMax:  2 * time.Second,
+				Dialer:               kademlia.DialerOptions{AllowPrivateAddresses: true},
 				DBPath:               storageDir, // TODO: replace with master db
 				Operator: kademlia.OperatorConfig{
 					Email:  prefix + "@example.com",
@@ -497,7 +656,7 @@ func (planet *Planet) newSatellites(count int) ([]*satellite.Peer, error) {
 				AuthType:          "simulate",
 			},
 			Console: consoleweb.Config{
-				Address:      "127.0.0.1:0",
+				Address:      planet.address(),
 				PasswordCost: console.TestPasswordCost,
 			},
 			Version: planet.NewVersionConfig(),
@@ -523,6 +682,7 @@ func (planet *Planet) newSatellites(count int) ([]*satellite.Peer, error) {
 		if err != nil {
 			return xs, err
 		}
+		serveConfig(peer.Server, config.Identity, config.Server, config.Kademlia)
 
 		log.Debug("id=" + peer.ID().String() + " addr=" + peer.Addr())
 		xs = append(xs, peer)
@@ -535,8 +695,8 @@ func (planet *Planet) newStorageNodes(count int, whitelistedSatelliteIDs []strin
 	// TODO: move into separate file
 	var xs []*storagenode.Peer
 	defer func() {
-		for _, x := range xs {
-			planet.peers = append(planet.peers, closablePeer{peer: x})
+		for i, x := range xs {
+			planet.peers = append(planet.peers, &closablePeer{name: "storage" + strconv.Itoa(i), peer: x})
 		}
 	}()
 
@@ -573,14 +733,14 @@ func (planet *Planet) newStorageNodes(count int, whitelistedSatelliteIDs []strin
 
 		config := storagenode.Config{
 			Server: server.Config{
-				Address:        "127.0.0.1:0",
-				PrivateAddress: "127.0.0.1:0",
+				Address:        planet.address(),
+				PrivateAddress: planet.address(),
 
 				Config: tlsopts.Config{
 					RevocationDBURL:     "bolt://" + filepath.Join(storageDir, "revocation.db"),
 					UsePeerCAWhitelist:  true,
 					PeerCAWhitelistPath: planet.whitelistPath,
-					PeerIDVersions:      "*",
+					PeerIDVersions:      planet.peerIDVersions("*"),
 					Extensions: extensions.Config{
 						Revocation:          false,
 						WhitelistSignedLeaf: false,
@@ -591,6 +751,7 @@ func (planet *Planet) newStorageNodes(count int, whitelistedSatelliteIDs []strin
 				BootstrapBackoffBase: 500 * time.Millisecond,
 				BootstrapBackoffMax:  2 * time.Second,
 				Alpha:                5,
+				Dialer:               kademlia.DialerOptions{AllowPrivateAddresses: true},
 				DBPath:               storageDir, // TODO: replace with master db
 				Operator: kademlia.OperatorConfig{
 					Email:  prefix + "@example.com",
@@ -615,15 +776,25 @@ func (planet *Planet) newStorageNodes(count int, whitelistedSatelliteIDs []strin
 					Timeout:  time.Hour,
 				},
 			},
+			Contact: contact.Config{
+				Interval:    time.Hour,
+				Jitter:      0,
+				BackoffBase: time.Second,
+				BackoffMax:  time.Minute,
+				Timeout:     10 * time.Second,
+
+				RefreshInterval: time.Second,
+			},
 			Version: planet.NewVersionConfig(),
 		}
 		if planet.config.Reconfigure.StorageNode != nil {
 			planet.config.Reconfigure.StorageNode(i, &config)
 		}
 
-		verInfo := planet.NewVersionInfo()
+		setup := storageNodeSetup{log: log, identity: identity, db: db, config: config}
+		planet.storageNodeSetups = append(planet.storageNodeSetups, setup)
 
-		peer, err := storagenode.New(log, identity, db, config, verInfo)
+		peer, err := planet.newStorageNode(setup)
 		if err != nil {
 			return xs, err
 		}
@@ -634,11 +805,39 @@ func (planet *Planet) newStorageNodes(count int, whitelistedSatelliteIDs []strin
 	return xs, nil
 }
 
+// storageNodeSetup is what a storage node is created from.
+type storageNodeSetup struct {
+	log      *zap.Logger
+	identity *identity.FullIdentity
+	db       storagenode.DB
+	config   storagenode.Config
+}
+
+// newStorageNode creates a storage node from setup.
+func (planet *Planet) newStorageNode(setup storageNodeSetup) (*storagenode.Peer, error) {
+	peer, err := storagenode.New(setup.log, setup.identity, setup.db, setup.config, planet.NewVersionInfo())
+	if err != nil {
+		return nil, err
+	}
+	serveConfig(peer.Server, setup.config.Identity, setup.config.Server, setup.config.Kademlia)
+	return peer, nil
+}
+
+// serveConfig serves the effective configuration of a peer on its private
+// listener, like the commands do.
+func serveConfig(peer *server.Server, identityConfig identity.Config, serverConfig server.Config, kademliaConfig kademlia.Config) {
+	peer.PrivateHTTP().Handle("/debug/config", cfgstruct.DescribeHandler(map[string]interface{}{
+		"identity": identityConfig,
+		"server":   serverConfig,
+		"kademlia": kademliaConfig,
+	}))
+}
+
 // newBootstrap initializes the bootstrap node
 func (planet *Planet) newBootstrap() (peer *bootstrap.Peer, err error) {
 	// TODO: move into separate file
 	defer func() {
-		planet.peers = append(planet.peers, closablePeer{peer: peer})
+		planet.peers = append(planet.peers, &closablePeer{name: "bootstrap", peer: peer})
 	}()
 
 	prefix := "bootstrap"
@@ -670,14 +869,14 @@ func (planet *Planet) newBootstrap() (peer *bootstrap.Peer, err error) {
 
 	config := bootstrap.Config{
 		Server: server.Config{
-			Address:        "127.0.0.1:0",
-			PrivateAddress: "127.0.0.1:0",
+			Address:        planet.address(),
+			PrivateAddress: planet.address(),
 
 			Config: tlsopts.Config{
 				RevocationDBURL:     "bolt://" + filepath.Join(dbDir, "revocation.db"),
 				UsePeerCAWhitelist:  true,
 				PeerCAWhitelistPath: planet.whitelistPath,
-				PeerIDVersions:      "latest",
+				PeerIDVersions:      planet.peerIDVersions("latest"),
 				Extensions: extensions.Config{
 					Revocation:          false,
 					WhitelistSignedLeaf: false,
@@ -688,6 +887,7 @@ func (planet *Planet) newBootstrap() (peer *bootstrap.Peer, err error) {
 			BootstrapBackoffBase: 500 * time.Millisecond,
 			BootstrapBackoffMax:  2 * time.Second,
 			Alpha:                5,
+			Dialer:               kademlia.DialerOptions{AllowPrivateAddresses: true},
 			DBPath:               dbDir, // TODO: replace with master db
 			Operator: kademlia.OperatorConfig{
 				Email:  prefix + "@example.com",
@@ -695,7 +895,7 @@ func (planet *Planet) newBootstrap() (peer *bootstrap.Peer, err error) {
 			},
 		},
 		Web: bootstrapserver.Config{
-			Address:   "127.0.0.1:0",
+			Address:   planet.address(),
 			StaticDir: "./web/bootstrap", // TODO: for development only
 		},
 		Version: planet.NewVersionConfig(),
@@ -711,6 +911,7 @@ func (planet *Planet) newBootstrap() (peer *bootstrap.Peer, err error) {
 	if err != nil {
 		return nil, err
 	}
+	serveConfig(peer.Server, config.Identity, config.Server, config.Kademlia)
 
 	log.Debug("id=" + peer.ID().String() + " addr=" + peer.Addr())
 
@@ -729,7 +930,7 @@ func (planet *Planet) newVersionControlServer() (peer *versioncontrol.Peer, err
 	}
 
 	config := &versioncontrol.Config{
-		Address: "127.0.0.1:0",
+		Address: planet.address(),
 		Versions: versioncontrol.ServiceVersions{
 			Bootstrap:   "v0.0.1",
 			Satellite:   "v0.0.1",
@@ -737,6 +938,7 @@ func (planet *Planet) newVersionControlServer() (peer *versioncontrol.Peer, err
 			Uplink:      "v0.0.1",
 			Gateway:     "v0.0.1",
 		},
+		Features: "kademlia.query-stream=true",
 	}
 	peer, err = versioncontrol.New(log, config)
 	if err != nil {
@@ -749,15 +951,13 @@ func (planet *Planet) newVersionControlServer() (peer *versioncontrol.Peer, err
 }
 
 // NewVersionInfo returns the Version Info for this planet with tuned metrics.
+// Like binaries built without linker flags, the peers are development builds
+// of an unknown version.
 func (planet *Planet) NewVersionInfo() version.Info {
 	info := version.Info{
 		Timestamp:  time.Now(),
 		CommitHash: "testplanet",
-		Version: version.SemVer{
-			Major: 0,
-			Minor: 0,
-			Patch: 1},
-		Release: false,
+		Release:    false,
 	}
 	return info
 }
@@ -768,6 +968,8 @@ func (planet *Planet) NewVersionConfig() version.Config {
 		ServerAddress:  fmt.Sprintf("http://%s/", planet.VersionControl.Addr()),
 		RequestTimeout: time.Second * 15,
 		CheckInterval:  time.Minute * 5,
+		// resolves the features enabled by the version control server
+		CheckDevBuilds: true,
 	}
 }
 
@@ -781,9 +983,27 @@ func (planet *Planet) NewIdentity() (*identity.FullIdentity, error) {
 	return planet.identities.NewIdentity()
 }
 
+// peerIDVersions returns versions, or the versions of all the identities of
+// a mixed planet, as the identity versions peers accept.
+func (planet *Planet) peerIDVersions(versions string) string {
+	if len(planet.config.IdentityVersions) == 0 {
+		return versions
+	}
+	numbers := make([]string, 0, len(planet.config.IdentityVersions))
+	for _, version := range planet.config.IdentityVersions {
+		numbers = append(numbers, strconv.Itoa(int(version.Number)))
+	}
+	return strings.Join(numbers, ",")
+}
+
 // NewListener creates a new listener
 func (planet *Planet) NewListener() (net.Listener, error) {
-	return net.Listen("tcp", "127.0.0.1:0")
+	return net.Listen("tcp", planet.address())
+}
+
+// address returns an address with a random port on the planet host.
+func (planet *Planet) address() string {
+	return net.JoinHostPort(planet.config.Host, "0")
 }
 
 // WriteWhitelist writes the pregenerated signer's CA cert to a "CA whitelist", PEM-encoded.
@@ -6,10 +6,16 @@ package testplanet_test
 import (
 	"context"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/testplanet"
@@ -78,3 +84,105 @@ func BenchmarkCreate(b *testing.B) {
 		})
 	}
 }
+
+func TestShutdownWithoutTransportErrors(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	planet, err := testplanet.NewWithLogger(zap.New(core), 1, 4, 0)
+	require.NoError(t, err)
+
+	planet.Start(ctx)
+
+	// keep kademlia busy while shutting down, each node has a lookup in
+	// progress until its kademlia refuses new ones
+	var busy sync.WaitGroup
+	for _, node := range planet.StorageNodes {
+		node := node
+		busy.Add(1)
+		ctx.Go(func() error {
+			first := true
+			for {
+				_, err := node.Kademlia.Service.FindNode(ctx, planet.Satellites[0].ID())
+				if first {
+					busy.Done()
+					first = false
+				}
+				if err == context.Canceled {
+					return nil
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+	busy.Wait()
+
+	started := logs.Len()
+	require.NoError(t, planet.Shutdown())
+
+	for _, entry := range logs.All()[started:] {
+		message := entry.Message
+		for _, field := range entry.Context {
+			if field.Type == zapcore.ErrorType {
+				message += " " + field.Interface.(error).Error()
+			}
+		}
+		if strings.Contains(message, "transport is closing") || strings.Contains(message, "connection closed") {
+			t.Errorf("%s: %s", entry.LoggerName, message)
+		}
+		require.True(t, entry.Level < zapcore.ErrorLevel, "%s: %s", entry.LoggerName, message)
+	}
+}
+
+func TestStartConcurrently(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts two large planets")
+	}
+
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	start := func(concurrency int) time.Duration {
+		planet, err := testplanet.NewCustom(zaptest.NewLogger(t), testplanet.Config{
+			SatelliteCount: 1, StorageNodeCount: 15, UplinkCount: 0,
+			StartConcurrency: concurrency,
+		})
+		require.NoError(t, err)
+		defer ctx.Check(planet.Shutdown)
+
+		started := time.Now()
+		planet.Start(ctx)
+		elapsed := time.Since(started)
+
+		for _, node := range planet.StorageNodes {
+			_, err := planet.Satellites[0].Kademlia.Service.Ping(ctx, node.Local().Node)
+			require.NoError(t, err)
+		}
+		return elapsed
+	}
+
+	sequential := start(1)
+	concurrent := start(16)
+	t.Logf("sequential %v, concurrent %v", sequential, concurrent)
+	// loose, so that a slow machine doesn't fail the test
+	require.True(t, concurrent < sequential*3/4, "sequential %v, concurrent %v", sequential, concurrent)
+}
+
+func TestShutdownAfterFailedStart(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.NewCustom(zaptest.NewLogger(t), testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
+		StartConcurrency: 1,
+	})
+	require.NoError(t, err)
+
+	// the start is canceled before all the peers are started
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	planet.Start(canceled)
+
+	require.NoError(t, planet.Shutdown())
+}
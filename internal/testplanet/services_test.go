@@ -0,0 +1,98 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information
+
+package testplanet_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/lifecycle"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/storagenode"
+)
+
+// waitForState waits until the service called name of node is in state,
+// after restarts restarts.
+func waitForState(t *testing.T, node *storagenode.Peer, name string, state lifecycle.State, restarts int) lifecycle.Status {
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		status, ok := node.Services.Status(name)
+		require.True(t, ok)
+		if status.State == state && status.Restarts >= restarts {
+			return status
+		}
+	}
+	t.Fatalf("service %q of %s did not reach state %q", name, node.ID(), state)
+	return lifecycle.Status{}
+}
+
+func TestServiceTransientFailure(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.New(t, 1, 1, 0)
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	node := planet.StorageNodes[0]
+	node.Services.Restart = backoff.Constant{Interval: time.Millisecond}
+	var runs int32
+	node.Services.Add("rigged", lifecycle.Func(func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1) <= 3 {
+			return lifecycle.Transient.New("rigged failure")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	planet.Start(ctx)
+
+	status := waitForState(t, node, "rigged", lifecycle.Running, 3)
+	assert.Equal(t, 3, status.Restarts)
+	assert.Equal(t, "transient: rigged failure", status.LastError)
+
+	// the node stayed up
+	_, err = planet.Satellites[0].Kademlia.Service.Ping(ctx, node.Local().Node)
+	require.NoError(t, err)
+	kademlia, ok := node.Services.Status("kademlia")
+	require.True(t, ok)
+	assert.Equal(t, lifecycle.Running, kademlia.State)
+	assert.Equal(t, 0, kademlia.Restarts)
+}
+
+func TestServiceFatalFailure(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.New(t, 1, 1, 0)
+	require.NoError(t, err)
+
+	node := planet.StorageNodes[0]
+	fail := make(chan struct{})
+	node.Services.Add("rigged", lifecycle.Func(func(ctx context.Context) error {
+		select {
+		case <-fail:
+			return errors.New("rigged failure")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}))
+
+	planet.Start(ctx)
+	close(fail)
+	waitForState(t, node, "rigged", lifecycle.Failed, 0)
+	// the failure stopped the node, which stopped its other services
+	waitForState(t, node, "kademlia", lifecycle.Stopped, 0)
+
+	err = planet.Shutdown()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage0: rigged failure")
+}
@@ -16,7 +16,6 @@ import (
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
 
-	"storj.io/storj/pkg/auth/signing"
 	"storj.io/storj/pkg/cfgstruct"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/pb"
@@ -50,7 +49,7 @@ func (planet *Planet) newUplink(name string, storageNodeCount int) (*Uplink, err
 	}
 
 	tlsOpts, err := tlsopts.NewOptions(identity, tlsopts.Config{
-		PeerIDVersions: strconv.Itoa(int(planet.config.IdentityVersion.Number)),
+		PeerIDVersions: planet.peerIDVersions(strconv.Itoa(int(planet.config.IdentityVersion.Number))),
 	})
 	if err != nil {
 		return nil, err
@@ -136,15 +135,7 @@ func (uplink *Uplink) DialMetainfo(ctx context.Context, destination Peer, apikey
 // DialPiecestore dials destination storagenode and returns a piecestore client.
 func (uplink *Uplink) DialPiecestore(ctx context.Context, destination Peer) (*piecestore.Client, error) {
 	node := destination.Local()
-
-	conn, err := uplink.Transport.DialNode(ctx, &node.Node)
-	if err != nil {
-		return nil, err
-	}
-
-	signer := signing.SignerFromFullIdentity(uplink.Transport.Identity())
-
-	return piecestore.NewClient(uplink.Log.Named("uplink>piecestore"), signer, conn, piecestore.DefaultConfig), nil
+	return piecestore.Dial(ctx, uplink.Transport, &node.Node, uplink.Log.Named("uplink>piecestore"), piecestore.DefaultConfig)
 }
 
 // Upload data to specific satellite
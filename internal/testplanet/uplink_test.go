@@ -13,6 +13,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
 	"golang.org/x/sync/errgroup"
 
 	"storj.io/storj/internal/memory"
@@ -278,7 +279,7 @@ func TestDownloadFromUnresponsiveNode(t *testing.T) {
 				})
 				require.NoError(t, err)
 
-				server, err := server.New(options, storageNode.Addr(), storageNode.PrivateAddr(), nil)
+				server, err := server.New(zaptest.NewLogger(t), options, storageNode.Addr(), storageNode.PrivateAddr(), nil)
 				require.NoError(t, err)
 				pb.RegisterPiecestoreServer(server.GRPC(), &piecestoreMock{})
 				go func() {
@@ -0,0 +1,420 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package transporttest defines the behavior a transport.Client must have to
+// be used in place of the TCP transport.
+//
+// A transport is compatible when it passes Run:
+//
+//	func TestConformance(t *testing.T) { transporttest.Run(t, quic.NewClient) }
+package transporttest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/extensions"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storagenode"
+	"storj.io/storj/uplink/piecestore"
+)
+
+// NewClient creates the transport under test, dialing with tlsOpts and
+// notifying obs about every dial.
+type NewClient func(tlsOpts *tlsopts.Options, obs ...transport.Observer) transport.Client
+
+const (
+	// dialTimeout is the time a dial to a responsive node may take
+	dialTimeout = 5 * time.Second
+	// slowHandshake is the handshake latency of the unresponsive node
+	slowHandshake = 3 * time.Second
+	// concurrentDials is the number of dials made at the same time
+	concurrentDials = 16
+)
+
+// Run runs the conformance suite against the transports created by newClient.
+//
+// The suite starts storage nodes using the TCP transport, so a transport
+// must be able to reach them, and uses the identity of one of them for the
+// transport under test.
+func Run(t *testing.T, newClient NewClient) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	slow := &transport.SimulatedNetwork{HandshakeLatency: slowHandshake}
+	planet, err := testplanet.NewCustom(zaptest.NewLogger(t), testplanet.Config{
+		SatelliteCount: 0, StorageNodeCount: 3, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				if index == 2 {
+					config.Server.WrapListener = slow.NewListener
+				}
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	whitelistPath, err := planet.WriteWhitelist(storj.LatestIDVersion())
+	require.NoError(t, err)
+
+	planet.Start(ctx)
+
+	self, target, slowNode := planet.StorageNodes[0], planet.StorageNodes[1], planet.StorageNodes[2]
+
+	tlsOpts, err := tlsopts.NewOptions(self.Identity, tlsopts.Config{
+		UsePeerCAWhitelist:  true,
+		PeerCAWhitelistPath: whitelistPath,
+		PeerIDVersions:      "*",
+	})
+	require.NoError(t, err)
+
+	suite := &suite{
+		ctx:       ctx,
+		newClient: newClient,
+		tlsOpts:   tlsOpts,
+		self:      self.Local().Node,
+		target:    target.Local().Node,
+		slow:      slowNode.Local().Node,
+	}
+
+	t.Run("Identity", suite.testIdentity)
+	t.Run("InvalidTarget", suite.testInvalidTarget)
+	t.Run("Timeout", suite.testTimeout)
+	t.Run("Observers", suite.testObservers)
+	t.Run("Close", suite.testClose)
+	t.Run("Concurrent", suite.testConcurrent)
+	t.Run("KademliaDialer", suite.testKademliaDialer)
+	t.Run("RejectedPeers", suite.testRejectedPeers)
+}
+
+// suite holds the network used by the conformance tests.
+type suite struct {
+	ctx       *testcontext.Context
+	newClient NewClient
+	tlsOpts   *tlsopts.Options
+
+	self   pb.Node
+	target pb.Node
+	slow   pb.Node
+}
+
+// dial dials node with the dial timeout.
+func (suite *suite) dial(client transport.Client, node pb.Node) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(suite.ctx, dialTimeout)
+	defer cancel()
+	return client.DialNode(ctx, &node)
+}
+
+// ping pings the node on conn.
+func (suite *suite) ping(conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(suite.ctx, dialTimeout)
+	defer cancel()
+	_, err := pb.NewNodesClient(conn).Ping(ctx, &pb.PingRequest{})
+	return err
+}
+
+// testIdentity verifies that only the node with the requested ID is connected to.
+func (suite *suite) testIdentity(t *testing.T) {
+	client := suite.newClient(suite.tlsOpts)
+	assert.Equal(t, suite.self.Id, client.Identity().ID)
+
+	conn, err := suite.dial(client, suite.target)
+	require.NoError(t, err)
+	require.NoError(t, suite.ping(conn))
+	require.NoError(t, conn.Close())
+
+	// the address of target with another ID
+	impostor := suite.target
+	impostor.Id = storj.NodeID{123}
+	conn, err = suite.dial(client, impostor)
+	assert.Error(t, err)
+	assert.Nil(t, conn)
+
+	// dialing by address doesn't verify the ID
+	ctx, cancel := context.WithTimeout(suite.ctx, dialTimeout)
+	defer cancel()
+	conn, err = client.DialAddress(ctx, suite.target.Address.Address)
+	require.NoError(t, err)
+	require.NoError(t, suite.ping(conn))
+	require.NoError(t, conn.Close())
+}
+
+// testInvalidTarget verifies that targets without an address fail without dialing.
+func (suite *suite) testInvalidTarget(t *testing.T) {
+	client := suite.newClient(suite.tlsOpts)
+
+	for _, target := range []pb.Node{
+		{Id: suite.target.Id},
+		{Id: suite.target.Id, Address: &pb.NodeAddress{Transport: pb.NodeTransport_TCP_TLS_GRPC}},
+	} {
+		conn, err := suite.dial(client, target)
+		assert.Error(t, err)
+		assert.Nil(t, conn)
+	}
+}
+
+// testTimeout verifies that failed dials are classified by their cause.
+func (suite *suite) testTimeout(t *testing.T) {
+	client := suite.newClient(suite.tlsOpts)
+
+	{ // the node accepts the connection, but doesn't complete the handshake in time
+		ctx, cancel := context.WithTimeout(suite.ctx, slowHandshake/4)
+		conn, err := client.DialNode(ctx, &suite.slow)
+		cancel()
+		require.Error(t, err)
+		assert.Nil(t, conn)
+		assert.Equal(t, errs2.DialTimeout, errs2.ClassifyDial(err), "%v", err)
+	}
+
+	{ // nothing listens on the address
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		closed := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		ctx, cancel := context.WithTimeout(suite.ctx, dialTimeout)
+		conn, err := client.DialNode(ctx, &pb.Node{Id: suite.target.Id, Address: &pb.NodeAddress{Address: closed}})
+		cancel()
+		require.Error(t, err)
+		assert.Nil(t, conn)
+		assert.Equal(t, errs2.DialRefused, errs2.ClassifyDial(err), "%v", err)
+	}
+
+	{ // the dial is canceled
+		ctx, cancel := context.WithCancel(suite.ctx)
+		cancel()
+		conn, err := client.DialNode(ctx, &suite.target)
+		require.Error(t, err)
+		assert.Nil(t, conn)
+		assert.Equal(t, errs2.DialCanceled, errs2.ClassifyDial(err), "%v", err)
+	}
+}
+
+// testObservers verifies that observers are told about the outcome of dials to nodes.
+func (suite *suite) testObservers(t *testing.T) {
+	constructed, added := &observer{}, &observer{}
+	client := suite.newClient(suite.tlsOpts, constructed).WithObservers(added)
+
+	conn, err := suite.dial(client, suite.target)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	impostor := suite.target
+	impostor.Id = storj.NodeID{123}
+	_, err = suite.dial(client, impostor)
+	require.Error(t, err)
+
+	for _, obs := range []*observer{constructed, added} {
+		assert.Equal(t, []storj.NodeID{suite.target.Id}, obs.successes())
+		assert.Equal(t, []storj.NodeID{impostor.Id}, obs.failures())
+	}
+
+	// adding observers doesn't change the original client
+	original, unused := &observer{}, &observer{}
+	client = suite.newClient(suite.tlsOpts, original)
+	_ = client.WithObservers(unused)
+	conn, err = suite.dial(client, suite.target)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	assert.Len(t, original.successes(), 1)
+	assert.Empty(t, unused.successes())
+}
+
+// testClose verifies that closing a connection doesn't affect the client or other connections.
+func (suite *suite) testClose(t *testing.T) {
+	client := suite.newClient(suite.tlsOpts)
+
+	first, err := suite.dial(client, suite.target)
+	require.NoError(t, err)
+	second, err := suite.dial(client, suite.target)
+	require.NoError(t, err)
+
+	require.NoError(t, first.Close())
+	assert.Error(t, suite.ping(first), "closed connection must not be usable")
+	assert.Error(t, first.Close(), "closing twice must fail")
+
+	require.NoError(t, suite.ping(second))
+	require.NoError(t, second.Close())
+
+	third, err := suite.dial(client, suite.target)
+	require.NoError(t, err)
+	require.NoError(t, suite.ping(third))
+	require.NoError(t, third.Close())
+}
+
+// testConcurrent verifies that a client can be used for many dials at the same time.
+func (suite *suite) testConcurrent(t *testing.T) {
+	obs := &observer{}
+	client := suite.newClient(suite.tlsOpts, obs)
+
+	var group errgroup.Group
+	for i := 0; i < concurrentDials; i++ {
+		group.Go(func() error {
+			conn, err := suite.dial(client, suite.target)
+			if err != nil {
+				return err
+			}
+			if err := suite.ping(conn); err != nil {
+				_ = conn.Close()
+				return err
+			}
+			return conn.Close()
+		})
+	}
+	require.NoError(t, group.Wait())
+	assert.Len(t, obs.successes(), concurrentDials)
+}
+
+// testKademliaDialer verifies that the kademlia dialer works on top of the client.
+func (suite *suite) testKademliaDialer(t *testing.T) {
+	dialer := kademlia.NewDialer(zaptest.NewLogger(t), suite.newClient(suite.tlsOpts))
+	defer suite.ctx.Check(dialer.Close)
+
+	ok, err := dialer.PingNode(suite.ctx, suite.target)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ident, err := dialer.FetchPeerIdentity(suite.ctx, suite.target)
+	require.NoError(t, err)
+	assert.Equal(t, suite.target.Id, ident.ID)
+
+	ident, err = dialer.FetchPeerIdentityUnverified(suite.ctx, suite.target.Address.Address)
+	require.NoError(t, err)
+	assert.Equal(t, suite.target.Id, ident.ID)
+
+	info, err := dialer.FetchInfo(suite.ctx, suite.target)
+	require.NoError(t, err)
+	assert.Equal(t, pb.NodeType_STORAGE, info.Type)
+
+	_, err = dialer.Lookup(suite.ctx, suite.self, suite.target, suite.self)
+	require.NoError(t, err)
+}
+
+// testRejectedPeers verifies that the kademlia dialer and the piecestore
+// client reject the same peers that fail identity verification.
+func (suite *suite) testRejectedPeers(t *testing.T) {
+	revoked, revokedOpts, stop := suite.serveRevoked(t)
+	defer stop()
+
+	difficulty, err := suite.target.Id.Difficulty()
+	require.NoError(t, err)
+	difficultOpts, err := tlsopts.NewOptions(suite.tlsOpts.Ident, tlsopts.Config{
+		UsePeerCAWhitelist:  true,
+		PeerCAWhitelistPath: suite.tlsOpts.Config.PeerCAWhitelistPath,
+		PeerIDVersions:      "*",
+		PeerIDMinDifficulty: uint(difficulty) + 1,
+	})
+	require.NoError(t, err)
+
+	impostor := suite.target
+	impostor.Id = storj.NodeID{123}
+
+	for _, bad := range []struct {
+		name    string
+		tlsOpts *tlsopts.Options
+		target  pb.Node
+	}{
+		{"wrong ID", suite.tlsOpts, impostor},
+		{"revoked certificate", revokedOpts, revoked},
+		{"below difficulty", difficultOpts, suite.target},
+	} {
+		client := suite.newClient(bad.tlsOpts)
+
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), client)
+		_, err := dialer.FetchPeerIdentity(suite.ctx, bad.target)
+		assert.True(t, transport.IsVerificationError(err), "kademlia dialer accepted %s: %v", bad.name, err)
+		assert.Equal(t, kademlia.ErrorCodeIdentityMismatch, kademlia.ErrorCode(err), bad.name)
+		require.NoError(t, dialer.Close())
+
+		ctx, cancel := context.WithTimeout(suite.ctx, dialTimeout)
+		ps, err := piecestore.Dial(ctx, client, &bad.target, zaptest.NewLogger(t), piecestore.DefaultConfig)
+		cancel()
+		assert.True(t, transport.IsVerificationError(err), "piecestore client accepted %s: %v", bad.name, err)
+		assert.Nil(t, ps)
+	}
+}
+
+// serveRevoked starts a node whose CA certificate is revoked, and returns it
+// with the options of a client that knows about the revocation. stop stops
+// the node.
+func (suite *suite) serveRevoked(t *testing.T) (_ pb.Node, _ *tlsopts.Options, stop func()) {
+	ca, err := testidentity.NewTestCA(suite.ctx)
+	require.NoError(t, err)
+	ident, err := ca.NewIdentity()
+	require.NoError(t, err)
+	require.NoError(t, ca.Revoke())
+	ident.CA = ca.Cert
+
+	clientOpts, err := tlsopts.NewOptions(suite.tlsOpts.Ident, tlsopts.Config{
+		PeerIDVersions:  "*",
+		RevocationDBURL: "bolt://" + suite.ctx.File("revocations.db"),
+		Extensions:      extensions.Config{Revocation: true},
+	})
+	require.NoError(t, err)
+	revocation := tlsopts.NewExtensionsMap(ca.Cert)[extensions.RevocationExtID.String()]
+	require.NoError(t, clientOpts.RevDB.Put(ident.Chain(), revocation))
+
+	serverOpts, err := tlsopts.NewOptions(ident, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(serverOpts.ServerOption())
+	suite.ctx.Go(func() error { return server.Serve(listener) })
+
+	stop = func() {
+		server.Stop()
+		suite.ctx.Check(clientOpts.RevDB.Close)
+	}
+	return pb.Node{Id: ident.ID, Address: &pb.NodeAddress{Address: listener.Addr().String()}}, clientOpts, stop
+}
+
+// observer records the nodes of dial outcomes.
+type observer struct {
+	mu      sync.Mutex
+	success []storj.NodeID
+	failure []storj.NodeID
+}
+
+// ConnSuccess records a successful dial to node.
+func (obs *observer) ConnSuccess(ctx context.Context, node *pb.Node) {
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	obs.success = append(obs.success, node.Id)
+}
+
+// ConnFailure records a failed dial to node.
+func (obs *observer) ConnFailure(ctx context.Context, node *pb.Node, err error) {
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	obs.failure = append(obs.failure, node.Id)
+}
+
+func (obs *observer) successes() []storj.NodeID {
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	return append([]storj.NodeID(nil), obs.success...)
+}
+
+func (obs *observer) failures() []storj.NodeID {
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	return append([]storj.NodeID(nil), obs.failure...)
+}
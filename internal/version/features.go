@@ -0,0 +1,101 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/pkg/storj"
+)
+
+// Feature is whether a network-wide feature is enabled, either for every
+// node or rolled out to a percentage of them.
+type Feature struct {
+	Enabled bool
+	// Percent is the percentage of nodes the feature is rolled out to when
+	// it isn't enabled for every node
+	Percent int
+}
+
+// MarshalJSON encodes the feature as true, false or its percentage.
+func (feature Feature) MarshalJSON() ([]byte, error) {
+	if feature.Enabled || feature.Percent <= 0 {
+		return json.Marshal(feature.Enabled)
+	}
+	return json.Marshal(feature.Percent)
+}
+
+// UnmarshalJSON decodes true, false or a percentage between 0 and 100.
+func (feature *Feature) UnmarshalJSON(data []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		*feature = Feature{Enabled: enabled}
+		return nil
+	}
+	var percent int
+	if err := json.Unmarshal(data, &percent); err != nil {
+		return errs.New("feature must be a bool or a percentage, got %s", data)
+	}
+	if percent < 0 || percent > 100 {
+		return errs.New("feature percentage %d out of range", percent)
+	}
+	*feature = Feature{Enabled: percent == 100, Percent: percent}
+	return nil
+}
+
+// rolloutCursor returns the position of the node with id in the rollout of
+// the feature name, between 0 and 99. A rollout of p percent enables the
+// nodes whose cursor is below p, so growing the percentage only enables more
+// nodes, and every feature rolls out to a different set of nodes first.
+func rolloutCursor(name string, id storj.NodeID) int {
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(name))
+	_, _ = hash.Write(id[:])
+	return int(binary.BigEndian.Uint64(hash.Sum(nil)[:8]) % 100)
+}
+
+// Features are the network-wide features enabled by the version server, by
+// name. Features that aren't listed are disabled.
+type Features map[string]Feature
+
+// IsEnabled returns whether the feature name is enabled for the node with id.
+func (features Features) IsEnabled(name string, id storj.NodeID) bool {
+	feature, ok := features[name]
+	switch {
+	case !ok:
+		return false
+	case feature.Enabled:
+		return true
+	default:
+		return rolloutCursor(name, id) < feature.Percent
+	}
+}
+
+// ParseFeatures parses features separated by commas, as <name>=<value>, the
+// value being true, false or a percentage.
+func ParseFeatures(s string) (Features, error) {
+	features := Features{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errs.New("invalid feature %q, expected <name>=<value>", entry)
+		}
+
+		var feature Feature
+		if err := json.Unmarshal([]byte(parts[1]), &feature); err != nil {
+			return nil, errs.New("invalid feature %q: %v", entry, err)
+		}
+		features[parts[0]] = feature
+	}
+	return features, nil
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestParseFeatures(t *testing.T) {
+	features, err := version.ParseFeatures("a=true, b=false,c=25,d=100,e=0,")
+	require.NoError(t, err)
+	assert.Equal(t, version.Features{
+		"a": {Enabled: true},
+		"b": {},
+		"c": {Percent: 25},
+		"d": {Enabled: true, Percent: 100},
+		"e": {},
+	}, features)
+
+	features, err = version.ParseFeatures("")
+	require.NoError(t, err)
+	assert.Empty(t, features)
+
+	for _, invalid := range []string{"a", "=true", "a=yes", "a=101", "a=-1", "a=1.5"} {
+		_, err := version.ParseFeatures(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// the version server response decodes to the same features
+	var versions version.AllowedVersions
+	require.NoError(t, json.Unmarshal([]byte(`{"Storagenode":[],"Features":{"a":true,"b":false,"c":25}}`), &versions))
+	assert.Equal(t, version.Features{"a": {Enabled: true}, "b": {}, "c": {Percent: 25}}, versions.Features)
+
+	data, err := json.Marshal(versions.Features)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":true,"b":false,"c":25}`, string(data))
+}
+
+func TestFeaturesPercentage(t *testing.T) {
+	ids := make([]storj.NodeID, 1000)
+	for i := range ids {
+		ids[i] = teststorj.NodeIDFromString(strconv.Itoa(i))
+	}
+
+	enabled := func(features version.Features) map[storj.NodeID]bool {
+		nodes := map[storj.NodeID]bool{}
+		for _, id := range ids {
+			if features.IsEnabled("feature", id) {
+				nodes[id] = true
+			}
+		}
+		return nodes
+	}
+
+	assert.Empty(t, enabled(version.Features{}), "unknown features are disabled")
+	assert.Empty(t, enabled(version.Features{"feature": {Percent: 0}}))
+	assert.Len(t, enabled(version.Features{"feature": {Enabled: true}}), len(ids))
+
+	previous := map[storj.NodeID]bool{}
+	for _, percent := range []int{10, 25, 50, 90} {
+		features := version.Features{"feature": {Percent: percent}}
+
+		nodes := enabled(features)
+		assert.Equal(t, nodes, enabled(features), "the same nodes are enabled every time")
+		assert.InDelta(t, percent*len(ids)/100, len(nodes), float64(len(ids))/20)
+		for id := range previous {
+			assert.True(t, nodes[id], "nodes stay enabled while the rollout grows")
+		}
+		previous = nodes
+	}
+
+	// other features roll out to other nodes first
+	other := 0
+	for _, id := range ids {
+		features := version.Features{"feature": {Percent: 50}, "other": {Percent: 50}}
+		if features.IsEnabled("feature", id) != features.IsEnabled("other", id) {
+			other++
+		}
+	}
+	assert.True(t, other > 0)
+}
+
+func TestServiceFeatures(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	current := version.SemVer{Major: 0, Minor: 1, Patch: 0}
+	id := teststorj.NodeIDFromString("node")
+
+	server := newVersionServer(version.AllowedVersions{
+		Storagenode: []version.SemVer{current},
+		Features:    version.Features{"enabled": {Enabled: true}, "disabled": {}},
+	})
+	defer server.Close()
+
+	config := version.Config{
+		ServerAddress:  server.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+	}
+	service := version.NewService(zaptest.NewLogger(t), config, version.Info{Release: true, Version: current}, "Storagenode")
+
+	// nothing is enabled before the first check
+	assert.False(t, service.IsEnabled("enabled", id))
+
+	require.NoError(t, service.CheckVersion(ctx))
+	assert.True(t, service.IsEnabled("enabled", id))
+	assert.False(t, service.IsEnabled("disabled", id))
+	assert.False(t, service.IsEnabled("unknown", id))
+
+	// the features are kept while the server fails
+	server.setFailing(true)
+	require.NoError(t, service.CheckVersion(ctx))
+	assert.True(t, service.IsEnabled("enabled", id))
+
+	// servers without features disable everything
+	old := newVersionServer(version.AllowedVersions{Storagenode: []version.SemVer{current}})
+	defer old.Close()
+	config.ServerAddress = old.URL
+	service = version.NewService(zaptest.NewLogger(t), config, version.Info{Release: true, Version: current}, "Storagenode")
+	require.NoError(t, service.CheckVersion(ctx))
+	assert.False(t, service.IsEnabled("enabled", id))
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LastRunFile is the file in the config directory recording the version that last started with the config.
+const LastRunFile = "last-run-version"
+
+// SaveLastRun records Build as the version that last started with the config
+// in dir, nothing is recorded by development builds without a version.
+func SaveLastRun(dir string) error {
+	if Build.Version == (SemVer{}) {
+		return nil
+	}
+	return ioutil.WriteFile(filepath.Join(dir, LastRunFile), []byte(Build.Version.String()+"\n"), 0644)
+}
+
+// LoadLastRun returns the version that last started with the config in dir,
+// it returns false when no version has been recorded.
+func LoadLastRun(dir string) (_ SemVer, ok bool, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, LastRunFile))
+	if os.IsNotExist(err) {
+		return SemVer{}, false, nil
+	}
+	if err != nil {
+		return SemVer{}, false, err
+	}
+
+	version, err := NewSemVer(strings.TrimSpace(string(data)))
+	if err != nil {
+		return SemVer{}, false, err
+	}
+	return *version, true, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Protocol is the version of the protocol spoken between nodes.
+//
+// Unlike SemVer, which identifies a build, Protocol is only increased when
+// the semantics of an RPC change, so peers can tell what the other side
+// understands regardless of the build it runs.
+type Protocol int32
+
+const (
+	// ProtocolLegacy is spoken by peers that don't send a protocol version
+	ProtocolLegacy Protocol = 0
+	// ProtocolQueryStream added streaming routing table queries
+	ProtocolQueryStream Protocol = 1
+
+	// CurrentProtocol is the protocol version spoken by this build
+	CurrentProtocol = ProtocolQueryStream
+)
+
+// ProtocolMetadataKey is the gRPC metadata key carrying the protocol version of the sender.
+const ProtocolMetadataKey = "storj-protocol"
+
+// String returns the protocol version as a number.
+func (protocol Protocol) String() string { return strconv.Itoa(int(protocol)) }
+
+// WithProtocol returns ctx sending protocol to the peer in the request metadata.
+func WithProtocol(ctx context.Context, protocol Protocol) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ProtocolMetadataKey, protocol.String())
+}
+
+// ProtocolHeader returns the response header advertising protocol.
+func ProtocolHeader(protocol Protocol) metadata.MD {
+	return metadata.Pairs(ProtocolMetadataKey, protocol.String())
+}
+
+// ProtocolFromMetadata returns the protocol version in md, missing and
+// invalid versions are treated as ProtocolLegacy.
+func ProtocolFromMetadata(md metadata.MD) Protocol {
+	values := md.Get(ProtocolMetadataKey)
+	if len(values) == 0 {
+		return ProtocolLegacy
+	}
+	protocol, err := strconv.ParseInt(values[0], 10, 32)
+	if err != nil || protocol < 0 {
+		return ProtocolLegacy
+	}
+	return Protocol(protocol)
+}
+
+// PeerProtocol returns the protocol version of the peer sending the request in ctx.
+func PeerProtocol(ctx context.Context) Protocol {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return ProtocolFromMetadata(md)
+}
+
+// CheckProtocol returns a FailedPrecondition error naming the required
+// version when the peer sending the request in ctx is older than minimum.
+func CheckProtocol(ctx context.Context, rpc string, minimum Protocol) error {
+	protocol := PeerProtocol(ctx)
+	if protocol >= minimum {
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition,
+		"%s requires protocol version %s or newer, peer uses %s", rpc, minimum, protocol)
+}
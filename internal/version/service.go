@@ -5,47 +5,127 @@ package version
 
 import (
 	"context"
+	"crypto"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/zeebo/errs"
 	"go.uber.org/zap"
 
 	"storj.io/storj/internal/sync2"
+	"storj.io/storj/pkg/storj"
 )
 
 // Config contains the necessary Information to check the Software Version
 type Config struct {
-	ServerAddress  string        `help:"server address to check its version against" default:"https://version.alpha.storj.io"`
-	RequestTimeout time.Duration `help:"Request timeout for version checks" default:"0h1m0s"`
+	ServerAddress  string        `help:"server addresses to check its version against, separated by commas and tried until one responds" default:"https://version.alpha.storj.io"`
+	ShuffleServers bool          `help:"try the version servers in random order instead of the configured order" default:"false"`
+	RequestTimeout time.Duration `help:"Request timeout for version checks of each server" default:"0h1m0s"`
 	CheckInterval  time.Duration `help:"Interval to check the version" default:"0h15m0s"`
+	CheckDevBuilds bool          `help:"report available updates for development builds" default:"false"`
+	PublicKeyPath  string        `help:"path to the public key verifying the signed responses of the version servers, responses are not verified when empty" default:""`
+}
+
+// outdatedWarningInterval is the minimum time between warnings about a newer version.
+const outdatedWarningInterval = 24 * time.Hour
+
+// Servers returns the addresses of the version servers.
+func (config Config) Servers() []string {
+	var servers []string
+	for _, server := range strings.Split(config.ServerAddress, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// Status compares the running version to the newest allowed version.
+type Status struct {
+	Current   SemVer
+	Suggested SemVer
+	Outdated  bool
+	// Unknown is whether the running version is unknown, see Info.IsDev.
+	// An unknown version is never outdated.
+	Unknown bool
+
+	// Server is the version server of the last successful check, done at Checked.
+	Server  string
+	Checked time.Time
+}
+
+// Age returns how old the result of the last successful check is at now,
+// zero when no check succeeded yet.
+func (status Status) Age(now time.Time) time.Duration {
+	if status.Checked.IsZero() {
+		return 0
+	}
+	return now.Sub(status.Checked)
+}
+
+// knownGood is the last successful response of a version server.
+type knownGood struct {
+	versions AllowedVersions
+	server   string
+	checked  time.Time
 }
 
 // Service contains the information and variables to ensure the Software is up to date
 type Service struct {
+	log     *zap.Logger
 	config  Config
 	info    Info
 	service string
 
 	Loop *sync2.Cycle
 
+	closeOnce sync.Once
+	closed    chan struct{}
+
 	checked sync2.Fence
 	mu      sync.Mutex
 	allowed bool
+
+	status Status
+	// change is the last state change, see Subscribe
+	change      StateChange
+	subscribers map[chan StateChange]struct{}
+	// features of the accepted response, nil until a server responded
+	features Features
+
+	// preferred is the server that responded last, it is tried first
+	preferred string
+	lastGood  *knownGood
+
+	// publicKey verifies the responses, when keyErr is set no response is accepted
+	publicKey crypto.PublicKey
+	keyErr    error
 }
 
 // NewService creates a Version Check Client with default configuration
-func NewService(config Config, info Info, service string) (client *Service) {
-	return &Service{
+func NewService(log *zap.Logger, config Config, info Info, service string) (client *Service) {
+	client = &Service{
+		log:     log,
 		config:  config,
 		info:    info,
 		service: service,
 		Loop:    sync2.NewCycle(config.CheckInterval),
+		closed:  make(chan struct{}),
 		allowed: true,
+		status:  Status{Current: info.Version, Unknown: info.IsDev()},
+		change:  StateChange{Current: info.Version, Time: time.Now()},
+	}
+	if config.PublicKeyPath != "" {
+		client.publicKey, client.keyErr = LoadPublicKey(config.PublicKeyPath)
 	}
+	return client
 }
 
 // CheckVersion checks to make sure the version is still okay, returning an error if not
@@ -56,14 +136,52 @@ func (srv *Service) CheckVersion(ctx context.Context) error {
 	return nil
 }
 
+// CheckServers queries the version servers, unlike CheckVersion it fails
+// when none of them responds, and returns the server that responded. The
+// check fails as well when the running release isn't allowed.
+func (srv *Service) CheckServers(ctx context.Context) (server string, err error) {
+	accepted, err := srv.queryVersions(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	srv.mu.Lock()
+	server = srv.preferred
+	srv.mu.Unlock()
+
+	list := getFieldString(&accepted, srv.service)
+	if srv.info.Release && list != nil && !containsVersion(list, srv.info.Version) {
+		return server, fmt.Errorf("version %s is not allowed by %s", srv.info.Version.String(), server)
+	}
+	return server, nil
+}
+
 // CheckProcessVersion is not meant to be used for peers but is meant to be
 // used for other utilities
 func CheckProcessVersion(ctx context.Context, config Config, info Info, service string) error {
-	return NewService(config, info, service).CheckVersion(ctx)
+	return NewService(zap.L(), config, info, service).CheckVersion(ctx)
 }
 
 // Run logs the current version information
 func (srv *Service) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	warned := make(chan struct{})
+	go func() {
+		defer close(warned)
+		srv.warnOutdated(ctx)
+	}()
+	go func() {
+		select {
+		case <-srv.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	defer func() {
+		cancel()
+		<-warned
+	}()
+
 	if !srv.checked.Released() {
 		err := srv.CheckVersion(ctx)
 		if err != nil {
@@ -76,6 +194,12 @@ func (srv *Service) Run(ctx context.Context) error {
 	})
 }
 
+// Close stops the version checks of Run.
+func (srv *Service) Close() error {
+	srv.closeOnce.Do(func() { close(srv.closed) })
+	return nil
+}
+
 // IsAllowed returns whether if the Service is allowed to operate or not
 func (srv *Service) IsAllowed() bool {
 	srv.checked.Wait()
@@ -91,38 +215,166 @@ func (srv *Service) checkVersion(ctx context.Context) (allowed bool) {
 	defer func() {
 		srv.mu.Lock()
 		srv.allowed = allowed
+		srv.publish()
 		srv.mu.Unlock()
 		srv.checked.Release()
 	}()
 
-	if !srv.info.Release {
+	if !srv.info.Release && !srv.config.CheckDevBuilds {
 		return true
 	}
 
-	accepted, err := srv.queryVersionFromControlServer(ctx)
+	accepted, err := srv.queryVersions(ctx)
 	if err != nil {
 		// Log about the error, but dont crash the service and allow further operation
-		zap.S().Errorf("Failed to do periodic version check: ", err)
-		return true
+		srv.log.Sugar().Errorf("Failed to do periodic version check: %v", err)
+
+		srv.mu.Lock()
+		lastGood := srv.lastGood
+		srv.mu.Unlock()
+		if lastGood == nil {
+			return true
+		}
+		srv.log.Warn("using the last known version information",
+			zap.String("server", lastGood.server),
+			zap.Duration("age", time.Since(lastGood.checked)))
+		accepted = lastGood.versions
 	}
 
+	srv.mu.Lock()
+	srv.features = accepted.Features
+	srv.mu.Unlock()
+
 	list := getFieldString(&accepted, srv.service)
-	zap.S().Debugf("allowed versions from Control Server: %v", list)
+	srv.log.Sugar().Debugf("allowed versions from Control Server: %v", list)
 
 	if list == nil {
-		zap.S().Errorf("Empty List from Versioning Server")
+		srv.log.Sugar().Errorf("Empty List from Versioning Server")
+		return true
+	}
+	srv.updateStatus(list)
+
+	// development builds are only checked for updates
+	if !srv.info.Release {
 		return true
 	}
 	if containsVersion(list, srv.info.Version) {
-		zap.S().Infof("running on version %s", srv.info.Version.String())
+		srv.log.Sugar().Infof("running on version %s", srv.info.Version.String())
 		return true
 	}
-	zap.S().Errorf("running on not allowed/outdated version %s", srv.info.Version.String())
+	srv.log.Sugar().Errorf("running on not allowed/outdated version %s", srv.info.Version.String())
 	return false
 }
 
+// Status returns the result of the last version check.
+func (srv *Service) Status() Status {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.status
+}
+
+// Features returns the features enabled by the version server. Without a
+// response, or when the server doesn't support features, every feature is
+// disabled.
+func (srv *Service) Features() Features {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.features
+}
+
+// IsEnabled returns whether the version server enabled the feature name for
+// the node with id.
+func (srv *Service) IsEnabled(name string, id storj.NodeID) bool {
+	return srv.Features().IsEnabled(name, id)
+}
+
+// updateStatus compares the running version to the newest allowed version.
+func (srv *Service) updateStatus(allowed []SemVer) {
+	suggested, _ := Newest(allowed)
+	unknown := srv.info.IsDev()
+	outdated := !unknown && suggested.Compare(srv.info.Version) > 0
+
+	srv.mu.Lock()
+	srv.status = Status{Current: srv.info.Version, Suggested: suggested, Outdated: outdated, Unknown: unknown}
+	if srv.lastGood != nil {
+		srv.status.Server, srv.status.Checked = srv.lastGood.server, srv.lastGood.checked
+	}
+	srv.mu.Unlock()
+
+	switch {
+	case unknown:
+		mon.Counter("version_unknown").Inc(1)
+	case outdated:
+		mon.IntVal("outdated").Observe(1)
+	default:
+		mon.IntVal("outdated").Observe(0)
+	}
+}
+
+// queryVersions queries the version servers in order, starting with the one
+// that responded last, and returns the first verified response.
+//
+// When the response of a server disagrees with the previous response of
+// another server, the previous response is kept: a single compromised server
+// taking over during a failover can't change the allowed versions.
+func (srv *Service) queryVersions(ctx context.Context) (ver AllowedVersions, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if srv.keyErr != nil {
+		return AllowedVersions{}, errs.New("unable to load the version server public key: %v", srv.keyErr)
+	}
+
+	servers := srv.config.Servers()
+	if len(servers) == 0 {
+		return AllowedVersions{}, errs.New("no version server configured")
+	}
+	if srv.config.ShuffleServers {
+		rand.Shuffle(len(servers), func(i, k int) { servers[i], servers[k] = servers[k], servers[i] })
+	}
+
+	srv.mu.Lock()
+	preferred, lastGood := srv.preferred, srv.lastGood
+	srv.mu.Unlock()
+	for i, server := range servers {
+		if server == preferred {
+			copy(servers[1:i+1], servers[:i])
+			servers[0] = server
+			break
+		}
+	}
+
+	var group errs.Group
+	for _, server := range servers {
+		ver, err := srv.queryVersionFromControlServer(ctx, server)
+		if err != nil {
+			srv.log.Debug("version server failed", zap.String("server", server), zap.Error(err))
+			mon.Counter("version_server_failure").Inc(1)
+			group.Add(errs.New("%s: %v", server, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		if lastGood != nil && lastGood.server != server && !reflect.DeepEqual(lastGood.versions, ver) {
+			srv.log.Warn("version servers disagree, keeping the first response",
+				zap.String("server", server),
+				zap.String("first server", lastGood.server))
+			mon.Counter("version_server_discrepancy").Inc(1)
+			return lastGood.versions, nil
+		}
+
+		srv.mu.Lock()
+		srv.preferred = server
+		srv.lastGood = &knownGood{versions: ver, server: server, checked: time.Now()}
+		srv.mu.Unlock()
+		return ver, nil
+	}
+	return AllowedVersions{}, group.Err()
+}
+
 // QueryVersionFromControlServer handles the HTTP request to gather the allowed and latest version information
-func (srv *Service) queryVersionFromControlServer(ctx context.Context) (ver AllowedVersions, err error) {
+func (srv *Service) queryVersionFromControlServer(ctx context.Context, server string) (ver AllowedVersions, err error) {
 	defer mon.Task()(&ctx)(&err)
 
 	// Tune Client to have a custom Timeout (reduces hanging software)
@@ -131,7 +383,7 @@ func (srv *Service) queryVersionFromControlServer(ctx context.Context) (ver Allo
 	}
 
 	// New Request that used the passed in context
-	req, err := http.NewRequest("GET", srv.config.ServerAddress, nil)
+	req, err := http.NewRequest("GET", server, nil)
 	if err != nil {
 		return AllowedVersions{}, err
 	}
@@ -144,7 +396,21 @@ func (srv *Service) queryVersionFromControlServer(ctx context.Context) (ver Allo
 
 	defer func() { _ = resp.Body.Close() }()
 
-	err = json.NewDecoder(resp.Body).Decode(&ver)
+	if resp.StatusCode != http.StatusOK {
+		return AllowedVersions{}, errs.New("unexpected status %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return AllowedVersions{}, err
+	}
+	if srv.publicKey != nil {
+		if err := VerifyResponse(resp.Header, srv.publicKey, body); err != nil {
+			return AllowedVersions{}, err
+		}
+	}
+
+	err = json.Unmarshal(body, &ver)
 	return ver, err
 }
 
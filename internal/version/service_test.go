@@ -0,0 +1,323 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version_test
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/pkcrypto"
+)
+
+// versionServer is a version server that can be made to fail.
+type versionServer struct {
+	*httptest.Server
+	failing  int32
+	requests int32
+	versions atomic.Value // version.AllowedVersions
+	key      crypto.PrivateKey
+}
+
+func newVersionServer(versions version.AllowedVersions) *versionServer {
+	return newSignedVersionServer(versions, nil)
+}
+
+// newSignedVersionServer returns a version server signing its responses with key, when set.
+func newSignedVersionServer(versions version.AllowedVersions, key crypto.PrivateKey) *versionServer {
+	server := &versionServer{key: key}
+	server.versions.Store(versions)
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&server.requests, 1)
+		if atomic.LoadInt32(&server.failing) != 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, err := json.Marshal(server.versions.Load())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if server.key != nil {
+			if err := version.SignResponse(w.Header(), server.key, body); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		_, _ = w.Write(body)
+	}))
+	return server
+}
+
+func (server *versionServer) setFailing(failing bool) {
+	if failing {
+		atomic.StoreInt32(&server.failing, 1)
+	} else {
+		atomic.StoreInt32(&server.failing, 0)
+	}
+}
+
+func (server *versionServer) requestCount() int32 { return atomic.LoadInt32(&server.requests) }
+
+func TestServiceFailover(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	current := version.SemVer{Major: 0, Minor: 1, Patch: 0}
+	newer := version.SemVer{Major: 0, Minor: 2, Patch: 0}
+
+	first := newVersionServer(version.AllowedVersions{Storagenode: []version.SemVer{current}})
+	defer first.Close()
+	second := newVersionServer(version.AllowedVersions{Storagenode: []version.SemVer{current, newer}})
+	defer second.Close()
+
+	service := version.NewService(zaptest.NewLogger(t), version.Config{
+		ServerAddress:  first.URL + ", " + second.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+	}, version.Info{Release: true, Version: current}, "Storagenode")
+
+	// the first server fails, the second one is used
+	first.setFailing(true)
+	require.NoError(t, service.CheckVersion(ctx))
+	status := service.Status()
+	assert.Equal(t, second.URL, status.Server)
+	assert.Equal(t, newer, status.Suggested)
+	assert.EqualValues(t, 1, first.requestCount())
+
+	// the working server is tried first next time
+	first.setFailing(false)
+	require.NoError(t, service.CheckVersion(ctx))
+	assert.Equal(t, second.URL, service.Status().Server)
+	assert.EqualValues(t, 1, first.requestCount())
+	assert.EqualValues(t, 2, second.requestCount())
+
+	// the servers are swapped, the first server disagrees and the first response is kept
+	second.setFailing(true)
+	require.NoError(t, service.CheckVersion(ctx))
+	status = service.Status()
+	assert.Equal(t, second.URL, status.Server)
+	assert.Equal(t, newer, status.Suggested)
+	assert.EqualValues(t, 2, first.requestCount())
+
+	// the first server agrees again and takes over
+	first.versions.Store(second.versions.Load())
+	require.NoError(t, service.CheckVersion(ctx))
+	assert.Equal(t, first.URL, service.Status().Server)
+
+	// all servers fail, the last known result is kept with its age
+	first.setFailing(true)
+	require.NoError(t, service.CheckVersion(ctx))
+	status = service.Status()
+	assert.Equal(t, first.URL, status.Server)
+	assert.True(t, status.Age(time.Now().Add(time.Minute)) >= time.Minute)
+	assert.True(t, service.IsAllowed())
+}
+
+func TestServiceSignedResponses(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	key, err := pkcrypto.GeneratePrivateKey()
+	require.NoError(t, err)
+	otherKey, err := pkcrypto.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	publicKey, err := pkcrypto.PublicKeyToPEM(pkcrypto.PublicKeyFromPrivate(key))
+	require.NoError(t, err)
+	keyPath := filepath.Join(ctx.Dir("version"), "version.pub")
+	require.NoError(t, ioutil.WriteFile(keyPath, publicKey, 0644))
+
+	current := version.SemVer{Major: 0, Minor: 1, Patch: 0}
+	newer := version.SemVer{Major: 0, Minor: 2, Patch: 0}
+	forged := version.AllowedVersions{Storagenode: []version.SemVer{newer}}
+
+	unsigned := newVersionServer(forged)
+	defer unsigned.Close()
+	wrongKey := newSignedVersionServer(forged, otherKey)
+	defer wrongKey.Close()
+	signed := newSignedVersionServer(version.AllowedVersions{Storagenode: []version.SemVer{current}}, key)
+	defer signed.Close()
+
+	service := version.NewService(zaptest.NewLogger(t), version.Config{
+		ServerAddress:  unsigned.URL + "," + wrongKey.URL + "," + signed.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+		PublicKeyPath:  keyPath,
+	}, version.Info{Release: true, Version: current}, "Storagenode")
+
+	// responses failing verification are skipped
+	require.NoError(t, service.CheckVersion(ctx))
+	status := service.Status()
+	assert.Equal(t, signed.URL, status.Server)
+	assert.Equal(t, current, status.Suggested)
+	assert.EqualValues(t, 1, unsigned.requestCount())
+	assert.EqualValues(t, 1, wrongKey.requestCount())
+
+	// a missing public key fails every check
+	service = version.NewService(zaptest.NewLogger(t), version.Config{
+		ServerAddress:  signed.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+		PublicKeyPath:  filepath.Join(ctx.Dir("version"), "missing.pub"),
+	}, version.Info{Release: true, Version: current}, "Storagenode")
+	require.NoError(t, service.CheckVersion(ctx))
+	assert.True(t, service.Status().Checked.IsZero())
+	assert.EqualValues(t, 1, signed.requestCount())
+}
+
+func TestServiceUnknownVersion(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server := newVersionServer(version.AllowedVersions{Storagenode: []version.SemVer{{Minor: 1}}})
+	defer server.Close()
+
+	// a development build is never outdated, whatever is allowed
+	service := version.NewService(zaptest.NewLogger(t), version.Config{
+		ServerAddress:  server.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+		CheckDevBuilds: true,
+	}, version.Info{}, "Storagenode")
+	assert.True(t, service.Status().Unknown)
+
+	require.NoError(t, service.CheckVersion(ctx))
+	status := service.Status()
+	assert.True(t, status.Unknown)
+	assert.False(t, status.Outdated)
+	assert.Equal(t, version.SemVer{Minor: 1}, status.Suggested)
+	assert.True(t, service.IsAllowed())
+
+	// a development build of a known version is compared
+	service = version.NewService(zaptest.NewLogger(t), version.Config{
+		ServerAddress:  server.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+		CheckDevBuilds: true,
+	}, version.Info{Version: version.SemVer{Patch: 1}}, "Storagenode")
+	require.NoError(t, service.CheckVersion(ctx))
+	status = service.Status()
+	assert.False(t, status.Unknown)
+	assert.True(t, status.Outdated)
+}
+
+func TestConfigServers(t *testing.T) {
+	config := version.Config{ServerAddress: " https://a.example, ,https://b.example "}
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, config.Servers())
+	assert.Empty(t, version.Config{}.Servers())
+}
+
+func TestServiceSubscribe(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	current := version.SemVer{Minor: 1}
+	newer := version.SemVer{Minor: 2}
+	allow := func(versions ...version.SemVer) version.AllowedVersions {
+		return version.AllowedVersions{Storagenode: versions}
+	}
+
+	server := newVersionServer(allow(current))
+	defer server.Close()
+
+	service := version.NewService(zaptest.NewLogger(t), version.Config{
+		ServerAddress:  server.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+	}, version.Info{Version: current, Release: true}, "Storagenode")
+
+	subscriberCtx, cancel := context.WithCancel(ctx)
+	changes := service.Subscribe(subscriberCtx)
+
+	// the allowed versions flip, some responses don't change the state
+	for _, allowed := range []version.AllowedVersions{
+		allow(current),
+		allow(current),
+		allow(current, newer),
+		allow(current, newer),
+		allow(newer),
+		allow(current),
+	} {
+		server.versions.Store(allowed)
+		_ = service.CheckVersion(ctx)
+	}
+	server.setFailing(true)
+	_ = service.CheckVersion(ctx)
+
+	cancel()
+	var observed []version.StateChange
+	for change := range changes {
+		assert.Equal(t, current, change.Current)
+		assert.False(t, change.Time.IsZero())
+		change.Current, change.Time = version.SemVer{}, time.Time{}
+		observed = append(observed, change)
+	}
+	assert.Equal(t, []version.StateChange{
+		{Old: version.StateUnknown, New: version.StateUnknown},
+		{Old: version.StateUnknown, New: version.StateAllowed, Suggested: current},
+		{Old: version.StateAllowed, New: version.StateOutdated, Suggested: newer},
+		{Old: version.StateOutdated, New: version.StateAllowed, Suggested: current},
+	}, observed)
+
+	// a late subscriber receives the current state right away
+	late := service.Subscribe(ctx)
+	change := <-late
+	assert.Equal(t, version.StateAllowed, change.Old)
+	assert.Equal(t, version.StateAllowed, change.New)
+	assert.Equal(t, current, change.Suggested)
+}
+
+func TestServiceSubscribeSlow(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	current := version.SemVer{Minor: 1}
+	server := newVersionServer(version.AllowedVersions{})
+	defer server.Close()
+
+	service := version.NewService(zaptest.NewLogger(t), version.Config{
+		ServerAddress:  server.URL,
+		RequestTimeout: time.Second,
+		CheckInterval:  time.Hour,
+	}, version.Info{Version: current, Release: true}, "Storagenode")
+
+	subscriberCtx, cancel := context.WithCancel(ctx)
+	changes := service.Subscribe(subscriberCtx)
+
+	// a subscriber that doesn't read misses the oldest changes
+	const flips = 50
+	for i := 1; i <= flips; i++ {
+		suggested := version.SemVer{Minor: 1, Patch: int64(i)}
+		server.versions.Store(version.AllowedVersions{Storagenode: []version.SemVer{current, suggested}})
+		_ = service.CheckVersion(ctx)
+	}
+
+	cancel()
+	var observed []version.StateChange
+	for change := range changes {
+		observed = append(observed, change)
+	}
+	require.NotEmpty(t, observed)
+	assert.True(t, len(observed) < flips, "%d changes buffered", len(observed))
+	last := observed[len(observed)-1]
+	assert.Equal(t, version.StateOutdated, last.New)
+	assert.Equal(t, version.SemVer{Minor: 1, Patch: flips}, last.Suggested)
+	for i := 1; i < len(observed); i++ {
+		assert.True(t, observed[i-1].Suggested.Compare(observed[i].Suggested) < 0, "changes out of order")
+	}
+}
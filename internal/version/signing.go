@@ -0,0 +1,65 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version
+
+import (
+	"crypto"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/pkg/pkcrypto"
+)
+
+// SignatureHeader is the header of a version server response containing the
+// base64 encoded signature of the response body.
+const SignatureHeader = "X-Version-Signature"
+
+// SignResponse signs the response body with key and sets the signature header.
+func SignResponse(header http.Header, key crypto.PrivateKey, body []byte) error {
+	signature, err := pkcrypto.HashAndSign(key, body)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+	header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(signature))
+	return nil
+}
+
+// VerifyResponse checks that the signature header was made by the private key of key over body.
+func VerifyResponse(header http.Header, key crypto.PublicKey, body []byte) error {
+	encoded := header.Get(SignatureHeader)
+	if encoded == "" {
+		return errs.New("response is not signed")
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return errs.New("invalid signature encoding: %v", err)
+	}
+	if err := pkcrypto.HashAndVerifySignature(key, body, signature); err != nil {
+		return errs.New("invalid signature: %v", err)
+	}
+	return nil
+}
+
+// LoadPublicKey loads the PEM encoded public key at path.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	key, err := pkcrypto.PublicKeyFromPEM(data)
+	return key, errs.Wrap(err)
+}
+
+// LoadPrivateKey loads the PEM encoded private key at path.
+func LoadPrivateKey(path string) (crypto.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	key, err := pkcrypto.PrivateKeyFromPEM(data)
+	return key, errs.Wrap(err)
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// subscriberBuffer is the number of state changes buffered for each subscriber.
+const subscriberBuffer = 16
+
+// State is how the running version compares to the versions the version
+// servers allow.
+type State int
+
+const (
+	// StateUnknown is the state of development builds, and of any build
+	// before a version server responded.
+	StateUnknown State = iota
+	// StateAllowed is the state of an allowed version no older than the
+	// suggested version.
+	StateAllowed
+	// StateOutdated is the state of a version older than the suggested
+	// version, or no longer allowed.
+	StateOutdated
+)
+
+// String returns the name of the state.
+func (state State) String() string {
+	switch state {
+	case StateAllowed:
+		return "allowed"
+	case StateOutdated:
+		return "outdated"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChange is a change of the version state or of the suggested version.
+type StateChange struct {
+	Old State
+	New State
+	// Current is the running version, Suggested is the newest allowed
+	// version, zero until a version server responded.
+	Current   SemVer
+	Suggested SemVer
+	Time      time.Time
+}
+
+// Subscribe returns the state changes until ctx is canceled, when the
+// channel is closed. The current state is delivered right away, with Old
+// equal to New. A subscriber falling behind misses the oldest changes, the
+// last change it receives is always the current state.
+func (srv *Service) Subscribe(ctx context.Context) <-chan StateChange {
+	changes := make(chan StateChange, subscriberBuffer)
+
+	srv.mu.Lock()
+	current := srv.change
+	current.Old = current.New
+	changes <- current
+	if srv.subscribers == nil {
+		srv.subscribers = map[chan StateChange]struct{}{}
+	}
+	srv.subscribers[changes] = struct{}{}
+	srv.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		delete(srv.subscribers, changes)
+		close(changes)
+	}()
+	return changes
+}
+
+// state returns the current state, srv.mu must be held.
+func (srv *Service) state() State {
+	switch {
+	case srv.status.Unknown || srv.status.Checked.IsZero():
+		return StateUnknown
+	case srv.status.Outdated || !srv.allowed:
+		return StateOutdated
+	default:
+		return StateAllowed
+	}
+}
+
+// publish delivers the change to the subscribers when the state or the
+// suggested version changed, srv.mu must be held.
+func (srv *Service) publish() {
+	state, suggested := srv.state(), srv.status.Suggested
+	if state == srv.change.New && suggested == srv.change.Suggested {
+		return
+	}
+	srv.change = StateChange{
+		Old:       srv.change.New,
+		New:       state,
+		Current:   srv.info.Version,
+		Suggested: suggested,
+		Time:      time.Now(),
+	}
+	mon.Counter("version_state_change").Inc(1)
+
+	for changes := range srv.subscribers {
+		deliver(changes, srv.change)
+	}
+}
+
+// deliver sends change, dropping the oldest buffered change when changes is
+// full. Only publish sends, so dropping a change always makes room.
+func deliver(changes chan StateChange, change StateChange) {
+	for {
+		select {
+		case changes <- change:
+			return
+		default:
+		}
+		select {
+		case <-changes:
+			mon.Counter("version_state_change_dropped").Inc(1)
+		default:
+		}
+	}
+}
+
+// warnOutdated warns when the running version becomes outdated, and then
+// once per day while it remains outdated, until ctx is canceled.
+func (srv *Service) warnOutdated(ctx context.Context) {
+	var last StateChange
+	var remind <-chan time.Time
+	warn := func() {
+		srv.log.Warn("a newer version is available, please update",
+			zap.String("current", last.Current.String()),
+			zap.String("suggested", last.Suggested.String()))
+		remind = time.After(outdatedWarningInterval)
+	}
+
+	changes := srv.Subscribe(ctx)
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			suggested := change.Suggested != last.Suggested
+			last = change
+			switch {
+			case change.New != StateOutdated:
+				remind = nil
+			case remind == nil || suggested:
+				warn()
+			}
+		case <-remind:
+			warn()
+		}
+	}
+}
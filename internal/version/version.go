@@ -43,7 +43,11 @@ type Info struct {
 	Release    bool      `json:"release,omitempty"`
 }
 
-// SemVer represents a semantic version
+// SemVer represents a semantic version.
+//
+// A zero SemVer of a binary that isn't a release, such as one built without
+// linker flags, is an unknown version: it's never compared to other versions,
+// see Info.IsDev.
 type SemVer struct {
 	Major int64 `json:"major"`
 	Minor int64 `json:"minor"`
@@ -58,6 +62,9 @@ type AllowedVersions struct {
 	Uplink      []SemVer
 	Gateway     []SemVer
 	Identity    []SemVer
+
+	// Features are the network-wide features enabled for every service
+	Features Features `json:",omitempty"`
 }
 
 // SemVerRegex is the regular expression used to parse a semantic version.
@@ -102,6 +109,79 @@ func (sem *SemVer) String() (version string) {
 	return fmt.Sprintf("v%d.%d.%d", sem.Major, sem.Minor, sem.Patch)
 }
 
+// IsZero returns whether sem is v0.0.0.
+func (sem SemVer) IsZero() bool { return sem == SemVer{} }
+
+// Compare returns -1, 0 or 1 when sem is older, equal or newer than other.
+func (sem SemVer) Compare(other SemVer) int {
+	for _, cmp := range [][2]int64{
+		{sem.Major, other.Major},
+		{sem.Minor, other.Minor},
+		{sem.Patch, other.Patch},
+	} {
+		switch {
+		case cmp[0] < cmp[1]:
+			return -1
+		case cmp[0] > cmp[1]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Newest returns the newest of versions, or false when versions is empty.
+func Newest(versions []SemVer) (newest SemVer, ok bool) {
+	for i, version := range versions {
+		if i == 0 || version.Compare(newest) > 0 {
+			newest = version
+		}
+	}
+	return newest, len(versions) > 0
+}
+
+// Range is a range of versions from Min up to, but not including, Max.
+// A zero Max leaves the range unbounded.
+type Range struct {
+	Min SemVer
+	Max SemVer
+}
+
+// Contains returns whether version is within the range.
+func (r Range) Contains(version SemVer) bool {
+	if version.Compare(r.Min) < 0 {
+		return false
+	}
+	return r.Max.IsZero() || version.Compare(r.Max) < 0
+}
+
+// IsDev returns whether v is a development build of an unknown version,
+// which is neither older nor newer than any version.
+func (v Info) IsDev() bool { return !v.Release && v.Version.IsZero() }
+
+// FromProto returns the Info of a version reported by a node. An empty
+// version is the zero version.
+func FromProto(pbv *pb.NodeVersion) (Info, error) {
+	info := Info{
+		CommitHash: pbv.GetCommitHash(),
+		Release:    pbv.GetRelease(),
+	}
+	if pbv.GetTimestamp() != nil {
+		timestamp, err := ptypes.Timestamp(pbv.GetTimestamp())
+		if err != nil {
+			return Info{}, err
+		}
+		info.Timestamp = timestamp
+	}
+	if pbv.GetVersion() != "" {
+		sv, err := NewSemVer(pbv.GetVersion())
+		if err != nil {
+			return Info{}, err
+		}
+		info.Version = *sv
+	}
+	return info, nil
+}
+
 // New creates Version_Info from a json byte array
 func New(data []byte) (v Info, err error) {
 	err = json.Unmarshal(data, &v)
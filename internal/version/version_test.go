@@ -0,0 +1,138 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package version_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestSemVerCompare(t *testing.T) {
+	parse := func(s string) version.SemVer {
+		v, err := version.NewSemVer(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *v
+	}
+
+	for _, tt := range []struct {
+		a, b string
+		cmp  int
+	}{
+		{"v0.1.0", "v0.1.0", 0},
+		{"v0.1.0", "v0.1.1", -1},
+		{"v0.10.0", "v0.9.9", 1},
+		{"v1.0.0", "v0.99.99", 1},
+		{"v0.0.9", "v0.1.0", -1},
+	} {
+		assert.Equal(t, tt.cmp, parse(tt.a).Compare(parse(tt.b)), "%s %s", tt.a, tt.b)
+		assert.Equal(t, -tt.cmp, parse(tt.b).Compare(parse(tt.a)), "%s %s", tt.b, tt.a)
+	}
+
+	newest, ok := version.Newest([]version.SemVer{parse("v0.9.0"), parse("v0.10.1"), parse("v0.10.0")})
+	assert.True(t, ok)
+	assert.Equal(t, parse("v0.10.1"), newest)
+
+	_, ok = version.Newest(nil)
+	assert.False(t, ok)
+}
+
+func TestUnknownVersion(t *testing.T) {
+	assert.True(t, version.SemVer{}.IsZero())
+	assert.False(t, version.SemVer{Patch: 1}.IsZero())
+
+	assert.True(t, version.Info{}.IsDev())
+	assert.False(t, version.Info{Release: true}.IsDev())
+	assert.False(t, version.Info{Version: version.SemVer{Minor: 1}}.IsDev())
+
+	// nodes built without linker flags report v0.0.0, or nothing at all
+	for _, reported := range []*pb.NodeVersion{{Version: "v0.0.0"}, {}} {
+		info, err := version.FromProto(reported)
+		require.NoError(t, err)
+		assert.True(t, info.IsDev(), reported.Version)
+	}
+
+	info, err := version.FromProto(&pb.NodeVersion{Version: "v0.14.3", Release: true})
+	require.NoError(t, err)
+	assert.Equal(t, version.SemVer{Minor: 14, Patch: 3}, info.Version)
+	assert.False(t, info.IsDev())
+
+	_, err = version.FromProto(&pb.NodeVersion{Version: "latest"})
+	assert.Error(t, err)
+}
+
+func TestRangeContains(t *testing.T) {
+	r := version.Range{
+		Min: version.SemVer{Minor: 10},
+		Max: version.SemVer{Minor: 12},
+	}
+	assert.False(t, r.Contains(version.SemVer{Minor: 9, Patch: 9}))
+	assert.True(t, r.Contains(version.SemVer{Minor: 10}))
+	assert.True(t, r.Contains(version.SemVer{Minor: 11, Patch: 3}))
+	assert.False(t, r.Contains(version.SemVer{Minor: 12}))
+
+	unbounded := version.Range{Min: version.SemVer{Minor: 10}}
+	assert.True(t, unbounded.Contains(version.SemVer{Major: 5}))
+	assert.True(t, version.Range{}.Contains(version.SemVer{}))
+}
+
+func TestLastRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storj-version")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	_, ok, err := version.LoadLastRun(dir)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// development builds don't record anything
+	require.NoError(t, version.SaveLastRun(dir))
+	_, ok, err = version.LoadLastRun(dir)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	build := version.Build
+	defer func() { version.Build = build }()
+	version.Build.Version = version.SemVer{Minor: 11, Patch: 2}
+
+	require.NoError(t, version.SaveLastRun(dir))
+	last, ok, err := version.LoadLastRun(dir)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, version.Build.Version, last)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, version.LastRunFile), []byte("garbage"), 0644))
+	_, _, err = version.LoadLastRun(dir)
+	assert.Error(t, err)
+}
+
+func TestProtocol(t *testing.T) {
+	assert.Equal(t, version.ProtocolLegacy, version.ProtocolFromMetadata(nil))
+	assert.Equal(t, version.ProtocolLegacy, version.ProtocolFromMetadata(metadata.Pairs(version.ProtocolMetadataKey, "garbage")))
+	assert.Equal(t, version.CurrentProtocol, version.ProtocolFromMetadata(version.ProtocolHeader(version.CurrentProtocol)))
+
+	// outgoing metadata of the client is the incoming metadata of the server
+	outgoing, _ := metadata.FromOutgoingContext(version.WithProtocol(context.Background(), version.ProtocolQueryStream))
+	incoming := metadata.NewIncomingContext(context.Background(), outgoing)
+	assert.Equal(t, version.ProtocolQueryStream, version.PeerProtocol(incoming))
+	assert.NoError(t, version.CheckProtocol(incoming, "QueryStream", version.ProtocolQueryStream))
+
+	err := version.CheckProtocol(context.Background(), "QueryStream", version.ProtocolQueryStream)
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, "QueryStream requires protocol version 1 or newer, peer uses 0", status.Convert(err).Message())
+}
@@ -55,7 +55,8 @@ type Config struct {
 		IdentityVersion storj.IDVersion
 
 		// PeerIDVersion is the identity versions remote peers to this node
-		// will be supported by this node.
+		// will be supported by this node, such as "0,2-3" or "*". If empty,
+		// only version 0 is supported.
 		PeerIDVersion string
 
 		// MaxInlineSize determines whether the uplink will attempt to
@@ -99,6 +100,9 @@ func (cfg *Config) setDefaults(ctx context.Context) error {
 	if idVersion.Number != cfg.Volatile.IdentityVersion.Number {
 		return storj.ErrVersion.New("`UseIdentity` version (%d) didn't match version in config (%d)", idVersion.Number, cfg.Volatile.IdentityVersion.Number)
 	}
+	if cfg.Volatile.PeerIDVersion == "" {
+		cfg.Volatile.PeerIDVersion = "0"
+	}
 	if cfg.Volatile.MaxInlineSize == 0 {
 		cfg.Volatile.MaxInlineSize = 4 * memory.KiB
 	}
@@ -130,7 +134,7 @@ func NewUplink(ctx context.Context, cfg *Config) (*Uplink, error) {
 	tlsConfig := tlsopts.Config{
 		UsePeerCAWhitelist:  !cfg.Volatile.TLS.SkipPeerCAWhitelist,
 		PeerCAWhitelistPath: cfg.Volatile.TLS.PeerCAWhitelistPath,
-		PeerIDVersions:      "0",
+		PeerIDVersions:      cfg.Volatile.PeerIDVersion,
 	}
 	tlsOpts, err := tlsopts.NewOptions(cfg.Volatile.UseIdentity, tlsConfig)
 	if err != nil {
@@ -15,7 +15,6 @@ import (
 	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
 	"storj.io/storj/internal/memory"
-	"storj.io/storj/pkg/auth/signing"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/overlay"
 	"storj.io/storj/pkg/pb"
@@ -180,19 +179,13 @@ func (d *defaultDownloader) getShare(ctx context.Context, limit *pb.AddressedOrd
 
 	storageNodeID := limit.GetLimit().StorageNodeId
 
-	conn, err := d.transport.DialNode(timedCtx, &pb.Node{
+	ps, err := piecestore.Dial(timedCtx, d.transport, &pb.Node{
 		Id:      storageNodeID,
 		Address: limit.GetStorageNodeAddress(),
-	})
+	}, d.log.Named(storageNodeID.String()), piecestore.DefaultConfig)
 	if err != nil {
 		return Share{}, err
 	}
-	ps := piecestore.NewClient(
-		d.log.Named(storageNodeID.String()),
-		signing.SignerFromFullIdentity(d.transport.Identity()),
-		conn,
-		piecestore.DefaultConfig,
-	)
 	defer func() {
 		err := ps.Close()
 		if err != nil {
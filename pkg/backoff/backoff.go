@@ -0,0 +1,126 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package backoff implements retrying with configurable delays.
+package backoff
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/internal/sync2"
+)
+
+// Permanent is the class of errors that must not be retried.
+var Permanent = errs.Class("permanent error")
+
+// Strategy decides how long to wait before the next attempt.
+type Strategy interface {
+	// Delay returns the delay after the specified number of consecutive failures,
+	// elapsed is the time since the first attempt. It returns false when no
+	// further attempts should be made.
+	Delay(failures int, elapsed time.Duration) (time.Duration, bool)
+}
+
+// Exponential doubles the delay after each failure up to Max.
+type Exponential struct {
+	Base time.Duration
+	Max  time.Duration
+	// Jitter is the fraction of the delay that is random, e.g. 0.5 picks a
+	// delay between half of the ceiling and the ceiling ("equal jitter").
+	// Zero disables jitter.
+	Jitter float64
+	// MaxElapsed stops retrying once this much time has passed, zero retries forever.
+	MaxElapsed time.Duration
+	// Rand is the source of jitter, nil uses the default source.
+	Rand *rand.Rand
+
+	mu sync.Mutex
+}
+
+// Delay implements Strategy.
+func (strategy *Exponential) Delay(failures int, elapsed time.Duration) (time.Duration, bool) {
+	delay := strategy.Ceiling(failures)
+	if jitter := time.Duration(float64(delay) * math.Min(strategy.Jitter, 1)); jitter > 0 {
+		delay = delay - jitter + strategy.int63n(int64(jitter)+1)
+	}
+	return limit(delay, elapsed, strategy.MaxElapsed)
+}
+
+// Ceiling returns the maximum delay after the specified number of consecutive failures.
+func (strategy *Exponential) Ceiling(failures int) time.Duration {
+	ceiling := strategy.Base
+	for i := 1; i < failures && ceiling < math.MaxInt64/2; i++ {
+		if strategy.Max > 0 && ceiling >= strategy.Max {
+			break
+		}
+		ceiling *= 2
+	}
+	if strategy.Max > 0 && ceiling > strategy.Max {
+		ceiling = strategy.Max
+	}
+	return ceiling
+}
+
+func (strategy *Exponential) int63n(n int64) time.Duration {
+	if strategy.Rand == nil {
+		return time.Duration(rand.Int63n(n))
+	}
+	strategy.mu.Lock()
+	defer strategy.mu.Unlock()
+	return time.Duration(strategy.Rand.Int63n(n))
+}
+
+// Constant waits the same interval after each failure.
+type Constant struct {
+	Interval time.Duration
+	// MaxElapsed stops retrying once this much time has passed, zero retries forever.
+	MaxElapsed time.Duration
+}
+
+// Delay implements Strategy.
+func (strategy Constant) Delay(failures int, elapsed time.Duration) (time.Duration, bool) {
+	return limit(strategy.Interval, elapsed, strategy.MaxElapsed)
+}
+
+// limit shortens delay so the next attempt happens no later than maxElapsed.
+func limit(delay, elapsed, maxElapsed time.Duration) (time.Duration, bool) {
+	if maxElapsed <= 0 {
+		return delay, true
+	}
+	if elapsed >= maxElapsed {
+		return 0, false
+	}
+	if delay > maxElapsed-elapsed {
+		delay = maxElapsed - elapsed
+	}
+	return delay, true
+}
+
+// Retry calls fn until it succeeds, fails with a Permanent error, the strategy
+// gives up or ctx is canceled. It returns the last error.
+func Retry(ctx context.Context, strategy Strategy, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	for failures := 1; ; failures++ {
+		err := fn(ctx)
+		if err == nil || Permanent.Has(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		delay, ok := strategy.Delay(failures, time.Since(start))
+		if !ok {
+			return err
+		}
+		if !sync2.Sleep(ctx, delay) {
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package backoff_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/backoff"
+)
+
+func TestExponentialJitter(t *testing.T) {
+	strategy := &backoff.Exponential{
+		Base:   100 * time.Millisecond,
+		Max:    time.Second,
+		Jitter: 0.5,
+		Rand:   rand.New(rand.NewSource(1)),
+	}
+
+	for failures, ceiling := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		assert.Equal(t, ceiling, strategy.Ceiling(failures))
+
+		var min, max time.Duration = ceiling, 0
+		for i := 0; i < 1000; i++ {
+			delay, ok := strategy.Delay(failures, 0)
+			require.True(t, ok)
+			require.True(t, delay >= ceiling/2 && delay <= ceiling, "delay %v outside [%v, %v]", delay, ceiling/2, ceiling)
+			if delay < min {
+				min = delay
+			}
+			if delay > max {
+				max = delay
+			}
+		}
+		// equal jitter spreads delays over the upper half
+		assert.True(t, min < ceiling*5/8, "min %v", min)
+		assert.True(t, max > ceiling*7/8, "max %v", max)
+	}
+
+	{ // without jitter the delay is the ceiling
+		strategy := &backoff.Exponential{Base: time.Second, Max: time.Minute}
+		delay, ok := strategy.Delay(3, 0)
+		require.True(t, ok)
+		assert.Equal(t, 4*time.Second, delay)
+	}
+
+	{ // the same source gives the same delays
+		delays := func() (delays []time.Duration) {
+			strategy := &backoff.Exponential{Base: time.Second, Max: time.Minute, Jitter: 1, Rand: rand.New(rand.NewSource(2))}
+			for failures := 1; failures < 10; failures++ {
+				delay, _ := strategy.Delay(failures, 0)
+				delays = append(delays, delay)
+			}
+			return delays
+		}
+		assert.Equal(t, delays(), delays())
+	}
+}
+
+func TestMaxElapsed(t *testing.T) {
+	for _, strategy := range []backoff.Strategy{
+		backoff.Constant{Interval: time.Second, MaxElapsed: 10 * time.Second},
+		&backoff.Exponential{Base: time.Second, Max: time.Second, MaxElapsed: 10 * time.Second, Jitter: 1, Rand: rand.New(rand.NewSource(1))},
+	} {
+		delay, ok := strategy.Delay(1, 9500*time.Millisecond)
+		require.True(t, ok)
+		assert.True(t, delay <= 500*time.Millisecond, "delay %v after the cutoff", delay)
+
+		_, ok = strategy.Delay(1, 10*time.Second)
+		assert.False(t, ok)
+	}
+
+	// zero never stops
+	delay, ok := backoff.Constant{Interval: time.Second}.Delay(100, 24*time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestRetry(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	errTransient := errors.New("transient")
+	strategy := backoff.Constant{Interval: time.Millisecond}
+
+	{ // transient errors are retried until success
+		calls := 0
+		err := backoff.Retry(ctx, strategy, func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return errTransient
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	}
+
+	{ // permanent errors stop immediately
+		calls := 0
+		err := backoff.Retry(ctx, strategy, func(ctx context.Context) error {
+			calls++
+			return backoff.Permanent.New("bad request")
+		})
+		require.True(t, backoff.Permanent.Has(err))
+		assert.Equal(t, 1, calls)
+	}
+
+	{ // the last error is returned after max elapsed
+		calls := 0
+		err := backoff.Retry(ctx, backoff.Constant{Interval: 10 * time.Millisecond, MaxElapsed: 50 * time.Millisecond}, func(ctx context.Context) error {
+			calls++
+			return errTransient
+		})
+		require.Equal(t, errTransient, err)
+		assert.True(t, calls >= 2 && calls <= 7, "calls %d", calls)
+	}
+
+	{ // cancellation interrupts waiting
+		canceled, cancel := context.WithCancel(ctx)
+		start := time.Now()
+		err := backoff.Retry(canceled, backoff.Constant{Interval: time.Hour}, func(ctx context.Context) error {
+			cancel()
+			return errTransient
+		})
+		require.Equal(t, context.Canceled, err)
+		assert.True(t, time.Since(start) < time.Minute)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package cfgstruct
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/spf13/pflag"
+)
+
+// Setting is the effective value of a configuration flag.
+type Setting struct {
+	// Value is the flag value, durations and sizes are formatted as strings
+	// and values of fields tagged with `secret:"true"` are replaced with
+	// whether they are set.
+	Value interface{} `json:"value"`
+	// Default is whether the value matches the default value
+	Default bool `json:"default"`
+}
+
+// Describe returns the settings of config keyed by their flag name.
+//
+// The defaults are determined the same way as Bind does with opts, defaults
+// containing unknown variables such as $CONFDIR are not reported as default.
+func Describe(config interface{}, opts ...BindOpt) map[string]Setting {
+	val := reflect.ValueOf(config)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	defaults := reflect.New(val.Type())
+	Bind(pflag.NewFlagSet("defaults", pflag.ContinueOnError), defaults.Interface(), opts...)
+
+	settings := map[string]Setting{}
+	describeConfig(settings, "", val, defaults.Elem())
+	return settings
+}
+
+func describeConfig(settings map[string]Setting, prefix string, val, def reflect.Value) {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldval, fielddef := val.Field(i), def.Field(i)
+		flagname := hyphenate(snakeCase(field.Name))
+
+		if field.Tag.Get("noprefix") != "true" {
+			flagname = prefix + flagname
+		}
+		if field.Tag.Get("internal") == "true" || field.Tag.Get("setup") == "true" {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			if field.Anonymous {
+				describeConfig(settings, prefix, fieldval, fielddef)
+			} else {
+				describeConfig(settings, flagname+".", fieldval, fielddef)
+			}
+		case reflect.Array, reflect.Slice:
+			digits := len(fmt.Sprint(fieldval.Len()))
+			for j := 0; j < fieldval.Len() && j < fielddef.Len(); j++ {
+				padding := fmt.Sprintf("%0*d", digits, j)
+				describeConfig(settings, fmt.Sprintf("%s.%s.", flagname, padding), fieldval.Index(j), fielddef.Index(j))
+			}
+		default:
+			value := fieldval.Interface()
+			if field.Tag.Get("secret") == "true" {
+				value = !isZero(fieldval)
+			} else if stringer, ok := value.(fmt.Stringer); ok {
+				value = stringer.String()
+			}
+			settings[flagname] = Setting{
+				Value:   value,
+				Default: reflect.DeepEqual(fieldval.Interface(), fielddef.Interface()),
+			}
+		}
+	}
+}
+
+func isZero(val reflect.Value) bool {
+	return reflect.DeepEqual(val.Interface(), reflect.Zero(val.Type()).Interface())
+}
+
+// DescribeHandler returns a handler that serves the settings of each config section as JSON.
+func DescribeHandler(sections map[string]interface{}, opts ...BindOpt) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		described := make(map[string]map[string]Setting, len(sections))
+		for name, config := range sections {
+			described[name] = Describe(config, opts...)
+		}
+
+		data, err := json.MarshalIndent(described, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package cfgstruct
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"storj.io/storj/internal/memory"
+)
+
+func TestDescribe(t *testing.T) {
+	type Nested struct {
+		Size memory.Size `default:"1MiB"`
+	}
+	var c struct {
+		String   string        `default:"a"`
+		Int      int           `releaseDefault:"1" devDefault:"2"`
+		Duration time.Duration `default:"1h"`
+		Secret   string        `default:"" secret:"true"`
+		Internal int           `internal:"true"`
+		Nested   Nested
+		Embedded struct {
+			Bool bool `default:"false"`
+		}
+	}
+	c.String = "a"
+	c.Int = 2
+	c.Duration = 90 * time.Minute
+	c.Secret = "hunter2"
+	c.Nested.Size = 1 * memory.MiB
+
+	settings := Describe(&c, UseReleaseDefaults())
+	assertEqual(settings, map[string]Setting{
+		"string":        {Value: "a", Default: true},
+		"int":           {Value: 2, Default: false},
+		"duration":      {Value: "1h30m0s", Default: false},
+		"secret":        {Value: true, Default: false},
+		"nested.size":   {Value: "1.0 MiB", Default: true},
+		"embedded.bool": {Value: false, Default: true},
+	})
+	assertEqual(Describe(c, UseDevDefaults())["int"], Setting{Value: 2, Default: true})
+
+	recorder := httptest.NewRecorder()
+	DescribeHandler(map[string]interface{}{"test": c}, UseReleaseDefaults()).
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/config", nil))
+
+	var described map[string]map[string]Setting
+	if err := json.Unmarshal(recorder.Body.Bytes(), &described); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(described["test"]["duration"], Setting{Value: "1h30m0s", Default: false})
+	assertEqual(described["test"]["secret"], Setting{Value: true, Default: false})
+}
@@ -16,6 +16,7 @@ import (
 	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/overlay"
 	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
 )
 
 var (
@@ -63,9 +64,24 @@ func New(logger *zap.Logger, ol *overlay.Cache, kad *kademlia.Kademlia, config C
 	discovery.Graveyard.SetInterval(config.GraveyardInterval)
 	discovery.Discovery.SetInterval(config.DiscoveryInterval)
 
+	// the census of satellites restarting at the same time is spread
+	self := kad.Local().Id.Bytes()
+	discovery.Refresh.SetSplay(sync2.NewSplay(self, "census refresh"))
+	discovery.Graveyard.SetSplay(sync2.NewSplay(self, "census graveyard"))
+	discovery.Discovery.SetSplay(sync2.NewSplay(self, "census discovery"))
+
 	return discovery
 }
 
+// NextRuns returns when the census loops run next, see sync2.NextRuns.
+func (discovery *Discovery) NextRuns() map[string]time.Time {
+	return map[string]time.Time{
+		"census refresh":   discovery.Refresh.NextRun(),
+		"census graveyard": discovery.Graveyard.NextRun(),
+		"census discovery": discovery.Discovery.NextRun(),
+	}
+}
+
 // Close closes resources
 func (discovery *Discovery) Close() error {
 	discovery.Refresh.Close()
@@ -76,23 +92,26 @@ func (discovery *Discovery) Close() error {
 
 // Run runs the discovery service
 func (discovery *Discovery) Run(ctx context.Context) error {
+	// the census of nodes shouldn't delay dials someone is waiting on,
+	// hence each loop dials with background priority
+	ctx = transport.WithLabel(ctx, "census")
 	var group errgroup.Group
 	discovery.Refresh.Start(ctx, &group, func(ctx context.Context) error {
-		err := discovery.refresh(ctx)
+		err := discovery.refresh(kademlia.WithDialPriority(ctx, kademlia.BackgroundDial))
 		if err != nil {
 			discovery.log.Error("error with cache refresh: ", zap.Error(err))
 		}
 		return nil
 	})
 	discovery.Graveyard.Start(ctx, &group, func(ctx context.Context) error {
-		err := discovery.searchGraveyard(ctx)
+		err := discovery.searchGraveyard(kademlia.WithDialPriority(ctx, kademlia.BackgroundDial))
 		if err != nil {
 			discovery.log.Error("graveyard resurrection failed: ", zap.Error(err))
 		}
 		return nil
 	})
 	discovery.Discovery.Start(ctx, &group, func(ctx context.Context) error {
-		err := discovery.discover(ctx)
+		err := discovery.discover(kademlia.WithDialPriority(ctx, kademlia.BackgroundDial))
 		if err != nil {
 			discovery.log.Error("error with cache discovery: ", zap.Error(err))
 		}
@@ -208,7 +227,7 @@ func (discovery *Discovery) discover(ctx context.Context) error {
 	if err != nil {
 		return Error.Wrap(err)
 	}
-	_, err = discovery.kad.FindNode(ctx, r)
+	_, err = discovery.kad.FindNode(kademlia.WithRandomTarget(ctx), r)
 	if err != nil && !kademlia.NodeNotFound.Has(err) {
 		return Error.Wrap(err)
 	}
@@ -0,0 +1,257 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package eventlog writes dial and contact events as JSON lines for offline analysis.
+package eventlog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/pkg/storj"
+)
+
+var (
+	mon = monkit.Package()
+
+	// Error is an eventlog error
+	Error = errs.Class("eventlog error")
+)
+
+// Stderr is the path that writes events to standard error
+const Stderr = "stderr"
+
+// Directions of an event
+const (
+	Outgoing = "outgoing"
+	Incoming = "incoming"
+)
+
+// Config configures the event sink
+type Config struct {
+	Path      string      `help:"file to write dial and contact events to as JSON lines, \"stderr\" for standard error, empty disables" default:""`
+	MaxSize   memory.Size `help:"size after which the event file is rotated, keeping one previous file" default:"64MiB"`
+	QueueSize memory.Size `help:"maximum size of events waiting to be written, further events are dropped" default:"1MiB"`
+
+	HistorySize int `help:"number of recent events kept in memory for the inspector, zero disables the history" default:"1000"`
+}
+
+// Event is a single dial or contact with a peer, or a change noticed by the node
+type Event struct {
+	Time       time.Time     `json:"time"`
+	Operation  string        `json:"operation"`
+	Direction  string        `json:"direction,omitempty"`
+	PeerID     string        `json:"peer_id,omitempty"`
+	Address    string        `json:"address,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	ErrorClass string        `json:"error_class,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	// Code is the stable code of the error, when the recorder knows it
+	Code string `json:"code,omitempty"`
+
+	// Before and After are the node IDs of a changed node set, such as the closest nodes to self,
+	// or the addresses of a node that moved
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// NewEvent creates an event for an outgoing operation started at start.
+func NewEvent(operation string, id storj.NodeID, address string, start time.Time, err error) Event {
+	event := Event{
+		Time:       start,
+		Operation:  operation,
+		Direction:  Outgoing,
+		Address:    address,
+		Duration:   time.Since(start),
+		ErrorClass: errs2.ClassifyDial(err).String(),
+	}
+	if !id.IsZero() {
+		event.PeerID = id.String()
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// Sink writes events in the background and keeps the most recent ones in
+// memory, a nil Sink discards events.
+//
+// Recording never blocks: events are dropped when the queue is full.
+type Sink struct {
+	log     *zap.Logger
+	config  Config
+	history *history
+
+	out     io.Writer
+	file    *os.File
+	written memory.Size
+
+	mu     sync.Mutex
+	cond   sync.Cond
+	queue  [][]byte
+	queued memory.Size
+	closed bool
+	done   chan struct{}
+}
+
+// Open opens the sink configured by config, it returns nil when disabled.
+func Open(log *zap.Logger, config Config) (*Sink, error) {
+	if config.Path == "" && config.HistorySize <= 0 {
+		return nil, nil
+	}
+
+	sink := &Sink{
+		log:     log,
+		config:  config,
+		history: newHistory(config.HistorySize),
+		done:    make(chan struct{}),
+	}
+	sink.cond.L = &sink.mu
+
+	switch config.Path {
+	case "":
+		close(sink.done)
+		return sink, nil
+	case Stderr:
+		sink.out = os.Stderr
+	default:
+		file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		info, err := file.Stat()
+		if err != nil {
+			return nil, Error.Wrap(errs.Combine(err, file.Close()))
+		}
+		sink.out, sink.file = file, file
+		sink.written = memory.Size(info.Size())
+	}
+
+	go sink.run()
+	return sink, nil
+}
+
+// Record adds event to the history of its peer and queues it to be written.
+func (sink *Sink) Record(event Event) {
+	if sink == nil {
+		return
+	}
+
+	sink.history.add(event)
+	if sink.out == nil {
+		return
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		sink.log.Debug("unable to encode event", zap.Error(err))
+		return
+	}
+	line = append(line, '\n')
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if sink.closed || sink.queued+memory.Size(len(line)) > sink.config.QueueSize {
+		mon.Counter("events_dropped").Inc(1)
+		return
+	}
+	sink.queue = append(sink.queue, line)
+	sink.queued += memory.Size(len(line))
+	sink.cond.Signal()
+}
+
+// History returns the most recent events involving peer, oldest first. At
+// most limit events are returned, a non-positive limit returns all of them.
+func (sink *Sink) History(peer storj.NodeID, limit int) []Event {
+	if sink == nil {
+		return nil
+	}
+	return sink.history.find(peer.String(), limit)
+}
+
+// Close writes the queued events and closes the file.
+func (sink *Sink) Close() error {
+	if sink == nil {
+		return nil
+	}
+
+	sink.mu.Lock()
+	sink.closed = true
+	sink.cond.Signal()
+	sink.mu.Unlock()
+
+	<-sink.done
+	if sink.file == nil {
+		return nil
+	}
+	return Error.Wrap(sink.file.Close())
+}
+
+// run writes queued events until the sink is closed.
+func (sink *Sink) run() {
+	defer close(sink.done)
+	for {
+		sink.mu.Lock()
+		for len(sink.queue) == 0 && !sink.closed {
+			sink.cond.Wait()
+		}
+		batch := sink.queue
+		sink.queue, sink.queued = nil, 0
+		sink.mu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+		for _, line := range batch {
+			if err := sink.write(line); err != nil {
+				mon.Counter("events_write_failed").Inc(1)
+				sink.log.Debug("unable to write event", zap.Error(err))
+			}
+		}
+	}
+}
+
+// write writes a single line, rotating the file when it would exceed the max size.
+func (sink *Sink) write(line []byte) error {
+	if sink.file != nil && sink.written > 0 && sink.written+memory.Size(len(line)) > sink.config.MaxSize {
+		if err := sink.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := sink.out.Write(line)
+	sink.written += memory.Size(n)
+	return err
+}
+
+// rotate moves the current file to its rotated path and starts a new one.
+func (sink *Sink) rotate() error {
+	if err := sink.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(sink.config.Path, RotatedPath(sink.config.Path)); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(sink.config.Path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	sink.out, sink.file = file, file
+	sink.written = 0
+	return nil
+}
+
+// RotatedPath returns the path the previous events of path are rotated to.
+func RotatedPath(path string) string { return path + ".1" }
@@ -0,0 +1,144 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package eventlog_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/eventlog"
+)
+
+func TestDisabled(t *testing.T) {
+	sink, err := eventlog.Open(zaptest.NewLogger(t), eventlog.Config{})
+	require.NoError(t, err)
+	require.Nil(t, sink)
+
+	sink.Record(eventlog.Event{Operation: "ignored"})
+	require.NoError(t, sink.Close())
+}
+
+func TestRotation(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	start := time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)
+	event := func(i int) eventlog.Event {
+		return eventlog.Event{Time: start, Operation: fmt.Sprintf("op-%02d", i)}
+	}
+
+	line, err := eventSize(event(0))
+	require.NoError(t, err)
+
+	path := filepath.Join(ctx.Dir("events"), "events.jsonl")
+	sink, err := eventlog.Open(zaptest.NewLogger(t), eventlog.Config{
+		Path:      path,
+		MaxSize:   3 * line,
+		QueueSize: memory.MiB,
+	})
+	require.NoError(t, err)
+
+	for i := 1; i <= 10; i++ {
+		sink.Record(event(i))
+	}
+	require.NoError(t, sink.Close())
+
+	// each file keeps 3 events, older files are removed by rotation
+	events, err := eventlog.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []eventlog.Event{event(7), event(8), event(9), event(10)}, events)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(line), info.Size())
+}
+
+func TestQueueLimit(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	path := filepath.Join(ctx.Dir("events"), "events.jsonl")
+	sink, err := eventlog.Open(zaptest.NewLogger(t), eventlog.Config{
+		Path:      path,
+		MaxSize:   memory.MiB,
+		QueueSize: 1 * memory.B,
+	})
+	require.NoError(t, err)
+
+	// events larger than the queue are always dropped
+	sink.Record(eventlog.Event{Operation: "dropped"})
+	require.NoError(t, sink.Close())
+
+	events, err := eventlog.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestNewEvent(t *testing.T) {
+	id := teststorj.NodeIDFromString("peer")
+	start := time.Now().Add(-time.Second)
+
+	event := eventlog.NewEvent("ping", id, "127.0.0.1:1", start, context.DeadlineExceeded)
+	assert.Equal(t, id.String(), event.PeerID)
+	assert.Equal(t, eventlog.Outgoing, event.Direction)
+	assert.Equal(t, "timeout", event.ErrorClass)
+	assert.Equal(t, context.DeadlineExceeded.Error(), event.Error)
+	assert.True(t, event.Duration >= time.Second)
+}
+
+func TestHistory(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	// the history is kept even when events aren't written anywhere
+	sink, err := eventlog.Open(zaptest.NewLogger(t), eventlog.Config{HistorySize: 5})
+	require.NoError(t, err)
+	require.NotNil(t, sink)
+	defer ctx.Check(sink.Close)
+
+	a, b := teststorj.NodeIDFromString("a"), teststorj.NodeIDFromString("b")
+	assert.Empty(t, sink.History(a, 0))
+
+	for i := 0; i < 4; i++ {
+		sink.Record(eventlog.Event{Operation: fmt.Sprint(i), PeerID: a.String()})
+	}
+	sink.Record(eventlog.Event{Operation: "other", PeerID: b.String()})
+
+	operations := func(events []eventlog.Event) (ops []string) {
+		for _, event := range events {
+			ops = append(ops, event.Operation)
+		}
+		return ops
+	}
+	assert.Equal(t, []string{"0", "1", "2", "3"}, operations(sink.History(a, 0)))
+	assert.Equal(t, []string{"2", "3"}, operations(sink.History(a, 2)))
+	assert.Equal(t, []string{"other"}, operations(sink.History(b, 10)))
+
+	// events not involving a peer aren't kept, so they don't replace older events
+	sink.Record(eventlog.Event{Operation: "no peer"})
+
+	// older events are replaced once the history is full
+	sink.Record(eventlog.Event{Operation: "4", PeerID: a.String()})
+	sink.Record(eventlog.Event{Operation: "5", PeerID: a.String()})
+	assert.Equal(t, []string{"2", "3", "4", "5"}, operations(sink.History(a, 0)))
+	assert.Equal(t, []string{"other"}, operations(sink.History(b, 0)))
+}
+
+// eventSize returns the size of an event as written by the sink.
+func eventSize(event eventlog.Event) (memory.Size, error) {
+	data, err := json.Marshal(event)
+	return memory.Size(len(data) + 1), err
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package eventlog
+
+import "sync"
+
+// history is a ring buffer of the most recent events, a nil history keeps nothing.
+type history struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+// newHistory creates a history keeping size events, it returns nil when size isn't positive.
+func newHistory(size int) *history {
+	if size <= 0 {
+		return nil
+	}
+	return &history{events: make([]Event, size)}
+}
+
+// add adds event, replacing the oldest event when the history is full.
+// Events not involving a peer aren't kept.
+func (history *history) add(event Event) {
+	if history == nil || event.PeerID == "" {
+		return
+	}
+
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	history.events[history.next] = event
+	history.next++
+	if history.next == len(history.events) {
+		history.next, history.full = 0, true
+	}
+}
+
+// find returns at most limit of the most recent events of peerID, oldest first.
+func (history *history) find(peerID string, limit int) []Event {
+	if history == nil {
+		return nil
+	}
+
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	var found []Event
+	for i := 1; i <= len(history.events); i++ {
+		if limit > 0 && len(found) >= limit {
+			break
+		}
+		index := history.next - i
+		if index < 0 {
+			if !history.full {
+				break
+			}
+			index += len(history.events)
+		}
+		if event := history.events[index]; event.PeerID == peerID {
+			found = append(found, event)
+		}
+	}
+
+	for i, k := 0, len(found)-1; i < k; i, k = i+1, k-1 {
+		found[i], found[k] = found[k], found[i]
+	}
+	return found
+}
@@ -0,0 +1,53 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/zeebo/errs"
+)
+
+// Read parses events written as JSON lines.
+func Read(r io.Reader) ([]Event, error) {
+	var events []Event
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return events, Error.Wrap(err)
+		}
+		events = append(events, event)
+	}
+	return events, Error.Wrap(scanner.Err())
+}
+
+// ReadFile parses the events written to path, including the rotated file.
+func ReadFile(path string) ([]Event, error) {
+	var events []Event
+	for _, path := range []string{RotatedPath(path), path} {
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return events, Error.Wrap(err)
+		}
+
+		read, err := Read(file)
+		events = append(events, read...)
+		if err := errs.Combine(err, file.Close()); err != nil {
+			return events, Error.Wrap(err)
+		}
+	}
+	return events, nil
+}
@@ -73,7 +73,7 @@ type ManageableFullIdentity struct {
 // identity. You can also just load an Identity from disk.
 type SetupConfig struct {
 	CertPath  string `help:"path to the certificate chain for this identity" default:"$IDENTITYDIR/identity.cert"`
-	KeyPath   string `help:"path to the private key for this identity" default:"$IDENTITYDIR/identity.key"`
+	KeyPath   string `help:"path to the private key for this identity" default:"$IDENTITYDIR/identity.key" secret:"true"`
 	Overwrite bool   `help:"if true, existing identity certs AND keys will overwritten for" default:"false" setup:"true"`
 	Version   string `help:"semantic version of identity storage format" default:"0"`
 }
@@ -82,7 +82,7 @@ type SetupConfig struct {
 // identity. You can also just load an Identity from disk.
 type Config struct {
 	CertPath string `help:"path to the certificate chain for this identity" default:"$IDENTITYDIR/identity.cert" user:"true"`
-	KeyPath  string `help:"path to the private key for this identity" default:"$IDENTITYDIR/identity.key" user:"true"`
+	KeyPath  string `help:"path to the private key for this identity" default:"$IDENTITYDIR/identity.key" user:"true" secret:"true"`
 }
 
 // PeerConfig allows you to interact with a peer identity (cert, no key) on disk.
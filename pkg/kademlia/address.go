@@ -0,0 +1,244 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/pb"
+)
+
+// ErrNotReady is the class of errors announcing the local node before it has
+// a valid address, such as while its DDNS record doesn't exist yet.
+var ErrNotReady = errs.Class("kademlia not ready")
+
+// validAddress returns whether peers can dial address: it has a host, which
+// isn't an unspecified IP, and a port.
+func validAddress(address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" || port == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		return false
+	}
+	return true
+}
+
+// ErrInvalidAddress is the class of errors of node addresses that peers
+// shouldn't dial, see ValidateNodeAddress.
+var ErrInvalidAddress = errs.Class("invalid node address")
+
+// privateNetworks are the networks that aren't reachable over the internet,
+// besides loopback and link-local networks: the private networks of RFC 1918
+// and the unique local addresses of RFC 4193.
+var privateNetworks = parseNetworks("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+
+// parseNetworks parses CIDRs known to be valid.
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// ipResolver resolves host names, such as net.Resolver.
+type ipResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// ValidateNodeAddress returns an ErrInvalidAddress error when peers
+// shouldn't dial the address of node: it must be a host and a nonzero port,
+// unlike the listening address of a node asking the system for a port, and
+// the host mustn't be an unspecified IP. Unless allowPrivate is set, such as
+// when all nodes run on localhost in tests, private, loopback and link-local
+// IPs and localhost names are invalid too, as are host names that don't
+// resolve or resolve to such IPs.
+func ValidateNodeAddress(ctx context.Context, node pb.Node, allowPrivate bool) error {
+	return validateNodeAddress(ctx, net.DefaultResolver, node, allowPrivate)
+}
+
+// validateNodeAddress is ValidateNodeAddress resolving host names with resolver.
+func validateNodeAddress(ctx context.Context, resolver ipResolver, node pb.Node, allowPrivate bool) error {
+	address := node.GetAddress().GetAddress()
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrInvalidAddress.New("%q: %v", address, err)
+	}
+	if host == "" {
+		return ErrInvalidAddress.New("%q has no host", address)
+	}
+	if number, err := strconv.Atoi(port); err != nil || number <= 0 || number > 65535 {
+		return ErrInvalidAddress.New("%q has an invalid port", address)
+	}
+
+	// the zone of an IPv6 address is only used for link-local addresses
+	zoned := strings.IndexByte(host, '%') >= 0
+	if zoned {
+		host = host[:strings.IndexByte(host, '%')]
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		if allowPrivate {
+			return nil
+		}
+		if zoned || localhostName(host) {
+			return ErrInvalidAddress.New("%q is local", address)
+		}
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return ErrInvalidAddress.New("%q doesn't resolve: %v", address, err)
+		}
+		if len(addrs) == 0 {
+			return ErrInvalidAddress.New("%q doesn't resolve", address)
+		}
+		// a peer may dial any of the IPs, they all have to be public
+		for _, addr := range addrs {
+			if addr.IP.IsUnspecified() || privateIP(addr.IP) {
+				return ErrInvalidAddress.New("%q resolves to the private IP %s", address, addr.IP)
+			}
+		}
+		return nil
+	}
+	if ip.IsUnspecified() {
+		return ErrInvalidAddress.New("%q has an unspecified IP", address)
+	}
+	if !allowPrivate && (zoned || privateIP(ip)) {
+		return ErrInvalidAddress.New("%q is private", address)
+	}
+	return nil
+}
+
+// privateIP returns whether ip is private, loopback or link-local.
+func privateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// localhostName returns whether host is a name of the loopback address,
+// localhost or a name under localhost, see RFC 6761.
+func localhostName(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host == "localhost" || strings.HasSuffix(host, ".localhost")
+}
+
+// announceable returns whether node can be announced to peers, which insert
+// it into their routing tables.
+func announceable(node pb.Node) bool {
+	return validAddress(node.GetAddress().GetAddress())
+}
+
+// Ready returns whether the local node has a valid address. Until then it
+// isn't announced to peers and omitted from query responses, while its
+// lookups and pings still work.
+func (k *Kademlia) Ready() bool {
+	return announceable(k.Local().Node)
+}
+
+// SetAddress changes the address of the local node, such as once its DDNS
+// record exists or its address was detected. The local node is announced
+// again when its address becomes valid.
+func (k *Kademlia) SetAddress(address string) error {
+	if !validAddress(address) {
+		return ErrNotReady.New("invalid address %q", address)
+	}
+	k.setAddress(address)
+	return nil
+}
+
+// setAddress changes the address of the local node to the valid address.
+func (k *Kademlia) setAddress(address string) {
+	previous, err := k.routingTable.setAddress(address)
+	if err != nil {
+		k.log.Warn("could not update the local node", zap.Error(err))
+	}
+	if previous == address {
+		return
+	}
+	k.log.Info("local node address changed", zap.String("previous", previous), zap.String("address", address))
+	if !validAddress(previous) {
+		k.announce()
+	}
+}
+
+// announce looks up the local node in the background, announcing it to the
+// nodes nearest to it.
+func (k *Kademlia) announce() {
+	ctx := k.dialer.backgroundCtx
+	k.workers.Go("announce", func() {
+		k.mon.Counter("announce").Inc(1)
+		if err := k.announceSelf(ctx); err != nil {
+			k.log.Warn("could not announce the local node", zap.Error(err))
+		}
+	})
+}
+
+// announceSelf looks up the local node, from the bootstrap nodes when the
+// routing table doesn't know other nodes yet.
+func (k *Kademlia) announceSelf(ctx context.Context) error {
+	self := k.Local().Id
+	known, err := k.routingTable.FindNear(self, 2)
+	if err != nil {
+		return err
+	}
+	bootstrap := true
+	for _, node := range known {
+		if node.Id != self {
+			bootstrap = false
+		}
+	}
+	_, err = k.lookup(WithFreshLookup(ctx), self, bootstrap)
+	if NodeNotFound.Has(err) {
+		return nil
+	}
+	return err
+}
+
+// setAddress changes the address of the local node, returning the previous one.
+func (rt *RoutingTable) setAddress(address string) (previous string, err error) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	previous = rt.self.Address.GetAddress()
+	transport := defaultTransport
+	if rt.self.Address != nil {
+		transport = rt.self.Address.Transport
+	}
+	// the previous address is shared by the copies returned by Local
+	rt.self.Address = &pb.NodeAddress{Transport: transport, Address: address}
+	return previous, rt.putNode(&rt.self.Node)
+}
+
+// withoutUnannounced returns nodes without the local node while it has no
+// valid address, nodes itself isn't modified.
+func (endpoint *Endpoint) withoutUnannounced(nodes []*pb.Node) []*pb.Node {
+	self := endpoint.service.Local().Node
+	if announceable(self) {
+		return nodes
+	}
+	for i, node := range nodes {
+		if node.Id == self.Id {
+			return append(append([]*pb.Node{}, nodes[:i]...), nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,270 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/credentials"
+	grpcpeer "google.golang.org/grpc/peer"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testdial"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestValidAddress(t *testing.T) {
+	for address, valid := range map[string]bool{
+		"":                     false,
+		":7777":                false,
+		"0.0.0.0:7777":         false,
+		"[::]:7777":            false,
+		"127.0.0.1":            false,
+		"node.example.com":     false,
+		"127.0.0.1:7777":       true,
+		"[::1]:7777":           true,
+		"node.example.com:443": true,
+	} {
+		assert.Equal(t, valid, validAddress(address), address)
+	}
+}
+
+// testResolver resolves the host names of the address tests.
+var testResolver = fakeResolver{
+	"node.example.com":           {"203.0.113.1"},
+	"node.localhost.example.com": {"203.0.113.2"},
+	"dualstack.example.com":      {"2001:db8::1", "203.0.113.3"},
+	"loopback.example.com":       {"127.0.0.1"},
+	"internal.example.com":       {"10.0.0.1"},
+	"mixed.example.com":          {"203.0.113.4", "192.168.1.1"},
+}
+
+// fakeResolver resolves host names to the IPs in the map.
+type fakeResolver map[string][]string
+
+func (resolver fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := resolver[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestValidateNodeAddress(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	for _, test := range []struct {
+		address string
+		public  bool // valid regardless of allowPrivate
+		private bool // valid only with allowPrivate
+	}{
+		{address: "8.8.8.8:7777", public: true},
+		{address: "[2001:db8::1]:7777", public: true},
+		{address: "node.example.com:443", public: true},
+		{address: "node.localhost.example.com:443", public: true},
+		{address: "dualstack.example.com:443", public: true},
+		{address: "loopback.example.com:443", private: true},
+		{address: "internal.example.com:443", private: true},
+		{address: "mixed.example.com:443", private: true},
+		{address: "missing.example.com:443", private: true},
+		{address: "127.0.0.1:7777", private: true},
+		{address: "127.1.2.3:7777", private: true},
+		{address: "10.0.0.1:7777", private: true},
+		{address: "172.16.0.1:7777", private: true},
+		{address: "192.168.1.1:7777", private: true},
+		{address: "169.254.1.1:7777", private: true},
+		{address: "[::1]:7777", private: true},
+		{address: "[::ffff:127.0.0.1]:7777", private: true},
+		{address: "[fd00::1]:7777", private: true},
+		{address: "[fe80::1]:7777", private: true},
+		{address: "[fe80::1%eth0]:7777", private: true},
+		{address: "localhost:7777", private: true},
+		{address: "LocalHost.:7777", private: true},
+		{address: "node.localhost:7777", private: true},
+		{address: ""},
+		{address: "8.8.8.8"},
+		{address: "node.example.com"},
+		{address: "[2001:db8::1]"},
+		{address: "8.8.8.8:"},
+		{address: "8.8.8.8:0"},
+		{address: "8.8.8.8:65536"},
+		{address: "8.8.8.8:http"},
+		{address: ":7777"},
+		{address: "0.0.0.0:7777"},
+		{address: "[::]:7777"},
+	} {
+		node := pb.Node{Address: &pb.NodeAddress{Address: test.address}}
+
+		err := validateNodeAddress(ctx, testResolver, node, false)
+		if test.public {
+			assert.NoError(t, err, test.address)
+		} else {
+			assert.True(t, ErrInvalidAddress.Has(err), "%q: %v", test.address, err)
+		}
+
+		err = validateNodeAddress(ctx, testResolver, node, true)
+		if test.public || test.private {
+			assert.NoError(t, err, test.address)
+		} else {
+			assert.True(t, ErrInvalidAddress.Has(err), "%q: %v", test.address, err)
+		}
+	}
+}
+
+func TestSanitizeNodesPrivateAddresses(t *testing.T) {
+	self := storj.NodeID{1}
+	nodes := []*pb.Node{
+		{Id: storj.NodeID{2}, Address: &pb.NodeAddress{Address: "8.8.8.8:7777"}},
+		{Id: storj.NodeID{3}, Address: &pb.NodeAddress{Address: "127.0.0.1:7777"}},
+		{Id: storj.NodeID{4}, Address: &pb.NodeAddress{Address: "localhost:7777"}},
+		{Id: storj.NodeID{5}, Address: &pb.NodeAddress{Address: "8.8.4.4:0"}},
+		{Id: storj.NodeID{6}, Address: &pb.NodeAddress{Address: "node.example.com:7777"}},
+		{Id: storj.NodeID{7}, Address: &pb.NodeAddress{Address: "loopback.example.com:7777"}},
+	}
+	ids := func(nodes []*pb.Node) (ids []storj.NodeID) {
+		for _, node := range nodes {
+			ids = append(ids, node.Id)
+		}
+		return ids
+	}
+
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	dialer := &Dialer{mon: mon, resolver: testResolver}
+	dialer.setSettings(CompressionConfig{}, DialerOptions{}, DefaultRPCPolicies())
+	assert.Equal(t, []storj.NodeID{{2}, {6}}, ids(dialer.sanitizeNodes(ctx, self, nodes)))
+
+	dialer.setSettings(CompressionConfig{}, DialerOptions{AllowPrivateAddresses: true}, DefaultRPCPolicies())
+	assert.Equal(t, []storj.NodeID{{2}, {3}, {4}, {6}, {7}}, ids(dialer.sanitizeNodes(ctx, self, nodes)))
+}
+
+func TestNotReadyUntilAddressValid(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, mock, serverID, serverAddress := startTestNodeServer(ctx)
+	defer server.GracefulStop()
+	ask := pb.Node{Id: serverID.ID, Address: &pb.NodeAddress{Address: serverAddress}}
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+	require.NoError(t, k.routingTable.ConnectionSuccess(&ask))
+
+	endpoint := NewEndpoint(zaptest.NewLogger(t), k, k.routingTable)
+	responds := func() (*pb.Node, bool) {
+		resp, err := endpoint.Query(ctx, &pb.QueryRequest{Target: &pb.Node{Id: fid.ID}, Limit: 20})
+		require.NoError(t, err)
+		for _, node := range resp.Response {
+			if node.Id == fid.ID {
+				return node, true
+			}
+		}
+		return nil, false
+	}
+
+	{ // without an address the node works, but isn't announced
+		require.False(t, k.Ready())
+
+		_, err := k.CheckIn(ctx, ask)
+		require.True(t, ErrNotReady.Has(err), "%v", err)
+		_, err = k.dialer.Lookup(ctx, k.Local().Node, ask, pb.Node{Id: fid.ID})
+		require.True(t, ErrNotReady.Has(err), "%v", err)
+		assert.EqualValues(t, 0, atomic.LoadInt32(&mock.queryCalled))
+
+		_, _ = k.FindNode(ctx, storj.NodeID{0x01})
+		assert.True(t, atomic.LoadInt32(&mock.queryCalled) > 0)
+		assert.EqualValues(t, 0, atomic.LoadInt32(&mock.announced))
+
+		_, err = k.Ping(ctx, ask)
+		require.NoError(t, err)
+
+		_, ok := responds()
+		assert.False(t, ok, "the local node is omitted from responses")
+	}
+
+	{ // invalid addresses leave the node not ready
+		require.True(t, ErrNotReady.Has(k.SetAddress("[::]:7777")))
+		require.False(t, k.Ready())
+	}
+
+	{ // a valid address announces the node
+		require.NoError(t, k.SetAddress("127.0.0.1:7777"))
+		require.True(t, k.Ready())
+
+		for atomic.LoadInt32(&mock.announced) == 0 {
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-ctx.Done():
+				t.Fatal("the node wasn't announced")
+			}
+		}
+
+		self, ok := responds()
+		require.True(t, ok)
+		assert.Equal(t, "127.0.0.1:7777", self.Address.Address)
+
+		_, err := k.CheckIn(ctx, ask)
+		require.NoError(t, err)
+	}
+}
+
+func TestQuerySenderWithoutAddress(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+	recorder := testdial.WithDialRecorder(t, k.dialer.transport)
+	k.dialer.transport = recorder.Client
+
+	sender, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	peerCtx := grpcpeer.NewContext(ctx, &grpcpeer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{sender.Leaf, sender.CA},
+		}},
+	})
+
+	endpoint := NewEndpoint(zaptest.NewLogger(t), k, k.routingTable)
+	query := func(ctx context.Context, address string) {
+		_, err := endpoint.Query(ctx, &pb.QueryRequest{
+			Sender:   &pb.Node{Id: sender.ID, Address: &pb.NodeAddress{Address: address}},
+			Target:   &pb.Node{Id: fid.ID},
+			Limit:    20,
+			Pingback: true,
+		})
+		require.NoError(t, err)
+	}
+
+	// the sender is answered, but not pinged back into the routing table
+	query(peerCtx, "")
+	query(peerCtx, ":7777")
+	recorder.ExpectNone()
+	_, ok := k.routingTable.advertisedAddress(sender.ID)
+	assert.False(t, ok)
+}
@@ -0,0 +1,42 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"storj.io/storj/pkg/storj"
+)
+
+// admitCacheSize is the number of unverified peers the doorkeeper remembers
+// until they are seen again
+const admitCacheSize = 10000
+
+// admit returns whether the information a peer sent about itself, such as its
+// capabilities, should be cached.
+//
+// Peers the dialer verified by dialing them, and peers already admitted, are
+// always admitted. Other peers, such as throwaway identities querying this
+// node, are admitted only when they are seen again while the cheaper
+// doorkeeper still remembers them, so that churning through identities can't
+// evict the entries of known peers.
+func (dialer *Dialer) admit(id storj.NodeID) bool {
+	if id.IsZero() {
+		return false
+	}
+	if _, ok := dialer.identities.entry(id); ok {
+		return true
+	}
+	if _, ok := dialer.capabilities.Peek(id); ok {
+		return true
+	}
+	if _, ok := dialer.protocols.Peek(id); ok {
+		return true
+	}
+	if _, ok := dialer.doorkeeper.Peek(id); ok {
+		dialer.doorkeeper.Remove(id)
+		return true
+	}
+	dialer.doorkeeper.Add(id, struct{}{})
+	dialer.mon.Counter("unverified_peer_deferred").Inc(1)
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"math/rand"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestAdmitUnverifiedPeers(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	dialer := NewDialer(zap.NewNop(), nil)
+	defer ctx.Check(dialer.Close)
+
+	// a peer is admitted when it's seen again
+	peer := RandomNode().Id
+	assert.False(t, dialer.admit(peer))
+	assert.True(t, dialer.admit(peer))
+	dialer.setCapabilities(peer, Capabilities)
+	assert.True(t, dialer.admit(peer), "admitted peers stay admitted")
+
+	assert.False(t, dialer.admit(storj.NodeID{}))
+}
+
+func TestCachesBoundedUnderChurn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("inserts a million node ids")
+	}
+
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	dialer := NewDialer(zap.NewNop(), nil)
+	defer ctx.Check(dialer.Close)
+	dialer.negative = newNegativeCache(NegativeCacheConfig{TTL: time.Hour, Size: 1000})
+	dialer.failures = newDialFailures(zap.NewNop(), FailureConfig{Threshold: 3, BackoffBase: time.Hour, BackoffMax: time.Hour, MaxAge: time.Hour})
+
+	// churn makes every cache see n throwaway identities, as peers querying
+	// this node, dialed peers and nodes lookups didn't find
+	random := rand.New(rand.NewSource(1))
+	now := time.Now()
+	churn := func(n int) {
+		for i := 0; i < n; i++ {
+			var id storj.NodeID
+			_, _ = random.Read(id[:])
+
+			if dialer.admit(id) {
+				dialer.setProtocol(id, 1)
+			}
+			dialer.setCapabilities(id, Capabilities)
+			dialer.failures.dialed(id, syscall.ECONNREFUSED)
+			dialer.negative.add(id, now)
+		}
+	}
+	heap := func() uint64 {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return stats.HeapAlloc
+	}
+
+	// fill the caches before measuring
+	churn(100000)
+	before := heap()
+	evictions := map[string]int64{}
+	for name, cache := range dialer.boundedCaches() {
+		evictions[name] = cache.Evictions()
+	}
+
+	churn(900000)
+	after := heap()
+
+	growth := int64(after) - int64(before)
+	assert.True(t, growth < 8<<20, "heap grew by %d bytes", growth)
+
+	for name, cache := range dialer.boundedCaches() {
+		var capacity float64
+		cache.Stats(func(stat string, val float64) {
+			if stat == "capacity" {
+				capacity = val
+			}
+		})
+		assert.True(t, float64(cache.Len()) <= capacity, "%s exceeds its capacity", name)
+	}
+	for _, name := range []string{"capabilities", "doorkeeper", "failures", "negative"} {
+		assert.True(t, dialer.boundedCaches()[name].Evictions() > evictions[name], "%s didn't evict", name)
+	}
+	// throwaway identities seen once never reach the protocols
+	assert.Zero(t, dialer.protocols.Len())
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+)
+
+// maxQuarantined is the maximum number of nodes in the antechamber
+const maxQuarantined = 100
+
+// Provenance is how what is known about a contact was learned.
+type Provenance int
+
+const (
+	// UnknownProvenance is the provenance of contacts the routing table doesn't know.
+	UnknownProvenance Provenance = iota
+	// VerifiedSource is a dial of the node ID the contact proved to own.
+	VerifiedSource
+	// UnverifiedSource is a dial of an address, such as a bootstrap address,
+	// which any node could have answered with its own identity.
+	UnverifiedSource
+)
+
+// String returns the name of the provenance.
+func (provenance Provenance) String() string {
+	switch provenance {
+	case VerifiedSource:
+		return "verified"
+	case UnverifiedSource:
+		return "unverified"
+	default:
+		return "unknown"
+	}
+}
+
+// QuarantinedNode is a node learned through an unverified dial, kept in the
+// antechamber until it is contacted by a verified dial.
+type QuarantinedNode struct {
+	Node pb.Node
+	// Type is the type the node is pinned with once verified, such as
+	// bootstrap, or unknown when it competes for a bucket slot.
+	Type    pb.NodeType
+	Learned time.Time
+}
+
+// Quarantine keeps node, learned through an unverified dial, in the
+// antechamber: it isn't added to the buckets or pinned, and doesn't answer
+// lookups, until the first verified contact with it. The node is then pinned
+// when nodeType is pinned. Nodes already verified are left as they are.
+func (rt *RoutingTable) Quarantine(node *pb.Node, nodeType pb.NodeType) error {
+	if node.Id.IsZero() || node.Id == rt.self.Id {
+		return nil
+	}
+	if rt.Provenance(node.Id) == VerifiedSource {
+		return nil
+	}
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	quarantined, ok := rt.antechamber[node.Id]
+	if !ok {
+		if len(rt.antechamber) >= maxQuarantined {
+			return RoutingErr.New("can't quarantine more than %d nodes", maxQuarantined)
+		}
+		quarantined = &QuarantinedNode{Learned: time.Now()}
+		rt.antechamber[node.Id] = quarantined
+		rt.mon.Counter("node_quarantined").Inc(1)
+	}
+	quarantined.Node, quarantined.Type = *node, nodeType
+	return nil
+}
+
+// unquarantine removes the node with id from the antechamber.
+func (rt *RoutingTable) unquarantine(id storj.NodeID) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	delete(rt.antechamber, id)
+}
+
+// promote moves node out of the antechamber on its first verified contact,
+// pinning it when it was learned with a pinned type.
+func (rt *RoutingTable) promote(node *pb.Node) {
+	rt.mutex.Lock()
+	quarantined, ok := rt.antechamber[node.Id]
+	delete(rt.antechamber, node.Id)
+	rt.mutex.Unlock()
+	if !ok {
+		return
+	}
+	rt.mon.Counter("node_quarantine_verified").Inc(1)
+
+	if pinnedType(quarantined.Type) {
+		if _, err := rt.Pin(node, quarantined.Type); err != nil {
+			rt.log.Debug("could not pin verified node", zap.Stringer("Node ID", node.Id), zap.Error(err))
+		}
+	}
+}
+
+// Provenance returns how the node with id was learned: nodes in the
+// antechamber are unverified, nodes in the buckets and pinned nodes are
+// verified.
+func (rt *RoutingTable) Provenance(id storj.NodeID) Provenance {
+	rt.mutex.Lock()
+	_, quarantined := rt.antechamber[id]
+	_, pinned := rt.pinned[id]
+	rt.mutex.Unlock()
+
+	switch {
+	case quarantined:
+		return UnverifiedSource
+	case pinned:
+		return VerifiedSource
+	}
+	if _, err := rt.nodeBucketDB.Get(storage.Key(id.Bytes())); err == nil {
+		return VerifiedSource
+	}
+	return UnknownProvenance
+}
+
+// QuarantinedNodes returns the nodes in the antechamber sorted by ID.
+func (rt *RoutingTable) QuarantinedNodes() []QuarantinedNode {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	nodes := make([]QuarantinedNode, 0, len(rt.antechamber))
+	for _, quarantined := range rt.antechamber {
+		nodes = append(nodes, *quarantined)
+	}
+	sort.Slice(nodes, func(i, k int) bool {
+		return nodes[i].Node.Id.Less(nodes[k].Node.Id)
+	})
+	return nodes
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+)
+
+func TestQuarantine(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	k, s, clean := testNode(ctx, "quarantine", t, nil)
+	defer clean()
+	defer s.GracefulStop()
+
+	asker, askerServer, askerClean := testNode(ctx, "asker", t, nil)
+	defer askerClean()
+	defer askerServer.GracefulStop()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	// learn the node through a dial of its address, like a bootstrap node
+	ident, conn, err := k.dialer.FetchPeerIdentityUnverifiedConn(ctx, server.Addr())
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	node := pb.Node{Id: ident.ID, Address: &pb.NodeAddress{Transport: defaultTransport, Address: server.Addr()}}
+	require.NoError(t, k.routingTable.Quarantine(&node, pb.NodeType_BOOTSTRAP))
+	require.NoError(t, k.routingTable.Flush(ctx))
+
+	assert.Equal(t, UnverifiedSource, k.routingTable.Provenance(node.Id))
+	quarantined := k.routingTable.QuarantinedNodes()
+	require.Len(t, quarantined, 1)
+	assert.Equal(t, node.Id, quarantined[0].Node.Id)
+	assert.Equal(t, pb.NodeType_BOOTSTRAP, quarantined[0].Type)
+
+	// answered is whether the node answers lookups, of this node and of other nodes
+	answered := func() (local, remote bool) {
+		near, err := k.FindNear(ctx, node.Id, 10)
+		require.NoError(t, err)
+		response, err := asker.dialer.Lookup(ctx, asker.Local().Node, k.Local().Node, node)
+		require.NoError(t, err)
+		return containsID(near, node.Id), containsID(response, node.Id)
+	}
+
+	for i := 0; i < 3; i++ {
+		local, remote := answered()
+		assert.False(t, local, "quarantined node found locally")
+		assert.False(t, remote, "quarantined node returned to another node")
+	}
+	assert.Empty(t, k.PinnedNodes())
+
+	// the first verified dial promotes the node
+	_, err = k.Ping(ctx, node)
+	require.NoError(t, err)
+	require.NoError(t, k.routingTable.Flush(ctx))
+
+	assert.Equal(t, VerifiedSource, k.routingTable.Provenance(node.Id))
+	assert.Empty(t, k.routingTable.QuarantinedNodes())
+	pinned := k.PinnedNodes()
+	require.Len(t, pinned, 1)
+	assert.Equal(t, node.Id, pinned[0].Node.Id)
+	assert.Equal(t, pb.NodeType_BOOTSTRAP, pinned[0].Type)
+
+	local, remote := answered()
+	assert.True(t, local, "verified node not found locally")
+	assert.True(t, remote, "verified node not returned to another node")
+
+	// learning it through an unverified dial again doesn't quarantine it
+	require.NoError(t, k.routingTable.Quarantine(&node, pb.NodeType_BOOTSTRAP))
+	assert.Equal(t, VerifiedSource, k.routingTable.Provenance(node.Id))
+	assert.Empty(t, k.routingTable.QuarantinedNodes())
+
+	assert.Equal(t, UnknownProvenance, k.routingTable.Provenance(storj.NodeID{1}))
+}
+
+func TestUnverifiedLifetime(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOpts, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	dialer := NewDialer(zaptest.NewLogger(t), transport.NewClient(tlsOpts))
+	defer ctx.Check(dialer.Close)
+
+	lifetime := 100 * time.Millisecond
+	dialer.setSettings(CompressionConfig{}, DialerOptions{UnverifiedLifetime: lifetime}, DefaultRPCPolicies())
+
+	{ // a released connection that is never used is closed
+		start := time.Now()
+		_, conn, err := dialer.FetchPeerIdentityUnverifiedConn(ctx, server.Addr())
+		require.NoError(t, err)
+		grpcconn := conn.conn
+		require.NoError(t, conn.Release())
+
+		assert.True(t, closedConn(ctx, grpcconn), "released connection wasn't closed")
+		assert.True(t, time.Since(start) >= lifetime, "closed after %v", time.Since(start))
+		assert.Nil(t, dialer.takeHandedBack(server.ID()))
+	}
+
+	{ // so is a connection kept by its owner, which can't release it anymore
+		_, conn, err := dialer.FetchPeerIdentityUnverifiedConn(ctx, server.Addr())
+		require.NoError(t, err)
+
+		assert.True(t, closedConn(ctx, conn.conn), "kept connection wasn't closed")
+		_, err = conn.Client().Ping(ctx, &pb.PingRequest{})
+		assert.Error(t, err)
+		require.NoError(t, conn.Release())
+		assert.Nil(t, dialer.takeHandedBack(server.ID()))
+	}
+
+	dialer.setSettings(CompressionConfig{}, DialerOptions{}, DefaultRPCPolicies())
+
+	{ // without a lifetime, a verified dial of the node closes the connection
+		_, conn, err := dialer.FetchPeerIdentityUnverifiedConn(ctx, server.Addr())
+		require.NoError(t, err)
+
+		_, err = dialer.PingNode(ctx, server.Node())
+		require.NoError(t, err)
+		assert.True(t, closedConn(ctx, conn.conn), "superseded connection wasn't closed")
+	}
+
+	{ // and connections closed by their owners are no longer tracked
+		_, conn, err := dialer.FetchPeerIdentityUnverifiedConn(ctx, server.Addr())
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		dialer.handedBackMu.Lock()
+		assert.Empty(t, dialer.unverified)
+		dialer.handedBackMu.Unlock()
+	}
+}
+
+// closedConn returns whether conn is closed within a few seconds.
+func closedConn(ctx context.Context, conn *grpc.ClientConn) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for state := conn.GetState(); state != connectivity.Shutdown; state = conn.GetState() {
+		if !conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsID returns whether nodes contains the node with id.
+func containsID(nodes []*pb.Node, id storj.NodeID) bool {
+	for _, node := range nodes {
+		if node.Id == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+)
+
+// batchWriter writes the values of nodes to db in batches, by a job on
+// workers, so that frequent changes don't wait for the db. Only the last
+// queued value of a node is written.
+type batchWriter struct {
+	log     *zap.Logger
+	db      storage.KeyValueStore
+	workers *WorkerPool
+	job     string
+
+	mu      sync.Mutex
+	pending map[storj.NodeID]storage.Value // nil values are deleted
+	writing bool
+}
+
+// newBatchWriter creates a writer to db running as job on workers.
+func newBatchWriter(log *zap.Logger, db storage.KeyValueStore, workers *WorkerPool, job string) *batchWriter {
+	return &batchWriter{
+		log:     log,
+		db:      db,
+		workers: workers,
+		job:     job,
+		pending: make(map[storj.NodeID]storage.Value),
+	}
+}
+
+// persist queues value to be written for id, a nil value deletes it.
+func (writer *batchWriter) persist(id storj.NodeID, value storage.Value) {
+	writer.mu.Lock()
+	writer.pending[id] = value
+	start := !writer.writing
+	writer.writing = true
+	writer.mu.Unlock()
+
+	// the pool is closed only when the dialer is, write the last changes directly
+	if start && !writer.workers.Go(writer.job, writer.write) {
+		writer.write()
+	}
+}
+
+// write writes the queued changes in batches until none are left.
+func (writer *batchWriter) write() {
+	for {
+		writer.mu.Lock()
+		batch := writer.pending
+		if len(batch) == 0 {
+			writer.writing = false
+			writer.mu.Unlock()
+			return
+		}
+		writer.pending = make(map[storj.NodeID]storage.Value)
+		writer.mu.Unlock()
+
+		for id, value := range batch {
+			var err error
+			if value == nil {
+				err = writer.db.Delete(id.Bytes())
+				if storage.ErrKeyNotFound.Has(err) {
+					err = nil
+				}
+			} else {
+				err = writer.db.Put(id.Bytes(), value)
+			}
+			if err != nil {
+				writer.log.Warn("failed to persist", zap.String("job", writer.job), zap.Stringer("Node ID", id), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"math/bits"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// BootstrapValidationConfig limits how much a single bootstrap node can shape
+// the routing table of a joining node.
+type BootstrapValidationConfig struct {
+	MaxNodes    int `help:"maximum number of nodes from a single bootstrap response used directly for bootstrapping, zero disables the limit" default:"8"`
+	MinPrefixes int `help:"minimum number of distinct distance prefixes the directly used nodes of a bootstrap response must span" default:"3"`
+	MinNetworks int `help:"minimum number of distinct /16 networks the directly used nodes of a bootstrap response must span" default:"2"`
+}
+
+// split splits the nodes of a bootstrap response into nodes used directly,
+// nodes that must be verified before use, and the number of invalid nodes.
+//
+// Nodes are picked across distance prefixes in turn, when the picked nodes
+// aren't diverse enough all of them must be verified first.
+func (config BootstrapValidationConfig) split(self storj.NodeID, nodes []*pb.Node) (accepted, deferred []*pb.Node, rejected int) {
+	seen := map[storj.NodeID]bool{}
+	byPrefix := map[int][]*pb.Node{}
+	var prefixes []int
+	for _, node := range nodes {
+		if node == nil || node.Id.IsZero() || node.Id == self || seen[node.Id] || node.GetAddress().GetAddress() == "" {
+			rejected++
+			continue
+		}
+		seen[node.Id] = true
+
+		prefix := commonPrefixLength(self, node.Id)
+		if _, ok := byPrefix[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		byPrefix[prefix] = append(byPrefix[prefix], node)
+	}
+
+	for picked := true; picked; {
+		picked = false
+		for _, prefix := range prefixes {
+			group := byPrefix[prefix]
+			if len(group) == 0 {
+				continue
+			}
+			byPrefix[prefix] = group[1:]
+			picked = true
+
+			if config.MaxNodes > 0 && len(accepted) >= config.MaxNodes {
+				deferred = append(deferred, group[0])
+			} else {
+				accepted = append(accepted, group[0])
+			}
+		}
+	}
+
+	if countPrefixes(self, accepted) < config.MinPrefixes || countNetworks(accepted) < config.MinNetworks {
+		return nil, append(accepted, deferred...), rejected
+	}
+	return accepted, deferred, rejected
+}
+
+// commonPrefixLength returns the number of leading bits a and b have in common.
+func commonPrefixLength(a, b storj.NodeID) int {
+	distance := xorNodeID(a, b)
+	for i, v := range distance {
+		if v != 0 {
+			return i*8 + bits.LeadingZeros8(v)
+		}
+	}
+	return len(distance) * 8
+}
+
+// countPrefixes returns the number of distinct distance prefixes of nodes from self.
+func countPrefixes(self storj.NodeID, nodes []*pb.Node) int {
+	prefixes := map[int]struct{}{}
+	for _, node := range nodes {
+		prefixes[commonPrefixLength(self, node.Id)] = struct{}{}
+	}
+	return len(prefixes)
+}
+
+// countNetworks returns the number of distinct /16 (IPv4) or /32 (IPv6) networks of nodes.
+func countNetworks(nodes []*pb.Node) int {
+	networks := map[string]struct{}{}
+	for _, node := range nodes {
+		networks[maskedNetworkOf(node.GetAddress().GetAddress(), 16, 32)] = struct{}{}
+	}
+	return len(networks)
+}
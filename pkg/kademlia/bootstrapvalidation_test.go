@@ -0,0 +1,170 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/eventlog"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestBootstrapValidationSplit(t *testing.T) {
+	self := randomNodeID(t)
+	config := BootstrapValidationConfig{MaxNodes: 8, MinPrefixes: 3, MinNetworks: 2}
+
+	{ // nodes from a single prefix and network are all deferred
+		var nodes []*pb.Node
+		for i := 0; i < 10; i++ {
+			nodes = append(nodes, nodeAtPrefix(t, self, 0, fmt.Sprintf("10.0.0.%d:7777", i)))
+		}
+		invalid := []*pb.Node{
+			nil,
+			{Id: self, Address: &pb.NodeAddress{Address: "10.0.0.1:7777"}},
+			{Id: nodes[0].Id, Address: nodes[0].Address},
+			{Id: randomNodeID(t)},
+		}
+
+		accepted, deferred, rejected := config.split(self, append(nodes, invalid...))
+		assert.Empty(t, accepted)
+		assert.ElementsMatch(t, nodes, deferred)
+		assert.Equal(t, len(invalid), rejected)
+	}
+
+	{ // diverse nodes are accepted up to the limit, spread across prefixes
+		var nodes []*pb.Node
+		for prefix := 0; prefix < 4; prefix++ {
+			for i := 0; i < 3; i++ {
+				nodes = append(nodes, nodeAtPrefix(t, self, prefix, fmt.Sprintf("10.%d.0.%d:7777", prefix, i)))
+			}
+		}
+
+		accepted, deferred, rejected := config.split(self, nodes)
+		require.Len(t, accepted, 8)
+		assert.Len(t, deferred, 4)
+		assert.Zero(t, rejected)
+		assert.Equal(t, 4, countPrefixes(self, accepted))
+		assert.Equal(t, 4, countNetworks(accepted))
+	}
+
+	{ // a zero config accepts all valid nodes
+		nodes := []*pb.Node{nodeAtPrefix(t, self, 0, "10.0.0.1:7777"), nodeAtPrefix(t, self, 0, "10.0.0.2:7777")}
+		accepted, deferred, _ := BootstrapValidationConfig{}.split(self, nodes)
+		assert.Equal(t, nodes, accepted)
+		assert.Empty(t, deferred)
+	}
+}
+
+func TestBootstrapResponseValidation(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, mock, _, address := startTestNodeServer(ctx)
+	defer server.GracefulStop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	unreachable := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	// bootstrap uses the nodes returned by the bootstrap node as lookup hops,
+	// unless they must be verified first
+	bootstrap := func(name string, config BootstrapValidationConfig) (lookups, pings int) {
+		ident, err := testidentity.NewTestIdentity(ctx)
+		require.NoError(t, err)
+
+		var crafted []*pb.Node
+		for i := 0; i < 5; i++ {
+			crafted = append(crafted, nodeAtPrefix(t, ident.ID, 0, unreachable))
+		}
+		mock.returnValue = crafted
+
+		bootstrapNodes := []pb.Node{{Address: &pb.NodeAddress{Address: address}}}
+		k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, bootstrapNodes, "127.0.0.1:0", pb.NodeOperator{}, ident, ctx.Dir(name), defaultAlpha)
+		require.NoError(t, err)
+		defer ctx.Check(k.Close)
+		k.bootstrapValidation = config
+
+		path := filepath.Join(ctx.Dir(name), "events.jsonl")
+		events, err := eventlog.Open(zaptest.NewLogger(t), eventlog.Config{Path: path, MaxSize: memory.MiB, QueueSize: memory.MiB})
+		require.NoError(t, err)
+		k.SetEventSink(events)
+
+		require.NoError(t, k.Bootstrap(ctx))
+		require.NoError(t, k.Events().Flush(ctx))
+		require.NoError(t, events.Close())
+
+		recorded, err := eventlog.ReadFile(path)
+		require.NoError(t, err)
+
+		craftedIDs := map[string]bool{}
+		for _, node := range crafted {
+			craftedIDs[node.Id.String()] = true
+		}
+		for _, event := range recorded {
+			if !craftedIDs[event.PeerID] {
+				continue
+			}
+			switch event.Operation {
+			case "lookup":
+				lookups++
+			case "ping":
+				pings++
+			}
+		}
+		return lookups, pings
+	}
+
+	lookups, pings := bootstrap("unvalidated", BootstrapValidationConfig{})
+	assert.NotZero(t, lookups)
+	assert.Zero(t, pings)
+
+	lookups, pings = bootstrap("validated", BootstrapValidationConfig{MaxNodes: 8, MinPrefixes: 3, MinNetworks: 2})
+	assert.Zero(t, lookups)
+	assert.Equal(t, 5, pings)
+}
+
+// nodeAtPrefix returns a random node that has prefix leading bits in common with self.
+func nodeAtPrefix(t testing.TB, self storj.NodeID, prefix int, address string) *pb.Node {
+	id := randomNodeID(t)
+	for i := 0; i < prefix; i++ {
+		mask := byte(0x80) >> uint(i%8)
+		id[i/8] = id[i/8]&^mask | self[i/8]&mask
+	}
+	mask := byte(0x80) >> uint(prefix%8)
+	id[prefix/8] = id[prefix/8]&^mask | ^self[prefix/8]&mask
+	require.Equal(t, prefix, commonPrefixLength(self, id))
+
+	return &pb.Node{Id: id, Address: &pb.NodeAddress{Address: address}}
+}
+
+func randomNodeID(t testing.TB) storj.NodeID {
+	var id storj.NodeID
+	_, err := rand.Read(id[:])
+	require.NoError(t, err)
+	return id
+}
+
+func TestIsBootstrapNode(t *testing.T) {
+	lookup := &peerDiscovery{opts: discoveryOptions{
+		bootstrapNodes: []pb.Node{{Address: &pb.NodeAddress{Address: "127.0.0.1:7777"}}},
+	}}
+
+	assert.True(t, lookup.isBootstrapNode(&pb.Node{Id: storj.NodeID{1}, Address: &pb.NodeAddress{Address: "127.0.0.1:7777"}}))
+	// the bootstrap node IDs are only learnt when bootstrapping
+	assert.False(t, lookup.isBootstrapNode(&pb.Node{Address: &pb.NodeAddress{Address: "127.0.0.1:8888"}}))
+	assert.False(t, lookup.isBootstrapNode(&pb.Node{}))
+}
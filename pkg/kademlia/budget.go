@@ -0,0 +1,259 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"sort"
+
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/pkg/storj"
+)
+
+const (
+	// approximate memory used by a node in the seen map or in a replacement cache
+	nodeEntrySize = 256 * memory.B
+	// approximate memory used by a successful connection timestamp
+	lastSuccessEntrySize = 64 * memory.B
+	// approximate memory used by a verified peer identity and its certificates
+	identityEntrySize = 4 * memory.KiB
+	// approximate memory used by a per-peer entry of the dialer or the negative cache
+	peerEntrySize = 64 * memory.B
+)
+
+// budgetedCache is a cache outside of the routing table counted against its memory budget.
+type budgetedCache interface {
+	// memoryUsage returns the approximate memory used by the cache.
+	memoryUsage() memory.Size
+	// evict evicts the least valuable entries until at least size has been
+	// freed or the cache is empty, and returns the memory freed.
+	evict(size memory.Size) memory.Size
+}
+
+// budgetCaches counts caches against the memory budget, they are evicted in
+// the given order after the replacement caches.
+func (rt *RoutingTable) budgetCaches(caches ...budgetedCache) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	rt.caches = append(rt.caches, caches...)
+}
+
+// MemoryUsage returns the approximate memory used by the in-memory
+// structures of the routing table and the caches counted against its budget,
+// the nodes themselves are stored in the node bucket database.
+func (rt *RoutingTable) MemoryUsage() memory.Size {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	rt.rcMutex.Lock()
+	defer rt.rcMutex.Unlock()
+
+	usage := rt.memoryUsage()
+	for _, cache := range rt.caches {
+		usage += cache.memoryUsage()
+	}
+	return usage
+}
+
+// memoryUsage returns the approximate memory usage of the routing table
+// structures, rt.mutex and rt.rcMutex must be held.
+func (rt *RoutingTable) memoryUsage() memory.Size {
+	cached := 0
+	for _, nodes := range rt.replacementCache {
+		cached += len(nodes)
+	}
+	return memory.Size(len(rt.seen)+cached)*nodeEntrySize +
+		memory.Size(len(rt.lastSuccess))*lastSuccessEntrySize +
+		memory.Size(len(rt.history))*peerEntrySize
+}
+
+// enforceMemoryBudget evicts in-memory entries until the usage is within the budget.
+//
+// The least valuable entries are evicted first: the oldest entries of the
+// largest replacement caches, then the budgeted caches, and finally the
+// oldest successful connection timestamps. Seen nodes are never evicted,
+// since discovery depends on them. A zero budget disables eviction.
+func (rt *RoutingTable) enforceMemoryBudget() {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	rt.rcMutex.Lock()
+	defer rt.rcMutex.Unlock()
+
+	usage := rt.memoryUsage()
+	for _, cache := range rt.caches {
+		usage += cache.memoryUsage()
+	}
+	defer func() {
+		rt.mon.IntVal("routing_table_memory_usage").Observe(usage.Int64())
+	}()
+
+	if rt.memoryBudget <= 0 || usage <= rt.memoryBudget {
+		return
+	}
+
+	excess := usage - rt.memoryBudget
+	freed := rt.evictReplacementCache(excess)
+	for _, cache := range rt.caches {
+		if freed >= excess {
+			break
+		}
+		freed += cache.evict(excess - freed)
+	}
+	if freed < excess {
+		freed += rt.evictLastSuccess(excess - freed)
+	}
+	usage -= freed
+
+	if usage > rt.memoryBudget {
+		rt.mon.Event("routing_table_memory_budget_exceeded")
+	}
+}
+
+// evictReplacementCache evicts the oldest nodes of the largest replacement
+// caches until at least size is freed, rt.rcMutex must be held.
+func (rt *RoutingTable) evictReplacementCache(size memory.Size) (freed memory.Size) {
+	for freed < size {
+		var largest bucketID
+		var largestSize int
+		for id, nodes := range rt.replacementCache {
+			if len(nodes) > largestSize {
+				largest, largestSize = id, len(nodes)
+			}
+		}
+		if largestSize == 0 {
+			return freed
+		}
+
+		rt.replacementCache[largest] = rt.replacementCache[largest][1:]
+		if len(rt.replacementCache[largest]) == 0 {
+			delete(rt.replacementCache, largest)
+		}
+		freed += nodeEntrySize
+	}
+	return freed
+}
+
+// evictLastSuccess evicts the oldest successful connection timestamps until
+// at least size is freed, rt.mutex must be held.
+func (rt *RoutingTable) evictLastSuccess(size memory.Size) (freed memory.Size) {
+	ids := make([]storj.NodeID, 0, len(rt.lastSuccess))
+	for id := range rt.lastSuccess {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, k int) bool {
+		return rt.lastSuccess[ids[i]].Before(rt.lastSuccess[ids[k]])
+	})
+	for _, id := range ids {
+		if freed >= size {
+			break
+		}
+		delete(rt.lastSuccess, id)
+		freed += lastSuccessEntrySize
+	}
+	return freed
+}
+
+// memoryUsage returns the approximate memory used by the remembered nodes.
+func (cache *negativeCache) memoryUsage() memory.Size {
+	if cache == nil {
+		return 0
+	}
+	return memory.Size(cache.expires.Len()) * peerEntrySize
+}
+
+// evict forgets the nodes that expire first until at least size is freed.
+func (cache *negativeCache) evict(size memory.Size) (freed memory.Size) {
+	if cache == nil {
+		return 0
+	}
+	for freed < size && cache.expires.RemoveOldest() {
+		freed += peerEntrySize
+	}
+	return freed
+}
+
+// memoryUsage returns the approximate memory used by the cached identities.
+func (cache *identityCache) memoryUsage() memory.Size {
+	return memory.Size(cache.entries.Len()) * identityEntrySize
+}
+
+// evict forgets the least recently used identities until at least size is
+// freed. Persisted identities are kept in the database.
+func (cache *identityCache) evict(size memory.Size) (freed memory.Size) {
+	for freed < size && cache.entries.RemoveOldest() {
+		freed += identityEntrySize
+	}
+	return freed
+}
+
+// memoryUsage returns the approximate memory used by the information remembered about peers.
+func (dialer *Dialer) memoryUsage() memory.Size {
+	entries := dialer.capabilities.Len() + dialer.orderViolations.Len() + dialer.protocols.Len()
+	return memory.Size(entries) * peerEntrySize
+}
+
+// evict forgets the least recently used capabilities, order violations and
+// protocol versions, in that order, until at least size is freed. Protocol
+// versions persisted with identities are kept in the database.
+func (dialer *Dialer) evict(size memory.Size) (freed memory.Size) {
+	for _, cache := range []*lrucache.Cache{dialer.capabilities, dialer.orderViolations, dialer.protocols} {
+		for freed < size && cache.RemoveOldest() {
+			freed += peerEntrySize
+		}
+	}
+	return freed
+}
+
+// memoryUsage returns the approximate memory used by the dial failure statistics.
+func (failures *dialFailures) memoryUsage() memory.Size {
+	if failures == nil {
+		return 0
+	}
+	return memory.Size(failures.entries.Len()) * peerEntrySize
+}
+
+// evict forgets the peers that failed least recently until at least size is
+// freed. Persisted statistics are kept in the database.
+func (failures *dialFailures) evict(size memory.Size) (freed memory.Size) {
+	if failures == nil {
+		return 0
+	}
+	for freed < size && failures.entries.RemoveOldest() {
+		freed += peerEntrySize
+	}
+	return freed
+}
+
+// boundedCaches returns the caches of the dialer keyed by node id, by name.
+func (dialer *Dialer) boundedCaches() map[string]*lrucache.Cache {
+	caches := map[string]*lrucache.Cache{
+		"capabilities":     dialer.capabilities,
+		"protocols":        dialer.protocols,
+		"order_violations": dialer.orderViolations,
+		"self_dials":       dialer.selfDials,
+		"clock_offsets":    dialer.clockOffsets,
+		"doorkeeper":       dialer.doorkeeper,
+		"identities":       dialer.identities.entries,
+	}
+	if dialer.negative != nil {
+		caches["negative"] = dialer.negative.expires
+	}
+	if dialer.failures != nil {
+		caches["failures"] = dialer.failures.entries
+	}
+	return caches
+}
+
+// cacheStats reports the entries, capacity and evictions of every cache
+// keyed by node id, such as capabilities_entries.
+func (k *Kademlia) cacheStats(cb func(name string, val float64)) {
+	caches := k.dialer.boundedCaches()
+	if k.routingTable.evicted != nil {
+		caches["evicted_contacts"] = k.routingTable.evicted.entries
+	}
+	for name, cache := range caches {
+		cache.Stats(func(stat string, val float64) {
+			cb(name+"_"+stat, val)
+		})
+	}
+}
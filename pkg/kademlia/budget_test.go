@@ -0,0 +1,155 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTable(teststorj.NodeIDFromString("AA"))
+	defer ctx.Check(rt.Close)
+
+	// fill resets the routing table to 10 seen nodes, 2 replacement caches
+	// with 3 and 1 nodes, and 10 connection timestamps.
+	fill := func() {
+		rt.seen = map[storj.NodeID]*pb.Node{}
+		rt.lastSuccess = map[storj.NodeID]time.Time{}
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			node := RandomNode()
+			rt.seen[node.Id] = &node
+			rt.lastSuccess[node.Id] = now.Add(time.Duration(i) * time.Second)
+		}
+
+		rt.replacementCache = map[bucketID][]*pb.Node{}
+		for i := 0; i < 3; i++ {
+			node := RandomNode()
+			rt.replacementCache[firstBucketID] = append(rt.replacementCache[firstBucketID], &node)
+		}
+		node := RandomNode()
+		rt.replacementCache[emptyBucketID] = []*pb.Node{&node}
+	}
+
+	fill()
+	full := rt.MemoryUsage()
+	require.Equal(t, 14*nodeEntrySize+10*lastSuccessEntrySize, full)
+
+	{ // zero budget disables eviction
+		rt.memoryBudget = 0
+		rt.enforceMemoryBudget()
+		assert.Equal(t, full, rt.MemoryUsage())
+	}
+
+	{ // the oldest nodes of the largest replacement cache are evicted first
+		fill()
+		oldest := rt.replacementCache[firstBucketID][0]
+		rt.memoryBudget = full - nodeEntrySize
+		rt.enforceMemoryBudget()
+		assert.Len(t, rt.replacementCache[firstBucketID], 2)
+		assert.NotContains(t, rt.replacementCache[firstBucketID], oldest)
+		assert.Len(t, rt.replacementCache[emptyBucketID], 1)
+		assert.Len(t, rt.seen, 10)
+		assert.Len(t, rt.lastSuccess, 10)
+		assert.True(t, rt.MemoryUsage() <= rt.memoryBudget)
+	}
+
+	{ // then the budgeted caches, in order
+		fill()
+		negative := newNegativeCache(NegativeCacheConfig{TTL: time.Minute, Size: 10})
+		for i := 0; i < 3; i++ {
+			negative.add(RandomNode().Id, time.Now())
+		}
+		identities := newIdentityCache(zaptest.NewLogger(t), nil, nil, IdentityCacheConfig{})
+		identities.verified(testidentity.MustPregeneratedIdentity(0, storj.LatestIDVersion()).PeerIdentity())
+		rt.caches = []budgetedCache{negative, identities}
+		defer func() { rt.caches = nil }()
+
+		rt.memoryBudget = full - 4*nodeEntrySize + peerEntrySize + identityEntrySize
+		rt.enforceMemoryBudget()
+		assert.Len(t, rt.replacementCache, 0)
+		assert.Equal(t, 1, negative.stats().Entries)
+		assert.Equal(t, 1, identities.entries.Len())
+
+		rt.memoryBudget = full - 4*nodeEntrySize
+		rt.enforceMemoryBudget()
+		assert.Equal(t, 0, negative.stats().Entries)
+		assert.Equal(t, 0, identities.entries.Len())
+		assert.Len(t, rt.seen, 10)
+		assert.Len(t, rt.lastSuccess, 10)
+		assert.True(t, rt.MemoryUsage() <= rt.memoryBudget)
+	}
+
+	{ // and finally the oldest connection timestamps, but never the seen nodes
+		fill()
+		var newest storj.NodeID
+		for id, at := range rt.lastSuccess {
+			if newest.IsZero() || at.After(rt.lastSuccess[newest]) {
+				newest = id
+			}
+		}
+		rt.memoryBudget = 10*nodeEntrySize + lastSuccessEntrySize
+		rt.enforceMemoryBudget()
+		assert.Len(t, rt.seen, 10)
+		assert.Len(t, rt.replacementCache, 0)
+		require.Len(t, rt.lastSuccess, 1)
+		assert.Contains(t, rt.lastSuccess, newest)
+		assert.Equal(t, rt.memoryBudget, rt.MemoryUsage())
+
+		// a budget smaller than the seen nodes can't be met
+		rt.memoryBudget = nodeEntrySize
+		rt.enforceMemoryBudget()
+		assert.Len(t, rt.seen, 10)
+		assert.Len(t, rt.lastSuccess, 0)
+	}
+
+	{ // budget is enforced on connection success
+		fill()
+		rt.memoryBudget = memory.Size(len(rt.seen)+1)*nodeEntrySize + memory.Size(len(rt.lastSuccess))*lastSuccessEntrySize
+		node := RandomNode()
+		node.Address = &pb.NodeAddress{Address: "127.0.0.1:7777"}
+		require.NoError(t, rt.ConnectionSuccess(&node))
+		assert.True(t, rt.MemoryUsage() <= rt.memoryBudget)
+	}
+}
+
+func TestDialerOrderViolationsBounded(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	dialer := NewDialer(zap.NewNop(), nil)
+	defer ctx.Check(dialer.Close)
+
+	first := RandomNode()
+	dialer.orderViolation(first, storj.NodeID{})
+	dialer.orderViolation(first, storj.NodeID{})
+	require.Equal(t, int64(2), dialer.OrderViolations(first.Id))
+
+	// the least recently violating peers are forgotten
+	for i := 0; i < peerCacheSize; i++ {
+		dialer.orderViolation(RandomNode(), storj.NodeID{})
+	}
+	assert.Equal(t, peerCacheSize, dialer.orderViolations.Len())
+	assert.Equal(t, int64(0), dialer.OrderViolations(first.Id))
+	assert.Equal(t, memory.Size(peerCacheSize)*peerEntrySize, dialer.memoryUsage())
+
+	assert.Equal(t, 2*peerEntrySize, dialer.evict(2*peerEntrySize))
+	assert.Equal(t, peerCacheSize-2, dialer.orderViolations.Len())
+}
@@ -0,0 +1,256 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"storj.io/storj/pkg/storj"
+)
+
+// defaultSubscriptionSize is the number of events waiting for a subscriber of
+// kademlia before further events are dropped for it.
+const defaultSubscriptionSize = 1024
+
+// EventType is the kind of a kademlia lifecycle event.
+type EventType int
+
+const (
+	// EventNodeAdded is published when a node is added to the buckets.
+	EventNodeAdded EventType = iota + 1
+	// EventNodeEvicted is published when a failing node is removed from the buckets.
+	EventNodeEvicted
+	// EventContactSucceeded is published when a request to or from a peer succeeded.
+	EventContactSucceeded
+	// EventContactFailed is published when a request to or from a peer failed.
+	EventContactFailed
+	// EventBootstrapFinished is published when bootstrapping finished, successfully or not.
+	EventBootstrapFinished
+	// EventNeighborhoodChanged is published when the closest nodes changed significantly.
+	EventNeighborhoodChanged
+	// EventAddressChanged is published when a node turned out to be at another address.
+	EventAddressChanged
+)
+
+// String returns the name of the event type.
+func (typ EventType) String() string {
+	switch typ {
+	case EventNodeAdded:
+		return "node_added"
+	case EventNodeEvicted:
+		return "node_evicted"
+	case EventContactSucceeded:
+		return "contact_succeeded"
+	case EventContactFailed:
+		return "contact_failed"
+	case EventBootstrapFinished:
+		return "bootstrap_finished"
+	case EventNeighborhoodChanged:
+		return "neighborhood_changed"
+	case EventAddressChanged:
+		return "address_changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a kademlia lifecycle event, only the fields of its type are set.
+type Event struct {
+	Type EventType
+	Time time.Time
+
+	// Node and Address are the node the event is about, the peer of contacts.
+	Node    storj.NodeID
+	Address string
+
+	// Operation and Direction are the request of contacts, Duration is how
+	// long contacts and bootstrapping took, Err why they failed.
+	Operation string
+	Direction string
+	Duration  time.Duration
+	Err       error
+
+	// PreviousAddress is the address of the node before an address change.
+	PreviousAddress string
+	// Neighborhood is the change of the closest nodes.
+	Neighborhood *NeighborhoodChange
+
+	// flushed is closed by the subscription handling it instead of the handler
+	flushed chan struct{}
+}
+
+// Bus publishes kademlia events to subscribers. Publishing never blocks, a
+// subscriber not keeping up loses the events that don't fit its queue, the
+// other subscribers don't notice.
+type Bus struct {
+	mu sync.Mutex
+	// []*Subscription, replaced instead of changed so that Publish doesn't lock
+	subscriptions atomic.Value
+}
+
+// NewBus returns a bus without subscribers.
+func NewBus() *Bus {
+	bus := &Bus{}
+	bus.subscriptions.Store([]*Subscription(nil))
+	return bus
+}
+
+// Subscribe calls handle with the events published after it returns, in the
+// order they were published, from a goroutine of the subscription. At most
+// size events wait to be handled, further ones are dropped.
+func (bus *Bus) Subscribe(name string, size int, handle func(Event)) *Subscription {
+	sub := &Subscription{
+		bus:    bus,
+		name:   name,
+		queue:  make(chan Event, size),
+		handle: handle,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go sub.run()
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	subs := bus.load()
+	bus.subscriptions.Store(append(subs[:len(subs):len(subs)], sub))
+	return sub
+}
+
+// Publish queues event for each subscriber without waiting for them.
+func (bus *Bus) Publish(event Event) {
+	if bus == nil {
+		return
+	}
+	for _, sub := range bus.load() {
+		sub.offer(event)
+	}
+}
+
+// Flush waits until the subscribers handled the events published before.
+func (bus *Bus) Flush(ctx context.Context) error {
+	if bus == nil {
+		return nil
+	}
+	for _, sub := range bus.load() {
+		if err := sub.flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close handles the queued events and stops the subscriptions.
+func (bus *Bus) Close() {
+	if bus == nil {
+		return
+	}
+	for _, sub := range bus.load() {
+		sub.Close()
+	}
+}
+
+func (bus *Bus) load() []*Subscription {
+	subs, _ := bus.subscriptions.Load().([]*Subscription)
+	return subs
+}
+
+// unsubscribe removes sub from the subscriptions.
+func (bus *Bus) unsubscribe(sub *Subscription) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	subs := bus.load()
+	remaining := make([]*Subscription, 0, len(subs))
+	for _, other := range subs {
+		if other != sub {
+			remaining = append(remaining, other)
+		}
+	}
+	bus.subscriptions.Store(remaining)
+}
+
+// Subscription is a subscriber of a bus.
+type Subscription struct {
+	bus     *Bus
+	name    string
+	queue   chan Event
+	handle  func(Event)
+	dropped int64
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Name returns the name the subscription was made with.
+func (sub *Subscription) Name() string { return sub.name }
+
+// Dropped returns the number of events dropped because the queue was full.
+func (sub *Subscription) Dropped() int64 { return atomic.LoadInt64(&sub.dropped) }
+
+// Close handles the queued events and stops the subscription.
+func (sub *Subscription) Close() {
+	sub.bus.unsubscribe(sub)
+	sub.stopOnce.Do(func() { close(sub.stop) })
+	<-sub.done
+}
+
+// offer queues event unless the queue is full.
+func (sub *Subscription) offer(event Event) {
+	select {
+	case sub.queue <- event:
+	default:
+		atomic.AddInt64(&sub.dropped, 1)
+	}
+}
+
+// flush waits until the events queued before are handled. Unlike events,
+// the marker it queues isn't dropped but waited for.
+func (sub *Subscription) flush(ctx context.Context) error {
+	marker := Event{flushed: make(chan struct{})}
+	select {
+	case sub.queue <- marker:
+	case <-sub.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-marker.flushed:
+		return nil
+	case <-sub.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (sub *Subscription) run() {
+	defer close(sub.done)
+	for {
+		select {
+		case event := <-sub.queue:
+			sub.deliver(event)
+		case <-sub.stop:
+			for {
+				select {
+				case event := <-sub.queue:
+					sub.deliver(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (sub *Subscription) deliver(event Event) {
+	if event.flushed != nil {
+		close(event.flushed)
+		return
+	}
+	sub.handle(event)
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestBusPublishesOnce(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, _, identity, address := startTestNodeServer(ctx)
+	defer server.GracefulStop()
+	node := pb.Node{Id: identity.ID, Address: &pb.NodeAddress{Address: address}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	offline := pb.Node{
+		Id:      teststorj.NodeIDFromString("offline"),
+		Address: &pb.NodeAddress{Address: listener.Addr().String()},
+	}
+	require.NoError(t, listener.Close())
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	var mu sync.Mutex
+	published := map[EventType]int{}
+	k.Events().Subscribe("test", 100, func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		published[event.Type]++
+	})
+	counts := func() map[EventType]int {
+		require.NoError(t, k.FlushRoutingTable(ctx))
+		require.NoError(t, k.Events().Flush(ctx))
+		mu.Lock()
+		defer mu.Unlock()
+		counts := map[EventType]int{}
+		for typ, count := range published {
+			counts[typ] = count
+		}
+		return counts
+	}
+
+	require.NoError(t, k.Bootstrap(ctx))
+
+	// the node is added when it's first contacted
+	for i := 0; i < 2; i++ {
+		_, err = k.Ping(ctx, node)
+		require.NoError(t, err)
+	}
+	_, err = k.Ping(ctx, offline)
+	require.Error(t, err)
+
+	// an incoming ping is shown on the dashboard
+	endpoint := NewEndpoint(zaptest.NewLogger(t), k, k.routingTable)
+	_, err = endpoint.Ping(ctx, &pb.PingRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[EventType]int{
+		EventBootstrapFinished: 1,
+		EventContactSucceeded:  3,
+		EventContactFailed:     1,
+		EventNodeAdded:         1,
+	}, counts())
+	assert.False(t, k.LastPinged().IsZero())
+	assert.True(t, k.LastQueried().IsZero())
+
+	// the node moves and then fails, the second failure doesn't evict it again
+	moved := &pb.Node{Id: node.Id, Address: &pb.NodeAddress{Address: offline.Address.Address}}
+	require.NoError(t, k.routingTable.ConnectionSuccess(moved))
+	require.NoError(t, k.routingTable.ConnectionFailed(moved))
+	require.NoError(t, k.routingTable.ConnectionFailed(moved))
+
+	assert.Equal(t, map[EventType]int{
+		EventBootstrapFinished: 1,
+		EventContactSucceeded:  3,
+		EventContactFailed:     1,
+		EventNodeAdded:         1,
+		EventAddressChanged:    1,
+		EventNodeEvicted:       1,
+	}, counts())
+}
+
+func TestBusStalledSubscriber(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	bus := NewBus()
+	defer bus.Close()
+
+	entered := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	stalled := bus.Subscribe("stalled", 2, func(Event) {
+		entered <- struct{}{}
+		<-unblock
+	})
+
+	handled := make(chan Event, 10)
+	healthy := bus.Subscribe("healthy", 10, func(event Event) {
+		handled <- event
+	})
+
+	bus.Publish(Event{Type: EventNodeAdded})
+	<-entered
+	for i := 0; i < 9; i++ {
+		bus.Publish(Event{Type: EventNodeAdded})
+	}
+
+	// the healthy subscriber gets every event while the other one is stalled
+	for i := 0; i < 10; i++ {
+		select {
+		case <-handled:
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+	assert.Zero(t, healthy.Dropped())
+
+	// the stalled subscriber handles the one it got and the two queued ones
+	assert.EqualValues(t, 7, stalled.Dropped())
+	close(unblock)
+	require.NoError(t, bus.Flush(ctx))
+	assert.Len(t, entered, 2)
+}
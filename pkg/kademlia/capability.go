@@ -0,0 +1,32 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+// Capability is a bitmask of optional protocol features supported by a node.
+//
+// Nodes advertise their capabilities in Ping and Query responses and in
+// Query requests. Bits that are not known to this version are ignored.
+type Capability uint64
+
+const (
+	// CapabilityQueryStream means the node implements Nodes.QueryStream
+	CapabilityQueryStream Capability = 1 << iota
+	// CapabilityGzip means the node accepts gzip compressed calls
+	CapabilityGzip
+	// CapabilityQueryFilter means the node honors the filter of queries. Only
+	// nodes knowing the metadata of other nodes, such as satellites, respond
+	// with it, see Endpoint.SetNodeMetadata.
+	CapabilityQueryFilter
+	// CapabilityCompactSnapshot means the node sends and reads routing table
+	// snapshots in the compact encoding, see EncodeCompactSnapshot
+	CapabilityCompactSnapshot
+)
+
+// Capabilities are the optional features supported by this node
+const Capabilities = CapabilityQueryStream | CapabilityGzip | CapabilityCompactSnapshot
+
+// Has returns whether all features in feature are supported
+func (capability Capability) Has(feature Capability) bool {
+	return capability&feature == feature
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// ClockOffset is the estimated difference between the clock of a peer and
+// the local clock, positive when the peer is ahead.
+type ClockOffset struct {
+	Offset time.Duration
+	// Accuracy is half of the round trip of the response the offset was estimated from
+	Accuracy time.Duration
+	Measured time.Time
+}
+
+// SetClock replaces the clock used for timestamps exchanged with peers, for tests.
+func (k *Kademlia) SetClock(now func() time.Time) {
+	k.dialer.clockMu.Lock()
+	defer k.dialer.clockMu.Unlock()
+	k.dialer.clock = now
+}
+
+// now returns the time on the local clock.
+func (dialer *Dialer) now() time.Time {
+	dialer.clockMu.Lock()
+	clock := dialer.clock
+	dialer.clockMu.Unlock()
+	if clock == nil {
+		return time.Now()
+	}
+	return clock()
+}
+
+// timestamp returns the time on the local clock for responses.
+func (dialer *Dialer) timestamp() *timestamp.Timestamp {
+	now, err := ptypes.TimestampProto(dialer.now())
+	if err != nil {
+		return nil
+	}
+	return now
+}
+
+// observeClock estimates the clock offset of the peer with id from the time
+// it responded with to a request sent at sent and answered at received, both
+// on the local clock. The peer is assumed to have answered halfway.
+func (dialer *Dialer) observeClock(id storj.NodeID, sent, received time.Time, remote *timestamp.Timestamp) {
+	if remote == nil {
+		return
+	}
+	remoteTime, err := ptypes.Timestamp(remote)
+	if err != nil {
+		return
+	}
+	halfway := received.Sub(sent) / 2
+	dialer.clockOffsets.Add(id, ClockOffset{
+		Offset:   remoteTime.Sub(sent.Add(halfway)),
+		Accuracy: halfway,
+		Measured: received,
+	})
+}
+
+// ClockOffset returns the estimated clock offset of the peer with id, known
+// once the peer answered a ping or a lookup.
+func (k *Kademlia) ClockOffset(id storj.NodeID) (ClockOffset, bool) {
+	value, ok := k.dialer.clockOffsets.Get(id)
+	if !ok {
+		return ClockOffset{}, false
+	}
+	return value.(ClockOffset), true
+}
+
+// PeerNow returns the current time on the clock of the peer with id, as
+// estimated from its responses, for validating the timestamps of the peer.
+// It is the local time while the offset of the peer isn't known.
+func (k *Kademlia) PeerNow(id storj.NodeID) time.Time {
+	now := k.dialer.now()
+	offset, ok := k.ClockOffset(id)
+	if !ok {
+		return now
+	}
+	return now.Add(offset.Offset)
+}
+
+// ClockSkew returns how far the local clock is ahead of the pinned
+// satellites, the median of their offsets so that a single satellite can't
+// sway it. skewed is whether the offsets of more than half of the
+// satellites are known and the skew is beyond Config.ClockSkewWarning.
+func (k *Kademlia) ClockSkew() (skew time.Duration, skewed bool) {
+	var satellites int
+	var offsets []time.Duration
+	for _, pinned := range k.routingTable.PinnedNodes() {
+		if pinned.Type != pb.NodeType_SATELLITE {
+			continue
+		}
+		satellites++
+		if offset, ok := k.ClockOffset(pinned.Node.Id); ok {
+			offsets = append(offsets, offset.Offset)
+		}
+	}
+	if len(offsets) == 0 || 2*len(offsets) <= satellites {
+		return 0, false
+	}
+
+	sort.Slice(offsets, func(i, k int) bool { return offsets[i] < offsets[k] })
+	median := offsets[len(offsets)/2]
+	if len(offsets)%2 == 0 {
+		median = (offsets[len(offsets)/2-1] + median) / 2
+	}
+	skew = -median
+
+	k.mu.Lock()
+	threshold := k.config.ClockSkewWarning
+	k.mu.Unlock()
+	magnitude := skew
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	return skew, threshold > 0 && magnitude > threshold
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestClockSkew(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	ident, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, ident, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	k.SetClock(func() time.Time { return now })
+	k.config.ClockSkewWarning = 5 * time.Minute
+
+	satellites := []storj.NodeID{{0x81}, {0x82}, {0x83}}
+	for i, id := range satellites {
+		node := pb.Node{Id: id, Address: &pb.NodeAddress{Address: "satellite:7777"}}
+		require.NoError(t, k.Pin(node, pb.NodeType_SATELLITE), i)
+	}
+
+	// observe answers the satellite sent at the given offset from the local clock, after a round trip of 2s
+	observe := func(id storj.NodeID, offset time.Duration) {
+		remote, err := ptypes.TimestampProto(now.Add(offset))
+		require.NoError(t, err)
+		k.dialer.observeClock(id, now.Add(-time.Second), now.Add(time.Second), remote)
+	}
+
+	{ // the offset is estimated from the middle of the round trip
+		observe(satellites[0], -time.Hour)
+		offset, ok := k.ClockOffset(satellites[0])
+		require.True(t, ok)
+		assert.Equal(t, ClockOffset{Offset: -time.Hour, Accuracy: time.Second, Measured: now.Add(time.Second)}, offset)
+		assert.Equal(t, now.Add(-time.Hour), k.PeerNow(satellites[0]))
+		assert.Equal(t, now, k.PeerNow(storj.NodeID{0x99}))
+	}
+
+	{ // without the offsets of a quorum of satellites the skew isn't known
+		_, skewed := k.ClockSkew()
+		assert.False(t, skewed)
+	}
+
+	{ // offsets within the tolerance aren't reported
+		observe(satellites[0], time.Minute)
+		observe(satellites[1], -time.Minute)
+		skew, skewed := k.ClockSkew()
+		assert.False(t, skewed)
+		assert.Equal(t, time.Duration(0), skew)
+	}
+
+	{ // the median resists a single satellite with a wrong clock
+		observe(satellites[0], -72*time.Hour)
+		observe(satellites[1], -time.Hour)
+		observe(satellites[2], -time.Hour)
+		skew, skewed := k.ClockSkew()
+		assert.True(t, skewed)
+		assert.Equal(t, time.Hour, skew)
+	}
+}
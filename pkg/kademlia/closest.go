@@ -0,0 +1,127 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// closestAlpha is the number of nodes LookupClosest asks at the same time,
+// the default kademlia alpha.
+const closestAlpha = 5
+
+// closestCandidate is a node found by LookupClosest.
+type closestCandidate struct {
+	node      *pb.Node
+	distance  storj.NodeID
+	queried   bool
+	responded bool
+}
+
+// LookupClosest finds the k nodes closest to target, starting from start.
+// It asks the closest nodes it hasn't asked yet, a few at a time, for nodes
+// closer to target until it has asked the k closest nodes it knows of, or
+// ctx is done. Nodes failing to respond are skipped.
+//
+// The k closest nodes that responded are returned sorted by distance to
+// target, also when some failed. An error is only returned when no node
+// responded.
+func (dialer *Dialer) LookupClosest(ctx context.Context, start []pb.Node, target storj.NodeID, k int) (_ []*pb.Node, err error) {
+	defer dialer.mon.Task()(&ctx)(&err)
+
+	if k <= 0 {
+		return nil, Error.New("invalid number of closest nodes %d", k)
+	}
+	if len(start) == 0 {
+		return nil, Error.New("no nodes to start from")
+	}
+
+	candidates := map[storj.NodeID]*closestCandidate{}
+	var ordered []*closestCandidate
+	add := func(nodes ...*pb.Node) {
+		for _, node := range nodes {
+			if _, known := candidates[node.Id]; known || node.Id.IsZero() {
+				continue
+			}
+			candidate := &closestCandidate{node: node, distance: xorNodeID(target, node.Id)}
+			candidates[node.Id] = candidate
+			ordered = append(ordered, candidate)
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].distance.Less(ordered[j].distance)
+		})
+	}
+	for i := range start {
+		add(&start[i])
+	}
+
+	var group errs.Group
+	for ctx.Err() == nil {
+		// the closest nodes not asked yet among the k closest that didn't fail
+		var next []*closestCandidate
+		rank := 0
+		for _, candidate := range ordered {
+			if rank >= k || len(next) >= closestAlpha {
+				break
+			}
+			if candidate.queried && !candidate.responded {
+				continue
+			}
+			rank++
+			if !candidate.queried {
+				next = append(next, candidate)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		var found []*pb.Node
+		for _, candidate := range next {
+			candidate := candidate
+			candidate.queried = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				nodes, err := dialer.Lookup(ctx, pb.Node{}, *candidate.node, pb.Node{Id: target})
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					dialer.log.Debug("closest lookup failed", zap.Stringer("Node ID", candidate.node.Id), zap.Error(err))
+					group.Add(err)
+					return
+				}
+				candidate.responded = true
+				found = append(found, nodes...)
+			}()
+		}
+		wg.Wait()
+		add(found...)
+	}
+
+	var closest []*pb.Node
+	for _, candidate := range ordered {
+		if candidate.responded && len(closest) < k {
+			closest = append(closest, candidate.node)
+		}
+	}
+	if len(closest) == 0 {
+		if err := ctx.Err(); err != nil {
+			group.Add(err)
+		}
+		return nil, Error.Wrap(group.Err())
+	}
+	return closest, nil
+}
@@ -0,0 +1,226 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"strconv"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// ErrCompactSnapshot is the error class for snapshots in the compact encoding
+var ErrCompactSnapshot = errs.Class("compact snapshot")
+
+const compactSnapshotVersion = 1
+
+var compactSnapshotTable = crc32.MakeTable(crc32.Castagnoli)
+
+// EncodeCompactSnapshot encodes nodes in the compact encoding of routing table
+// snapshots, which is smaller than their protobuf encoding since the nodes of a
+// snapshot only differ in a few bytes.
+//
+// The encoding is columnar:
+//
+//	version     1 byte
+//	count       uvarint
+//	bitmaps     3 bitmaps of count bits: has address, has port, other transport
+//	ids         count * 32 bytes
+//	transports  uvarint for every node with another transport
+//	addresses   uvarint length and the bytes for every node with an address,
+//	            without the port when the node has one
+//	ports       uvarint for every node with a port
+//	checksum    4 bytes, CRC-32C of everything before it
+func EncodeCompactSnapshot(nodes []*pb.Node) ([]byte, error) {
+	count := len(nodes)
+	bitmapSize := (count + 7) / 8
+	hasAddress := make([]byte, bitmapSize)
+	hasPort := make([]byte, bitmapSize)
+	otherTransport := make([]byte, bitmapSize)
+
+	hosts := make([]string, count)
+	ports := make([]uint64, count)
+	for i, node := range nodes {
+		if node == nil {
+			return nil, ErrCompactSnapshot.New("nil node")
+		}
+		if node.Address == nil {
+			continue
+		}
+		setBit(hasAddress, i)
+		if node.Address.Transport != pb.NodeTransport_TCP_TLS_GRPC {
+			setBit(otherTransport, i)
+		}
+		hosts[i] = node.Address.Address
+		if host, port, ok := splitPort(node.Address.Address); ok {
+			setBit(hasPort, i)
+			hosts[i], ports[i] = host, port
+		}
+	}
+
+	data := make([]byte, 0, 1+binary.MaxVarintLen64+3*bitmapSize+count*(len(storj.NodeID{})+16)+crc32.Size)
+	data = append(data, compactSnapshotVersion)
+	data = appendUvarint(data, uint64(count))
+	data = append(data, hasAddress...)
+	data = append(data, hasPort...)
+	data = append(data, otherTransport...)
+	for _, node := range nodes {
+		data = append(data, node.Id[:]...)
+	}
+	for i, node := range nodes {
+		if getBit(otherTransport, i) {
+			data = appendUvarint(data, uint64(node.Address.Transport))
+		}
+	}
+	for i := range nodes {
+		if getBit(hasAddress, i) {
+			data = appendUvarint(data, uint64(len(hosts[i])))
+			data = append(data, hosts[i]...)
+		}
+	}
+	for i := range nodes {
+		if getBit(hasPort, i) {
+			data = appendUvarint(data, ports[i])
+		}
+	}
+
+	var checksum [crc32.Size]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.Checksum(data, compactSnapshotTable))
+	return append(data, checksum[:]...), nil
+}
+
+// DecodeCompactSnapshot decodes the nodes encoded by EncodeCompactSnapshot.
+func DecodeCompactSnapshot(data []byte) ([]*pb.Node, error) {
+	if len(data) < 1+crc32.Size {
+		return nil, ErrCompactSnapshot.New("too short: %d bytes", len(data))
+	}
+	body, checksum := data[:len(data)-crc32.Size], data[len(data)-crc32.Size:]
+	if crc32.Checksum(body, compactSnapshotTable) != binary.BigEndian.Uint32(checksum) {
+		return nil, ErrCompactSnapshot.New("checksum mismatch")
+	}
+
+	decoder := compactDecoder{data: body}
+	if version := decoder.byte(); version != compactSnapshotVersion {
+		return nil, ErrCompactSnapshot.New("unknown version %d", version)
+	}
+	count64 := decoder.uvarint()
+	// every node takes at least the bytes of its ID
+	if count64 > uint64(len(body)/len(storj.NodeID{})) {
+		return nil, ErrCompactSnapshot.New("invalid node count %d", count64)
+	}
+	count := int(count64)
+	bitmapSize := (count + 7) / 8
+	hasAddress := decoder.bytes(bitmapSize)
+	hasPort := decoder.bytes(bitmapSize)
+	otherTransport := decoder.bytes(bitmapSize)
+
+	nodes := make([]*pb.Node, count)
+	for i := range nodes {
+		nodes[i] = &pb.Node{}
+		copy(nodes[i].Id[:], decoder.bytes(len(storj.NodeID{})))
+		if getBit(hasAddress, i) {
+			nodes[i].Address = &pb.NodeAddress{Transport: pb.NodeTransport_TCP_TLS_GRPC}
+		} else if getBit(hasPort, i) || getBit(otherTransport, i) {
+			return nil, ErrCompactSnapshot.New("node %d has no address", i)
+		}
+	}
+	for i, node := range nodes {
+		if getBit(otherTransport, i) {
+			node.Address.Transport = pb.NodeTransport(decoder.uvarint())
+		}
+	}
+	for _, node := range nodes {
+		if node.Address != nil {
+			node.Address.Address = string(decoder.bytes(int(decoder.uvarint())))
+		}
+	}
+	for i, node := range nodes {
+		if getBit(hasPort, i) {
+			port := decoder.uvarint()
+			if port > 65535 {
+				return nil, ErrCompactSnapshot.New("node %d has invalid port %d", i, port)
+			}
+			node.Address.Address = net.JoinHostPort(node.Address.Address, strconv.FormatUint(port, 10))
+		}
+	}
+
+	if decoder.err != nil {
+		return nil, decoder.err
+	}
+	if len(decoder.data) > 0 {
+		return nil, ErrCompactSnapshot.New("%d trailing bytes", len(decoder.data))
+	}
+	return nodes, nil
+}
+
+// splitPort splits address into its host and port, when joining them again
+// results in the same address.
+func splitPort(address string) (host string, port uint64, ok bool) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, false
+	}
+	port, err = strconv.ParseUint(portString, 10, 16)
+	if err != nil || net.JoinHostPort(host, strconv.FormatUint(port, 10)) != address {
+		return "", 0, false
+	}
+	return host, port, true
+}
+
+// compactDecoder reads the fields of a compact snapshot, after the first error
+// it returns zero values.
+type compactDecoder struct {
+	data []byte
+	err  error
+}
+
+func (decoder *compactDecoder) byte() byte {
+	b := decoder.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (decoder *compactDecoder) bytes(n int) []byte {
+	if decoder.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(decoder.data) {
+		decoder.err = ErrCompactSnapshot.New("truncated")
+		return nil
+	}
+	b := decoder.data[:n]
+	decoder.data = decoder.data[n:]
+	return b
+}
+
+func (decoder *compactDecoder) uvarint() uint64 {
+	if decoder.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(decoder.data)
+	if n <= 0 {
+		decoder.err = ErrCompactSnapshot.New("invalid varint")
+		return 0
+	}
+	decoder.data = decoder.data[n:]
+	return value
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutUvarint(buf[:], value)]...)
+}
+
+func setBit(bitmap []byte, i int) { bitmap[i/8] |= 1 << uint(i%8) }
+
+func getBit(bitmap []byte, i int) bool {
+	return i/8 < len(bitmap) && bitmap[i/8]&(1<<uint(i%8)) != 0
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestCompactSnapshotRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	nodes := make([]*pb.Node, 10000)
+	for i := range nodes {
+		node := &pb.Node{}
+		_, _ = r.Read(node.Id[:])
+		node.Address = &pb.NodeAddress{
+			Transport: pb.NodeTransport_TCP_TLS_GRPC,
+			Address:   fmt.Sprintf("10.%d.%d.%d:%d", r.Intn(256), r.Intn(256), r.Intn(256), 28967+r.Intn(100)),
+		}
+		nodes[i] = node
+	}
+
+	compact, err := EncodeCompactSnapshot(nodes)
+	require.NoError(t, err)
+	decoded, err := DecodeCompactSnapshot(compact)
+	require.NoError(t, err)
+
+	requireEqualNodes(t, nodes, decoded)
+	expected, err := SnapshotChecksum(nodes)
+	require.NoError(t, err)
+	checksum, err := SnapshotChecksum(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, expected, checksum)
+
+	size := proto.Size(&pb.DumpNodesResponse{Nodes: nodes})
+	t.Logf("compact %d bytes, protobuf %d bytes", len(compact), size)
+	assert.True(t, len(compact) < size*9/10, "compact %d bytes, protobuf %d bytes", len(compact), size)
+}
+
+func TestCompactSnapshotNodes(t *testing.T) {
+	var count int
+	node := func(address *pb.NodeAddress) *pb.Node {
+		count++
+		return &pb.Node{Id: teststorj.NodeIDFromString(fmt.Sprintf("node-%d", count)), Address: address}
+	}
+
+	nodes := []*pb.Node{
+		node(nil),
+		node(&pb.NodeAddress{}),
+		node(&pb.NodeAddress{Address: "127.0.0.1:7777"}),
+		node(&pb.NodeAddress{Address: "[::1]:7777"}),
+		node(&pb.NodeAddress{Address: "example.test"}),
+		node(&pb.NodeAddress{Address: "example.test:07777"}),
+		node(&pb.NodeAddress{Address: "example.test:99999"}),
+		node(&pb.NodeAddress{Address: ":7777"}),
+		node(&pb.NodeAddress{Transport: pb.NodeTransport(7), Address: "127.0.0.1:7777"}),
+		node(&pb.NodeAddress{Transport: pb.NodeTransport(7)}),
+	}
+
+	for _, nodes := range [][]*pb.Node{nil, nodes, nodes[:1], nodes[2:3]} {
+		compact, err := EncodeCompactSnapshot(nodes)
+		require.NoError(t, err)
+		decoded, err := DecodeCompactSnapshot(compact)
+		require.NoError(t, err)
+		requireEqualNodes(t, nodes, decoded)
+	}
+
+	_, err := EncodeCompactSnapshot([]*pb.Node{nil})
+	assert.True(t, ErrCompactSnapshot.Has(err))
+}
+
+func TestCompactSnapshotCorrupted(t *testing.T) {
+	nodes := make([]*pb.Node, 100)
+	for i := range nodes {
+		nodes[i] = &pb.Node{
+			Id:      teststorj.NodeIDFromString(fmt.Sprintf("node-%05d", i)),
+			Address: &pb.NodeAddress{Address: fmt.Sprintf("127.0.0.1:%d", 10000+i)},
+		}
+	}
+
+	compact, err := EncodeCompactSnapshot(nodes)
+	require.NoError(t, err)
+
+	for i := 0; i < len(compact); i += 37 {
+		corrupted := append([]byte(nil), compact...)
+		corrupted[i] ^= 0x40
+		_, err := DecodeCompactSnapshot(corrupted)
+		assert.True(t, ErrCompactSnapshot.Has(err), "byte %d: %v", i, err)
+	}
+
+	for _, size := range []int{0, 1, 4, 5, len(compact) / 2, len(compact) - 1} {
+		_, err := DecodeCompactSnapshot(compact[:size])
+		assert.True(t, ErrCompactSnapshot.Has(err), "%d bytes: %v", size, err)
+	}
+}
+
+// compactInspector serves DumpNodes from an inspector, dropping the
+// capabilities of the requests when old, as a node not knowing them would.
+type compactInspector struct {
+	pb.KadInspectorClient
+	inspector *Inspector
+	old       bool
+
+	compact, protobuf int
+}
+
+func (client *compactInspector) DumpNodes(ctx context.Context, req *pb.DumpNodesRequest, opts ...grpc.CallOption) (*pb.DumpNodesResponse, error) {
+	if client.old {
+		req.Capabilities = 0
+	}
+	resp, err := client.inspector.DumpNodes(ctx, req)
+	if err == nil {
+		if len(resp.CompactNodes) > 0 {
+			client.compact++
+		} else {
+			client.protobuf++
+		}
+	}
+	return resp, err
+}
+
+func TestDumpSnapshotCompact(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	inspector, rt := newSnapshotInspector(t, 250)
+	defer ctx.Check(rt.Close)
+
+	for _, old := range []bool{false, true} {
+		client := &compactInspector{inspector: inspector, old: old}
+		nodes, err := DumpSnapshot(ctx, client, 100, backoff.Constant{Interval: time.Millisecond})
+		require.NoError(t, err)
+		requireSameNodes(t, rt, nodes)
+
+		if old {
+			assert.Equal(t, 0, client.compact)
+			assert.Equal(t, 3, client.protobuf)
+		} else {
+			assert.Equal(t, 3, client.compact)
+			assert.Equal(t, 0, client.protobuf)
+		}
+	}
+}
+
+// requireEqualNodes requires the nodes to be equal, including whether they
+// have an address.
+func requireEqualNodes(t *testing.T, expected, actual []*pb.Node) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.True(t, proto.Equal(expected[i], actual[i]), "node %d: expected %v, got %v", i, expected[i], actual[i])
+	}
+}
@@ -10,14 +10,16 @@ import (
 
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
+	"storj.io/storj/internal/memory"
 	"storj.io/storj/pkg/pb"
 )
 
 var (
 	// Error defines a Kademlia error
 	Error = errs.Class("kademlia error")
-	// mon   = monkit.Package() // TODO: figure out whether this is needed
+	mon   = monkit.Package()
 )
 
 // Config defines all of the things that are needed to start up Kademlia
@@ -26,15 +28,91 @@ type Config struct {
 	BootstrapAddr        string        `help:"the Kademlia node to bootstrap against" default:""`
 	BootstrapBackoffMax  time.Duration `help:"the maximum amount of time to wait when retrying bootstrap" default:"30s"`
 	BootstrapBackoffBase time.Duration `help:"the base interval to wait when retrying bootstrap" default:"1s"`
-	DBPath               string        `help:"the path for storage node db services to be created on" default:"$CONFDIR/kademlia"`
-	ExternalAddress      string        `user:"true" help:"the public address of the Kademlia node, useful for nodes behind NAT" default:""`
+	BootstrapValidation  BootstrapValidationConfig
+	DBPath               string `help:"the path for storage node db services to be created on" default:"$CONFDIR/kademlia"`
+	ExternalAddress      string `user:"true" help:"the public address of the Kademlia node, useful for nodes behind NAT" default:""`
+	TrustedProxies       string `help:"comma separated IP addresses or CIDR ranges of proxies whose x-forwarded-for metadata is trusted as the source address of pings" default:""`
 	Operator             OperatorConfig
+	Compression          CompressionConfig
+	Warmup               WarmupConfig
+	Dialer               DialerOptions
+	ConnPool             ConnPoolConfig
+	IdentityCache        IdentityCacheConfig
+	NegativeCache        NegativeCacheConfig
+	Neighborhood         NeighborhoodConfig
+	Failures             FailureConfig
 
 	// TODO: reduce the number of flags here
-	Alpha int `help:"alpha is a system wide concurrency parameter" default:"5"`
+	Alpha   int `help:"alpha is a system wide concurrency parameter" default:"5"`
+	Workers int `help:"maximum number of goroutines used for lookups and background work" default:"64"`
+
+	DialConcurrency int `help:"maximum number of dials to other nodes at the same time" default:"32"`
+
+	StreamLimit     int           `help:"maximum number of nodes sent in response to a single streaming query" default:"1000"`
+	QueryShare      time.Duration `help:"how long the closest nodes found for a query are reused for identical queries, zero only shares concurrent queries" default:"500ms"`
+	RefreshInterval time.Duration `help:"how often buckets are checked for refreshing" default:"5m"`
+
+	ClockSkewWarning time.Duration `help:"how far the clock may be off from the satellites before the dashboard warns about it, zero disables the warning" default:"5m"`
+
+	ConsistencyCheck time.Duration `help:"how often the routing table is checked for duplicate entries of a node, such as left behind by an address change, zero disables the check" default:"0s"`
+
+	LeaveOnShutdown bool `help:"tell the closest nodes when shutting down, so that they remove the node from their routing tables right away" default:"true"`
 	RoutingTableConfig
 }
 
+// CompressionConfig defines when bulk kademlia responses are compressed
+type CompressionConfig struct {
+	Enabled   bool        `help:"compress bulk responses, such as routing table dumps, from peers that support it" default:"true"`
+	Threshold memory.Size `help:"expected response size below which responses are not compressed" default:"16KiB"`
+}
+
+// DialerOptions defines how responses from other nodes are checked and how RPCs are made
+type DialerOptions struct {
+	VerifyOrdering        bool          `help:"verify that lookup responses are sorted by distance to the target, re-sorting them otherwise" default:"true"`
+	Policies              string        `help:"comma separated overrides of the default timeouts, retries, retry backoffs and dial priorities of kademlia RPCs, such as ping.timeout=5s,fetch-info.retries=2,lookup.priority=background" default:""`
+	UnverifiedLifetime    time.Duration `help:"how long a connection dialed by address, without verifying the node ID, may stay open before it is closed, zero disables the limit" default:"30s"`
+	AllowPrivateAddresses bool          `help:"accept nodes with private, loopback and link-local addresses in lookup responses, such as in test networks running on one machine" default:"false"`
+	Retry                 RetryConfig
+}
+
+// RetryConfig defines how kademlia RPCs failing because of the transport,
+// such as by a refused or timed out dial, are retried, unless the policy of
+// the RPC sets its retries
+type RetryConfig struct {
+	MaxAttempts    int           `help:"maximum number of attempts of a kademlia RPC failing because of the transport, one disables retrying" default:"1"`
+	InitialBackoff time.Duration `help:"how long to wait before the first retry, doubled for every further one" default:"100ms"`
+	MaxBackoff     time.Duration `help:"the maximum amount of time to wait between retries" default:"2s"`
+	Jitter         float64       `help:"fraction of the backoff that is random, so that peers don't retry in lockstep" default:"0.2"`
+}
+
+// NegativeCacheConfig defines how long lookups that didn't find a node are remembered
+type NegativeCacheConfig struct {
+	TTL  time.Duration `help:"how long a node that wasn't found by a lookup is reported as not found without searching again, zero disables the cache" default:"1m"`
+	Size int           `help:"maximum number of nodes remembered as not found" default:"1000"`
+}
+
+// NeighborhoodConfig defines when a change of the closest nodes to self is reported
+type NeighborhoodConfig struct {
+	Interval  time.Duration `help:"how often the closest nodes to self are compared with the previous snapshot, zero disables the monitor" default:"5m"`
+	Threshold float64       `help:"fraction of the previous closest nodes that must remain to not report a neighborhood change" default:"0.5"`
+	Baseline  time.Duration `help:"how long a snapshot of the closest nodes is kept to also catch gradual changes, zero only compares with the previous snapshot" default:"1h"`
+}
+
+// FailureConfig defines when background dials skip peers whose recent dials failed
+type FailureConfig struct {
+	Threshold   int           `help:"consecutive failed dials after which background dials of a peer are backed off, zero disables backing off" default:"3"`
+	BackoffBase time.Duration `help:"how long a peer is backed off after reaching the threshold, doubled with every further failure" default:"5m"`
+	BackoffMax  time.Duration `help:"the maximum amount of time a peer is backed off" default:"24h"`
+	MaxAge      time.Duration `help:"how long the failures of a peer are remembered across restarts, zero remembers them until the peer is dialed again" default:"168h"`
+}
+
+// WarmupConfig defines how queries are limited while a node is starting up
+type WarmupConfig struct {
+	Duration    time.Duration `help:"how long after start queries from other nodes are limited in favor of bootstrapping, zero disables warmup" default:"0s"`
+	UntilReady  bool          `help:"end warmup early once bootstrapping has finished" default:"true"`
+	Concurrency int           `help:"maximum number of queries from other nodes served concurrently during warmup" default:"4"`
+}
+
 // BootstrapNodes returns bootstrap nodes defined in the config
 func (c Config) BootstrapNodes() []pb.Node {
 	var nodes []pb.Node
@@ -51,13 +129,21 @@ func (c Config) BootstrapNodes() []pb.Node {
 
 // Verify verifies whether kademlia config is valid.
 func (c Config) Verify(log *zap.Logger) error {
+	if err := c.verifyTunables(); err != nil {
+		return err
+	}
+	if _, err := parseTrustedProxies(c.TrustedProxies); err != nil {
+		return err
+	}
 	return c.Operator.Verify(log)
 }
 
 // OperatorConfig defines properties related to storage node operator metadata
+//
+// The fields are secret, so that /debug/config only reports whether they are set.
 type OperatorConfig struct {
-	Email  string `user:"true" help:"operator email address" default:""`
-	Wallet string `user:"true" help:"operator wallet adress" default:""`
+	Email  string `user:"true" help:"operator email address" default:"" secret:"true"`
+	Wallet string `user:"true" help:"operator wallet adress" default:"" secret:"true"`
 }
 
 // Verify verifies whether operator config is valid.
@@ -0,0 +1,229 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// AddressConflictConfig configures the detection of nodes verified at several addresses.
+type AddressConflictConfig struct {
+	Window time.Duration `help:"how long an address verified for a node stays active, a node with several active addresses is reported as a conflict, 0 disables the detection" default:"1h"`
+	Size   int           `help:"number of nodes whose recently verified addresses are tracked" default:"1000"`
+}
+
+// VerifiedAddress is an address a node was verified at by a successful connection.
+type VerifiedAddress struct {
+	Address       *pb.NodeAddress
+	FirstVerified time.Time
+	LastVerified  time.Time
+}
+
+// AddressConflict is a node verified at several addresses within the
+// window, because another node uses its key or because it migrated.
+type AddressConflict struct {
+	Node storj.NodeID
+	// Preferred is the address in the routing table, the one verified for
+	// the first time most recently, so that repeated contacts at the other
+	// addresses don't change it.
+	Preferred VerifiedAddress
+	// Alternates are the other active addresses, most recently verified first.
+	Alternates []VerifiedAddress
+}
+
+// addressConflicts tracks the addresses recently verified for each node.
+type addressConflicts struct {
+	window time.Duration
+	// storj.NodeID -> []VerifiedAddress, oldest first
+	entries *lrucache.Cache
+}
+
+// newAddressConflicts returns the tracker of the addresses of nodes, nil
+// when the detection is disabled.
+func newAddressConflicts(config AddressConflictConfig) *addressConflicts {
+	if config.Window <= 0 || config.Size <= 0 {
+		return nil
+	}
+	return &addressConflicts{window: config.Window, entries: lrucache.New(config.Size)}
+}
+
+// verified records that node was verified at its address at now and returns
+// the preferred address of the node, and the conflict when the address is
+// new and the node has other active addresses.
+func (conflicts *addressConflicts) verified(node *pb.Node, now time.Time) (preferred *pb.NodeAddress, conflict *AddressConflict) {
+	if conflicts == nil {
+		return node.Address, nil
+	}
+
+	var addresses []VerifiedAddress
+	added := true
+	conflicts.entries.Update(node.Id, func(value interface{}, ok bool) interface{} {
+		if ok {
+			addresses = conflicts.active(value.([]VerifiedAddress), now)
+		}
+		for i := range addresses {
+			if pb.AddressEqual(addresses[i].Address, node.Address) {
+				addresses[i].LastVerified = now
+				added = false
+			}
+		}
+		if added {
+			addresses = append(addresses, VerifiedAddress{Address: node.Address, FirstVerified: now, LastVerified: now})
+		}
+		return addresses
+	})
+
+	current := newConflict(node.Id, addresses)
+	if added && len(addresses) > 1 {
+		conflict = &current
+	}
+	return current.Preferred.Address, conflict
+}
+
+// preferred returns the preferred address of the node with id when it has
+// several active addresses.
+func (conflicts *addressConflicts) preferred(id storj.NodeID, now time.Time) (*pb.NodeAddress, bool) {
+	if conflicts == nil {
+		return nil, false
+	}
+	value, ok := conflicts.entries.Peek(id)
+	if !ok {
+		return nil, false
+	}
+	addresses := conflicts.active(value.([]VerifiedAddress), now)
+	if len(addresses) < 2 {
+		return nil, false
+	}
+	return newConflict(id, addresses).Preferred.Address, true
+}
+
+// forget forgets that the node with id was verified at address.
+func (conflicts *addressConflicts) forget(id storj.NodeID, address *pb.NodeAddress) {
+	if conflicts == nil {
+		return
+	}
+	conflicts.entries.Update(id, func(value interface{}, ok bool) interface{} {
+		var kept []VerifiedAddress
+		if ok {
+			for _, verified := range value.([]VerifiedAddress) {
+				if !pb.AddressEqual(verified.Address, address) {
+					kept = append(kept, verified)
+				}
+			}
+		}
+		return kept
+	})
+}
+
+// list returns the active conflicts, ordered by node ID.
+func (conflicts *addressConflicts) list(now time.Time) []AddressConflict {
+	if conflicts == nil {
+		return nil
+	}
+	var list []AddressConflict
+	conflicts.entries.Each(func(key, value interface{}) {
+		addresses := conflicts.active(value.([]VerifiedAddress), now)
+		if len(addresses) > 1 {
+			list = append(list, newConflict(key.(storj.NodeID), addresses))
+		}
+	})
+	sort.Slice(list, func(i, k int) bool { return list[i].Node.Less(list[k].Node) })
+	return list
+}
+
+// active returns the addresses verified within the window before now.
+func (conflicts *addressConflicts) active(addresses []VerifiedAddress, now time.Time) []VerifiedAddress {
+	active := make([]VerifiedAddress, 0, len(addresses)+1)
+	for _, verified := range addresses {
+		if now.Sub(verified.LastVerified) < conflicts.window {
+			active = append(active, verified)
+		}
+	}
+	return active
+}
+
+// newConflict returns the conflict of the node with id between its active
+// addresses, which are ordered by when they were first verified.
+func newConflict(id storj.NodeID, addresses []VerifiedAddress) AddressConflict {
+	conflict := AddressConflict{Node: id, Preferred: addresses[len(addresses)-1]}
+	alternates := append([]VerifiedAddress(nil), addresses[:len(addresses)-1]...)
+	sort.SliceStable(alternates, func(i, k int) bool {
+		return alternates[i].LastVerified.After(alternates[k].LastVerified)
+	})
+	if len(alternates) > 0 {
+		conflict.Alternates = alternates
+	}
+	return conflict
+}
+
+// memoryUsage returns the approximate memory used by the tracked addresses.
+func (conflicts *addressConflicts) memoryUsage() memory.Size {
+	if conflicts == nil {
+		return 0
+	}
+	return memory.Size(conflicts.entries.Len()) * peerEntrySize
+}
+
+// evict forgets the least recently verified nodes until at least size is freed.
+func (conflicts *addressConflicts) evict(size memory.Size) (freed memory.Size) {
+	if conflicts == nil {
+		return 0
+	}
+	for freed < size && conflicts.entries.RemoveOldest() {
+		freed += peerEntrySize
+	}
+	return freed
+}
+
+// verifiedAddress records that node was verified at its address and returns
+// the node as kept in the routing table, at its preferred address. A node
+// verified at a new address while other addresses are active is reported,
+// since another node may be using its key.
+func (rt *RoutingTable) verifiedAddress(node *pb.Node) *pb.Node {
+	preferred, conflict := rt.conflicts.verified(node, time.Now())
+	if conflict != nil {
+		rt.mon.Counter("address_conflicts").Inc(1)
+		alternates := make([]string, 0, len(conflict.Alternates))
+		for _, alternate := range conflict.Alternates {
+			alternates = append(alternates, alternate.Address.GetAddress())
+		}
+		rt.log.Warn("node verified at several addresses, its identity may be used by another node",
+			zap.Stringer("Node ID", node.Id),
+			zap.String("preferred", preferred.GetAddress()),
+			zap.Strings("alternates", alternates))
+	}
+	if pb.AddressEqual(preferred, node.Address) {
+		return node
+	}
+	rt.mon.Counter("address_conflict_alternate_contacts").Inc(1)
+	kept := *node
+	kept.Address = preferred
+	return &kept
+}
+
+// alternateFailed returns whether node failed at an alternate address of a
+// conflict, which doesn't affect the entry at the preferred address. The
+// alternate is forgotten.
+func (rt *RoutingTable) alternateFailed(node *pb.Node) bool {
+	preferred, ok := rt.conflicts.preferred(node.Id, time.Now())
+	if !ok || pb.AddressEqual(preferred, node.Address) {
+		return false
+	}
+	rt.conflicts.forget(node.Id, node.Address)
+	return true
+}
+
+// AddressConflicts returns the nodes verified at several addresses within
+// the window, ordered by node ID.
+func (rt *RoutingTable) AddressConflicts() []AddressConflict {
+	return rt.conflicts.list(time.Now())
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestAddressConflicts(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	original, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer original.Close()
+
+	// another node presenting the same identity
+	impostor, err := testkademlia.StartAs(ctx, original.Identity())
+	require.NoError(t, err)
+	defer impostor.Close()
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	k.routingTable.log = zap.New(core)
+	k.routingTable.conflicts = newAddressConflicts(AddressConflictConfig{Window: time.Hour, Size: 10})
+
+	contact := func(node pb.Node) error {
+		_, err := k.Ping(ctx, node)
+		require.NoError(t, k.FlushRoutingTable(ctx))
+		return err
+	}
+	stored := func() string {
+		nodes, err := k.routingTable.FindNear(original.ID(), 1)
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		require.Equal(t, original.ID(), nodes[0].Id)
+		return nodes[0].Address.GetAddress()
+	}
+
+	require.NoError(t, contact(original.Node()))
+	assert.Equal(t, original.Addr(), stored())
+	assert.Empty(t, k.routingTable.AddressConflicts())
+	assert.Zero(t, logs.Len())
+
+	// the most recently verified address is preferred and the conflict reported
+	require.NoError(t, contact(impostor.Node()))
+	assert.Equal(t, impostor.Addr(), stored())
+
+	warnings := logs.FilterMessageSnippet("verified at several addresses").AllUntimed()
+	require.Len(t, warnings, 1)
+	fields := warnings[0].ContextMap()
+	assert.Equal(t, impostor.Addr(), fields["preferred"])
+	assert.Equal(t, []interface{}{original.Addr()}, fields["alternates"])
+
+	conflicts := k.routingTable.AddressConflicts()
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, original.ID(), conflicts[0].Node)
+	assert.Equal(t, impostor.Addr(), conflicts[0].Preferred.Address.GetAddress())
+	require.Len(t, conflicts[0].Alternates, 1)
+	assert.Equal(t, original.Addr(), conflicts[0].Alternates[0].Address.GetAddress())
+
+	// repeated contacts at either address don't flap or warn again
+	for i := 0; i < 3; i++ {
+		require.NoError(t, contact(original.Node()))
+		assert.Equal(t, impostor.Addr(), stored())
+		require.NoError(t, contact(impostor.Node()))
+		assert.Equal(t, impostor.Addr(), stored())
+	}
+	assert.Equal(t, 1, logs.Len())
+
+	{ // the inspector lists the conflict
+		resp, err := NewInspector(k, fid).AddressConflicts(ctx, &pb.AddressConflictsRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Conflicts, 1)
+		assert.Equal(t, original.ID(), resp.Conflicts[0].NodeId)
+		assert.Equal(t, impostor.Addr(), resp.Conflicts[0].Preferred.Address.Address)
+		require.Len(t, resp.Conflicts[0].Alternates, 1)
+		assert.Equal(t, original.Addr(), resp.Conflicts[0].Alternates[0].Address.Address)
+	}
+
+	// failures at the alternate address don't evict the node
+	original.Close()
+	require.Error(t, contact(original.Node()))
+	assert.Equal(t, impostor.Addr(), stored())
+	assert.Empty(t, k.routingTable.AddressConflicts())
+}
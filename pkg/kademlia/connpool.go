@@ -0,0 +1,255 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/pkg/storj"
+)
+
+// ConnPoolConfig defines how many idle connections to other nodes a dialer
+// keeps open, so that repeated RPCs to the same node don't dial again
+type ConnPoolConfig struct {
+	Size        int           `help:"maximum number of idle connections to other nodes kept open for the next RPC, zero dials for every RPC" default:"16"`
+	IdleTimeout time.Duration `help:"how long an idle connection is kept open for the next RPC" default:"1m"`
+}
+
+// DialerOption configures a dialer.
+type DialerOption func(*Dialer)
+
+// WithConnPool makes the dialer keep idle connections open as configured
+// and use them for the next RPCs to the same nodes.
+func WithConnPool(config ConnPoolConfig) DialerOption {
+	return func(dialer *Dialer) { dialer.pool = newConnPool(config, dialer.mon, &dialer.conns) }
+}
+
+// WithDialLimit makes the dialer make at most limit dials at the same time,
+// instead of the default. Further dials wait for a slot by priority, see
+// WithDialPriority. Limits that aren't positive keep the default.
+func WithDialLimit(limit int) DialerOption {
+	return func(dialer *Dialer) {
+		if limit > 0 {
+			dialer.initLimits(limit)
+		}
+	}
+}
+
+// WithPrivateAddresses makes the dialer accept nodes with private, loopback
+// and link-local addresses in lookup responses, such as when all nodes run on
+// localhost in tests. Reloading the configuration replaces the setting.
+func WithPrivateAddresses() DialerOption {
+	return func(dialer *Dialer) {
+		dialer.settingsMu.Lock()
+		defer dialer.settingsMu.Unlock()
+		dialer.options.AllowPrivateAddresses = true
+		dialer.allowPrivate = true
+	}
+}
+
+// WithIdentityCache makes the dialer cache the peer identities it fetches as
+// configured, instead of with the defaults.
+func WithIdentityCache(config IdentityCacheConfig) DialerOption {
+	return func(dialer *Dialer) {
+		dialer.identities = newIdentityCache(dialer.log, nil, dialer.workers, config)
+	}
+}
+
+// poolKey identifies the connections of a pool: verified connections by the
+// ID of the node, connections dialed by address by the address.
+type poolKey struct {
+	id      storj.NodeID
+	address string
+}
+
+// pooledConn is an idle connection of a pool.
+type pooledConn struct {
+	key  poolKey
+	id   storj.NodeID // the node of the connection, also when dialed by address
+	conn *grpc.ClientConn
+	// timer closes the connection after the idle timeout
+	timer *time.Timer
+}
+
+// connPool keeps idle connections open, closing the least recently used one
+// when it's full and any after the idle timeout. A nil pool keeps nothing.
+type connPool struct {
+	config ConnPoolConfig
+	mon    *monkit.Scope
+	conns  *connCounter
+
+	mu      sync.Mutex
+	lru     *list.List // of *pooledConn, the most recently used first
+	entries map[poolKey]*list.Element
+	closed  bool
+}
+
+// newConnPool returns the pool configured by config, nil when it keeps nothing.
+func newConnPool(config ConnPoolConfig, mon *monkit.Scope, conns *connCounter) *connPool {
+	if config.Size <= 0 || config.IdleTimeout <= 0 {
+		return nil
+	}
+	return &connPool{
+		config:  config,
+		mon:     mon,
+		conns:   conns,
+		lru:     list.New(),
+		entries: map[poolKey]*list.Element{},
+	}
+}
+
+// take removes the idle connection of key from the pool and returns it, nil
+// when there's none. Connections that are known to be broken are closed
+// instead.
+func (pool *connPool) take(key poolKey) *grpc.ClientConn {
+	if pool == nil {
+		return nil
+	}
+
+	pool.mu.Lock()
+	element, ok := pool.entries[key]
+	if ok {
+		pool.remove(element)
+	}
+	pool.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	conn := element.Value.(*pooledConn).conn
+	switch conn.GetState() {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		pool.mon.Counter("conn_pool_broken").Inc(1)
+		_ = pool.conns.close(conn)
+		return nil
+	}
+	return conn
+}
+
+// put adds the idle connection to the node with id to the pool as key. It
+// returns false when the pool doesn't keep it, such as when it already has a
+// connection of key, and the caller closes it.
+func (pool *connPool) put(key poolKey, id storj.NodeID, conn *grpc.ClientConn) bool {
+	if pool == nil {
+		return false
+	}
+
+	pool.mu.Lock()
+	if _, exists := pool.entries[key]; exists || pool.closed {
+		pool.mu.Unlock()
+		return false
+	}
+
+	var evicted *grpc.ClientConn
+	if pool.lru.Len() >= pool.config.Size {
+		oldest := pool.lru.Back()
+		pool.remove(oldest)
+		evicted = oldest.Value.(*pooledConn).conn
+	}
+
+	pooled := &pooledConn{key: key, id: id, conn: conn}
+	element := pool.lru.PushFront(pooled)
+	pool.entries[key] = element
+	pooled.timer = time.AfterFunc(pool.config.IdleTimeout, func() {
+		pool.expire(element)
+	})
+	pool.mu.Unlock()
+
+	if evicted != nil {
+		pool.mon.Counter("conn_pool_evicted").Inc(1)
+		_ = pool.conns.close(evicted)
+	}
+	return true
+}
+
+// expire closes the connection of element when it's still idle.
+func (pool *connPool) expire(element *list.Element) {
+	pool.mu.Lock()
+	pooled := element.Value.(*pooledConn)
+	current, ok := pool.entries[pooled.key]
+	if ok && current == element {
+		pool.remove(element)
+	}
+	pool.mu.Unlock()
+
+	if ok && current == element {
+		pool.mon.Counter("conn_pool_expired").Inc(1)
+		_ = pool.conns.close(pooled.conn)
+	}
+}
+
+// closeDialedByAddress closes the idle connections to the node with id that
+// were dialed by address, since a verified dial reached the node.
+func (pool *connPool) closeDialedByAddress(id storj.NodeID) {
+	if pool == nil {
+		return
+	}
+
+	var superseded []*grpc.ClientConn
+	pool.mu.Lock()
+	for element := pool.lru.Front(); element != nil; {
+		next := element.Next()
+		pooled := element.Value.(*pooledConn)
+		if pooled.key.address != "" && pooled.id == id {
+			pool.remove(element)
+			superseded = append(superseded, pooled.conn)
+		}
+		element = next
+	}
+	pool.mu.Unlock()
+
+	for _, conn := range superseded {
+		_ = pool.conns.close(conn)
+	}
+}
+
+// remove removes element from the pool, the caller holds mu.
+func (pool *connPool) remove(element *list.Element) {
+	pooled := element.Value.(*pooledConn)
+	if pooled.timer != nil {
+		pooled.timer.Stop()
+	}
+	pool.lru.Remove(element)
+	delete(pool.entries, pooled.key)
+}
+
+// size returns the number of idle connections in the pool.
+func (pool *connPool) size() int {
+	if pool == nil {
+		return 0
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.lru.Len()
+}
+
+// close closes the idle connections, connections put afterwards aren't kept.
+func (pool *connPool) close() error {
+	if pool == nil {
+		return nil
+	}
+
+	pool.mu.Lock()
+	pool.closed = true
+	var conns []*grpc.ClientConn
+	for pool.lru.Len() > 0 {
+		element := pool.lru.Front()
+		pool.remove(element)
+		conns = append(conns, element.Value.(*pooledConn).conn)
+	}
+	pool.mu.Unlock()
+
+	var group errs.Group
+	for _, conn := range conns {
+		group.Add(pool.conns.close(conn))
+	}
+	return group.Err()
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/transport"
+)
+
+// newPoolingDialer returns a dialer pooling connections as configured, and
+// the counter of its dials.
+func newPoolingDialer(ctx *testcontext.Context, t *testing.T, config ConnPoolConfig) (*Dialer, *dialCounter) {
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOpts, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	dials := &dialCounter{}
+	dialer := NewDialer(zaptest.NewLogger(t), transport.NewClient(tlsOpts, dials), WithConnPool(config))
+	return dialer, dials
+}
+
+func TestConnPoolReuse(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	dialer, dials := newPoolingDialer(ctx, t, ConnPoolConfig{Size: 4, IdleTimeout: time.Minute})
+	defer ctx.Check(dialer.Close)
+
+	for i := 0; i < 2; i++ {
+		ok, err := dialer.PingNode(ctx, server.Node())
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	assert.Equal(t, int32(1), dials.count())
+	assert.Equal(t, 2, server.Calls("Ping"))
+	assert.Equal(t, 1, dialer.pool.size())
+}
+
+func TestConnPoolBrokenConn(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+
+	dialer, dials := newPoolingDialer(ctx, t, ConnPoolConfig{Size: 4, IdleTimeout: time.Minute})
+	defer ctx.Check(dialer.Close)
+
+	ok, err := dialer.PingNode(ctx, server.Node())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the node restarts, breaking the idle connection
+	server.Close()
+	restarted, err := testkademlia.StartAs(ctx, server.Identity())
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	ok, err = dialer.PingNode(ctx, restarted.Node())
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, int32(2), dials.count())
+	assert.Equal(t, 1, restarted.Calls("Ping"))
+}
+
+func TestConnPoolIdleTimeout(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	idleTimeout := 50 * time.Millisecond
+	dialer, dials := newPoolingDialer(ctx, t, ConnPoolConfig{Size: 4, IdleTimeout: idleTimeout})
+	defer ctx.Check(dialer.Close)
+
+	ok, err := dialer.PingNode(ctx, server.Node())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, dialer.pool.size())
+
+	time.Sleep(4 * idleTimeout)
+	assert.Equal(t, 0, dialer.pool.size())
+
+	ok, err = dialer.PingNode(ctx, server.Node())
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, int32(2), dials.count())
+}
+
+func TestConnPoolEviction(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	first, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer second.Close()
+
+	dialer, dials := newPoolingDialer(ctx, t, ConnPoolConfig{Size: 1, IdleTimeout: time.Minute})
+	defer ctx.Check(dialer.Close)
+
+	// the connection to second evicts the one to first
+	for _, server := range []*testkademlia.Server{first, second, first} {
+		ok, err := dialer.PingNode(ctx, server.Node())
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	assert.Equal(t, int32(3), dials.count())
+	assert.Equal(t, 1, dialer.pool.size())
+}
+
+func TestConnPoolClose(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	dialer, _ := newPoolingDialer(ctx, t, ConnPoolConfig{Size: 4, IdleTimeout: time.Minute})
+
+	_, err = dialer.FetchPeerIdentityUnverified(ctx, server.Addr())
+	require.NoError(t, err)
+	ok, err := dialer.PingNode(ctx, server.Node())
+	require.NoError(t, err)
+	require.True(t, ok)
+	// the verified dial closed the connection dialed by address
+	require.Equal(t, 1, dialer.pool.size())
+
+	require.NoError(t, dialer.Close())
+	assert.Equal(t, 0, dialer.pool.size())
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+)
+
+// addressChanged records that the entry of a node with the previous address
+// was replaced by the one of current, whose address was verified by a
+// successful connection.
+func (rt *RoutingTable) addressChanged(previous, current *pb.Node) {
+	if pb.AddressEqual(previous.Address, current.Address) {
+		return
+	}
+	rt.bus.Publish(Event{
+		Type:            EventAddressChanged,
+		Time:            time.Now(),
+		Node:            current.Id,
+		Address:         current.Address.GetAddress(),
+		PreviousAddress: previous.Address.GetAddress(),
+	})
+}
+
+// checkConsistency enforces that a node has at most one entry in the
+// buckets, the replacement caches and the pinned nodes, which would otherwise
+// make the node flap between its addresses. Duplicate entries are removed,
+// keeping the pinned entry, then the bucket entry, then the most recently
+// cached one. It returns the number of removed entries.
+func (rt *RoutingTable) checkConsistency() (violations int, err error) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	bucketed := make(map[storj.NodeID]bool)
+	var pinnedBucketed []storj.NodeID
+	err = rt.iterateNodes(storj.NodeID{}, func(id storj.NodeID, _ []byte) error {
+		if _, ok := rt.pinned[id]; ok {
+			pinnedBucketed = append(pinnedBucketed, id)
+			return nil
+		}
+		bucketed[id] = true
+		return nil
+	}, true)
+	if err != nil {
+		return 0, RoutingErr.Wrap(err)
+	}
+
+	for _, id := range pinnedBucketed {
+		if err := rt.nodeBucketDB.Delete(id.Bytes()); err != nil && !storage.ErrKeyNotFound.Has(err) {
+			return violations, RoutingErr.Wrap(err)
+		}
+		violations++
+	}
+
+	rt.rcMutex.Lock()
+	cached := make(map[storj.NodeID]bool)
+	for kadBucketID, nodes := range rt.replacementCache {
+		kept := make([]*pb.Node, 0, len(nodes))
+		// newest first, so that the most recent entry survives
+		for i := len(nodes) - 1; i >= 0; i-- {
+			id := nodes[i].Id
+			if _, pinned := rt.pinned[id]; pinned || bucketed[id] || cached[id] {
+				violations++
+				continue
+			}
+			cached[id] = true
+			kept = append(kept, nodes[i])
+		}
+		for i, k := 0, len(kept)-1; i < k; i, k = i+1, k-1 {
+			kept[i], kept[k] = kept[k], kept[i]
+		}
+		rt.replacementCache[kadBucketID] = kept
+	}
+	rt.rcMutex.Unlock()
+
+	return violations, nil
+}
+
+// CheckConsistency removes the duplicate entries of nodes in the routing
+// table and reports them, see Config.ConsistencyCheck.
+func (k *Kademlia) CheckConsistency() error {
+	violations, err := k.routingTable.checkConsistency()
+	k.mon.IntVal("routing_table_violations").Observe(int64(violations))
+	if violations > 0 {
+		k.log.Warn("removed duplicate routing table entries", zap.Int("count", violations))
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func cachedAddresses(rt *RoutingTable, id storj.NodeID) []string {
+	rt.rcMutex.Lock()
+	defer rt.rcMutex.Unlock()
+	var addresses []string
+	for _, nodes := range rt.replacementCache {
+		for _, node := range nodes {
+			if node.Id == id {
+				addresses = append(addresses, node.Address.Address)
+			}
+		}
+	}
+	return addresses
+}
+
+func TestAddressChange(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(storj.NodeID{0x00}, routingTableOpts{bucketSize: 2})
+	defer ctx.Check(rt.Close)
+
+	node := func(id byte, address string) *pb.Node {
+		return &pb.Node{Id: storj.NodeID{id}, Address: &pb.NodeAddress{Address: address}}
+	}
+
+	// fill the far bucket, so that the next node is cached
+	require.NoError(t, rt.ConnectionSuccess(node(0x81, "a:7777")))
+	require.NoError(t, rt.ConnectionSuccess(node(0x82, "b:7777")))
+
+	{ // a cached node that moved is cached once, with its new address
+		require.NoError(t, rt.ConnectionSuccess(node(0x83, "old:7777")))
+		assert.Equal(t, []string{"old:7777"}, cachedAddresses(rt, storj.NodeID{0x83}))
+
+		require.NoError(t, rt.ConnectionSuccess(node(0x83, "new:7777")))
+		assert.Equal(t, []string{"new:7777"}, cachedAddresses(rt, storj.NodeID{0x83}))
+	}
+
+	{ // a node in the table loses its stale cached entry when it connects from its new address
+		rt.rcMutex.Lock()
+		for kadBucketID := range rt.replacementCache {
+			rt.replacementCache[kadBucketID] = append(rt.replacementCache[kadBucketID], node(0x81, "stale:7777"))
+		}
+		rt.rcMutex.Unlock()
+
+		require.NoError(t, rt.ConnectionSuccess(node(0x81, "moved:7777")))
+		assert.Empty(t, cachedAddresses(rt, storj.NodeID{0x81}))
+		address, ok := rt.advertisedAddress(storj.NodeID{0x81})
+		require.True(t, ok)
+		assert.Equal(t, "moved:7777", address)
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(storj.NodeID{0x00}, routingTableOpts{bucketSize: 2})
+	defer ctx.Check(rt.Close)
+
+	node := func(id byte, address string) *pb.Node {
+		return &pb.Node{Id: storj.NodeID{id}, Address: &pb.NodeAddress{Address: address}}
+	}
+
+	require.NoError(t, rt.ConnectionSuccess(node(0x81, "a:7777")))
+	require.NoError(t, rt.ConnectionSuccess(node(0x82, "b:7777")))
+	require.NoError(t, rt.ConnectionSuccess(node(0x83, "c:7777")))
+
+	violations, err := rt.checkConsistency()
+	require.NoError(t, err)
+	assert.Equal(t, 0, violations)
+
+	// duplicates of a bucketed node, and of a cached node
+	rt.rcMutex.Lock()
+	for kadBucketID := range rt.replacementCache {
+		rt.replacementCache[kadBucketID] = append(rt.replacementCache[kadBucketID],
+			node(0x81, "stale:7777"), node(0x83, "c2:7777"))
+	}
+	rt.rcMutex.Unlock()
+
+	violations, err = rt.checkConsistency()
+	require.NoError(t, err)
+	assert.Equal(t, 2, violations)
+	assert.Empty(t, cachedAddresses(rt, storj.NodeID{0x81}))
+	assert.Equal(t, []string{"c2:7777"}, cachedAddresses(rt, storj.NodeID{0x83}))
+
+	violations, err = rt.checkConsistency()
+	require.NoError(t, err)
+	assert.Equal(t, 0, violations)
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+)
+
+// ContactStages are the stages of a contact check, in order.
+var ContactStages = []string{"dns", "tcp", "tls", "ping", "version"}
+
+// ContactStage is the result of a single stage of a contact check.
+type ContactStage struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Cause    string        `json:"cause,omitempty"`
+}
+
+// CheckContact checks step by step whether target can be contacted, each
+// stage is limited to timeout. Stages after a failed stage are skipped. The
+// stages from tcp on dial like the other dials of dialer, through the proxy
+// of its transport if it has one.
+func CheckContact(ctx context.Context, dialer *Dialer, target storj.NodeURL, timeout time.Duration) (stages []ContactStage) {
+	defer dialer.mon.Task()(&ctx)(nil)
+
+	node := pb.Node{Id: target.ID, Address: &pb.NodeAddress{Address: target.Address}}
+	checks := map[string]func(ctx context.Context) (detail, cause string, err error){
+		"dns": func(ctx context.Context) (string, string, error) {
+			host, _, err := net.SplitHostPort(target.Address)
+			if err != nil {
+				return "", "the address is not in host:port form", err
+			}
+			if net.ParseIP(host) != nil {
+				return host, "", nil
+			}
+			addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return "", "the hostname does not resolve, check the address and the DNS records", err
+			}
+			return addrs[0], "", nil
+		},
+		"tcp": func(ctx context.Context) (string, string, error) {
+			conn, err := dialer.transport.DialTCP(ctx, target.Address)
+			if err != nil {
+				switch errs2.ClassifyDial(err) {
+				case errs2.DialRefused:
+					return "", "nothing is listening on the port, check that the node is running and the port is forwarded", err
+				case errs2.DialTimeout:
+					return "", "the connection timed out, the port is likely blocked by a firewall", err
+				}
+				return "", "unable to connect, check the network and firewall configuration", err
+			}
+			return conn.RemoteAddr().String(), "", conn.Close()
+		},
+		"tls": func(ctx context.Context) (string, string, error) {
+			ident, err := dialer.fetchPeerIdentityUnverified(ctx, target.Address)
+			if err != nil {
+				if transport.IsVerificationError(err) {
+					return "", "the peer identity was rejected, its certificate chain is invalid or revoked", err
+				}
+				return "", "the TLS handshake failed, the address may not belong to a storj node", err
+			}
+			if !target.ID.IsZero() && ident.ID != target.ID {
+				return ident.ID.String(), "identity mismatch, the address is used by a different node", Error.New("expected node %s, got %s", target.ID, ident.ID)
+			}
+			node.Id = ident.ID
+			return ident.ID.String(), "", nil
+		},
+		"ping": func(ctx context.Context) (string, string, error) {
+			_, err := dialer.PingNode(ctx, node)
+			if err != nil {
+				return "", "the node does not answer kademlia requests", err
+			}
+			return "", "", nil
+		},
+		"version": func(ctx context.Context) (string, string, error) {
+			version, err := dialer.FetchVersion(ctx, node)
+			if err != nil {
+				return "", "the node does not report its version, it is likely running an outdated release", err
+			}
+			return version.Version, "", nil
+		},
+	}
+
+	failed := false
+	for _, name := range ContactStages {
+		stage := ContactStage{Name: name, Skipped: failed}
+		if !failed {
+			stageCtx, cancel := context.WithTimeout(ctx, timeout)
+			start := time.Now()
+			detail, cause, err := checks[name](stageCtx)
+			stage.Duration = time.Since(start)
+			cancel()
+
+			stage.Passed, stage.Detail = err == nil, detail
+			if err != nil {
+				stage.Error, stage.Cause = err.Error(), cause
+				failed = true
+			}
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
@@ -5,167 +5,1362 @@ package kademlia
 
 import (
 	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/internal/memory"
 	"storj.io/storj/internal/sync2"
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/storj"
 	"storj.io/storj/pkg/transport"
 )
 
+const (
+	// estimatedNodeSize is the approximate encoded size of a node in a response
+	estimatedNodeSize = 64 * memory.B
+	// defaultDialLimit is the maximum number of concurrent dials, unless
+	// configured otherwise with WithDialLimit
+	defaultDialLimit = 32
+	// bootstrapReservedDials is the number of dials only bootstrapping may use during warmup
+	bootstrapReservedDials = 8
+	// peerCacheSize is the maximum number of peers remembered per kind of information
+	peerCacheSize = 10000
+	// maxHandedBack is the maximum number of connections kept for reuse after being released
+	maxHandedBack = 16
+	// selfDialCacheSize is the maximum number of node IDs remembered at the address of this node
+	selfDialCacheSize = 100
+)
+
 // Dialer is a kademlia dialer
 type Dialer struct {
 	log       *zap.Logger
 	transport transport.Client
-	limit     sync2.Semaphore
+	limit     priorityLimiter
+
+	// changed by reloading the configuration
+	settingsMu  sync.Mutex
+	compression CompressionConfig
+	options     DialerOptions
+	policies    RPCPolicies
+	// whether lookup responses may return private addresses, see
+	// WithPrivateAddresses
+	allowPrivate bool
+	// resolves the host names of the nodes of lookup responses
+	resolver ipResolver
+	// features enabled for featureID, see SetFeatures
+	features  FeatureChecker
+	featureID storj.NodeID
+
+	// dials other than bootstrapping additionally lock unreserved during warmup
+	warmup     *warmup
+	unreserved priorityLimiter
+
+	// contacts are published to bus, events keeps the history of the peers
+	bus    *Bus
+	events *eventlog.Sink
+	mon    *monkit.Scope
+
+	// capabilities advertised by peers, keyed by their verified identity
+	capabilities *lrucache.Cache
+	// number of unsorted lookup responses of peers
+	orderViolations *lrucache.Cache
+	// protocol versions advertised by peers
+	protocols *lrucache.Cache
+	// node IDs whose address turned out to be this node, they aren't dialed again
+	selfDials *lrucache.Cache
+	// estimated clock offsets of peers, see ClockOffset
+	clockOffsets *lrucache.Cache
+	// unverified peers seen once, see admit
+	doorkeeper *lrucache.Cache
+
+	// the local clock, time.Now when nil
+	clockMu sync.Mutex
+	clock   func() time.Time
+
+	identities *identityCache
+
+	// nodes seen in responses and dials are no longer reported as not found
+	negative *negativeCache
+
+	// peers whose recent dials failed, skipped by background dials
+	failures *dialFailures
+
+	// the address a peer last observed this node connecting from
+	observedMu sync.Mutex
+	observed   string
+
+	// connections released by their owners, used by the next dial of the node
+	handedBackMu sync.Mutex
+	handedBack   map[storj.NodeID]*grpc.ClientConn
+	closed       bool
+	// open connections dialed by address, see trackUnverified
+	unverified map[*grpc.ClientConn]*unverifiedConn
+	// idle connections used by the next RPCs to their nodes, nil when the
+	// dialer dials for every RPC, see WithConnPool
+	pool *connPool
+	// the connections dialed and not closed yet, whoever owns them
+	conns connCounter
+	// the RPCs made, see Stats
+	rpcs *rpcCounters
+
+	// background work, such as verifying stale identities
+	workers          *WorkerPool
+	backgroundCtx    context.Context
+	cancelBackground func()
 }
 
 // Conn represents a kademlia connection
 type Conn struct {
 	conn   *grpc.ClientConn
 	client pb.NodesClient
+
+	// the dialer and peer a released connection is handed back for, a
+	// connection reused from the dialer only has the dialer
+	dialer *Dialer
+	id     storj.NodeID
+
+	// the pool the connection is put in after a successful RPC, as key, and
+	// the counter of the open connections of the dialer
+	pool  *connPool
+	key   poolKey
+	conns *connCounter
+	// whether the connection was open before, it may have broken meanwhile
+	reused bool
+
+	// the node the RPCs are meant for, zero when dialed by address, and the
+	// peer that answered them, see verifyPeer
+	expected storj.NodeID
+	peer     peer.Peer
 }
 
-// NewDialer creates a dialer for kademlia.
-func NewDialer(log *zap.Logger, transport transport.Client) *Dialer {
+// ErrNodeIDMismatch is returned when the peer answering at the address of a
+// node has another ID than the node.
+var ErrNodeIDMismatch = errs.Class("node ID mismatch")
+
+// The failures of RPCs to other nodes are of one of these classes, they wrap
+// the error of the transport or of the RPC.
+var (
+	// ErrDial is the class of failures to connect to a node, such as when it
+	// is offline or the dial timed out.
+	ErrDial = errs.Class("dial")
+	// ErrRPC is the class of failures of RPCs on a connection, such as when
+	// the node returned an error or a bad response.
+	ErrRPC = errs.Class("rpc")
+	// ErrVerify is the class of failures to verify the identity of the peer
+	// answering, such as when it isn't the node it was dialed as.
+	ErrVerify = errs.Class("identity verification")
+)
+
+// IsDialFailure returns whether err is a failure to connect to a node.
+func IsDialFailure(err error) bool { return ErrDial.Has(err) }
+
+// IsRPCFailure returns whether err is a failure of an RPC of a connected node.
+func IsRPCFailure(err error) bool { return ErrRPC.Has(err) }
+
+// IsVerifyFailure returns whether err is a failure to verify the identity of
+// a node.
+func IsVerifyFailure(err error) bool { return ErrVerify.Has(err) }
+
+// classified returns whether err already has the class of an RPC failure.
+func classified(err error) bool {
+	return ErrDial.Has(err) || ErrRPC.Has(err) || ErrVerify.Has(err)
+}
+
+// dialFailure returns err of a dial as ErrDial or ErrVerify.
+func dialFailure(err error) error {
+	switch {
+	case err == nil, classified(err):
+		return err
+	case isIdentityMismatch(err):
+		return ErrVerify.Wrap(err)
+	default:
+		return ErrDial.Wrap(err)
+	}
+}
+
+// rpcFailure returns err of an RPC on a connection as ErrRPC or ErrVerify.
+func rpcFailure(err error) error {
+	switch {
+	case err == nil, classified(err):
+		return err
+	case isIdentityMismatch(err):
+		return ErrVerify.Wrap(err)
+	default:
+		return ErrRPC.Wrap(err)
+	}
+}
+
+// NewDialer creates a dialer for kademlia, configured by opts.
+func NewDialer(log *zap.Logger, transport transport.Client, opts ...DialerOption) *Dialer {
+	workers := NewWorkerPool(log.Named("workers"), "dialer", defaultWorkers)
+	return newDialer(log, transport, workers, newIdentityCache(log, nil, workers, IdentityCacheConfig{}), opts...)
+}
+
+// newDialer creates a dialer that runs its background work on workers.
+func newDialer(log *zap.Logger, transport transport.Client, workers *WorkerPool, identities *identityCache, opts ...DialerOption) *Dialer {
 	dialer := &Dialer{
-		log:       log,
-		transport: transport,
+		log:             log,
+		transport:       transport,
+		capabilities:    lrucache.New(peerCacheSize),
+		protocols:       lrucache.New(peerCacheSize),
+		orderViolations: lrucache.New(peerCacheSize),
+		selfDials:       lrucache.New(selfDialCacheSize),
+		clockOffsets:    lrucache.New(peerCacheSize),
+		doorkeeper:      lrucache.New(admitCacheSize),
+		identities:      identities,
+		handedBack:      map[storj.NodeID]*grpc.ClientConn{},
+		unverified:      map[*grpc.ClientConn]*unverifiedConn{},
+		workers:         workers,
+		mon:             mon,
+		policies:        DefaultRPCPolicies(),
+		resolver:        net.DefaultResolver,
+		rpcs:            newRPCCounters(),
+	}
+	dialer.backgroundCtx, dialer.cancelBackground = context.WithCancel(context.Background())
+	dialer.initLimits(defaultDialLimit)
+	for _, opt := range opts {
+		opt(dialer)
 	}
-	dialer.limit.Init(32) // TODO: limit should not be hardcoded
 	return dialer
 }
 
 // Close closes the pool resources and prevents new connections to be made.
 func (dialer *Dialer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	return dialer.Shutdown(ctx)
+}
+
+// Shutdown prevents new connections to be made and waits until the
+// background work has finished or ctx is done.
+func (dialer *Dialer) Shutdown(ctx context.Context) error {
 	dialer.limit.Close()
-	return nil
+	dialer.unreserved.Close()
+	dialer.cancelBackground()
+
+	dialer.handedBackMu.Lock()
+	var group errs.Group
+	for id, conn := range dialer.handedBack {
+		group.Add(dialer.conns.close(conn))
+		delete(dialer.handedBack, id)
+		delete(dialer.unverified, conn)
+	}
+	// the connections in use are closed by their owners
+	for _, tracked := range dialer.unverified {
+		if tracked.timer != nil {
+			tracked.timer.Stop()
+		}
+	}
+	dialer.closed = true
+	dialer.handedBackMu.Unlock()
+
+	group.Add(dialer.pool.close())
+	group.Add(dialer.workers.Close(ctx))
+
+	stats := dialer.Stats()
+	dialer.log.Debug("dialer closed",
+		zap.Int64("rpcs", stats.Issued),
+		zap.Int64("failed", stats.Failed),
+		zap.Any("in flight", stats.InFlight),
+		zap.Int64("open conns", stats.OpenConns))
+	return group.Err()
+}
+
+// initLimits limits the dialer to limit concurrent dials, of which
+// bootstrapReservedDials are reserved for bootstrapping during warmup.
+func (dialer *Dialer) initLimits(limit int) {
+	unreserved := limit - bootstrapReservedDials
+	if unreserved < 1 {
+		unreserved = 1
+	}
+	dialer.limit.init(limit)
+	dialer.unreserved.init(unreserved)
+}
+
+// acquire locks a dial slot with the priority of ctx, during warmup dials
+// other than bootstrapping can't use the slots reserved for bootstrapping.
+func (dialer *Dialer) acquire(ctx context.Context) (release func(), ok bool) {
+	priority := dialPriorityOf(ctx)
+	if !dialer.warmup.active() || hasBootstrapPriority(ctx) {
+		if !dialer.limit.Lock(ctx, priority) {
+			return nil, false
+		}
+		return dialer.limit.Unlock, true
+	}
+
+	if !dialer.unreserved.Lock(ctx, priority) {
+		return nil, false
+	}
+	if !dialer.limit.Lock(ctx, priority) {
+		dialer.unreserved.Unlock()
+		return nil, false
+	}
+	return func() {
+		dialer.limit.Unlock()
+		dialer.unreserved.Unlock()
+	}, true
+}
+
+// Capabilities returns the last capabilities advertised by the node.
+func (dialer *Dialer) Capabilities(id storj.NodeID) (capabilities Capability, known bool) {
+	value, known := dialer.capabilities.Get(id)
+	if !known {
+		return 0, false
+	}
+	return value.(Capability), true
+}
+
+// setCapabilities remembers the capabilities advertised by the node, id must
+// have been verified by the TLS handshake.
+func (dialer *Dialer) setCapabilities(id storj.NodeID, capabilities Capability) {
+	if id.IsZero() {
+		return
+	}
+	dialer.capabilities.Add(id, capabilities)
+}
+
+// Lookup queries ask about find, and also sends information about self,
+// see query. The response is sanitized, see sanitizeNodes, and the nodes are
+// filtered as configured by opts, by ask when it has the query filter
+// capability and locally otherwise.
+func (dialer *Dialer) Lookup(ctx context.Context, self pb.Node, ask pb.Node, find pb.Node, opts ...LookupOption) ([]*pb.Node, error) {
+	filter := newLookupFilter(opts)
+	resp, err := dialer.query(ctx, self, ask, find, filter)
+	if err != nil {
+		return nil, err
+	}
+	nodes := dialer.sanitizeNodes(ctx, self.Id, resp.Response)
+	if filter.Empty() || Capability(resp.Capabilities).Has(CapabilityQueryFilter) {
+		return nodes, nil
+	}
+	return dialer.filterLocally(ctx, filter, nodes)
+}
+
+// LookupRaw is Lookup without filtering, it returns the nodes as ask sent
+// them, such as to diagnose misbehaving peers.
+func (dialer *Dialer) LookupRaw(ctx context.Context, self pb.Node, ask pb.Node, find pb.Node) ([]*pb.Node, error) {
+	resp, err := dialer.query(ctx, self, ask, find, LookupFilter{})
+	return resp.GetResponse(), err
 }
 
-// Lookup queries ask about find, and also sends information about self.
-func (dialer *Dialer) Lookup(ctx context.Context, self pb.Node, ask pb.Node, find pb.Node) ([]*pb.Node, error) {
-	if !dialer.limit.Lock() {
+// sanitizeNodes returns the nodes of a lookup response without the ones that
+// are no use to the caller: duplicates, the node with the self ID, and nodes
+// without an ID or with an address that ValidateNodeAddress rejects. nodes
+// itself isn't modified.
+func (dialer *Dialer) sanitizeNodes(ctx context.Context, self storj.NodeID, nodes []*pb.Node) []*pb.Node {
+	dialer.settingsMu.Lock()
+	allowPrivate := dialer.allowPrivate
+	dialer.settingsMu.Unlock()
+
+	sanitized := make([]*pb.Node, 0, len(nodes))
+	seen := make(map[storj.NodeID]struct{}, len(nodes))
+	for _, node := range nodes {
+		if _, duplicate := seen[node.Id]; duplicate || node.Id.IsZero() || node.Id == self {
+			continue
+		}
+		if validateNodeAddress(ctx, dialer.resolver, *node, allowPrivate) != nil {
+			continue
+		}
+		seen[node.Id] = struct{}{}
+		sanitized = append(sanitized, node)
+	}
+	if dropped := len(nodes) - len(sanitized); dropped > 0 {
+		dialer.mon.Counter("lookup_nodes_dropped").Inc(int64(dropped))
+	}
+	return sanitized
+}
+
+// query queries ask about find, and also sends information about self. An
+// empty self is not sent, and a self without a valid address is rejected
+// with ErrNotReady, since ask would insert it into its routing table. The
+// filter is honored only by peers with the query filter capability.
+func (dialer *Dialer) query(ctx context.Context, self pb.Node, ask pb.Node, find pb.Node, filter LookupFilter) (resp *pb.QueryResponse, err error) {
+	err = dialer.call(ctx, RPCLookup, func(ctx context.Context) (err error) {
+		resp, err = dialer.queryOnce(ctx, self, ask, find, filter)
+		return err
+	})
+	return resp, err
+}
+
+// queryOnce makes a single attempt of query.
+func (dialer *Dialer) queryOnce(ctx context.Context, self pb.Node, ask pb.Node, find pb.Node, filter LookupFilter) (_ *pb.QueryResponse, err error) {
+	defer dialer.record(RPCLookup, ask, time.Now())(&err)
+
+	if !self.Id.IsZero() && !announceable(self) {
+		return nil, ErrNotReady.New("no valid address to announce")
+	}
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
 		return nil, context.Canceled
 	}
-	defer dialer.limit.Unlock()
+	defer release()
 
-	conn, err := dialer.dialNode(ctx, ask)
+	ctx = dialContext(ctx, RPCLookup)
+	req := &pb.QueryRequest{
+		Limit:        20, // TODO: should not be hardcoded, but instead kademlia k value, routing table depth, etc
+		Target:       &find,
+		Capabilities: uint64(Capabilities),
+		Filter:       filter.proto(),
+	}
+	if !self.Id.IsZero() {
+		req.Sender = &self
+		req.Pingback = true // should only be true during bucket refreshing
+	}
+
+	var (
+		resp   *pb.QueryResponse
+		header metadata.MD
+		sent   time.Time
+	)
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialNode(ctx, ask)
+	}, func(conn *Conn) (err error) {
+		header, sent = nil, dialer.now()
+		resp, err = conn.client.Query(ctx, req, conn.callOptions(grpc.Header(&header))...)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	dialer.setCapabilities(ask.Id, Capability(resp.Capabilities))
+	dialer.setProtocolFromHeader(ask.Id, header)
+	dialer.observeClock(ask.Id, sent, dialer.now(), resp.Time)
+
+	nodes := resp.Response
+	dialer.negative.seen(nodes...)
+	_, options := dialer.settings()
+	if options.VerifyOrdering && !NodesSortedByXOR(nodes, find.Id) {
+		dialer.orderViolation(ask, find.Id)
+		SortNodesByXOR(nodes, find.Id)
+	}
 
-	resp, err := conn.client.Query(ctx, &pb.QueryRequest{
-		Limit:    20, // TODO: should not be hardcoded, but instead kademlia k value, routing table depth, etc
-		Sender:   &self,
-		Target:   &find,
-		Pingback: true, // should only be true during bucket refreshing
+	return resp, conn.finish()
+}
+
+// orderViolation records that ask responded to a lookup of target with unsorted nodes.
+func (dialer *Dialer) orderViolation(ask pb.Node, target storj.NodeID) {
+	dialer.mon.Counter("lookup_order_violations").Inc(1)
+	dialer.log.Debug("lookup response is not sorted by distance to the target",
+		zap.Stringer("Node ID", ask.Id), zap.Stringer("target", target))
+
+	dialer.orderViolations.Update(ask.Id, func(value interface{}, ok bool) interface{} {
+		if !ok {
+			return int64(1)
+		}
+		return value.(int64) + 1
 	})
+}
+
+// OrderViolations returns the number of lookup responses from the node that weren't sorted by distance.
+func (dialer *Dialer) OrderViolations(id storj.NodeID) int64 {
+	value, ok := dialer.orderViolations.Peek(id)
+	if !ok {
+		return 0
+	}
+	return value.(int64)
+}
+
+// LookupStream queries ask about the nodes nearest to find and returns them in chunks of chunkSize.
+//
+// A non-positive limit asks for the whole routing table of ask. The returned
+// stream must be closed, closing it before the end stops the server from sending.
+//
+// When ask doesn't support streaming, or streaming lookups aren't enabled for
+// this node, a single unary query is made instead and
+// its result is split into chunks. A non-positive limit is then replaced with
+// the largest chunk size.
+//
+// Large responses are gzip compressed when enabled and supported by ask.
+// The timeout of the policy of lookup streams bounds the whole stream.
+func (dialer *Dialer) LookupStream(ctx context.Context, ask pb.Node, find pb.Node, limit, chunkSize int) (_ *NodeStream, err error) {
+	defer dialer.record(RPCLookupStream, ask, time.Now())(&err)
+	finish := dialer.rpcs.begin(RPCLookupStream)
+	defer func() {
+		if err != nil {
+			finish(err)
+		}
+	}()
+
+	ctx, cancel := dialer.policy(RPCLookupStream).context(ctx)
+	acquired, ok := dialer.acquire(ctx)
+	if !ok {
+		cancel()
+		return nil, context.Canceled
+	}
+	release := func() {
+		acquired()
+		cancel()
+	}
+
+	ctx = dialContext(ctx, RPCLookupStream)
+	conn, err := dialer.dialNode(ctx, ask)
 	if err != nil {
+		release()
+		return nil, err
+	}
+
+	capabilities, known := dialer.Capabilities(ask.Id)
+	if !known {
+		capabilities, err = dialer.ping(ctx, conn, ask.Id)
+		if err == nil {
+			err = dialer.verifyPeer(conn)
+		}
+		if err != nil {
+			release()
+			return nil, errs.Combine(err, conn.disconnect())
+		}
+	}
+
+	var opts []grpc.CallOption
+	if dialer.compress(capabilities, limit) {
+		opts = append(opts, grpc.UseCompressor(gzip.Name))
+	}
+
+	if !capabilities.Has(CapabilityQueryStream) || !dialer.featureEnabled(FeatureQueryStream) {
+		defer release()
+
+		if limit <= 0 {
+			limit = maxChunkSize
+		}
+		if chunkSize <= 0 {
+			chunkSize = defaultChunkSize
+		}
+
+		resp, err := conn.client.Query(ctx, &pb.QueryRequest{
+			Target:       &find,
+			Limit:        int64(limit),
+			Capabilities: uint64(Capabilities),
+		}, conn.callOptions(opts...)...)
+		if err == nil {
+			err = dialer.verifyPeer(conn)
+		}
+		if err != nil {
+			return nil, errs.Combine(err, conn.disconnect())
+		}
+		finish(nil)
+		return &NodeStream{pending: resp.Response, chunkSize: chunkSize}, conn.disconnect()
+	}
+
+	stream, err := conn.client.QueryStream(ctx, &pb.QueryRequest{
+		Target:    &find,
+		Limit:     int64(limit),
+		ChunkSize: int64(chunkSize),
+	}, opts...)
+	if err == nil {
+		// the peer of a stream is only recorded when it ends, but the
+		// context of the stream has it once it started. Without it the
+		// peer can't be verified, and the stream is rejected.
+		conn.peer = peer.Peer{}
+		if p, ok := peer.FromContext(stream.Context()); ok {
+			conn.peer = *p
+		}
+		err = dialer.verifyPeer(conn)
+	}
+	if err != nil {
+		release()
 		return nil, errs.Combine(err, conn.disconnect())
 	}
 
-	return resp.Response, conn.disconnect()
+	return &NodeStream{
+		conn:   conn,
+		stream: stream,
+		cancel: cancel,
+		release: func() {
+			release()
+			finish(nil)
+		},
+	}, nil
+}
+
+// compress returns whether a lookup of limit nodes from a peer with capabilities should be compressed.
+func (dialer *Dialer) compress(capabilities Capability, limit int) bool {
+	compression, _ := dialer.settings()
+	if !compression.Enabled || !capabilities.Has(CapabilityGzip) {
+		return false
+	}
+	return limit <= 0 || memory.Size(limit)*estimatedNodeSize >= compression.Threshold
+}
+
+// settings returns the compression and response checks currently configured.
+func (dialer *Dialer) settings() (CompressionConfig, DialerOptions) {
+	dialer.settingsMu.Lock()
+	defer dialer.settingsMu.Unlock()
+	return dialer.compression, dialer.options
+}
+
+// setSettings changes the compression, response checks and RPC policies of the following dials.
+func (dialer *Dialer) setSettings(compression CompressionConfig, options DialerOptions, policies RPCPolicies) {
+	dialer.settingsMu.Lock()
+	defer dialer.settingsMu.Unlock()
+	dialer.compression, dialer.options, dialer.policies = compression, options, policies
+	dialer.allowPrivate = options.AllowPrivateAddresses
+}
+
+// NodeStream iterates over the chunks of nodes returned by Dialer.LookupStream
+type NodeStream struct {
+	conn    *Conn
+	stream  pb.Nodes_QueryStreamClient
+	cancel  func()
+	release func()
+
+	// used instead of stream when the peer doesn't support streaming
+	pending   []*pb.Node
+	chunkSize int
+
+	closed bool
+}
+
+// Next returns the next chunk of nodes, or io.EOF when all nodes have been received.
+func (stream *NodeStream) Next() ([]*pb.Node, error) {
+	if stream.stream == nil {
+		if len(stream.pending) == 0 {
+			return nil, io.EOF
+		}
+		chunk := stream.pending
+		if len(chunk) > stream.chunkSize {
+			chunk = chunk[:stream.chunkSize]
+		}
+		stream.pending = stream.pending[len(chunk):]
+		return chunk, nil
+	}
+
+	resp, err := stream.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Response, nil
+}
+
+// Close cancels the stream and disconnects, closing it again does nothing.
+func (stream *NodeStream) Close() error {
+	if stream.closed {
+		return nil
+	}
+	stream.closed = true
+
+	if stream.stream == nil {
+		stream.pending = nil
+		return nil
+	}
+	stream.cancel()
+	err := stream.conn.disconnect()
+	stream.release()
+	return err
 }
 
 // PingNode pings target.
-func (dialer *Dialer) PingNode(ctx context.Context, target pb.Node) (bool, error) {
-	if !dialer.limit.Lock() {
+func (dialer *Dialer) PingNode(ctx context.Context, target pb.Node) (pinged bool, err error) {
+	err = dialer.call(ctx, RPCPing, func(ctx context.Context) (err error) {
+		pinged, err = dialer.pingNode(ctx, target)
+		return err
+	})
+	return pinged, err
+}
+
+// PingResult is the result of pinging a node with PingNodes.
+type PingResult struct {
+	Node   pb.Node
+	Pinged bool
+	// RTT is how long the ping took, including dialing the node when there
+	// was no open connection to it
+	RTT time.Duration
+	Err error
+}
+
+// PingNodes pings nodes, at most concurrency at a time, and returns the
+// result of each in the order of nodes, also when some failed. Nodes not
+// pinged yet when ctx is canceled fail with the error of ctx. The errors of
+// the pings are combined in the returned error.
+func (dialer *Dialer) PingNodes(ctx context.Context, nodes []pb.Node, concurrency int) ([]PingResult, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]PingResult, len(nodes))
+	limiter := sync2.NewLimiter(concurrency)
+	for i := range nodes {
+		result := &results[i]
+		result.Node = nodes[i]
+		started := limiter.Go(ctx, func() {
+			start := time.Now()
+			result.Pinged, result.Err = dialer.PingNode(ctx, result.Node)
+			result.RTT = time.Since(start)
+		})
+		if !started {
+			result.Err = ctx.Err()
+		}
+	}
+	limiter.Wait()
+
+	var errlist []error
+	for _, result := range results {
+		if result.Err != nil {
+			errlist = append(errlist, result.Err)
+		}
+	}
+	return results, errs.Combine(errlist...)
+}
+
+// pingNode makes a single attempt of PingNode.
+func (dialer *Dialer) pingNode(ctx context.Context, target pb.Node) (_ bool, err error) {
+	defer dialer.record(RPCPing, target, time.Now())(&err)
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
 		return false, context.Canceled
 	}
-	defer dialer.limit.Unlock()
+	defer release()
 
-	conn, err := dialer.dialNode(ctx, target)
+	ctx = dialContext(ctx, RPCPing)
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialNode(ctx, target)
+	}, func(conn *Conn) error {
+		_, err := dialer.ping(ctx, conn, target.Id)
+		return err
+	})
 	if err != nil {
 		return false, err
 	}
+	return true, conn.finish()
+}
+
+// AlivenessCheck pings target like PingNode and returns the round trip time
+// of the ping. Dialing isn't included, such as the TLS handshake of a new
+// connection, so that the time is the same whether a pooled connection was
+// used or not. The errors are classified like those of the other RPCs:
+// ErrDial, ErrRPC or ErrVerify.
+func (dialer *Dialer) AlivenessCheck(ctx context.Context, target pb.Node) (rtt time.Duration, err error) {
+	err = dialer.call(ctx, RPCPing, func(ctx context.Context) (err error) {
+		rtt, err = dialer.alivenessCheck(ctx, target)
+		return err
+	})
+	return rtt, err
+}
+
+// alivenessCheck makes a single attempt of AlivenessCheck.
+func (dialer *Dialer) alivenessCheck(ctx context.Context, target pb.Node) (_ time.Duration, err error) {
+	defer dialer.record(RPCPing, target, time.Now())(&err)
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
+		return 0, ErrDial.Wrap(context.Canceled)
+	}
+	defer release()
 
-	_, err = conn.client.Ping(ctx, &pb.PingRequest{})
+	ctx = dialContext(ctx, RPCPing)
+	var rtt time.Duration
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialNode(ctx, target)
+	}, func(conn *Conn) error {
+		start := time.Now()
+		_, err := dialer.ping(ctx, conn, target.Id)
+		rtt = time.Since(start)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rtt, conn.finish()
+}
 
-	return err == nil, errs.Combine(err, conn.disconnect())
+// ping pings the node on conn and remembers its capabilities and clock offset.
+func (dialer *Dialer) ping(ctx context.Context, conn *Conn, id storj.NodeID, opts ...grpc.CallOption) (Capability, error) {
+	var header metadata.MD
+	sent := dialer.now()
+	resp, err := conn.client.Ping(ctx, &pb.PingRequest{}, conn.callOptions(append(opts, grpc.Header(&header))...)...)
+	if err != nil {
+		return 0, err
+	}
+	capabilities := Capability(resp.Capabilities)
+	dialer.setCapabilities(id, capabilities)
+	dialer.setProtocolFromHeader(id, header)
+	dialer.observe(resp.ObservedAddress)
+	dialer.observeClock(id, sent, dialer.now(), resp.Time)
+	return capabilities, nil
 }
 
-// FetchPeerIdentity connects to a node and returns its peer identity
+// FetchPeerIdentity returns the peer identity of a node, connecting to it when the identity isn't cached.
+// Concurrent fetches of an identity that isn't cached share a single dial.
+//
+// Stale identities, loaded from the database at startup or verified longer
+// than the TTL ago, are returned immediately and verified again in the
+// background.
 func (dialer *Dialer) FetchPeerIdentity(ctx context.Context, target pb.Node) (_ *identity.PeerIdentity, err error) {
-	if !dialer.limit.Lock() {
-		return nil, context.Canceled
+	if ident, needsVerify := dialer.identities.get(target.Id); ident != nil {
+		dialer.mon.Counter("identity_cache_hit").Inc(1)
+		if needsVerify && !dialer.workers.Go("verify-identity", func() { dialer.verifyIdentity(target) }) {
+			dialer.identities.verifyFailed(target.Id)
+		}
+		return ident, nil
 	}
-	defer dialer.limit.Unlock()
 
-	conn, err := dialer.dialNode(ctx, target)
+	return dialer.fetchShared("node "+target.Id.String(), func() (*identity.PeerIdentity, error) {
+		ident, err := dialer.fetchPeerIdentity(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		dialer.identities.verified(ident)
+		return ident, nil
+	})
+}
+
+// fetchShared fetches an identity that isn't cached with fetch, sharing the
+// fetch with the concurrent callers with the same key.
+func (dialer *Dialer) fetchShared(key string, fetch func() (*identity.PeerIdentity, error)) (*identity.PeerIdentity, error) {
+	result, err, shared := dialer.identities.fetches.Do(key, func() (interface{}, error) {
+		return fetch()
+	})
+	if shared {
+		dialer.mon.Counter("identity_fetch_shared").Inc(1)
+	} else {
+		dialer.mon.Counter("identity_cache_miss").Inc(1)
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		err = errs.Combine(err, conn.disconnect())
-	}()
+	return result.(*identity.PeerIdentity), nil
+}
 
-	p := &peer.Peer{}
-	_, err = conn.client.Ping(ctx, &pb.PingRequest{}, grpc.Peer(p))
-	ident, errFromPeer := identity.PeerIdentityFromPeer(p)
-	return ident, errs.Combine(err, errFromPeer)
+// InvalidateIdentity forgets the cached identity of the node with id, such as
+// when the caller learned that it changed. The next fetch dials the node.
+func (dialer *Dialer) InvalidateIdentity(id storj.NodeID) {
+	dialer.identities.invalidate(id)
+}
+
+// verifyIdentity connects to target to verify its stale cached identity.
+func (dialer *Dialer) verifyIdentity(target pb.Node) {
+	if dialer.failures.backedOff(target.Id) {
+		dialer.identities.verifyFailed(target.Id)
+		return
+	}
+
+	ident, err := dialer.fetchPeerIdentity(dialer.backgroundCtx, target)
+	switch {
+	case err == nil:
+		dialer.identities.verified(ident)
+	case transport.IsVerificationError(err):
+		dialer.log.Warn("cached peer identity failed verification", zap.Stringer("Node ID", target.Id), zap.Error(err))
+		dialer.identities.invalidate(target.Id)
+	default:
+		dialer.log.Debug("unable to verify cached peer identity", zap.Stringer("Node ID", target.Id), zap.Error(err))
+		dialer.identities.verifyFailed(target.Id)
+	}
+}
+
+// fetchPeerIdentity connects to a node and returns its peer identity
+func (dialer *Dialer) fetchPeerIdentity(ctx context.Context, target pb.Node) (ident *identity.PeerIdentity, err error) {
+	err = dialer.call(ctx, RPCFetchIdentity, func(ctx context.Context) (err error) {
+		ident, err = dialer.fetchPeerIdentityOnce(ctx, target)
+		return err
+	})
+	return ident, err
+}
+
+// fetchPeerIdentityOnce makes a single attempt of fetchPeerIdentity.
+func (dialer *Dialer) fetchPeerIdentityOnce(ctx context.Context, target pb.Node) (_ *identity.PeerIdentity, err error) {
+	defer dialer.record(RPCFetchIdentity, target, time.Now())(&err)
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
+		return nil, context.Canceled
+	}
+	defer release()
+
+	ctx = dialContext(ctx, RPCFetchIdentity)
+	var ident *identity.PeerIdentity
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialNode(ctx, target)
+	}, func(conn *Conn) (err error) {
+		p := &peer.Peer{}
+		_, err = dialer.ping(ctx, conn, target.Id, grpc.Peer(p))
+		var errFromPeer error
+		ident, errFromPeer = identity.PeerIdentityFromPeer(p)
+		return errs.Combine(err, errFromPeer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ident, conn.finish()
 }
 
 // FetchPeerIdentityUnverified connects to an address and returns its peer identity (no node ID verification).
+//
+// When the identities of addresses are cached, see IdentityCacheConfig, the
+// identity fetched from the address is used until the address TTL expires.
+// Fetches with opts always connect. Concurrent fetches from an address share
+// a single dial.
 func (dialer *Dialer) FetchPeerIdentityUnverified(ctx context.Context, address string, opts ...grpc.CallOption) (_ *identity.PeerIdentity, err error) {
-	if !dialer.limit.Lock() {
-		return nil, context.Canceled
+	if len(opts) > 0 {
+		return dialer.fetchPeerIdentityUnverified(ctx, address, opts...)
+	}
+	if ident, ok := dialer.identities.address(address); ok {
+		dialer.mon.Counter("identity_cache_hit").Inc(1)
+		return ident, nil
 	}
-	defer dialer.limit.Unlock()
 
-	conn, err := dialer.dialAddress(ctx, address)
+	return dialer.fetchShared("address "+address, func() (*identity.PeerIdentity, error) {
+		ident, err := dialer.fetchPeerIdentityUnverified(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		dialer.identities.fetchedFrom(address, ident)
+		return ident, nil
+	})
+}
+
+// fetchPeerIdentityUnverified connects to an address and returns its peer
+// identity, bypassing the cache.
+func (dialer *Dialer) fetchPeerIdentityUnverified(ctx context.Context, address string, opts ...grpc.CallOption) (*identity.PeerIdentity, error) {
+	ident, conn, err := dialer.FetchPeerIdentityUnverifiedConn(ctx, address, opts...)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		err = errs.Combine(err, conn.disconnect())
-	}()
+	return ident, conn.finish()
+}
+
+// FetchPeerIdentityUnverifiedConn connects to an address and returns its peer
+// identity (no node ID verification) together with the connection.
+//
+// The caller owns the connection: it must either Close it, or Release it to
+// be used by the next RPC of the dialer to the node of the identity.
+func (dialer *Dialer) FetchPeerIdentityUnverifiedConn(ctx context.Context, address string, opts ...grpc.CallOption) (ident *identity.PeerIdentity, conn *Conn, err error) {
+	err = dialer.call(ctx, RPCFetchIdentityUnverified, func(ctx context.Context) (err error) {
+		ident, conn, err = dialer.fetchPeerIdentityUnverifiedConn(ctx, address, opts...)
+		return err
+	})
+	return ident, conn, err
+}
+
+// fetchPeerIdentityUnverifiedConn makes a single attempt of FetchPeerIdentityUnverifiedConn.
+func (dialer *Dialer) fetchPeerIdentityUnverifiedConn(ctx context.Context, address string, opts ...grpc.CallOption) (_ *identity.PeerIdentity, _ *Conn, err error) {
+	defer dialer.record(RPCFetchIdentityUnverified, pb.Node{Address: &pb.NodeAddress{Address: address}}, time.Now())(&err)
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
+		return nil, nil, context.Canceled
+	}
+	defer release()
+
+	ctx = dialContext(ctx, RPCFetchIdentityUnverified)
+	var ident *identity.PeerIdentity
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialAddress(ctx, address)
+	}, func(conn *Conn) (err error) {
+		p := &peer.Peer{}
+		_, err = conn.client.Ping(ctx, &pb.PingRequest{}, append(opts, grpc.Peer(p))...)
+		var errFromPeer error
+		ident, errFromPeer = identity.PeerIdentityFromPeer(p)
+		return errs.Combine(err, errFromPeer)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn.dialer, conn.id = dialer, ident.ID
+	dialer.trackUnverified(conn.conn, ident.ID)
+	return ident, conn, nil
+}
+
+// PingAddress pings the node at address without verifying its node ID, for
+// callers that only know the address, such as diagnostics. It returns the ID
+// the node identified itself with.
+func (dialer *Dialer) PingAddress(ctx context.Context, address string) (id storj.NodeID, pinged bool, err error) {
+	err = dialer.call(ctx, RPCPingAddress, func(ctx context.Context) (err error) {
+		id, err = dialer.pingAddress(ctx, address)
+		return err
+	})
+	return id, err == nil, err
+}
 
-	p := &peer.Peer{}
-	_, err = conn.client.Ping(ctx, &pb.PingRequest{}, grpc.Peer(p))
-	ident, errFromPeer := identity.PeerIdentityFromPeer(p)
-	return ident, errs.Combine(err, errFromPeer)
+// pingAddress makes a single attempt of PingAddress.
+func (dialer *Dialer) pingAddress(ctx context.Context, address string) (_ storj.NodeID, err error) {
+	defer dialer.record(RPCPingAddress, pb.Node{Address: &pb.NodeAddress{Address: address}}, time.Now())(&err)
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
+		return storj.NodeID{}, context.Canceled
+	}
+	defer release()
+
+	ctx = dialContext(ctx, RPCPingAddress)
+	var ident *identity.PeerIdentity
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialAddress(ctx, address)
+	}, func(conn *Conn) (err error) {
+		p := &peer.Peer{}
+		_, err = conn.client.Ping(ctx, &pb.PingRequest{}, grpc.Peer(p))
+		if err != nil {
+			return err
+		}
+		ident, err = identity.PeerIdentityFromPeer(p)
+		return err
+	})
+	if err != nil {
+		return storj.NodeID{}, err
+	}
+	return ident.ID, conn.finish()
 }
 
 // FetchInfo connects to a node and returns its node info.
-func (dialer *Dialer) FetchInfo(ctx context.Context, target pb.Node) (*pb.InfoResponse, error) {
-	if !dialer.limit.Lock() {
+func (dialer *Dialer) FetchInfo(ctx context.Context, target pb.Node) (info *pb.InfoResponse, err error) {
+	err = dialer.call(ctx, RPCFetchInfo, func(ctx context.Context) (err error) {
+		info, err = dialer.fetchInfo(ctx, target)
+		return err
+	})
+	return info, err
+}
+
+// fetchInfo makes a single attempt of FetchInfo.
+func (dialer *Dialer) fetchInfo(ctx context.Context, target pb.Node) (_ *pb.InfoResponse, err error) {
+	defer dialer.record(RPCFetchInfo, target, time.Now())(&err)
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
 		return nil, context.Canceled
 	}
-	defer dialer.limit.Unlock()
+	defer release()
 
-	conn, err := dialer.dialNode(ctx, target)
+	ctx = dialContext(ctx, RPCFetchInfo)
+	var resp *pb.InfoResponse
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialNode(ctx, target)
+	}, func(conn *Conn) (err error) {
+		resp, err = conn.client.RequestInfo(ctx, &pb.InfoRequest{}, conn.callOptions()...)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return resp, conn.finish()
+}
+
+// FetchVersion connects to a node and returns the version it is running.
+func (dialer *Dialer) FetchVersion(ctx context.Context, target pb.Node) (version *pb.NodeVersion, err error) {
+	err = dialer.call(ctx, RPCFetchVersion, func(ctx context.Context) (err error) {
+		version, err = dialer.fetchVersion(ctx, target)
+		return err
+	})
+	return version, err
+}
 
-	resp, err := conn.client.RequestInfo(ctx, &pb.InfoRequest{})
+// fetchVersion makes a single attempt of FetchVersion.
+func (dialer *Dialer) fetchVersion(ctx context.Context, target pb.Node) (_ *pb.NodeVersion, err error) {
+	defer dialer.record(RPCFetchVersion, target, time.Now())(&err)
 
-	return resp, errs.Combine(err, conn.disconnect())
+	release, ok := dialer.acquire(ctx)
+	if !ok {
+		return nil, context.Canceled
+	}
+	defer release()
+
+	ctx = dialContext(ctx, RPCFetchVersion)
+	var resp *pb.InfoResponse
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialNode(ctx, target)
+	}, func(conn *Conn) (err error) {
+		resp, err = conn.client.RequestInfo(ctx, &pb.InfoRequest{}, conn.callOptions()...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.finish(); err != nil {
+		return nil, err
+	}
+	if resp.GetVersion().GetVersion() == "" {
+		return nil, Error.New("node %s did not report a version", target.Id)
+	}
+	return resp.Version, nil
+}
+
+// record records the outcome of a contact with target started at start.
+func (dialer *Dialer) record(operation string, target pb.Node, start time.Time) func(*error) {
+	if dialer.bus == nil {
+		return func(*error) {}
+	}
+	return func(errptr *error) {
+		dialer.bus.Publish(contactEvent(operation, eventlog.Outgoing, target.Id, target.GetAddress().GetAddress(), start, *errptr))
+	}
 }
 
-// dialNode dials the specified node.
+// contactEvent returns the event of a contact with a peer started at start.
+func contactEvent(operation, direction string, id storj.NodeID, address string, start time.Time, err error) Event {
+	event := Event{
+		Type:      EventContactSucceeded,
+		Time:      start,
+		Node:      id,
+		Address:   address,
+		Operation: operation,
+		Direction: direction,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+	if err != nil {
+		event.Type = EventContactFailed
+	}
+	return event
+}
+
+// dialContext attributes the bytes of the dials and the requests made with ctx
+// to kademlia, unless they are made on behalf of another component, and
+// annotates the dials with operation as their site.
+func dialContext(ctx context.Context, operation string) context.Context {
+	ctx = transport.WithDefaultLabel(ctx, "kademlia")
+	return transport.WithDialSite(ctx, "kademlia "+operation)
+}
+
+// dialNode dials the specified node, using a released or idle connection when
+// there is one. Background dials of backed off peers fail without dialing.
 func (dialer *Dialer) dialNode(ctx context.Context, target pb.Node) (*Conn, error) {
+	if _, ok := dialer.selfDials.Get(target.Id); ok {
+		return nil, transport.SelfDial.New("%s is at the address of this node", target.Id)
+	}
+	if dialPriorityOf(ctx) == BackgroundDial && dialer.failures.backedOff(target.Id) {
+		dialer.mon.Counter("dial_backed_off").Inc(1)
+		return nil, PeerBackedOff.New("%s", target.Id)
+	}
+
+	if grpcconn := dialer.takeHandedBack(target.Id); grpcconn != nil {
+		dialer.mon.Counter("dialer_conn_reused").Inc(1)
+		return &Conn{
+			conn:     grpcconn,
+			client:   pb.NewNodesClient(grpcconn),
+			dialer:   dialer,
+			pool:     dialer.pool,
+			key:      poolKey{id: target.Id},
+			conns:    &dialer.conns,
+			reused:   true,
+			expected: target.Id,
+		}, nil
+	}
+	if grpcconn := dialer.pool.take(poolKey{id: target.Id}); grpcconn != nil {
+		dialer.mon.Counter("dialer_conn_pooled").Inc(1)
+		return &Conn{
+			conn:     grpcconn,
+			client:   pb.NewNodesClient(grpcconn),
+			pool:     dialer.pool,
+			key:      poolKey{id: target.Id},
+			conns:    &dialer.conns,
+			reused:   true,
+			expected: target.Id,
+		}, nil
+	}
+
 	grpcconn, err := dialer.transport.DialNode(ctx, &target)
+	if transport.SelfDial.Has(err) {
+		dialer.selfDial(target)
+		return nil, err
+	}
+	if tlsopts.IsPeerIDMismatch(err) {
+		dialer.mon.Counter("dialer_node_id_mismatch").Inc(1)
+		err = ErrNodeIDMismatch.Wrap(err)
+	}
+	dialer.failures.dialed(target.Id, err)
+	if err == nil {
+		dialer.conns.opened()
+		dialer.negative.seenID(target.Id)
+		dialer.verifiedDial(target.Id)
+	}
 	return &Conn{
-		conn:   grpcconn,
-		client: pb.NewNodesClient(grpcconn),
+		conn:     grpcconn,
+		client:   pb.NewNodesClient(grpcconn),
+		pool:     dialer.pool,
+		key:      poolKey{id: target.Id},
+		conns:    &dialer.conns,
+		expected: target.Id,
 	}, err
 }
 
-// dialAddress dials the specified node by address (no node ID verification)
+// selfDial remembers that the address of target is this node, the routing
+// table removes the entry on the failure reported by the transport.
+func (dialer *Dialer) selfDial(target pb.Node) {
+	dialer.mon.Counter("self_dial").Inc(1)
+	if _, ok := dialer.selfDials.Get(target.Id); ok {
+		return
+	}
+	dialer.selfDials.Add(target.Id, struct{}{})
+	dialer.log.Warn("node address is the address of this node, it won't be dialed again",
+		zap.Stringer("Node ID", target.Id), zap.String("address", target.GetAddress().GetAddress()))
+}
+
+// dialAddress dials the specified node by address (no node ID verification),
+// using an idle connection to the address when there is one.
 func (dialer *Dialer) dialAddress(ctx context.Context, address string) (*Conn, error) {
+	key := poolKey{address: address}
+	if grpcconn := dialer.pool.take(key); grpcconn != nil {
+		dialer.mon.Counter("dialer_conn_pooled").Inc(1)
+		return &Conn{
+			conn:   grpcconn,
+			client: pb.NewNodesClient(grpcconn),
+			pool:   dialer.pool,
+			key:    key,
+			conns:  &dialer.conns,
+			reused: true,
+		}, nil
+	}
+
 	grpcconn, err := dialer.transport.DialAddress(ctx, address)
+	if err == nil {
+		dialer.conns.opened()
+	}
 	return &Conn{
 		conn:   grpcconn,
 		client: pb.NewNodesClient(grpcconn),
+		pool:   dialer.pool,
+		key:    key,
+		conns:  &dialer.conns,
 	}, err
 }
 
+// withConn dials with dial and makes rpc on the connection, which it
+// returns for the caller to finish. The call options of rpc include
+// conn.callOptions, the peer that answered is verified. A reused connection may have broken
+// while idle, such as by a restart of the peer, hence when rpc fails on it
+// as unavailable, the node is dialed again and rpc is made on the new
+// connection. The connection is closed when rpc fails.
+//
+// The errors are classified by where they happened: ErrDial, ErrRPC or
+// ErrVerify.
+func (dialer *Dialer) withConn(ctx context.Context, dial func() (*Conn, error), rpc func(conn *Conn) error) (*Conn, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, dialFailure(err)
+	}
+
+	err = rpc(conn)
+	if err != nil && conn.reused && status.Code(err) == codes.Unavailable && ctx.Err() == nil {
+		dialer.mon.Counter("dialer_reused_conn_broken").Inc(1)
+		_ = conn.disconnect()
+
+		conn, err = dial()
+		if err != nil {
+			return nil, dialFailure(err)
+		}
+		err = rpc(conn)
+	}
+	err = rpcFailure(err)
+	if err == nil {
+		err = ErrVerify.Wrap(dialer.verifyPeer(conn))
+	}
+	if err != nil {
+		return nil, errs.Combine(err, conn.disconnect())
+	}
+	return conn, nil
+}
+
+// callOptions returns opts with the option recording the peer answering an
+// RPC on conn, for verifyPeer.
+func (conn *Conn) callOptions(opts ...grpc.CallOption) []grpc.CallOption {
+	return append(opts, grpc.Peer(&conn.peer))
+}
+
+// verifyPeer returns an ErrNodeIDMismatch when the peer that answered the
+// last RPC on conn isn't the node it was meant for. The handshake of a dial
+// already rejects other peers, but the connection may have been dialed by
+// address, or the transport may not verify them.
+func (dialer *Dialer) verifyPeer(conn *Conn) error {
+	if conn.expected.IsZero() {
+		return nil
+	}
+	ident, err := identity.PeerIdentityFromPeer(&conn.peer)
+	if err != nil {
+		dialer.mon.Counter("dialer_node_id_mismatch").Inc(1)
+		return ErrNodeIDMismatch.New("expected node %s, could not identify the peer: %v", conn.expected, err)
+	}
+	if ident.ID != conn.expected {
+		dialer.mon.Counter("dialer_node_id_mismatch").Inc(1)
+		return ErrNodeIDMismatch.New("expected node %s, got %s", conn.expected, ident.ID)
+	}
+	return nil
+}
+
+// takeHandedBack returns the released connection to the node, if there is one.
+func (dialer *Dialer) takeHandedBack(id storj.NodeID) *grpc.ClientConn {
+	dialer.handedBackMu.Lock()
+	defer dialer.handedBackMu.Unlock()
+
+	conn, ok := dialer.handedBack[id]
+	if ok {
+		delete(dialer.handedBack, id)
+	}
+	return conn
+}
+
 // disconnect disconnects this connection.
 func (conn *Conn) disconnect() error {
-	return conn.conn.Close()
+	if conn.dialer != nil {
+		conn.dialer.untrack(conn.conn)
+	}
+	return conn.conns.close(conn.conn)
+}
+
+// finish ends the RPCs of the dialer on the connection: it's kept open for
+// the next RPC to the node when the dialer pools connections, and closed
+// otherwise.
+func (conn *Conn) finish() error {
+	if conn.pool == nil {
+		return conn.disconnect()
+	}
+
+	id := conn.key.id
+	if conn.dialer != nil {
+		// kept in the pool, it's closed after the idle timeout instead
+		conn.dialer.untrack(conn.conn)
+	}
+	if !conn.id.IsZero() {
+		id = conn.id
+	}
+	if conn.pool.put(conn.key, id, conn.conn) {
+		return nil
+	}
+	return conn.conns.close(conn.conn)
+}
+
+// Client returns the client for the RPCs of the node.
+func (conn *Conn) Client() pb.NodesClient { return conn.client }
+
+// Close closes the connection.
+func (conn *Conn) Close() error { return conn.disconnect() }
+
+// Release hands the connection back to the dialer, which uses it for the next
+// RPC to the node. The connection must not be used afterwards. It is closed
+// instead when the dialer already holds a connection to the node or too many
+// connections, and nothing is done when the dialer already closed it, see
+// DialerOptions.UnverifiedLifetime.
+func (conn *Conn) Release() error {
+	dialer := conn.dialer
+	if dialer == nil || conn.id.IsZero() {
+		return conn.disconnect()
+	}
+
+	dialer.handedBackMu.Lock()
+	if _, open := dialer.unverified[conn.conn]; !open {
+		dialer.handedBackMu.Unlock()
+		return nil
+	}
+	_, exists := dialer.handedBack[conn.id]
+	if exists || dialer.closed || len(dialer.handedBack) >= maxHandedBack {
+		dialer.handedBackMu.Unlock()
+		return conn.disconnect()
+	}
+	dialer.handedBack[conn.id] = conn.conn
+	dialer.handedBackMu.Unlock()
+	return nil
 }
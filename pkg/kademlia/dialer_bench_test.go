@@ -0,0 +1,140 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia_test
+
+import (
+	"flag"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+)
+
+// The dialer benchmarks are named BenchmarkDialer/<operation>/<variant>,
+// which allows comparing runs with benchstat:
+//
+//	go test -run none -bench Dialer -benchtime 10x -count 10 ./pkg/kademlia > old.txt
+//	go test -run none -bench Dialer -benchtime 10x -count 10 ./pkg/kademlia > new.txt
+//	benchstat old.txt new.txt
+//
+// Lookup targets and the global random source used by the peers are seeded
+// with a fixed seed, use -kademlia.bench-seed to change it. The lookup latency
+// percentiles are reported as the p50-ns, p90-ns, p99-ns and max-ns metrics.
+
+var benchSeed = flag.Int64("kademlia.bench-seed", 1, "seed for the random sources of the dialer benchmarks")
+
+// benchNetworks are the simulated network profiles for the lookup benchmarks.
+var benchNetworks = []struct {
+	name    string
+	network transport.SimulatedNetwork
+}{
+	{"Local", transport.SimulatedNetwork{}},
+	{"LAN", transport.SimulatedNetwork{DialLatency: time.Millisecond, Latency: 500 * time.Microsecond, BytesPerSecond: 100 * memory.MB}},
+	{"WAN", transport.SimulatedNetwork{DialLatency: 50 * time.Millisecond, Latency: 25 * time.Millisecond, BytesPerSecond: 10 * memory.MB}},
+}
+
+func BenchmarkDialer(b *testing.B) {
+	if testing.Short() {
+		b.Skip("dialer benchmarks start a testplanet")
+	}
+
+	// the peers choose random bucket refresh targets with the global source
+	rand.Seed(*benchSeed)
+
+	ctx := testcontext.New(b)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.NewWithLogger(zap.NewNop(), 1, 8, 0)
+	require.NoError(b, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	self := planet.StorageNodes[0]
+	peers := planet.StorageNodes[1:]
+
+	b.Run("PingNode/NewConn", func(b *testing.B) {
+		dialer := kademlia.NewDialer(zap.NewNop(), self.Transport)
+		defer ctx.Check(dialer.Close)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := dialer.PingNode(ctx, peers[i%len(peers)].Local().Node)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("PingNode/ReuseConn", func(b *testing.B) {
+		clients := make([]pb.NodesClient, len(peers))
+		for i, peer := range peers {
+			node := peer.Local().Node
+			conn, err := self.Transport.DialNode(ctx, &node)
+			require.NoError(b, err)
+			defer func(conn *grpc.ClientConn) { ctx.Check(conn.Close) }(conn)
+			clients[i] = pb.NewNodesClient(conn)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := clients[i%len(clients)].Ping(ctx, &pb.PingRequest{})
+			require.NoError(b, err)
+		}
+	})
+
+	for _, profile := range benchNetworks {
+		profile := profile
+		b.Run("Lookup/"+profile.name, func(b *testing.B) {
+			dialer := kademlia.NewDialer(zap.NewNop(), profile.network.NewClient(self.Transport))
+			defer ctx.Check(dialer.Close)
+
+			targets := benchTargets(b.N)
+			durations := make([]time.Duration, 0, b.N)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				_, err := dialer.Lookup(ctx, self.Local().Node, peers[i%len(peers)].Local().Node, pb.Node{Id: targets[i]})
+				durations = append(durations, time.Since(start))
+				require.NoError(b, err)
+			}
+			b.StopTimer()
+
+			sort.Slice(durations, func(i, k int) bool { return durations[i] < durations[k] })
+			b.ReportMetric(float64(percentile(durations, 0.5)), "p50-ns")
+			b.ReportMetric(float64(percentile(durations, 0.9)), "p90-ns")
+			b.ReportMetric(float64(percentile(durations, 0.99)), "p99-ns")
+			b.ReportMetric(float64(durations[len(durations)-1]), "max-ns")
+		})
+	}
+}
+
+// benchTargets returns n lookup targets chosen with the benchmark seed.
+func benchTargets(n int) []storj.NodeID {
+	rng := rand.New(rand.NewSource(*benchSeed))
+	targets := make([]storj.NodeID, n)
+	for i := range targets {
+		_, _ = rng.Read(targets[i][:])
+	}
+	return targets
+}
+
+// percentile returns the p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	return sorted[int(p*float64(len(sorted)-1))]
+}
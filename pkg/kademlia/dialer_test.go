@@ -6,19 +6,33 @@ package kademlia_test
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zeebo/errs"
 	"go.uber.org/zap/zaptest"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
 	"storj.io/storj/pkg/peertls/tlsopts"
 
 	"storj.io/storj/internal/memory"
 	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
 	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/internal/teststorj"
 	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
@@ -26,16 +40,47 @@ import (
 )
 
 func TestDialer(t *testing.T) {
+	t.Run("IPv4", func(t *testing.T) { testDialer(t, testplanet.Config{Host: "127.0.0.1"}) })
+	t.Run("IPv6", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "[::1]:0")
+		if err != nil {
+			t.Skip("IPv6 loopback is not available:", err)
+		}
+		require.NoError(t, listener.Close())
+
+		testDialer(t, testplanet.Config{Host: "::1"})
+	})
+	t.Run("MixedIdentityVersions", func(t *testing.T) {
+		versionV1, unregister := testidentity.RegisterIDVersion(1)
+		defer unregister()
+
+		testDialer(t, testplanet.Config{
+			IdentityVersions: []storj.IDVersion{storj.IDVersions[storj.V0], versionV1},
+		})
+	})
+}
+
+func testDialer(t *testing.T, config testplanet.Config) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
 
-	planet, err := testplanet.New(t, 1, 4, 3)
+	config.SatelliteCount, config.StorageNodeCount, config.UplinkCount = 1, 4, 3
+	planet, err := testplanet.NewCustom(zaptest.NewLogger(t), config)
 	require.NoError(t, err)
 	defer ctx.Check(planet.Shutdown)
 
 	planet.Start(ctx)
 
-	expectedKademliaEntries := len(planet.Satellites) + len(planet.StorageNodes)
+	if len(config.IdentityVersions) > 0 {
+		versions := make(map[storj.IDVersionNumber]bool)
+		for _, node := range planet.StorageNodes {
+			versions[node.ID().VersionNumber()] = true
+		}
+		require.Len(t, versions, len(config.IdentityVersions))
+	}
+
+	// every node but the asking one, which lookups don't return
+	expectedKademliaEntries := len(planet.Satellites) + len(planet.StorageNodes) - 1
 
 	// TODO: also use satellites
 	peers := planet.StorageNodes
@@ -46,19 +91,14 @@ func TestDialer(t *testing.T) {
 		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
 		defer ctx.Check(dialer.Close)
 
-		var group errgroup.Group
-		defer ctx.Check(group.Wait)
-
+		var nodes []pb.Node
 		for _, peer := range peers {
-			peer := peer
-			group.Go(func() error {
-				pinged, err := dialer.PingNode(ctx, peer.Local().Node)
-				var pingErr error
-				if !pinged {
-					pingErr = fmt.Errorf("ping to %s should have succeeded", peer.ID())
-				}
-				return errs.Combine(pingErr, err)
-			})
+			nodes = append(nodes, peer.Local().Node)
+		}
+		results, err := dialer.PingNodes(ctx, nodes, len(nodes))
+		require.NoError(t, err)
+		for _, result := range results {
+			assert.True(t, result.Pinged, "ping to %s should have succeeded", result.Node.Id)
 		}
 	}
 
@@ -92,9 +132,48 @@ func TestDialer(t *testing.T) {
 		})
 	}
 
+	{ // PingAddress: storage node pings the satellite by address
+		self := planet.StorageNodes[0]
+
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
+		defer ctx.Check(dialer.Close)
+
+		id, pinged, err := dialer.PingAddress(ctx, planet.Satellites[0].Addr())
+		require.NoError(t, err)
+		assert.True(t, pinged)
+		assert.Equal(t, planet.Satellites[0].ID(), id)
+
+		// nothing listens on the port of a closed listener
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		unreachable := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		id, pinged, err = dialer.PingAddress(ctx, unreachable)
+		require.Error(t, err)
+		assert.False(t, pinged)
+		assert.True(t, id.IsZero())
+		assert.True(t, kademlia.IsDialFailure(err), err)
+	}
+
+	{ // FetchInfo: storage node fetches info of the satellite
+		self := planet.StorageNodes[0]
+
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
+		defer ctx.Check(dialer.Close)
+
+		info, err := dialer.FetchInfo(ctx, planet.Satellites[0].Local().Node)
+		require.NoError(t, err)
+		assert.Equal(t, pb.NodeType_SATELLITE, info.Type)
+		require.NotNil(t, info.Version)
+		assert.Equal(t, "testplanet", info.Version.CommitHash)
+		assert.NotNil(t, info.Operator)
+		assert.NotNil(t, info.Capacity)
+	}
+
 	{ // Lookup: storage node query every node for everyone elese
 		self := planet.StorageNodes[1]
-		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport, kademlia.WithPrivateAddresses())
 		defer ctx.Check(dialer.Close)
 
 		var group errgroup.Group
@@ -130,7 +209,7 @@ func TestDialer(t *testing.T) {
 
 	{ // Lookup: storage node queries every node for missing storj.NodeID{} and storj.NodeID{255}
 		self := planet.StorageNodes[2]
-		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport, kademlia.WithPrivateAddresses())
 		defer ctx.Check(dialer.Close)
 
 		targets := []storj.NodeID{
@@ -164,6 +243,59 @@ func TestDialer(t *testing.T) {
 	}
 }
 
+func TestLookupStream(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 6, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		self, ask := planet.StorageNodes[0], planet.StorageNodes[1]
+
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport, kademlia.WithPrivateAddresses())
+		defer ctx.Check(dialer.Close)
+
+		// collect reads the whole stream
+		collect := func(limit, chunkSize int) []storj.NodeID {
+			stream, err := dialer.LookupStream(ctx, ask.Local().Node, self.Local().Node, limit, chunkSize)
+			require.NoError(t, err)
+			defer ctx.Check(stream.Close)
+
+			var ids []storj.NodeID
+			for {
+				chunk, err := stream.Next()
+				if err == io.EOF {
+					return ids
+				}
+				require.NoError(t, err)
+				require.True(t, len(chunk) <= chunkSize)
+				for _, node := range chunk {
+					ids = append(ids, node.Id)
+				}
+			}
+		}
+
+		// the unary lookup adds self to the routing table of ask, hence it goes first
+		unary, err := dialer.Lookup(ctx, self.Local().Node, ask.Local().Node, self.Local().Node)
+		require.NoError(t, err)
+
+		var expected []storj.NodeID
+		for _, node := range unary {
+			expected = append(expected, node.Id)
+		}
+		require.NotEmpty(t, expected)
+		require.Equal(t, expected, collect(20, 2))
+
+		capabilities, known := dialer.Capabilities(ask.ID())
+		require.True(t, known)
+		require.True(t, capabilities.Has(kademlia.CapabilityQueryStream))
+
+		// a non-positive limit dumps the routing table up to the stream limit
+		all, err := ask.Kademlia.RoutingTable.DumpNodes()
+		require.NoError(t, err)
+		dump := collect(0, 3)
+		require.Len(t, dump, len(all)-1)
+		require.NotContains(t, dump, ask.ID())
+	})
+}
+
 func TestSlowDialerHasTimeout(t *testing.T) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
@@ -205,6 +337,14 @@ func TestSlowDialerHasTimeout(t *testing.T) {
 				_, err := dialer.PingNode(ctx, peer.Local().Node)
 				require.Error(t, err, context.DeadlineExceeded)
 				require.True(t, transport.Error.Has(err))
+				require.True(t, kademlia.ErrDial.Has(err))
+				require.True(t, kademlia.IsDialFailure(err))
+
+				_, pinged, err := dialer.PingAddress(ctx, peer.Addr())
+				require.Error(t, err, context.DeadlineExceeded)
+				require.False(t, pinged)
+				require.True(t, transport.Error.Has(err))
+				require.True(t, kademlia.IsDialFailure(err))
 
 				return nil
 			})
@@ -237,10 +377,17 @@ func TestSlowDialerHasTimeout(t *testing.T) {
 			_, err := dialer.FetchPeerIdentity(ctx, planet.Satellites[0].Local().Node)
 			require.Error(t, err, context.DeadlineExceeded)
 			require.True(t, transport.Error.Has(err))
+			require.True(t, kademlia.ErrDial.Has(err))
 
 			_, err = dialer.FetchPeerIdentityUnverified(ctx, planet.Satellites[0].Addr())
 			require.Error(t, err, context.DeadlineExceeded)
 			require.True(t, transport.Error.Has(err))
+			require.True(t, kademlia.ErrDial.Has(err))
+
+			_, err = dialer.FetchInfo(ctx, planet.Satellites[0].Local().Node)
+			require.Error(t, err, context.DeadlineExceeded)
+			require.True(t, transport.Error.Has(err))
+			require.True(t, kademlia.ErrDial.Has(err))
 
 			return nil
 		})
@@ -279,6 +426,7 @@ func TestSlowDialerHasTimeout(t *testing.T) {
 					_, err := dialer.Lookup(ctx, self.Local().Node, peer.Local().Node, target.Local().Node)
 					require.Error(t, err, context.DeadlineExceeded, errTag)
 					require.True(t, transport.Error.Has(err), errTag)
+					require.True(t, kademlia.ErrDial.Has(err), errTag)
 
 					return nil
 				}
@@ -296,3 +444,671 @@ func containsResult(nodes []*pb.Node, target storj.NodeID) bool {
 	}
 	return false
 }
+
+// newFakeNodeDialer returns a fake node and a dialer of a test identity.
+func newFakeNodeDialer(t *testing.T, ctx *testcontext.Context) (*testkademlia.Server, *kademlia.Dialer) {
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOpts, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	return server, kademlia.NewDialer(zaptest.NewLogger(t), transport.NewClient(tlsOpts), kademlia.WithPrivateAddresses())
+}
+
+func TestDialerErrorCodes(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, dialer := newFakeNodeDialer(t, ctx)
+	defer server.Close()
+	defer ctx.Check(dialer.Close)
+
+	server.Set(testkademlia.Behavior{Capabilities: uint64(kademlia.CapabilityQueryStream)})
+	node := server.Node()
+
+	for _, test := range []struct {
+		rpc  string
+		call func() error
+	}{
+		{testkademlia.Ping, func() error {
+			_, err := dialer.PingNode(ctx, node)
+			return err
+		}},
+		{testkademlia.Query, func() error {
+			_, err := dialer.Lookup(ctx, pb.Node{}, node, node)
+			return err
+		}},
+		{testkademlia.QueryStream, func() error {
+			stream, err := dialer.LookupStream(ctx, node, node, 0, 10)
+			if err != nil {
+				return err
+			}
+			defer ctx.Check(stream.Close)
+			_, err = stream.Next()
+			return err
+		}},
+		{testkademlia.RequestInfo, func() error {
+			_, err := dialer.FetchInfo(ctx, node)
+			return err
+		}},
+	} {
+		server.Script(test.rpc,
+			testkademlia.Behavior{Code: codes.FailedPrecondition, ErrorCode: kademlia.ErrorCodeProtocolTooOld},
+			testkademlia.Behavior{Code: codes.Unavailable},
+		)
+
+		// the code of the endpoint is kept, errors of old peers are failed RPCs
+		err := test.call()
+		require.Error(t, err, test.rpc)
+		assert.Equal(t, kademlia.ErrorCodeProtocolTooOld, kademlia.ErrorCode(err), test.rpc)
+
+		err = test.call()
+		require.Error(t, err, test.rpc)
+		assert.Equal(t, codes.Unavailable, status.Code(errs.Unwrap(err)), test.rpc)
+		if test.rpc != testkademlia.QueryStream {
+			assert.True(t, kademlia.IsRPCFailure(err), test.rpc)
+			assert.Equal(t, "kad:rpc", kademlia.ErrorCode(err), test.rpc)
+		}
+
+		// the failures don't affect the following calls
+		require.NoError(t, test.call(), test.rpc)
+	}
+}
+
+func TestDialerDelayedResponse(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, dialer := newFakeNodeDialer(t, ctx)
+	defer server.Close()
+	defer ctx.Check(dialer.Close)
+
+	node := server.Node()
+	server.Set(testkademlia.Behavior{Delay: time.Minute}, testkademlia.Query)
+	server.Set(testkademlia.Behavior{Delay: 10 * time.Millisecond}, testkademlia.Ping)
+
+	{ // slow responses are waited for
+		ok, err := dialer.PingNode(ctx, node)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	{ // the deadline of the caller cancels the call on the server
+		timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := dialer.Lookup(timeout, pb.Node{}, node, node)
+		require.Error(t, err)
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(errs.Unwrap(err)))
+		assert.True(t, time.Since(start) < 5*time.Second, "lookup took %v", time.Since(start))
+
+		deadline := time.Now().Add(5 * time.Second)
+		for server.Active(testkademlia.Query) != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("query was not canceled on the server")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestLookupSanitized(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, dialer := newFakeNodeDialer(t, ctx)
+	defer server.Close()
+	defer ctx.Check(dialer.Close)
+
+	valid := testkademlia.RandomNodes(2)
+	self := testkademlia.RandomNodes(1)[0]
+	withAddress := func(id storj.NodeID, address string) *pb.Node {
+		return &pb.Node{Id: id, Address: &pb.NodeAddress{Address: address}}
+	}
+	response := []*pb.Node{
+		valid[0],
+		self,
+		withAddress(storj.NodeID{}, "10.0.0.1:28967"),
+		withAddress(storj.NodeID{1}, ""),
+		withAddress(storj.NodeID{2}, ":28967"),
+		withAddress(storj.NodeID{3}, "10.0.0.3:0"),
+		{Id: storj.NodeID{4}},
+		valid[1],
+		valid[0],
+	}
+	server.Set(testkademlia.Behavior{Nodes: response, Order: testkademlia.AsGiven}, testkademlia.Query)
+	ask := server.Node()
+
+	found, err := dialer.Lookup(ctx, *self, ask, ask)
+	require.NoError(t, err)
+	assert.Equal(t, pb.NodesToIDs(valid), pb.NodesToIDs(found))
+
+	raw, err := dialer.LookupRaw(ctx, *self, ask, ask)
+	require.NoError(t, err)
+	assert.Equal(t, pb.NodesToIDs(response), pb.NodesToIDs(raw))
+}
+
+func TestLookupClosest(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 3, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), planet.Uplinks[0].Transport, kademlia.WithPrivateAddresses())
+		defer ctx.Check(dialer.Close)
+
+		var all []pb.Node
+		var ids storj.NodeIDList
+		for _, peer := range planet.StorageNodes {
+			all = append(all, peer.Local().Node)
+			ids = append(ids, peer.ID())
+		}
+		all = append(all, planet.Satellites[0].Local().Node)
+		ids = append(ids, planet.Satellites[0].ID())
+
+		target := teststorj.NodeIDFromString("target")
+		for _, start := range all {
+			closest, err := dialer.LookupClosest(ctx, []pb.Node{start}, target, 20)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, ids, pb.NodesToIDs(closest))
+			assert.True(t, kademlia.NodesSortedByXOR(closest, target))
+		}
+
+		closest, err := dialer.LookupClosest(ctx, all[:1], target, 2)
+		require.NoError(t, err)
+		assert.Len(t, closest, 2)
+
+		// nodes that don't respond are skipped
+		unreachable := pb.Node{Id: teststorj.NodeIDFromString("unreachable"), Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}
+		closest, err = dialer.LookupClosest(ctx, []pb.Node{unreachable, all[0]}, target, 20)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ids, pb.NodesToIDs(closest))
+
+		_, err = dialer.LookupClosest(ctx, []pb.Node{unreachable}, target, 20)
+		require.Error(t, err)
+	})
+}
+
+func TestDialerHugeResponse(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, dialer := newFakeNodeDialer(t, ctx)
+	defer server.Close()
+	defer ctx.Check(dialer.Close)
+
+	node := server.Node()
+	nodes := testkademlia.RandomNodes(3)
+
+	{ // responses larger than the client accepts fail
+		server.Set(testkademlia.Behavior{Nodes: nodes, Padding: 5 * memory.MiB}, testkademlia.Query)
+		_, err := dialer.Lookup(ctx, pb.Node{}, node, node)
+		require.Error(t, err)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(errs.Unwrap(err)))
+	}
+
+	{ // unknown fields of large responses are ignored
+		server.Set(testkademlia.Behavior{Nodes: nodes, Padding: memory.MiB}, testkademlia.Query)
+		found, err := dialer.Lookup(ctx, pb.Node{}, node, node)
+		require.NoError(t, err)
+		require.Len(t, found, len(nodes))
+	}
+}
+
+func TestDialPriority(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 2, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		self, slowNode := planet.StorageNodes[0], planet.StorageNodes[1]
+
+		network := &transport.SimulatedNetwork{DialLatency: 300 * time.Millisecond}
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), &routedTransport{
+			Client: self.Transport,
+			slow:   network.NewClient(self.Transport),
+			slowID: slowNode.ID(),
+		})
+		defer ctx.Check(dialer.Close)
+
+		// saturate the dial limit with slow background dials, taking about 3 seconds
+		backgroundCtx, cancel := context.WithCancel(kademlia.WithDialPriority(ctx, kademlia.BackgroundDial))
+		defer cancel()
+
+		var group errgroup.Group
+		for i := 0; i < 320; i++ {
+			group.Go(func() error {
+				_, _ = dialer.PingNode(backgroundCtx, slowNode.Local().Node)
+				return nil
+			})
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		// an interactive dial only waits for the first background dial to finish
+		start := time.Now()
+		ok, err := dialer.PingNode(ctx, planet.Satellites[0].Local().Node)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.True(t, time.Since(start) < time.Second, "interactive ping took %v", time.Since(start))
+
+		cancel()
+		require.NoError(t, group.Wait())
+	})
+}
+
+// routedTransport dials slowID through slow.
+type routedTransport struct {
+	transport.Client
+	slow   transport.Client
+	slowID storj.NodeID
+}
+
+func (client *routedTransport) DialNode(ctx context.Context, node *pb.Node, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if node.Id == client.slowID {
+		return client.slow.DialNode(ctx, node, opts...)
+	}
+	return client.Client.DialNode(ctx, node, opts...)
+}
+
+func TestPingNodes(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		self, slowNode := planet.StorageNodes[0], planet.StorageNodes[1]
+
+		latency := time.Second
+		network := &transport.SimulatedNetwork{DialLatency: latency}
+		dials := &activeDials{Client: &routedTransport{
+			Client: self.Transport,
+			slow:   network.NewClient(self.Transport),
+			slowID: slowNode.ID(),
+		}}
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), dials)
+		defer ctx.Check(dialer.Close)
+
+		nodes := []pb.Node{slowNode.Local().Node, planet.Satellites[0].Local().Node}
+		for _, peer := range planet.StorageNodes[2:] {
+			nodes = append(nodes, peer.Local().Node)
+		}
+
+		{ // the slow node doesn't block the others
+			results, err := dialer.PingNodes(ctx, nodes, 2)
+			require.NoError(t, err)
+			require.Len(t, results, len(nodes))
+			for i, result := range results {
+				assert.Equal(t, nodes[i].Id, result.Node.Id)
+				assert.True(t, result.Pinged)
+				if i == 0 {
+					assert.True(t, result.RTT >= latency, "slow ping took %v", result.RTT)
+				} else {
+					assert.True(t, result.RTT < latency, "ping took %v", result.RTT)
+				}
+			}
+			assert.True(t, dials.max() <= 2, "%d dials at the same time", dials.max())
+		}
+
+		{ // canceling returns the partial results
+			pingCtx, cancel := context.WithTimeout(ctx, latency/2)
+			defer cancel()
+
+			results, err := dialer.PingNodes(pingCtx, nodes, 2)
+			require.Error(t, err)
+			require.Len(t, results, len(nodes))
+			assert.False(t, results[0].Pinged)
+			assert.Error(t, results[0].Err)
+			for _, result := range results[1:] {
+				assert.True(t, result.Pinged)
+				assert.NoError(t, result.Err)
+			}
+		}
+	})
+}
+
+// activeDials tracks the most dials made at the same time.
+type activeDials struct {
+	transport.Client
+	active, peak int32
+}
+
+func (client *activeDials) max() int32 { return atomic.LoadInt32(&client.peak) }
+
+func (client *activeDials) DialNode(ctx context.Context, node *pb.Node, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	active := atomic.AddInt32(&client.active, 1)
+	defer atomic.AddInt32(&client.active, -1)
+	for {
+		peak := atomic.LoadInt32(&client.peak)
+		if active <= peak || atomic.CompareAndSwapInt32(&client.peak, peak, active) {
+			break
+		}
+	}
+	return client.Client.DialNode(ctx, node, opts...)
+}
+
+func TestDialLimit(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		self, target := planet.StorageNodes[0], planet.Satellites[0].Local().Node
+
+		network := &transport.SimulatedNetwork{DialLatency: 20 * time.Millisecond}
+		dials := &activeDials{Client: network.NewClient(self.Transport)}
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), dials, kademlia.WithDialLimit(4))
+		defer ctx.Check(dialer.Close)
+
+		var group errgroup.Group
+		for i := 0; i < 100; i++ {
+			group.Go(func() error {
+				_, err := dialer.PingNode(ctx, target)
+				return err
+			})
+		}
+		require.NoError(t, group.Wait())
+		assert.Equal(t, int32(4), dials.max())
+
+		{ // waiting for a slot ends with the context
+			blocking := &blockingTransport{
+				Client:  self.Transport,
+				release: make(chan struct{}),
+				dialing: make(chan struct{}, 1),
+			}
+			blocked := kademlia.NewDialer(zaptest.NewLogger(t), blocking, kademlia.WithDialLimit(1))
+			defer ctx.Check(blocked.Close)
+
+			var pinging errgroup.Group
+			pinging.Go(func() error {
+				_, err := blocked.PingNode(ctx, target)
+				return err
+			})
+			<-blocking.dialing
+
+			waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			_, err := blocked.PingNode(waitCtx, target)
+			require.Error(t, err)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&blocking.dials))
+			close(blocking.release)
+			require.NoError(t, pinging.Wait())
+		}
+	})
+}
+
+// blockingTransport signals dialing and dials once release is closed.
+type blockingTransport struct {
+	transport.Client
+	release chan struct{}
+	dialing chan struct{}
+	dials   int32
+}
+
+func (client *blockingTransport) DialNode(ctx context.Context, node *pb.Node, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	atomic.AddInt32(&client.dials, 1)
+	client.dialing <- struct{}{}
+	<-client.release
+	return client.Client.DialNode(ctx, node, opts...)
+}
+
+func TestDialerNodeIDMismatch(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 2, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		self, node, other := planet.Satellites[0], planet.StorageNodes[0], planet.StorageNodes[1]
+		// node answers at the address, but other is asked for
+		impostor := pb.Node{Id: other.ID(), Address: node.Local().Address}
+
+		{ // the handshake rejects the peer
+			dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
+			defer ctx.Check(dialer.Close)
+
+			_, err := dialer.PingNode(ctx, impostor)
+			require.Error(t, err)
+			assert.True(t, kademlia.ErrNodeIDMismatch.Has(err))
+			assert.Contains(t, err.Error(), other.ID().String())
+			assert.Contains(t, err.Error(), node.ID().String())
+			assert.Equal(t, kademlia.ErrorCodeIdentityMismatch, kademlia.ErrorCode(err))
+		}
+
+		{ // so does the dialer when the transport doesn't
+			unverified := &unverifiedTransport{Client: self.Transport}
+			dialer := kademlia.NewDialer(zaptest.NewLogger(t), unverified)
+			defer ctx.Check(dialer.Close)
+
+			_, err := dialer.PingNode(ctx, impostor)
+			require.Error(t, err)
+			assert.True(t, kademlia.ErrNodeIDMismatch.Has(err))
+			assert.Contains(t, err.Error(), other.ID().String())
+			assert.Contains(t, err.Error(), node.ID().String())
+
+			_, err = dialer.FetchInfo(ctx, impostor)
+			assert.True(t, kademlia.ErrNodeIDMismatch.Has(err))
+
+			_, err = dialer.Lookup(ctx, self.Local().Node, impostor, pb.Node{Id: self.ID()})
+			assert.True(t, kademlia.ErrNodeIDMismatch.Has(err))
+
+			// the connections to the impostor were closed
+			conns := unverified.dialed()
+			require.Len(t, conns, 3)
+			for _, conn := range conns {
+				assert.Equal(t, connectivity.Shutdown, conn.GetState())
+			}
+		}
+
+		{ // and streams, once the capabilities of the node are known
+			dialer := kademlia.NewDialer(zaptest.NewLogger(t), &unverifiedTransport{Client: self.Transport})
+			defer ctx.Check(dialer.Close)
+
+			_, err := dialer.PingNode(ctx, other.Local().Node)
+			require.NoError(t, err)
+
+			_, err = dialer.LookupStream(ctx, impostor, pb.Node{Id: self.ID()}, 0, 10)
+			require.Error(t, err)
+			assert.True(t, kademlia.ErrNodeIDMismatch.Has(err))
+			assert.Contains(t, err.Error(), node.ID().String())
+		}
+	})
+}
+
+// unverifiedTransport dials nodes by address, without verifying their ID.
+type unverifiedTransport struct {
+	transport.Client
+
+	mu    sync.Mutex
+	conns []*grpc.ClientConn
+}
+
+func (client *unverifiedTransport) DialNode(ctx context.Context, node *pb.Node, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, err := client.Client.DialAddress(ctx, node.Address.Address, opts...)
+	if err == nil {
+		client.mu.Lock()
+		client.conns = append(client.conns, conn)
+		client.mu.Unlock()
+	}
+	return conn, err
+}
+
+// dialed returns the connections dialed so far.
+func (client *unverifiedTransport) dialed() []*grpc.ClientConn {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return append([]*grpc.ClientConn(nil), client.conns...)
+}
+
+func TestDialerMetrics(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 2, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		self, node, other := planet.Satellites[0], planet.StorageNodes[0], planet.StorageNodes[1]
+
+		scope := monkit.NewRegistry().ScopeNamed("dialer")
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), self.Transport)
+		defer ctx.Check(dialer.Close)
+		dialer.SetMonitorScope(scope)
+
+		// stat returns the value of the stat of scope with name in its key
+		stat := func(name string) float64 {
+			for key, val := range monkit.Collect(scope) {
+				if strings.Contains(key, name) {
+					return val
+				}
+			}
+			t.Fatalf("no stat %q", name)
+			return 0
+		}
+
+		_, err := dialer.PingNode(ctx, node.Local().Node)
+		require.NoError(t, err)
+		_, err = dialer.Lookup(ctx, self.Local().Node, node.Local().Node, other.Local().Node)
+		require.NoError(t, err)
+		_, err = dialer.FetchPeerIdentity(ctx, other.Local().Node)
+		require.NoError(t, err)
+
+		_, conn, err := dialer.FetchPeerIdentityUnverifiedConn(ctx, other.Addr())
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, stat("open_conns"))
+		require.NoError(t, conn.Close())
+		assert.EqualValues(t, 0, stat("open_conns"))
+
+		// another node answers
+		_, err = dialer.PingNode(ctx, pb.Node{Id: other.ID(), Address: node.Local().Address})
+		require.Error(t, err)
+
+		// nothing listens at the address
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		closed := listener.Addr().String()
+		require.NoError(t, listener.Close())
+		_, err = dialer.PingNode(ctx, pb.Node{Id: node.ID(), Address: &pb.NodeAddress{Address: closed}})
+		require.Error(t, err)
+
+		for name, count := range map[string]int64{
+			"rpc_ping_success":                      1,
+			"rpc_ping_identity_mismatch":            1,
+			"rpc_ping_dial_failure":                 1,
+			"rpc_lookup_success":                    1,
+			"rpc_fetch_identity_success":            1,
+			"rpc_fetch_identity_unverified_success": 1,
+		} {
+			assert.Equal(t, count, scope.Counter(name).Current(), name)
+		}
+		assert.True(t, stat("rpc_lookup_duration") > 0)
+	})
+}
+
+func TestDialerStats(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, dialer := newFakeNodeDialer(t, ctx)
+	defer server.Close()
+	defer ctx.Check(dialer.Close)
+
+	const pings = 8
+	stats := dialer.Stats()
+	assert.Zero(t, stats.Issued)
+	assert.Zero(t, stats.InFlight[kademlia.RPCPing])
+
+	{ // pings are in flight until they end
+		server.Set(testkademlia.Behavior{Delay: time.Hour}, testkademlia.Ping)
+		pingCtx, cancel := context.WithCancel(ctx)
+		var group errgroup.Group
+		for i := 0; i < pings; i++ {
+			group.Go(func() error {
+				_, err := dialer.PingNode(pingCtx, server.Node())
+				return err
+			})
+		}
+		for server.Active(testkademlia.Ping) < pings {
+			time.Sleep(time.Millisecond)
+		}
+
+		stats = dialer.Stats()
+		assert.EqualValues(t, pings, stats.Issued)
+		assert.EqualValues(t, pings, stats.InFlight[kademlia.RPCPing])
+		assert.Zero(t, stats.InFlight[kademlia.RPCLookup])
+		assert.EqualValues(t, pings, stats.OpenConns)
+
+		cancel()
+		require.Error(t, group.Wait())
+		stats = dialer.Stats()
+		assert.EqualValues(t, pings, stats.Failed)
+		assert.Zero(t, stats.InFlight[kademlia.RPCPing])
+	}
+
+	{ // pings that succeed
+		server.Set(testkademlia.Behavior{}, testkademlia.Ping)
+		var group errgroup.Group
+		for i := 0; i < pings; i++ {
+			group.Go(func() error {
+				_, err := dialer.PingNode(ctx, server.Node())
+				return err
+			})
+		}
+		require.NoError(t, group.Wait())
+
+		stats = dialer.Stats()
+		assert.EqualValues(t, 2*pings, stats.Issued)
+		assert.EqualValues(t, pings, stats.Failed)
+		for rpc, inFlight := range stats.InFlight {
+			assert.Zero(t, inFlight, rpc)
+		}
+		assert.Zero(t, stats.OpenConns)
+	}
+}
+
+func TestAlivenessCheck(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		self, node := planet.Satellites[0], planet.StorageNodes[0]
+
+		dialLatency := 500 * time.Millisecond
+		network := &transport.SimulatedNetwork{
+			DialLatency:    dialLatency,
+			BytesPerSecond: 64 * memory.KB,
+		}
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), network.NewClient(self.Transport))
+		defer ctx.Check(dialer.Close)
+
+		{ // the round trip time of the ping doesn't include dialing
+			start := time.Now()
+			rtt, err := dialer.AlivenessCheck(ctx, node.Local().Node)
+			require.NoError(t, err)
+			assert.True(t, time.Since(start) >= dialLatency, "check took %v", time.Since(start))
+			assert.True(t, rtt > 0 && rtt < dialLatency, "rtt %v", rtt)
+		}
+
+		{ // a dial timing out fails the dial
+			timeoutCtx, cancel := context.WithTimeout(ctx, dialLatency/5)
+			defer cancel()
+
+			_, err := dialer.AlivenessCheck(timeoutCtx, node.Local().Node)
+			require.Error(t, err)
+			assert.True(t, kademlia.IsDialFailure(err), err)
+			assert.True(t, transport.Error.Has(err), err)
+			assert.False(t, kademlia.IsRPCFailure(err), err)
+		}
+
+		{ // another node answering fails the verification, not the ping
+			impostor := pb.Node{Id: teststorj.NodeIDFromString("impostor"), Address: node.Local().Address}
+
+			_, err := dialer.AlivenessCheck(ctx, impostor)
+			require.Error(t, err)
+			assert.True(t, kademlia.IsVerifyFailure(err), err)
+			assert.False(t, kademlia.IsDialFailure(err), err)
+			assert.False(t, kademlia.IsRPCFailure(err), err)
+			assert.Equal(t, kademlia.ErrorCodeIdentityMismatch, kademlia.ErrorCode(err))
+		}
+
+		{ // a connected node not answering fails the ping
+			server, err := testkademlia.Start(ctx)
+			require.NoError(t, err)
+			defer server.Close()
+			server.Set(testkademlia.Behavior{Code: codes.Internal}, testkademlia.Ping)
+
+			_, err = dialer.AlivenessCheck(ctx, server.Node())
+			require.Error(t, err)
+			assert.True(t, kademlia.IsRPCFailure(err), err)
+			assert.False(t, kademlia.IsDialFailure(err), err)
+			assert.Equal(t, codes.Internal, status.Code(errs.Unwrap(err)))
+		}
+	})
+}
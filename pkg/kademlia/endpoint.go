@@ -6,24 +6,55 @@ package kademlia
 import (
 	"context"
 	"sync/atomic"
+	"time"
 
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	grpcpeer "google.golang.org/grpc/peer"
 
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/eventlog"
+	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
 )
 
 // EndpointError defines errors class for Endpoint
 var EndpointError = errs.Class("kademlia endpoint error")
 
+const (
+	// defaultChunkSize is the number of nodes in a QueryStream response when the client doesn't specify it
+	defaultChunkSize = 100
+	// maxChunkSize is the largest number of nodes sent in a single QueryStream response
+	maxChunkSize = 1000
+)
+
 // Endpoint implements the kademlia Endpoints
 type Endpoint struct {
 	log          *zap.Logger
 	service      *Kademlia
 	routingTable *RoutingTable
+	hinter       ContactHinter
+	recorder     CheckInRecorder
+	metadata     NodeMetadata
 	connected    int32
 }
 
+// ContactHinter suggests how the nodes checking in should adapt.
+type ContactHinter interface {
+	// ContactHints returns the hints for node, reachable is whether the
+	// endpoint reached it at its address.
+	ContactHints(ctx context.Context, node pb.Node, reachable bool) *pb.ContactHints
+}
+
+// CheckInRecorder records the nodes checking in.
+type CheckInRecorder interface {
+	// RecordCheckIn records that node checked in, reachable is whether the
+	// endpoint reached it at its address.
+	RecordCheckIn(ctx context.Context, node pb.Node, reachable bool)
+}
+
 // NewEndpoint returns a new kademlia endpoint
 func NewEndpoint(log *zap.Logger, service *Kademlia, routingTable *RoutingTable) *Endpoint {
 	return &Endpoint{
@@ -33,24 +64,255 @@ func NewEndpoint(log *zap.Logger, service *Kademlia, routingTable *RoutingTable)
 	}
 }
 
+// SetContactHinter makes the endpoint respond to the nodes checking in with
+// the hints of hinter. It must be called before the endpoint serves.
+func (endpoint *Endpoint) SetContactHinter(hinter ContactHinter) {
+	endpoint.hinter = hinter
+}
+
+// SetCheckInRecorder makes the endpoint tell recorder about the nodes
+// checking in. It must be called before the endpoint serves.
+func (endpoint *Endpoint) SetCheckInRecorder(recorder CheckInRecorder) {
+	endpoint.recorder = recorder
+}
+
+// SetNodeMetadata makes the endpoint honor the filters of queries, by the
+// metadata of the nodes it knows. It must be called before the endpoint serves.
+func (endpoint *Endpoint) SetNodeMetadata(metadata NodeMetadata) {
+	endpoint.metadata = metadata
+}
+
+// capabilities returns the capabilities the endpoint responds with.
+func (endpoint *Endpoint) capabilities() Capability {
+	if endpoint.metadata != nil {
+		return Capabilities | CapabilityQueryFilter
+	}
+	return Capabilities
+}
+
+// closest returns the nodes closest to target, up to limit, that the filter
+// of req allows when the endpoint can honor it.
+func (endpoint *Endpoint) closest(ctx context.Context, req *pb.QueryRequest, limit int) ([]*pb.Node, error) {
+	filter := lookupFilterFromProto(req.Filter)
+	if filter.Empty() || endpoint.metadata == nil {
+		return endpoint.service.queries.closest(req.Target.Id, limit)
+	}
+
+	endpoint.service.mon.Counter("query_filtered").Inc(1)
+	nodes, err := endpoint.service.queries.closest(req.Target.Id, limit*filterOverfetch)
+	if err != nil {
+		return nil, err
+	}
+	return filterNodes(ctx, endpoint.metadata, filter, nodes, limit), nil
+}
+
 // Query is a node to node communication query
-func (endpoint *Endpoint) Query(ctx context.Context, req *pb.QueryRequest) (*pb.QueryResponse, error) {
-	endpoint.service.Queried()
+func (endpoint *Endpoint) Query(ctx context.Context, req *pb.QueryRequest) (_ *pb.QueryResponse, err error) {
+	defer withErrorCode(&err)
+	defer endpoint.record(ctx, "lookup", time.Now())(&err)
+
+	if err := validateQuery(req); err != nil {
+		return nil, err
+	}
+	peer, err := endpoint.verifySender(ctx, req.Sender)
+	if err != nil {
+		return nil, err
+	}
 
-	if req.GetPingback() {
-		endpoint.pingback(ctx, req.Sender)
+	warmingUp, release, err := endpoint.service.warmup.acquireQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if peer != nil {
+		if endpoint.service.dialer.admit(peer.ID) {
+			endpoint.service.dialer.setCapabilities(peer.ID, Capability(req.Capabilities))
+			endpoint.service.dialer.setProtocol(peer.ID, version.PeerProtocol(ctx))
+		}
+		endpoint.service.negative.seenID(peer.ID)
 	}
+	advertiseProtocol(ctx)
 
-	nodes, err := endpoint.routingTable.FindNear(req.Target.Id, int(req.Limit))
+	reachable := false
+	switch {
+	case !req.GetPingback():
+	case !announceable(*req.Sender):
+		// the sender isn't ready, it would be a useless routing table entry
+		endpoint.service.mon.Counter("query_sender_without_address").Inc(1)
+	default:
+		reachable = endpoint.pingback(ctx, req.Sender)
+	}
+
+	if warmingUp {
+		endpoint.service.mon.Counter("query_during_warmup").Inc(1)
+	}
+
+	// clients rely on the closest nodes coming first
+	nodes, err := endpoint.closest(ctx, req, int(req.Limit))
 	if err != nil {
 		return &pb.QueryResponse{}, EndpointError.New("could not find near endpoint: %v", err)
 	}
+	nodes = endpoint.withoutUnannounced(nodes)
+
+	// a node checking in looks itself up, see Kademlia.CheckIn
+	var hints *pb.ContactHints
+	if req.GetPingback() && req.Sender.Id == req.Target.Id {
+		if endpoint.recorder != nil {
+			endpoint.recorder.RecordCheckIn(ctx, *req.Sender, reachable)
+		}
+		if endpoint.hinter != nil {
+			hints = endpoint.hinter.ContactHints(ctx, *req.Sender, reachable)
+		}
+	}
+
+	return &pb.QueryResponse{
+		Sender:       req.Sender,
+		Response:     nodes,
+		Capabilities: uint64(endpoint.capabilities()),
+		WarmingUp:    warmingUp,
+		Time:         endpoint.service.dialer.timestamp(),
+		Hints:        hints,
+	}, nil
+}
+
+// QueryStream sends the nodes nearest to the target in chunks.
+//
+// Unlike Query, a non-positive limit returns as many nodes as the configured
+// stream limit allows, which is also the most any request gets.
+func (endpoint *Endpoint) QueryStream(req *pb.QueryRequest, stream pb.Nodes_QueryStreamServer) (err error) {
+	ctx := stream.Context()
+	defer withErrorCode(&err)
+	defer endpoint.record(ctx, "lookup-stream", time.Now())(&err)
+
+	if err := endpoint.checkProtocol(ctx, "QueryStream"); err != nil {
+		return err
+	}
+	if err := validateQuery(req); err != nil {
+		return err
+	}
+	if _, err := endpoint.verifySender(ctx, req.Sender); err != nil {
+		return err
+	}
+
+	warmingUp, release, err := endpoint.service.warmup.acquireQuery(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if warmingUp {
+		endpoint.service.mon.Counter("query_during_warmup").Inc(1)
+	}
+
+	nodes, err := endpoint.closest(ctx, req, endpoint.streamLimit(req.Limit))
+	if err != nil {
+		return EndpointError.New("could not find near endpoint: %v", err)
+	}
+	nodes = endpoint.withoutUnannounced(nodes)
+
+	chunkSize := int(req.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	if chunkSize > maxChunkSize {
+		chunkSize = maxChunkSize
+	}
+
+	for len(nodes) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		chunk := nodes
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		nodes = nodes[len(chunk):]
+
+		err := stream.Send(&pb.QueryResponse{
+			Sender:       req.Sender,
+			Response:     chunk,
+			Capabilities: uint64(endpoint.capabilities()),
+			WarmingUp:    warmingUp,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// record publishes the outcome of a request from a peer started at start.
+func (endpoint *Endpoint) record(ctx context.Context, operation string, start time.Time) func(*error) {
+	bus := endpoint.service.bus
+	if bus == nil {
+		return func(*error) {}
+	}
+	return func(errptr *error) {
+		var id storj.NodeID
+		if peer, err := identity.PeerIdentityFromContext(ctx); err == nil {
+			id = peer.ID
+		}
+		var address string
+		if peer, ok := grpcpeer.FromContext(ctx); ok {
+			address = peer.Addr.String()
+		}
+		bus.Publish(contactEvent(operation, eventlog.Incoming, id, address, start, *errptr))
+	}
+}
+
+// validateQuery rejects queries that can't be answered.
+func validateQuery(req *pb.QueryRequest) error {
+	if req.Target == nil {
+		return statusError(codes.InvalidArgument, ErrorCodeInvalidQuery, "missing target")
+	}
+	if req.Pingback && req.Sender == nil {
+		return statusError(codes.InvalidArgument, ErrorCodeInvalidQuery, "pingback requires a sender")
+	}
+	return nil
+}
+
+// verifySender checks that the claimed sender ID matches the TLS peer identity
+// and returns that identity, or nil when there is no sender.
+//
+// The claimed address is trusted, since the node may be behind NAT.
+func (endpoint *Endpoint) verifySender(ctx context.Context, sender *pb.Node) (*identity.PeerIdentity, error) {
+	if sender == nil {
+		return nil, nil
+	}
 
-	return &pb.QueryResponse{Sender: req.Sender, Response: nodes}, nil
+	peer, err := identity.PeerIdentityFromContext(ctx)
+	if err != nil {
+		return nil, statusError(codes.Unauthenticated, ErrorCodeUnauthenticated, err.Error())
+	}
+
+	if sender.Id != peer.ID {
+		// any peer can cause this, hence it's counted instead of logged loudly
+		endpoint.service.mon.Counter("query_sender_mismatch").Inc(1)
+		endpoint.log.Debug("query sender does not match peer identity",
+			zap.Stringer("claimed", sender.Id), zap.Stringer("peer", peer.ID))
+		return nil, statusError(codes.InvalidArgument, ErrorCodeSenderMismatch, "sender does not match peer identity")
+	}
+	return peer, nil
 }
 
-// pingback implements pingback for queries
-func (endpoint *Endpoint) pingback(ctx context.Context, target *pb.Node) {
+// streamLimit returns the number of nodes sent for a streaming query with
+// the requested limit, clamped to the configured stream limit or K when none
+// is configured, so that unauthenticated peers can't request arbitrarily much.
+func (endpoint *Endpoint) streamLimit(requested int64) int {
+	max := endpoint.service.settings().streamLimit
+	if max <= 0 {
+		max = endpoint.routingTable.K()
+	}
+	if requested <= 0 || requested > int64(max) {
+		return max
+	}
+	return int(requested)
+}
+
+// pingback implements pingback for queries, returning whether target was reached.
+func (endpoint *Endpoint) pingback(ctx context.Context, target *pb.Node) bool {
 	_, err := endpoint.service.Ping(ctx, *target)
 	if err != nil {
 		endpoint.log.Debug("connection to node failed", zap.Error(err), zap.String("nodeID", target.Id.String()))
@@ -58,29 +320,70 @@ func (endpoint *Endpoint) pingback(ctx context.Context, target *pb.Node) {
 		if err != nil {
 			endpoint.log.Error("could not respond to connection failed", zap.Error(err))
 		}
+		return false
+	}
+
+	err = endpoint.routingTable.ConnectionSuccess(target)
+	if err != nil {
+		endpoint.log.Error("could not respond to connection success", zap.Error(err))
 	} else {
-		err = endpoint.routingTable.ConnectionSuccess(target)
-		if err != nil {
-			endpoint.log.Error("could not respond to connection success", zap.Error(err))
-		} else {
-			count := atomic.AddInt32(&endpoint.connected, 1)
-			if count == 1 {
-				endpoint.log.Sugar().Debugf("Successfully connected with %s", target.Address.Address)
-			} else if count%100 == 0 {
-				endpoint.log.Sugar().Debugf("Successfully connected with %s %dx times", target.Address.Address, count)
-			}
+		count := atomic.AddInt32(&endpoint.connected, 1)
+		if count == 1 {
+			endpoint.log.Sugar().Debugf("Successfully connected with %s", target.Address.Address)
+		} else if count%100 == 0 {
+			endpoint.log.Sugar().Debugf("Successfully connected with %s %dx times", target.Address.Address, count)
 		}
 	}
+	return true
 }
 
 // Ping provides an easy way to verify a node is online and accepting requests
-func (endpoint *Endpoint) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
-	endpoint.service.Pinged()
-	return &pb.PingResponse{}, nil
+func (endpoint *Endpoint) Ping(ctx context.Context, req *pb.PingRequest) (_ *pb.PingResponse, err error) {
+	defer withErrorCode(&err)
+	defer endpoint.record(ctx, "ping", time.Now())(&err)
+	observed := endpoint.service.proxies.observedAddress(ctx)
+	if peer, err := identity.PeerIdentityFromContext(ctx); err == nil {
+		if endpoint.service.dialer.admit(peer.ID) {
+			endpoint.service.dialer.setProtocol(peer.ID, version.PeerProtocol(ctx))
+		}
+		endpoint.service.negative.seenID(peer.ID)
+		endpoint.checkAdvertised(peer.ID, observed)
+	}
+	advertiseProtocol(ctx)
+	return &pb.PingResponse{
+		Capabilities:    uint64(endpoint.capabilities()),
+		ObservedAddress: observed,
+		Time:            endpoint.service.dialer.timestamp(),
+	}, nil
+}
+
+// Leave removes the sender from the routing table, since it announced that
+// it's shutting down. Only a node can announce that it leaves itself.
+func (endpoint *Endpoint) Leave(ctx context.Context, req *pb.LeaveRequest) (_ *pb.LeaveResponse, err error) {
+	defer withErrorCode(&err)
+	defer endpoint.record(ctx, "leave", time.Now())(&err)
+
+	peer, err := identity.PeerIdentityFromContext(ctx)
+	if err != nil {
+		return nil, statusError(codes.Unauthenticated, ErrorCodeUnauthenticated, err.Error())
+	}
+	if req.NodeId != peer.ID {
+		endpoint.service.mon.Counter("leave_sender_mismatch").Inc(1)
+		endpoint.log.Debug("leaving node does not match peer identity",
+			zap.Stringer("claimed", req.NodeId), zap.Stringer("peer", peer.ID))
+		return nil, statusError(codes.InvalidArgument, ErrorCodeSenderMismatch, "leaving node does not match peer identity")
+	}
+
+	if err := endpoint.service.nodeLeft(peer.ID); err != nil {
+		return nil, EndpointError.Wrap(err)
+	}
+	return &pb.LeaveResponse{}, nil
 }
 
 // RequestInfo returns the node info
-func (endpoint *Endpoint) RequestInfo(ctx context.Context, req *pb.InfoRequest) (*pb.InfoResponse, error) {
+func (endpoint *Endpoint) RequestInfo(ctx context.Context, req *pb.InfoRequest) (_ *pb.InfoResponse, err error) {
+	defer withErrorCode(&err)
+	defer endpoint.record(ctx, "fetch-info", time.Now())(&err)
 	self := endpoint.service.Local()
 
 	return &pb.InfoResponse{
@@ -0,0 +1,160 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/zeebo/errs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/pkg/transport"
+)
+
+// Codes of the errors that aren't typed, see ErrorCode.
+const (
+	ErrorCodeUnknown          = "unknown"
+	ErrorCodeCanceled         = "transport:canceled"
+	ErrorCodeTimeout          = "transport:timeout"
+	ErrorCodeRefused          = "transport:refused"
+	ErrorCodeIdentityMismatch = "kad:identity_mismatch"
+	ErrorCodeInvalidQuery     = "kad:invalid_query"
+	ErrorCodeUnauthenticated  = "kad:unauthenticated"
+	ErrorCodeSenderMismatch   = "kad:sender_mismatch"
+	ErrorCodeProtocolTooOld   = "kad:protocol_too_old"
+)
+
+// errorCode is a stable code and the errors it is the code of.
+type errorCode struct {
+	code string
+	// class is the typed error with the code
+	class *errs.Class
+	// is matches the errors with the code that aren't typed, errors without
+	// class and is only come from the status details of server errors
+	is func(err error) bool
+}
+
+// errorCodes lists the codes of every typed error of the kademlia and
+// transport packages. The first matching entry is the code of an error, so
+// specific errors come before the generic ones of their package.
+//
+// The table is append-only: a released code is never changed or reused.
+var errorCodes = []errorCode{
+	{code: "transport:self_dial", class: &transport.SelfDial},
+	{code: "transport:policy_denied", class: &transport.PolicyDenied},
+	{code: "kad:backed_off", class: &PeerBackedOff},
+	{code: ErrorCodeIdentityMismatch, class: &ErrNodeIDMismatch, is: transport.IsVerificationError},
+	{code: ErrorCodeCanceled, is: dialOutcome(errs2.DialCanceled)},
+	{code: ErrorCodeTimeout, is: dialOutcome(errs2.DialTimeout)},
+	{code: ErrorCodeRefused, is: dialOutcome(errs2.DialRefused)},
+	{code: "kad:node_not_found", class: &NodeNotFound},
+	{code: "kad:immutable_config", class: &ErrImmutableConfig},
+	{code: "kad:snapshot_rotated", class: &ErrSnapshotRotated},
+	{code: "kad:max_retries", class: &ErrMaxRetries},
+	{code: "kad:not_ready", class: &ErrNotReady},
+	{code: "kad:compact_snapshot", class: &ErrCompactSnapshot},
+	{code: "kad:invalid_address", class: &ErrInvalidAddress},
+	{code: "kad:bootstrap", class: &BootstrapErr},
+	{code: "kad:node", class: &NodeErr},
+	{code: "kad:routing_table", class: &RoutingErr},
+	{code: ErrorCodeInvalidQuery},
+	{code: ErrorCodeUnauthenticated},
+	{code: ErrorCodeSenderMismatch},
+	{code: ErrorCodeProtocolTooOld},
+	{code: "kad:endpoint", class: &EndpointError},
+	{code: "kad:dial", class: &ErrDial},
+	{code: "kad:rpc", class: &ErrRPC},
+	{code: "kad:verify", class: &ErrVerify},
+	{code: "transport:error", class: &transport.Error},
+	{code: "kad:error", class: &Error},
+}
+
+// isIdentityMismatch matches the errors of peers that aren't the node they
+// were dialed as, or failed verification otherwise.
+func isIdentityMismatch(err error) bool {
+	return ErrNodeIDMismatch.Has(err) || transport.IsVerificationError(err)
+}
+
+// dialOutcome matches the errors of dials with outcome.
+func dialOutcome(outcome errs2.DialOutcome) func(error) bool {
+	return func(err error) bool { return errs2.ClassifyDial(err) == outcome }
+}
+
+// ErrorCode returns the stable, machine-readable code of err, such as
+// "transport:timeout", for automation that would otherwise parse error
+// messages. Errors returned by the kademlia endpoint carry their code in
+// the status details. It is empty for nil and ErrorCodeUnknown for errors
+// without a code.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if code, ok := statusErrorCode(err); ok {
+		return code
+	}
+	for _, entry := range errorCodes {
+		if entry.class != nil && entry.class.Has(err) {
+			return entry.code
+		}
+		if entry.is != nil && entry.is(err) {
+			return entry.code
+		}
+	}
+	return ErrorCodeUnknown
+}
+
+// statusErrorCode returns the code in the details of a status error, which
+// may be wrapped.
+func statusErrorCode(err error) (string, bool) {
+	for _, err := range []error{err, errs.Unwrap(err)} {
+		st, ok := status.FromError(err)
+		if !ok {
+			continue
+		}
+		for _, detail := range st.Details() {
+			if code, ok := detail.(*wrappers.StringValue); ok && knownErrorCode(code.Value) {
+				return code.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// knownErrorCode returns whether code is in errorCodes.
+func knownErrorCode(code string) bool {
+	for _, entry := range errorCodes {
+		if entry.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError returns a status error with the code of the error in its details.
+func statusError(grpcCode codes.Code, code, message string) error {
+	st, err := status.New(grpcCode, message).WithDetails(&wrappers.StringValue{Value: code})
+	if err != nil {
+		return status.Error(grpcCode, message)
+	}
+	return st.Err()
+}
+
+// withErrorCode adds the code of the error returned by an endpoint to its
+// status details.
+func withErrorCode(errptr *error) {
+	err := *errptr
+	if err == nil {
+		return
+	}
+	if _, ok := statusErrorCode(err); ok {
+		return
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		// the status grpc responds with to other errors
+		st = status.New(codes.Unknown, err.Error())
+	}
+	*errptr = statusError(st.Code(), ErrorCode(err), st.Message())
+}
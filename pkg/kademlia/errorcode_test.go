@@ -0,0 +1,101 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/pkg/transport"
+)
+
+func TestErrorCodesComplete(t *testing.T) {
+	classes := make(map[string]bool)
+	for _, entry := range errorCodes {
+		if entry.class != nil {
+			classes[string(*entry.class)] = true
+		}
+	}
+
+	for _, dir := range []string{".", "../transport"} {
+		packages, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+			return !strings.HasSuffix(info.Name(), "_test.go")
+		}, 0)
+		require.NoError(t, err)
+
+		for _, pkg := range packages {
+			ast.Inspect(pkg, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok || len(call.Args) != 1 {
+					return true
+				}
+				selector, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok || selector.Sel.Name != "Class" {
+					return true
+				}
+				if ident, ok := selector.X.(*ast.Ident); !ok || ident.Name != "errs" {
+					return true
+				}
+				literal, ok := call.Args[0].(*ast.BasicLit)
+				require.True(t, ok, "errs.Class without a literal name in %s", dir)
+				class, err := strconv.Unquote(literal.Value)
+				require.NoError(t, err)
+
+				assert.True(t, classes[class], "errs.Class(%q) in %s has no error code", class, dir)
+				return true
+			})
+		}
+	}
+}
+
+func TestErrorCodesFormat(t *testing.T) {
+	format := regexp.MustCompile(`^(kad|transport):[a-z_]+$`)
+	seen := make(map[string]bool)
+	for _, entry := range errorCodes {
+		assert.Regexp(t, format, entry.code)
+		assert.False(t, seen[entry.code], "duplicate code %s", entry.code)
+		seen[entry.code] = true
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	for _, testcase := range []struct {
+		err  error
+		code string
+	}{
+		{nil, ""},
+		{errors.New("unexpected"), ErrorCodeUnknown},
+		{transport.SelfDial.New("self"), "transport:self_dial"},
+		{Error.Wrap(transport.SelfDial.New("self")), "transport:self_dial"},
+		{Error.Wrap(context.DeadlineExceeded), ErrorCodeTimeout},
+		{NodeNotFound.New("missing"), "kad:node_not_found"},
+		{Error.New("other"), "kad:error"},
+		{statusError(codes.InvalidArgument, ErrorCodeSenderMismatch, "forged"), ErrorCodeSenderMismatch},
+		{NodeErr.Wrap(statusError(codes.InvalidArgument, ErrorCodeSenderMismatch, "forged")), ErrorCodeSenderMismatch},
+		{status.Error(codes.Unknown, "without code"), ErrorCodeUnknown},
+	} {
+		assert.Equal(t, testcase.code, ErrorCode(testcase.err), "%v", testcase.err)
+	}
+
+	{ // endpoint errors keep their grpc code and message
+		err := EndpointError.New("failed")
+		withErrorCode(&err)
+		assert.Equal(t, codes.Unknown, status.Code(err))
+		assert.Equal(t, "kad:endpoint", ErrorCode(err))
+		assert.Equal(t, "kademlia endpoint error: failed", status.Convert(err).Message())
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+)
+
+// FailureBucket is the string representing the bucket used for dial failure statistics
+const FailureBucket = "failures"
+
+// PeerBackedOff is the class of errors for background dials skipped since
+// the peer failed its recent dials.
+var PeerBackedOff = errs.Class("peer backed off")
+
+// failureEntry summarizes the failed dials of a peer since its last successful dial.
+type failureEntry struct {
+	Consecutive int       `json:"consecutive"`
+	LastFailure time.Time `json:"last_failure"`
+	LastError   string    `json:"last_error"`
+}
+
+// dialFailures remembers the peers whose recent dials failed, optionally
+// persisting them in db, so that background dials, such as bucket refreshes
+// and identity verifications, back off from long unreachable peers even
+// right after a restart.
+//
+// A peer is backed off once it failed Threshold consecutive dials, for
+// BackoffBase doubling with every further failure up to BackoffMax after its
+// last failure. A nil dialFailures never backs off.
+type dialFailures struct {
+	log    *zap.Logger
+	config FailureConfig
+	now    func() time.Time
+
+	entries *lrucache.Cache
+	writer  *batchWriter // nil when not persisted
+}
+
+// newDialFailures creates the failure statistics, it returns nil when disabled by config.
+func newDialFailures(log *zap.Logger, config FailureConfig) *dialFailures {
+	if config.Threshold <= 0 {
+		return nil
+	}
+	return &dialFailures{
+		log:     log,
+		config:  config,
+		now:     time.Now,
+		entries: lrucache.New(peerCacheSize),
+	}
+}
+
+// load persists the statistics in db, starting with the statistics persisted
+// before. Rows older than MaxAge, and corrupt rows, are deleted.
+func (failures *dialFailures) load(db storage.KeyValueStore, workers *WorkerPool) error {
+	if failures == nil {
+		return nil
+	}
+
+	now := failures.now()
+	var purged storage.Keys
+	err := db.Iterate(storage.IterateOptions{Recurse: true},
+		func(it storage.Iterator) error {
+			var item storage.ListItem
+			for it.Next(&item) {
+				id, entry, err := decodeFailureEntry(item.Key, item.Value)
+				if err != nil {
+					failures.log.Warn("dropping corrupt dial failures", zap.Binary("key", item.Key), zap.Error(err))
+					purged = append(purged, storage.CloneKey(item.Key))
+					continue
+				}
+				if failures.config.MaxAge > 0 && now.Sub(entry.LastFailure) > failures.config.MaxAge {
+					purged = append(purged, storage.CloneKey(item.Key))
+					continue
+				}
+				failures.entries.Add(id, entry)
+			}
+			return nil
+		})
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	var group errs.Group
+	for _, key := range purged {
+		group.Add(db.Delete(key))
+	}
+
+	failures.writer = newBatchWriter(failures.log, db, workers, "persist-failures")
+	return Error.Wrap(group.Err())
+}
+
+// backedOff returns whether background dials of id should be skipped.
+func (failures *dialFailures) backedOff(id storj.NodeID) bool {
+	if failures == nil {
+		return false
+	}
+	value, ok := failures.entries.Peek(id)
+	if !ok {
+		return false
+	}
+	entry := value.(failureEntry)
+	if entry.Consecutive < failures.config.Threshold {
+		return false
+	}
+	return failures.now().Before(entry.LastFailure.Add(failures.backoff(entry.Consecutive)))
+}
+
+// backoff returns how long a peer with consecutive failed dials is backed off.
+func (failures *dialFailures) backoff(consecutive int) time.Duration {
+	backoff := failures.config.BackoffBase
+	for i := failures.config.Threshold; i < consecutive && backoff < failures.config.BackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > failures.config.BackoffMax {
+		backoff = failures.config.BackoffMax
+	}
+	return backoff
+}
+
+// dialed records the outcome of a dial of id, canceled dials aren't counted.
+func (failures *dialFailures) dialed(id storj.NodeID, err error) {
+	if failures == nil || id.IsZero() {
+		return
+	}
+
+	outcome := errs2.ClassifyDial(err)
+	switch outcome {
+	case errs2.DialCanceled:
+		return
+	case errs2.DialSuccess:
+		if _, ok := failures.entries.Peek(id); ok {
+			failures.entries.Remove(id)
+			failures.persist(id, nil)
+		}
+		return
+	}
+
+	var updated failureEntry
+	failures.entries.Update(id, func(value interface{}, ok bool) interface{} {
+		if ok {
+			updated = value.(failureEntry)
+		}
+		updated.Consecutive++
+		updated.LastFailure = failures.now()
+		updated.LastError = outcome.String()
+		return updated
+	})
+	failures.persist(id, &updated)
+}
+
+// persist queues entry to be written for id, a nil entry deletes it.
+func (failures *dialFailures) persist(id storj.NodeID, entry *failureEntry) {
+	if failures.writer == nil {
+		return
+	}
+	if entry == nil {
+		failures.writer.persist(id, nil)
+		return
+	}
+	value, err := json.Marshal(entry)
+	if err != nil {
+		failures.log.Warn("failed to encode dial failures", zap.Stringer("Node ID", id), zap.Error(err))
+		return
+	}
+	failures.writer.persist(id, value)
+}
+
+// decodeFailureEntry decodes the persisted failures of the node in key.
+func decodeFailureEntry(key storage.Key, value storage.Value) (storj.NodeID, failureEntry, error) {
+	id, err := storj.NodeIDFromBytes(key)
+	if err != nil {
+		return storj.NodeID{}, failureEntry{}, err
+	}
+	var entry failureEntry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return storj.NodeID{}, failureEntry{}, err
+	}
+	if entry.Consecutive <= 0 {
+		return storj.NodeID{}, failureEntry{}, errs.New("no failures recorded")
+	}
+	return id, entry, nil
+}
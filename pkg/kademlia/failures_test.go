@@ -0,0 +1,149 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testdial"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/storage/teststore"
+)
+
+// deadAddress returns an address nothing listens on and the error of dialing it.
+func deadAddress(t *testing.T) (string, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	_, err = net.Dial("tcp", address)
+	require.Error(t, err)
+	return address, err
+}
+
+func TestDialFailuresPersisted(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	log := zaptest.NewLogger(t)
+	config := FailureConfig{Threshold: 2, BackoffBase: time.Hour, BackoffMax: 4 * time.Hour, MaxAge: 24 * time.Hour}
+	db := teststore.New()
+	_, refused := deadAddress(t)
+
+	dead, flaky, old := teststorj.NodeIDFromString("dead"), teststorj.NodeIDFromString("flaky"), teststorj.NodeIDFromString("old")
+
+	{ // the previous run
+		workers := NewWorkerPool(log, "test", 1)
+		failures := newDialFailures(log, config)
+		require.NoError(t, failures.load(db, workers))
+
+		failures.dialed(dead, refused)
+		assert.False(t, failures.backedOff(dead), "backed off below the threshold")
+		failures.dialed(dead, refused)
+		assert.True(t, failures.backedOff(dead))
+
+		failures.dialed(flaky, refused)
+		failures.dialed(flaky, refused)
+		failures.dialed(flaky, nil)
+		assert.False(t, failures.backedOff(flaky), "backed off after a successful dial")
+
+		failures.now = func() time.Time { return time.Now().Add(-48 * time.Hour) }
+		failures.dialed(old, refused)
+		failures.dialed(old, refused)
+
+		// canceled dials aren't failures
+		failures.dialed(flaky, context.Canceled)
+
+		require.NoError(t, workers.Close(ctx))
+	}
+
+	keys, err := db.List(nil, 0)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	failures := newDialFailures(log, config)
+	require.NoError(t, failures.load(db, NewWorkerPool(log, "test", 1)))
+	assert.True(t, failures.backedOff(dead))
+	assert.False(t, failures.backedOff(flaky))
+	assert.False(t, failures.backedOff(old))
+
+	// the old failures are purged on load
+	keys, err = db.List(nil, 0)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, dead.Bytes(), []byte(keys[0]))
+
+	// the backoff doubles up to the maximum
+	assert.Equal(t, time.Hour, failures.backoff(2))
+	assert.Equal(t, 2*time.Hour, failures.backoff(3))
+	assert.Equal(t, 4*time.Hour, failures.backoff(10))
+}
+
+func TestRefreshSkipsFailedPeers(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	log := zaptest.NewLogger(t)
+	config := FailureConfig{Threshold: 3, BackoffBase: time.Hour, BackoffMax: time.Hour, MaxAge: 24 * time.Hour}
+	db := teststore.New()
+
+	server, _, liveIdentity, liveAddress := startTestNodeServer(ctx)
+	defer server.Stop()
+	live := pb.Node{Id: liveIdentity.ID, Address: &pb.NodeAddress{Transport: defaultTransport, Address: liveAddress}}
+
+	deadAddr, refused := deadAddress(t)
+	dead := pb.Node{Id: teststorj.NodeIDFromString("dead"), Address: &pb.NodeAddress{Transport: defaultTransport, Address: deadAddr}}
+
+	{ // the dead peer failed before the restart
+		workers := NewWorkerPool(log, "test", 1)
+		failures := newDialFailures(log, config)
+		require.NoError(t, failures.load(db, workers))
+		for i := 0; i < config.Threshold; i++ {
+			failures.dialed(dead.Id, refused)
+		}
+		require.NoError(t, workers.Close(ctx))
+	}
+
+	ident, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(log, pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, ident, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	recorder := testdial.WithDialRecorder(t, k.dialer.transport)
+	k.dialer.transport = recorder.Client
+	k.dialer.failures = newDialFailures(log, config)
+	require.NoError(t, k.LoadDialFailures(db))
+
+	for _, node := range []pb.Node{live, dead} {
+		node := node
+		ok, err := k.routingTable.addNode(&node)
+		require.True(t, ok)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, k.refresh(ctx, 0))
+
+	// the backed off peer isn't dialed
+	recorder.ExpectOnly(live.Id)
+	assert.NotZero(t, recorder.Count(live.Id), "fresh peer wasn't dialed")
+
+	// interactive dials still reach the backed off peer
+	recorder.Reset()
+	_, err = k.Ping(ctx, dead)
+	assert.Error(t, err)
+	recorder.ExpectOnly(dead.Id)
+	assert.Equal(t, 1, recorder.Count(dead.Id))
+}
@@ -0,0 +1,36 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"storj.io/storj/pkg/storj"
+)
+
+// FeatureQueryStream is the feature enabling streaming lookups, peers are
+// queried with unary lookups while it's disabled
+const FeatureQueryStream = "kademlia.query-stream"
+
+// FeatureChecker returns whether a network-wide feature is enabled for a node,
+// such as *version.Service.
+type FeatureChecker interface {
+	IsEnabled(name string, id storj.NodeID) bool
+}
+
+// SetFeatures makes the dialer use only the features checker enabled for this
+// node. Without a checker every feature is used.
+func (k *Kademlia) SetFeatures(checker FeatureChecker) {
+	id := k.Local().Id
+
+	k.dialer.settingsMu.Lock()
+	defer k.dialer.settingsMu.Unlock()
+	k.dialer.features, k.dialer.featureID = checker, id
+}
+
+// featureEnabled returns whether the feature name is enabled for this node.
+func (dialer *Dialer) featureEnabled(name string) bool {
+	dialer.settingsMu.Lock()
+	checker, id := dialer.features, dialer.featureID
+	dialer.settingsMu.Unlock()
+	return checker == nil || checker.IsEnabled(name, id)
+}
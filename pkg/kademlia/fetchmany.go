@@ -0,0 +1,102 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/pkg/pb"
+)
+
+// progressSteps is how many times at most the progress of a batch is reported
+const progressSteps = 100
+
+// FetchInfoResult is the node info fetched from one node of a batch, or why it couldn't be.
+type FetchInfoResult struct {
+	Info    *pb.InfoResponse
+	Err     error
+	Outcome errs2.DialOutcome
+}
+
+// FetchInfoMany fetches the node info of nodes with up to concurrency dials
+// at a time, reusing released connections. The results are in the order of
+// nodes, failures are classified per node. When ctx is canceled midway, the
+// nodes not fetched yet fail with the error of ctx, which is also returned
+// along with the partial results.
+//
+// progress, when not nil, is called with the number of nodes done, every
+// hundredth of the batch and at the end.
+func (dialer *Dialer) FetchInfoMany(ctx context.Context, nodes []pb.Node, concurrency int, progress func(done, total int)) (_ []FetchInfoResult, err error) {
+	defer dialer.mon.Task()(&ctx)(&err)
+
+	results := make([]FetchInfoResult, len(nodes))
+	total := len(nodes)
+	if total == 0 {
+		return results, nil
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	step := (total + progressSteps - 1) / progressSteps
+
+	var mu sync.Mutex
+	var next, done int
+	work := func() {
+		for {
+			mu.Lock()
+			if next >= total || ctx.Err() != nil {
+				mu.Unlock()
+				return
+			}
+			i := next
+			next++
+			mu.Unlock()
+
+			info, err := dialer.FetchInfo(ctx, nodes[i])
+			results[i] = FetchInfoResult{Info: info, Err: err, Outcome: errs2.ClassifyDial(err)}
+
+			mu.Lock()
+			done++
+			if progress != nil && (done%step == 0 || done == total) {
+				progress(done, total)
+			}
+			mu.Unlock()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i < concurrency && i < total; i++ {
+		wg.Add(1)
+		if !dialer.workers.Go("fetch-info-many", func() {
+			defer wg.Done()
+			work()
+		}) {
+			wg.Done()
+			break
+		}
+	}
+	// the caller is a worker as well, so that a full pool doesn't stall the batch
+	work()
+	wg.Wait()
+
+	if next < total {
+		for i := next; i < total; i++ {
+			results[i] = FetchInfoResult{Err: ctx.Err(), Outcome: errs2.ClassifyDial(ctx.Err())}
+		}
+		return results, ctx.Err()
+	}
+	return results, nil
+}
+
+// FetchInfoMany fetches the node info of nodes in a batch, see Dialer.FetchInfoMany.
+func (k *Kademlia) FetchInfoMany(ctx context.Context, nodes []pb.Node, concurrency int, progress func(done, total int)) ([]FetchInfoResult, error) {
+	if !k.lookups.Start() {
+		return nil, context.Canceled
+	}
+	defer k.lookups.Done()
+
+	return k.dialer.FetchInfoMany(ctx, nodes, concurrency, progress)
+}
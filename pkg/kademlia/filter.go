@@ -0,0 +1,137 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+const (
+	// filterOverfetch is how many more nodes than requested are considered
+	// when filtering a query, so that filtering still fills the response
+	filterOverfetch = 4
+	// filterFetchConcurrency is the number of nodes whose info is fetched at
+	// a time, when filtering a response locally
+	filterFetchConcurrency = 8
+)
+
+// NodeMetadata knows the type and the capacity of other nodes, for filtering
+// queries.
+type NodeMetadata interface {
+	// NodeMetadata returns the type and the free disk of the node with id,
+	// ok is false when the node isn't known.
+	NodeMetadata(ctx context.Context, id storj.NodeID) (nodeType pb.NodeType, freeDisk int64, ok bool)
+}
+
+// LookupFilter restricts the nodes a lookup returns.
+type LookupFilter struct {
+	// MinFreeDisk is the least free disk in bytes, 0 for any.
+	MinFreeDisk int64
+	// Types are the allowed node types, empty for any.
+	Types []pb.NodeType
+}
+
+// LookupOption configures a lookup.
+type LookupOption func(*LookupFilter)
+
+// WithMinFreeDisk makes a lookup return only the nodes with at least free
+// disk space.
+func WithMinFreeDisk(free memory.Size) LookupOption {
+	return func(filter *LookupFilter) { filter.MinFreeDisk = free.Int64() }
+}
+
+// WithNodeTypes makes a lookup return only the nodes of types.
+func WithNodeTypes(types ...pb.NodeType) LookupOption {
+	return func(filter *LookupFilter) { filter.Types = types }
+}
+
+// newLookupFilter returns the filter configured by opts.
+func newLookupFilter(opts []LookupOption) LookupFilter {
+	var filter LookupFilter
+	for _, opt := range opts {
+		opt(&filter)
+	}
+	return filter
+}
+
+// lookupFilterFromProto returns the filter of a query request.
+func lookupFilterFromProto(filter *pb.QueryFilter) LookupFilter {
+	return LookupFilter{
+		MinFreeDisk: filter.GetMinFreeDisk(),
+		Types:       filter.GetTypes(),
+	}
+}
+
+// Empty returns whether the filter allows every node.
+func (filter LookupFilter) Empty() bool {
+	return filter.MinFreeDisk <= 0 && len(filter.Types) == 0
+}
+
+// Allows returns whether the filter allows a node of nodeType with freeDisk.
+func (filter LookupFilter) Allows(nodeType pb.NodeType, freeDisk int64) bool {
+	if freeDisk < filter.MinFreeDisk {
+		return false
+	}
+	if len(filter.Types) == 0 {
+		return true
+	}
+	for _, allowed := range filter.Types {
+		if nodeType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// proto returns the filter as sent in a query request, nil when empty.
+func (filter LookupFilter) proto() *pb.QueryFilter {
+	if filter.Empty() {
+		return nil
+	}
+	return &pb.QueryFilter{MinFreeDisk: filter.MinFreeDisk, Types: filter.Types}
+}
+
+// filterNodes returns the first limit nodes the filter allows, by their
+// metadata. Nodes without metadata are left out.
+func filterNodes(ctx context.Context, metadata NodeMetadata, filter LookupFilter, nodes []*pb.Node, limit int) []*pb.Node {
+	filtered := make([]*pb.Node, 0, limit)
+	for _, node := range nodes {
+		if len(filtered) >= limit {
+			break
+		}
+		nodeType, freeDisk, ok := metadata.NodeMetadata(ctx, node.Id)
+		if ok && filter.Allows(nodeType, freeDisk) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// filterLocally filters the nodes a peer without the query filter capability
+// responded with, by fetching their info. Nodes whose info can't be fetched
+// are left out.
+func (dialer *Dialer) filterLocally(ctx context.Context, filter LookupFilter, nodes []*pb.Node) ([]*pb.Node, error) {
+	dialer.mon.Counter("lookup_filtered_locally").Inc(1)
+
+	targets := make([]pb.Node, len(nodes))
+	for i, node := range nodes {
+		targets[i] = *node
+	}
+	results, err := dialer.FetchInfoMany(ctx, targets, filterFetchConcurrency, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []*pb.Node
+	for i, result := range results {
+		if result.Err == nil && filter.Allows(result.Info.GetType(), result.Info.GetCapacity().GetFreeDisk()) {
+			filtered = append(filtered, nodes[i])
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// stubMetadata is the metadata of a fixed set of nodes.
+type stubMetadata map[storj.NodeID]pb.InfoResponse
+
+func (metadata stubMetadata) NodeMetadata(ctx context.Context, id storj.NodeID) (pb.NodeType, int64, bool) {
+	info, ok := metadata[id]
+	return info.Type, info.GetCapacity().GetFreeDisk(), ok
+}
+
+func TestQueryFilter(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_SATELLITE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	large := teststorj.NodeIDFromString("large")
+	small := teststorj.NodeIDFromString("small")
+	satellite := teststorj.NodeIDFromString("satellite")
+	unknown := teststorj.NodeIDFromString("unknown")
+	for _, id := range []storj.NodeID{large, small, satellite, unknown} {
+		require.NoError(t, k.routingTable.putNode(&pb.Node{Id: id, Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}))
+	}
+	metadata := stubMetadata{
+		large:     {Type: pb.NodeType_STORAGE, Capacity: &pb.NodeCapacity{FreeDisk: 10 * memory.GB.Int64()}},
+		small:     {Type: pb.NodeType_STORAGE, Capacity: &pb.NodeCapacity{FreeDisk: memory.GB.Int64()}},
+		satellite: {Type: pb.NodeType_SATELLITE, Capacity: &pb.NodeCapacity{FreeDisk: 10 * memory.GB.Int64()}},
+	}
+
+	req := &pb.QueryRequest{
+		Target: &pb.Node{Id: large},
+		Limit:  20,
+		Filter: &pb.QueryFilter{MinFreeDisk: 5 * memory.GB.Int64(), Types: []pb.NodeType{pb.NodeType_STORAGE}},
+	}
+
+	// without metadata the filter is ignored
+	endpoint := NewEndpoint(zaptest.NewLogger(t), k, k.routingTable)
+	resp, err := endpoint.Query(ctx, req)
+	require.NoError(t, err)
+	assert.Len(t, resp.Response, 4)
+	assert.False(t, Capability(resp.Capabilities).Has(CapabilityQueryFilter))
+
+	// only the known nodes the filter allows are returned
+	endpoint.SetNodeMetadata(metadata)
+	resp, err = endpoint.Query(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, resp.Response, 1)
+	assert.Equal(t, large, resp.Response[0].Id)
+	assert.True(t, Capability(resp.Capabilities).Has(CapabilityQueryFilter))
+
+	// queries without filter are answered as before
+	resp, err = endpoint.Query(ctx, &pb.QueryRequest{Target: &pb.Node{Id: large}, Limit: 20})
+	require.NoError(t, err)
+	assert.Len(t, resp.Response, 4)
+}
+
+func TestLookupFilterFallback(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	// nodes answering with their info
+	infos := []*pb.InfoResponse{
+		{Type: pb.NodeType_STORAGE, Capacity: &pb.NodeCapacity{FreeDisk: 10 * memory.GB.Int64()}},
+		{Type: pb.NodeType_STORAGE, Capacity: &pb.NodeCapacity{FreeDisk: memory.GB.Int64()}},
+		{Type: pb.NodeType_SATELLITE, Capacity: &pb.NodeCapacity{FreeDisk: 10 * memory.GB.Int64()}},
+	}
+	var found []*pb.Node
+	var servers []*testkademlia.Server
+	for _, info := range infos {
+		server, err := testkademlia.Start(ctx)
+		require.NoError(t, err)
+		defer server.Close()
+		server.Set(testkademlia.Behavior{Info: info}, testkademlia.RequestInfo)
+		servers = append(servers, server)
+		node := server.Node()
+		found = append(found, &node)
+	}
+
+	// the asked peer doesn't know the query filter
+	old, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer old.Close()
+	old.Set(testkademlia.Behavior{Nodes: found, Capabilities: uint64(CapabilityQueryStream | CapabilityGzip)})
+	ask := old.Node()
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	lookup := func() []*pb.Node {
+		nodes, err := k.dialer.Lookup(ctx, pb.Node{}, ask, pb.Node{Id: found[0].Id},
+			WithMinFreeDisk(5*memory.GB), WithNodeTypes(pb.NodeType_STORAGE))
+		require.NoError(t, err)
+		return nodes
+	}
+
+	// the response is filtered locally by the info of the nodes
+	nodes := lookup()
+	require.Len(t, nodes, 1)
+	assert.Equal(t, found[0].Id, nodes[0].Id)
+	for _, server := range servers {
+		assert.Equal(t, 1, server.Calls(testkademlia.RequestInfo))
+	}
+
+	// the response of a peer honoring the filter is trusted
+	old.Set(testkademlia.Behavior{Nodes: found, Capabilities: uint64(Capabilities | CapabilityQueryFilter)})
+	nodes = lookup()
+	assert.Len(t, nodes, len(found))
+	for _, server := range servers {
+		assert.Equal(t, 1, server.Calls(testkademlia.RequestInfo))
+	}
+}
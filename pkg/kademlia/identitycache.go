@@ -0,0 +1,365 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/identity"
+	"storj.io/storj/pkg/peertls"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+)
+
+// IdentityBucket is the string representing the bucket used for verified peer identities
+const IdentityBucket = "identities"
+
+const (
+	// identityCacheSize is the default maximum number of identities kept in memory
+	identityCacheSize = 10000
+	// identityTTL is how long a verified identity is served by default before it must be verified again
+	identityTTL = 24 * time.Hour
+)
+
+// IdentityCacheConfig defines how many fetched peer identities are kept and
+// how long they are used without dialing the peers
+type IdentityCacheConfig struct {
+	Size       int           `help:"maximum number of peer identities kept in memory, of verified nodes and of addresses each" default:"10000"`
+	TTL        time.Duration `help:"how long a verified peer identity is used before it's verified again by dialing the node" default:"24h"`
+	AddressTTL time.Duration `help:"how long the identity fetched from an address without verifying the node ID is used for the next fetches from the address, zero fetches it every time" default:"0s"`
+}
+
+// identityCache remembers verified peer identities, optionally persisting them in db.
+//
+// Identities loaded from db, or verified longer than the TTL ago, are
+// stale: they are served, but must be verified again by dialing the peer.
+// Only the most recently used identities are kept in memory.
+//
+// Changes are written to db in batches by a job on workers.
+//
+// The identities fetched from addresses are kept in memory only, until the
+// address TTL expires.
+type identityCache struct {
+	log    *zap.Logger
+	db     storage.KeyValueStore
+	writer *batchWriter
+	now    func() time.Time
+	config IdentityCacheConfig
+
+	mu      sync.Mutex // protects the fields of the entries
+	entries *lrucache.Cache
+	// identities of addresses, nil when they aren't kept
+	addresses *lrucache.Cache
+
+	// concurrent fetches of the same identity share a dial
+	fetches singleflight.Group
+}
+
+// addressIdentity is the identity fetched from an address.
+type addressIdentity struct {
+	identity *identity.PeerIdentity
+	fetched  time.Time
+}
+
+type identityEntry struct {
+	identity      *identity.PeerIdentity
+	firstSeen     time.Time
+	lastVerified  time.Time
+	protocol      version.Protocol
+	protocolKnown bool
+	stale         bool
+	verifying     bool
+}
+
+// persistedIdentity is the db representation of an identity entry
+type persistedIdentity struct {
+	Chain        [][]byte          `json:"chain"`
+	FirstSeen    time.Time         `json:"first_seen"`
+	LastVerified time.Time         `json:"last_verified"`
+	Protocol     *version.Protocol `json:"protocol,omitempty"`
+}
+
+// newIdentityCache creates an identity cache configured by config, db may be
+// nil. A zero size or TTL are the defaults.
+func newIdentityCache(log *zap.Logger, db storage.KeyValueStore, workers *WorkerPool, config IdentityCacheConfig) *identityCache {
+	if config.Size <= 0 {
+		config.Size = identityCacheSize
+	}
+	if config.TTL <= 0 {
+		config.TTL = identityTTL
+	}
+	cache := &identityCache{
+		log:     log,
+		db:      db,
+		writer:  newBatchWriter(log, db, workers, "persist-identities"),
+		now:     time.Now,
+		config:  config,
+		entries: lrucache.New(config.Size),
+	}
+	if config.AddressTTL > 0 {
+		cache.addresses = lrucache.New(config.Size)
+	}
+	return cache
+}
+
+// entry returns the entry of id.
+func (cache *identityCache) entry(id storj.NodeID) (*identityEntry, bool) {
+	value, ok := cache.entries.Get(id)
+	if !ok {
+		return nil, false
+	}
+	return value.(*identityEntry), true
+}
+
+// load loads the persisted identities as stale entries, corrupt rows are deleted.
+func (cache *identityCache) load() error {
+	if cache.db == nil {
+		return nil
+	}
+
+	var corrupt storage.Keys
+	err := cache.db.Iterate(storage.IterateOptions{Recurse: true},
+		func(it storage.Iterator) error {
+			var item storage.ListItem
+			for it.Next(&item) {
+				entry, err := decodeIdentityEntry(item.Key, item.Value)
+				if err != nil {
+					cache.log.Warn("dropping corrupt peer identity", zap.Binary("key", item.Key), zap.Error(err))
+					corrupt = append(corrupt, storage.CloneKey(item.Key))
+					continue
+				}
+				entry.stale = true
+				cache.entries.Add(entry.identity.ID, entry)
+			}
+			return nil
+		})
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	var group errs.Group
+	for _, key := range corrupt {
+		group.Add(cache.db.Delete(key))
+	}
+	return Error.Wrap(group.Err())
+}
+
+// get returns the cached identity and whether it must be verified again.
+// needsVerify is true only for the first caller after the entry became stale.
+func (cache *identityCache) get(id storj.NodeID) (ident *identity.PeerIdentity, needsVerify bool) {
+	entry, ok := cache.entry(id)
+	if !ok {
+		return nil, false
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.now().Sub(entry.lastVerified) > cache.config.TTL {
+		entry.stale = true
+	}
+	if entry.stale && !entry.verifying {
+		entry.verifying = true
+		needsVerify = true
+	}
+	return entry.identity, needsVerify
+}
+
+// verified adds or refreshes a verified identity.
+func (cache *identityCache) verified(ident *identity.PeerIdentity) {
+	now := cache.now()
+
+	entry, ok := cache.entry(ident.ID)
+	if !ok {
+		entry = &identityEntry{firstSeen: now}
+		cache.entries.Add(ident.ID, entry)
+	}
+
+	cache.mu.Lock()
+	entry.identity = ident
+	entry.lastVerified = now
+	entry.stale = false
+	entry.verifying = false
+	persisted := entry.persisted()
+	cache.mu.Unlock()
+
+	cache.save(ident.ID, persisted)
+}
+
+// protocol returns the protocol version recorded with the identity of id.
+func (cache *identityCache) protocol(id storj.NodeID) (protocol version.Protocol, known bool) {
+	entry, ok := cache.entry(id)
+	if !ok {
+		return 0, false
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return entry.protocol, entry.protocolKnown
+}
+
+// setProtocol records the protocol version advertised by id with its
+// identity, nodes without a cached identity are ignored.
+func (cache *identityCache) setProtocol(id storj.NodeID, protocol version.Protocol) {
+	entry, ok := cache.entry(id)
+	if !ok {
+		return
+	}
+
+	cache.mu.Lock()
+	if entry.protocolKnown && entry.protocol == protocol {
+		cache.mu.Unlock()
+		return
+	}
+	entry.protocol, entry.protocolKnown = protocol, true
+	persisted := entry.persisted()
+	cache.mu.Unlock()
+
+	cache.save(id, persisted)
+}
+
+// save queues persisted to be written as the identity of id.
+func (cache *identityCache) save(id storj.NodeID, persisted persistedIdentity) {
+	if cache.db == nil {
+		return
+	}
+	value, err := json.Marshal(persisted)
+	if err != nil {
+		cache.log.Warn("failed to encode peer identity", zap.Stringer("Node ID", id), zap.Error(err))
+		return
+	}
+	cache.writer.persist(id, value)
+}
+
+// verifyFailed allows the stale entry to be verified again later.
+func (cache *identityCache) verifyFailed(id storj.NodeID) {
+	entry, ok := cache.entry(id)
+	if !ok {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry.verifying = false
+}
+
+// invalidate removes the identity, also as the identity of addresses.
+func (cache *identityCache) invalidate(id storj.NodeID) {
+	cache.entries.Remove(id)
+	if cache.db != nil {
+		cache.writer.persist(id, nil)
+	}
+
+	if cache.addresses == nil {
+		return
+	}
+	var addresses []string
+	cache.addresses.Each(func(key, value interface{}) {
+		if value.(addressIdentity).identity.ID == id {
+			addresses = append(addresses, key.(string))
+		}
+	})
+	for _, address := range addresses {
+		cache.addresses.Remove(address)
+	}
+}
+
+// address returns the identity fetched from address within the address TTL.
+func (cache *identityCache) address(address string) (*identity.PeerIdentity, bool) {
+	if cache.addresses == nil {
+		return nil, false
+	}
+	value, ok := cache.addresses.Get(address)
+	if !ok {
+		return nil, false
+	}
+	fetched := value.(addressIdentity)
+	if cache.now().Sub(fetched.fetched) >= cache.config.AddressTTL {
+		cache.addresses.Remove(address)
+		return nil, false
+	}
+	return fetched.identity, true
+}
+
+// fetchedFrom remembers the identity fetched from address.
+func (cache *identityCache) fetchedFrom(address string, ident *identity.PeerIdentity) {
+	if cache.addresses == nil {
+		return
+	}
+	cache.addresses.Add(address, addressIdentity{identity: ident, fetched: cache.now()})
+}
+
+// persisted returns the db representation of the entry.
+func (entry *identityEntry) persisted() persistedIdentity {
+	chain := [][]byte{entry.identity.Leaf.Raw, entry.identity.CA.Raw}
+	for _, cert := range entry.identity.RestChain {
+		chain = append(chain, cert.Raw)
+	}
+	persisted := persistedIdentity{
+		Chain:        chain,
+		FirstSeen:    entry.firstSeen,
+		LastVerified: entry.lastVerified,
+	}
+	if entry.protocolKnown {
+		protocol := entry.protocol
+		persisted.Protocol = &protocol
+	}
+	return persisted
+}
+
+// decodeIdentityEntry decodes a persisted identity and checks that it belongs to the node in key.
+func decodeIdentityEntry(key storage.Key, value storage.Value) (*identityEntry, error) {
+	id, err := storj.NodeIDFromBytes(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var persisted persistedIdentity
+	if err := json.Unmarshal(value, &persisted); err != nil {
+		return nil, err
+	}
+	if len(persisted.Chain) < 2 {
+		return nil, errs.New("identity chain is too short")
+	}
+
+	chain := make([]*x509.Certificate, 0, len(persisted.Chain))
+	for _, der := range persisted.Chain {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+
+	// the peer signed the chain when it was verified, but the db may have been tampered with
+	if err := peertls.VerifyPeerCertChains(nil, [][]*x509.Certificate{chain}); err != nil {
+		return nil, err
+	}
+
+	ident, err := identity.PeerIdentityFromChain(chain)
+	if err != nil {
+		return nil, err
+	}
+	if ident.ID != id {
+		return nil, errs.New("identity %s stored as %s", ident.ID, id)
+	}
+
+	entry := &identityEntry{
+		identity:     ident,
+		firstSeen:    persisted.FirstSeen,
+		lastVerified: persisted.LastVerified,
+	}
+	if persisted.Protocol != nil {
+		entry.protocol, entry.protocolKnown = *persisted.Protocol, true
+	}
+	return entry, nil
+}
@@ -0,0 +1,285 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storage"
+	"storj.io/storj/storage/teststore"
+)
+
+func TestIdentityCachePersistence(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, _, serverID, serverAddress := startTestNodeServer(ctx)
+	defer server.GracefulStop()
+	target := pb.Node{Id: serverID.ID, Address: &pb.NodeAddress{Address: serverAddress}}
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOptions, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	db := teststore.New()
+	defer ctx.Check(db.Close)
+
+	// newDialer simulates starting the node with an identity cache loaded from db
+	newDialer := func() (*Dialer, *dialCounter) {
+		dials := &dialCounter{}
+		dialer := NewDialer(zaptest.NewLogger(t), transport.NewClient(tlsOptions, dials))
+		dialer.identities = newIdentityCache(zaptest.NewLogger(t), db, dialer.workers, IdentityCacheConfig{})
+		require.NoError(t, dialer.identities.load())
+		return dialer, dials
+	}
+
+	{ // identities are fetched once and persisted
+		dialer, dials := newDialer()
+		for i := 0; i < 2; i++ {
+			ident, err := dialer.FetchPeerIdentity(ctx, target)
+			require.NoError(t, err)
+			require.Equal(t, serverID.ID, ident.ID)
+		}
+		require.NoError(t, dialer.Close())
+		assert.Equal(t, int32(1), dials.count())
+
+		keys, err := db.List(nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, storage.Keys{serverID.ID.Bytes()}, keys)
+	}
+
+	{ // after a restart stale identities are served and verified once in the background
+		dialer, dials := newDialer()
+		for i := 0; i < 3; i++ {
+			ident, err := dialer.FetchPeerIdentity(ctx, target)
+			require.NoError(t, err)
+			require.Equal(t, serverID.ID, ident.ID)
+			require.True(t, ident.Leaf.Equal(serverID.Leaf))
+		}
+		require.NoError(t, dialer.Close())
+		assert.Equal(t, int32(1), dials.count())
+
+		_, needsVerify := dialer.identities.get(serverID.ID)
+		assert.False(t, needsVerify)
+	}
+
+	{ // protocol versions are persisted with the identities
+		dialer, _ := newDialer()
+		ok, err := dialer.PingNode(ctx, target)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NoError(t, dialer.Close())
+
+		dialer, _ = newDialer()
+		protocol, known := dialer.Protocol(serverID.ID)
+		assert.True(t, known)
+		assert.Equal(t, version.ProtocolLegacy, protocol)
+		require.NoError(t, dialer.Close())
+	}
+
+	{ // identities failing verification are invalidated
+		impostor := target
+		impostor.Id = teststorj.NodeIDFromString("impostor")
+		dialer, _ := newDialer()
+		dialer.identities.entries.Add(impostor.Id, &identityEntry{identity: serverID.PeerIdentity(), stale: true})
+
+		ident, err := dialer.FetchPeerIdentity(ctx, impostor)
+		require.NoError(t, err)
+		require.NotNil(t, ident)
+		require.NoError(t, dialer.Close())
+
+		cached, _ := dialer.identities.get(impostor.Id)
+		assert.Nil(t, cached)
+	}
+
+	{ // corrupt rows are dropped
+		require.NoError(t, db.Put(storage.Key("not a node id"), storage.Value("{}")))
+		require.NoError(t, db.Put(teststorj.NodeIDFromString("corrupt").Bytes(), storage.Value("not json")))
+		valid, err := db.Get(serverID.ID.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, db.Put(teststorj.NodeIDFromString("mismatch").Bytes(), valid))
+
+		dialer, _ := newDialer()
+		require.NoError(t, dialer.Close())
+		assert.Equal(t, 1, dialer.identities.entries.Len())
+
+		keys, err := db.List(nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, storage.Keys{serverID.ID.Bytes()}, keys)
+	}
+}
+
+func TestIdentityCacheTTL(t *testing.T) {
+	cache := newIdentityCache(zaptest.NewLogger(t), nil, nil, IdentityCacheConfig{})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	ident := testidentity.MustPregeneratedIdentity(0, storj.LatestIDVersion()).PeerIdentity()
+	cache.verified(ident)
+	_, needsVerify := cache.get(ident.ID)
+	assert.False(t, needsVerify)
+
+	// expired identities are verified again by the first caller only
+	now = now.Add(identityTTL + time.Second)
+	_, needsVerify = cache.get(ident.ID)
+	assert.True(t, needsVerify)
+	_, needsVerify = cache.get(ident.ID)
+	assert.False(t, needsVerify)
+}
+
+func TestDecodeIdentityEntryVerifiesChain(t *testing.T) {
+	a := testidentity.MustPregeneratedIdentity(0, storj.LatestIDVersion())
+	b := testidentity.MustPregeneratedIdentity(1, storj.LatestIDVersion())
+
+	encode := func(chain ...*x509.Certificate) storage.Value {
+		var persisted persistedIdentity
+		for _, cert := range chain {
+			persisted.Chain = append(persisted.Chain, cert.Raw)
+		}
+		value, err := json.Marshal(persisted)
+		require.NoError(t, err)
+		return value
+	}
+
+	entry, err := decodeIdentityEntry(a.ID.Bytes(), encode(a.Leaf, a.CA))
+	require.NoError(t, err)
+	assert.Equal(t, a.ID, entry.identity.ID)
+
+	// the id matches the CA, but the CA didn't sign the leaf
+	_, err = decodeIdentityEntry(b.ID.Bytes(), encode(a.Leaf, b.CA))
+	assert.Error(t, err)
+}
+
+// dialCounter counts the dials made by a transport
+type dialCounter struct{ dials int32 }
+
+func (counter *dialCounter) count() int32 { return atomic.LoadInt32(&counter.dials) }
+
+func (counter *dialCounter) ConnSuccess(ctx context.Context, node *pb.Node) {
+	atomic.AddInt32(&counter.dials, 1)
+}
+
+func (counter *dialCounter) ConnFailure(ctx context.Context, node *pb.Node, err error) {
+	atomic.AddInt32(&counter.dials, 1)
+}
+
+func TestIdentityCacheSharedFetch(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, _, serverID, serverAddress := startTestNodeServer(ctx)
+	defer server.GracefulStop()
+	target := pb.Node{Id: serverID.ID, Address: &pb.NodeAddress{Address: serverAddress}}
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOptions, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	dials := &dialCounter{}
+	dialer := NewDialer(zaptest.NewLogger(t), transport.NewClient(tlsOptions, dials))
+	defer ctx.Check(dialer.Close)
+
+	var group errgroup.Group
+	for i := 0; i < 10; i++ {
+		group.Go(func() error {
+			ident, err := dialer.FetchPeerIdentity(ctx, target)
+			if err == nil && ident.ID != serverID.ID {
+				err = errs.New("fetched %s", ident.ID)
+			}
+			return err
+		})
+	}
+	require.NoError(t, group.Wait())
+	assert.Equal(t, int32(1), dials.count())
+
+	// an invalidated identity is fetched again
+	dialer.InvalidateIdentity(serverID.ID)
+	_, err = dialer.FetchPeerIdentity(ctx, target)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), dials.count())
+}
+
+// addressDials counts the dials of addresses made by a transport.
+type addressDials struct {
+	transport.Client
+	dials int32
+}
+
+func (client *addressDials) count() int32 { return atomic.LoadInt32(&client.dials) }
+
+func (client *addressDials) DialAddress(ctx context.Context, address string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	atomic.AddInt32(&client.dials, 1)
+	return client.Client.DialAddress(ctx, address, opts...)
+}
+
+func TestIdentityCacheAddresses(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, _, serverID, serverAddress := startTestNodeServer(ctx)
+	defer server.GracefulStop()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOptions, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	addressTTL := time.Minute
+	client := &addressDials{Client: transport.NewClient(tlsOptions)}
+	dialer := NewDialer(zaptest.NewLogger(t), client, WithIdentityCache(IdentityCacheConfig{AddressTTL: addressTTL}))
+	defer ctx.Check(dialer.Close)
+	now := time.Now()
+	dialer.identities.now = func() time.Time { return now }
+
+	fetch := func() {
+		ident, err := dialer.FetchPeerIdentityUnverified(ctx, serverAddress)
+		require.NoError(t, err)
+		require.Equal(t, serverID.ID, ident.ID)
+	}
+
+	fetch()
+	fetch()
+	assert.Equal(t, int32(1), client.count())
+
+	// expired identities are fetched again
+	now = now.Add(addressTTL)
+	fetch()
+	assert.Equal(t, int32(2), client.count())
+
+	// so are invalidated ones
+	dialer.InvalidateIdentity(serverID.ID)
+	fetch()
+	assert.Equal(t, int32(3), client.count())
+
+	// unless configured, the identities of addresses aren't cached
+	uncached := NewDialer(zaptest.NewLogger(t), client)
+	defer ctx.Check(uncached.Close)
+	for i := 0; i < 2; i++ {
+		_, err := uncached.FetchPeerIdentityUnverified(ctx, serverAddress)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(5), client.count())
+}
@@ -4,14 +4,27 @@
 package kademlia
 
 import (
+	"bytes"
 	"context"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
 )
 
-// Inspector is a gRPC service for inspecting kademlia internals
+// Inspector is a gRPC service for inspecting kademlia internals.
+//
+// Its listings are ordered, so that the dumps of an unchanged routing table
+// are identical: buckets by ID, which is their index, nodes by ID, nodes near
+// a target by distance, and contact events and lookup hops by time.
 type Inspector struct {
 	dht      *Kademlia
 	identity *identity.FullIdentity
@@ -49,6 +62,7 @@ func (srv *Inspector) GetBuckets(ctx context.Context, req *pb.GetBucketsRequest)
 	if err != nil {
 		return nil, err
 	}
+	sort.Slice(nodeIDs, func(i, k int) bool { return nodeIDs[i].Less(nodeIDs[k]) })
 	return &pb.GetBucketsResponse{
 		Total: int64(len(b)),
 		// TODO(bryanchriswhite): should use bucketID type
@@ -92,7 +106,7 @@ func (srv *Inspector) LookupNode(ctx context.Context, req *pb.LookupNodeRequest)
 	if err != nil {
 		return &pb.LookupNodeResponse{}, err
 	}
-	node, err := srv.dht.FindNode(ctx, id)
+	node, err := srv.dht.FindNode(WithFreshLookup(ctx), id)
 	if err != nil {
 		return &pb.LookupNodeResponse{}, err
 	}
@@ -102,16 +116,37 @@ func (srv *Inspector) LookupNode(ctx context.Context, req *pb.LookupNodeRequest)
 	}, nil
 }
 
-// DumpNodes returns all of the nodes in the routing table database.
+// DumpNodes returns the nodes of a snapshot of the routing table, in chunks when requested.
 func (srv *Inspector) DumpNodes(ctx context.Context, req *pb.DumpNodesRequest) (*pb.DumpNodesResponse, error) {
-	nodes, err := srv.dht.DumpNodes(ctx)
+	chunk, err := srv.dht.SnapshotChunk(ctx, req.Generation, req.After, int(req.Limit))
+	if ErrSnapshotRotated.Has(err) {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &pb.DumpNodesResponse{
-		Nodes: nodes,
-	}, nil
+	resp := &pb.DumpNodesResponse{
+		Nodes:      chunk.Nodes,
+		Generation: chunk.Generation,
+		More:       chunk.More,
+		Checksum:   chunk.Checksum,
+	}
+	if Capability(req.Capabilities).Has(CapabilityCompactSnapshot) {
+		compact, err := EncodeCompactSnapshot(chunk.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		size := proto.Size(&pb.DumpNodesResponse{Nodes: chunk.Nodes})
+		srv.dht.mon.IntVal("snapshot_compact_bytes").Observe(int64(len(compact)))
+		srv.dht.mon.IntVal("snapshot_protobuf_bytes").Observe(int64(size))
+		srv.dht.log.Debug("sending compact snapshot chunk",
+			zap.Int("Nodes", len(chunk.Nodes)),
+			zap.Int("Compact Bytes", len(compact)),
+			zap.Int("Protobuf Bytes", size))
+		resp.Nodes, resp.CompactNodes = nil, compact
+	}
+	return resp, nil
 }
 
 // NodeInfo sends a PING RPC to a node and returns its local info.
@@ -131,12 +166,14 @@ func (srv *Inspector) NodeInfo(ctx context.Context, req *pb.NodeInfoRequest) (*p
 	}, nil
 }
 
-// GetBucketList returns the list of buckets with their routing nodes and their cached nodes
+// GetBucketList returns the list of buckets with their routing nodes and their cached nodes,
+// and the pinned nodes outside of the buckets
 func (srv *Inspector) GetBucketList(ctx context.Context, req *pb.GetBucketListRequest) (*pb.GetBucketListResponse, error) {
 	bucketIds, err := srv.dht.GetBucketIds()
 	if err != nil {
 		return nil, err
 	}
+	sort.Slice(bucketIds, func(i, k int) bool { return bytes.Compare(bucketIds[i], bucketIds[k]) < 0 })
 
 	buckets := make([]*pb.GetBucketListResponse_Bucket, len(bucketIds))
 
@@ -149,12 +186,217 @@ func (srv *Inspector) GetBucketList(ctx context.Context, req *pb.GetBucketListRe
 		cachedNodes := srv.dht.GetCachedNodesWithinKBucket(bucketID)
 		buckets[i] = &pb.GetBucketListResponse_Bucket{
 			BucketId:     keyToBucketID(b),
-			RoutingNodes: routingNodes,
-			CachedNodes:  cachedNodes,
+			RoutingNodes: sortedByID(routingNodes),
+			CachedNodes:  sortedByID(cachedNodes),
 		}
+	}
 
+	var pinnedNodes []*pb.GetBucketListResponse_PinnedNode
+	for _, pinned := range srv.dht.PinnedNodes() {
+		node := pinned.Node
+		pinnedNodes = append(pinnedNodes, &pb.GetBucketListResponse_PinnedNode{
+			Node: &node,
+			Type: pinned.Type,
+		})
 	}
+
 	return &pb.GetBucketListResponse{
-		Buckets: buckets,
+		Buckets:     buckets,
+		PinnedNodes: pinnedNodes,
 	}, nil
 }
+
+// GetContactHistory returns the most recent recorded contacts with a peer, oldest first.
+func (srv *Inspector) GetContactHistory(ctx context.Context, req *pb.GetContactHistoryRequest) (*pb.GetContactHistoryResponse, error) {
+	// the contacts that just ended are recorded
+	if err := srv.dht.bus.Flush(ctx); err != nil {
+		return nil, Error.Wrap(err)
+	}
+	events := srv.dht.dialer.events.History(req.PeerId, int(req.Limit))
+	// events are recorded when they end, an earlier contact can end later
+	sort.SliceStable(events, func(i, k int) bool {
+		a, b := events[i], events[k]
+		switch {
+		case !a.Time.Equal(b.Time):
+			return a.Time.Before(b.Time)
+		case a.Operation != b.Operation:
+			return a.Operation < b.Operation
+		default:
+			return a.Direction < b.Direction
+		}
+	})
+
+	resp := &pb.GetContactHistoryResponse{
+		NodeId:     srv.identity.ID,
+		Provenance: srv.dht.routingTable.Provenance(req.PeerId).String(),
+	}
+	for _, event := range events {
+		at, err := ptypes.TimestampProto(event.Time)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		resp.Events = append(resp.Events, &pb.ContactEvent{
+			Time:       at,
+			Operation:  event.Operation,
+			Direction:  event.Direction,
+			Address:    event.Address,
+			Duration:   ptypes.DurationProto(event.Duration),
+			ErrorClass: event.ErrorClass,
+			Error:      event.Error,
+			ErrorCode:  event.Code,
+		})
+	}
+
+	tenure := srv.dht.routingTable.Tenure()
+	resp.TenureThreshold = ptypes.DurationProto(tenure.Threshold)
+	resp.TenureGrace = int32(tenure.Grace)
+	if history, ok := srv.dht.routingTable.ContactHistory(req.PeerId); ok {
+		firstSeen, err := ptypes.TimestampProto(history.FirstSeen)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		resp.Stats = &pb.ContactStats{
+			FirstSeen:           firstSeen,
+			Successes:           history.Successes,
+			Failures:            history.Failures,
+			ConsecutiveFailures: int32(history.Consecutive),
+			Tenured:             history.tenured(tenure.Threshold, time.Now()),
+			Evicted:             history.Evicted,
+		}
+	}
+	return resp, nil
+}
+
+// LookupWithTrace triggers a Kademlia lookup and returns the trace of the walk,
+// a failed lookup is reported in the response together with its trace.
+func (srv *Inspector) LookupWithTrace(ctx context.Context, req *pb.LookupWithTraceRequest) (*pb.LookupWithTraceResponse, error) {
+	trace, node, lookupErr := srv.dht.TraceLookup(ctx, req.Target)
+
+	start, err := ptypes.TimestampProto(trace.Start)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	resp := &pb.LookupWithTraceResponse{
+		Start:       start,
+		Duration:    ptypes.DurationProto(trace.Duration),
+		DroppedHops: int64(trace.DroppedHops),
+	}
+	if lookupErr != nil {
+		resp.Error = lookupErr.Error()
+	} else {
+		resp.Node = &node
+	}
+
+	hops := append([]LookupHop(nil), trace.Hops...)
+	sort.SliceStable(hops, func(i, k int) bool {
+		if !hops[i].Start.Equal(hops[k].Start) {
+			return hops[i].Start.Before(hops[k].Start)
+		}
+		return hops[i].Peer.Less(hops[k].Peer)
+	})
+	for _, hop := range hops {
+		start, err := ptypes.TimestampProto(hop.Start)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		resp.Hops = append(resp.Hops, &pb.LookupHop{
+			PeerId:       hop.Peer,
+			Address:      hop.Address,
+			ParentId:     hop.Parent,
+			Start:        start,
+			Duration:     ptypes.DurationProto(hop.Duration),
+			Learned:      hop.Learned,
+			LearnedCount: int64(hop.LearnedCount),
+			Error:        hop.Error,
+		})
+	}
+	return resp, nil
+}
+
+// ReloadConfig reads the configuration again and applies the settings that can change while running.
+func (srv *Inspector) ReloadConfig(ctx context.Context, req *pb.ReloadConfigRequest) (*pb.ReloadConfigResponse, error) {
+	if err := srv.dht.ReloadConfig(ctx); err != nil {
+		if ErrImmutableConfig.Has(err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, err
+	}
+	return &pb.ReloadConfigResponse{}, nil
+}
+
+// NetworkSize returns the number of nodes in the network estimated from the routing table.
+func (srv *Inspector) NetworkSize(ctx context.Context, req *pb.NetworkSizeRequest) (*pb.NetworkSizeResponse, error) {
+	return srv.dht.EstimateNetworkSize().Response(), nil
+}
+
+// AddressConflicts returns the nodes recently verified at several addresses, ordered by node ID.
+func (srv *Inspector) AddressConflicts(ctx context.Context, req *pb.AddressConflictsRequest) (*pb.AddressConflictsResponse, error) {
+	resp := &pb.AddressConflictsResponse{}
+	for _, conflict := range srv.dht.routingTable.AddressConflicts() {
+		preferred, err := verifiedAddressProto(conflict.Preferred)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		converted := &pb.AddressConflict{NodeId: conflict.Node, Preferred: preferred}
+		for _, alternate := range conflict.Alternates {
+			alternate, err := verifiedAddressProto(alternate)
+			if err != nil {
+				return nil, Error.Wrap(err)
+			}
+			converted.Alternates = append(converted.Alternates, alternate)
+		}
+		resp.Conflicts = append(resp.Conflicts, converted)
+	}
+	return resp, nil
+}
+
+// RPCPolicies returns the effective policies of the RPCs made by the dialer, sorted by RPC name.
+func (srv *Inspector) RPCPolicies(ctx context.Context, req *pb.RPCPoliciesRequest) (*pb.RPCPoliciesResponse, error) {
+	policies := srv.dht.RPCPolicies()
+	resp := &pb.RPCPoliciesResponse{}
+	for _, rpc := range policies.Names() {
+		policy := policies[rpc]
+		resp.Policies = append(resp.Policies, &pb.RPCPolicy{
+			Rpc:      rpc,
+			Timeout:  ptypes.DurationProto(policy.Timeout),
+			Retries:  int64(policy.Retries),
+			Backoff:  ptypes.DurationProto(policy.Backoff),
+			Priority: policy.Priority.String(),
+		})
+	}
+	return resp, nil
+}
+
+// QuarantinedNodes returns the nodes learned through unverified dials that weren't verified yet, ordered by node ID.
+func (srv *Inspector) QuarantinedNodes(ctx context.Context, req *pb.QuarantinedNodesRequest) (*pb.QuarantinedNodesResponse, error) {
+	resp := &pb.QuarantinedNodesResponse{}
+	for _, quarantined := range srv.dht.routingTable.QuarantinedNodes() {
+		learned, err := ptypes.TimestampProto(quarantined.Learned)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		node := quarantined.Node
+		resp.Nodes = append(resp.Nodes, &pb.QuarantinedNode{Node: &node, Type: quarantined.Type, Learned: learned})
+	}
+	return resp, nil
+}
+
+// verifiedAddressProto converts verified to its protobuf message.
+func verifiedAddressProto(verified VerifiedAddress) (*pb.VerifiedAddress, error) {
+	first, err := ptypes.TimestampProto(verified.FirstVerified)
+	if err != nil {
+		return nil, err
+	}
+	last, err := ptypes.TimestampProto(verified.LastVerified)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.VerifiedAddress{Address: verified.Address, FirstVerified: first, LastVerified: last}, nil
+}
+
+// sortedByID returns a copy of nodes sorted by ID.
+func sortedByID(nodes []*pb.Node) []*pb.Node {
+	sorted := append([]*pb.Node(nil), nodes...)
+	sort.Slice(sorted, func(i, k int) bool { return sorted[i].Id.Less(sorted[k].Id) })
+	return sorted
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/eventlog"
+	"storj.io/storj/pkg/identity"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// inspectorID returns the node ID starting with a and b, which the golden
+// listings show as their hex.
+func inspectorID(a, b byte) storj.NodeID {
+	return storj.NodeID{a, b}
+}
+
+// inspectorBucket returns the ID of the bucket ending at the IDs starting with a.
+func inspectorBucket(a byte) bucketID {
+	var id bucketID
+	for i := range id {
+		id[i] = 0xff
+	}
+	id[0] = a
+	return id
+}
+
+func inspectorName(id storj.NodeID) string {
+	return fmt.Sprintf("%x", id[:2])
+}
+
+func inspectorNames(nodes []*pb.Node) string {
+	var names []string
+	for _, node := range nodes {
+		names = append(names, inspectorName(node.Id))
+	}
+	return strings.Join(names, " ")
+}
+
+// newGoldenInspector returns an inspector of a fixed routing table, whose
+// nodes, cached nodes, pinned nodes and events are added out of order.
+func newGoldenInspector(t *testing.T, start time.Time) (*Inspector, *RoutingTable, *eventlog.Sink) {
+	self := inspectorID(0xc0, 0x00)
+	rt := createRoutingTable(self)
+
+	for _, bound := range []byte{0xbf, 0x3f, 0x7f} {
+		require.NoError(t, rt.createOrUpdateKBucket(inspectorBucket(bound), start))
+	}
+	for _, id := range []storj.NodeID{
+		inspectorID(0x90, 0x01), inspectorID(0x10, 0x02), inspectorID(0xd0, 0x01),
+		inspectorID(0x50, 0x01), inspectorID(0x10, 0x01),
+	} {
+		require.NoError(t, rt.putNode(&pb.Node{Id: id, Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}))
+	}
+	rt.addToReplacementCache(inspectorBucket(0x7f), &pb.Node{Id: inspectorID(0x70, 0x01)})
+	rt.addToReplacementCache(inspectorBucket(0x7f), &pb.Node{Id: inspectorID(0x60, 0x01)})
+
+	_, err := rt.Pin(&pb.Node{Id: inspectorID(0xe0, 0x01)}, pb.NodeType_SATELLITE)
+	require.NoError(t, err)
+	_, err = rt.Pin(&pb.Node{Id: inspectorID(0x20, 0x01)}, pb.NodeType_BOOTSTRAP)
+	require.NoError(t, err)
+
+	events, err := eventlog.Open(zap.NewNop(), eventlog.Config{HistorySize: 100})
+	require.NoError(t, err)
+	// events are recorded when the contacts end, not in the order they started
+	peer := inspectorID(0x10, 0x01)
+	for _, recorded := range []struct {
+		operation string
+		direction string
+		offset    time.Duration
+	}{
+		{"lookup", eventlog.Outgoing, 2 * time.Second},
+		{"ping", eventlog.Outgoing, 0},
+		{"ping", eventlog.Incoming, 2 * time.Second},
+		{"fetch-info", eventlog.Outgoing, 2 * time.Second},
+		{"ping", eventlog.Outgoing, time.Second},
+	} {
+		event := eventlog.NewEvent(recorded.operation, peer, "127.0.0.1:1", start.Add(recorded.offset), nil)
+		event.Direction = recorded.direction
+		events.Record(event)
+	}
+
+	return &Inspector{
+		dht: &Kademlia{
+			routingTable: rt,
+			snapshots:    newSnapshotter(rt.DumpNodes),
+			dialer:       &Dialer{events: events},
+		},
+		identity: &identity.FullIdentity{ID: self},
+	}, rt, events
+}
+
+func TestInspectorGolden(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	start := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	inspector, rt, events := newGoldenInspector(t, start)
+	defer ctx.Check(rt.Close)
+	defer ctx.Check(events.Close)
+
+	t.Run("CountNodes", func(t *testing.T) {
+		resp, err := inspector.CountNodes(ctx, &pb.CountNodesRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), resp.Count)
+	})
+
+	t.Run("GetBuckets", func(t *testing.T) {
+		resp, err := inspector.GetBuckets(ctx, &pb.GetBucketsRequest{})
+		require.NoError(t, err)
+		var ids []string
+		for _, id := range resp.Ids {
+			ids = append(ids, fmt.Sprintf("%x", id[0]))
+		}
+		assert.Equal(t, int64(4), resp.Total)
+		assert.Equal(t, "3f 7f bf ff", strings.Join(ids, " "))
+	})
+
+	t.Run("GetBucketList", func(t *testing.T) {
+		resp, err := inspector.GetBucketList(ctx, &pb.GetBucketListRequest{})
+		require.NoError(t, err)
+
+		var lines []string
+		for _, bucket := range resp.Buckets {
+			lines = append(lines, fmt.Sprintf("%x routing [%s] cached [%s]",
+				bucket.BucketId[0], inspectorNames(bucket.RoutingNodes), inspectorNames(bucket.CachedNodes)))
+		}
+		for _, pinned := range resp.PinnedNodes {
+			lines = append(lines, fmt.Sprintf("pinned %s %s", inspectorName(pinned.Node.Id), pinned.Type))
+		}
+		assert.Equal(t, strings.Join([]string{
+			"3f routing [1001 1002] cached []",
+			"7f routing [5001] cached [6001 7001]",
+			"bf routing [9001] cached []",
+			"ff routing [c000 d001] cached []",
+			"pinned 2001 BOOTSTRAP",
+			"pinned e001 SATELLITE",
+		}, "\n"), strings.Join(lines, "\n"))
+	})
+
+	t.Run("FindNear", func(t *testing.T) {
+		resp, err := inspector.FindNear(ctx, &pb.FindNearRequest{Start: inspectorID(0x50, 0x00), Limit: 4})
+		require.NoError(t, err)
+		assert.Equal(t, "5001 1001 1002 2001", inspectorNames(resp.Nodes))
+	})
+
+	t.Run("DumpNodes", func(t *testing.T) {
+		var (
+			pages      []string
+			generation uint64
+			after      storj.NodeID
+		)
+		for {
+			req := &pb.DumpNodesRequest{Generation: generation, After: after, Limit: 2}
+			resp, err := inspector.DumpNodes(ctx, req)
+			require.NoError(t, err)
+
+			// the same cursor returns the same page
+			again, err := inspector.DumpNodes(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, resp, again)
+
+			pages = append(pages, inspectorNames(resp.Nodes))
+			if !resp.More {
+				break
+			}
+			generation, after = resp.Generation, resp.Nodes[len(resp.Nodes)-1].Id
+		}
+		assert.Equal(t, "1001 1002 | 5001 9001 | c000 d001", strings.Join(pages, " | "))
+	})
+
+	t.Run("GetContactHistory", func(t *testing.T) {
+		resp, err := inspector.GetContactHistory(ctx, &pb.GetContactHistoryRequest{PeerId: inspectorID(0x10, 0x01)})
+		require.NoError(t, err)
+
+		var lines []string
+		for _, event := range resp.Events {
+			lines = append(lines, fmt.Sprintf("+%ds %s %s", event.Time.Seconds-start.Unix(), event.Operation, event.Direction))
+		}
+		assert.Equal(t, strings.Join([]string{
+			"+0s ping " + eventlog.Outgoing,
+			"+1s ping " + eventlog.Outgoing,
+			"+2s fetch-info " + eventlog.Outgoing,
+			"+2s lookup " + eventlog.Outgoing,
+			"+2s ping " + eventlog.Incoming,
+		}, "\n"), strings.Join(lines, "\n"))
+		assert.Nil(t, resp.Stats)
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/overlay"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storage/teststore"
+)
+
+func TestTwoInstancesInOneProcess(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		type instance struct {
+			service *kademlia.Kademlia
+			scope   *monkit.Scope
+		}
+
+		var instances []instance
+		for _, name := range []string{"a", "b"} {
+			log := zaptest.NewLogger(t).Named(name)
+
+			ident, err := testidentity.NewTestIdentity(ctx)
+			require.NoError(t, err)
+
+			self := &overlay.NodeDossier{
+				Node: pb.Node{
+					Id:      ident.ID,
+					Address: &pb.NodeAddress{Transport: pb.NodeTransport_TCP_TLS_GRPC, Address: "127.0.0.1:0"},
+				},
+				Type: pb.NodeType_STORAGE,
+			}
+			rt, err := kademlia.NewRoutingTable(log.Named("routing"), self, teststore.New(), teststore.New(), nil)
+			require.NoError(t, err)
+
+			tlsOptions, err := tlsopts.NewOptions(ident, tlsopts.Config{PeerIDVersions: "*"})
+			require.NoError(t, err)
+
+			service, err := kademlia.NewService(log, transport.NewClient(tlsOptions, rt), rt, nil, kademlia.Config{
+				BootstrapAddr:        planet.Bootstrap.Addr(),
+				BootstrapBackoffMax:  10 * time.Second,
+				BootstrapBackoffBase: time.Second,
+				Alpha:                5,
+				Dialer:               kademlia.DialerOptions{AllowPrivateAddresses: true},
+			})
+			require.NoError(t, err)
+			ctx.Check(service.Close)
+			ctx.Check(rt.Close)
+
+			scope := monkit.NewRegistry().ScopeNamed("kademlia-" + name)
+			service.SetMonitorScope(scope)
+
+			instances = append(instances, instance{service: service, scope: scope})
+		}
+
+		target := planet.StorageNodes[0].Local().Node
+		for _, instance := range instances {
+			require.NoError(t, instance.service.Bootstrap(ctx))
+
+			_, err := instance.service.Ping(ctx, target)
+			require.NoError(t, err)
+
+			peer, err := instance.service.FetchPeerIdentity(ctx, target.Id)
+			require.NoError(t, err)
+			assert.Equal(t, target.Id, peer.ID)
+
+			found, err := instance.service.FindNode(ctx, planet.StorageNodes[1].ID())
+			require.NoError(t, err)
+			assert.Equal(t, planet.StorageNodes[1].ID(), found.Id)
+		}
+
+		// each instance reports to its own scope only
+		for _, instance := range instances {
+			assert.True(t, instance.scope.Counter("dialer_conn_reused").Current() > 0)
+			assert.True(t, instance.scope.FuncNamed(kademlia.RPCPing).Success() > 0)
+		}
+	})
+}
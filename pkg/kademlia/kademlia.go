@@ -12,8 +12,12 @@ import (
 
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
 	"storj.io/storj/internal/sync2"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/overlay"
 	"storj.io/storj/pkg/pb"
@@ -32,6 +36,8 @@ var (
 	// TODO: shouldn't default to TCP but not sure what to do yet
 	defaultTransport = pb.NodeTransport_TCP_TLS_GRPC
 	defaultRetries   = 3
+	// how long Close waits for lookups in progress
+	defaultShutdownTimeout = 5 * time.Second
 )
 
 type discoveryOptions struct {
@@ -39,51 +45,152 @@ type discoveryOptions struct {
 	retries        int
 	bootstrap      bool
 	bootstrapNodes []pb.Node
+	validation     BootstrapValidationConfig
 }
 
 // Kademlia is an implementation of kademlia adhering to the DHT interface.
 type Kademlia struct {
 	log            *zap.Logger
-	alpha          int // alpha is a system wide concurrency parameter
 	routingTable   *RoutingTable
 	bootstrapNodes []pb.Node
 	dialer         *Dialer
+	workers        *WorkerPool
 	lookups        sync2.WorkGroup
 
-	bootstrapFinished    sync2.Fence
-	bootstrapBackoffMax  time.Duration
-	bootstrapBackoffBase time.Duration
+	bootstrapFinished   sync2.Fence
+	warmup              *warmup
+	negative            *negativeCache
+	queries             *queryMemo
+	snapshots           *snapshotter
+	neighborhood        *neighborhoodMonitor
+	bootstrapValidation BootstrapValidationConfig
+	proxies             trustedProxies
+
+	// lifecycle events, see subscribe
+	bus *Bus
+	mon *monkit.Scope
+
+	// serializes reloads with starting the cycles
+	reloadMu   sync.Mutex
+	running    bool
+	loadConfig func() (Config, error)
 
 	refreshThreshold int64
 	RefreshBuckets   sync2.Cycle
+	Neighborhood     sync2.Cycle
+	ConsistencyCheck sync2.Cycle
 
 	mu          sync.Mutex
 	lastPinged  time.Time
 	lastQueried time.Time
+	config      Config
+	tunables    tunables
+	networkSize NetworkSize
 }
 
-// NewService returns a newly configured Kademlia instance
-func NewService(log *zap.Logger, transport transport.Client, rt *RoutingTable, config Config) (*Kademlia, error) {
+// NewService returns a newly configured Kademlia instance, verified peer
+// identities are persisted in identities when it isn't nil.
+func NewService(log *zap.Logger, transport transport.Client, rt *RoutingTable, identities storage.KeyValueStore, config Config) (*Kademlia, error) {
 	k := &Kademlia{
-		log:                  log,
-		alpha:                config.Alpha,
-		routingTable:         rt,
-		bootstrapNodes:       config.BootstrapNodes(),
-		bootstrapBackoffMax:  config.BootstrapBackoffMax,
-		bootstrapBackoffBase: config.BootstrapBackoffBase,
-		dialer:               NewDialer(log.Named("dialer"), transport),
-		refreshThreshold:     int64(time.Minute),
+		log:                 log,
+		routingTable:        rt,
+		bootstrapNodes:      config.BootstrapNodes(),
+		bootstrapValidation: config.BootstrapValidation,
+		refreshThreshold:    int64(time.Minute),
+		mon:                 mon,
+		config:              config,
+		tunables:            newTunables(config),
+	}
+
+	proxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
 	}
+	k.proxies = proxies
+
+	k.workers = NewWorkerPool(log.Named("workers"), rt.Local().Id.String(), config.Workers)
+	cachedIdentities := newIdentityCache(log.Named("identities"), identities, k.workers, config.IdentityCache)
+	if err := cachedIdentities.load(); err != nil {
+		return nil, err
+	}
+
+	policies, err := ParseRPCPolicies(config.Dialer.Policies)
+	if err != nil {
+		return nil, err
+	}
+
+	k.dialer = newDialer(log.Named("dialer"), transport, k.workers, cachedIdentities,
+		WithConnPool(config.ConnPool), WithDialLimit(config.DialConcurrency))
+	k.dialer.setSettings(config.Compression, config.Dialer, policies)
+
+	k.warmup = newWarmup(config.Warmup)
+	k.dialer.warmup = k.warmup
+
+	k.negative = newNegativeCache(config.NegativeCache)
+	k.dialer.negative = k.negative
+	rt.negative = k.negative
+
+	k.dialer.failures = newDialFailures(log.Named("failures"), config.Failures)
+
+	k.queries = newQueryMemo(config.QueryShare, rt.FindNear)
+	k.snapshots = newSnapshotter(rt.DumpNodes)
+
+	k.neighborhood = newNeighborhoodMonitor(config.Neighborhood)
+
+	// the loops of nodes restarting at the same time are spread
+	self := rt.Local().Id.Bytes()
+	k.RefreshBuckets.SetSplay(sync2.NewSplay(self, "kademlia refresh"))
+	k.Neighborhood.SetSplay(sync2.NewSplay(self, "kademlia neighborhood"))
+	k.ConsistencyCheck.SetSplay(sync2.NewSplay(self, "kademlia consistency"))
+
+	k.bus = NewBus()
+	k.dialer.bus = k.bus
+	rt.bus = k.bus
+	k.subscribe()
+
+	rt.budgetCaches(k.negative, k.dialer, k.dialer.identities, k.dialer.failures)
+	k.mon.Chain("caches", monkit.StatSourceFunc(k.cacheStats))
+	k.mon.Chain("dialer", monkit.StatSourceFunc(k.dialer.stats))
 
 	return k, nil
 }
 
 // Close closes all kademlia connections and prevents new ones from being created.
 func (k *Kademlia) Close() error {
-	dialerErr := k.dialer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	return k.Shutdown(ctx)
+}
+
+// Shutdown stops kademlia in order: no new lookups are started, lookups in
+// progress are given until ctx is done to finish using the still open
+// dialer, the closest nodes are told that the node is leaving when
+// LeaveOnShutdown is configured, the routing table writes the lookups caused
+// are flushed, and only then the dialer is closed. Hence everything using
+// kademlia should be closed before it.
+func (k *Kademlia) Shutdown(ctx context.Context) (err error) {
+	defer k.mon.Task()(&ctx)(&err)
+
 	k.lookups.Close()
-	k.lookups.Wait()
-	return dialerErr
+	if !k.lookups.WaitContext(ctx) {
+		k.log.Debug("lookups did not finish before shutdown", zap.Error(ctx.Err()))
+	}
+
+	k.mu.Lock()
+	leave := k.config.LeaveOnShutdown
+	k.mu.Unlock()
+	if leave {
+		k.leave(ctx)
+	}
+
+	if err := k.routingTable.Flush(ctx); err != nil {
+		k.log.Debug("routing table writes did not finish before shutdown", zap.Error(err))
+	}
+
+	err = k.dialer.Shutdown(ctx)
+	// the subscribers handle the events of the contacts that just ended
+	k.bus.Close()
+	return err
 }
 
 // LastPinged returns last time someone pinged this node.
@@ -120,6 +227,12 @@ func (k *Kademlia) FindNear(ctx context.Context, start storj.NodeID, limit int)
 	return k.routingTable.FindNear(start, limit)
 }
 
+// FlushRoutingTable blocks until all queued routing table writes have been
+// applied or ctx is done.
+func (k *Kademlia) FlushRoutingTable(ctx context.Context) error {
+	return k.routingTable.Flush(ctx)
+}
+
 // GetBucketIds returns a storage.Keys type of bucket ID's in the Kademlia instance
 func (k *Kademlia) GetBucketIds() (storage.Keys, error) {
 	return k.routingTable.GetBucketIds()
@@ -144,8 +257,17 @@ func (k *Kademlia) DumpNodes(ctx context.Context) ([]*pb.Node, error) {
 
 // Bootstrap contacts one of a set of pre defined trusted nodes on the network and
 // begins populating the local Kademlia node
-func (k *Kademlia) Bootstrap(ctx context.Context) error {
-	defer k.bootstrapFinished.Release()
+func (k *Kademlia) Bootstrap(ctx context.Context) (err error) {
+	defer func(start time.Time) {
+		k.bootstrapFinished.Release()
+		k.bus.Publish(Event{Type: EventBootstrapFinished, Time: time.Now(), Duration: time.Since(start), Err: err})
+	}(time.Now())
+	defer func() {
+		if err == nil {
+			k.warmup.markReady()
+		}
+	}()
+	ctx = withBootstrapPriority(ctx)
 
 	if !k.lookups.Start() {
 		return context.Canceled
@@ -157,38 +279,72 @@ func (k *Kademlia) Bootstrap(ctx context.Context) error {
 		return nil
 	}
 
-	waitInterval := k.bootstrapBackoffBase
+	settings := k.settings()
+	strategy := bootstrapBackoff{&backoff.Exponential{
+		Base:   settings.bootstrapBackoffBase,
+		Max:    settings.bootstrapBackoffMax,
+		Jitter: 0.5,
+	}}
 
 	var errGroup errs.Group
-	for i := 0; waitInterval < k.bootstrapBackoffMax; i++ {
-		if i > 0 {
-			time.Sleep(waitInterval)
-			waitInterval = waitInterval * 2
-		}
-
+	err = backoff.Retry(ctx, strategy, func(ctx context.Context) error {
 		var foundOnlineBootstrap bool
+		var denied, self int
 		for i, node := range k.bootstrapNodes {
 			if ctx.Err() != nil {
-				errGroup.Add(ctx.Err())
-				return errGroup.Err()
+				return ctx.Err()
 			}
 
-			ident, err := k.dialer.FetchPeerIdentityUnverified(ctx, node.Address.Address)
+			ident, conn, err := k.dialer.FetchPeerIdentityUnverifiedConn(ctx, node.Address.Address)
 			if err != nil {
+				if transport.SelfDial.Has(err) {
+					// such as the bootstrap node bootstrapping against itself
+					self++
+					continue
+				}
+				if transport.PolicyDenied.Has(err) {
+					denied++
+				}
 				errGroup.Add(err)
 				continue
 			}
+			// the lookup below asks the bootstrap node first, it reuses the connection
+			if err := conn.Release(); err != nil {
+				k.log.Debug("could not release bootstrap connection", zap.Error(err))
+			}
 
 			k.routingTable.mutex.Lock()
+			previous := k.bootstrapNodes[i].Id
 			node.Id = ident.ID
 			k.bootstrapNodes[i] = node
 			k.routingTable.mutex.Unlock()
 			foundOnlineBootstrap = true
+
+			// the address is served by a different node now
+			if !previous.IsZero() && previous != node.Id {
+				k.routingTable.Unpin(previous)
+				k.routingTable.unquarantine(previous)
+			}
+
+			// any node could have answered at the address, the bootstrap
+			// node is pinned once a verified dial reaches it
+			if err := k.routingTable.Quarantine(&node, pb.NodeType_BOOTSTRAP); err != nil {
+				k.log.Debug("could not quarantine bootstrap node", zap.Stringer("node", node.Id), zap.Error(err))
+			}
 		}
 
 		if !foundOnlineBootstrap {
-			errGroup.Add(Error.New("no bootstrap node found online"))
-			continue
+			if self == len(k.bootstrapNodes) {
+				k.log.Info("this node is the only bootstrap node")
+				return nil
+			}
+			// retrying doesn't help when the egress policy denies every bootstrap node
+			if denied == len(k.bootstrapNodes) {
+				return backoff.Permanent.New("egress policy denies every bootstrap node")
+			}
+			err := Error.New("no bootstrap node found online")
+			errGroup.Add(err)
+			return err
 		}
 
 		//find nodes most similar to self
@@ -198,8 +354,16 @@ func (k *Kademlia) Bootstrap(ctx context.Context) error {
 		_, err := k.lookup(ctx, id, true)
 		if err != nil {
 			errGroup.Add(err)
-			continue
+			return err
+		}
+		// the bootstrap nodes were only reached by address so far
+		var unverified []*pb.Node
+		for _, quarantined := range k.routingTable.QuarantinedNodes() {
+			node := quarantined.Node
+			unverified = append(unverified, &node)
 		}
+		k.verifyNodes(ctx, unverified)
+		k.recordRoutingTableSize()
 		return nil
 		// TODO(dylan): We do not currently handle this last bit of behavior.
 		// ```
@@ -207,24 +371,113 @@ func (k *Kademlia) Bootstrap(ctx context.Context) error {
 		// During the refreshes, u both populates its own k-buckets and inserts
 		// itself into other nodes' k-buckets as necessary.
 		// ```
+	})
+	if err == nil {
+		return nil
 	}
 
-	errGroup.Add(Error.New("unable to start bootstrap after final wait time of %s", waitInterval))
+	switch {
+	case ctx.Err() != nil:
+		errGroup.Add(ctx.Err())
+	case backoff.Permanent.Has(err):
+		errGroup.Add(err)
+	default:
+		errGroup.Add(Error.New("unable to start bootstrap before the wait time reached %s", settings.bootstrapBackoffMax))
+	}
 	return errGroup.Err()
 }
 
+// bootstrapBackoff doubles the wait between bootstrap attempts, and gives up
+// once the wait would reach the maximum.
+type bootstrapBackoff struct {
+	*backoff.Exponential
+}
+
+// Delay implements backoff.Strategy.
+func (strategy bootstrapBackoff) Delay(failures int, elapsed time.Duration) (time.Duration, bool) {
+	if strategy.Ceiling(failures) >= strategy.Max {
+		return 0, false
+	}
+	return strategy.Exponential.Delay(failures, elapsed)
+}
+
+// WarmingUp returns whether queries from other nodes are still limited in favor of bootstrapping.
+func (k *Kademlia) WarmingUp() bool {
+	return k.warmup.active()
+}
+
+// Workers returns the worker pool running the goroutines of kademlia.
+func (k *Kademlia) Workers() *WorkerPool { return k.workers }
+
+// NextRuns returns when the periodic loops of kademlia run next, see
+// sync2.NextRuns.
+func (k *Kademlia) NextRuns() map[string]time.Time {
+	return map[string]time.Time{
+		"kademlia refresh":      k.RefreshBuckets.NextRun(),
+		"kademlia neighborhood": k.Neighborhood.NextRun(),
+		"kademlia consistency":  k.ConsistencyCheck.NextRun(),
+	}
+}
+
+// Dialer returns the dialer kademlia contacts other nodes with.
+func (k *Kademlia) Dialer() *Dialer { return k.dialer }
+
+// NegativeCacheStats returns the counters of the cache of nodes lookups recently didn't find.
+func (k *Kademlia) NegativeCacheStats() NegativeCacheStats { return k.negative.stats() }
+
+// Events returns the bus kademlia publishes its lifecycle events to.
+func (k *Kademlia) Events() *Bus { return k.bus }
+
+// SetEventSink records the contacts made by kademlia, the address changes of
+// nodes in the routing table and the changes of the neighborhood to events.
+// It must be called before the service is used.
+func (k *Kademlia) SetEventSink(events *eventlog.Sink) {
+	k.dialer.events = events
+	k.bus.Subscribe("eventlog", defaultSubscriptionSize, func(event Event) {
+		recordEvent(events, event)
+	})
+}
+
+// LoadDialFailures persists the dial failure statistics in db, starting with
+// the statistics persisted before, so that peers unreachable before a restart
+// are still skipped by background dials.
+func (k *Kademlia) LoadDialFailures(db storage.KeyValueStore) error {
+	return k.dialer.failures.load(db, k.workers)
+}
+
+// SetMonitorScope reports the metrics of this instance, including its dialer
+// and routing table, to scope instead of the package scope, so that several
+// instances in one process can be told apart. It must be called before the
+// service is used. Function tasks are still reported to the package scope.
+func (k *Kademlia) SetMonitorScope(scope *monkit.Scope) {
+	k.mon = scope
+	k.dialer.SetMonitorScope(scope)
+	k.routingTable.mon = scope
+	k.negative.setMonitorScope(scope)
+	k.queries.mon = scope
+	scope.Chain("caches", monkit.StatSourceFunc(k.cacheStats))
+}
+
 // WaitForBootstrap waits for bootstrap pinging has been completed.
 func (k *Kademlia) WaitForBootstrap() {
 	k.bootstrapFinished.Wait()
 }
 
+// WaitForBootstrapContext waits for bootstrap pinging has been completed or ctx to be done.
+func (k *Kademlia) WaitForBootstrapContext(ctx context.Context) error {
+	if !k.bootstrapFinished.WaitContext(ctx) {
+		return ctx.Err()
+	}
+	return nil
+}
+
 // FetchPeerIdentity connects to a node and returns its peer identity
 func (k *Kademlia) FetchPeerIdentity(ctx context.Context, nodeID storj.NodeID) (*identity.PeerIdentity, error) {
 	if !k.lookups.Start() {
 		return nil, context.Canceled
 	}
 	defer k.lookups.Done()
-	node, err := k.FindNode(ctx, nodeID)
+	node, err := k.FindNode(WithFreshLookup(ctx), nodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -248,6 +501,23 @@ func (k *Kademlia) Ping(ctx context.Context, node pb.Node) (pb.Node, error) {
 	return node, nil
 }
 
+// CheckIn announces the local node to target, which in turn pings back the
+// local node to verify that it is reachable. It returns the hints of target,
+// nil when it has none.
+func (k *Kademlia) CheckIn(ctx context.Context, target pb.Node) (*pb.ContactHints, error) {
+	if !k.lookups.Start() {
+		return nil, context.Canceled
+	}
+	defer k.lookups.Done()
+
+	self := k.routingTable.Local().Node
+	resp, err := k.dialer.query(ctx, self, target, self, LookupFilter{})
+	if err != nil {
+		return nil, NodeErr.Wrap(err)
+	}
+	return resp.Hints, nil
+}
+
 // FetchInfo connects to a node address and returns the node info
 func (k *Kademlia) FetchInfo(ctx context.Context, node pb.Node) (*pb.InfoResponse, error) {
 	if !k.lookups.Start() {
@@ -262,8 +532,28 @@ func (k *Kademlia) FetchInfo(ctx context.Context, node pb.Node) (*pb.InfoRespons
 	return info, nil
 }
 
-// FindNode looks up the provided NodeID first in the local Node, and if it is not found
-// begins searching the network for the NodeID. Returns and error if node was not found
+// Pin keeps node out of the buckets when it is a satellite or bootstrap node,
+// which are reachable through configuration and shouldn't crowd out storage nodes.
+// The type must be confirmed by configuration, such as the trusted satellites.
+func (k *Kademlia) Pin(node pb.Node, nodeType pb.NodeType) error {
+	_, err := k.routingTable.Pin(&node, nodeType)
+	return err
+}
+
+// Unpin lets the node with id compete for bucket slots again, when it is no
+// longer confirmed by configuration.
+func (k *Kademlia) Unpin(id storj.NodeID) {
+	k.routingTable.Unpin(id)
+}
+
+// PinnedNodes returns the satellites and bootstrap nodes kept outside of the buckets.
+func (k *Kademlia) PinnedNodes() []PinnedNode {
+	return k.routingTable.PinnedNodes()
+}
+
+// FindNode searches the network for the current address of the provided NodeID, stopping
+// as soon as a peer returns it. The address in the local routing table is only used when
+// no peer knows the node. Returns NodeNotFound if node was not found
 func (k *Kademlia) FindNode(ctx context.Context, ID storj.NodeID) (pb.Node, error) {
 	if !k.lookups.Start() {
 		return pb.Node{}, context.Canceled
@@ -287,6 +577,10 @@ func (k *Kademlia) lookup(ctx context.Context, ID storj.NodeID, isBootstrap bool
 			nodes = append(nodes, &v)
 		}
 	} else {
+		if !isFreshLookup(ctx) && !isRandomTarget(ctx) && k.negative.notFound(ID, time.Now()) {
+			return pb.Node{}, NodeNotFound.New("%s was recently not found", ID)
+		}
+
 		var err error
 		nodes, err = k.routingTable.FindNear(ID, kb)
 		if err != nil {
@@ -294,28 +588,59 @@ func (k *Kademlia) lookup(ctx context.Context, ID storj.NodeID, isBootstrap bool
 		}
 	}
 	lookup := newPeerDiscovery(k.log, k.routingTable.Local().Node, nodes, k.dialer, ID, discoveryOptions{
-		concurrency: k.alpha, retries: defaultRetries, bootstrap: isBootstrap, bootstrapNodes: k.bootstrapNodes,
+		concurrency: k.settings().alpha, retries: defaultRetries, bootstrap: isBootstrap, bootstrapNodes: k.bootstrapNodes,
+		validation: k.bootstrapValidation,
 	})
-	target, err := lookup.Run(ctx)
-	if err != nil {
-		return pb.Node{}, err
+	var target pb.Node
+	var err error
+	if isBootstrap {
+		if err := lookup.Run(ctx); err != nil {
+			return pb.Node{}, err
+		}
+		k.verifyNodes(ctx, lookup.Deferred())
+	} else {
+		target, err = lookup.FindNode(ctx)
+		if err != nil && !NodeNotFound.Has(err) {
+			return pb.Node{}, err
+		}
+		switch {
+		case isRandomTarget(ctx):
+			// random IDs are unlikely to be looked up again
+		case NodeNotFound.Has(err):
+			k.negative.add(ID, time.Now())
+		default:
+			k.negative.seenID(ID)
+		}
 	}
-	bucket, err := k.routingTable.getKBucketID(ID)
-	if err != nil {
+
+	bucket, bucketErr := k.routingTable.getKBucketID(ID)
+	if bucketErr != nil {
 		k.log.Warn("Error getting getKBucketID in kad lookup")
 	} else {
-		err = k.routingTable.SetBucketTimestamp(bucket[:], time.Now())
-		if err != nil {
+		bucketErr = k.routingTable.SetBucketTimestamp(bucket[:], time.Now())
+		if bucketErr != nil {
 			k.log.Warn("Error updating bucket timestamp in kad lookup")
 		}
 	}
-	if target == nil {
-		if isBootstrap {
-			return pb.Node{}, nil
+	return target, err
+}
+
+// verifyNodes pings nodes, the routing table adds the ones that respond with the expected identity.
+func (k *Kademlia) verifyNodes(ctx context.Context, nodes []*pb.Node) {
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		node := *node
+		wg.Add(1)
+		if !k.workers.Go("verify-bootstrap-node", func() {
+			defer wg.Done()
+			if _, err := k.dialer.PingNode(ctx, node); err != nil {
+				k.log.Debug("unable to verify bootstrap node", zap.Stringer("Node ID", node.Id), zap.Error(err))
+			}
+		}) {
+			wg.Done()
 		}
-		return pb.Node{}, NodeNotFound.New("")
 	}
-	return *target, nil
+	wg.Wait()
 }
 
 // Seen returns all nodes that this kademlia instance has successfully communicated with
@@ -336,7 +661,9 @@ func (k *Kademlia) GetNodesWithinKBucket(bID bucketID) ([]*pb.Node, error) {
 
 // GetCachedNodesWithinKBucket returns all the cached nodes in the specified k-bucket
 func (k *Kademlia) GetCachedNodesWithinKBucket(bID bucketID) []*pb.Node {
-	return k.routingTable.replacementCache[bID]
+	k.routingTable.rcMutex.Lock()
+	defer k.routingTable.rcMutex.Unlock()
+	return append([]*pb.Node(nil), k.routingTable.replacementCache[bID]...)
 }
 
 // SetBucketRefreshThreshold changes the threshold when buckets are considered stale and need refreshing.
@@ -344,26 +671,60 @@ func (k *Kademlia) SetBucketRefreshThreshold(threshold time.Duration) {
 	atomic.StoreInt64(&k.refreshThreshold, int64(threshold))
 }
 
-// Run occasionally refreshes stale kad buckets
+// Run occasionally refreshes stale kad buckets, checks the neighborhood of
+// self and, when configured, the consistency of the routing table
 func (k *Kademlia) Run(ctx context.Context) error {
 	if !k.lookups.Start() {
 		return context.Canceled
 	}
 	defer k.lookups.Done()
 
-	k.RefreshBuckets.SetInterval(5 * time.Minute)
-	return k.RefreshBuckets.Run(ctx, func(ctx context.Context) error {
+	var group errgroup.Group
+	k.reloadMu.Lock()
+	settings := k.settings()
+	k.RefreshBuckets.SetInterval(settings.refreshInterval)
+	k.RefreshBuckets.Start(ctx, &group, func(ctx context.Context) error {
 		threshold := time.Duration(atomic.LoadInt64(&k.refreshThreshold))
 		err := k.refresh(ctx, threshold)
 		if err != nil {
 			k.log.Warn("bucket refresh failed", zap.Error(err))
 		}
+		k.updateNetworkSize()
 		return nil
 	})
+	if k.neighborhood != nil {
+		k.Neighborhood.SetInterval(settings.neighborhoodInterval)
+		k.Neighborhood.Start(ctx, &group, func(ctx context.Context) error {
+			err := k.checkNeighborhood(ctx)
+			if err != nil {
+				k.log.Warn("neighborhood check failed", zap.Error(err))
+			}
+			return nil
+		})
+	}
+	k.mu.Lock()
+	consistencyInterval := k.config.ConsistencyCheck
+	k.mu.Unlock()
+	if consistencyInterval > 0 {
+		k.ConsistencyCheck.SetInterval(consistencyInterval)
+		k.ConsistencyCheck.Start(ctx, &group, func(ctx context.Context) error {
+			if err := k.CheckConsistency(); err != nil {
+				k.log.Warn("routing table consistency check failed", zap.Error(err))
+			}
+			return nil
+		})
+	}
+	k.running = true
+	k.reloadMu.Unlock()
+	return group.Wait()
 }
 
 // refresh updates each Kademlia bucket not contacted in the last hour
-func (k *Kademlia) refresh(ctx context.Context, threshold time.Duration) error {
+func (k *Kademlia) refresh(ctx context.Context, threshold time.Duration) (err error) {
+	defer k.mon.TaskNamed("refresh")(&ctx)(&err)
+	defer k.recordRoutingTableSize()
+	ctx = WithDialPriority(ctx, BackgroundDial)
+
 	bIDs, err := k.routingTable.GetBucketIds()
 	if err != nil {
 		return Error.Wrap(err)
@@ -378,13 +739,22 @@ func (k *Kademlia) refresh(ctx context.Context, threshold time.Duration) error {
 			errors.Add(tErr)
 		} else if now.After(ts.Add(threshold)) {
 			rID, _ := randomIDInRange(startID, endID)
-			_, _ = k.FindNode(ctx, rID) // ignore node not found
+			_, _ = k.FindNode(WithRandomTarget(ctx), rID) // ignore node not found
 		}
 		startID = endID
 	}
 	return Error.Wrap(errors.Err())
 }
 
+// recordRoutingTableSize reports the number of nodes in the routing table
+func (k *Kademlia) recordRoutingTableSize() {
+	count, err := k.routingTable.countNodes()
+	if err != nil {
+		return
+	}
+	k.mon.IntVal("routing_table_size").Observe(int64(count))
+}
+
 // randomIDInRange finds a random node ID with a range (start..end]
 func randomIDInRange(start, end bucketID) (storj.NodeID, error) {
 	randID := storj.NodeID{}
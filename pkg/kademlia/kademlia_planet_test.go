@@ -4,26 +4,42 @@
 package kademlia_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
 	"storj.io/storj/internal/errs2"
 	"storj.io/storj/internal/memory"
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/storj"
 	"storj.io/storj/pkg/transport"
 	"storj.io/storj/satellite"
 	"storj.io/storj/storagenode"
@@ -56,6 +72,173 @@ func TestRequestInfo(t *testing.T) {
 	})
 }
 
+func TestShutdown(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		service := planet.StorageNodes[0].Kademlia.Service
+
+		_, err := service.FindNode(ctx, planet.Satellites[0].ID())
+		require.NoError(t, err)
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, service.Shutdown(shutdownCtx))
+
+		// no new lookups after shutdown
+		_, err = service.FindNode(ctx, planet.Satellites[0].ID())
+		require.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestQueryForgedSender(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 2, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sender, receiver := planet.StorageNodes[0], planet.StorageNodes[1]
+
+		target := receiver.Local().Node
+		conn, err := sender.Transport.DialNode(ctx, &target)
+		require.NoError(t, err)
+		defer ctx.Check(conn.Close)
+		client := pb.NewNodesClient(conn)
+
+		forged := sender.Local().Node
+		forged.Id = teststorj.NodeIDFromString("forged")
+
+		_, err = client.Query(ctx, &pb.QueryRequest{
+			Sender:   &forged,
+			Target:   &target,
+			Limit:    20,
+			Pingback: true,
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, kademlia.ErrorCodeSenderMismatch, kademlia.ErrorCode(err))
+
+		nodes, err := receiver.Kademlia.RoutingTable.DumpNodes()
+		require.NoError(t, err)
+		for _, node := range nodes {
+			require.NotEqual(t, forged.Id, node.Id)
+		}
+
+		// the real identity is still accepted
+		self := sender.Local().Node
+		_, err = client.Query(ctx, &pb.QueryRequest{
+			Sender: &self,
+			Target: &target,
+			Limit:  20,
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestEventLog(t *testing.T) {
+	var path string
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 2, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				if index == 0 {
+					path = filepath.Join(config.Kademlia.DBPath, "events.jsonl")
+					config.Events = eventlog.Config{Path: path, MaxSize: memory.MiB, QueueSize: memory.MiB}
+				}
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		node := planet.StorageNodes[0]
+		target := planet.StorageNodes[1].Local().Node
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		offline := pb.Node{
+			Id:      teststorj.NodeIDFromString("offline"),
+			Address: &pb.NodeAddress{Address: listener.Addr().String()},
+		}
+		require.NoError(t, listener.Close())
+
+		// events from starting the planet are ignored
+		start := time.Now()
+
+		const pings, infos = 5, 3
+		for i := 0; i < pings; i++ {
+			_, err := node.Kademlia.Service.Ping(ctx, target)
+			require.NoError(t, err)
+		}
+		for i := 0; i < infos; i++ {
+			_, err := node.Kademlia.Service.FetchInfo(ctx, target)
+			require.NoError(t, err)
+		}
+		_, err = node.Kademlia.Service.Ping(ctx, offline)
+		require.Error(t, err)
+
+		// closing the node flushes the events
+		require.NoError(t, planet.StopPeer(node))
+
+		events, err := eventlog.ReadFile(path)
+		require.NoError(t, err)
+
+		counts := map[string]int{}
+		for _, event := range events {
+			if event.Time.Before(start) {
+				continue
+			}
+			switch event.PeerID {
+			case target.Id.String():
+				require.Empty(t, event.ErrorClass, event.Error)
+				require.Equal(t, target.Address.Address, event.Address)
+				counts[event.Operation]++
+			case offline.Id.String():
+				require.Equal(t, "refused", event.ErrorClass, event.Error)
+				counts["offline "+event.Operation]++
+			}
+		}
+		require.Equal(t, map[string]int{
+			"ping":              pings,
+			"fetch-info":        infos,
+			"dial-node":         pings + infos,
+			"offline ping":      1,
+			"offline dial-node": 1,
+		}, counts)
+	})
+}
+
+func TestBootstrapReusesConnection(t *testing.T) {
+	var path string
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 0, StorageNodeCount: 2, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				if index == 0 {
+					path = filepath.Join(config.Kademlia.DBPath, "events.jsonl")
+					config.Events = eventlog.Config{Path: path, MaxSize: memory.MiB, QueueSize: memory.MiB}
+				}
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		node := planet.StorageNodes[0]
+		node.Kademlia.Service.WaitForBootstrap()
+		bootstrapped := time.Now()
+
+		// closing the node flushes the events
+		require.NoError(t, planet.StopPeer(node))
+
+		events, err := eventlog.ReadFile(path)
+		require.NoError(t, err)
+
+		// fetching the identity and the first query share a single dial
+		dials := map[string]int{}
+		for _, event := range events {
+			if event.Time.After(bootstrapped) || event.Address != planet.Bootstrap.Addr() {
+				continue
+			}
+			if strings.HasPrefix(event.Operation, "dial-") {
+				dials[event.Operation]++
+			}
+		}
+		require.Equal(t, map[string]int{"dial-address": 1}, dials)
+	})
+}
+
 func TestPingTimeout(t *testing.T) {
 	testplanet.Run(t, testplanet.Config{
 		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
@@ -77,7 +260,9 @@ func TestPingTimeout(t *testing.T) {
 		slowClient := network.NewClient(self.Transport)
 		require.NotNil(t, slowClient)
 
-		newService, err := kademlia.NewService(zaptest.NewLogger(t), slowClient, routingTable, kademlia.Config{})
+		newService, err := kademlia.NewService(zaptest.NewLogger(t), slowClient, routingTable, nil, kademlia.Config{
+			Dialer: kademlia.DialerOptions{AllowPrivateAddresses: true},
+		})
 		require.NoError(t, err)
 
 		target := pb.Node{
@@ -232,3 +417,477 @@ func (proxy *badProxy) run(ctx context.Context) error {
 	})
 	return errs.Wrap(group.Wait())
 }
+
+func TestCheckContact(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat, node := planet.Satellites[0], planet.StorageNodes[0]
+
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), node.Transport)
+		defer ctx.Check(dialer.Close)
+
+		check := func(target storj.NodeURL) (failed string, stages []kademlia.ContactStage) {
+			stages = kademlia.CheckContact(ctx, dialer, target, time.Second)
+			require.Len(t, stages, len(kademlia.ContactStages))
+			for _, stage := range stages {
+				if !stage.Passed && !stage.Skipped {
+					require.Empty(t, failed)
+					require.NotEmpty(t, stage.Cause)
+					failed = stage.Name
+				}
+			}
+			return failed, stages
+		}
+
+		failed, stages := check(storj.NodeURL{ID: sat.ID(), Address: sat.Addr()})
+		require.Empty(t, failed)
+		require.Equal(t, sat.ID().String(), stages[2].Detail)
+		require.Equal(t, sat.Local().Version.Version, stages[4].Detail)
+
+		failed, _ = check(storj.NodeURL{Address: sat.Addr()})
+		require.Empty(t, failed)
+
+		failed, stages = check(storj.NodeURL{ID: node.ID(), Address: sat.Addr()})
+		require.Equal(t, "tls", failed)
+		require.Contains(t, stages[2].Cause, "identity mismatch")
+		require.True(t, stages[3].Skipped && stages[4].Skipped)
+
+		// TEST-NET-1 addresses are never routed
+		failed, _ = check(storj.NodeURL{ID: sat.ID(), Address: "192.0.2.1:7777"})
+		require.Equal(t, "tcp", failed)
+	})
+}
+
+func TestMalformedQuery(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+
+		satNode := sat.Local().Node
+		conn, err := planet.StorageNodes[0].Transport.DialNode(ctx, &satNode)
+		require.NoError(t, err)
+		defer ctx.Check(conn.Close)
+		client := pb.NewNodesClient(conn)
+
+		target := &pb.Node{Id: teststorj.NodeIDFromString("target")}
+		for _, req := range []*pb.QueryRequest{
+			{Limit: 5},
+			{Limit: 5, Target: target, Pingback: true},
+		} {
+			_, err := client.Query(ctx, req)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), "%v", err)
+			require.Equal(t, kademlia.ErrorCodeInvalidQuery, kademlia.ErrorCode(err))
+
+			stream, err := client.QueryStream(ctx, req)
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			require.Equal(t, codes.InvalidArgument, status.Code(err), "%v", err)
+			require.Equal(t, kademlia.ErrorCodeInvalidQuery, kademlia.ErrorCode(err))
+		}
+
+		// well formed queries still work on the same connection
+		_, err = client.Query(ctx, &pb.QueryRequest{Limit: 5, Target: target})
+		require.NoError(t, err)
+	})
+}
+
+func TestProtocolVersion(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat, node := planet.Satellites[0], planet.StorageNodes[0]
+
+		satNode := sat.Local().Node
+
+		// old peers dial without the interceptors sending the protocol version
+		legacyConn, err := node.Transport.DialNode(ctx, &satNode,
+			grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}),
+			grpc.WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return streamer(ctx, desc, cc, method, opts...)
+			}),
+		)
+		require.NoError(t, err)
+		defer ctx.Check(legacyConn.Close)
+		legacy := pb.NewNodesClient(legacyConn)
+
+		// peers without a protocol version are rejected by streaming queries
+		req := &pb.QueryRequest{Limit: 5, Target: &pb.Node{Id: teststorj.NodeIDFromString("target")}}
+		stream, err := legacy.QueryStream(ctx, req)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.FailedPrecondition, status.Code(err), "%v", err)
+		require.Equal(t, "QueryStream requires protocol version 1 or newer, peer uses 0", status.Convert(err).Message())
+		require.Equal(t, kademlia.ErrorCodeProtocolTooOld, kademlia.ErrorCode(err))
+
+		// but can still ping and query, learning the protocol of the satellite
+		var header metadata.MD
+		_, err = legacy.Ping(ctx, &pb.PingRequest{}, grpc.Header(&header))
+		require.NoError(t, err)
+		require.Equal(t, version.CurrentProtocol, version.ProtocolFromMetadata(header))
+
+		_, err = legacy.Query(ctx, req)
+		require.NoError(t, err)
+
+		// the transport sends the protocol version with every request
+		conn, err := node.Transport.DialNode(ctx, &satNode)
+		require.NoError(t, err)
+		defer ctx.Check(conn.Close)
+
+		stream, err = pb.NewNodesClient(conn).QueryStream(ctx, req)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.NoError(t, err)
+
+		// dialers send and remember protocol versions
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), node.Transport)
+		defer ctx.Check(dialer.Close)
+
+		ok, err := dialer.PingNode(ctx, satNode)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		protocol, known := dialer.Protocol(sat.ID())
+		require.True(t, known)
+		require.Equal(t, version.CurrentProtocol, protocol)
+	})
+}
+
+func TestMemoryRoutingTable(t *testing.T) {
+	var dbPath string
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.RoutingTable.Store = satellite.RoutingTableMemory
+				config.Kademlia.DBPath = filepath.Join(config.Kademlia.DBPath, "unused")
+				dbPath = config.Kademlia.DBPath
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+
+		nodes, err := sat.Kademlia.RoutingTable.DumpNodes()
+		require.NoError(t, err)
+		require.True(t, len(nodes) > 1, "routing table should contain more than self")
+
+		for _, node := range planet.StorageNodes {
+			found, err := sat.Kademlia.Service.FindNode(ctx, node.ID())
+			require.NoError(t, err)
+			require.Equal(t, node.ID(), found.Id)
+		}
+
+		// nothing is written to disk
+		_, err = os.Stat(dbPath)
+		require.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestContactHistory(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Events.HistorySize = 1000
+			},
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Events.HistorySize = 1000
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat, node := planet.Satellites[0], planet.StorageNodes[0]
+		start := time.Now()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		refusing := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		_, err = sat.Kademlia.Service.Ping(ctx, node.Local().Node)
+		require.NoError(t, err)
+		_, err = node.Kademlia.Service.FetchInfo(ctx, sat.Local().Node)
+		require.NoError(t, err)
+		_, err = sat.Kademlia.Service.Ping(ctx, pb.Node{Id: node.ID(), Address: &pb.NodeAddress{Address: refusing}})
+		require.Error(t, err)
+		_, err = sat.Kademlia.Service.Ping(ctx, node.Local().Node)
+		require.NoError(t, err)
+
+		// history returns the contacts made during the test as "direction operation class"
+		history := func(inspector *kademlia.Inspector, peer storj.NodeID) []string {
+			resp, err := inspector.GetContactHistory(ctx, &pb.GetContactHistoryRequest{PeerId: peer})
+			require.NoError(t, err)
+
+			var contacts []string
+			for _, event := range resp.Events {
+				at, err := ptypes.Timestamp(event.Time)
+				require.NoError(t, err)
+				if at.Before(start) || strings.HasPrefix(event.Operation, "dial-") {
+					continue
+				}
+				contacts = append(contacts, strings.TrimSpace(event.Direction+" "+event.Operation+" "+event.ErrorClass))
+			}
+			return contacts
+		}
+
+		require.Equal(t, []string{
+			"outgoing ping",
+			"incoming fetch-info",
+			"outgoing ping refused",
+			"outgoing ping",
+		}, history(sat.Kademlia.Inspector, node.ID()))
+
+		require.Equal(t, []string{
+			"incoming ping",
+			"outgoing fetch-info",
+			"incoming ping",
+		}, history(node.Kademlia.Inspector, sat.ID()))
+
+		resp, err := sat.Kademlia.Inspector.GetContactHistory(ctx, &pb.GetContactHistoryRequest{PeerId: node.ID(), Limit: 1})
+		require.NoError(t, err)
+		require.Equal(t, sat.ID(), resp.NodeId)
+		require.Len(t, resp.Events, 1)
+	})
+}
+
+func TestLookupWithTrace(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 6, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Events.HistorySize = 1000
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		node, target := planet.StorageNodes[0], planet.StorageNodes[5]
+
+		// the check-in with the satellite looks it up, wait for it to not mix up the lookups
+		sat := planet.Satellites[0].ID()
+		for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+			if status, ok := node.Contact.Service.Status(sat); ok && !status.LastSuccess.IsZero() {
+				break
+			}
+			require.True(t, time.Now().Before(deadline), "no check-in with the satellite")
+		}
+
+		start := time.Now()
+		resp, err := node.Kademlia.Inspector.LookupWithTrace(ctx, &pb.LookupWithTraceRequest{Target: target.ID()})
+		wall := time.Since(start)
+		require.NoError(t, err)
+		require.Empty(t, resp.Error)
+		require.Equal(t, target.ID(), resp.Node.Id)
+
+		duration, err := ptypes.Duration(resp.Duration)
+		require.NoError(t, err)
+		require.True(t, duration <= wall, "trace %v longer than the lookup %v", duration, wall)
+
+		// the hops run with at most alpha concurrent requests, as configured by testplanet
+		const alpha = 5
+		var sum time.Duration
+		hops := map[storj.NodeID]bool{}
+		for _, hop := range resp.Hops {
+			hopDuration, err := ptypes.Duration(hop.Duration)
+			require.NoError(t, err)
+			require.True(t, hopDuration <= duration, "hop %v longer than the lookup %v", hopDuration, duration)
+			sum += hopDuration
+			hops[hop.PeerId] = true
+		}
+		require.NotEmpty(t, hops)
+		require.True(t, sum <= alpha*duration+time.Millisecond, "hops took %v in %v", sum, duration)
+
+		// every peer asked by the lookup is in the trace
+		contacted := map[storj.NodeID]bool{}
+		peers := []storj.NodeID{sat, planet.Bootstrap.ID()}
+		for _, peer := range planet.StorageNodes[1:] {
+			peers = append(peers, peer.ID())
+		}
+		for _, peer := range peers {
+			history, err := node.Kademlia.Inspector.GetContactHistory(ctx, &pb.GetContactHistoryRequest{PeerId: peer})
+			require.NoError(t, err)
+			for _, event := range history.Events {
+				at, err := ptypes.Timestamp(event.Time)
+				require.NoError(t, err)
+				if !at.Before(start) && event.Direction == eventlog.Outgoing && event.Operation == "lookup" {
+					contacted[peer] = true
+				}
+			}
+		}
+		require.Equal(t, contacted, hops)
+	})
+}
+
+func TestWorkerPoolBounded(t *testing.T) {
+	const workers = 8
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 6, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Kademlia.Workers = workers
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat := planet.Satellites[0]
+
+		var load errgroup.Group
+		for i := 0; i < 32; i++ {
+			i := i
+			load.Go(func() error {
+				for k := 0; k < 4; k++ {
+					target := planet.StorageNodes[(i+k)%len(planet.StorageNodes)].ID()
+					if _, err := sat.Kademlia.Service.FindNode(ctx, target); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+
+		loaded := make(chan error, 1)
+		go func() { loaded <- load.Wait() }()
+
+		most := 0
+		for done := false; !done; {
+			select {
+			case err := <-loaded:
+				require.NoError(t, err)
+				done = true
+			case <-time.After(time.Millisecond):
+			}
+			if count := countLabeled(t, sat.ID()); count > most {
+				most = count
+			}
+		}
+		// the 32 concurrent lookups would start hundreds of goroutines if
+		// they weren't bounded by the workers
+		require.True(t, most > 0)
+		require.True(t, most <= workers*(1+goroutinesPerJob), "%d goroutines", most)
+	})
+}
+
+// goroutinesPerJob is the most goroutines a kademlia job starts, such as the
+// ones serving the connection of a dial, which inherit the labels of the job.
+const goroutinesPerJob = 8
+
+// countLabeled returns the number of goroutines labeled as kademlia work of
+// the node with id, that is the workers and the goroutines started by jobs.
+func countLabeled(t *testing.T, id storj.NodeID) int {
+	var profile bytes.Buffer
+	require.NoError(t, pprof.Lookup("goroutine").WriteTo(&profile, 1))
+
+	// skip the header, the records are separated by empty lines
+	records := profile.String()
+	records = records[strings.Index(records, "\n")+1:]
+
+	label := fmt.Sprintf("%q:%q", "kademlia", id.String())
+	count := 0
+	for _, record := range strings.Split(records, "\n\n") {
+		if !strings.Contains(record, label) {
+			continue
+		}
+		var n int
+		_, err := fmt.Sscanf(record, "%d @", &n)
+		require.NoError(t, err)
+		count += n
+	}
+	return count
+}
+
+func TestObservedAddress(t *testing.T) {
+	// an address on a different host than the node connects from
+	const advertised = "10.255.0.1:28967"
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Kademlia.ExternalAddress = advertised
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		sat, node := planet.Satellites[0], planet.StorageNodes[0]
+		require.Equal(t, advertised, node.Local().Address.Address)
+
+		// the satellite knows the advertised address of the node
+		self := node.Local().Node
+		require.NoError(t, sat.Kademlia.RoutingTable.ConnectionSuccess(&self))
+		mismatches := monkit.Default.ScopeNamed("storj.io/storj/pkg/kademlia").Counter("ping_address_mismatch")
+		before := mismatches.Current()
+
+		satNode := sat.Local().Node
+		conn, err := node.Transport.DialNode(ctx, &satNode)
+		require.NoError(t, err)
+		defer ctx.Check(conn.Close)
+
+		resp, err := pb.NewNodesClient(conn).Ping(ctx, &pb.PingRequest{})
+		require.NoError(t, err)
+		host, _, err := net.SplitHostPort(resp.ObservedAddress)
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1", host)
+		require.True(t, mismatches.Current() > before, "mismatch wasn't counted")
+
+		_, err = node.Kademlia.Service.Ping(ctx, satNode)
+		require.NoError(t, err)
+		observed, mismatch := node.Kademlia.Service.ObservedAddress()
+		require.True(t, mismatch)
+		require.Equal(t, "127.0.0.1", strings.Split(observed, ":")[0])
+	})
+}
+
+func TestFetchInfoMany(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 10, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		kad := planet.Satellites[0].Kademlia.Service
+
+		var nodes []pb.Node
+		for _, node := range planet.StorageNodes {
+			nodes = append(nodes, node.Local().Node)
+		}
+		// two nodes don't respond
+		stopped := map[int]bool{3: true, 7: true}
+		for i := range stopped {
+			require.NoError(t, planet.StopPeer(planet.StorageNodes[i]))
+		}
+
+		var reports []int
+		results, err := kad.FetchInfoMany(ctx, nodes, 4, func(done, total int) {
+			require.Equal(t, len(nodes), total)
+			reports = append(reports, done)
+		})
+		require.NoError(t, err)
+		require.Len(t, results, len(nodes))
+		for i, result := range results {
+			if stopped[i] {
+				require.Error(t, result.Err)
+				require.Nil(t, result.Info)
+				require.NotEqual(t, errs2.DialSuccess, result.Outcome)
+				require.NotEqual(t, errs2.DialCanceled, result.Outcome)
+				continue
+			}
+			require.NoError(t, result.Err)
+			require.Equal(t, pb.NodeType_STORAGE, result.Info.Type)
+			require.Equal(t, planet.StorageNodes[i].Local().Operator.Wallet, result.Info.Operator.Wallet)
+		}
+		require.NotEmpty(t, reports)
+		require.Equal(t, len(nodes), reports[len(reports)-1])
+
+		// canceling midway returns the results so far
+		cancelCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		results, err = kad.FetchInfoMany(cancelCtx, nodes, 2, func(done, total int) {
+			if done == total/2 {
+				cancel()
+			}
+		})
+		require.Equal(t, context.Canceled, err)
+		require.Len(t, results, len(nodes))
+		completed := 0
+		for _, result := range results {
+			if result.Outcome != errs2.DialCanceled {
+				completed++
+			}
+		}
+		require.True(t, completed >= len(nodes)/2 && completed <= len(nodes)/2+2, "completed %d", completed)
+	})
+}
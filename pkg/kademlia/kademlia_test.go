@@ -6,9 +6,11 @@ package kademlia
 import (
 	"bytes"
 	"context"
+	"io"
 	"math/rand"
 	"net"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -18,10 +20,14 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
 
+	"storj.io/storj/internal/memory"
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
 	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/overlay"
 	"storj.io/storj/pkg/pb"
@@ -123,6 +129,237 @@ func TestPeerDiscovery(t *testing.T) {
 	}
 }
 
+func TestLookupStreamCancel(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	ask := server.Node()
+	server.Set(testkademlia.Behavior{
+		Nodes:        []*pb.Node{&ask},
+		Repeat:       true,
+		Capabilities: uint64(CapabilityQueryStream),
+	})
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, clientID, ctx.Dir("client"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	stream, err := k.dialer.LookupStream(ctx, ask, ask, 0, 1)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		chunk, err := stream.Next()
+		require.NoError(t, err)
+		require.Len(t, chunk, 1)
+	}
+	require.NoError(t, stream.Close())
+
+	// the server must notice the cancellation and stop sending
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Active(testkademlia.QueryStream) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stream was not stopped on the server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sent := server.Responses(testkademlia.QueryStream)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, sent, server.Responses(testkademlia.QueryStream))
+}
+
+func TestLookupStreamCapabilities(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, clientID, ctx.Dir("client"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	test := func(capabilities uint64) (server *testkademlia.Server, chunks [][]*pb.Node) {
+		server, err := testkademlia.Start(ctx)
+		require.NoError(t, err)
+		defer server.Close()
+
+		var nodes []*pb.Node
+		for i := 0; i < 5; i++ {
+			nodes = append(nodes, &pb.Node{Id: teststorj.NodeIDFromString(strconv.Itoa(i))})
+		}
+		server.Set(testkademlia.Behavior{Nodes: nodes, Repeat: true, Capabilities: capabilities})
+
+		ask := server.Node()
+		stream, err := k.dialer.LookupStream(ctx, ask, ask, 5, 2)
+		require.NoError(t, err)
+		defer ctx.Check(stream.Close)
+
+		// the server streams forever, read only a few chunks
+		for len(chunks) < 3 {
+			chunk, err := stream.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			chunks = append(chunks, chunk)
+		}
+
+		known, ok := k.dialer.Capabilities(server.ID())
+		require.True(t, ok)
+		require.Equal(t, Capability(capabilities), known)
+		return server, chunks
+	}
+
+	{ // old peer without capabilities uses unary query
+		server, chunks := test(0)
+		require.Equal(t, 1, server.Calls(testkademlia.Query))
+		require.Equal(t, 0, server.Calls(testkademlia.QueryStream))
+		require.Len(t, chunks, 3)
+		require.Len(t, chunks[0], 2)
+		require.Len(t, chunks[2], 1)
+	}
+
+	{ // peer with streaming support, unknown bits are ignored
+		server, chunks := test(uint64(CapabilityQueryStream) | 1<<63)
+		require.Equal(t, 0, server.Calls(testkademlia.Query))
+		require.Equal(t, 1, server.Calls(testkademlia.QueryStream))
+		require.Len(t, chunks, 3)
+	}
+
+	{ // streaming lookups not enabled by the version server use unary query
+		features := &stubFeatures{}
+		k.SetFeatures(features)
+		server, chunks := test(uint64(CapabilityQueryStream))
+		require.Equal(t, 1, server.Calls(testkademlia.Query))
+		require.Equal(t, 0, server.Calls(testkademlia.QueryStream))
+		require.Len(t, chunks, 3)
+		require.Equal(t, []storj.NodeID{clientID.ID}, features.checked)
+
+		features.enabled = []string{FeatureQueryStream}
+		server, _ = test(uint64(CapabilityQueryStream))
+		require.Equal(t, 0, server.Calls(testkademlia.Query))
+		require.Equal(t, 1, server.Calls(testkademlia.QueryStream))
+	}
+}
+
+// stubFeatures enables the listed features, recording the node IDs checked.
+type stubFeatures struct {
+	enabled []string
+	checked []storj.NodeID
+}
+
+func (features *stubFeatures) IsEnabled(name string, id storj.NodeID) bool {
+	features.checked = append(features.checked, id)
+	for _, enabled := range features.enabled {
+		if enabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLookupStreamCompression(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, clientID, ctx.Dir("client"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	// dump streams 5000 nodes and returns the bytes sent by the server before and after compression
+	dump := func(compression CompressionConfig, capabilities Capability, limit int) (length, wireLength int) {
+		payloads := &payloadStats{}
+		server, err := testkademlia.Start(ctx, grpc.StatsHandler(payloads))
+		require.NoError(t, err)
+		defer server.Close()
+
+		server.Set(testkademlia.Behavior{
+			Nodes:        testkademlia.RandomNodes(maxChunkSize),
+			Repeat:       true,
+			Capabilities: uint64(capabilities),
+		})
+
+		k.dialer.compression = compression
+		ask := server.Node()
+		stream, err := k.dialer.LookupStream(ctx, ask, ask, limit, maxChunkSize)
+		require.NoError(t, err)
+
+		received := 0
+		for received < 5000 {
+			chunk, err := stream.Next()
+			require.NoError(t, err)
+			received += len(chunk)
+		}
+		require.NoError(t, stream.Close())
+
+		return payloads.sent()
+	}
+
+	enabled := CompressionConfig{Enabled: true, Threshold: 16 * memory.KiB}
+
+	{ // bulk responses from new peers are compressed
+		length, wireLength := dump(enabled, Capabilities, 0)
+		t.Logf("compressed %d to %d bytes", length, wireLength)
+		// node IDs are random, so mostly the addresses compress
+		assert.True(t, wireLength < length*3/4)
+	}
+
+	{ // small responses are not compressed
+		length, wireLength := dump(enabled, Capabilities, 10)
+		assert.True(t, wireLength >= length)
+	}
+
+	{ // peers without gzip support get uncompressed calls
+		length, wireLength := dump(enabled, CapabilityQueryStream, 0)
+		assert.True(t, wireLength >= length)
+	}
+
+	{ // clients with compression disabled interoperate with new peers
+		length, wireLength := dump(CompressionConfig{}, Capabilities, 0)
+		assert.True(t, wireLength >= length)
+	}
+}
+
+// payloadStats sums the sizes of the messages sent by a server
+type payloadStats struct {
+	mu         sync.Mutex
+	length     int
+	wireLength int
+}
+
+func (payloads *payloadStats) sent() (length, wireLength int) {
+	payloads.mu.Lock()
+	defer payloads.mu.Unlock()
+	return payloads.length, payloads.wireLength
+}
+
+func (payloads *payloadStats) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	if out, ok := s.(*stats.OutPayload); ok {
+		payloads.mu.Lock()
+		payloads.length += out.Length
+		payloads.wireLength += out.WireLength
+		payloads.mu.Unlock()
+	}
+}
+
+func (payloads *payloadStats) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (payloads *payloadStats) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (payloads *payloadStats) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
 func TestBootstrap(t *testing.T) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
@@ -151,13 +388,17 @@ func TestBootstrap(t *testing.T) {
 }
 
 func testNode(ctx *testcontext.Context, name string, t *testing.T, bn []pb.Node) (*Kademlia, *grpc.Server, func()) {
-	// new address
-	lis, err := net.Listen("tcp", "127.0.0.1:0")
-	require.NoError(t, err)
-	// new config
 	// new identity
 	fid, err := testidentity.NewTestIdentity(ctx)
 	require.NoError(t, err)
+	return testNodeWithIdentity(ctx, name, t, bn, fid)
+}
+
+// testNodeWithIdentity starts a kademlia node with fid on a new address.
+func testNodeWithIdentity(ctx *testcontext.Context, name string, t *testing.T, bn []pb.Node, fid *identity.FullIdentity) (*Kademlia, *grpc.Server, func()) {
+	// new address
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
 	// new kademlia
 
 	logger := zaptest.NewLogger(t)
@@ -189,6 +430,50 @@ func testNode(ctx *testcontext.Context, name string, t *testing.T, bn []pb.Node)
 	}
 }
 
+func TestFindNodeAddressChange(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	bn, s, clean := testNode(ctx, "bootstrap", t, []pb.Node{})
+	defer clean()
+	defer s.GracefulStop()
+	bootstrap := []pb.Node{bn.routingTable.self.Node}
+
+	movingID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	moving, movingServer, movingClean := testNodeWithIdentity(ctx, "before", t, bootstrap, movingID)
+	require.NoError(t, moving.Bootstrap(ctx))
+	before := moving.Local().Address.Address
+
+	searcher, s2, clean2 := testNode(ctx, "searcher", t, bootstrap)
+	defer clean2()
+	defer s2.GracefulStop()
+	require.NoError(t, searcher.Bootstrap(ctx))
+	require.NoError(t, searcher.routingTable.Flush(ctx))
+
+	_, err = searcher.routingTable.nodeBucketDB.Get(movingID.ID.Bytes())
+	require.NoError(t, err, "searcher should have contacted the node before it moved")
+
+	// the node restarts on a new address and announces it to the bootstrap node
+	movingServer.GracefulStop()
+	movingClean()
+	moved, movedServer, movedClean := testNodeWithIdentity(ctx, "after", t, bootstrap, movingID)
+	defer movedClean()
+	defer movedServer.GracefulStop()
+	require.NoError(t, moved.Bootstrap(ctx))
+	require.NoError(t, bn.routingTable.Flush(ctx))
+	after := moved.Local().Address.Address
+	require.NotEqual(t, before, after)
+
+	found, err := searcher.FindNode(ctx, movingID.ID)
+	require.NoError(t, err)
+	assert.Equal(t, movingID.ID, found.Id)
+	assert.Equal(t, after, found.Address.Address)
+
+	_, err = searcher.FindNode(ctx, teststorj.NodeIDFromString("unknown"))
+	assert.True(t, NodeNotFound.Has(err), "%v", err)
+}
+
 func TestRefresh(t *testing.T) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
@@ -292,7 +577,188 @@ func TestFindNear(t *testing.T) {
 	}
 }
 
-func startTestNodeServer(ctx *testcontext.Context) (*grpc.Server, *mockNodesServer, *identity.FullIdentity, string) {
+func TestQueryOrderedByXOR(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	target := storj.NodeID{0x0F}
+	// inserted furthest first, distances to target are 0xF0, 0x70, 0x30, 0x1F, 0x0F and 0x01
+	for _, prefix := range []byte{0xFF, 0x7F, 0x3F, 0x10, 0x00, 0x0E} {
+		require.NoError(t, k.routingTable.ConnectionSuccess(&pb.Node{
+			Id:      storj.NodeID{prefix, 0xAA},
+			Address: &pb.NodeAddress{Address: "127.0.0.1:1"},
+		}))
+	}
+
+	endpoint := NewEndpoint(zaptest.NewLogger(t), k, k.routingTable)
+	resp, err := endpoint.Query(ctx, &pb.QueryRequest{Target: &pb.Node{Id: target}, Limit: 20})
+	require.NoError(t, err)
+
+	var prefixes []byte
+	for _, node := range resp.Response {
+		if node.Id == fid.ID {
+			continue
+		}
+		prefixes = append(prefixes, node.Id[0])
+	}
+	assert.Equal(t, []byte{0x0E, 0x00, 0x10, 0x3F, 0x7F, 0xFF}, prefixes)
+	assert.True(t, NodesSortedByXOR(resp.Response, target))
+}
+
+func TestLookupOrderViolation(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	target := storj.NodeID{0x0F}
+	nodes := testkademlia.RandomNodes(4)
+	for i, id := range []storj.NodeID{{0x7F}, {0x0E}, {0xFF}, {0x10}} {
+		nodes[i].Id = id
+	}
+	server.Set(testkademlia.Behavior{Nodes: nodes, Order: testkademlia.AsGiven})
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, clientID, ctx.Dir("client"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	ask := server.Node()
+	self := k.Local().Node
+
+	{ // unverified responses are returned as is
+		nodes, err := k.dialer.Lookup(ctx, self, ask, pb.Node{Id: target})
+		require.NoError(t, err)
+		require.False(t, NodesSortedByXOR(nodes, target))
+		require.Equal(t, int64(0), k.dialer.OrderViolations(server.ID()))
+	}
+
+	k.dialer.options.VerifyOrdering = true
+	{ // verified responses are sorted and the violation is counted
+		nodes, err := k.dialer.Lookup(ctx, self, ask, pb.Node{Id: target})
+		require.NoError(t, err)
+		require.Len(t, nodes, 4)
+		assert.Equal(t, storj.NodeID{0x0E}, nodes[0].Id)
+		assert.Equal(t, storj.NodeID{0x10}, nodes[1].Id)
+		assert.Equal(t, storj.NodeID{0x7F}, nodes[2].Id)
+		assert.Equal(t, storj.NodeID{0xFF}, nodes[3].Id)
+		assert.Equal(t, int64(1), k.dialer.OrderViolations(server.ID()))
+	}
+
+	server.Set(testkademlia.Behavior{Nodes: nodes, Order: testkademlia.Closest})
+	{ // sorted responses aren't counted
+		_, err := k.dialer.Lookup(ctx, self, ask, pb.Node{Id: target})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), k.dialer.OrderViolations(server.ID()))
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, mock, serverID, serverAddress := startTestNodeServer(ctx)
+	defer server.GracefulStop()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, clientID, ctx.Dir("client"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	k.negative = newNegativeCache(NegativeCacheConfig{TTL: time.Hour, Size: 10})
+	k.dialer.negative = k.negative
+	k.routingTable.negative = k.negative
+
+	ask := pb.Node{Id: serverID.ID, Address: &pb.NodeAddress{Address: serverAddress}}
+	require.NoError(t, k.routingTable.ConnectionSuccess(&ask))
+
+	target := pb.Node{Id: storj.NodeID{0x0F}, Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}
+
+	_, err = k.FindNode(ctx, target.Id)
+	require.True(t, NodeNotFound.Has(err), "%v", err)
+	queried := atomic.LoadInt32(&mock.queryCalled)
+	require.NotZero(t, queried)
+
+	{ // the second lookup doesn't search again
+		_, err = k.FindNode(ctx, target.Id)
+		require.True(t, NodeNotFound.Has(err), "%v", err)
+		assert.Equal(t, queried, atomic.LoadInt32(&mock.queryCalled))
+		assert.Equal(t, NegativeCacheStats{Hits: 1, Misses: 1, Entries: 1}, k.NegativeCacheStats())
+	}
+
+	{ // fresh lookups bypass the cache
+		_, err = k.FindNode(WithFreshLookup(ctx), target.Id)
+		require.True(t, NodeNotFound.Has(err), "%v", err)
+		assert.True(t, atomic.LoadInt32(&mock.queryCalled) > queried)
+		queried = atomic.LoadInt32(&mock.queryCalled)
+	}
+
+	{ // random targets, such as bucket refreshes, aren't remembered
+		_, err = k.FindNode(WithRandomTarget(ctx), storj.NodeID{0x0E})
+		require.True(t, NodeNotFound.Has(err), "%v", err)
+		assert.True(t, atomic.LoadInt32(&mock.queryCalled) > queried)
+		assert.Equal(t, 1, k.NegativeCacheStats().Entries)
+	}
+
+	{ // the target is observed in a lookup response, which invalidates the entry
+		mock.returnValue = []*pb.Node{&target}
+		_, err = k.dialer.Lookup(ctx, k.Local().Node, ask, pb.Node{Id: storj.NodeID{0x10}})
+		require.NoError(t, err)
+		assert.Equal(t, 0, k.NegativeCacheStats().Entries)
+		queried = atomic.LoadInt32(&mock.queryCalled)
+
+		found, err := k.FindNode(ctx, target.Id)
+		require.NoError(t, err)
+		assert.Equal(t, target.Id, found.Id)
+		assert.True(t, atomic.LoadInt32(&mock.queryCalled) > queried)
+	}
+
+	{ // contacting the target through the transport invalidates the entry
+		k.negative.add(target.Id, time.Now())
+		k.routingTable.ConnSuccess(ctx, &target)
+		assert.Equal(t, 0, k.NegativeCacheStats().Entries)
+	}
+}
+
+func TestProtocolLegacyPeer(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	// the server doesn't advertise a protocol version, like an old peer
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, clientID, ctx.Dir("client"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	_, known := k.dialer.Protocol(server.ID())
+	require.False(t, known)
+
+	ok, err := k.dialer.PingNode(ctx, server.Node())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, server.Calls(testkademlia.Ping))
+
+	protocol, known := k.dialer.Protocol(server.ID())
+	require.True(t, known)
+	require.Equal(t, version.ProtocolLegacy, protocol)
+}
+
+func startTestNodeServer(ctx *testcontext.Context, opts ...grpc.ServerOption) (*grpc.Server, *mockNodesServer, *identity.FullIdentity, string) {
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		return nil, nil, nil, ""
@@ -307,13 +773,15 @@ func startTestNodeServer(ctx *testcontext.Context) (*grpc.Server, *mockNodesServ
 		return nil, nil, nil, ""
 	}
 
-	serverOptions, err := tlsopts.NewOptions(identity, tlsopts.Config{})
+	serverOptions, err := tlsopts.NewOptions(identity, tlsopts.Config{
+		PeerIDVersions: "*",
+	})
 	if err != nil {
 		return nil, nil, nil, ""
 	}
 	identOpt := serverOptions.ServerOption()
 
-	grpcServer := grpc.NewServer(identOpt)
+	grpcServer := grpc.NewServer(append([]grpc.ServerOption{identOpt}, opts...)...)
 	mn := &mockNodesServer{queryCalled: 0}
 
 	pb.RegisterNodesServer(grpcServer, mn)
@@ -384,28 +852,46 @@ func TestRandomIds(t *testing.T) {
 	}
 }
 
+// mockNodesServer answers queries with returnValue, see testkademlia for a
+// scriptable server.
 type mockNodesServer struct {
 	queryCalled int32
-	pingCalled  int32
-	infoCalled  int32
 	returnValue []*pb.Node
+	// queries announcing their sender
+	announced int32
 }
 
 func (mn *mockNodesServer) Query(ctx context.Context, req *pb.QueryRequest) (*pb.QueryResponse, error) {
 	atomic.AddInt32(&mn.queryCalled, 1)
+	if req.Sender != nil {
+		atomic.AddInt32(&mn.announced, 1)
+	}
 	return &pb.QueryResponse{Response: mn.returnValue}, nil
 }
 
+// QueryStream sends returnValue until the client cancels.
+func (mn *mockNodesServer) QueryStream(req *pb.QueryRequest, stream pb.Nodes_QueryStreamServer) error {
+	for stream.Context().Err() == nil {
+		err := stream.Send(&pb.QueryResponse{Response: mn.returnValue})
+		if err != nil {
+			return err
+		}
+	}
+	return stream.Context().Err()
+}
+
 func (mn *mockNodesServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
-	atomic.AddInt32(&mn.pingCalled, 1)
 	return &pb.PingResponse{}, nil
 }
 
 func (mn *mockNodesServer) RequestInfo(ctx context.Context, req *pb.InfoRequest) (*pb.InfoResponse, error) {
-	atomic.AddInt32(&mn.infoCalled, 1)
 	return &pb.InfoResponse{}, nil
 }
 
+func (mn *mockNodesServer) Leave(ctx context.Context, req *pb.LeaveRequest) (*pb.LeaveResponse, error) {
+	return &pb.LeaveResponse{}, nil
+}
+
 // newKademlia returns a newly configured Kademlia instance
 func newKademlia(log *zap.Logger, nodeType pb.NodeType, bootstrapNodes []pb.Node, address string, operator pb.NodeOperator, identity *identity.FullIdentity, path string, alpha int) (*Kademlia, error) {
 	self := &overlay.NodeDossier{
@@ -435,9 +921,10 @@ func newKademlia(log *zap.Logger, nodeType pb.NodeType, bootstrapNodes []pb.Node
 		BootstrapBackoffMax:  10 * time.Second,
 		BootstrapBackoffBase: 1 * time.Second,
 		Alpha:                alpha,
+		Dialer:               DialerOptions{AllowPrivateAddresses: true},
 	}
 
-	kad, err := NewService(log, transportClient, rt, kadConfig)
+	kad, err := NewService(log, transportClient, rt, nil, kadConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -445,3 +932,27 @@ func newKademlia(log *zap.Logger, nodeType pb.NodeType, bootstrapNodes []pb.Node
 
 	return kad, nil
 }
+
+func TestQueryStreamLimit(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	endpoint := NewEndpoint(zaptest.NewLogger(t), k, k.routingTable)
+
+	// without a configured limit, streams are limited to K
+	assert.Equal(t, k.routingTable.K(), endpoint.streamLimit(0))
+	assert.Equal(t, k.routingTable.K(), endpoint.streamLimit(-1))
+	assert.Equal(t, k.routingTable.K(), endpoint.streamLimit(1<<40))
+	assert.Equal(t, 3, endpoint.streamLimit(3))
+
+	k.tunables.streamLimit = 50
+	assert.Equal(t, 50, endpoint.streamLimit(0))
+	assert.Equal(t, 50, endpoint.streamLimit(100))
+	assert.Equal(t, 30, endpoint.streamLimit(30))
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/sync2"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+)
+
+// leaveConcurrency is the number of peers NotifyLeaving notifies at the same time.
+const leaveConcurrency = 8
+
+// NotifyLeaving tells peers that this node is shutting down intentionally,
+// so that they remove it from their routing tables instead of finding out by
+// failing to reach it. It's best effort: peers are notified concurrently,
+// each within the timeout of the leave policy. It returns the number of
+// peers notified and the combined errors of the others.
+func (dialer *Dialer) NotifyLeaving(ctx context.Context, peers []pb.Node) (notified int, err error) {
+	defer dialer.mon.Task()(&ctx)(&err)
+
+	var mu sync.Mutex
+	var group errs.Group
+	limiter := sync2.NewLimiter(leaveConcurrency)
+	for _, peer := range peers {
+		peer := peer
+		started := limiter.Go(ctx, func() {
+			err := dialer.NotifyLeavingPeer(ctx, peer)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				dialer.log.Debug("leave notification failed", zap.Stringer("Node ID", peer.Id), zap.Error(err))
+				group.Add(err)
+				return
+			}
+			notified++
+		})
+		if !started {
+			break
+		}
+	}
+	limiter.Wait()
+
+	if err := ctx.Err(); err != nil && notified < len(peers) {
+		group.Add(err)
+	}
+	return notified, group.Err()
+}
+
+// NotifyLeavingPeer tells target that this node is shutting down, see NotifyLeaving.
+func (dialer *Dialer) NotifyLeavingPeer(ctx context.Context, target pb.Node) error {
+	return dialer.call(ctx, RPCLeave, func(ctx context.Context) error {
+		return dialer.notifyLeaving(ctx, target)
+	})
+}
+
+// notifyLeaving makes a single attempt of NotifyLeavingPeer.
+func (dialer *Dialer) notifyLeaving(ctx context.Context, target pb.Node) (err error) {
+	defer dialer.record(RPCLeave, target, time.Now())(&err)
+
+	release, ok := dialer.acquire(ctx)
+	if !ok {
+		return context.Canceled
+	}
+	defer release()
+
+	self := dialer.transport.Identity().ID
+	ctx = dialContext(ctx, RPCLeave)
+	conn, err := dialer.withConn(ctx, func() (*Conn, error) {
+		return dialer.dialNode(ctx, target)
+	}, func(conn *Conn) error {
+		_, err := conn.client.Leave(ctx, &pb.LeaveRequest{NodeId: self}, conn.callOptions()...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// the connection won't be used again
+	return conn.disconnect()
+}
+
+// leave notifies the closest nodes to self that this node is shutting down.
+func (k *Kademlia) leave(ctx context.Context) {
+	defer k.mon.Task()(&ctx)(nil)
+
+	self := k.routingTable.Local().Id
+	neighbors, err := k.routingTable.FindNear(self, k.routingTable.K())
+	if err != nil {
+		k.log.Debug("could not find the nodes to notify of leaving", zap.Error(err))
+		return
+	}
+	peers := make([]pb.Node, 0, len(neighbors))
+	for _, node := range neighbors {
+		if node.Id != self {
+			peers = append(peers, *node)
+		}
+	}
+	if len(peers) == 0 {
+		return
+	}
+
+	notified, err := k.dialer.NotifyLeaving(ctx, peers)
+	k.mon.IntVal("leave_notified").Observe(int64(notified))
+	k.log.Debug("notified the closest nodes of leaving",
+		zap.Int("notified", notified), zap.Int("neighbors", len(peers)), zap.Error(err))
+}
+
+// nodeLeft forgets the node with id, which announced that it's shutting down.
+func (k *Kademlia) nodeLeft(id storj.NodeID) error {
+	left, err := k.routingTable.NodeLeft(id)
+	if err != nil {
+		return err
+	}
+	k.queries.forget(id)
+	if left {
+		k.mon.Counter("node_left").Inc(1)
+		k.log.Debug("node left", zap.Stringer("Node ID", id))
+	}
+	return nil
+}
+
+// NodeLeft removes the node with id from the buckets and the replacement
+// caches right away, since it announced that it's shutting down. Unlike
+// ConnectionFailed, the node isn't tolerated for its tenure, but its history
+// is kept for when it comes back. Pinned nodes are kept. It returns whether
+// the node was known.
+func (rt *RoutingTable) NodeLeft(id storj.NodeID) (left bool, err error) {
+	if rt.isPinned(id) {
+		return false, nil
+	}
+	left = len(rt.uncache(id)) > 0
+
+	value, err := rt.nodeBucketDB.Get(id.Bytes())
+	if storage.ErrKeyNotFound.Has(err) {
+		return left, nil
+	} else if err != nil {
+		return left, RoutingErr.New("could not get node %s", err)
+	}
+	node := &pb.Node{}
+	if err := proto.Unmarshal(value, node); err != nil {
+		return left, RoutingErr.New("could not unmarshal node %s", err)
+	}
+
+	removed, err := rt.removeFromBuckets(node)
+	if err != nil {
+		return left, RoutingErr.New("could not remove node %s", err)
+	}
+	if removed {
+		rt.bus.Publish(Event{Type: EventNodeEvicted, Time: time.Now(), Node: id, Address: node.Address.GetAddress()})
+		rt.parkHistory(id)
+	}
+	return left || removed, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testkademlia"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/storagenode"
+)
+
+func TestLeaveOnShutdown(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 0, StorageNodeCount: 4, UplinkCount: 1,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Kademlia.LeaveOnShutdown = index == 0
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), planet.Uplinks[0].Transport, kademlia.WithPrivateAddresses())
+		defer ctx.Check(dialer.Close)
+
+		leaving, neighbors := planet.StorageNodes[0], planet.StorageNodes[1:]
+		returned := func(neighbor *storagenode.Peer) bool {
+			nodes, err := dialer.Lookup(ctx, pb.Node{}, neighbor.Local().Node, pb.Node{Id: leaving.ID()})
+			require.NoError(t, err)
+			for _, node := range nodes {
+				if node.Id == leaving.ID() {
+					return true
+				}
+			}
+			return false
+		}
+
+		for _, neighbor := range neighbors {
+			require.NoError(t, planet.WaitForContact(ctx, neighbor.Kademlia.Service, leaving))
+			require.NoError(t, planet.WaitForContact(ctx, leaving.Kademlia.Service, neighbor))
+			require.NoError(t, neighbor.Kademlia.Service.FlushRoutingTable(ctx))
+		}
+		require.NoError(t, leaving.Kademlia.Service.FlushRoutingTable(ctx))
+		for _, neighbor := range neighbors {
+			require.True(t, returned(neighbor), neighbor.ID())
+		}
+
+		// only the leaving node can announce that it leaves
+		neighbor := neighbors[0].Local().Node
+		conn, err := planet.Uplinks[0].Transport.DialNode(ctx, &neighbor)
+		require.NoError(t, err)
+		_, err = pb.NewNodesClient(conn).Leave(ctx, &pb.LeaveRequest{NodeId: leaving.ID()})
+		require.Error(t, err)
+		assert.Equal(t, kademlia.ErrorCodeSenderMismatch, kademlia.ErrorCode(err))
+		require.NoError(t, conn.Close())
+		require.True(t, returned(neighbors[0]))
+
+		require.NoError(t, planet.StopPeer(leaving))
+
+		for _, neighbor := range neighbors {
+			assert.False(t, returned(neighbor), neighbor.ID())
+		}
+	})
+}
+
+func TestNotifyLeaving(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	reachable, dialer := newFakeNodeDialer(t, ctx)
+	defer reachable.Close()
+	defer ctx.Check(dialer.Close)
+	unreachable, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	unreachable.Close()
+
+	notified, err := dialer.NotifyLeaving(ctx, []pb.Node{reachable.Node(), unreachable.Node()})
+	require.Error(t, err)
+	assert.True(t, kademlia.IsDialFailure(err), err)
+	assert.Equal(t, 1, notified)
+	assert.Equal(t, 1, reachable.Calls(testkademlia.Leave))
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// The benchmarks of the lookup hot path report allocations, compare them
+// before and after a change with benchstat:
+//
+//	go test -run none -bench LookupPath -benchmem -count 10 ./pkg/kademlia > old.txt
+//	go test -run none -bench LookupPath -benchmem -count 10 ./pkg/kademlia > new.txt
+//	benchstat old.txt new.txt
+
+func BenchmarkLookupPath(b *testing.B) {
+	ctx := testcontext.New(b)
+	defer ctx.Cleanup()
+
+	r := rand.New(rand.NewSource(1))
+	randomID := func() (id storj.NodeID) {
+		_, _ = r.Read(id[:])
+		return id
+	}
+	targets := make([]storj.NodeID, 1024)
+	for i := range targets {
+		targets[i] = randomID()
+	}
+
+	ident, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(b, err)
+	k, err := newKademlia(zap.NewNop(), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, ident, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(b, err)
+	defer ctx.Check(k.Close)
+
+	for i := 0; i < 2000; i++ {
+		node := pb.Node{Id: randomID(), Address: &pb.NodeAddress{Transport: defaultTransport, Address: "127.0.0.1:7777"}}
+		_, err := k.routingTable.addNode(&node)
+		require.NoError(b, err)
+	}
+
+	b.Run("Endpoint/Query", func(b *testing.B) {
+		endpoint := NewEndpoint(zap.NewNop(), k, k.routingTable)
+		requests := make([]*pb.QueryRequest, len(targets))
+		for i, target := range targets {
+			requests[i] = &pb.QueryRequest{Target: &pb.Node{Id: target}, Limit: 20}
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := endpoint.Query(ctx, requests[i%len(requests)])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("RoutingTable/FindNear", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := k.routingTable.FindNear(targets[i%len(targets)], 20)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// responses are the nodes returned by the peers, as processed by the
+	// client, in the order of the peers
+	responses := make([][]*pb.Node, len(targets))
+	for i := range responses {
+		responses[i] = make([]*pb.Node, 20)
+		for j := range responses[i] {
+			responses[i][j] = &pb.Node{Id: randomID()}
+		}
+	}
+
+	b.Run("Client/SortNodesByXOR", func(b *testing.B) {
+		nodes := make([]*pb.Node, 20)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			copy(nodes, responses[i%len(responses)])
+			SortNodesByXOR(nodes, targets[i%len(targets)])
+		}
+	})
+
+	b.Run("Client/Queue", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			target := targets[i%len(targets)]
+			queue := newDiscoveryQueue(defaultAlpha)
+			for j := 0; j < 8; j++ {
+				response := responses[(i+j)%len(responses)]
+				queue.Insert(target, response...)
+				_ = queue.Closest()
+			}
+		}
+	})
+}
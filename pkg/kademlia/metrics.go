@@ -0,0 +1,167 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/pkg/transport"
+)
+
+// The outcomes of RPCs counted by the dialer, see rpcOutcome.
+const (
+	outcomeSuccess          = "success"
+	outcomeDialFailure      = "dial_failure"
+	outcomeTimeout          = "timeout"
+	outcomeCanceled         = "canceled"
+	outcomeIdentityMismatch = "identity_mismatch"
+	outcomeError            = "error"
+)
+
+// rpcOutcome returns the outcome of an RPC that returned err.
+func rpcOutcome(err error) string {
+	if err == nil {
+		return outcomeSuccess
+	}
+	if isIdentityMismatch(err) {
+		return outcomeIdentityMismatch
+	}
+	switch errs2.ClassifyDial(err) {
+	case errs2.DialTimeout:
+		return outcomeTimeout
+	case errs2.DialCanceled:
+		return outcomeCanceled
+	}
+	if transport.Error.Has(err) {
+		return outcomeDialFailure
+	}
+	return outcomeError
+}
+
+// rpcMetric returns the name of the metric of rpc, such as rpc_fetch_info_timeout.
+func rpcMetric(rpc, metric string) string {
+	return "rpc_" + strings.Replace(rpc, "-", "_", -1) + "_" + metric
+}
+
+// recordRPC counts the outcome of a call of rpc that returned err and
+// reports its duration, retries included.
+func (dialer *Dialer) recordRPC(rpc string, duration time.Duration, err error) {
+	dialer.mon.Counter(rpcMetric(rpc, rpcOutcome(err))).Inc(1)
+	dialer.mon.DurationVal(rpcMetric(rpc, "duration")).Observe(duration)
+}
+
+// stats reports the gauges of the dialer.
+func (dialer *Dialer) stats(cb func(name string, val float64)) {
+	cb("open_conns", float64(dialer.conns.count()))
+	cb("idle_conns", float64(dialer.pool.size()))
+	cb("dials_in_progress", float64(dialer.limit.InUse()))
+}
+
+// SetMonitorScope makes the dialer report its metrics to scope instead of
+// the package scope, it must be called before the dialer is used.
+func (dialer *Dialer) SetMonitorScope(scope *monkit.Scope) {
+	dialer.mon = scope
+	if dialer.pool != nil {
+		dialer.pool.mon = scope
+	}
+	scope.Chain("dialer", monkit.StatSourceFunc(dialer.stats))
+}
+
+// connCounter counts the open connections of a dialer.
+type connCounter struct{ open int64 }
+
+// opened counts a newly dialed connection.
+func (counter *connCounter) opened() { atomic.AddInt64(&counter.open, 1) }
+
+// close closes conn, which is counted until it's closed the first time.
+func (counter *connCounter) close(conn *grpc.ClientConn) error {
+	err := conn.Close()
+	if err != grpc.ErrClientConnClosing {
+		atomic.AddInt64(&counter.open, -1)
+	}
+	return err
+}
+
+// count returns the number of open connections.
+func (counter *connCounter) count() int64 { return atomic.LoadInt64(&counter.open) }
+
+// DialerStats are the RPCs and connections of a dialer, see Dialer.Stats.
+type DialerStats struct {
+	// OpenConns is the number of connections dialed and not closed yet.
+	OpenConns int64
+	// InFlight is the number of RPCs in progress by RPC, such as ping. A
+	// lookup stream is in progress until it's closed.
+	InFlight map[string]int64
+	// Issued is the number of RPCs made since the dialer was created, an RPC
+	// is counted once however often it was retried.
+	Issued int64
+	// Failed is the number of the issued RPCs that failed.
+	Failed int64
+}
+
+// Stats returns the current RPCs and connections of the dialer, it's cheap
+// enough to be called for every request of a status endpoint.
+func (dialer *Dialer) Stats() DialerStats {
+	return DialerStats{
+		OpenConns: dialer.conns.count(),
+		InFlight:  dialer.rpcs.inFlight(),
+		Issued:    atomic.LoadInt64(&dialer.rpcs.issued),
+		Failed:    atomic.LoadInt64(&dialer.rpcs.failed),
+	}
+}
+
+// rpcCounters counts the RPCs of a dialer.
+type rpcCounters struct {
+	issued int64
+	failed int64
+	// the RPCs in progress by RPC, the map isn't changed after creation
+	active map[string]*int64
+}
+
+// newRPCCounters returns counters of the RPCs with default policies.
+func newRPCCounters() *rpcCounters {
+	counters := &rpcCounters{active: map[string]*int64{}}
+	for _, rpc := range DefaultRPCPolicies().Names() {
+		counters.active[rpc] = new(int64)
+	}
+	return counters
+}
+
+// begin counts a call of rpc as issued and in progress until the returned
+// func is called with its error, calling it again does nothing.
+func (counters *rpcCounters) begin(rpc string) (finish func(err error)) {
+	atomic.AddInt64(&counters.issued, 1)
+	active := counters.active[rpc]
+	if active != nil {
+		atomic.AddInt64(active, 1)
+	}
+
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			if active != nil {
+				atomic.AddInt64(active, -1)
+			}
+			if err != nil {
+				atomic.AddInt64(&counters.failed, 1)
+			}
+		})
+	}
+}
+
+// inFlight returns the number of RPCs in progress by RPC.
+func (counters *rpcCounters) inFlight() map[string]int64 {
+	inFlight := make(map[string]int64, len(counters.active))
+	for rpc, active := range counters.active {
+		inFlight[rpc] = atomic.LoadInt64(active)
+	}
+	return inFlight
+}
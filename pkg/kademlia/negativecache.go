@@ -0,0 +1,146 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// NegativeCacheStats are the counters of the negative lookup cache.
+type NegativeCacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+type freshLookupKey struct{}
+
+// WithFreshLookup makes lookups with ctx search the network even when the
+// node was recently not found, for callers that can't accept a stale result.
+func WithFreshLookup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshLookupKey{}, true)
+}
+
+// isFreshLookup returns whether lookups with ctx must ignore the negative cache.
+func isFreshLookup(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshLookupKey{}).(bool)
+	return fresh
+}
+
+type randomTargetKey struct{}
+
+// WithRandomTarget marks lookups with ctx as searching for random IDs, such
+// as bucket refreshes, which neither use nor fill the negative cache.
+func WithRandomTarget(ctx context.Context) context.Context {
+	return context.WithValue(ctx, randomTargetKey{}, true)
+}
+
+// isRandomTarget returns whether lookups with ctx search for random IDs.
+func isRandomTarget(ctx context.Context) bool {
+	random, _ := ctx.Value(randomTargetKey{}).(bool)
+	return random
+}
+
+// negativeCache remembers the nodes lookups didn't find, until they expire or
+// are seen again. A nil cache remembers nothing.
+//
+// Entries are evicted in the order they were added, which is the order they
+// expire in.
+type negativeCache struct {
+	ttl time.Duration
+	mon *monkit.Scope
+
+	// expiration times of the nodes, keyed by their id
+	expires *lrucache.Cache
+	hits    int64
+	misses  int64
+}
+
+// newNegativeCache creates a negative cache, it returns nil when disabled by config.
+func newNegativeCache(config NegativeCacheConfig) *negativeCache {
+	if config.TTL <= 0 || config.Size <= 0 {
+		return nil
+	}
+	return &negativeCache{
+		ttl:     config.TTL,
+		expires: lrucache.New(config.Size),
+		mon:     mon,
+	}
+}
+
+// notFound returns whether id was recently not found.
+func (cache *negativeCache) notFound(id storj.NodeID, now time.Time) bool {
+	if cache == nil {
+		return false
+	}
+
+	// peeking keeps the entries in the order they expire in
+	expires, ok := cache.expires.Peek(id)
+	if ok && now.After(expires.(time.Time)) {
+		cache.expires.Remove(id)
+		ok = false
+	}
+
+	if ok {
+		atomic.AddInt64(&cache.hits, 1)
+		cache.mon.Counter("negative_cache_hits").Inc(1)
+	} else {
+		atomic.AddInt64(&cache.misses, 1)
+		cache.mon.Counter("negative_cache_misses").Inc(1)
+	}
+	return ok
+}
+
+// add remembers that id wasn't found, evicting the entry that expires first
+// when the cache is full.
+func (cache *negativeCache) add(id storj.NodeID, now time.Time) {
+	if cache == nil {
+		return
+	}
+	cache.expires.Add(id, now.Add(cache.ttl))
+}
+
+// seen forgets the nodes, since they have been seen on the network.
+func (cache *negativeCache) seen(nodes ...*pb.Node) {
+	if cache == nil {
+		return
+	}
+	for _, node := range nodes {
+		if node != nil {
+			cache.expires.Remove(node.Id)
+		}
+	}
+}
+
+// seenID forgets the node with id, since it has been seen on the network.
+func (cache *negativeCache) seenID(id storj.NodeID) {
+	cache.seen(&pb.Node{Id: id})
+}
+
+// setMonitorScope reports the hits and misses of the cache to scope.
+func (cache *negativeCache) setMonitorScope(scope *monkit.Scope) {
+	if cache != nil {
+		cache.mon = scope
+	}
+}
+
+// stats returns the counters of the cache.
+func (cache *negativeCache) stats() NegativeCacheStats {
+	if cache == nil {
+		return NegativeCacheStats{}
+	}
+	return NegativeCacheStats{
+		Hits:    atomic.LoadInt64(&cache.hits),
+		Misses:  atomic.LoadInt64(&cache.misses),
+		Entries: cache.expires.Len(),
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestNegativeCacheExpiry(t *testing.T) {
+	cache := newNegativeCache(NegativeCacheConfig{TTL: time.Minute, Size: 2})
+	now := time.Now()
+
+	cache.add(storj.NodeID{1}, now)
+	assert.True(t, cache.notFound(storj.NodeID{1}, now.Add(time.Second)))
+	assert.False(t, cache.notFound(storj.NodeID{1}, now.Add(2*time.Minute)), "expired entries must be removed")
+	assert.False(t, cache.notFound(storj.NodeID{1}, now))
+
+	cache.add(storj.NodeID{1}, now)
+	cache.add(storj.NodeID{2}, now.Add(time.Second))
+	cache.add(storj.NodeID{3}, now.Add(2*time.Second))
+	assert.Equal(t, 2, cache.stats().Entries)
+	assert.False(t, cache.notFound(storj.NodeID{1}, now), "the entry expiring first must be evicted")
+	assert.True(t, cache.notFound(storj.NodeID{2}, now))
+	assert.True(t, cache.notFound(storj.NodeID{3}, now))
+
+	cache.seen(&pb.Node{Id: storj.NodeID{2}})
+	assert.False(t, cache.notFound(storj.NodeID{2}, now))
+}
+
+func TestNegativeCacheDisabled(t *testing.T) {
+	cache := newNegativeCache(NegativeCacheConfig{})
+	assert.Nil(t, cache)
+
+	cache.add(storj.NodeID{1}, time.Now())
+	assert.False(t, cache.notFound(storj.NodeID{1}, time.Now()))
+	assert.Equal(t, NegativeCacheStats{}, cache.stats())
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"storj.io/storj/pkg/storj"
+)
+
+// NeighborhoodChange describes a large change of the closest nodes to self,
+// which may mean that the node is being eclipsed or the network partitioned.
+type NeighborhoodChange struct {
+	Time    time.Time
+	Since   time.Time // when the Before snapshot was taken
+	Overlap float64
+	Before  []storj.NodeID
+	After   []storj.NodeID
+}
+
+// neighborhoodSnapshot is the set of closest nodes to self at a time.
+type neighborhoodSnapshot struct {
+	time  time.Time
+	nodes []storj.NodeID
+}
+
+// neighborhoodMonitor compares snapshots of the closest nodes to self with
+// the previous snapshot, catching sudden changes, and with a longer-lived
+// baseline, catching changes spread over several snapshots.
+type neighborhoodMonitor struct {
+	threshold float64
+	baseline  time.Duration
+
+	mu       sync.Mutex
+	previous neighborhoodSnapshot
+	oldest   neighborhoodSnapshot // the baseline, empty when disabled or bootstrapping
+}
+
+// newNeighborhoodMonitor creates a monitor, it returns nil when disabled by config.
+func newNeighborhoodMonitor(config NeighborhoodConfig) *neighborhoodMonitor {
+	if config.Interval <= 0 {
+		return nil
+	}
+	return &neighborhoodMonitor{threshold: config.Threshold, baseline: config.Baseline}
+}
+
+// update replaces the snapshot with closest and returns the change when less
+// than the threshold of the nodes of the previous snapshot or the baseline
+// remain. Changes are not reported until ready, since the neighborhood is
+// expected to change while bootstrapping.
+func (monitor *neighborhoodMonitor) update(closest []storj.NodeID, ready bool, now time.Time) *NeighborhoodChange {
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+
+	current := neighborhoodSnapshot{time: now, nodes: append([]storj.NodeID(nil), closest...)}
+	previous := monitor.previous
+	monitor.previous = current
+	if !ready {
+		monitor.oldest = neighborhoodSnapshot{}
+		return nil
+	}
+
+	change := monitor.compare(previous, current)
+	if change == nil {
+		change = monitor.compare(monitor.oldest, current)
+	}
+
+	// a reported change becomes the new baseline, so it's only reported once
+	if monitor.baseline > 0 && (change != nil || len(monitor.oldest.nodes) == 0 || now.Sub(monitor.oldest.time) >= monitor.baseline) {
+		monitor.oldest = current
+	}
+	return change
+}
+
+// compare returns the change from before to after when less than the
+// threshold of the nodes in before remain.
+func (monitor *neighborhoodMonitor) compare(before, after neighborhoodSnapshot) *NeighborhoodChange {
+	if len(before.nodes) == 0 {
+		return nil
+	}
+
+	remaining := make(map[storj.NodeID]struct{}, len(after.nodes))
+	for _, id := range after.nodes {
+		remaining[id] = struct{}{}
+	}
+	count := 0
+	for _, id := range before.nodes {
+		if _, ok := remaining[id]; ok {
+			count++
+		}
+	}
+
+	overlap := float64(count) / float64(len(before.nodes))
+	if overlap >= monitor.threshold {
+		return nil
+	}
+	return &NeighborhoodChange{
+		Time:    after.time,
+		Since:   before.time,
+		Overlap: overlap,
+		Before:  before.nodes,
+		After:   after.nodes,
+	}
+}
+
+// checkNeighborhood snapshots the closest nodes to self and reports a large change.
+func (k *Kademlia) checkNeighborhood(ctx context.Context) (err error) {
+	defer k.mon.Task()(&ctx)(&err)
+
+	// pinned nodes are known through configuration, they can't eclipse the node
+	self := k.routingTable.Local().Id
+	nodes, err := k.routingTable.findNearUnpinned(self, k.routingTable.K())
+	if err != nil {
+		return Error.Wrap(err)
+	}
+	closest := make([]storj.NodeID, 0, len(nodes))
+	for _, node := range nodes {
+		closest = append(closest, node.Id)
+	}
+
+	change := k.neighborhood.update(closest, k.bootstrapFinished.Released(), time.Now())
+	if change == nil {
+		return nil
+	}
+
+	k.bus.Publish(Event{Type: EventNeighborhoodChanged, Time: change.Time, Neighborhood: change})
+	return nil
+}
+
+func nodeIDStrings(ids []storj.NodeID) []string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	return strs
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/eventlog"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestNeighborhoodMonitor(t *testing.T) {
+	monitor := newNeighborhoodMonitor(NeighborhoodConfig{Interval: time.Minute, Threshold: 0.5})
+	now := time.Now()
+
+	ids := func(bytes ...byte) (list []storj.NodeID) {
+		for _, b := range bytes {
+			list = append(list, storj.NodeID{b})
+		}
+		return list
+	}
+
+	// changes aren't reported while bootstrapping
+	assert.Nil(t, monitor.update(ids(1, 2, 3, 4), false, now))
+	assert.Nil(t, monitor.update(ids(5, 6, 7, 8), false, now))
+
+	// half of the nodes remaining is still within the threshold
+	assert.Nil(t, monitor.update(ids(5, 6, 9, 10), true, now))
+
+	// only a quarter remaining is reported
+	change := monitor.update(ids(5, 11, 12, 13), true, now)
+	require.NotNil(t, change)
+	assert.Equal(t, 0.25, change.Overlap)
+	assert.Equal(t, ids(5, 6, 9, 10), change.Before)
+	assert.Equal(t, ids(5, 11, 12, 13), change.After)
+
+	// the snapshot is replaced after a report
+	assert.Nil(t, monitor.update(ids(5, 11, 12, 14), true, now))
+
+	assert.Nil(t, newNeighborhoodMonitor(NeighborhoodConfig{}), "zero interval disables the monitor")
+}
+
+func TestNeighborhoodMonitorBaseline(t *testing.T) {
+	monitor := newNeighborhoodMonitor(NeighborhoodConfig{Interval: time.Minute, Threshold: 0.5, Baseline: time.Hour})
+	start := time.Now()
+
+	ids := func(bytes ...byte) (list []storj.NodeID) {
+		for _, b := range bytes {
+			list = append(list, storj.NodeID{b})
+		}
+		return list
+	}
+
+	// a quarter of the nodes is replaced at every snapshot, which stays
+	// within the threshold of the previous snapshot
+	assert.Nil(t, monitor.update(ids(1, 2, 3, 4), true, start))
+	assert.Nil(t, monitor.update(ids(2, 3, 4, 5), true, start.Add(5*time.Minute)))
+	assert.Nil(t, monitor.update(ids(3, 4, 5, 6), true, start.Add(10*time.Minute)))
+
+	// but not of the baseline
+	change := monitor.update(ids(4, 5, 6, 7), true, start.Add(15*time.Minute))
+	require.NotNil(t, change)
+	assert.Equal(t, 0.25, change.Overlap)
+	assert.Equal(t, start, change.Since)
+	assert.Equal(t, ids(1, 2, 3, 4), change.Before)
+	assert.Equal(t, ids(4, 5, 6, 7), change.After)
+
+	// the reported neighborhood becomes the baseline
+	assert.Nil(t, monitor.update(ids(5, 6, 7, 8), true, start.Add(20*time.Minute)))
+
+	// and the baseline is replaced once it's old enough
+	monitor = newNeighborhoodMonitor(NeighborhoodConfig{Interval: time.Minute, Threshold: 0.5, Baseline: time.Hour})
+	assert.Nil(t, monitor.update(ids(1, 2, 3, 4), true, start))
+	assert.Nil(t, monitor.update(ids(2, 3, 4, 5), true, start.Add(time.Hour)))
+	assert.Nil(t, monitor.update(ids(3, 4, 5, 6), true, start.Add(time.Hour+5*time.Minute)))
+}
+
+func TestCheckNeighborhood(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	ident, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, ident, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	path := ctx.File("events.jsonl")
+	events, err := eventlog.Open(zaptest.NewLogger(t), eventlog.Config{Path: path, MaxSize: memory.MiB, QueueSize: memory.MiB, HistorySize: 10})
+	require.NoError(t, err)
+	defer func() { _ = events.Close() }()
+	k.SetEventSink(events)
+
+	k.neighborhood = newNeighborhoodMonitor(NeighborhoodConfig{Interval: time.Minute, Threshold: 0.5})
+	k.bootstrapFinished.Release()
+
+	self := k.Local().Id
+	neighbor := func(b byte) *pb.Node {
+		id := self
+		id[storj.NodeIDSize-1] ^= b
+		return &pb.Node{Id: id, Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}
+	}
+
+	before := []*pb.Node{neighbor(1), neighbor(2), neighbor(3), neighbor(4)}
+	for _, node := range before {
+		require.NoError(t, k.routingTable.ConnectionSuccess(node))
+	}
+
+	// pinned nodes aren't part of the neighborhood
+	pinned := neighbor(8)
+	_, err = k.routingTable.Pin(pinned, pb.NodeType_SATELLITE)
+	require.NoError(t, err)
+	require.NoError(t, k.checkNeighborhood(ctx))
+
+	// the closest nodes are replaced
+	for _, node := range before[1:] {
+		require.NoError(t, k.routingTable.removeNode(node))
+	}
+	for b := byte(5); b < 8; b++ {
+		require.NoError(t, k.routingTable.ConnectionSuccess(neighbor(b)))
+	}
+	require.NoError(t, k.checkNeighborhood(ctx))
+
+	// the change isn't kept in the history of self
+	require.NoError(t, k.Events().Flush(ctx))
+	assert.Empty(t, events.History(self, 0))
+	require.NoError(t, events.Close())
+
+	written, err := eventlog.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	assert.Equal(t, "neighborhood_changed", written[0].Operation)
+	assert.Empty(t, written[0].PeerID)
+	assert.Len(t, written[0].Before, 4)
+	assert.Len(t, written[0].After, 4)
+	assert.Contains(t, written[0].After, neighbor(5).Id.String())
+	assert.NotContains(t, written[0].After, pinned.Id.String())
+}
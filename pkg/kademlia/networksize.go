@@ -0,0 +1,151 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// networkSizeConfidence is how many standard deviations the bounds of a
+// network size estimate are from the estimate, about 95% confidence
+const networkSizeConfidence = 2
+
+// NetworkSize is the number of nodes in the network, estimated from the
+// density of the routing table around self.
+type NetworkSize struct {
+	Estimate int64
+	// Low and High bound the estimate
+	Low  int64
+	High int64
+	// Exact is whether the routing table holds every node it has seen, the
+	// estimate is then the size of the table
+	Exact   bool
+	Updated time.Time
+}
+
+// Response returns the size for the inspectors.
+func (size NetworkSize) Response() *pb.NetworkSizeResponse {
+	updated, err := ptypes.TimestampProto(size.Updated)
+	if err != nil {
+		updated = nil
+	}
+	return &pb.NetworkSizeResponse{
+		Estimate: size.Estimate,
+		Low:      size.Low,
+		High:     size.High,
+		Exact:    size.Exact,
+		Updated:  updated,
+	}
+}
+
+// estimateNetworkSize estimates the number of nodes in the network from the
+// nodes closest to self, sorted by distance.
+//
+// Node IDs are uniformly distributed, so in a network of n nodes the
+// distance to the k-th closest node, as a fraction of the ID space, follows
+// a gamma distribution with shape k and rate n. The estimate is (k-1)/d_k,
+// the bounds use the Wilson-Hilferty approximation of the gamma quantiles.
+// With k=20 the estimate is within a factor of 2.5 of n.
+func estimateNetworkSize(self storj.NodeID, closest []storj.NodeID) (estimate, low, high float64) {
+	k := float64(len(closest))
+	if k < 2 {
+		return k + 1, k + 1, k + 1
+	}
+	distance := xorDistance(self, closest[len(closest)-1])
+	quantile := func(z float64) float64 {
+		c := 1 - 1/(9*k) + z/(3*math.Sqrt(k))
+		return k * c * c * c
+	}
+	// the closest nodes and self
+	return (k-1)/distance + 1, quantile(-networkSizeConfidence)/distance + 1, quantile(networkSizeConfidence)/distance + 1
+}
+
+// xorDistance returns the distance between a and b as a fraction of the ID space.
+func xorDistance(a, b storj.NodeID) float64 {
+	xor := xorNodeID(a, b)
+	return (float64(binary.BigEndian.Uint64(xor[:8])) + 0.5) / math.Pow(2, 64)
+}
+
+// saturated returns whether nodes were turned away from the full buckets,
+// they wait in the replacement caches then.
+func (rt *RoutingTable) saturated() bool {
+	rt.rcMutex.Lock()
+	defer rt.rcMutex.Unlock()
+	for _, nodes := range rt.replacementCache {
+		if len(nodes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// networkSize estimates the number of nodes in the network. While the
+// buckets aren't saturated, such as in small test networks, the routing
+// table holds every node and the size is exact.
+func (rt *RoutingTable) networkSize(now time.Time) (NetworkSize, error) {
+	count, err := rt.countNodes()
+	if err != nil {
+		return NetworkSize{}, Error.Wrap(err)
+	}
+	count += len(rt.PinnedNodes())
+
+	if !rt.saturated() {
+		return NetworkSize{Estimate: int64(count), Low: int64(count), High: int64(count), Exact: true, Updated: now}, nil
+	}
+
+	nodes, err := rt.FindNear(rt.self.Id, rt.K())
+	if err != nil {
+		return NetworkSize{}, err
+	}
+	closest := make([]storj.NodeID, 0, len(nodes))
+	for _, node := range nodes {
+		closest = append(closest, node.Id)
+	}
+	estimate, low, high := estimateNetworkSize(rt.self.Id, closest)
+
+	// there are at least as many nodes as the table holds
+	size := NetworkSize{Updated: now}
+	size.Estimate = int64(math.Max(math.Round(estimate), float64(count)))
+	size.Low = int64(math.Max(math.Round(low), float64(count)))
+	size.High = int64(math.Max(math.Round(high), float64(count)))
+	return size, nil
+}
+
+// updateNetworkSize estimates the number of nodes in the network again.
+func (k *Kademlia) updateNetworkSize() {
+	size, err := k.routingTable.networkSize(time.Now())
+	if err != nil {
+		k.log.Debug("unable to estimate the network size", zap.Error(err))
+		return
+	}
+	k.mon.IntVal("network_size_estimate").Observe(size.Estimate)
+
+	k.mu.Lock()
+	k.networkSize = size
+	k.mu.Unlock()
+}
+
+// EstimateNetworkSize returns the number of nodes in the network estimated
+// after the last bucket refresh, or now when there wasn't one yet.
+func (k *Kademlia) EstimateNetworkSize() NetworkSize {
+	k.mu.Lock()
+	size := k.networkSize
+	k.mu.Unlock()
+	if !size.Updated.IsZero() {
+		return size
+	}
+
+	k.updateNetworkSize()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.networkSize
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// estimateErrorFactor is how far the estimate of estimateNetworkSize may be off
+const estimateErrorFactor = 2.5
+
+func TestEstimateNetworkSize(t *testing.T) {
+	for _, size := range []int{1e2, 1e4} {
+		for seed := int64(0); seed < 10; seed++ {
+			random := rand.New(rand.NewSource(seed))
+			randomID := func() (id storj.NodeID) {
+				_, _ = random.Read(id[:])
+				return id
+			}
+
+			self := randomID()
+			others := make(storj.NodeIDList, size-1)
+			for i := range others {
+				others[i] = randomID()
+			}
+			sort.Slice(others, func(i, k int) bool {
+				return compareByXor(others[i], others[k], self) < 0
+			})
+
+			estimate, low, high := estimateNetworkSize(self, others[:20])
+			assert.True(t, low <= estimate && estimate <= high, "%v outside of [%v, %v]", estimate, low, high)
+			assert.True(t, float64(size)/estimateErrorFactor <= estimate && estimate <= float64(size)*estimateErrorFactor,
+				"size %d, seed %d: estimated %v", size, seed, estimate)
+		}
+	}
+}
+
+func TestNetworkSizeExact(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTable(storj.NodeID{0x00})
+	defer ctx.Check(rt.Close)
+
+	for _, id := range []storj.NodeID{{0x81}, {0x42}, {0x23}} {
+		require.NoError(t, rt.ConnectionSuccess(&pb.Node{Id: id, Address: &pb.NodeAddress{Address: "node:7777"}}))
+	}
+
+	now := time.Now()
+	size, err := rt.networkSize(now)
+	require.NoError(t, err)
+	assert.Equal(t, NetworkSize{Estimate: 4, Low: 4, High: 4, Exact: true, Updated: now}, size)
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// ForwardedForKey is the metadata key in which proxies pass on the source
+// addresses of the requests they forward, nearest last.
+const ForwardedForKey = "x-forwarded-for"
+
+// trustedProxies are the networks of the proxies whose forwarded source addresses are believed.
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies parses comma separated IP addresses and CIDR ranges.
+func parseTrustedProxies(list string) (trustedProxies, error) {
+	var proxies trustedProxies
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, Error.New("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, Error.New("invalid trusted proxy %q: %v", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// contains returns whether ip is a trusted proxy.
+func (proxies trustedProxies) contains(ip net.IP) bool {
+	for _, network := range proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// observedAddress returns the source address of the request in ctx. When the
+// request came through trusted proxies, it is the nearest address forwarded
+// by them, without a port, and empty when they didn't forward any.
+func (proxies trustedProxies) observedAddress(ctx context.Context) string {
+	peer, ok := grpcpeer.FromContext(ctx)
+	if !ok || peer.Addr == nil {
+		return ""
+	}
+	address := peer.Addr.String()
+	if ip := net.ParseIP(addressHost(address)); ip == nil || !proxies.contains(ip) {
+		return address
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	forwarded := strings.Split(strings.Join(md.Get(ForwardedForKey), ","), ",")
+	// addresses before the first untrusted one could have been made up by the sender
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			return ""
+		}
+		if !proxies.contains(ip) {
+			return hop
+		}
+	}
+	return ""
+}
+
+// addressHost returns the host of address, which may lack a port.
+func addressHost(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
+
+// sameHost returns whether the addresses have the same host, ignoring the
+// ports, which NAT changes. Hosts other than IP addresses, such as DNS
+// names, can't be compared and are considered the same.
+func sameHost(advertised, observed string) bool {
+	advertisedIP := net.ParseIP(addressHost(advertised))
+	observedIP := net.ParseIP(addressHost(observed))
+	if advertisedIP == nil || observedIP == nil {
+		return true
+	}
+	return advertisedIP.Equal(observedIP)
+}
+
+// checkAdvertised counts pings from known peers that connect from a different
+// host than they advertise, which usually means their address is misconfigured.
+func (endpoint *Endpoint) checkAdvertised(id storj.NodeID, observed string) {
+	if observed == "" {
+		return
+	}
+	advertised, ok := endpoint.routingTable.advertisedAddress(id)
+	if !ok || sameHost(advertised, observed) {
+		return
+	}
+	endpoint.service.mon.Counter("ping_address_mismatch").Inc(1)
+	endpoint.log.Debug("peer connects from a different host than it advertises",
+		zap.Stringer("Node ID", id), zap.String("advertised", advertised), zap.String("observed", observed))
+}
+
+// advertisedAddress returns the address of the node with id in the routing table.
+func (rt *RoutingTable) advertisedAddress(id storj.NodeID) (string, bool) {
+	rt.mutex.Lock()
+	pinned, ok := rt.pinned[id]
+	rt.mutex.Unlock()
+	if ok {
+		return pinned.Node.GetAddress().GetAddress(), pinned.Node.GetAddress() != nil
+	}
+
+	value, err := rt.nodeBucketDB.Get(id.Bytes())
+	if err != nil {
+		return "", false
+	}
+	node := &pb.Node{}
+	if err := proto.Unmarshal(value, node); err != nil || node.Address == nil {
+		return "", false
+	}
+	return node.Address.Address, true
+}
+
+// observe remembers the address a peer observed this node connecting from.
+func (dialer *Dialer) observe(address string) {
+	if address == "" {
+		return
+	}
+	dialer.observedMu.Lock()
+	defer dialer.observedMu.Unlock()
+	dialer.observed = address
+}
+
+// ObservedAddress returns the address peers last observed this node
+// connecting from, and whether its host differs from the advertised address,
+// which usually means that the external address is misconfigured.
+func (k *Kademlia) ObservedAddress() (observed string, mismatch bool) {
+	k.dialer.observedMu.Lock()
+	observed = k.dialer.observed
+	k.dialer.observedMu.Unlock()
+	if observed == "" {
+		return "", false
+	}
+	return observed, !sameHost(k.Local().Address.Address, observed)
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
+)
+
+func TestObservedAddressProxies(t *testing.T) {
+	proxies, err := parseTrustedProxies("10.0.0.1, 192.168.0.0/16")
+	require.NoError(t, err)
+	_, err = parseTrustedProxies("10.0.0.1,proxy")
+	assert.Error(t, err)
+
+	request := func(source string, forwarded ...string) context.Context {
+		addr, err := net.ResolveTCPAddr("tcp", source)
+		require.NoError(t, err)
+		ctx := grpcpeer.NewContext(context.Background(), &grpcpeer.Peer{Addr: addr})
+		if len(forwarded) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ForwardedForKey, forwarded[0]))
+		}
+		return ctx
+	}
+
+	for _, test := range []struct {
+		source    string
+		forwarded []string
+		observed  string
+	}{
+		{"1.2.3.4:5000", nil, "1.2.3.4:5000"},
+		// only trusted proxies can forward the source address
+		{"1.2.3.4:5000", []string{"5.6.7.8"}, "1.2.3.4:5000"},
+		{"10.0.0.1:5000", []string{"5.6.7.8"}, "5.6.7.8"},
+		// the nearest untrusted address is the source
+		{"10.0.0.1:5000", []string{"9.9.9.9, 5.6.7.8, 192.168.1.1"}, "5.6.7.8"},
+		// the proxy itself isn't the source
+		{"10.0.0.1:5000", nil, ""},
+		{"10.0.0.1:5000", []string{"unknown"}, ""},
+	} {
+		assert.Equal(t, test.observed, proxies.observedAddress(request(test.source, test.forwarded...)), "%v", test)
+	}
+
+	assert.True(t, sameHost("1.2.3.4:28967", "1.2.3.4:40000"))
+	assert.True(t, sameHost("node.example.com:28967", "1.2.3.4:40000"))
+	assert.True(t, sameHost("1.2.3.4:28967", "1.2.3.4"))
+	assert.False(t, sameHost("1.2.3.4:28967", "5.6.7.8:28967"))
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
@@ -25,6 +26,9 @@ type peerDiscovery struct {
 
 	cond  sync.Cond
 	queue discoveryQueue
+
+	mu       sync.Mutex
+	deferred []*pb.Node
 }
 
 // ErrMaxRetries is used when a lookup has been retried the max number of times
@@ -44,22 +48,99 @@ func newPeerDiscovery(log *zap.Logger, self pb.Node, nodes []*pb.Node, dialer *D
 	return discovery
 }
 
-func (lookup *peerDiscovery) Run(ctx context.Context) (target *pb.Node, err error) {
-	if lookup.queue.Len() == 0 {
-		return nil, nil // TODO: should we return an error here?
+// sender returns the node announced to the nodes asked, none while self has
+// no valid address, so that the lookup still works without announcing it.
+func (lookup *peerDiscovery) sender() pb.Node {
+	if !announceable(lookup.self) {
+		return pb.Node{}
+	}
+	return lookup.self
+}
+
+// Run asks the nodes closest to the target for closer nodes until no closer
+// nodes are returned.
+func (lookup *peerDiscovery) Run(ctx context.Context) error {
+	lookup.search(ctx, "lookup", func(next *pb.Node, neighbors []*pb.Node) []*pb.Node {
+		if lookup.opts.bootstrap && lookup.isBootstrapNode(next) {
+			return lookup.validateBootstrapResponse(neighbors)
+		}
+		return neighbors
+	}, nil)
+
+	err := ctx.Err()
+	if err == context.Canceled {
+		err = nil
 	}
+	return err
+}
+
+// FindNode searches for the target and stops as soon as a peer returns it,
+// instead of waiting for the closest nodes to stabilize.
+//
+// When several peers return the target before the search stops, the address
+// from the peer closest to the target wins, ties go to the latest response.
+// The routing table entry of the target is only used when no peer returns
+// it, and NodeNotFound is returned when neither knows the target.
+func (lookup *peerDiscovery) FindNode(ctx context.Context) (pb.Node, error) {
+	// the target itself is not asked, its known address may be the stale one
+	known := lookup.queue.Remove(lookup.target)
 
+	// protected by `lookup.cond.L`
+	var found *foundNode
+
+	lookup.search(ctx, "find-node", func(next *pb.Node, neighbors []*pb.Node) []*pb.Node {
+		others := make([]*pb.Node, 0, len(neighbors))
+		for _, neighbor := range neighbors {
+			if neighbor.Id != lookup.target {
+				others = append(others, neighbor)
+				continue
+			}
+			result := &foundNode{
+				node:     neighbor,
+				distance: xorNodeID(lookup.target, next.Id),
+				at:       time.Now(),
+			}
+			if result.preferred(found) {
+				found = result
+			}
+		}
+		return others
+	}, func() bool {
+		return found != nil
+	})
+
+	switch {
+	case found != nil:
+		return *found.node, nil
+	case known != nil:
+		return *known, nil
+	case ctx.Err() != nil:
+		return pb.Node{}, ctx.Err()
+	default:
+		return pb.Node{}, NodeNotFound.New("%s", lookup.target)
+	}
+}
+
+// search asks the closest queued nodes for nodes close to the target, with
+// opts.concurrency workers, until no nodes are queued, ctx is done or stop
+// returns true. Once stop returns true no further nodes are asked, but the
+// lookups in progress are waited for.
+//
+// respond is called with every response and returns the nodes to queue, a nil
+// stop never stops early. Both are called with lookup.cond.L held.
+func (lookup *peerDiscovery) search(ctx context.Context, name string, respond func(next *pb.Node, neighbors []*pb.Node) []*pb.Node, stop func() bool) {
 	// protected by `lookup.cond.L`
 	working := 0
-	allDone := false
-	target = nil
+	allDone := lookup.queue.Len() == 0
+	stopped := func() bool { return stop != nil && stop() }
+	tracer := lookupTracerOf(ctx)
 
 	wg := sync.WaitGroup{}
 	wg.Add(lookup.opts.concurrency)
 	defer wg.Wait()
 
 	for i := 0; i < lookup.opts.concurrency; i++ {
-		go func() {
+		started := lookup.dialer.workers.Go(name, func() {
 			defer wg.Done()
 			for {
 				var next *pb.Node
@@ -72,54 +153,98 @@ func (lookup *peerDiscovery) Run(ctx context.Context) (target *pb.Node, err erro
 						return
 					}
 
-					next = lookup.queue.Closest()
-
-					if !lookup.opts.bootstrap && next != nil && next.Id == lookup.target {
-						allDone = true
-						target = next
-						break // closest node is the target and is already in routing table (i.e. no lookup required)
+					if !stopped() {
+						next = lookup.queue.Closest()
 					}
-
 					if next != nil {
 						working++
 						break
 					}
-					// no work, wait until some other routine inserts into the queue
+					// no work, wait until some other routine inserts into the queue or finishes
 					lookup.cond.Wait()
 				}
 				lookup.cond.L.Unlock()
-				neighbors, err := lookup.dialer.Lookup(ctx, lookup.self, *next, pb.Node{Id: lookup.target})
 
+				// TODO: retry failed nodes with lookup.queue.Reinsert after fixing the logic
+				start := time.Now()
+				neighbors, err := lookup.dialer.Lookup(ctx, lookup.sender(), *next, pb.Node{Id: lookup.target})
+				if tracer != nil {
+					tracer.hop(next, start, neighbors, err)
+				}
 				if err != nil && !isDone(ctx) {
-					// TODO: reenable retry after fixing logic
-					// ok := lookup.queue.Reinsert(lookup.target, next, lookup.opts.retries)
-					ok := false
-					if !ok {
-						lookup.log.Debug("connecting to node failed",
-							zap.Any("target", lookup.target),
-							zap.Any("dial-node", next.Id),
-							zap.Any("dial-address", next.Address.Address),
-							zap.Error(err),
-						)
-					}
+					lookup.log.Debug("connecting to node failed",
+						zap.Any("target", lookup.target),
+						zap.Any("dial-node", next.Id),
+						zap.Any("dial-address", next.Address.Address),
+						zap.Error(err),
+					)
 				}
 
-				lookup.queue.Insert(lookup.target, neighbors...)
-
 				lookup.cond.L.Lock()
+				lookup.queue.Insert(lookup.target, respond(next, neighbors)...)
 				working--
-				allDone = allDone || isDone(ctx) || working == 0 && lookup.queue.Len() == 0
+				allDone = allDone || isDone(ctx) || working == 0 && (stopped() || lookup.queue.Len() == 0)
 				lookup.cond.L.Unlock()
 				lookup.cond.Broadcast()
 			}
-		}()
+		})
+		if !started {
+			wg.Done()
+		}
 	}
+}
 
-	err = ctx.Err()
-	if err == context.Canceled {
-		err = nil
+// foundNode is the target as returned by a peer.
+type foundNode struct {
+	node     *pb.Node
+	distance storj.NodeID // of the responding peer to the target
+	at       time.Time
+}
+
+// preferred returns whether found is preferred over other, which may be nil.
+func (found *foundNode) preferred(other *foundNode) bool {
+	if other == nil {
+		return true
+	}
+	if found.distance != other.distance {
+		return found.distance.Less(other.distance)
 	}
-	return target, err
+	return !found.at.Before(other.at)
+}
+
+// isBootstrapNode returns whether node has the address of one of the
+// configured bootstrap nodes, whose IDs aren't known in advance.
+func (lookup *peerDiscovery) isBootstrapNode(node *pb.Node) bool {
+	address := node.GetAddress().GetAddress()
+	if address == "" {
+		return false
+	}
+	for _, bootstrap := range lookup.opts.bootstrapNodes {
+		if bootstrap.GetAddress().GetAddress() == address {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBootstrapResponse returns the nodes of a bootstrap response that can
+// be used directly, the others are deferred for verification.
+func (lookup *peerDiscovery) validateBootstrapResponse(nodes []*pb.Node) []*pb.Node {
+	accepted, deferred, rejected := lookup.opts.validation.split(lookup.self.Id, nodes)
+	lookup.dialer.mon.Counter("bootstrap_nodes_rejected").Inc(int64(rejected))
+	lookup.dialer.mon.Counter("bootstrap_nodes_deferred").Inc(int64(len(deferred)))
+
+	lookup.mu.Lock()
+	lookup.deferred = append(lookup.deferred, deferred...)
+	lookup.mu.Unlock()
+	return accepted
+}
+
+// Deferred returns the bootstrap response nodes that must be verified before use.
+func (lookup *peerDiscovery) Deferred() []*pb.Node {
+	lookup.mu.Lock()
+	defer lookup.mu.Unlock()
+	return append([]*pb.Node(nil), lookup.deferred...)
 }
 
 func isDone(ctx context.Context) bool {
@@ -191,22 +316,37 @@ func (queue *discoveryQueue) Reinsert(target storj.NodeID, node *pb.Node, limit
 	return true
 }
 
-// insert must hold lock while adding
+// insert must hold lock while adding, the items stay sorted by priority
+// without reallocating once the queue is full.
 func (queue *discoveryQueue) insert(target storj.NodeID, nodes ...*pb.Node) {
 	for _, node := range nodes {
-		queue.items = append(queue.items, queueItem{
-			node:     node,
-			priority: xorNodeID(target, node.Id),
+		priority := xorNodeID(target, node.Id)
+		pos := sort.Search(len(queue.items), func(i int) bool {
+			return priority.Less(queue.items[i].priority)
 		})
+		if pos >= queue.maxLen {
+			continue
+		}
+		if len(queue.items) < queue.maxLen {
+			queue.items = append(queue.items, queueItem{})
+		}
+		copy(queue.items[pos+1:], queue.items[pos:])
+		queue.items[pos] = queueItem{node: node, priority: priority}
 	}
+}
 
-	sort.Slice(queue.items, func(i, k int) bool {
-		return queue.items[i].priority.Less(queue.items[k].priority)
-	})
+// Remove removes the node with id from the queue and returns it, or nil when it isn't queued.
+func (queue *discoveryQueue) Remove(id storj.NodeID) *pb.Node {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
 
-	if len(queue.items) > queue.maxLen {
-		queue.items = queue.items[:queue.maxLen]
+	for i, item := range queue.items {
+		if item.node.Id == id {
+			queue.items = append(queue.items[:i], queue.items[i+1:]...)
+			return item.node
+		}
 	}
+	return nil
 }
 
 // Closest returns the closest item in the queue
@@ -218,8 +358,11 @@ func (queue *discoveryQueue) Closest() *pb.Node {
 		return nil
 	}
 
-	var item queueItem
-	item, queue.items = queue.items[0], queue.items[1:]
+	// shifting instead of reslicing keeps the capacity for later inserts
+	item := queue.items[0]
+	copy(queue.items, queue.items[1:])
+	queue.items[len(queue.items)-1] = queueItem{}
+	queue.items = queue.items[:len(queue.items)-1]
 	return item.node
 }
 
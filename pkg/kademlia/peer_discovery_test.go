@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -98,3 +99,30 @@ func TestDiscoveryQueueRandom(t *testing.T) {
 		}
 	}
 }
+
+func TestDiscoveryQueueRemove(t *testing.T) {
+	target := storj.NodeID{1, 1}
+	nodeA := &pb.Node{Id: storj.NodeID{1, 1}}
+	nodeB := &pb.Node{Id: storj.NodeID{3, 2}}
+
+	queue := newDiscoveryQueue(6)
+	queue.Insert(target, nodeA, nodeB)
+
+	assert.Equal(t, nodeA, queue.Remove(nodeA.Id))
+	assert.Nil(t, queue.Remove(nodeA.Id))
+	assert.Equal(t, 1, queue.Len())
+	assert.Equal(t, nodeB, queue.Closest())
+}
+
+func TestFoundNodePreferred(t *testing.T) {
+	now := time.Now()
+	closer := &foundNode{distance: storj.NodeID{1}, at: now}
+	further := &foundNode{distance: storj.NodeID{2}, at: now.Add(time.Second)}
+	later := &foundNode{distance: storj.NodeID{1}, at: now.Add(time.Second)}
+
+	assert.True(t, closer.preferred(nil))
+	assert.True(t, closer.preferred(further))
+	assert.False(t, further.preferred(closer))
+	assert.True(t, later.preferred(closer))
+	assert.False(t, closer.preferred(later))
+}
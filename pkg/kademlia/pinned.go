@@ -0,0 +1,263 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+)
+
+// PinnedBucket is the string representing the bucket used for pinned nodes
+const PinnedBucket = "pinned"
+
+// maxPinned is the maximum number of pinned nodes
+const maxPinned = 100
+
+// PinnedNode is a node kept outside of the buckets because of its type.
+type PinnedNode struct {
+	Node pb.Node
+	Type pb.NodeType
+}
+
+// pinnedType returns whether nodes of nodeType are pinned instead of competing for bucket slots.
+//
+// Satellites and bootstrap nodes are always reachable through configuration,
+// so they shouldn't take the place of storage nodes in the buckets.
+func pinnedType(nodeType pb.NodeType) bool {
+	return nodeType == pb.NodeType_SATELLITE || nodeType == pb.NodeType_BOOTSTRAP
+}
+
+// persistedPin is the db representation of a pinned node
+type persistedPin struct {
+	Address string      `json:"address"`
+	Type    pb.NodeType `json:"type"`
+}
+
+// Pin moves node out of the buckets into the pinned set when nodeType is
+// pinned, where it is never evicted and doesn't count against bucket capacity.
+// It returns whether the node is pinned.
+//
+// The type must be confirmed by the local configuration, such as the trusted
+// satellites or the bootstrap addresses, never by what the node reports.
+func (rt *RoutingTable) Pin(node *pb.Node, nodeType pb.NodeType) (bool, error) {
+	if !pinnedType(nodeType) || node.Id == (storj.NodeID{}) || node.Id == rt.self.Id {
+		return false, nil
+	}
+
+	rt.mutex.Lock()
+	if _, ok := rt.pinned[node.Id]; !ok && len(rt.pinned) >= maxPinned {
+		rt.mutex.Unlock()
+		return false, RoutingErr.New("can't pin more than %d nodes", maxPinned)
+	}
+	pinned := &PinnedNode{Node: *node, Type: nodeType}
+	rt.pinned[node.Id] = pinned
+	rt.persistPin(pinned)
+	rt.mutex.Unlock()
+
+	return true, rt.unbucket(node)
+}
+
+// unbucket removes the pinned node from the buckets, since it no longer
+// competes for a slot, giving its slot to a replacement.
+func (rt *RoutingTable) unbucket(node *pb.Node) error {
+	v, err := rt.nodeBucketDB.Get(storage.Key(node.Id.Bytes()))
+	if storage.ErrKeyNotFound.Has(err) {
+		return rt.removeNode(node)
+	}
+	if err != nil {
+		return RoutingErr.New("could not get node %s", err)
+	}
+	var stored pb.Node
+	if err := proto.Unmarshal(v, &stored); err != nil {
+		return RoutingErr.New("could not unmarshal node %s", err)
+	}
+	if err := rt.removeNode(&stored); err != nil {
+		return RoutingErr.New("could not remove pinned node from buckets %s", err)
+	}
+	return nil
+}
+
+// Unpin removes the node with id from the pinned set, it competes for bucket
+// slots again the next time it is seen.
+func (rt *RoutingTable) Unpin(id storj.NodeID) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	if _, ok := rt.pinned[id]; !ok {
+		return
+	}
+	delete(rt.pinned, id)
+	if rt.pinnedDB != nil {
+		if err := rt.pinnedDB.Delete(id.Bytes()); err != nil && !storage.ErrKeyNotFound.Has(err) {
+			rt.log.Warn("failed to unpin node", zap.Stringer("Node ID", id), zap.Error(err))
+		}
+	}
+}
+
+// LoadPinned persists the pinned nodes in db, starting with the nodes pinned
+// in db before. Corrupt rows, and rows over the limit, are deleted.
+func (rt *RoutingTable) LoadPinned(db storage.KeyValueStore) error {
+	var corrupt storage.Keys
+	loaded := make(map[storj.NodeID]*PinnedNode)
+	err := db.Iterate(storage.IterateOptions{Recurse: true},
+		func(it storage.Iterator) error {
+			var item storage.ListItem
+			for it.Next(&item) {
+				pinned, err := decodePin(item.Key, item.Value)
+				if err != nil {
+					rt.log.Warn("dropping corrupt pinned node", zap.Binary("key", item.Key), zap.Error(err))
+					corrupt = append(corrupt, storage.CloneKey(item.Key))
+					continue
+				}
+				loaded[pinned.Node.Id] = pinned
+			}
+			return nil
+		})
+	if err != nil {
+		return RoutingErr.Wrap(err)
+	}
+
+	var group errs.Group
+	for _, key := range corrupt {
+		group.Add(db.Delete(key))
+	}
+
+	rt.mutex.Lock()
+	rt.pinnedDB = db
+	var added []pb.Node
+	for id, pinned := range loaded {
+		if _, ok := rt.pinned[id]; ok {
+			continue
+		}
+		if len(rt.pinned) >= maxPinned {
+			group.Add(db.Delete(id.Bytes()))
+			continue
+		}
+		rt.pinned[id] = pinned
+		added = append(added, pinned.Node)
+	}
+	for _, pinned := range rt.pinned {
+		rt.persistPin(pinned)
+	}
+	rt.mutex.Unlock()
+
+	for i := range added {
+		group.Add(rt.unbucket(&added[i]))
+	}
+	return RoutingErr.Wrap(group.Err())
+}
+
+// persistPin writes pinned to the db when pinned nodes are persisted, rt.mutex must be held.
+func (rt *RoutingTable) persistPin(pinned *PinnedNode) {
+	if rt.pinnedDB == nil {
+		return
+	}
+	value, err := json.Marshal(persistedPin{
+		Address: pinned.Node.GetAddress().GetAddress(),
+		Type:    pinned.Type,
+	})
+	if err == nil {
+		err = rt.pinnedDB.Put(pinned.Node.Id.Bytes(), value)
+	}
+	if err != nil {
+		rt.log.Warn("failed to persist pinned node", zap.Stringer("Node ID", pinned.Node.Id), zap.Error(err))
+	}
+}
+
+// decodePin decodes a persisted pinned node.
+func decodePin(key storage.Key, value storage.Value) (*PinnedNode, error) {
+	id, err := storj.NodeIDFromBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	var persisted persistedPin
+	if err := json.Unmarshal(value, &persisted); err != nil {
+		return nil, err
+	}
+	if !pinnedType(persisted.Type) {
+		return nil, errs.New("nodes of type %s aren't pinned", persisted.Type)
+	}
+	return &PinnedNode{
+		Node: pb.Node{Id: id, Address: &pb.NodeAddress{Transport: pb.NodeTransport_TCP_TLS_GRPC, Address: persisted.Address}},
+		Type: persisted.Type,
+	}, nil
+}
+
+// PinnedNodes returns the pinned nodes sorted by ID.
+func (rt *RoutingTable) PinnedNodes() []PinnedNode {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	nodes := make([]PinnedNode, 0, len(rt.pinned))
+	for _, pinned := range rt.pinned {
+		nodes = append(nodes, *pinned)
+	}
+	sort.Slice(nodes, func(i, k int) bool {
+		return nodes[i].Node.Id.Less(nodes[k].Node.Id)
+	})
+	return nodes
+}
+
+// updatePinned updates the address of node when it is pinned and returns whether it is.
+func (rt *RoutingTable) updatePinned(node *pb.Node) bool {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	pinned, ok := rt.pinned[node.Id]
+	if !ok {
+		return false
+	}
+	if node.Address != nil && node.Address.Address != pinned.Node.GetAddress().GetAddress() {
+		rt.addressChanged(&pinned.Node, node)
+		pinned.Node.Address = node.Address
+		rt.persistPin(pinned)
+	}
+	return true
+}
+
+// isPinned returns whether the node with id is pinned.
+func (rt *RoutingTable) isPinned(id storj.NodeID) bool {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	_, ok := rt.pinned[id]
+	return ok
+}
+
+// mergePinned adds the pinned nodes to the nodes closest to target, keeping
+// the limit closest ones sorted by distance.
+func (rt *RoutingTable) mergePinned(nodes []*pb.Node, target storj.NodeID, limit int) []*pb.Node {
+	rt.mutex.Lock()
+	if len(rt.pinned) == 0 || limit <= 0 {
+		rt.mutex.Unlock()
+		return nodes
+	}
+	// nodes are sorted, pinned nodes that aren't closer than the farthest of
+	// limit nodes would be cut, they aren't copied
+	full := len(nodes) >= limit
+	var farthest storj.NodeID
+	if full {
+		farthest = nodes[limit-1].Id
+	}
+	for _, pinned := range rt.pinned {
+		if full && compareByXor(pinned.Node.Id, farthest, target) >= 0 {
+			continue
+		}
+		node := pinned.Node
+		nodes = append(nodes, &node)
+	}
+	rt.mutex.Unlock()
+
+	SortNodesByXOR(nodes, target)
+	if len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	return nodes
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+	"storj.io/storj/storage/teststore"
+)
+
+func TestPinnedNodes(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(storj.NodeID{0x00}, routingTableOpts{bucketSize: 2})
+	defer ctx.Check(rt.Close)
+
+	satellite := &pb.Node{Id: storj.NodeID{0x81}, Address: &pb.NodeAddress{Address: "satellite:7777"}}
+	bootstrap := &pb.Node{Id: storj.NodeID{0x82}, Address: &pb.NodeAddress{Address: "bootstrap:8888"}}
+	storage := []*pb.Node{
+		{Id: storj.NodeID{0x83}, Address: &pb.NodeAddress{Address: "storage1:7777"}},
+		{Id: storj.NodeID{0x84}, Address: &pb.NodeAddress{Address: "storage2:7777"}},
+	}
+
+	// the satellite and bootstrap node fill the far bucket before their type is known
+	require.NoError(t, rt.ConnectionSuccess(satellite))
+	require.NoError(t, rt.ConnectionSuccess(bootstrap))
+
+	pinned, err := rt.Pin(satellite, pb.NodeType_SATELLITE)
+	require.NoError(t, err)
+	assert.True(t, pinned)
+	pinned, err = rt.Pin(bootstrap, pb.NodeType_BOOTSTRAP)
+	require.NoError(t, err)
+	assert.True(t, pinned)
+	pinned, err = rt.Pin(storage[0], pb.NodeType_STORAGE)
+	require.NoError(t, err)
+	assert.False(t, pinned, "storage nodes compete for buckets")
+
+	for _, node := range storage {
+		require.NoError(t, rt.ConnectionSuccess(node))
+	}
+
+	{ // pinned nodes don't take bucket slots
+		nodes, err := rt.DumpNodes()
+		require.NoError(t, err)
+		var ids []storj.NodeID
+		for _, node := range nodes {
+			ids = append(ids, node.Id)
+		}
+		assert.ElementsMatch(t, []storj.NodeID{rt.self.Id, storage[0].Id, storage[1].Id}, ids)
+	}
+
+	{ // pinned nodes are listed separately
+		pinned := rt.PinnedNodes()
+		require.Len(t, pinned, 2)
+		assert.Equal(t, satellite.Id, pinned[0].Node.Id)
+		assert.Equal(t, pb.NodeType_SATELLITE, pinned[0].Type)
+		assert.Equal(t, bootstrap.Id, pinned[1].Node.Id)
+		assert.Equal(t, pb.NodeType_BOOTSTRAP, pinned[1].Type)
+	}
+
+	{ // lookups return pinned nodes when they're closest
+		nodes, err := rt.FindNear(storj.NodeID{0x81}, 2)
+		require.NoError(t, err)
+		require.Len(t, nodes, 2)
+		assert.Equal(t, satellite.Id, nodes[0].Id)
+		assert.Equal(t, storage[0].Id, nodes[1].Id)
+
+		nodes, err = rt.FindNear(storj.NodeID{0x83}, 3)
+		require.NoError(t, err)
+		require.Len(t, nodes, 3)
+		assert.Equal(t, storage[0].Id, nodes[0].Id)
+		assert.Equal(t, bootstrap.Id, nodes[1].Id)
+		assert.Equal(t, satellite.Id, nodes[2].Id)
+	}
+
+	{ // pinned nodes are never evicted, but their address is updated
+		require.NoError(t, rt.ConnectionFailed(satellite))
+		moved := &pb.Node{Id: satellite.Id, Address: &pb.NodeAddress{Address: "satellite:9999"}}
+		require.NoError(t, rt.ConnectionSuccess(moved))
+
+		pinned := rt.PinnedNodes()
+		require.Len(t, pinned, 2)
+		assert.Equal(t, "satellite:9999", pinned[0].Node.Address.Address)
+
+		nodes, err := rt.DumpNodes()
+		require.NoError(t, err)
+		assert.Len(t, nodes, 3)
+	}
+}
+
+func TestPinnedNodesPersistence(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	db := teststore.New()
+	defer ctx.Check(db.Close)
+
+	satellite := &pb.Node{Id: storj.NodeID{0x81}, Address: &pb.NodeAddress{Address: "satellite:7777"}}
+	bootstrap := &pb.Node{Id: storj.NodeID{0x82}, Address: &pb.NodeAddress{Address: "bootstrap:8888"}}
+
+	{ // pinned nodes are persisted
+		rt := createRoutingTableWith(storj.NodeID{0x00}, routingTableOpts{bucketSize: 2})
+		defer ctx.Check(rt.Close)
+
+		_, err := rt.Pin(satellite, pb.NodeType_SATELLITE)
+		require.NoError(t, err)
+		require.NoError(t, rt.LoadPinned(db))
+		_, err = rt.Pin(bootstrap, pb.NodeType_BOOTSTRAP)
+		require.NoError(t, err)
+
+		moved := &pb.Node{Id: satellite.Id, Address: &pb.NodeAddress{Address: "satellite:9999"}}
+		require.NoError(t, rt.ConnectionSuccess(moved))
+	}
+
+	require.NoError(t, db.Put(storage.Key("not a node id"), storage.Value("{}")))
+	require.NoError(t, db.Put(storj.NodeID{0x83}.Bytes(), storage.Value(`{"address":"storage:7777","type":2}`)))
+
+	{ // and loaded after a restart, without the corrupt rows
+		rt := createRoutingTableWith(storj.NodeID{0x00}, routingTableOpts{bucketSize: 2})
+		defer ctx.Check(rt.Close)
+
+		require.NoError(t, rt.ConnectionSuccess(bootstrap))
+		require.NoError(t, rt.LoadPinned(db))
+
+		pinned := rt.PinnedNodes()
+		require.Len(t, pinned, 2)
+		assert.Equal(t, satellite.Id, pinned[0].Node.Id)
+		assert.Equal(t, "satellite:9999", pinned[0].Node.Address.Address)
+		assert.Equal(t, pb.NodeType_SATELLITE, pinned[0].Type)
+		assert.Equal(t, bootstrap.Id, pinned[1].Node.Id)
+		assert.Equal(t, pb.NodeType_BOOTSTRAP, pinned[1].Type)
+
+		nodes, err := rt.DumpNodes()
+		require.NoError(t, err)
+		assert.Len(t, nodes, 1, "loaded nodes leave the buckets")
+
+		keys, err := db.List(nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, storage.Keys{satellite.Id.Bytes(), bootstrap.Id.Bytes()}, keys)
+
+		// unpinned nodes are forgotten
+		rt.Unpin(satellite.Id)
+		keys, err = db.List(nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, storage.Keys{bootstrap.Id.Bytes()}, keys)
+	}
+}
+
+func TestPinnedNodesLimit(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(storj.NodeID{0x00}, routingTableOpts{bucketSize: 2})
+	defer ctx.Check(rt.Close)
+
+	for i := 0; i < maxPinned; i++ {
+		pinned, err := rt.Pin(&pb.Node{Id: storj.NodeID{0x80, byte(i)}}, pb.NodeType_SATELLITE)
+		require.NoError(t, err)
+		require.True(t, pinned)
+	}
+
+	pinned, err := rt.Pin(&pb.Node{Id: storj.NodeID{0x81}}, pb.NodeType_SATELLITE)
+	require.Error(t, err)
+	assert.False(t, pinned)
+
+	// pinning a pinned node again is allowed
+	pinned, err = rt.Pin(&pb.Node{Id: storj.NodeID{0x80, 0x00}}, pb.NodeType_BOOTSTRAP)
+	require.NoError(t, err)
+	assert.True(t, pinned)
+	assert.Len(t, rt.PinnedNodes(), maxPinned)
+}
@@ -0,0 +1,282 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeebo/errs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/sync2"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/transport"
+)
+
+// The RPCs made by the dialer, as named in its policies, contact events and dial sites.
+const (
+	RPCLookup                  = "lookup"
+	RPCLookupStream            = "lookup-stream"
+	RPCPing                    = "ping"
+	RPCPingAddress             = "ping-address"
+	RPCFetchIdentity           = "fetch-identity"
+	RPCFetchIdentityUnverified = "fetch-identity-unverified"
+	RPCFetchInfo               = "fetch-info"
+	RPCFetchVersion            = "fetch-version"
+	RPCLeave                   = "leave"
+)
+
+// RPCPolicy is how the dialer makes one kind of RPC.
+type RPCPolicy struct {
+	// Timeout bounds a single attempt, dialing included, zero leaves it to
+	// the caller and the request timeout of the transport.
+	Timeout time.Duration
+	// Retries is how many times an attempt failing because the peer is
+	// unavailable is repeated, zero leaves it to DialerOptions.Retry.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for every further one.
+	Backoff time.Duration
+	// Priority is the dial priority when the caller didn't set one with
+	// WithDialPriority.
+	Priority DialPriority
+}
+
+// RPCPolicies are the policies of the RPCs made by the dialer, by RPC name.
+type RPCPolicies map[string]RPCPolicy
+
+// DefaultRPCPolicies returns the policies used unless overridden by
+// DialerOptions.Policies. Every RPC times out after 20s like the requests of
+// the transport, except lookup streams which last as long as their caller
+// reads them, and leave notifications, which shouldn't hold up shutting
+// down. RPCs aren't retried, lookups query other peers instead, and are
+// interactive dials unless the caller says otherwise.
+func DefaultRPCPolicies() RPCPolicies {
+	unary := RPCPolicy{Timeout: 20 * time.Second, Backoff: 500 * time.Millisecond, Priority: InteractiveDial}
+	return RPCPolicies{
+		RPCLookup:                  unary,
+		RPCLookupStream:            {Priority: InteractiveDial},
+		RPCPing:                    unary,
+		RPCPingAddress:             unary,
+		RPCFetchIdentity:           unary,
+		RPCFetchIdentityUnverified: unary,
+		RPCFetchInfo:               unary,
+		RPCFetchVersion:            unary,
+		RPCLeave:                   {Timeout: 2 * time.Second, Backoff: unary.Backoff, Priority: InteractiveDial},
+	}
+}
+
+// ParseRPCPolicies returns the default policies with the comma separated
+// overrides applied. An override is rpc.setting=value, where setting is one
+// of timeout, retries, backoff and priority, such as ping.timeout=5s or
+// fetch-info.priority=background.
+func ParseRPCPolicies(overrides string) (RPCPolicies, error) {
+	policies := DefaultRPCPolicies()
+	for _, entry := range strings.Split(overrides, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if err := policies.override(entry); err != nil {
+			return nil, Error.New("invalid rpc policy %q: %v", entry, err)
+		}
+	}
+	return policies, nil
+}
+
+// override applies a single rpc.setting=value override.
+func (policies RPCPolicies) override(entry string) error {
+	split := strings.IndexByte(entry, '=')
+	dot := strings.IndexByte(entry, '.')
+	if split < 0 || dot < 0 || dot > split {
+		return errs.New("expected rpc.setting=value")
+	}
+	rpc, setting, value := entry[:dot], entry[dot+1:split], entry[split+1:]
+
+	policy, ok := policies[rpc]
+	if !ok {
+		return errs.New("unknown rpc %q", rpc)
+	}
+
+	switch setting {
+	case "timeout", "backoff":
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if duration < 0 {
+			return errs.New("%s must not be negative", setting)
+		}
+		if setting == "timeout" {
+			policy.Timeout = duration
+		} else {
+			policy.Backoff = duration
+		}
+	case "retries":
+		retries, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if retries < 0 {
+			return errs.New("retries must not be negative")
+		}
+		if retries > 0 && rpc == RPCLookupStream {
+			return errs.New("lookup streams can't be retried")
+		}
+		policy.Retries = retries
+	case "priority":
+		priority, ok := parseDialPriority(value)
+		if !ok {
+			return errs.New("priority must be interactive or background")
+		}
+		policy.Priority = priority
+	default:
+		return errs.New("unknown setting %q", setting)
+	}
+
+	policies[rpc] = policy
+	return nil
+}
+
+// Names returns the names of the RPCs, sorted.
+func (policies RPCPolicies) Names() []string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// policy returns the policy of rpc.
+func (dialer *Dialer) policy(rpc string) RPCPolicy {
+	dialer.settingsMu.Lock()
+	defer dialer.settingsMu.Unlock()
+	return dialer.policies[rpc]
+}
+
+// Policies returns the effective policies of the RPCs.
+func (dialer *Dialer) Policies() RPCPolicies {
+	dialer.settingsMu.Lock()
+	defer dialer.settingsMu.Unlock()
+	policies := make(RPCPolicies, len(dialer.policies))
+	for rpc, policy := range dialer.policies {
+		policies[rpc] = policy
+	}
+	return policies
+}
+
+// RPCPolicies returns the effective policies of the RPCs made by the dialer.
+func (k *Kademlia) RPCPolicies() RPCPolicies {
+	return k.dialer.Policies()
+}
+
+// call makes the attempts of rpc with attempt as allowed by its policy and
+// the retry of the dialer: each attempt gets the deadline and dial priority
+// of the policy, and attempts failing transiently are retried after a
+// backoff. Retries never outlast the deadline of ctx. The error after
+// several attempts tells their number. Calls are monitored as a whole, see
+// recordRPC.
+func (dialer *Dialer) call(ctx context.Context, rpc string, attempt func(ctx context.Context) error) (err error) {
+	defer dialer.mon.TaskNamed(rpc)(&ctx)(&err)
+	policy := dialer.policy(rpc)
+	_, options := dialer.settings()
+	attempts, delays := policy.attempts(options.Retry)
+
+	start := time.Now()
+	finish := dialer.rpcs.begin(rpc)
+	defer func() {
+		finish(err)
+		dialer.recordRPC(rpc, time.Since(start), err)
+	}()
+	for made := 1; ; made++ {
+		attemptCtx, cancel := policy.context(ctx)
+		err := attempt(attemptCtx)
+		cancel()
+		if err == nil || made >= attempts || ctx.Err() != nil || !retryable(err) {
+			return withAttempts(err, made)
+		}
+
+		delay, _ := delays.Delay(made, time.Since(start))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return withAttempts(err, made)
+		}
+		dialer.mon.Counter("rpc_retries").Inc(1)
+		if !sync2.Sleep(ctx, delay) {
+			return withAttempts(err, made)
+		}
+	}
+}
+
+// attempts returns how many attempts of an RPC of policy are made at most,
+// and the delays between them. The retries of the policy take precedence
+// over retry.
+func (policy RPCPolicy) attempts(retry RetryConfig) (int, backoff.Strategy) {
+	if policy.Retries > 0 {
+		return policy.Retries + 1, &backoff.Exponential{Base: policy.Backoff}
+	}
+	if retry.MaxAttempts <= 1 {
+		return 1, nil
+	}
+	return retry.MaxAttempts, &backoff.Exponential{
+		Base:   retry.InitialBackoff,
+		Max:    retry.MaxBackoff,
+		Jitter: retry.Jitter,
+	}
+}
+
+// context returns ctx with the deadline of policy, and its dial priority
+// unless ctx has one.
+func (policy RPCPolicy) context(ctx context.Context) (context.Context, func()) {
+	if _, ok := ctx.Value(dialPriorityKey{}).(DialPriority); !ok && policy.Priority != InteractiveDial {
+		ctx = WithDialPriority(ctx, policy.Priority)
+	}
+	if policy.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, policy.Timeout)
+}
+
+// retryable returns whether an attempt that failed with err may be repeated:
+// the peer was unavailable or the transport failed, such as by a refused or
+// timed out dial, but not because the peer can't be trusted or mustn't be
+// dialed.
+func retryable(err error) bool {
+	switch {
+	case transport.IsVerificationError(err),
+		ErrNodeIDMismatch.Has(err),
+		transport.SelfDial.Has(err),
+		transport.PolicyDenied.Has(err),
+		PeerBackedOff.Has(err):
+		return false
+	}
+	return status.Code(errs.Unwrap(err)) == codes.Unavailable || transport.Error.Has(err)
+}
+
+// attemptsError is the error of the last of several attempts of an RPC.
+type attemptsError struct {
+	err      error
+	attempts int
+}
+
+// withAttempts returns err of the last of attempts, telling their number
+// when there were several.
+func withAttempts(err error, attempts int) error {
+	if err == nil || attempts <= 1 {
+		return err
+	}
+	return &attemptsError{err: err, attempts: attempts}
+}
+
+func (err *attemptsError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", err.err, err.attempts)
+}
+
+// Cause returns the error of the last attempt.
+func (err *attemptsError) Cause() error { return err.err }
@@ -0,0 +1,240 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeebo/errs"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/transport"
+)
+
+func TestParseRPCPolicies(t *testing.T) {
+	policies, err := ParseRPCPolicies("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRPCPolicies(), policies)
+
+	policies, err = ParseRPCPolicies(" ping.timeout=5s, fetch-info.retries=2,fetch-info.backoff=1s ,lookup.priority=background")
+	require.NoError(t, err)
+	defaults := DefaultRPCPolicies()
+	for _, rpc := range policies.Names() {
+		switch rpc {
+		case RPCPing:
+			expected := defaults[rpc]
+			expected.Timeout = 5 * time.Second
+			assert.Equal(t, expected, policies[rpc])
+		case RPCFetchInfo:
+			expected := defaults[rpc]
+			expected.Retries, expected.Backoff = 2, time.Second
+			assert.Equal(t, expected, policies[rpc])
+		case RPCLookup:
+			expected := defaults[rpc]
+			expected.Priority = BackgroundDial
+			assert.Equal(t, expected, policies[rpc])
+		default:
+			assert.Equal(t, defaults[rpc], policies[rpc], rpc)
+		}
+	}
+
+	for _, invalid := range []string{
+		"ping",
+		"ping.timeout",
+		"timeout=5s",
+		"query.timeout=5s",
+		"ping.deadline=5s",
+		"ping.timeout=soon",
+		"ping.timeout=-1s",
+		"ping.retries=-1",
+		"ping.priority=urgent",
+		"lookup-stream.retries=1",
+	} {
+		_, err := ParseRPCPolicies(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRPCPolicies(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOpts, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	dialer := NewDialer(zaptest.NewLogger(t), transport.NewClient(tlsOpts))
+	defer ctx.Check(dialer.Close)
+
+	options := DialerOptions{Policies: "ping.timeout=100ms,ping.retries=1,ping.backoff=10ms"}
+	policies, err := ParseRPCPolicies(options.Policies)
+	require.NoError(t, err)
+	dialer.setSettings(CompressionConfig{}, options, policies)
+	assert.Equal(t, policies, dialer.Policies())
+
+	node := server.Node()
+	delay := 500 * time.Millisecond
+	server.Set(testkademlia.Behavior{Delay: delay})
+
+	{ // only the timeout of pings is shortened
+		start := time.Now()
+		_, err := dialer.PingNode(ctx, node)
+		require.Error(t, err)
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(errs.Unwrap(err)))
+		assert.True(t, time.Since(start) < delay, "ping took %v", time.Since(start))
+		// deadlines aren't retried
+		assert.Equal(t, 1, server.Calls(testkademlia.Ping))
+
+		start = time.Now()
+		_, err = dialer.FetchInfo(ctx, node)
+		require.NoError(t, err)
+		assert.True(t, time.Since(start) >= delay, "info took %v", time.Since(start))
+
+		start = time.Now()
+		_, err = dialer.Lookup(ctx, pb.Node{}, node, node)
+		require.NoError(t, err)
+		assert.True(t, time.Since(start) >= delay, "lookup took %v", time.Since(start))
+	}
+
+	server.Set(testkademlia.Behavior{})
+
+	{ // unavailable peers are retried as configured
+		server.Script(testkademlia.Ping, testkademlia.Behavior{Code: codes.Unavailable})
+		ok, err := dialer.PingNode(ctx, node)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 3, server.Calls(testkademlia.Ping))
+
+		server.Script(testkademlia.RequestInfo, testkademlia.Behavior{Code: codes.Unavailable})
+		_, err = dialer.FetchInfo(ctx, node)
+		require.Error(t, err)
+		assert.Equal(t, codes.Unavailable, status.Code(errs.Unwrap(err)))
+		// other RPCs aren't retried
+		assert.Equal(t, 2, server.Calls(testkademlia.RequestInfo))
+	}
+}
+
+func TestDialerRetry(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+
+	clientID, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	tlsOpts, err := tlsopts.NewOptions(clientID, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	// dials slower than the timeout of pings fail
+	client := transport.NewClient(tlsOpts)
+	network := &transport.SimulatedNetwork{DialLatency: time.Minute}
+	flaky := &flakyTransport{Client: client, slow: network.NewClient(client)}
+
+	dialer := NewDialer(zaptest.NewLogger(t), flaky)
+	defer ctx.Check(dialer.Close)
+
+	options := DialerOptions{
+		Policies: "ping.timeout=100ms,fetch-info.timeout=100ms",
+		Retry:    RetryConfig{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond},
+	}
+	policies, err := ParseRPCPolicies(options.Policies)
+	require.NoError(t, err)
+	dialer.setSettings(CompressionConfig{}, options, policies)
+
+	node := server.Node()
+
+	{ // a failed dial is retried
+		flaky.reset(1)
+		ok, err := dialer.PingNode(ctx, node)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, int32(2), flaky.count())
+		assert.Equal(t, 1, server.Calls(testkademlia.Ping))
+	}
+
+	{ // the error after the last attempt tells how many were made
+		flaky.reset(3)
+		_, err := dialer.PingNode(ctx, node)
+		require.Error(t, err)
+		assert.True(t, transport.Error.Has(err))
+		assert.Contains(t, err.Error(), "after 3 attempts")
+		assert.Equal(t, int32(3), flaky.count())
+	}
+
+	{ // retries don't outlast the deadline of the caller
+		flaky.reset(3)
+		deadlineCtx, cancel := context.WithTimeout(ctx, 150*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := dialer.PingNode(deadlineCtx, node)
+		require.Error(t, err)
+		assert.True(t, time.Since(start) < 200*time.Millisecond, "ping took %v", time.Since(start))
+		assert.Equal(t, int32(2), flaky.count())
+	}
+
+	{ // identity verification failures aren't retried
+		flaky.reset(0)
+		impostor := pb.Node{Id: teststorj.NodeIDFromString("impostor"), Address: node.Address}
+		_, err := dialer.PingNode(ctx, impostor)
+		require.Error(t, err)
+		assert.True(t, transport.IsVerificationError(err))
+		assert.Equal(t, int32(1), flaky.count())
+	}
+
+	{ // neither are errors of the peer
+		flaky.reset(0)
+		server.Script(testkademlia.RequestInfo, testkademlia.Behavior{Code: codes.Internal})
+		_, err := dialer.FetchInfo(ctx, node)
+		require.Error(t, err)
+		assert.Equal(t, codes.Internal, status.Code(errs.Unwrap(err)))
+		assert.Equal(t, 1, server.Calls(testkademlia.RequestInfo))
+	}
+}
+
+// flakyTransport makes the first dials through slow.
+type flakyTransport struct {
+	transport.Client
+	slow transport.Client
+
+	slowDials int32
+	dials     int32
+}
+
+// reset makes the next slowDials dials through slow and resets the count of dials.
+func (client *flakyTransport) reset(slowDials int32) {
+	atomic.StoreInt32(&client.slowDials, slowDials)
+	atomic.StoreInt32(&client.dials, 0)
+}
+
+func (client *flakyTransport) count() int32 { return atomic.LoadInt32(&client.dials) }
+
+func (client *flakyTransport) DialNode(ctx context.Context, node *pb.Node, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	atomic.AddInt32(&client.dials, 1)
+	if atomic.AddInt32(&client.slowDials, -1) >= 0 {
+		return client.slow.DialNode(ctx, node, opts...)
+	}
+	return client.Client.DialNode(ctx, node, opts...)
+}
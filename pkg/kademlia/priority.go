@@ -0,0 +1,160 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync"
+)
+
+// DialPriority decides which dials are served first when the dial limit is reached.
+type DialPriority int
+
+const (
+	// InteractiveDial is the default priority, for dials someone is waiting on.
+	InteractiveDial DialPriority = iota
+	// BackgroundDial is for maintenance such as refreshing buckets.
+	BackgroundDial
+)
+
+// String returns the name of the priority.
+func (priority DialPriority) String() string {
+	if priority == BackgroundDial {
+		return "background"
+	}
+	return "interactive"
+}
+
+// parseDialPriority returns the priority named name.
+func parseDialPriority(name string) (DialPriority, bool) {
+	switch name {
+	case "interactive":
+		return InteractiveDial, true
+	case "background":
+		return BackgroundDial, true
+	}
+	return 0, false
+}
+
+// backgroundStarvationLimit is the number of interactive dials served while
+// background dials are waiting, before a background dial is served.
+const backgroundStarvationLimit = 8
+
+type dialPriorityKey struct{}
+
+// WithDialPriority sets the priority of dials made with ctx.
+func WithDialPriority(ctx context.Context, priority DialPriority) context.Context {
+	return context.WithValue(ctx, dialPriorityKey{}, priority)
+}
+
+// dialPriorityOf returns the priority of dials made with ctx.
+func dialPriorityOf(ctx context.Context) DialPriority {
+	if hasBootstrapPriority(ctx) {
+		return InteractiveDial
+	}
+	if priority, _ := ctx.Value(dialPriorityKey{}).(DialPriority); priority == BackgroundDial {
+		return BackgroundDial
+	}
+	return InteractiveDial
+}
+
+// priorityLimiter limits concurrency, serving waiting interactive callers
+// before background callers.
+type priorityLimiter struct {
+	mu      sync.Mutex
+	size    int
+	free    int
+	closed  bool
+	done    chan struct{}
+	waiting [2][]chan struct{}
+	// skipped is the number of interactive callers served while background callers were waiting
+	skipped int
+}
+
+// init initializes the limiter to size slots.
+func (limiter *priorityLimiter) init(size int) {
+	limiter.size = size
+	limiter.free = size
+	limiter.done = make(chan struct{})
+}
+
+// Lock waits for a slot, it returns false when ctx is canceled or the limiter is closed.
+func (limiter *priorityLimiter) Lock(ctx context.Context, priority DialPriority) bool {
+	limiter.mu.Lock()
+	if limiter.closed {
+		limiter.mu.Unlock()
+		return false
+	}
+	if limiter.free > 0 {
+		limiter.free--
+		limiter.mu.Unlock()
+		return true
+	}
+	granted := make(chan struct{})
+	limiter.waiting[priority] = append(limiter.waiting[priority], granted)
+	limiter.mu.Unlock()
+
+	select {
+	case <-granted:
+		return true
+	case <-ctx.Done():
+	case <-limiter.done:
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	queue := limiter.waiting[priority]
+	for i, waiter := range queue {
+		if waiter == granted {
+			limiter.waiting[priority] = append(queue[:i:i], queue[i+1:]...)
+			return false
+		}
+	}
+	// the slot was granted concurrently, pass it on
+	limiter.release()
+	return false
+}
+
+// Unlock releases a slot.
+func (limiter *priorityLimiter) Unlock() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.release()
+}
+
+// release hands the slot to the next waiter, if any.
+func (limiter *priorityLimiter) release() {
+	interactive, background := limiter.waiting[InteractiveDial], limiter.waiting[BackgroundDial]
+	switch {
+	case len(background) > 0 && (len(interactive) == 0 || limiter.skipped >= backgroundStarvationLimit):
+		limiter.waiting[BackgroundDial] = background[1:]
+		limiter.skipped = 0
+		close(background[0])
+	case len(interactive) > 0:
+		limiter.waiting[InteractiveDial] = interactive[1:]
+		if len(background) > 0 {
+			limiter.skipped++
+		}
+		close(interactive[0])
+	default:
+		limiter.free++
+	}
+}
+
+// InUse returns the number of locked slots.
+func (limiter *priorityLimiter) InUse() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return limiter.size - limiter.free
+}
+
+// Close makes waiting and further calls to Lock fail.
+func (limiter *priorityLimiter) Close() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if !limiter.closed {
+		limiter.closed = true
+		close(limiter.done)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+)
+
+func TestPriorityLimiter(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	var limiter priorityLimiter
+	limiter.init(1)
+	defer limiter.Close()
+
+	// queue waits with priority, returning the order in which they were served
+	served := make(chan string, 100)
+	queue := func(name string, priority DialPriority) {
+		waiting := limiter.queued(priority)
+		ctx.Go(func() error {
+			if limiter.Lock(ctx, priority) {
+				served <- name
+			}
+			return nil
+		})
+		for limiter.queued(priority) == waiting {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	next := func() string {
+		limiter.Unlock()
+		return <-served
+	}
+
+	require.True(t, limiter.Lock(ctx, BackgroundDial))
+
+	{ // interactive waits are served first
+		queue("background-1", BackgroundDial)
+		queue("background-2", BackgroundDial)
+		queue("interactive", InteractiveDial)
+
+		assert.Equal(t, "interactive", next())
+		assert.Equal(t, "background-1", next())
+		assert.Equal(t, "background-2", next())
+	}
+
+	{ // background waits aren't starved
+		queue("background", BackgroundDial)
+		for i := 0; i < backgroundStarvationLimit; i++ {
+			queue("interactive", InteractiveDial)
+			assert.Equal(t, "interactive", next())
+		}
+		queue("interactive", InteractiveDial)
+		assert.Equal(t, "background", next())
+		assert.Equal(t, "interactive", next())
+	}
+
+	{ // canceled waits are removed
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.False(t, limiter.Lock(canceled, InteractiveDial))
+		assert.Zero(t, limiter.queued(InteractiveDial))
+
+		queue("interactive", InteractiveDial)
+		assert.Equal(t, "interactive", next())
+	}
+
+	{ // closing fails waits
+		done := make(chan bool)
+		go func() { done <- limiter.Lock(ctx, InteractiveDial) }()
+		for limiter.queued(InteractiveDial) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		limiter.Close()
+		assert.False(t, <-done)
+		assert.False(t, limiter.Lock(ctx, InteractiveDial))
+	}
+}
+
+func TestDialPriorityOf(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, InteractiveDial, dialPriorityOf(ctx))
+	assert.Equal(t, BackgroundDial, dialPriorityOf(WithDialPriority(ctx, BackgroundDial)))
+	assert.Equal(t, InteractiveDial, dialPriorityOf(withBootstrapPriority(WithDialPriority(ctx, BackgroundDial))))
+	assert.Equal(t, InteractiveDial, dialPriorityOf(WithDialPriority(ctx, DialPriority(42))))
+}
+
+// queued returns the number of waits with priority.
+func (limiter *priorityLimiter) queued(priority DialPriority) int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return len(limiter.waiting[priority])
+}
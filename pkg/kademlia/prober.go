@@ -0,0 +1,181 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/sync2"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// ProberConfig defines how often a prober pings its nodes and when it
+// considers them dead.
+type ProberConfig struct {
+	Interval         time.Duration `help:"how often the nodes are pinged" default:"1m"`
+	FailureThreshold int           `help:"number of consecutive failed pings after which a node is considered dead" default:"3"`
+}
+
+// NodeHealth is the health of a node pinged by a prober.
+type NodeHealth struct {
+	Node pb.Node
+	// Alive is false after FailureThreshold consecutive failed pings, nodes
+	// are alive until then, also before the first ping.
+	Alive bool
+	// LastSeen is the time of the last successful ping, zero when none was.
+	LastSeen time.Time
+	// Failures is the number of consecutive failed pings.
+	Failures int
+	// RTT is the round trip time of the last successful ping.
+	RTT time.Duration
+}
+
+// Prober pings a set of nodes periodically, keeping track of their health and
+// reporting when they become dead or alive again.
+type Prober struct {
+	log      *zap.Logger
+	dialer   *Dialer
+	config   ProberConfig
+	onChange func(health NodeHealth)
+
+	Loop *sync2.Cycle
+
+	mu     sync.Mutex
+	health map[storj.NodeID]*NodeHealth
+	order  []storj.NodeID
+}
+
+// NewProber creates a prober pinging nodes with dialer. onChange, when not
+// nil, is called with the health of a node when it becomes dead or alive again.
+func NewProber(log *zap.Logger, dialer *Dialer, nodes []pb.Node, config ProberConfig, onChange func(health NodeHealth)) *Prober {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 1
+	}
+	prober := &Prober{
+		log:      log,
+		dialer:   dialer,
+		config:   config,
+		onChange: onChange,
+		Loop:     sync2.NewCycle(config.Interval),
+		health:   map[storj.NodeID]*NodeHealth{},
+	}
+	for _, node := range nodes {
+		if _, exists := prober.health[node.Id]; exists {
+			continue
+		}
+		prober.health[node.Id] = &NodeHealth{Node: node, Alive: true}
+		prober.order = append(prober.order, node.Id)
+	}
+	return prober
+}
+
+// Run pings the nodes every interval until ctx is done or the prober is closed.
+func (prober *Prober) Run(ctx context.Context) (err error) {
+	defer prober.dialer.mon.Task()(&ctx)(&err)
+	return prober.Loop.Run(ctx, func(ctx context.Context) error {
+		prober.probe(ctx)
+		return nil
+	})
+}
+
+// Close stops the prober.
+func (prober *Prober) Close() error {
+	prober.Loop.Close()
+	return nil
+}
+
+// probe pings every node once. The pings are spread over half the interval,
+// so that the nodes aren't all dialed at the same time.
+func (prober *Prober) probe(ctx context.Context) {
+	defer prober.dialer.mon.Task()(&ctx)(nil)
+
+	prober.mu.Lock()
+	nodes := make([]pb.Node, 0, len(prober.order))
+	for _, id := range prober.order {
+		nodes = append(nodes, prober.health[id].Node)
+	}
+	prober.mu.Unlock()
+	if len(nodes) == 0 {
+		return
+	}
+
+	spread := prober.config.Interval / time.Duration(2*len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		if i > 0 && !sync2.Sleep(ctx, spread) {
+			break
+		}
+		node := node
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rtt, err := prober.dialer.AlivenessCheck(ctx, node)
+			if err != nil && ctx.Err() != nil {
+				// the prober stopped, the node didn't fail
+				return
+			}
+			prober.update(node.Id, rtt, err)
+		}()
+	}
+	wg.Wait()
+}
+
+// update records the outcome of a ping of the node with id and reports when
+// the node became dead or alive again.
+func (prober *Prober) update(id storj.NodeID, rtt time.Duration, err error) {
+	prober.mu.Lock()
+	health := prober.health[id]
+	wasAlive := health.Alive
+	if err != nil {
+		health.Failures++
+		if health.Failures >= prober.config.FailureThreshold {
+			health.Alive = false
+		}
+	} else {
+		health.Alive = true
+		health.Failures = 0
+		health.LastSeen = time.Now()
+		health.RTT = rtt
+	}
+	changed := *health
+	prober.mu.Unlock()
+
+	if err != nil {
+		prober.log.Debug("probe failed", zap.Stringer("Node ID", id), zap.Int("failures", changed.Failures), zap.Error(err))
+	}
+	if changed.Alive == wasAlive {
+		return
+	}
+	if changed.Alive {
+		prober.dialer.mon.Counter("prober_node_alive").Inc(1)
+		prober.log.Info("node is alive again", zap.Stringer("Node ID", id))
+	} else {
+		prober.dialer.mon.Counter("prober_node_dead").Inc(1)
+		prober.log.Info("node is dead", zap.Stringer("Node ID", id), zap.Int("failures", changed.Failures))
+	}
+	if prober.onChange != nil {
+		prober.onChange(changed)
+	}
+}
+
+// Snapshot returns the current health of the nodes, sorted by node ID.
+func (prober *Prober) Snapshot() []NodeHealth {
+	prober.mu.Lock()
+	defer prober.mu.Unlock()
+
+	snapshot := make([]NodeHealth, 0, len(prober.health))
+	for _, health := range prober.health {
+		snapshot = append(snapshot, *health)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Node.Id.Less(snapshot[j].Node.Id)
+	})
+	return snapshot
+}
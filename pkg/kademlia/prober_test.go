@@ -0,0 +1,83 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestProber(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 0, StorageNodeCount: 2, UplinkCount: 1,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		dialer := kademlia.NewDialer(zaptest.NewLogger(t), planet.Uplinks[0].Transport)
+		defer ctx.Check(dialer.Close)
+
+		var nodes []pb.Node
+		for _, peer := range planet.StorageNodes {
+			nodes = append(nodes, peer.Local().Node)
+		}
+
+		const interval, threshold = 200 * time.Millisecond, 2
+		changes := make(chan kademlia.NodeHealth, len(nodes))
+		prober := kademlia.NewProber(zaptest.NewLogger(t), dialer, nodes, kademlia.ProberConfig{
+			Interval:         interval,
+			FailureThreshold: threshold,
+		}, func(health kademlia.NodeHealth) {
+			changes <- health
+		})
+		ctx.Go(func() error {
+			return prober.Run(ctx)
+		})
+		defer ctx.Check(prober.Close)
+
+		prober.Loop.TriggerWait()
+		for _, health := range prober.Snapshot() {
+			assert.True(t, health.Alive, health.Node.Id)
+			assert.False(t, health.LastSeen.IsZero(), health.Node.Id)
+			assert.Zero(t, health.Failures, health.Node.Id)
+			assert.NotZero(t, health.RTT, health.Node.Id)
+		}
+
+		stopped := planet.StorageNodes[0]
+		require.NoError(t, planet.StopPeer(stopped))
+		start := time.Now()
+
+		select {
+		case health := <-changes:
+			assert.Equal(t, stopped.ID(), health.Node.Id)
+			assert.False(t, health.Alive)
+			assert.Equal(t, threshold, health.Failures)
+			// the round running when the node stopped may not fail
+			assert.True(t, time.Since(start) < (threshold+2)*interval, time.Since(start))
+		case <-time.After(10 * (threshold + 2) * interval):
+			t.Fatal("dead node wasn't reported")
+		}
+
+		for _, health := range prober.Snapshot() {
+			if health.Node.Id == stopped.ID() {
+				assert.False(t, health.Alive)
+				assert.True(t, health.Failures >= threshold)
+			} else {
+				assert.True(t, health.Alive)
+				assert.Zero(t, health.Failures)
+			}
+		}
+		select {
+		case health := <-changes:
+			t.Fatalf("unexpected change %+v", health)
+		default:
+		}
+	})
+}
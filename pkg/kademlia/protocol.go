@@ -0,0 +1,65 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/storj"
+)
+
+// minimumProtocol is the oldest protocol version served by an RPC, RPCs that
+// aren't listed serve peers of any version.
+var minimumProtocol = map[string]version.Protocol{
+	"QueryStream": version.ProtocolQueryStream,
+}
+
+// checkProtocol rejects requests from peers too old for rpc.
+func (endpoint *Endpoint) checkProtocol(ctx context.Context, rpc string) error {
+	minimum, ok := minimumProtocol[rpc]
+	if !ok {
+		return nil
+	}
+	err := version.CheckProtocol(ctx, rpc, minimum)
+	if err != nil {
+		endpoint.service.mon.Counter("protocol_rejected").Inc(1)
+		return statusError(codes.FailedPrecondition, ErrorCodeProtocolTooOld, status.Convert(err).Message())
+	}
+	return nil
+}
+
+// advertiseProtocol sends the protocol version of this node in the response header.
+func advertiseProtocol(ctx context.Context) {
+	// fails only when ctx doesn't belong to a server call
+	_ = grpc.SetHeader(ctx, version.ProtocolHeader(version.CurrentProtocol))
+}
+
+// Protocol returns the last protocol version advertised by the node, the
+// versions of nodes with a persisted identity are known across restarts.
+func (dialer *Dialer) Protocol(id storj.NodeID) (protocol version.Protocol, known bool) {
+	if value, ok := dialer.protocols.Get(id); ok {
+		return value.(version.Protocol), true
+	}
+	return dialer.identities.protocol(id)
+}
+
+// setProtocol remembers the protocol version advertised by the node.
+func (dialer *Dialer) setProtocol(id storj.NodeID, protocol version.Protocol) {
+	if id.IsZero() {
+		return
+	}
+	dialer.protocols.Add(id, protocol)
+	dialer.identities.setProtocol(id, protocol)
+}
+
+// setProtocolFromHeader remembers the protocol version in the response header of the node.
+func (dialer *Dialer) setProtocolFromHeader(id storj.NodeID, header metadata.MD) {
+	dialer.setProtocol(id, version.ProtocolFromMetadata(header))
+}
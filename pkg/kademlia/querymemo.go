@@ -0,0 +1,139 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// queryKey identifies the result of a query, every input the closest nodes
+// depend on must be part of it, so that callers with different inputs never
+// share a result.
+type queryKey struct {
+	target storj.NodeID
+	limit  int
+}
+
+// string returns the singleflight key.
+func (key queryKey) string() string {
+	return key.target.String() + "/" + strconv.Itoa(key.limit)
+}
+
+// queryResult is the shared result of a query.
+type queryResult struct {
+	nodes    []*pb.Node
+	computed time.Time
+}
+
+// queryMemo shares the closest nodes to a target between identical queries
+// arriving within a short window, since popular targets, like the ID of a
+// satellite, are looked up by many nodes at once and the routing table
+// barely changes within the window.
+//
+// The shared nodes are sorted by distance to the target and must not be
+// modified by the callers.
+type queryMemo struct {
+	window time.Duration
+	find   func(target storj.NodeID, limit int) ([]*pb.Node, error)
+
+	group singleflight.Group
+	mon   *monkit.Scope
+
+	mu      sync.Mutex
+	results map[queryKey]queryResult
+}
+
+// newQueryMemo returns a memo computing the results with find, a
+// non-positive window only shares concurrent computations.
+func newQueryMemo(window time.Duration, find func(target storj.NodeID, limit int) ([]*pb.Node, error)) *queryMemo {
+	return &queryMemo{
+		window:  window,
+		find:    find,
+		results: map[queryKey]queryResult{},
+		mon:     mon,
+	}
+}
+
+// closest returns the limit closest nodes to target, sorted by distance.
+func (memo *queryMemo) closest(target storj.NodeID, limit int) ([]*pb.Node, error) {
+	key := queryKey{target: target, limit: limit}
+	if nodes, ok := memo.get(key, time.Now()); ok {
+		memo.mon.Counter("query_memo_hit").Inc(1)
+		return nodes, nil
+	}
+
+	result, err, shared := memo.group.Do(key.string(), func() (interface{}, error) {
+		nodes, err := memo.find(target, limit)
+		if err != nil {
+			return nil, err
+		}
+		if !NodesSortedByXOR(nodes, target) {
+			SortNodesByXOR(nodes, target)
+		}
+		memo.put(key, nodes, time.Now())
+		return nodes, nil
+	})
+	if shared {
+		memo.mon.Counter("query_memo_shared").Inc(1)
+	} else {
+		memo.mon.Counter("query_memo_miss").Inc(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*pb.Node), nil
+}
+
+// get returns the result for key when it was computed within the window.
+func (memo *queryMemo) get(key queryKey, now time.Time) ([]*pb.Node, bool) {
+	memo.mu.Lock()
+	defer memo.mu.Unlock()
+
+	result, ok := memo.results[key]
+	if !ok || now.Sub(result.computed) >= memo.window {
+		return nil, false
+	}
+	return result.nodes, true
+}
+
+// put remembers the result for key and forgets the expired results.
+func (memo *queryMemo) put(key queryKey, nodes []*pb.Node, now time.Time) {
+	if memo.window <= 0 {
+		return
+	}
+
+	memo.mu.Lock()
+	defer memo.mu.Unlock()
+
+	for other, result := range memo.results {
+		if now.Sub(result.computed) >= memo.window {
+			delete(memo.results, other)
+		}
+	}
+	memo.results[key] = queryResult{nodes: nodes, computed: now}
+}
+
+// forget forgets the results including the node with id, such as when the
+// node left, so that it isn't shared with further queries.
+func (memo *queryMemo) forget(id storj.NodeID) {
+	memo.mu.Lock()
+	defer memo.mu.Unlock()
+
+	for key, result := range memo.results {
+		for _, node := range result.nodes {
+			if node.Id == id {
+				delete(memo.results, key)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// countingTable is a fake routing table counting the walks to find the closest nodes.
+type countingTable struct {
+	walks int32
+	delay time.Duration
+}
+
+func (table *countingTable) findNear(target storj.NodeID, limit int) ([]*pb.Node, error) {
+	atomic.AddInt32(&table.walks, 1)
+	time.Sleep(table.delay)
+	var nodes []*pb.Node
+	for i := limit; i > 0; i-- {
+		nodes = append(nodes, &pb.Node{Id: storj.NodeID{byte(i)}})
+	}
+	return nodes, nil
+}
+
+func (table *countingTable) walkCount() int32 { return atomic.LoadInt32(&table.walks) }
+
+func TestQueryMemoConcurrent(t *testing.T) {
+	table := &countingTable{delay: 50 * time.Millisecond}
+	memo := newQueryMemo(500*time.Millisecond, table.findNear)
+	target := storj.NodeID{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodes, err := memo.closest(target, 5)
+			if assert.NoError(t, err) && assert.Len(t, nodes, 5) {
+				// the shared result is sorted
+				assert.Equal(t, storj.NodeID{1}, nodes[0].Id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, table.walkCount() <= 3, "%d table walks", table.walkCount())
+}
+
+func TestQueryMemoWindow(t *testing.T) {
+	table := &countingTable{}
+	memo := newQueryMemo(time.Hour, table.findNear)
+	target := storj.NodeID{1}
+
+	_, err := memo.closest(target, 5)
+	require.NoError(t, err)
+	_, err = memo.closest(target, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, table.walkCount())
+
+	// different inputs are never shared
+	_, err = memo.closest(target, 6)
+	require.NoError(t, err)
+	_, err = memo.closest(storj.NodeID{2}, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, table.walkCount())
+
+	// expired results are recomputed and forgotten
+	now := time.Now().Add(2 * time.Hour)
+	_, ok := memo.get(queryKey{target: target, limit: 5}, now)
+	assert.False(t, ok)
+	memo.put(queryKey{target: target, limit: 7}, nil, now)
+	assert.Len(t, memo.results, 1)
+
+	// without a window only concurrent queries are shared
+	memo = newQueryMemo(0, table.findNear)
+	_, err = memo.closest(target, 5)
+	require.NoError(t, err)
+	_, err = memo.closest(target, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, table.walkCount())
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+)
+
+// defaultRefreshInterval is used when the configuration doesn't set a refresh interval
+const defaultRefreshInterval = 5 * time.Minute
+
+// ErrImmutableConfig is the class of errors for reloaded configurations
+// changing settings that need a restart, such as addresses and database paths.
+var ErrImmutableConfig = errs.Class("immutable config")
+
+// tunables are the settings of a Kademlia changed by reloading the configuration.
+type tunables struct {
+	alpha                int
+	streamLimit          int
+	refreshInterval      time.Duration
+	neighborhoodInterval time.Duration
+	bootstrapBackoffMax  time.Duration
+	bootstrapBackoffBase time.Duration
+}
+
+func newTunables(config Config) tunables {
+	refreshInterval := config.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = defaultRefreshInterval
+	}
+	return tunables{
+		alpha:                config.Alpha,
+		streamLimit:          config.StreamLimit,
+		refreshInterval:      refreshInterval,
+		neighborhoodInterval: config.Neighborhood.Interval,
+		bootstrapBackoffMax:  config.BootstrapBackoffMax,
+		bootstrapBackoffBase: config.BootstrapBackoffBase,
+	}
+}
+
+// settings returns the tunables currently configured.
+func (k *Kademlia) settings() tunables {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.tunables
+}
+
+// Reload changes the operational settings of a running Kademlia to the ones
+// of config: Alpha, StreamLimit, RefreshInterval, the bootstrap backoff,
+// Compression, Dialer, the Neighborhood interval and ClockSkewWarning, and
+// ExternalAddress while the node has no valid address. The settings are
+// validated first, and the whole configuration is rejected with
+// ErrImmutableConfig when any other setting differs from the current one.
+// The cycles continue with the new intervals, waiting for a run in progress.
+func (k *Kademlia) Reload(config Config) error {
+	k.reloadMu.Lock()
+	defer k.reloadMu.Unlock()
+
+	if err := config.verifyTunables(); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	current := k.config
+	k.mu.Unlock()
+
+	// the address of a node that isn't ready yet can be set
+	becomesReady := !k.Ready() && validAddress(config.ExternalAddress)
+	if becomesReady {
+		current.ExternalAddress = config.ExternalAddress
+	}
+
+	if changed := immutableChanges(current, config); len(changed) > 0 {
+		return ErrImmutableConfig.New("changing %s requires a restart", strings.Join(changed, ", "))
+	}
+	if (current.Neighborhood.Interval > 0) != (config.Neighborhood.Interval > 0) {
+		return ErrImmutableConfig.New("enabling or disabling the neighborhood monitor requires a restart")
+	}
+
+	// verified with the tunables
+	policies, _ := ParseRPCPolicies(config.Dialer.Policies)
+
+	settings := newTunables(config)
+	k.mu.Lock()
+	previous := k.tunables
+	k.config, k.tunables = config, settings
+	k.mu.Unlock()
+	k.dialer.setSettings(config.Compression, config.Dialer, policies)
+	if becomesReady {
+		k.setAddress(config.ExternalAddress)
+	}
+
+	if k.running {
+		if settings.refreshInterval != previous.refreshInterval {
+			k.RefreshBuckets.ChangeInterval(settings.refreshInterval)
+		}
+		if k.neighborhood != nil && settings.neighborhoodInterval != previous.neighborhoodInterval {
+			k.Neighborhood.ChangeInterval(settings.neighborhoodInterval)
+		}
+	}
+
+	k.log.Info("configuration reloaded",
+		zap.Int("alpha", settings.alpha),
+		zap.Duration("refresh interval", settings.refreshInterval),
+		zap.Duration("neighborhood interval", settings.neighborhoodInterval))
+	return nil
+}
+
+// SetConfigLoader sets how ReloadConfig reads the configuration again,
+// such as from the configuration file of the process.
+func (k *Kademlia) SetConfigLoader(load func() (Config, error)) {
+	k.reloadMu.Lock()
+	defer k.reloadMu.Unlock()
+	k.loadConfig = load
+}
+
+// ReloadConfig reads the configuration again with the loader set by
+// SetConfigLoader and reloads it, see Reload.
+func (k *Kademlia) ReloadConfig(ctx context.Context) (err error) {
+	defer k.mon.Task()(&ctx)(&err)
+
+	k.reloadMu.Lock()
+	load := k.loadConfig
+	k.reloadMu.Unlock()
+	if load == nil {
+		return Error.New("configuration can't be reloaded")
+	}
+
+	config, err := load()
+	if err != nil {
+		return Error.Wrap(err)
+	}
+	return k.Reload(config)
+}
+
+// verifyTunables verifies whether the settings changed by Reload are valid.
+func (c Config) verifyTunables() error {
+	var group errs.Group
+	if c.Alpha <= 0 {
+		group.Add(Error.New("alpha must be positive, got %d", c.Alpha))
+	}
+	if c.StreamLimit < 0 {
+		group.Add(Error.New("stream limit must not be negative, got %d", c.StreamLimit))
+	}
+	if c.RefreshInterval < 0 {
+		group.Add(Error.New("refresh interval must not be negative, got %v", c.RefreshInterval))
+	}
+	if c.Neighborhood.Interval < 0 {
+		group.Add(Error.New("neighborhood interval must not be negative, got %v", c.Neighborhood.Interval))
+	}
+	if c.BootstrapBackoffBase <= 0 || c.BootstrapBackoffBase > c.BootstrapBackoffMax {
+		group.Add(Error.New("bootstrap backoff base must be positive and at most the maximum, got %v and %v",
+			c.BootstrapBackoffBase, c.BootstrapBackoffMax))
+	}
+	if _, err := ParseRPCPolicies(c.Dialer.Policies); err != nil {
+		group.Add(err)
+	}
+	if c.Dialer.UnverifiedLifetime < 0 {
+		group.Add(Error.New("unverified connection lifetime must not be negative, got %v", c.Dialer.UnverifiedLifetime))
+	}
+	if retry := c.Dialer.Retry; retry.InitialBackoff < 0 || retry.MaxBackoff < 0 || retry.Jitter < 0 || retry.Jitter > 1 {
+		group.Add(Error.New("retry backoffs must not be negative and jitter must be between 0 and 1, got %v, %v and %v",
+			retry.InitialBackoff, retry.MaxBackoff, retry.Jitter))
+	}
+	return group.Err()
+}
+
+// immutableChanges returns the names of the settings of next that differ
+// from current and can't be changed by Reload.
+func immutableChanges(current, next Config) []string {
+	// copy the tunables, so that only immutable settings differ
+	next.Alpha = current.Alpha
+	next.StreamLimit = current.StreamLimit
+	next.RefreshInterval = current.RefreshInterval
+	next.BootstrapBackoffMax = current.BootstrapBackoffMax
+	next.BootstrapBackoffBase = current.BootstrapBackoffBase
+	next.Compression = current.Compression
+	next.Dialer = current.Dialer
+	next.Neighborhood.Interval = current.Neighborhood.Interval
+	next.ClockSkewWarning = current.ClockSkewWarning
+
+	var changed []string
+	before, after := reflect.ValueOf(current), reflect.ValueOf(next)
+	for i := 0; i < before.NumField(); i++ {
+		if !reflect.DeepEqual(before.Field(i).Interface(), after.Field(i).Interface()) {
+			changed = append(changed, before.Type().Field(i).Name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/sync2"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestReloadRefreshInterval(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	ident, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, ident, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	refreshes := k.mon.FuncNamed("refresh")
+	initial := refreshes.Success()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx.Go(func() error {
+		err := k.Run(runCtx)
+		if err == context.Canceled {
+			return nil
+		}
+		return err
+	})
+
+	// with the default interval the first refresh is delayed by the splay
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, initial, refreshes.Success())
+	next := k.RefreshBuckets.NextRun()
+	assert.False(t, next.IsZero())
+	assert.True(t, time.Until(next) <= time.Duration(sync2.SplayInitial*float64(k.settings().refreshInterval)), "next refresh at %v", next)
+
+	k.mu.Lock()
+	config := k.config
+	k.mu.Unlock()
+
+	config.RefreshInterval = 10 * time.Millisecond
+	config.Alpha = 3
+	require.NoError(t, k.Reload(config))
+	assert.Equal(t, 3, k.settings().alpha)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for refreshes.Success() < initial+6 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, refreshes.Success() >= initial+6, "refresh interval didn't change")
+
+	// invalid and immutable settings are rejected, and the settings remain
+	invalid := config
+	invalid.Alpha = 0
+	assert.Error(t, k.Reload(invalid))
+
+	immutable := config
+	immutable.Alpha = 4
+	immutable.DBPath = "elsewhere"
+	immutable.ExternalAddress = "127.0.0.1:1"
+	err = k.Reload(immutable)
+	require.True(t, ErrImmutableConfig.Has(err), err)
+	assert.Contains(t, err.Error(), "DBPath, ExternalAddress")
+	assert.Equal(t, 3, k.settings().alpha)
+}
@@ -5,15 +5,24 @@ package kademlia
 
 import (
 	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
 )
 
+// addToReplacementCache adds node to the cache of the bucket, evicting the oldest node when full.
+// An entry of the node with another address is replaced, so that the cache
+// holds at most one entry per node. rt.mutex must be held.
 func (rt *RoutingTable) addToReplacementCache(kadBucketID bucketID, node *pb.Node) {
 	rt.rcMutex.Lock()
 	defer rt.rcMutex.Unlock()
+	for _, previous := range rt.uncacheLocked(node.Id) {
+		rt.addressChanged(previous, node)
+	}
 	nodes := rt.replacementCache[kadBucketID]
 	nodes = append(nodes, node)
 
 	if len(nodes) > rt.rcBucketSize {
+		// the evicted node is neither in a bucket nor in the cache anymore
+		delete(rt.lastSuccess, nodes[0].Id)
 		copy(nodes, nodes[1:])
 		nodes = nodes[:len(nodes)-1]
 	}
@@ -32,3 +41,26 @@ func (rt *RoutingTable) removeFromReplacementCache(kadBucketID bucketID, node *p
 	}
 	rt.replacementCache[kadBucketID] = nodes
 }
+
+// uncache removes the entries of the node with id from the replacement caches
+// and returns them, such as when the node got a slot in a bucket.
+func (rt *RoutingTable) uncache(id storj.NodeID) []*pb.Node {
+	rt.rcMutex.Lock()
+	defer rt.rcMutex.Unlock()
+	return rt.uncacheLocked(id)
+}
+
+// uncacheLocked is uncache with rt.rcMutex held.
+func (rt *RoutingTable) uncacheLocked(id storj.NodeID) (removed []*pb.Node) {
+	for kadBucketID, nodes := range rt.replacementCache {
+		for i := 0; i < len(nodes); i++ {
+			if nodes[i].Id == id {
+				removed = append(removed, nodes[i])
+				nodes = append(nodes[:i], nodes[i+1:]...)
+				i--
+			}
+		}
+		rt.replacementCache[kadBucketID] = nodes
+	}
+	return removed
+}
@@ -6,17 +6,19 @@ package kademlia
 import (
 	"context"
 	"encoding/binary"
-	"fmt"
 	"sync"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
+	"storj.io/storj/internal/memory"
 	"storj.io/storj/pkg/overlay"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
 	"storj.io/storj/storage"
 )
 
@@ -49,8 +51,11 @@ var emptyBucketID = bucketID{}
 
 // RoutingTableConfig configures the routing table
 type RoutingTableConfig struct {
-	BucketSize           int `help:"size of each Kademlia bucket" default:"20"`
-	ReplacementCacheSize int `help:"size of Kademlia replacement cache" default:"5"`
+	BucketSize           int         `help:"size of each Kademlia bucket" default:"20"`
+	ReplacementCacheSize int         `help:"size of Kademlia replacement cache" default:"5"`
+	MemoryBudget         memory.Size `help:"approximate memory limit for the in-memory routing table structures and kademlia caches, 0 disables the limit" default:"0"`
+	Tenure               TenureConfig
+	Conflicts            AddressConflictConfig
 }
 
 // RoutingTable implements the RoutingTable interface
@@ -63,18 +68,55 @@ type RoutingTable struct {
 	mutex            *sync.Mutex
 	rcMutex          *sync.Mutex
 	seen             map[storj.NodeID]*pb.Node
+	lastSuccess      map[storj.NodeID]time.Time
 	replacementCache map[bucketID][]*pb.Node
+	pinned           map[storj.NodeID]*PinnedNode
+	pinnedDB         storage.KeyValueStore // nil when pinned nodes aren't persisted
+	antechamber      map[storj.NodeID]*QuarantinedNode
+	writer           *routingWriter
 	bucketSize       int // max number of nodes stored in a kbucket = 20 (k)
 	rcBucketSize     int // replacementCache bucket max length
+	memoryBudget     memory.Size
+	mon              *monkit.Scope
+
+	// what is known about the contacts in the buckets, and about the
+	// recently evicted ones
+	history map[storj.NodeID]*ContactHistory
+	evicted *evictedContacts
+	tenure  TenureConfig
+
+	// the addresses recently verified for each node, nil when conflicts
+	// between them aren't detected
+	conflicts *addressConflicts
+
+	// caches outside of the routing table evicted when over the memory budget
+	caches []budgetedCache
+
+	// nodes contacted through the transport are no longer reported as not
+	// found, set before the routing table is used
+	negative *negativeCache
+
+	// nodes added, evicted and changing addresses are published to bus,
+	// set before the routing table is used
+	bus *Bus
 }
 
 // NewRoutingTable returns a newly configured instance of a RoutingTable
 func NewRoutingTable(logger *zap.Logger, localNode *overlay.NodeDossier, kdb, ndb storage.KeyValueStore, config *RoutingTableConfig) (*RoutingTable, error) {
 	if config == nil || config.BucketSize == 0 || config.ReplacementCacheSize == 0 {
 		// TODO: handle this more nicely
+		budget := memory.Size(0)
+		var tenure TenureConfig
+		var conflicts AddressConflictConfig
+		if config != nil {
+			budget, tenure, conflicts = config.MemoryBudget, config.Tenure, config.Conflicts
+		}
 		config = &RoutingTableConfig{
 			BucketSize:           20,
 			ReplacementCacheSize: 5,
+			MemoryBudget:         budget,
+			Tenure:               tenure,
+			Conflicts:            conflicts,
 		}
 	}
 
@@ -88,11 +130,29 @@ func NewRoutingTable(logger *zap.Logger, localNode *overlay.NodeDossier, kdb, nd
 		mutex:            &sync.Mutex{},
 		rcMutex:          &sync.Mutex{},
 		seen:             make(map[storj.NodeID]*pb.Node),
+		lastSuccess:      make(map[storj.NodeID]time.Time),
 		replacementCache: make(map[bucketID][]*pb.Node),
+		pinned:           make(map[storj.NodeID]*PinnedNode),
+		antechamber:      make(map[storj.NodeID]*QuarantinedNode),
 
 		bucketSize:   config.BucketSize,
 		rcBucketSize: config.ReplacementCacheSize,
+		memoryBudget: config.MemoryBudget,
+		mon:          mon,
+
+		history: make(map[storj.NodeID]*ContactHistory),
+		evicted: newEvictedContacts(config.Tenure.EvictedSize),
+		tenure:  config.Tenure,
+
+		conflicts: newAddressConflicts(config.Conflicts),
+	}
+	if rt.evicted != nil {
+		rt.caches = append(rt.caches, rt.evicted)
+	}
+	if rt.conflicts != nil {
+		rt.caches = append(rt.caches, rt.conflicts)
 	}
+	rt.writer = newRoutingWriter(NewWorkerPool(logger.Named("writer"), localNode.Id.String(), 1), rt.applySeen)
 	ok, err := rt.addNode(&localNode.Node)
 	if !ok || err != nil {
 		return nil, RoutingErr.New("could not add localNode to routing table: %s", err)
@@ -100,9 +160,12 @@ func NewRoutingTable(logger *zap.Logger, localNode *overlay.NodeDossier, kdb, nd
 	return rt, nil
 }
 
-// Close closes without closing dependencies
+// Close applies the queued connection outcomes and stops applying new ones,
+// without closing dependencies
 func (rt *RoutingTable) Close() error {
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	return rt.writer.close(ctx)
 }
 
 // Local returns the local node
@@ -179,25 +242,55 @@ func (rt *RoutingTable) DumpNodes() ([]*pb.Node, error) {
 	return nodes, nodeErrors.Err()
 }
 
+// countNodes returns the number of nodes in the nodeBucketDB without unmarshaling them
+func (rt *RoutingTable) countNodes() (int, error) {
+	count := 0
+	err := rt.iterateNodes(storj.NodeID{}, func(storj.NodeID, []byte) error {
+		count++
+		return nil
+	}, false)
+	return count, err
+}
+
 // FindNear returns the node corresponding to the provided nodeID
-// returns all Nodes (excluding self) closest via XOR to the provided nodeID up to the provided limit
+// returns all Nodes (excluding self) closest via XOR to the provided nodeID up to the provided limit,
+// including pinned nodes
 func (rt *RoutingTable) FindNear(target storj.NodeID, limit int) ([]*pb.Node, error) {
+	closestNodes, err := rt.findNearUnpinned(target, limit)
+	if err != nil {
+		return closestNodes, err
+	}
+	return rt.mergePinned(closestNodes, target, limit), nil
+}
+
+// findNearUnpinned returns the nodes in the buckets (excluding self) closest
+// via XOR to target, up to limit.
+func (rt *RoutingTable) findNearUnpinned(target storj.NodeID, limit int) ([]*pb.Node, error) {
 	closestNodes := make([]*pb.Node, 0, limit+1)
+	// the node pushed out of the closest nodes is unmarshaled into again,
+	// instead of allocating a node for every closer candidate
+	var spare *pb.Node
 	err := rt.iterateNodes(storj.NodeID{}, func(newID storj.NodeID, protoNode []byte) error {
 		newPos := len(closestNodes)
 		for ; newPos > 0 && compareByXor(closestNodes[newPos-1].Id, newID, target) > 0; newPos-- {
 		}
 		if newPos != limit {
-			newNode := pb.Node{}
-			err := proto.Unmarshal(protoNode, &newNode)
+			newNode := spare
+			if newNode == nil {
+				newNode = &pb.Node{}
+			}
+			spare = nil
+			err := proto.Unmarshal(protoNode, newNode)
 			if err != nil {
 				return err
 			}
-			closestNodes = append(closestNodes, &newNode)
+			closestNodes = append(closestNodes, newNode)
 			if newPos != len(closestNodes) { //reorder
 				copy(closestNodes[newPos+1:], closestNodes[newPos:])
-				closestNodes[newPos] = &newNode
+				closestNodes[newPos] = newNode
 				if len(closestNodes) > limit {
+					spare = closestNodes[limit]
+					closestNodes[limit] = nil
 					closestNodes = closestNodes[:limit]
 				}
 			}
@@ -208,42 +301,94 @@ func (rt *RoutingTable) FindNear(target storj.NodeID, limit int) ([]*pb.Node, er
 }
 
 // ConnectionSuccess updates or adds a node to the routing table when
-// a successful connection is made to the node on the network
+// a successful connection is made to the node on the network. A node verified
+// at several addresses is kept at its preferred one, see AddressConflict, and
+// a node in the antechamber leaves it, see Quarantine.
 func (rt *RoutingTable) ConnectionSuccess(node *pb.Node) error {
 	// valid to connect to node without ID but don't store connection
 	if node.Id == (storj.NodeID{}) {
 		return nil
 	}
+	node = rt.verifiedAddress(node)
+	rt.promote(node)
 
 	rt.mutex.Lock()
 	rt.seen[node.Id] = node
+	rt.lastSuccess[node.Id] = time.Now()
 	rt.mutex.Unlock()
+
+	if rt.updatePinned(node) {
+		return nil
+	}
+
 	v, err := rt.nodeBucketDB.Get(storage.Key(node.Id.Bytes()))
 	if err != nil && !storage.ErrKeyNotFound.Has(err) {
 		return RoutingErr.New("could not get node %s", err)
 	}
+	defer rt.enforceMemoryBudget()
+
 	if v != nil {
+		var stored pb.Node
+		if err := proto.Unmarshal(v, &stored); err == nil {
+			rt.addressChanged(&stored, node)
+		}
 		err = rt.updateNode(node)
 		if err != nil {
 			return RoutingErr.New("could not update node %s", err)
 		}
+		rt.mutex.Lock()
+		rt.contactSucceeded(node.Id, time.Now())
+		rt.mutex.Unlock()
+		// a copy with the previous address may have been cached meanwhile
+		for _, previous := range rt.uncache(node.Id) {
+			rt.addressChanged(previous, node)
+		}
 		return nil
 	}
-	_, err = rt.addNode(node)
+	added, err := rt.addNode(node)
 	if err != nil {
 		return RoutingErr.New("could not add node %s", err)
 	}
+	if added {
+		rt.mutex.Lock()
+		rt.contactSucceeded(node.Id, time.Now())
+		rt.mutex.Unlock()
+	}
 
 	return nil
 }
 
 // ConnectionFailed removes a node from the routing table when
-// a connection fails for the node on the network
+// a connection fails for the node on the network. Nodes known for longer
+// than the tenure threshold are removed only once they failed more than the
+// grace of consecutive connections, and the history of removed nodes is
+// restored when they reappear. Failures at an alternate address of a node
+// verified at several addresses don't affect the node.
 func (rt *RoutingTable) ConnectionFailed(node *pb.Node) error {
-	err := rt.removeNode(node)
+	if rt.alternateFailed(node) {
+		return nil
+	}
+
+	rt.mutex.Lock()
+	delete(rt.lastSuccess, node.Id)
+	rt.mutex.Unlock()
+
+	// pinned nodes are never evicted
+	if rt.isPinned(node.Id) {
+		return nil
+	}
+	if rt.tolerateFailure(node.Id, time.Now()) {
+		return nil
+	}
+
+	evicted, err := rt.removeFromBuckets(node)
 	if err != nil {
 		return RoutingErr.New("could not remove node %s", err)
 	}
+	if evicted {
+		rt.bus.Publish(Event{Type: EventNodeEvicted, Time: time.Now(), Node: node.Id, Address: node.Address.GetAddress()})
+	}
+	rt.parkHistory(node.Id)
 	return nil
 }
 
@@ -290,18 +435,46 @@ func (rt *RoutingTable) iterateNodes(start storj.NodeID, f func(storj.NodeID, []
 	)
 }
 
-// ConnFailure implements the Transport failure function
+// ConnFailure implements the Transport failure function, the failure is
+// applied to the routing table asynchronously.
 func (rt *RoutingTable) ConnFailure(ctx context.Context, node *pb.Node, err error) {
-	err2 := rt.ConnectionFailed(node)
-	if err2 != nil {
-		zap.L().Debug(fmt.Sprintf("error with ConnFailure hook  %+v : %+v", err, err2))
-	}
+	rt.writer.enqueue(seenEvent{node: node, failed: true, self: transport.SelfDial.Has(err)})
 }
 
-// ConnSuccess implements the Transport success function
+// ConnSuccess implements the Transport success function, the success is
+// applied to the routing table asynchronously.
 func (rt *RoutingTable) ConnSuccess(ctx context.Context, node *pb.Node) {
-	err := rt.ConnectionSuccess(node)
-	if err != nil {
-		zap.L().Debug("connection success error:", zap.Error(err))
+	rt.negative.seen(node)
+	rt.writer.enqueue(seenEvent{node: node})
+}
+
+// applySeen applies a batch of queued connection outcomes in order.
+func (rt *RoutingTable) applySeen(events []seenEvent) {
+	for _, event := range events {
+		if event.failed {
+			// an entry pointing at this node is removed even when pinned
+			if event.self {
+				rt.Unpin(event.node.Id)
+			}
+			if err := rt.ConnectionFailed(event.node); err != nil {
+				rt.log.Debug("error with ConnFailure hook", zap.Error(err))
+			}
+			continue
+		}
+		if err := rt.ConnectionSuccess(event.node); err != nil {
+			rt.log.Debug("connection success error:", zap.Error(err))
+		}
 	}
 }
+
+// Flush blocks until all connection outcomes queued by ConnSuccess and
+// ConnFailure have been applied or ctx is done. Under heavy load the queue
+// may keep refilling, so Flush can take a while and callers should bound ctx.
+func (rt *RoutingTable) Flush(ctx context.Context) error {
+	return rt.writer.flush(ctx)
+}
+
+// QueueDepth returns the number of queued connection outcomes not yet applied.
+func (rt *RoutingTable) QueueDepth() int {
+	return rt.writer.queueDepth()
+}
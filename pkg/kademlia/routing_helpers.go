@@ -82,10 +82,15 @@ func (rt *RoutingTable) addNode(node *pb.Node) (bool, error) {
 	if err != nil {
 		return false, RoutingErr.New("could not add node to nodeBucketDB: %s", err)
 	}
+	// the node is in a bucket now, not waiting in a replacement cache
+	for _, previous := range rt.uncache(node.Id) {
+		rt.addressChanged(previous, node)
+	}
 	err = rt.createOrUpdateKBucket(kadBucketID, time.Now())
 	if err != nil {
 		return false, RoutingErr.New("could not create or update K bucket: %s", err)
 	}
+	rt.bus.Publish(Event{Type: EventNodeAdded, Time: time.Now(), Node: node.Id, Address: node.Address.GetAddress()})
 	return true, nil
 }
 
@@ -100,48 +105,58 @@ func (rt *RoutingTable) updateNode(node *pb.Node) error {
 
 // removeNode will remove churned nodes and replace those entries with nodes from the replacement cache.
 func (rt *RoutingTable) removeNode(node *pb.Node) error {
+	_, err := rt.removeFromBuckets(node)
+	return err
+}
+
+// removeFromBuckets removes node like removeNode, it returns whether node
+// was removed from the buckets.
+func (rt *RoutingTable) removeFromBuckets(node *pb.Node) (removed bool, err error) {
 	rt.mutex.Lock()
 	defer rt.mutex.Unlock()
 	kadBucketID, err := rt.getKBucketID(node.Id)
 
 	if err != nil {
-		return RoutingErr.New("could not get k bucket %s", err)
+		return false, RoutingErr.New("could not get k bucket %s", err)
 	}
 
 	existingMarshalled, err := rt.nodeBucketDB.Get(node.Id.Bytes())
 	if storage.ErrKeyNotFound.Has(err) {
 		//check replacement cache
 		rt.removeFromReplacementCache(kadBucketID, node)
-		return nil
+		delete(rt.lastSuccess, node.Id)
+		return false, nil
 	} else if err != nil {
-		return RoutingErr.New("could not get node %s", err)
+		return false, RoutingErr.New("could not get node %s", err)
 	}
 
 	var existing pb.Node
 	err = proto.Unmarshal(existingMarshalled, &existing)
 	if err != nil {
-		return RoutingErr.New("could not unmarshal node %s", err)
+		return false, RoutingErr.New("could not unmarshal node %s", err)
 	}
 
 	if !pb.AddressEqual(existing.Address, node.Address) {
 		// don't remove a node if the address is different
-		return nil
+		return false, nil
 	}
 	err = rt.nodeBucketDB.Delete(node.Id.Bytes())
 	if err != nil {
-		return RoutingErr.New("could not delete node %s", err)
+		return false, RoutingErr.New("could not delete node %s", err)
 	}
+	delete(rt.lastSuccess, node.Id)
 	nodes := rt.replacementCache[kadBucketID]
 	if len(nodes) == 0 {
-		return nil
+		return true, nil
 	}
-	err = rt.putNode(nodes[len(nodes)-1])
+	replacement := nodes[len(nodes)-1]
+	err = rt.putNode(replacement)
 	if err != nil {
-		return err
+		return true, err
 	}
 	rt.replacementCache[kadBucketID] = nodes[:len(nodes)-1]
-	return nil
-
+	rt.bus.Publish(Event{Type: EventNodeAdded, Time: time.Now(), Node: replacement.Id, Address: replacement.Address.GetAddress()})
+	return true, nil
 }
 
 // putNode: helper, adds or updates Node and ID to nodeBucketDB
@@ -27,6 +27,7 @@ import (
 type routingTableOpts struct {
 	bucketSize int
 	cacheSize  int
+	tenure     TenureConfig
 }
 
 // newTestRoutingTable returns a newly configured instance of a RoutingTable
@@ -38,6 +39,7 @@ func newTestRoutingTable(local *overlay.NodeDossier, opts routingTableOpts) (*Ro
 		opts.cacheSize = 2
 	}
 	rt := &RoutingTable{
+		log:          zap.L(),
 		self:         local,
 		kadBucketDB:  storelogger.New(zap.L().Named("rt.kad"), teststore.New()),
 		nodeBucketDB: storelogger.New(zap.L().Named("rt.node"), teststore.New()),
@@ -46,11 +48,19 @@ func newTestRoutingTable(local *overlay.NodeDossier, opts routingTableOpts) (*Ro
 		mutex:            &sync.Mutex{},
 		rcMutex:          &sync.Mutex{},
 		seen:             make(map[storj.NodeID]*pb.Node),
+		lastSuccess:      make(map[storj.NodeID]time.Time),
 		replacementCache: make(map[bucketID][]*pb.Node),
+		pinned:           make(map[storj.NodeID]*PinnedNode),
 
 		bucketSize:   opts.bucketSize,
 		rcBucketSize: opts.cacheSize,
+		mon:          mon,
+
+		history: make(map[storj.NodeID]*ContactHistory),
+		evicted: newEvictedContacts(opts.tenure.EvictedSize),
+		tenure:  opts.tenure,
 	}
+	rt.writer = newRoutingWriter(NewWorkerPool(zap.L(), local.Id.String(), 1), rt.applySeen)
 	ok, err := rt.addNode(&local.Node)
 	if !ok || err != nil {
 		return nil, RoutingErr.New("could not add localNode to routing table: %s", err)
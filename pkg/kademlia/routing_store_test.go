@@ -0,0 +1,208 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/overlay"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storage"
+	"storj.io/storj/storage/boltdb"
+	"storj.io/storj/storage/memorykv"
+)
+
+// routingStore creates the kademlia and node buckets of a routing table.
+type routingStore func(t testing.TB, ctx *testcontext.Context) (kdb, ndb storage.KeyValueStore)
+
+// routingStores are the routing table stores a satellite can select.
+var routingStores = []struct {
+	name string
+	new  routingStore
+}{
+	{"memory", func(t testing.TB, ctx *testcontext.Context) (kdb, ndb storage.KeyValueStore) {
+		return memorykv.New(), memorykv.New()
+	}},
+	{"bolt", func(t testing.TB, ctx *testcontext.Context) (kdb, ndb storage.KeyValueStore) {
+		dbs, err := boltdb.NewShared(filepath.Join(ctx.Dir("kademlia"), "kademlia.db"), KademliaBucket, NodeBucket)
+		require.NoError(t, err)
+		return dbs[0], dbs[1]
+	}},
+}
+
+func TestRoutingTableStores(t *testing.T) {
+	for _, store := range routingStores {
+		t.Run(store.name, func(t *testing.T) {
+			testRoutingTableStore(t, store.new)
+		})
+	}
+}
+
+// testRoutingTableStore checks that a routing table backed by newStore behaves
+// the same as any other routing table.
+func testRoutingTableStore(t *testing.T, newStore routingStore) {
+	newTable := func(t *testing.T, ctx *testcontext.Context, self storj.NodeID) *RoutingTable {
+		kdb, ndb := newStore(t, ctx)
+		rt, err := NewRoutingTable(zaptest.NewLogger(t), &overlay.NodeDossier{Node: pb.Node{Id: self}}, kdb, ndb,
+			&RoutingTableConfig{BucketSize: 4, ReplacementCacheSize: 2})
+		require.NoError(t, err)
+		return rt
+	}
+	closeTable := func(ctx *testcontext.Context, rt *RoutingTable) {
+		ctx.Check(rt.Close)
+		ctx.Check(rt.kadBucketDB.Close)
+		ctx.Check(rt.nodeBucketDB.Close)
+	}
+
+	t.Run("Local", func(t *testing.T) {
+		ctx := testcontext.New(t)
+		defer ctx.Cleanup()
+
+		self := randomNodeID(t)
+		rt := newTable(t, ctx, self)
+		defer closeTable(ctx, rt)
+
+		nodes, err := rt.DumpNodes()
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		assert.Equal(t, self, nodes[0].Id)
+
+		buckets, err := rt.GetBucketIds()
+		require.NoError(t, err)
+		assert.Len(t, buckets, 1)
+	})
+
+	t.Run("ConnectionSuccess", func(t *testing.T) {
+		ctx := testcontext.New(t)
+		defer ctx.Cleanup()
+
+		self := randomNodeID(t)
+		rt := newTable(t, ctx, self)
+		defer closeTable(ctx, rt)
+
+		node := nodeAtPrefix(t, self, 0, "10.0.0.1:7777")
+		require.NoError(t, rt.ConnectionSuccess(node))
+
+		updated := &pb.Node{Id: node.Id, Address: &pb.NodeAddress{Address: "10.0.0.2:7777"}}
+		require.NoError(t, rt.ConnectionSuccess(updated))
+
+		nodes, err := rt.FindNear(node.Id, 1)
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		assert.Equal(t, node.Id, nodes[0].Id)
+		assert.Equal(t, "10.0.0.2:7777", nodes[0].Address.Address)
+	})
+
+	t.Run("ConnectionFailed", func(t *testing.T) {
+		ctx := testcontext.New(t)
+		defer ctx.Cleanup()
+
+		self := randomNodeID(t)
+		rt := newTable(t, ctx, self)
+		defer closeTable(ctx, rt)
+
+		node := nodeAtPrefix(t, self, 0, "10.0.0.1:7777")
+		require.NoError(t, rt.ConnectionSuccess(node))
+		require.NoError(t, rt.ConnectionFailed(node))
+
+		_, err := rt.nodeBucketDB.Get(node.Id.Bytes())
+		assert.True(t, storage.ErrKeyNotFound.Has(err))
+	})
+
+	t.Run("SplitAndFindNear", func(t *testing.T) {
+		ctx := testcontext.New(t)
+		defer ctx.Cleanup()
+
+		self := randomNodeID(t)
+		rt := newTable(t, ctx, self)
+		defer closeTable(ctx, rt)
+
+		var added []*pb.Node
+		for prefix := 0; prefix < 4; prefix++ {
+			for i := 0; i < 3; i++ {
+				node := nodeAtPrefix(t, self, prefix, fmt.Sprintf("10.%d.0.%d:7777", prefix, i))
+				require.NoError(t, rt.ConnectionSuccess(node))
+				added = append(added, node)
+			}
+		}
+
+		buckets, err := rt.GetBucketIds()
+		require.NoError(t, err)
+		assert.True(t, len(buckets) > 1, "buckets should have been split")
+
+		nodes, err := rt.DumpNodes()
+		require.NoError(t, err)
+		assert.Len(t, nodes, len(added)+1)
+
+		near, err := rt.FindNear(self, 3)
+		require.NoError(t, err)
+		require.Len(t, near, 3)
+		for i := 1; i < len(near); i++ {
+			assert.True(t, compareByXor(near[i-1].Id, near[i].Id, self) < 0)
+		}
+		// nodes sharing the longest prefix with self are the closest
+		for _, node := range near {
+			assert.Equal(t, 3, commonPrefixLength(self, node.Id))
+		}
+	})
+
+	t.Run("BucketTimestamp", func(t *testing.T) {
+		ctx := testcontext.New(t)
+		defer ctx.Cleanup()
+
+		self := randomNodeID(t)
+		rt := newTable(t, ctx, self)
+		defer closeTable(ctx, rt)
+
+		now := time.Now().UTC()
+		require.NoError(t, rt.SetBucketTimestamp(self.Bytes(), now))
+		stamp, err := rt.GetBucketTimestamp(self.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, now, stamp)
+	})
+}
+
+// BenchmarkRoutingTableStores compares contact processing throughput of the
+// routing table stores:
+//
+//	go test -run none -bench RoutingTableStores ./pkg/kademlia
+func BenchmarkRoutingTableStores(b *testing.B) {
+	for _, store := range routingStores {
+		b.Run(store.name, func(b *testing.B) {
+			ctx := testcontext.New(b)
+			defer ctx.Cleanup()
+
+			self := randomNodeID(b)
+			kdb, ndb := store.new(b, ctx)
+			defer ctx.Check(kdb.Close)
+			defer ctx.Check(ndb.Close)
+
+			rt, err := NewRoutingTable(zap.NewNop(), &overlay.NodeDossier{Node: pb.Node{Id: self}}, kdb, ndb, nil)
+			require.NoError(b, err)
+			defer ctx.Check(rt.Close)
+
+			nodes := make([]*pb.Node, 1000)
+			for i := range nodes {
+				nodes[i] = &pb.Node{Id: randomNodeID(b), Address: &pb.NodeAddress{Address: fmt.Sprintf("10.0.%d.%d:7777", i/256, i%256)}}
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := rt.ConnectionSuccess(nodes[i%len(nodes)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
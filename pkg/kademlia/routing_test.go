@@ -8,11 +8,14 @@ import (
 	"fmt"
 	"math/rand"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/zeebo/errs"
+	"golang.org/x/sync/errgroup"
 
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/teststorj"
@@ -135,6 +138,55 @@ func TestKademliaFindNear(t *testing.T) {
 	}
 }
 
+func TestFindNearFreshResults(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	self := RandomNode()
+	rt := createRoutingTable(self.Id)
+	defer ctx.Check(rt.Close)
+	for i := 0; i < 100; i++ {
+		node := RandomNode()
+		node.Address = &pb.NodeAddress{Address: fmt.Sprintf("node%d:7777", i)}
+		_, err := rt.addNode(&node)
+		require.NoError(t, err)
+	}
+	pinned := RandomNode()
+	pinned.Address = &pb.NodeAddress{Address: "pinned:7777"}
+	_, err := rt.Pin(&pinned, pb.NodeType_SATELLITE)
+	require.NoError(t, err)
+
+	// concurrent lookups get their own nodes, which the caller may modify
+	var group errgroup.Group
+	for worker := 0; worker < 8; worker++ {
+		group.Go(func() error {
+			for i := 0; i < 50; i++ {
+				target := RandomNode().Id
+				nodes, err := rt.FindNear(target, 10)
+				if err != nil {
+					return err
+				}
+				if !NodesSortedByXOR(nodes, target) {
+					return errs.New("nodes not sorted")
+				}
+				for _, node := range nodes {
+					if !strings.HasSuffix(node.Address.GetAddress(), ":7777") {
+						return errs.New("node %v modified by another lookup", node.Id)
+					}
+					node.Address = &pb.NodeAddress{Address: "modified"}
+				}
+			}
+			return nil
+		})
+	}
+	require.NoError(t, group.Wait())
+
+	nodes, err := rt.FindNear(pinned.Id, 1)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "pinned:7777", nodes[0].Address.Address)
+}
+
 func TestConnectionSuccess(t *testing.T) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
@@ -30,6 +30,7 @@ func Draw(w io.Writer, info *pb.GetBucketListResponse) (err error) {
 
 	buckets := info.GetBuckets()
 	dot.addBuckets(buckets, 0, "")
+	dot.Pinned(info.GetPinnedNodes())
 	return dot.err
 }
 
@@ -73,6 +74,19 @@ func (dot *dot) Leaf(b *pb.GetBucketListResponse_Bucket, prefix string) {
 	}
 }
 
+// Pinned draws the nodes kept outside of the buckets separately from the tree.
+func (dot *dot) Pinned(pinned []*pb.GetBucketListResponse_PinnedNode) {
+	if len(pinned) == 0 {
+		return
+	}
+	dot.printf(`pinned [label=< <table cellborder="0"><tr><td cellspacing="0" sides="b" border="1" colspan="2"><b><font point-size="18"> pinned </font></b></td></tr>`)
+	defer dot.printf("</table>>];")
+
+	for _, p := range pinned {
+		dot.printf(`<tr><td align="left"><font point-size="14">%s</font></td><td sides="r" align="left"><i>(%s, %s)</i></td></tr>`, p.Node.Id, p.Node.GetAddress().GetAddress(), p.Type)
+	}
+}
+
 func (dot *dot) Node(node *pb.Node) {
 	dot.printf(`<tr><td align="left"><font point-size="14">%s</font></td><td sides="r" align="left"><i>(%s)</i></td></tr>`, node.Id, node.Address.Address)
 }
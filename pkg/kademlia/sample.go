@@ -0,0 +1,136 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// SampleOptions restricts the nodes returned by RoutingTable.Sample
+type SampleOptions struct {
+	// Exclude lists nodes that must not be returned
+	Exclude []storj.NodeID
+	// SeenWithin requires a successful connection to the node within the duration, when non-zero
+	SeenWithin time.Duration
+	// DistinctNetworks allows at most one node per /24 (IPv4) or /64 (IPv6) network
+	DistinctNetworks bool
+}
+
+// Sample returns up to n nodes chosen uniformly at random from the routing table.
+//
+// When the table has fewer than n eligible nodes, all of them are returned.
+// With DistinctNetworks the networks are chosen uniformly and then a single
+// node is chosen uniformly within each network.
+func (rt *RoutingTable) Sample(n int, opts SampleOptions) ([]pb.Node, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	excluded := make(map[storj.NodeID]bool, len(opts.Exclude))
+	for _, id := range opts.Exclude {
+		excluded[id] = true
+	}
+
+	var lastSuccess map[storj.NodeID]time.Time
+	if opts.SeenWithin > 0 {
+		rt.mutex.Lock()
+		lastSuccess = make(map[storj.NodeID]time.Time, len(rt.lastSuccess))
+		for id, at := range rt.lastSuccess {
+			lastSuccess[id] = at
+		}
+		rt.mutex.Unlock()
+	}
+	now := time.Now()
+
+	// candidate is a reservoir of size one for a single network
+	type candidate struct {
+		node  pb.Node
+		count int
+	}
+	networks := make(map[string]*candidate)
+
+	var reservoir []pb.Node
+	var eligible int
+	add := func(node pb.Node) {
+		if eligible < n {
+			reservoir = append(reservoir, node)
+		} else if k := rand.Intn(eligible + 1); k < n {
+			reservoir[k] = node
+		}
+		eligible++
+	}
+
+	err := rt.iterateNodes(storj.NodeID{}, func(id storj.NodeID, protoNode []byte) error {
+		if excluded[id] {
+			return nil
+		}
+		if opts.SeenWithin > 0 {
+			at, ok := lastSuccess[id]
+			if !ok || now.Sub(at) > opts.SeenWithin {
+				return nil
+			}
+		}
+
+		var node pb.Node
+		if err := proto.Unmarshal(protoNode, &node); err != nil {
+			return err
+		}
+
+		if !opts.DistinctNetworks {
+			add(node)
+			return nil
+		}
+
+		network := networkOf(node.Address.GetAddress())
+		c, ok := networks[network]
+		if !ok {
+			c = &candidate{}
+			networks[network] = c
+		}
+		c.count++
+		if rand.Intn(c.count) == 0 {
+			c.node = node
+		}
+		return nil
+	}, true)
+	if err != nil {
+		return nil, RoutingErr.Wrap(err)
+	}
+
+	for _, c := range networks {
+		add(c.node)
+	}
+
+	return reservoir, nil
+}
+
+// networkOf returns the /24 (IPv4) or /64 (IPv6) network of address.
+// Addresses that are not IPs are their own network.
+func networkOf(address string) string {
+	return maskedNetworkOf(address, 24, 64)
+}
+
+// maskedNetworkOf returns the network of address with the given prefix sizes.
+// Addresses that are not IPs are their own network.
+func maskedNetworkOf(address string, ipv4Bits, ipv6Bits int) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.Mask(net.CIDRMask(ipv4Bits, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(ipv6Bits, 128)).String()
+}
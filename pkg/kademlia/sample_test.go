@@ -0,0 +1,162 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// createSampleTable returns a routing table with count nodes, where every
+// pair of nodes shares a /24 network.
+func createSampleTable(t *testing.T, count int) (*RoutingTable, []pb.Node) {
+	rt := createRoutingTableWith(teststorj.NodeIDFromString("AA"), routingTableOpts{bucketSize: count + 1})
+
+	nodes := make([]pb.Node, count)
+	for i := range nodes {
+		nodes[i] = RandomNode()
+		nodes[i].Address = &pb.NodeAddress{Address: fmt.Sprintf("10.0.%d.%d:7777", i/2, i%2+1)}
+		ok, err := rt.addNode(&nodes[i])
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	return rt, nodes
+}
+
+func TestSampleUniform(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	const nodeCount, n, rounds = 20, 5, 4000
+	rt, _ := createSampleTable(t, nodeCount)
+	defer ctx.Check(rt.Close)
+
+	frequency := map[storj.NodeID]int{}
+	for i := 0; i < rounds; i++ {
+		sample, err := rt.Sample(n, SampleOptions{})
+		require.NoError(t, err)
+		require.Len(t, sample, n)
+
+		unique := map[storj.NodeID]bool{}
+		for _, node := range sample {
+			assert.NotEqual(t, rt.self.Id, node.Id)
+			unique[node.Id] = true
+			frequency[node.Id]++
+		}
+		require.Len(t, unique, n)
+	}
+
+	require.Len(t, frequency, nodeCount)
+	expected := float64(rounds*n) / nodeCount
+	for id, count := range frequency {
+		// a binomial with p=1/4 over 4000 rounds has a deviation of ~27
+		assert.InDelta(t, expected, float64(count), expected*0.15, id.String())
+	}
+}
+
+func TestSampleOptions(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt, nodes := createSampleTable(t, 10)
+	defer ctx.Check(rt.Close)
+
+	{ // small table returns everything
+		sample, err := rt.Sample(100, SampleOptions{})
+		require.NoError(t, err)
+		assert.Len(t, sample, len(nodes))
+	}
+
+	{ // excluded nodes are never returned
+		exclude := []storj.NodeID{nodes[0].Id, nodes[1].Id}
+		sample, err := rt.Sample(100, SampleOptions{Exclude: exclude})
+		require.NoError(t, err)
+		assert.Len(t, sample, len(nodes)-len(exclude))
+		for _, node := range sample {
+			assert.NotContains(t, exclude, node.Id)
+		}
+	}
+
+	{ // at most one node per network
+		for i := 0; i < 100; i++ {
+			sample, err := rt.Sample(100, SampleOptions{DistinctNetworks: true})
+			require.NoError(t, err)
+			require.Len(t, sample, len(nodes)/2)
+
+			networks := map[string]bool{}
+			for _, node := range sample {
+				network := networkOf(node.Address.Address)
+				require.False(t, networks[network], network)
+				networks[network] = true
+			}
+		}
+	}
+
+	{ // only recently contacted nodes
+		sample, err := rt.Sample(100, SampleOptions{SeenWithin: time.Hour})
+		require.NoError(t, err)
+		assert.Len(t, sample, 0)
+
+		require.NoError(t, rt.ConnectionSuccess(&nodes[3]))
+		sample, err = rt.Sample(100, SampleOptions{SeenWithin: time.Hour})
+		require.NoError(t, err)
+		require.Len(t, sample, 1)
+		assert.Equal(t, nodes[3].Id, sample[0].Id)
+
+		require.NoError(t, rt.ConnectionFailed(&nodes[3]))
+		sample, err = rt.Sample(100, SampleOptions{SeenWithin: time.Hour})
+		require.NoError(t, err)
+		assert.Len(t, sample, 0)
+	}
+}
+
+func TestNetworkOf(t *testing.T) {
+	for address, network := range map[string]string{
+		"10.1.2.3:7777":            "10.1.2.0",
+		"10.1.2.3":                 "10.1.2.0",
+		"[2001:db8:1:2:3::4]:7777": "2001:db8:1:2::",
+		"example.com:7777":         "example.com",
+	} {
+		assert.Equal(t, network, networkOf(address), address)
+	}
+}
+
+func TestLastSuccessForgottenOnRemoval(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(teststorj.NodeIDFromString("AA"), routingTableOpts{bucketSize: 2, cacheSize: 1})
+	defer ctx.Check(rt.Close)
+
+	// far away nodes compete for a single bucket with one replacement slot
+	var nodes []*pb.Node
+	for i := 0; i < 5; i++ {
+		node := &pb.Node{Id: teststorj.NodeIDFromString(fmt.Sprintf("Z%d", i)), Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}
+		nodes = append(nodes, node)
+		require.NoError(t, rt.ConnectionSuccess(node))
+	}
+
+	dumped, err := rt.DumpNodes()
+	require.NoError(t, err)
+	// only nodes in a bucket or in the replacement cache are remembered
+	assert.True(t, len(rt.lastSuccess) <= len(dumped)-1+rt.rcBucketSize, "%d entries", len(rt.lastSuccess))
+
+	for _, node := range dumped {
+		if node.Id == rt.self.Id {
+			continue
+		}
+		require.NoError(t, rt.removeNode(node))
+		_, ok := rt.lastSuccess[node.Id]
+		assert.False(t, ok)
+	}
+}
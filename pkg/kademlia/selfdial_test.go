@@ -0,0 +1,66 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testdial"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storage"
+)
+
+func TestSelfDial(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	k, server, clean := testNode(ctx, "self", t, nil)
+	defer clean()
+	defer server.GracefulStop()
+
+	scope := monkit.NewRegistry().ScopeNamed("self")
+	k.SetMonitorScope(scope)
+	recorder := testdial.WithDialRecorder(t, k.dialer.transport)
+	k.dialer.transport = recorder.Client
+
+	// a stale entry with the address of this node
+	stale := pb.Node{
+		Id:      teststorj.NodeIDFromString("stale"),
+		Address: &pb.NodeAddress{Transport: defaultTransport, Address: k.Local().Address.Address},
+	}
+	ok, err := k.routingTable.addNode(&stale)
+	require.True(t, ok)
+	require.NoError(t, err)
+	pinned, err := k.routingTable.Pin(&stale, pb.NodeType_BOOTSTRAP)
+	require.True(t, pinned)
+	require.NoError(t, err)
+
+	_, err = k.Ping(ctx, stale)
+	require.Error(t, err)
+	assert.True(t, transport.SelfDial.Has(err), err)
+	assert.Equal(t, 1, recorder.Count(stale.Id))
+	assert.EqualValues(t, 1, scope.Counter("self_dial").Current())
+
+	// the entry is removed
+	require.NoError(t, k.routingTable.Flush(ctx))
+	_, err = k.routingTable.nodeBucketDB.Get(stale.Id.Bytes())
+	assert.True(t, storage.ErrKeyNotFound.Has(err), err)
+	assert.False(t, k.routingTable.isPinned(stale.Id))
+
+	// and never dialed again
+	for i := 0; i < 3; i++ {
+		_, err = k.Ping(ctx, stale)
+		assert.True(t, transport.SelfDial.Has(err), err)
+	}
+	_, err = k.FetchPeerIdentity(ctx, stale.Id)
+	assert.Error(t, err)
+	assert.Equal(t, 1, recorder.Count(stale.Id))
+}
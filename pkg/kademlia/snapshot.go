@@ -0,0 +1,255 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/zeebo/errs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// snapshotTTL is how long a snapshot is served to new chunked dumps before
+// it is replaced, dumps in progress can continue until then.
+const snapshotTTL = 10 * time.Minute
+
+// ErrSnapshotRotated is the class of errors for chunks requested from a
+// snapshot that has been replaced, the dump must start again.
+var ErrSnapshotRotated = errs.Class("snapshot rotated")
+
+// SnapshotChunk is a part of a snapshot of the routing table.
+type SnapshotChunk struct {
+	Generation uint64
+	Nodes      []*pb.Node
+	// More is whether nodes follow the chunk, the last chunk has the
+	// Checksum of all the nodes of the snapshot.
+	More     bool
+	Checksum []byte
+}
+
+// routingSnapshot is the nodes of the routing table at a point in time.
+type routingSnapshot struct {
+	generation uint64
+	taken      time.Time
+	nodes      []*pb.Node // sorted by ID
+	checksum   []byte
+}
+
+// snapshotter serves chunked dumps of the routing table from a snapshot, so
+// that dumps resumed after reconnecting don't mix different states.
+type snapshotter struct {
+	dump func() ([]*pb.Node, error)
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	current *routingSnapshot
+}
+
+// newSnapshotter creates a snapshotter taking the snapshots with dump.
+func newSnapshotter(dump func() ([]*pb.Node, error)) *snapshotter {
+	return &snapshotter{dump: dump, ttl: snapshotTTL, now: time.Now}
+}
+
+// chunk returns up to limit nodes after the node after of the snapshot of
+// generation. A zero generation starts a dump of the current snapshot, which
+// is replaced when expired, and a non-positive limit dumps all the nodes of a
+// new snapshot.
+func (snapshots *snapshotter) chunk(generation uint64, after storj.NodeID, limit int) (SnapshotChunk, error) {
+	if generation == 0 && limit <= 0 {
+		snapshot, err := snapshots.take(0)
+		if err != nil {
+			return SnapshotChunk{}, err
+		}
+		return snapshot.chunk(after, limit), nil
+	}
+
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+
+	current := snapshots.current
+	if generation == 0 {
+		if current == nil || snapshots.now().Sub(current.taken) >= snapshots.ttl {
+			var previous uint64
+			if current != nil {
+				previous = current.generation
+			}
+			snapshot, err := snapshots.take(previous)
+			if err != nil {
+				return SnapshotChunk{}, err
+			}
+			snapshots.current, current = snapshot, snapshot
+		}
+	} else if current == nil || current.generation != generation {
+		return SnapshotChunk{}, ErrSnapshotRotated.New("generation %d is no longer served", generation)
+	}
+	return current.chunk(after, limit), nil
+}
+
+// rotate replaces the current snapshot with a new one.
+func (snapshots *snapshotter) rotate() error {
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+
+	var previous uint64
+	if snapshots.current != nil {
+		previous = snapshots.current.generation
+	}
+	snapshot, err := snapshots.take(previous)
+	if err != nil {
+		return err
+	}
+	snapshots.current = snapshot
+	return nil
+}
+
+// take takes a snapshot with a generation different from previous.
+func (snapshots *snapshotter) take(previous uint64) (*routingSnapshot, error) {
+	nodes, err := snapshots.dump()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nodes, func(i, k int) bool { return nodes[i].Id.Less(nodes[k].Id) })
+	checksum, err := SnapshotChecksum(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := snapshots.now()
+	generation := uint64(taken.UnixNano())
+	if generation == previous || generation == 0 {
+		generation = previous + 1
+	}
+	return &routingSnapshot{
+		generation: generation,
+		taken:      taken,
+		nodes:      nodes,
+		checksum:   checksum,
+	}, nil
+}
+
+// chunk returns up to limit nodes after the node after, all of them when limit is non-positive.
+func (snapshot *routingSnapshot) chunk(after storj.NodeID, limit int) SnapshotChunk {
+	start := 0
+	if !after.IsZero() {
+		start = sort.Search(len(snapshot.nodes), func(i int) bool {
+			return after.Less(snapshot.nodes[i].Id)
+		})
+	}
+	end := len(snapshot.nodes)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	chunk := SnapshotChunk{
+		Generation: snapshot.generation,
+		Nodes:      snapshot.nodes[start:end],
+		More:       end < len(snapshot.nodes),
+	}
+	if !chunk.More {
+		chunk.Checksum = snapshot.checksum
+	}
+	return chunk
+}
+
+// SnapshotChecksum returns the checksum of the nodes of a snapshot, sorted by ID.
+func SnapshotChecksum(nodes []*pb.Node) ([]byte, error) {
+	hash := sha256.New()
+	for _, node := range nodes {
+		data, err := proto.Marshal(node)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		_, _ = hash.Write(data)
+	}
+	return hash.Sum(nil), nil
+}
+
+// SnapshotChunk returns up to limit nodes after the node after of the
+// routing table snapshot of generation, see DumpSnapshot.
+func (k *Kademlia) SnapshotChunk(ctx context.Context, generation uint64, after storj.NodeID, limit int) (SnapshotChunk, error) {
+	return k.snapshots.chunk(generation, after, limit)
+}
+
+// DumpSnapshot downloads the routing table of the node of client in chunks of
+// limit nodes. Failed requests are retried as decided by strategy, continuing
+// after the last received node, and the download starts over when the
+// snapshot of the node has been replaced meanwhile. The checksum of the
+// nodes is verified at the end. The chunks are sent in the compact encoding
+// when the node supports it.
+func DumpSnapshot(ctx context.Context, client pb.KadInspectorClient, limit int, strategy backoff.Strategy) ([]*pb.Node, error) {
+	var (
+		nodes      []*pb.Node
+		generation uint64
+		after      storj.NodeID
+	)
+	restart := func() {
+		nodes, generation, after = nil, 0, storj.NodeID{}
+	}
+
+	err := backoff.Retry(ctx, strategy, func(ctx context.Context) error {
+		for {
+			resp, err := client.DumpNodes(ctx, &pb.DumpNodesRequest{
+				After:      after,
+				Generation: generation,
+				Limit:      int32(limit),
+				// nodes not knowing the compact encoding ignore the
+				// capabilities and send the nodes as protobuf
+				Capabilities: uint64(Capabilities),
+			})
+			if err != nil {
+				if status.Code(err) == codes.Aborted {
+					restart()
+				}
+				return err
+			}
+			if generation != 0 && resp.Generation != generation {
+				restart()
+				return ErrSnapshotRotated.New("expected generation %d, got %d", generation, resp.Generation)
+			}
+
+			chunk := resp.Nodes
+			if len(resp.CompactNodes) > 0 {
+				chunk, err = DecodeCompactSnapshot(resp.CompactNodes)
+				if err != nil {
+					return err
+				}
+			}
+
+			generation = resp.Generation
+			nodes = append(nodes, chunk...)
+			if len(chunk) > 0 {
+				after = chunk[len(chunk)-1].Id
+			}
+			if resp.More {
+				continue
+			}
+
+			checksum, err := SnapshotChecksum(nodes)
+			if err != nil {
+				return backoff.Permanent.Wrap(err)
+			}
+			if !bytes.Equal(checksum, resp.Checksum) {
+				restart()
+				return Error.New("snapshot checksum mismatch")
+			}
+			return nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// pausableInspector serves DumpNodes from an inspector, failing the requests
+// while paused as if the connection to the peer was lost.
+type pausableInspector struct {
+	pb.KadInspectorClient
+	inspector *Inspector
+
+	mu       sync.Mutex
+	requests int
+	// before is called with the number of the request before serving it
+	before func(request int)
+	paused bool
+}
+
+func (client *pausableInspector) DumpNodes(ctx context.Context, req *pb.DumpNodesRequest, opts ...grpc.CallOption) (*pb.DumpNodesResponse, error) {
+	client.mu.Lock()
+	client.requests++
+	if client.before != nil {
+		client.before(client.requests)
+	}
+	paused := client.paused
+	client.mu.Unlock()
+
+	if paused {
+		return nil, status.Error(codes.Unavailable, "peer paused")
+	}
+	return client.inspector.DumpNodes(ctx, req)
+}
+
+// newSnapshotInspector returns an inspector of a routing table with count nodes besides self.
+func newSnapshotInspector(t *testing.T, count int) (*Inspector, *RoutingTable) {
+	rt := createRoutingTable(teststorj.NodeIDFromString("self"))
+	addSnapshotNodes(t, rt, 0, count)
+	return &Inspector{dht: &Kademlia{log: zaptest.NewLogger(t), mon: mon, routingTable: rt, snapshots: newSnapshotter(rt.DumpNodes)}}, rt
+}
+
+// addSnapshotNodes adds the nodes numbered from first to first+count.
+func addSnapshotNodes(t *testing.T, rt *RoutingTable, first, count int) {
+	for i := first; i < first+count; i++ {
+		require.NoError(t, rt.putNode(&pb.Node{
+			Id:      teststorj.NodeIDFromString(fmt.Sprintf("node-%05d", i)),
+			Address: &pb.NodeAddress{Address: fmt.Sprintf("127.0.0.1:%d", 10000+i)},
+		}))
+	}
+}
+
+// requireSameNodes requires the downloaded nodes to be the nodes of the routing table.
+func requireSameNodes(t *testing.T, rt *RoutingTable, downloaded []*pb.Node) {
+	direct, err := rt.DumpNodes()
+	require.NoError(t, err)
+	require.Len(t, downloaded, len(direct))
+
+	expected, err := newSnapshotter(func() ([]*pb.Node, error) { return direct, nil }).take(0)
+	require.NoError(t, err)
+	for i, node := range expected.nodes {
+		assert.Equal(t, node.Id, downloaded[i].Id)
+		assert.Equal(t, node.Address.Address, downloaded[i].Address.Address)
+	}
+}
+
+func TestDumpSnapshotResumes(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	inspector, rt := newSnapshotInspector(t, 250)
+	defer ctx.Check(rt.Close)
+	client := &pausableInspector{inspector: inspector}
+	// the connection is lost after the second chunk and comes back two requests later
+	client.before = func(request int) {
+		client.paused = request == 3 || request == 4
+	}
+
+	nodes, err := DumpSnapshot(ctx, client, 100, backoff.Constant{Interval: time.Millisecond})
+	require.NoError(t, err)
+	requireSameNodes(t, rt, nodes)
+
+	// 3 chunks and 2 failed requests, the download continued after the second chunk
+	assert.Equal(t, 5, client.requests)
+}
+
+func TestDumpSnapshotRestartsOnRotation(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	inspector, rt := newSnapshotInspector(t, 250)
+	defer ctx.Check(rt.Close)
+	client := &pausableInspector{inspector: inspector}
+	// the table changes and the snapshot rotates after the second chunk
+	client.before = func(request int) {
+		if request == 3 {
+			addSnapshotNodes(t, rt, 250, 50)
+			require.NoError(t, inspector.dht.snapshots.rotate())
+		}
+	}
+
+	nodes, err := DumpSnapshot(ctx, client, 100, backoff.Constant{Interval: time.Millisecond})
+	require.NoError(t, err)
+	requireSameNodes(t, rt, nodes)
+
+	// 2 chunks, a rejected request and 4 chunks of the new snapshot
+	assert.Equal(t, 7, client.requests)
+}
+
+func TestSnapshotChunks(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	inspector, rt := newSnapshotInspector(t, 10)
+	defer ctx.Check(rt.Close)
+	snapshots := inspector.dht.snapshots
+	now := time.Now()
+	snapshots.now = func() time.Time { return now }
+
+	first, err := snapshots.chunk(0, storj.NodeID{}, 4)
+	require.NoError(t, err)
+	require.Len(t, first.Nodes, 4)
+	assert.True(t, first.More)
+	assert.Nil(t, first.Checksum)
+
+	// new dumps share the snapshot until it expires
+	again, err := snapshots.chunk(0, storj.NodeID{}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, first.Generation, again.Generation)
+
+	last, err := snapshots.chunk(first.Generation, first.Nodes[3].Id, 100)
+	require.NoError(t, err)
+	assert.Len(t, last.Nodes, 7)
+	assert.False(t, last.More)
+	assert.NotEmpty(t, last.Checksum)
+
+	now = now.Add(snapshotTTL)
+	rotated, err := snapshots.chunk(0, storj.NodeID{}, 4)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Generation, rotated.Generation)
+
+	_, err = snapshots.chunk(first.Generation, first.Nodes[3].Id, 4)
+	assert.True(t, ErrSnapshotRotated.Has(err))
+
+	// a full dump doesn't replace the snapshot of chunked dumps
+	full, err := snapshots.chunk(0, storj.NodeID{}, 0)
+	require.NoError(t, err)
+	assert.Len(t, full.Nodes, 11)
+	assert.Equal(t, last.Checksum, full.Checksum)
+	_, err = snapshots.chunk(rotated.Generation, rotated.Nodes[3].Id, 4)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/eventlog"
+)
+
+// subscribe subscribes the metrics, the log and the dashboard to the
+// lifecycle events.
+func (k *Kademlia) subscribe() {
+	k.bus.Subscribe("monkit", defaultSubscriptionSize, k.countEvent)
+	k.bus.Subscribe("log", defaultSubscriptionSize, k.logEvent)
+	k.bus.Subscribe("dashboard", defaultSubscriptionSize, k.contacted)
+}
+
+// countEvent counts event in the metrics.
+func (k *Kademlia) countEvent(event Event) {
+	switch event.Type {
+	case EventNeighborhoodChanged:
+		k.mon.Event("neighborhood_changed")
+		k.mon.FloatVal("neighborhood_overlap").Observe(event.Neighborhood.Overlap)
+	case EventAddressChanged:
+		k.mon.Counter("node_address_changed").Inc(1)
+	default:
+		k.mon.Counter(event.Type.String()).Inc(1)
+	}
+}
+
+// logEvent logs the events worth the attention of the operator.
+func (k *Kademlia) logEvent(event Event) {
+	switch event.Type {
+	case EventNeighborhoodChanged:
+		change := event.Neighborhood
+		k.log.Warn("closest nodes changed significantly, the node may be eclipsed or partitioned",
+			zap.Float64("overlap", change.Overlap),
+			zap.Duration("since", change.Time.Sub(change.Since)),
+			zap.Int("before", len(change.Before)),
+			zap.Int("after", len(change.After)))
+	case EventBootstrapFinished:
+		k.log.Debug("bootstrap finished", zap.Duration("duration", event.Duration), zap.Error(event.Err))
+	}
+}
+
+// contacted updates when this node was last pinged and queried, as shown on
+// the dashboard.
+func (k *Kademlia) contacted(event Event) {
+	if event.Direction != eventlog.Incoming {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	switch event.Operation {
+	case "ping":
+		if event.Time.After(k.lastPinged) {
+			k.lastPinged = event.Time
+		}
+	case "lookup", "lookup-stream":
+		if event.Time.After(k.lastQueried) {
+			k.lastQueried = event.Time
+		}
+	}
+}
+
+// recordEvent records the events kept in the history of the peers, or
+// written for offline analysis, to events.
+func recordEvent(events *eventlog.Sink, event Event) {
+	switch event.Type {
+	case EventContactSucceeded, EventContactFailed:
+		recorded := eventlog.NewEvent(event.Operation, event.Node, event.Address, event.Time, event.Err)
+		recorded.Direction = event.Direction
+		recorded.Duration = event.Duration
+		recorded.Code = ErrorCode(event.Err)
+		events.Record(recorded)
+	case EventAddressChanged:
+		events.Record(eventlog.Event{
+			Time:      event.Time,
+			Operation: "address-change",
+			PeerID:    event.Node.String(),
+			Address:   event.Address,
+			Before:    []string{event.PreviousAddress},
+			After:     []string{event.Address},
+		})
+	case EventNeighborhoodChanged:
+		// the change doesn't involve a single peer, so it isn't kept in the peer history
+		change := event.Neighborhood
+		events.Record(eventlog.Event{
+			Time:      change.Time,
+			Operation: "neighborhood_changed",
+			Duration:  change.Time.Sub(change.Since),
+			Before:    nodeIDStrings(change.Before),
+			After:     nodeIDStrings(change.After),
+		})
+	}
+}
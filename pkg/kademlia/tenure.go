@@ -0,0 +1,171 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"time"
+
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/pkg/storj"
+)
+
+// TenureConfig configures the grace period of long-known contacts.
+type TenureConfig struct {
+	Threshold   time.Duration `help:"contacts known for longer are evicted only after a grace period of failed checks, 0 evicts every contact on its first failure" default:"24h"`
+	Grace       int           `help:"number of consecutive failed checks tolerated from contacts known for longer than the tenure threshold" default:"2"`
+	EvictedSize int           `help:"number of recently evicted contacts whose history is restored when they reappear" default:"1000"`
+}
+
+// ContactHistory is what the routing table knows about a contact.
+type ContactHistory struct {
+	FirstSeen time.Time
+	Successes int64
+	Failures  int64
+	// Consecutive is the number of failed checks since the last success
+	Consecutive int
+	// Evicted is whether the contact was evicted and its history parked
+	Evicted bool
+}
+
+// tenured returns whether the contact is known for longer than threshold at now.
+func (history *ContactHistory) tenured(threshold time.Duration, now time.Time) bool {
+	return threshold > 0 && now.Sub(history.FirstSeen) >= threshold
+}
+
+// evictedContacts parks the history of evicted contacts.
+type evictedContacts struct {
+	entries *lrucache.Cache
+}
+
+// newEvictedContacts returns the store of up to size evicted contacts, nil
+// when size is zero.
+func newEvictedContacts(size int) *evictedContacts {
+	if size <= 0 {
+		return nil
+	}
+	return &evictedContacts{entries: lrucache.New(size)}
+}
+
+// park remembers the history of the evicted contact with id.
+func (evicted *evictedContacts) park(id storj.NodeID, history ContactHistory) {
+	if evicted == nil {
+		return
+	}
+	evicted.entries.Add(id, history)
+}
+
+// restore returns and forgets the history of the evicted contact with id.
+func (evicted *evictedContacts) restore(id storj.NodeID) (ContactHistory, bool) {
+	if evicted == nil {
+		return ContactHistory{}, false
+	}
+	value, ok := evicted.entries.Peek(id)
+	if !ok {
+		return ContactHistory{}, false
+	}
+	evicted.entries.Remove(id)
+	return value.(ContactHistory), true
+}
+
+// get returns the history of the evicted contact with id.
+func (evicted *evictedContacts) get(id storj.NodeID) (ContactHistory, bool) {
+	if evicted == nil {
+		return ContactHistory{}, false
+	}
+	value, ok := evicted.entries.Peek(id)
+	if !ok {
+		return ContactHistory{}, false
+	}
+	return value.(ContactHistory), true
+}
+
+// memoryUsage returns the approximate memory used by the parked histories.
+func (evicted *evictedContacts) memoryUsage() memory.Size {
+	if evicted == nil {
+		return 0
+	}
+	return memory.Size(evicted.entries.Len()) * peerEntrySize
+}
+
+// evict forgets the least recently evicted contacts until at least size is freed.
+func (evicted *evictedContacts) evict(size memory.Size) (freed memory.Size) {
+	if evicted == nil {
+		return 0
+	}
+	for freed < size && evicted.entries.RemoveOldest() {
+		freed += peerEntrySize
+	}
+	return freed
+}
+
+// contactSucceeded records a successful check of the contact with id in the
+// buckets, restoring its history when it was evicted before. rt.mutex must
+// be held.
+func (rt *RoutingTable) contactSucceeded(id storj.NodeID, now time.Time) {
+	history, ok := rt.history[id]
+	if !ok {
+		restored, ok := rt.evicted.restore(id)
+		if !ok {
+			restored = ContactHistory{FirstSeen: now}
+		}
+		restored.Evicted = false
+		history = &restored
+		rt.history[id] = history
+	}
+	history.Successes++
+	history.Consecutive = 0
+}
+
+// tolerateFailure records a failed check of the contact with id and returns
+// whether it stays in the buckets, which is when it is known for longer
+// than the tenure threshold and within its grace of failed checks.
+func (rt *RoutingTable) tolerateFailure(id storj.NodeID, now time.Time) bool {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	history, ok := rt.history[id]
+	if !ok {
+		return false
+	}
+	history.Failures++
+	history.Consecutive++
+	if history.tenured(rt.tenure.Threshold, now) && history.Consecutive <= rt.tenure.Grace {
+		rt.mon.Counter("tenured_failure_tolerated").Inc(1)
+		return true
+	}
+	return false
+}
+
+// parkHistory moves the history of the evicted contact with id to the
+// recently evicted contacts.
+func (rt *RoutingTable) parkHistory(id storj.NodeID) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	history, ok := rt.history[id]
+	if !ok {
+		return
+	}
+	delete(rt.history, id)
+	history.Evicted = true
+	rt.evicted.park(id, *history)
+}
+
+// ContactHistory returns what the routing table knows about the contact with
+// id, either in the buckets or recently evicted.
+func (rt *RoutingTable) ContactHistory(id storj.NodeID) (ContactHistory, bool) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	if history, ok := rt.history[id]; ok {
+		return *history, true
+	}
+	return rt.evicted.get(id)
+}
+
+// Tenure returns the configuration of the grace period of long-known contacts.
+func (rt *RoutingTable) Tenure() TenureConfig {
+	return rt.tenure
+}
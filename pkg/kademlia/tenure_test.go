@@ -0,0 +1,101 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestTenuredContactSurvivesFlapping(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(teststorj.NodeIDFromString("AA"), routingTableOpts{
+		tenure: TenureConfig{Threshold: time.Hour, Grace: 2, EvictedSize: 10},
+	})
+	defer ctx.Check(rt.Close)
+
+	old := &pb.Node{Id: teststorj.NodeIDFromString("BB"), Address: &pb.NodeAddress{Address: "old:7777"}}
+	young := &pb.Node{Id: teststorj.NodeIDFromString("CC"), Address: &pb.NodeAddress{Address: "young:7777"}}
+	require.NoError(t, rt.ConnectionSuccess(old))
+	require.NoError(t, rt.ConnectionSuccess(young))
+
+	rt.mutex.Lock()
+	rt.history[old.Id].FirstSeen = time.Now().Add(-2 * time.Hour)
+	rt.mutex.Unlock()
+
+	inBuckets := func(node *pb.Node) bool {
+		_, err := rt.nodeBucketDB.Get(node.Id.Bytes())
+		return err == nil
+	}
+
+	// the old contact flaps, failing checks within its grace
+	for i := 0; i < 3; i++ {
+		require.NoError(t, rt.ConnectionFailed(old))
+		require.NoError(t, rt.ConnectionFailed(old))
+		assert.True(t, inBuckets(old))
+		require.NoError(t, rt.ConnectionSuccess(old))
+	}
+	history, ok := rt.ContactHistory(old.Id)
+	require.True(t, ok)
+	assert.Equal(t, int64(4), history.Successes)
+	assert.Equal(t, int64(6), history.Failures)
+	assert.Equal(t, 0, history.Consecutive)
+	assert.False(t, history.Evicted)
+
+	// the young contact is evicted on its first failure
+	require.NoError(t, rt.ConnectionFailed(young))
+	assert.False(t, inBuckets(young))
+	history, ok = rt.ContactHistory(young.Id)
+	require.True(t, ok)
+	assert.True(t, history.Evicted)
+
+	// the old contact is evicted once it fails beyond its grace
+	for i := 0; i < 3; i++ {
+		require.NoError(t, rt.ConnectionFailed(old))
+	}
+	assert.False(t, inBuckets(old))
+	history, ok = rt.ContactHistory(old.Id)
+	require.True(t, ok)
+	assert.True(t, history.Evicted)
+
+	// and recovers its history when it reappears
+	require.NoError(t, rt.ConnectionSuccess(old))
+	assert.True(t, inBuckets(old))
+	history, ok = rt.ContactHistory(old.Id)
+	require.True(t, ok)
+	assert.False(t, history.Evicted)
+	assert.Equal(t, int64(5), history.Successes)
+	assert.Equal(t, int64(9), history.Failures)
+	assert.True(t, history.tenured(time.Hour, time.Now()))
+}
+
+func TestTenureDisabled(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTable(teststorj.NodeIDFromString("AA"))
+	defer ctx.Check(rt.Close)
+
+	node := &pb.Node{Id: teststorj.NodeIDFromString("BB"), Address: &pb.NodeAddress{Address: "node:7777"}}
+	require.NoError(t, rt.ConnectionSuccess(node))
+	rt.mutex.Lock()
+	rt.history[node.Id].FirstSeen = time.Now().Add(-24 * time.Hour)
+	rt.mutex.Unlock()
+
+	// without a tenure threshold the first failure evicts, and nothing is parked
+	require.NoError(t, rt.ConnectionFailed(node))
+	_, err := rt.nodeBucketDB.Get(node.Id.Bytes())
+	assert.Error(t, err)
+	_, ok := rt.ContactHistory(node.Id)
+	assert.False(t, ok)
+}
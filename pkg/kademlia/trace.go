@@ -0,0 +1,131 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+const (
+	// maxTraceHops is the maximum number of peers recorded in a lookup trace
+	maxTraceHops = 256
+	// maxTraceLearned is the maximum number of returned nodes recorded per hop
+	maxTraceLearned = 20
+)
+
+// LookupTrace records the walk of a single lookup.
+type LookupTrace struct {
+	Target   storj.NodeID
+	Start    time.Time
+	Duration time.Duration
+
+	// Hops are the peers asked, in the order their responses arrived.
+	Hops []LookupHop
+	// DroppedHops is the number of hops not recorded, since the trace was full.
+	DroppedHops int
+}
+
+// LookupHop is a single peer asked during a lookup.
+type LookupHop struct {
+	Peer    storj.NodeID
+	Address string
+	// Parent is the peer whose response introduced Peer, zero when it was
+	// known before the lookup.
+	Parent storj.NodeID
+
+	Start    time.Time
+	Duration time.Duration
+
+	// Learned are the first of the nodes returned by the peer, LearnedCount all of them.
+	Learned      []storj.NodeID
+	LearnedCount int
+	Error        string
+}
+
+// lookupTracer collects the trace of a lookup from its workers.
+type lookupTracer struct {
+	mu      sync.Mutex
+	trace   LookupTrace
+	parents map[storj.NodeID]storj.NodeID
+}
+
+// newLookupTracer starts the trace of a lookup of target.
+func newLookupTracer(target storj.NodeID) *lookupTracer {
+	return &lookupTracer{
+		trace:   LookupTrace{Target: target, Start: time.Now()},
+		parents: map[storj.NodeID]storj.NodeID{},
+	}
+}
+
+// hop records that peer was asked at start and responded with neighbors or err.
+func (tracer *lookupTracer) hop(peer *pb.Node, start time.Time, neighbors []*pb.Node, err error) {
+	duration := time.Since(start)
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+
+	if len(tracer.trace.Hops) >= maxTraceHops {
+		tracer.trace.DroppedHops++
+		return
+	}
+
+	hop := LookupHop{
+		Peer:         peer.Id,
+		Address:      peer.GetAddress().GetAddress(),
+		Parent:       tracer.parents[peer.Id],
+		Start:        start,
+		Duration:     duration,
+		LearnedCount: len(neighbors),
+	}
+	if err != nil {
+		hop.Error = err.Error()
+	}
+	for _, neighbor := range neighbors {
+		if len(hop.Learned) < maxTraceLearned {
+			hop.Learned = append(hop.Learned, neighbor.Id)
+		}
+		// only the peers that can still be recorded need a parent
+		if _, ok := tracer.parents[neighbor.Id]; !ok && len(tracer.parents) < maxTraceHops*maxTraceLearned {
+			tracer.parents[neighbor.Id] = peer.Id
+		}
+	}
+	tracer.trace.Hops = append(tracer.trace.Hops, hop)
+}
+
+// finish returns the trace.
+func (tracer *lookupTracer) finish() *LookupTrace {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+
+	trace := tracer.trace
+	trace.Duration = time.Since(trace.Start)
+	trace.Hops = append([]LookupHop(nil), trace.Hops...)
+	return &trace
+}
+
+type lookupTracerKey struct{}
+
+// withLookupTracer returns a context whose lookup records its walk to tracer.
+func withLookupTracer(ctx context.Context, tracer *lookupTracer) context.Context {
+	return context.WithValue(ctx, lookupTracerKey{}, tracer)
+}
+
+// lookupTracerOf returns the tracer of ctx, nil when the lookup isn't traced.
+func lookupTracerOf(ctx context.Context) *lookupTracer {
+	tracer, _ := ctx.Value(lookupTracerKey{}).(*lookupTracer)
+	return tracer
+}
+
+// TraceLookup searches the network for id like FindNode, without using the
+// negative cache, and returns the trace of the walk with the result.
+func (k *Kademlia) TraceLookup(ctx context.Context, id storj.NodeID) (*LookupTrace, pb.Node, error) {
+	tracer := newLookupTracer(id)
+	node, err := k.FindNode(withLookupTracer(WithFreshLookup(ctx), tracer), id)
+	return tracer.finish(), node, err
+}
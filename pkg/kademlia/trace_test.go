@@ -0,0 +1,51 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestLookupTracerBounded(t *testing.T) {
+	tracer := newLookupTracer(storj.NodeID{0xFF})
+
+	nodes := func(first, count int) []*pb.Node {
+		var nodes []*pb.Node
+		for i := 0; i < count; i++ {
+			nodes = append(nodes, &pb.Node{Id: storj.NodeID{byte(first + i), byte((first + i) >> 8)}})
+		}
+		return nodes
+	}
+
+	seed := &pb.Node{Id: storj.NodeID{0xFE}, Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}
+	learned := nodes(1, maxTraceLearned+10)
+	tracer.hop(seed, time.Now(), learned, nil)
+	tracer.hop(learned[0], time.Now(), nil, errors.New("refused"))
+
+	trace := tracer.finish()
+	require.Len(t, trace.Hops, 2)
+	assert.Equal(t, seed.Id, trace.Hops[0].Peer)
+	assert.Equal(t, "127.0.0.1:1", trace.Hops[0].Address)
+	assert.True(t, trace.Hops[0].Parent.IsZero())
+	assert.Len(t, trace.Hops[0].Learned, maxTraceLearned)
+	assert.Equal(t, maxTraceLearned+10, trace.Hops[0].LearnedCount)
+	assert.Equal(t, seed.Id, trace.Hops[1].Parent)
+	assert.Equal(t, "refused", trace.Hops[1].Error)
+
+	for i := 0; i < maxTraceHops; i++ {
+		tracer.hop(seed, time.Now(), nodes(i*maxTraceLearned, maxTraceLearned), nil)
+	}
+	trace = tracer.finish()
+	assert.Len(t, trace.Hops, maxTraceHops)
+	assert.Equal(t, 2, trace.DroppedHops)
+	assert.True(t, len(tracer.parents) <= maxTraceHops*maxTraceLearned)
+}
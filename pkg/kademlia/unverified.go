@@ -0,0 +1,101 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"storj.io/storj/pkg/storj"
+)
+
+// unverifiedConn is an open connection dialed by address.
+type unverifiedConn struct {
+	id    storj.NodeID // presented by the peer, not asked for
+	timer *time.Timer  // nil when the lifetime isn't limited
+}
+
+// trackUnverified tracks conn, dialed by address to the node with id, until
+// it is closed. Whoever owns it, it is closed after
+// DialerOptions.UnverifiedLifetime, or once a verified dial reached the node.
+func (dialer *Dialer) trackUnverified(conn *grpc.ClientConn, id storj.NodeID) {
+	_, options := dialer.settings()
+
+	dialer.handedBackMu.Lock()
+	defer dialer.handedBackMu.Unlock()
+
+	tracked := &unverifiedConn{id: id}
+	if options.UnverifiedLifetime > 0 {
+		tracked.timer = time.AfterFunc(options.UnverifiedLifetime, func() {
+			dialer.expireUnverified(conn)
+		})
+	}
+	dialer.unverified[conn] = tracked
+}
+
+// untrack stops tracking conn, it is being closed.
+func (dialer *Dialer) untrack(conn *grpc.ClientConn) {
+	dialer.handedBackMu.Lock()
+	defer dialer.handedBackMu.Unlock()
+
+	if tracked, ok := dialer.unverified[conn]; ok {
+		if tracked.timer != nil {
+			tracked.timer.Stop()
+		}
+		delete(dialer.unverified, conn)
+	}
+}
+
+// expireUnverified closes conn, which outlived the unverified lifetime.
+func (dialer *Dialer) expireUnverified(conn *grpc.ClientConn) {
+	dialer.handedBackMu.Lock()
+	tracked, ok := dialer.unverified[conn]
+	if ok {
+		delete(dialer.unverified, conn)
+		if dialer.handedBack[tracked.id] == conn {
+			delete(dialer.handedBack, tracked.id)
+		}
+	}
+	dialer.handedBackMu.Unlock()
+	if !ok {
+		return
+	}
+
+	dialer.mon.Counter("unverified_conn_expired").Inc(1)
+	if err := dialer.conns.close(conn); err != nil {
+		dialer.log.Debug("could not close expired unverified connection", zap.Stringer("Node ID", tracked.id), zap.Error(err))
+	}
+}
+
+// verifiedDial closes the connections dialed by address to the node with
+// id, whoever owns them, since a verified dial reached the node.
+func (dialer *Dialer) verifiedDial(id storj.NodeID) {
+	dialer.pool.closeDialedByAddress(id)
+
+	var superseded []*grpc.ClientConn
+	dialer.handedBackMu.Lock()
+	for conn, tracked := range dialer.unverified {
+		if tracked.id != id {
+			continue
+		}
+		if tracked.timer != nil {
+			tracked.timer.Stop()
+		}
+		delete(dialer.unverified, conn)
+		if dialer.handedBack[id] == conn {
+			delete(dialer.handedBack, id)
+		}
+		superseded = append(superseded, conn)
+	}
+	dialer.handedBackMu.Unlock()
+
+	for _, conn := range superseded {
+		dialer.mon.Counter("unverified_conn_superseded").Inc(1)
+		if err := dialer.conns.close(conn); err != nil {
+			dialer.log.Debug("could not close superseded unverified connection", zap.Stringer("Node ID", id), zap.Error(err))
+		}
+	}
+}
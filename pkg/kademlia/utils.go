@@ -6,7 +6,9 @@ package kademlia
 import (
 	"math/bits"
 	"sort"
+	"sync"
 
+	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
 	"storj.io/storj/storage"
 )
@@ -31,6 +33,71 @@ func sortByXOR(nodeIDs storj.NodeIDList, ref storj.NodeID) {
 	})
 }
 
+// SortNodesByXOR sorts nodes by their XOR distance to target, closest first.
+// Nodes at the same distance keep their order.
+func SortNodesByXOR(nodes []*pb.Node, target storj.NodeID) {
+	if len(nodes) < 2 {
+		return
+	}
+	sorter := xorSorters.Get().(*xorSorter)
+	sorter.sort(nodes, target)
+	xorSorters.Put(sorter)
+}
+
+// xorSorters holds the scratch space of SortNodesByXOR. A sorter is owned by
+// the call that got it until it is put back, and keeps no reference to the
+// nodes it sorted once put back.
+var xorSorters = sync.Pool{
+	New: func() interface{} { return &xorSorter{} },
+}
+
+// xorSorter sorts the indices of nodes by their precomputed distances, and
+// moves each node once the order is known.
+type xorSorter struct {
+	distances []storj.NodeID
+	order     []int
+	scratch   []*pb.Node
+}
+
+// sort sorts nodes by their distance to target, stably.
+func (sorter *xorSorter) sort(nodes []*pb.Node, target storj.NodeID) {
+	sorter.distances = sorter.distances[:0]
+	sorter.order = sorter.order[:0]
+	for i, node := range nodes {
+		sorter.distances = append(sorter.distances, xorNodeID(target, node.Id))
+		sorter.order = append(sorter.order, i)
+	}
+	sort.Stable(sorter)
+
+	sorter.scratch = append(sorter.scratch[:0], nodes...)
+	for i, index := range sorter.order {
+		nodes[i] = sorter.scratch[index]
+	}
+	for i := range sorter.scratch {
+		sorter.scratch[i] = nil
+	}
+}
+
+func (sorter *xorSorter) Len() int { return len(sorter.order) }
+
+func (sorter *xorSorter) Less(i, k int) bool {
+	return sorter.distances[sorter.order[i]].Less(sorter.distances[sorter.order[k]])
+}
+
+func (sorter *xorSorter) Swap(i, k int) {
+	sorter.order[i], sorter.order[k] = sorter.order[k], sorter.order[i]
+}
+
+// NodesSortedByXOR returns whether nodes are sorted by their XOR distance to target, closest first.
+func NodesSortedByXOR(nodes []*pb.Node, target storj.NodeID) bool {
+	for i := 1; i < len(nodes); i++ {
+		if compareByXor(nodes[i-1].Id, nodes[i].Id, target) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func keyToBucketID(key storage.Key) (bID bucketID) {
 	copy(bID[:], key)
 	return bID
@@ -8,7 +8,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeebo/errs"
+	"golang.org/x/sync/errgroup"
 
+	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
 )
 
@@ -24,6 +27,67 @@ func TestSortByXOR(t *testing.T) {
 	assert.Equal(t, sorted, unsorted)
 }
 
+func TestSortNodesByXOR(t *testing.T) {
+	target := storj.NodeID{127, 255}
+	nodes := []*pb.Node{
+		{Id: storj.NodeID{133, 255}}, //xor 250
+		{Id: storj.NodeID{127, 255}}, //xor 0
+		{Id: storj.NodeID{191, 255}}, //xor 192
+		{Id: storj.NodeID{255, 255}}, //xor 128
+	}
+	assert.False(t, NodesSortedByXOR(nodes, target))
+
+	SortNodesByXOR(nodes, target)
+	assert.True(t, NodesSortedByXOR(nodes, target))
+	assert.Equal(t, storj.NodeID{127, 255}, nodes[0].Id)
+	assert.Equal(t, storj.NodeID{255, 255}, nodes[1].Id)
+	assert.Equal(t, storj.NodeID{191, 255}, nodes[2].Id)
+	assert.Equal(t, storj.NodeID{133, 255}, nodes[3].Id)
+
+	assert.True(t, NodesSortedByXOR(nil, target))
+}
+
+func TestSortNodesByXORConcurrent(t *testing.T) {
+	// the sorters are pooled, concurrent sorts must not share them
+	var group errgroup.Group
+	for worker := 0; worker < 8; worker++ {
+		seed := int64(worker)
+		group.Go(func() error {
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < 100; i++ {
+				var target storj.NodeID
+				_, _ = r.Read(target[:])
+
+				nodes := make([]*pb.Node, 1+r.Intn(50))
+				ids := map[storj.NodeID]bool{}
+				for k := range nodes {
+					nodes[k] = &pb.Node{}
+					_, _ = r.Read(nodes[k].Id[:])
+					ids[nodes[k].Id] = true
+				}
+				// duplicates keep their order
+				nodes = append(nodes, &pb.Node{Id: nodes[0].Id, Address: &pb.NodeAddress{Address: "second"}})
+				first := nodes[0]
+
+				SortNodesByXOR(nodes, target)
+				if !NodesSortedByXOR(nodes, target) {
+					return errs.New("nodes not sorted")
+				}
+				for k, node := range nodes {
+					if !ids[node.Id] {
+						return errs.New("unexpected node %v", node.Id)
+					}
+					if node == first && nodes[k+1].Address.GetAddress() != "second" {
+						return errs.New("duplicates reordered")
+					}
+				}
+			}
+			return nil
+		})
+	}
+	assert.NoError(t, group.Wait())
+}
+
 func BenchmarkSortByXOR(b *testing.B) {
 	newNodeID := func() storj.NodeID {
 		var id storj.NodeID
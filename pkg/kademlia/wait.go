@@ -0,0 +1,119 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/pb"
+)
+
+const (
+	// defaultWaitBackoff is the delay after the first failed ping of WaitForNode
+	defaultWaitBackoff = 50 * time.Millisecond
+	// defaultWaitMaxBackoff is the longest delay between pings of WaitForNode
+	defaultWaitMaxBackoff = time.Second
+)
+
+// WaitOptions configures how WaitForNode waits for a node.
+type WaitOptions struct {
+	// MaxAttempts stops waiting after this many attempts, zero waits until
+	// the context expires
+	MaxAttempts int
+	// Backoff is the delay after the first failed attempt, doubling up to
+	// MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MinimumVersion additionally waits until the node reports at least
+	// this version, when set. Development builds of an unknown version are
+	// accepted.
+	MinimumVersion *version.SemVer
+}
+
+// attemptLimit stops a strategy after a number of attempts.
+type attemptLimit struct {
+	backoff.Strategy
+	max int
+}
+
+// Delay implements backoff.Strategy.
+func (strategy attemptLimit) Delay(failures int, elapsed time.Duration) (time.Duration, bool) {
+	if strategy.max > 0 && failures >= strategy.max {
+		return 0, false
+	}
+	return strategy.Strategy.Delay(failures, elapsed)
+}
+
+// WaitForNode pings node with exponential backoff until it responds, and
+// reports at least opts.MinimumVersion when set. It returns the latency of
+// the successful ping and the number of attempts made, also when it gives up
+// because ctx expired or opts.MaxAttempts were made.
+func WaitForNode(ctx context.Context, dialer *Dialer, node pb.Node, opts WaitOptions) (latency time.Duration, attempts int, err error) {
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultWaitBackoff
+	}
+	if opts.MaxBackoff < opts.Backoff {
+		opts.MaxBackoff = defaultWaitMaxBackoff
+		if opts.MaxBackoff < opts.Backoff {
+			opts.MaxBackoff = opts.Backoff
+		}
+	}
+	strategy := attemptLimit{
+		Strategy: &backoff.Exponential{Base: opts.Backoff, Max: opts.MaxBackoff},
+		max:      opts.MaxAttempts,
+	}
+
+	var last error
+	err = backoff.Retry(ctx, strategy, func(ctx context.Context) error {
+		attempts++
+		latency, last = waitAttempt(ctx, dialer, node, opts.MinimumVersion)
+		return last
+	})
+	if err == nil {
+		return latency, attempts, nil
+	}
+	if last == nil || last == err {
+		return 0, attempts, Error.New("node %s not reachable after %d attempts: %v", node.Id, attempts, err)
+	}
+	return 0, attempts, Error.New("node %s not reachable after %d attempts: %v: %v", node.Id, attempts, err, last)
+}
+
+// waitAttempt pings node once, and checks its version when minimum is set.
+func waitAttempt(ctx context.Context, dialer *Dialer, node pb.Node, minimum *version.SemVer) (time.Duration, error) {
+	start := time.Now()
+	ok, err := dialer.PingNode(ctx, node)
+	latency := time.Since(start)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, errs.New("ping failed")
+	}
+
+	if minimum == nil {
+		return latency, nil
+	}
+	reported, err := dialer.FetchVersion(ctx, node)
+	if err != nil {
+		return 0, err
+	}
+	current, err := version.FromProto(reported)
+	if err != nil {
+		return 0, errs.New("invalid version %q: %v", reported.GetVersion(), err)
+	}
+	if current.IsDev() {
+		// development builds can't be compared, they are waited for like any version
+		dialer.mon.Counter("wait_version_unknown").Inc(1)
+		return latency, nil
+	}
+	if current.Version.Compare(*minimum) < 0 {
+		return 0, errs.New("version %s is older than %s", current.Version.String(), minimum)
+	}
+	return latency, nil
+}
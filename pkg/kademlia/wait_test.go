@@ -0,0 +1,104 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testkademlia"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestWaitForNode(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server, err := testkademlia.Start(ctx)
+	require.NoError(t, err)
+	defer server.Close()
+	node := server.Node()
+
+	unavailable := testkademlia.Behavior{Code: codes.Unavailable}
+	reportVersion := func(reported string) {
+		info := &pb.InfoResponse{Version: &pb.NodeVersion{Version: reported}}
+		server.Set(testkademlia.Behavior{Info: info}, testkademlia.RequestInfo)
+	}
+
+	fid, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	k, err := newKademlia(zaptest.NewLogger(t), pb.NodeType_STORAGE, nil, "127.0.0.1:0", pb.NodeOperator{}, fid, ctx.Dir("kademlia"), defaultAlpha)
+	require.NoError(t, err)
+	defer ctx.Check(k.Close)
+
+	opts := WaitOptions{Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	{ // reachable nodes are pinged once
+		latency, attempts, err := WaitForNode(ctx, k.Dialer(), node, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+		assert.True(t, latency > 0)
+		assert.Equal(t, 1, server.Calls(testkademlia.Ping))
+	}
+
+	{ // nodes recovering are pinged until they respond
+		server.Script(testkademlia.Ping, unavailable, unavailable, unavailable)
+		_, attempts, err := WaitForNode(ctx, k.Dialer(), node, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 4, attempts)
+	}
+
+	{ // waiting stops after the maximum attempts
+		server.Set(unavailable, testkademlia.Ping)
+		limited := opts
+		limited.MaxAttempts = 3
+		_, attempts, err := WaitForNode(ctx, k.Dialer(), node, limited)
+		require.Error(t, err)
+		assert.Equal(t, 3, attempts)
+		assert.Contains(t, err.Error(), "after 3 attempts")
+	}
+
+	{ // waiting stops when the context expires
+		expiring, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, attempts, err := WaitForNode(expiring, k.Dialer(), node, opts)
+		require.Error(t, err)
+		assert.True(t, attempts > 1)
+		assert.Contains(t, err.Error(), "not reachable after")
+		assert.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+		server.Set(testkademlia.Behavior{}, testkademlia.Ping)
+	}
+
+	{ // a minimum version is waited for once the node is reachable
+		minimum := &version.SemVer{Major: 0, Minor: 2, Patch: 0}
+		reportVersion("v0.1.0")
+		required := opts
+		required.MinimumVersion = minimum
+		required.MaxAttempts = 2
+		_, attempts, err := WaitForNode(ctx, k.Dialer(), node, required)
+		require.Error(t, err)
+		assert.Equal(t, 2, attempts)
+		assert.Contains(t, err.Error(), "older than")
+
+		reportVersion("v0.2.0")
+		_, attempts, err = WaitForNode(ctx, k.Dialer(), node, required)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+
+		// the unknown version of a development build isn't older than the minimum
+		reportVersion("v0.0.0")
+		_, attempts, err = WaitForNode(ctx, k.Dialer(), node, required)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+	}
+}
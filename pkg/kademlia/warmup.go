@@ -0,0 +1,82 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// warmup limits serving queries while the node is starting up, so that
+// bootstrapping isn't slowed down by queries from other nodes.
+//
+// A nil warmup is never active.
+type warmup struct {
+	deadline   time.Time
+	untilReady bool
+	ready      int32
+
+	queries chan struct{}
+}
+
+// newWarmup starts a warmup, it returns nil when warmup is disabled.
+func newWarmup(config WarmupConfig) *warmup {
+	if config.Duration <= 0 {
+		return nil
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &warmup{
+		deadline:   time.Now().Add(config.Duration),
+		untilReady: config.UntilReady,
+		queries:    make(chan struct{}, concurrency),
+	}
+}
+
+// active returns whether the node is still warming up.
+func (w *warmup) active() bool {
+	if w == nil {
+		return false
+	}
+	if w.untilReady && atomic.LoadInt32(&w.ready) != 0 {
+		return false
+	}
+	return time.Now().Before(w.deadline)
+}
+
+// markReady marks bootstrapping as finished.
+func (w *warmup) markReady() {
+	if w != nil {
+		atomic.StoreInt32(&w.ready, 1)
+	}
+}
+
+// acquireQuery waits for a slot to serve a query when warming up.
+func (w *warmup) acquireQuery(ctx context.Context) (warmingUp bool, release func(), err error) {
+	if !w.active() {
+		return false, func() {}, nil
+	}
+	select {
+	case w.queries <- struct{}{}:
+		return true, func() { <-w.queries }, nil
+	case <-ctx.Done():
+		return true, nil, ctx.Err()
+	}
+}
+
+type bootstrapPriorityKey struct{}
+
+// withBootstrapPriority marks dials made with ctx as part of bootstrapping.
+func withBootstrapPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bootstrapPriorityKey{}, true)
+}
+
+// hasBootstrapPriority returns whether dials made with ctx are part of bootstrapping.
+func hasBootstrapPriority(ctx context.Context) bool {
+	priority, _ := ctx.Value(bootstrapPriorityKey{}).(bool)
+	return priority
+}
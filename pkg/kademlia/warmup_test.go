@@ -0,0 +1,145 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/pb"
+)
+
+func TestWarmup(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	node, server, clean := testNode(ctx, "node", t, []pb.Node{})
+	defer clean()
+	defer server.GracefulStop()
+
+	node.warmup = newWarmup(WarmupConfig{Duration: time.Hour, UntilReady: true, Concurrency: 1})
+	node.dialer.warmup = node.warmup
+
+	client, clientServer, cleanClient := testNode(ctx, "client", t, []pb.Node{})
+	defer cleanClient()
+	defer clientServer.GracefulStop()
+
+	conn, err := client.dialer.dialNode(ctx, node.Local().Node)
+	require.NoError(t, err)
+	defer ctx.Check(conn.disconnect)
+
+	query := func(ctx context.Context) (*pb.QueryResponse, error) {
+		self := client.Local().Node
+		return conn.client.Query(ctx, &pb.QueryRequest{Sender: &self, Target: &self, Limit: 20})
+	}
+
+	{ // responses are flagged while warming up
+		require.True(t, node.WarmingUp())
+		resp, err := query(ctx)
+		require.NoError(t, err)
+		assert.True(t, resp.WarmingUp)
+	}
+
+	{ // queries beyond the warmup concurrency wait for a slot
+		node.warmup.queries <- struct{}{}
+		timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		_, err := query(timeoutCtx)
+		cancel()
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		<-node.warmup.queries
+	}
+
+	{ // warmup ends once bootstrapping has finished
+		require.NoError(t, node.Bootstrap(ctx))
+		require.False(t, node.WarmingUp())
+		resp, err := query(ctx)
+		require.NoError(t, err)
+		assert.False(t, resp.WarmingUp)
+	}
+}
+
+func TestWarmupDuration(t *testing.T) {
+	w := newWarmup(WarmupConfig{Duration: time.Hour})
+	assert.True(t, w.active())
+	w.markReady()
+	assert.True(t, w.active(), "warmup should last the whole duration without UntilReady")
+
+	w.deadline = time.Now()
+	assert.False(t, w.active())
+
+	assert.Nil(t, newWarmup(WarmupConfig{}))
+	assert.False(t, (*warmup)(nil).active())
+}
+
+func TestWarmupDialPriority(t *testing.T) {
+	dialer := NewDialer(zaptest.NewLogger(t), nil)
+	defer func() { require.NoError(t, dialer.Close()) }()
+	dialer.warmup = newWarmup(WarmupConfig{Duration: time.Hour})
+
+	ctx := context.Background()
+	var releases []func()
+	for i := 0; i < defaultDialLimit-bootstrapReservedDials; i++ {
+		release, ok := dialer.acquire(ctx)
+		require.True(t, ok)
+		releases = append(releases, release)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		release, _ := dialer.acquire(ctx)
+		acquired <- release
+	}()
+
+	// bootstrapping can still use the reserved dials
+	for i := 0; i < bootstrapReservedDials; i++ {
+		release, ok := dialer.acquire(withBootstrapPriority(ctx))
+		require.True(t, ok)
+		defer release()
+	}
+
+	select {
+	case <-acquired:
+		t.Fatal("dial acquired a reserved slot during warmup")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releases[0]()
+	(<-acquired)()
+	for _, release := range releases[1:] {
+		release()
+	}
+}
+
+func TestWarmupDialCanceled(t *testing.T) {
+	dialer := NewDialer(zaptest.NewLogger(t), nil)
+	defer func() { require.NoError(t, dialer.Close()) }()
+	dialer.warmup = newWarmup(WarmupConfig{Duration: time.Hour})
+
+	ctx := context.Background()
+	var releases []func()
+	for i := 0; i < defaultDialLimit-bootstrapReservedDials; i++ {
+		release, ok := dialer.acquire(ctx)
+		require.True(t, ok)
+		releases = append(releases, release)
+	}
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+
+	// waiting for an unreserved slot stops when ctx is done
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, ok := dialer.acquire(timeout)
+	assert.False(t, ok)
+}
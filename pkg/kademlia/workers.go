@@ -0,0 +1,180 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/pprof"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// defaultWorkers is the number of workers used when none are configured
+const defaultWorkers = 64
+
+// WorkerPool runs the goroutines of kademlia, such as lookups and background
+// verification, on a limited number of workers.
+//
+// Jobs are queued while all workers are busy, so the number of goroutines
+// stays bounded regardless of the load. Workers are labeled with
+// "kademlia" set to the pool name and "job" set to the job name in
+// goroutine profiles.
+type WorkerPool struct {
+	log    *zap.Logger
+	size   int
+	labels context.Context
+
+	mu      sync.Mutex
+	work    sync.Cond
+	closed  bool
+	stopped chan struct{} // closed once the pool is closed and all workers have exited
+	workers int
+	idle    int
+	queue   []workerJob
+	running map[string]int
+}
+
+// workerJob is a named job waiting for a worker.
+type workerJob struct {
+	name string
+	fn   func()
+}
+
+// WorkerStats describes the jobs of a worker pool.
+type WorkerStats struct {
+	Size    int            `json:"size"`
+	Workers int            `json:"workers"`
+	Running map[string]int `json:"running"`
+	Queued  map[string]int `json:"queued"`
+}
+
+// NewWorkerPool creates a pool with at most size workers, a non-positive
+// size uses the default.
+func NewWorkerPool(log *zap.Logger, name string, size int) *WorkerPool {
+	if size <= 0 {
+		size = defaultWorkers
+	}
+	pool := &WorkerPool{
+		log:     log,
+		size:    size,
+		labels:  pprof.WithLabels(context.Background(), pprof.Labels("kademlia", name)),
+		stopped: make(chan struct{}),
+		running: make(map[string]int),
+	}
+	pool.work.L = &pool.mu
+	return pool
+}
+
+// Go queues fn to be run by a worker. Returns false when the pool has been closed.
+func (pool *WorkerPool) Go(name string, fn func()) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.closed {
+		return false
+	}
+	pool.queue = append(pool.queue, workerJob{name: name, fn: fn})
+
+	// each idle worker takes one job, start a new worker for the rest
+	if len(pool.queue) > pool.idle && pool.workers < pool.size {
+		pool.workers++
+		go pool.serve()
+	}
+	pool.work.Signal()
+	return true
+}
+
+// serve runs queued jobs until the pool is closed and the queue is empty.
+func (pool *WorkerPool) serve() {
+	pprof.SetGoroutineLabels(pool.labels)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for {
+		for len(pool.queue) == 0 {
+			if pool.closed {
+				pool.workers--
+				if pool.workers == 0 {
+					close(pool.stopped)
+				}
+				return
+			}
+			pool.idle++
+			pool.work.Wait()
+			pool.idle--
+		}
+
+		job := pool.queue[0]
+		pool.queue[0] = workerJob{}
+		pool.queue = pool.queue[1:]
+		pool.running[job.name]++
+		pool.mu.Unlock()
+
+		pprof.Do(pool.labels, pprof.Labels("job", job.name), func(context.Context) {
+			job.fn()
+		})
+
+		pool.mu.Lock()
+		pool.running[job.name]--
+		if pool.running[job.name] == 0 {
+			delete(pool.running, job.name)
+		}
+	}
+}
+
+// Close prevents new jobs from being queued and waits for the queued ones to
+// finish, or until ctx is done. The jobs still running then are left to
+// finish in the background.
+func (pool *WorkerPool) Close(ctx context.Context) error {
+	pool.mu.Lock()
+	if !pool.closed {
+		pool.closed = true
+		if pool.workers == 0 {
+			close(pool.stopped)
+		}
+		pool.work.Broadcast()
+	}
+	pool.mu.Unlock()
+
+	select {
+	case <-pool.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the number of running and queued jobs by name.
+func (pool *WorkerPool) Stats() WorkerStats {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	stats := WorkerStats{
+		Size:    pool.size,
+		Workers: pool.workers,
+		Running: make(map[string]int, len(pool.running)),
+		Queued:  make(map[string]int),
+	}
+	for name, count := range pool.running {
+		stats.Running[name] = count
+	}
+	for _, job := range pool.queue {
+		stats.Queued[job.name]++
+	}
+	return stats
+}
+
+// ServeHTTP writes the stats of the pool as JSON.
+func (pool *WorkerPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(pool.Stats()); err != nil {
+		pool.log.Debug("unable to write worker stats", zap.Error(err))
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/pkg/kademlia"
+)
+
+func TestWorkerPool(t *testing.T) {
+	pool := kademlia.NewWorkerPool(zaptest.NewLogger(t), "test", 2)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 5)
+	var finished int32
+	for i := 0; i < 5; i++ {
+		require.True(t, pool.Go("blocking", func() {
+			started <- struct{}{}
+			<-release
+			atomic.AddInt32(&finished, 1)
+		}))
+	}
+
+	// only two jobs run at once, the others are queued
+	<-started
+	<-started
+	stats := pool.Stats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 2, stats.Workers)
+	assert.Equal(t, map[string]int{"blocking": 2}, stats.Running)
+	assert.Equal(t, map[string]int{"blocking": 3}, stats.Queued)
+
+	recorder := httptest.NewRecorder()
+	pool.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/kademlia/workers", nil))
+	var served kademlia.WorkerStats
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &served))
+	assert.Equal(t, stats, served)
+
+	// closing runs the queued jobs and stops accepting new ones
+	close(release)
+	require.NoError(t, pool.Close(context.Background()))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&finished))
+	assert.False(t, pool.Go("rejected", func() {}))
+
+	stats = pool.Stats()
+	assert.Equal(t, 0, stats.Workers)
+	assert.Empty(t, stats.Running)
+	assert.Empty(t, stats.Queued)
+}
+
+func TestWorkerPoolCloseContext(t *testing.T) {
+	pool := kademlia.NewWorkerPool(zaptest.NewLogger(t), "test", 1)
+
+	release := make(chan struct{})
+	require.True(t, pool.Go("blocking", func() { <-release }))
+
+	// closing gives up waiting for the running job when ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, pool.Close(ctx))
+	assert.False(t, pool.Go("rejected", func() {}))
+
+	close(release)
+	require.NoError(t, pool.Close(context.Background()))
+}
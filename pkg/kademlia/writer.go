@@ -0,0 +1,125 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"sync"
+
+	"storj.io/storj/pkg/pb"
+)
+
+// seenEvent is a queued outcome of a connection to node.
+type seenEvent struct {
+	node   *pb.Node
+	failed bool
+	// the address of node turned out to be this node
+	self bool
+}
+
+// routingWriter applies seen events to the routing table in batches, so that
+// the dialing goroutines reporting them don't wait on the database.
+//
+// Batches are applied by a single job on workers, hence events are applied
+// in the order they were queued.
+type routingWriter struct {
+	apply   func(events []seenEvent)
+	workers *WorkerPool
+
+	mu      sync.Mutex
+	pending []seenEvent
+	depth   int           // events pending or being applied
+	active  bool          // whether a job is applying events
+	drained chan struct{} // closed when the active job finishes
+	closed  bool
+}
+
+// newRoutingWriter returns a writer that calls apply with batches of events on workers.
+func newRoutingWriter(workers *WorkerPool, apply func(events []seenEvent)) *routingWriter {
+	return &routingWriter{apply: apply, workers: workers}
+}
+
+// enqueue queues event to be applied, starting a job when none is active.
+// Returns false when the writer has been closed and the event is dropped.
+func (writer *routingWriter) enqueue(event seenEvent) bool {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+
+	if writer.closed {
+		return false
+	}
+	writer.pending = append(writer.pending, event)
+	writer.depth++
+	if writer.active {
+		return true
+	}
+	writer.active = true
+	writer.drained = make(chan struct{})
+	if !writer.workers.Go("routing-writer", writer.run) {
+		// the pool is only closed together with the writer
+		writer.pending, writer.depth, writer.active = nil, 0, false
+		close(writer.drained)
+		return false
+	}
+	return true
+}
+
+// run applies batches of events until the queue is empty.
+func (writer *routingWriter) run() {
+	for {
+		writer.mu.Lock()
+		batch := writer.pending
+		writer.pending = nil
+		if len(batch) == 0 {
+			writer.active = false
+			close(writer.drained)
+			writer.mu.Unlock()
+			return
+		}
+		writer.mu.Unlock()
+
+		writer.apply(batch)
+
+		writer.mu.Lock()
+		writer.depth -= len(batch)
+		writer.mu.Unlock()
+	}
+}
+
+// flush blocks until the queue has drained or ctx is done.
+func (writer *routingWriter) flush(ctx context.Context) error {
+	for {
+		writer.mu.Lock()
+		if !writer.active {
+			writer.mu.Unlock()
+			return nil
+		}
+		drained := writer.drained
+		writer.mu.Unlock()
+
+		select {
+		case <-drained:
+			// events may have been queued again in the meantime
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// queueDepth returns the number of events not yet applied.
+func (writer *routingWriter) queueDepth() int {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return writer.depth
+}
+
+// close stops accepting events and waits until the queued ones have been
+// applied or ctx is done.
+func (writer *routingWriter) close(ctx context.Context) error {
+	writer.mu.Lock()
+	writer.closed = true
+	writer.mu.Unlock()
+
+	return writer.workers.Close(ctx)
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package kademlia
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/teststorj"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// seenBurst reports a success for count nodes followed by a failure of every other node.
+func seenBurst(ctx *testcontext.Context, rt *RoutingTable, count int) []*pb.Node {
+	var nodes []*pb.Node
+	for i := 0; i < count; i++ {
+		node := &pb.Node{Id: storj.NodeID{byte(i + 1)}, Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}
+		nodes = append(nodes, node)
+		rt.ConnSuccess(ctx, node)
+	}
+	for i := 0; i < len(nodes); i += 2 {
+		rt.ConnFailure(ctx, nodes[i], nil)
+	}
+	return nodes
+}
+
+// requireSeenBurst checks that every event of seenBurst has been applied.
+func requireSeenBurst(t *testing.T, rt *RoutingTable, nodes []*pb.Node) {
+	dumped, err := rt.DumpNodes()
+	require.NoError(t, err)
+	stored := make(map[storj.NodeID]bool)
+	for _, node := range dumped {
+		stored[node.Id] = true
+	}
+	for i, node := range nodes {
+		assert.Equal(t, i%2 == 1, stored[node.Id], "node %d", i)
+	}
+}
+
+func TestRoutingTableCloseAppliesQueued(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(teststorj.NodeIDFromString("AA"), routingTableOpts{bucketSize: 100})
+	nodes := seenBurst(ctx, rt, 50)
+	require.NoError(t, rt.Close())
+	requireSeenBurst(t, rt, nodes)
+
+	// outcomes reported after closing are dropped
+	late := &pb.Node{Id: storj.NodeID{0xFF}, Address: &pb.NodeAddress{Address: "127.0.0.1:1"}}
+	rt.ConnSuccess(ctx, late)
+	_, ok := rt.seen[late.Id]
+	assert.False(t, ok)
+}
+
+func TestRoutingTableFlush(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	rt := createRoutingTableWith(teststorj.NodeIDFromString("AA"), routingTableOpts{bucketSize: 100})
+	defer ctx.Check(rt.Close)
+
+	nodes := seenBurst(ctx, rt, 50)
+	require.NoError(t, rt.Flush(ctx))
+	assert.Equal(t, 0, rt.QueueDepth())
+	requireSeenBurst(t, rt, nodes)
+}
+
+func TestRoutingWriterFlushContext(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	release := make(chan struct{})
+	writer := newRoutingWriter(NewWorkerPool(zaptest.NewLogger(t), "test", 1), func(events []seenEvent) { <-release })
+	defer ctx.Check(func() error { return writer.close(ctx) })
+
+	writer.enqueue(seenEvent{})
+	writer.enqueue(seenEvent{})
+	assert.Equal(t, 2, writer.queueDepth())
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, writer.flush(timeout))
+
+	close(release)
+	require.NoError(t, writer.flush(ctx))
+	assert.Equal(t, 0, writer.queueDepth())
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
 
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
 	"storj.io/storj/storage"
@@ -96,6 +98,9 @@ type NodeDossier struct {
 	Capacity   pb.NodeCapacity
 	Reputation NodeStats
 	Version    pb.NodeVersion
+
+	// Reachability is the score from recent dials to the node
+	Reachability float64
 }
 
 // NodeStats contains statistics about a node.
@@ -116,6 +121,8 @@ type Cache struct {
 	log         *zap.Logger
 	db          DB
 	preferences NodeSelectionConfig
+
+	reachability *Reachability
 }
 
 // NewCache returns a new Cache
@@ -124,6 +131,8 @@ func NewCache(log *zap.Logger, db DB, preferences NodeSelectionConfig) *Cache {
 		log:         log,
 		db:          db,
 		preferences: preferences,
+
+		reachability: NewReachability(preferences.Reachability),
 	}
 }
 
@@ -148,9 +157,27 @@ func (cache *Cache) Get(ctx context.Context, nodeID storj.NodeID) (_ *NodeDossie
 	if nodeID.IsZero() {
 		return nil, ErrEmptyNode
 	}
-	return cache.db.Get(ctx, nodeID)
+	node, err := cache.db.Get(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	node.Reachability = cache.reachability.Score(nodeID)
+	return node, nil
+}
+
+// NodeMetadata returns the type and the free disk of the node with id, for
+// filtering kademlia queries.
+func (cache *Cache) NodeMetadata(ctx context.Context, id storj.NodeID) (nodeType pb.NodeType, freeDisk int64, ok bool) {
+	node, err := cache.Get(ctx, id)
+	if err != nil {
+		return pb.NodeType_INVALID, 0, false
+	}
+	return node.Type, node.Capacity.FreeDisk, true
 }
 
+// Reachability returns the reachability scorer fed by the dial outcomes.
+func (cache *Cache) Reachability() *Reachability { return cache.reachability }
+
 // IsOnline checks if a node is 'online' based on the collected statistics.
 func (cache *Cache) IsOnline(node *NodeDossier) bool {
 	return time.Now().Sub(node.Reputation.LastContactSuccess) < cache.preferences.OnlineWindow &&
@@ -182,7 +209,7 @@ func (cache *Cache) FindStorageNodesWithPreferences(ctx context.Context, req Fin
 
 	var newNodes []*pb.Node
 	if newNodeCount > 0 {
-		newNodes, err = cache.db.SelectNewStorageNodes(ctx, newNodeCount, &NodeCriteria{
+		newNodes, err = cache.selectReachable(ctx, cache.db.SelectNewStorageNodes, newNodeCount, &NodeCriteria{
 			FreeBandwidth:     req.FreeBandwidth,
 			FreeDisk:          req.FreeDisk,
 			AuditCount:        preferences.AuditCount,
@@ -201,7 +228,7 @@ func (cache *Cache) FindStorageNodesWithPreferences(ctx context.Context, req Fin
 		excluded = append(excluded, newNode.Id)
 	}
 
-	reputableNodes, err := cache.db.SelectStorageNodes(ctx, reputableNodeCount-len(newNodes), &NodeCriteria{
+	reputableNodes, err := cache.selectReachable(ctx, cache.db.SelectStorageNodes, reputableNodeCount-len(newNodes), &NodeCriteria{
 		FreeBandwidth:      req.FreeBandwidth,
 		FreeDisk:           req.FreeDisk,
 		AuditCount:         preferences.AuditCount,
@@ -226,6 +253,39 @@ func (cache *Cache) FindStorageNodesWithPreferences(ctx context.Context, req Fin
 	return nodes, nil
 }
 
+// selectReachable selects count nodes matching criteria, replacing the
+// selected nodes that are unreachable according to their dial outcomes.
+//
+// Only the unreachable nodes that were selected are added to the excluded
+// nodes, so that the query doesn't grow with every unreachable node.
+func (cache *Cache) selectReachable(ctx context.Context, selectNodes func(context.Context, int, *NodeCriteria) ([]*pb.Node, error), count int, criteria *NodeCriteria) (nodes []*pb.Node, err error) {
+	// the excluded nodes of the caller must not be modified
+	criteria.Excluded = append([]storj.NodeID(nil), criteria.Excluded...)
+
+	for len(nodes) < count {
+		selected, err := selectNodes(ctx, count-len(nodes), criteria)
+		if err != nil {
+			return nil, err
+		}
+
+		unreachable := 0
+		for _, node := range selected {
+			// selecting again must not return the already selected nodes
+			criteria.Excluded = append(criteria.Excluded, node.Id)
+			if cache.reachability.Unreachable(node.Id) {
+				unreachable++
+				continue
+			}
+			nodes = append(nodes, node)
+		}
+		if unreachable == 0 {
+			break
+		}
+		mon.Meter("unreachable_nodes_skipped").Mark(unreachable)
+	}
+	return nodes, nil
+}
+
 // KnownUnreliableOrOffline filters a set of nodes to unhealth or offlines node, independent of new
 // Note that KnownUnreliableOrOffline will not return node ids which are not in the database at all
 func (cache *Cache) KnownUnreliableOrOffline(ctx context.Context, nodeIds storj.NodeIDList) (badNodes storj.NodeIDList, err error) {
@@ -270,9 +330,28 @@ func (cache *Cache) UpdateStats(ctx context.Context, request *UpdateRequest) (st
 // UpdateNodeInfo updates node dossier with info requested from the node itself like node type, email, wallet, capacity, and version.
 func (cache *Cache) UpdateNodeInfo(ctx context.Context, node storj.NodeID, nodeInfo *pb.InfoResponse) (stats *NodeDossier, err error) {
 	defer mon.Task()(&ctx)(&err)
+	if nodeInfo.GetVersion() != nil {
+		mon.Counter("node_version_" + versionCategory(nodeInfo.GetVersion())).Inc(1)
+	}
 	return cache.db.UpdateNodeInfo(ctx, node, nodeInfo)
 }
 
+// versionCategory returns whether reported is a release, a development build
+// or of an unknown version, which isn't compared with the other versions.
+func versionCategory(reported *pb.NodeVersion) string {
+	info, err := version.FromProto(reported)
+	switch {
+	case err != nil:
+		return "invalid"
+	case info.IsDev():
+		return "unknown"
+	case info.Release:
+		return "release"
+	default:
+		return "dev"
+	}
+}
+
 // UpdateUptime updates a single storagenode's uptime stats.
 func (cache *Cache) UpdateUptime(ctx context.Context, nodeID storj.NodeID, isUp bool) (stats *NodeStats, err error) {
 	defer mon.Task()(&ctx)(&err)
@@ -284,6 +363,8 @@ func (cache *Cache) ConnFailure(ctx context.Context, node *pb.Node, failureError
 	var err error
 	defer mon.Task()(&ctx)(&err)
 
+	cache.reachability.Record(node.Id, errs2.ClassifyDial(failureError))
+
 	// TODO: Kademlia paper specifies 5 unsuccessful PINGs before removing the node
 	// from our routing table, but this is the cache so maybe we want to treat
 	// it differently.
@@ -298,6 +379,8 @@ func (cache *Cache) ConnSuccess(ctx context.Context, node *pb.Node) {
 	var err error
 	defer mon.Task()(&ctx)(&err)
 
+	cache.reachability.Record(node.Id, errs2.DialSuccess)
+
 	err = cache.Put(ctx, node.Id, *node)
 	if err != nil {
 		zap.L().Debug("error updating uptime for node", zap.Error(err))
@@ -46,10 +46,11 @@ func (srv *Inspector) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*p
 	}
 
 	return &pb.GetStatsResponse{
-		AuditCount:  node.Reputation.AuditCount,
-		AuditRatio:  node.Reputation.AuditSuccessRatio,
-		UptimeCount: node.Reputation.UptimeCount,
-		UptimeRatio: node.Reputation.UptimeRatio,
+		AuditCount:   node.Reputation.AuditCount,
+		AuditRatio:   node.Reputation.AuditSuccessRatio,
+		UptimeCount:  node.Reputation.UptimeCount,
+		UptimeRatio:  node.Reputation.UptimeRatio,
+		Reachability: node.Reachability,
 	}, nil
 }
 
@@ -0,0 +1,128 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package overlay
+
+import (
+	"math"
+	"time"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/lrucache"
+	"storj.io/storj/pkg/storj"
+)
+
+const (
+	// maxReachabilityScore bounds the score, so that a long history of successes
+	// does not hide a node going offline.
+	maxReachabilityScore = 10
+	// forgetHalfLives is the number of half-lives after which the score of a
+	// node that hasn't been dialed is forgotten.
+	forgetHalfLives = 10
+)
+
+// ReachabilityConfig configures scoring nodes by the outcome of dials to them
+type ReachabilityConfig struct {
+	SuccessWeight float64       `help:"amount a successful dial adds to a node's reachability score" default:"1"`
+	TimeoutWeight float64       `help:"amount a timed out dial subtracts from a node's reachability score" default:"2"`
+	RefusedWeight float64       `help:"amount a refused dial subtracts from a node's reachability score" default:"1"`
+	FailureWeight float64       `help:"amount any other failed dial subtracts from a node's reachability score" default:"1"`
+	HalfLife      time.Duration `help:"time after which a reachability score has decayed to half" default:"1h"`
+	Threshold     float64       `help:"reachability score below which a node is excluded from selection, zero disables exclusion" default:"-5"`
+	MaxNodes      int           `help:"maximum number of nodes with a reachability score, the least recently dialed are forgotten first" default:"10000"`
+}
+
+// Reachability keeps a decaying score per node based on dial outcomes.
+//
+// Nodes are unreachable while their score is below the threshold, which the
+// score recovers from by decay or by successful dials. Only the scores of the
+// most recently dialed nodes are kept.
+type Reachability struct {
+	config ReachabilityConfig
+	now    func() time.Time
+
+	scores *lrucache.Cache
+}
+
+type reachabilityScore struct {
+	value   float64
+	updated time.Time
+}
+
+// NewReachability returns a new reachability scorer.
+func NewReachability(config ReachabilityConfig) *Reachability {
+	if config.MaxNodes <= 0 {
+		config.MaxNodes = 10000
+	}
+	return &Reachability{
+		config: config,
+		now:    time.Now,
+		scores: lrucache.New(config.MaxNodes),
+	}
+}
+
+// Record updates the score of the node with a dial outcome, canceled dials
+// say nothing about the node and are ignored.
+func (reachability *Reachability) Record(id storj.NodeID, outcome errs2.DialOutcome) {
+	if outcome == errs2.DialCanceled {
+		return
+	}
+
+	now := reachability.now()
+	reachability.scores.Update(id, func(value interface{}, ok bool) interface{} {
+		score := reachabilityScore{updated: now}
+		if ok {
+			score.value = reachability.decayed(value.(reachabilityScore), now)
+		}
+
+		switch outcome {
+		case errs2.DialSuccess:
+			// a node that can be dialed again is no longer penalized for past failures
+			score.value = math.Min(math.Max(score.value, 0)+reachability.config.SuccessWeight, maxReachabilityScore)
+		case errs2.DialTimeout:
+			score.value -= reachability.config.TimeoutWeight
+		case errs2.DialRefused:
+			score.value -= reachability.config.RefusedWeight
+		default:
+			score.value -= reachability.config.FailureWeight
+		}
+		return score
+	})
+}
+
+// Score returns the current score of the node, unknown nodes have a score of zero.
+func (reachability *Reachability) Score(id storj.NodeID) float64 {
+	value, ok := reachability.scores.Peek(id)
+	if !ok {
+		return 0
+	}
+
+	now := reachability.now()
+	score := value.(reachabilityScore)
+	if reachability.config.HalfLife > 0 && now.Sub(score.updated) > forgetHalfLives*reachability.config.HalfLife {
+		reachability.scores.Remove(id)
+		return 0
+	}
+	return reachability.decayed(score, now)
+}
+
+// Unreachable returns whether the node should be excluded from selection.
+func (reachability *Reachability) Unreachable(id storj.NodeID) bool {
+	threshold := reachability.config.Threshold
+	return threshold != 0 && reachability.Score(id) < threshold
+}
+
+// Len returns the number of nodes with a score.
+func (reachability *Reachability) Len() int { return reachability.scores.Len() }
+
+// decayed returns the score decayed until now.
+func (reachability *Reachability) decayed(score reachabilityScore, now time.Time) float64 {
+	if reachability.config.HalfLife <= 0 {
+		return score.value
+	}
+	elapsed := now.Sub(score.updated)
+	if elapsed <= 0 {
+		return score.value
+	}
+	return score.value * math.Exp2(-float64(elapsed)/float64(reachability.config.HalfLife))
+}
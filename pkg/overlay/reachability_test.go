@@ -0,0 +1,112 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package overlay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/teststorj"
+)
+
+func TestReachability(t *testing.T) {
+	now := time.Now()
+	reachability := NewReachability(ReachabilityConfig{
+		SuccessWeight: 1,
+		TimeoutWeight: 2,
+		RefusedWeight: 1,
+		FailureWeight: 0.5,
+		HalfLife:      time.Hour,
+		Threshold:     -3,
+	})
+	reachability.now = func() time.Time { return now }
+
+	a := teststorj.NodeIDFromString("a")
+	b := teststorj.NodeIDFromString("b")
+
+	assert.Equal(t, 0.0, reachability.Score(a))
+
+	for _, outcome := range []errs2.DialOutcome{errs2.DialSuccess, errs2.DialSuccess, errs2.DialTimeout, errs2.DialRefused, errs2.DialFailure} {
+		reachability.Record(a, outcome)
+	}
+	assert.InDelta(t, -1.5, reachability.Score(a), 1e-9)
+	assert.False(t, reachability.Unreachable(a))
+
+	{ // successes are capped
+		for i := 0; i < 2*maxReachabilityScore; i++ {
+			reachability.Record(b, errs2.DialSuccess)
+		}
+		assert.Equal(t, float64(maxReachabilityScore), reachability.Score(b))
+	}
+
+	{ // scores decay with the half-life
+		now = now.Add(time.Hour)
+		assert.InDelta(t, -0.75, reachability.Score(a), 1e-9)
+		assert.InDelta(t, maxReachabilityScore/2, reachability.Score(b), 1e-9)
+	}
+
+	{ // dropping below the threshold excludes the node
+		reachability.Record(a, errs2.DialTimeout)
+		reachability.Record(a, errs2.DialRefused)
+		assert.InDelta(t, -3.75, reachability.Score(a), 1e-9)
+		assert.True(t, reachability.Unreachable(a))
+		assert.False(t, reachability.Unreachable(b))
+	}
+
+	{ // until the score recovers by decay
+		now = now.Add(time.Hour)
+		assert.True(t, reachability.Score(a) > -3)
+		assert.False(t, reachability.Unreachable(a))
+	}
+
+	{ // or by a successful contact
+		reachability.Record(a, errs2.DialTimeout)
+		reachability.Record(a, errs2.DialTimeout)
+		assert.True(t, reachability.Unreachable(a))
+		reachability.Record(a, errs2.DialSuccess)
+		assert.False(t, reachability.Unreachable(a))
+	}
+
+	{ // scores of nodes that aren't dialed anymore are forgotten
+		now = now.Add(forgetHalfLives*time.Hour + time.Second)
+		assert.Equal(t, 2, reachability.Len())
+		assert.Equal(t, 0.0, reachability.Score(a))
+		assert.Equal(t, 1, reachability.Len())
+	}
+}
+
+func TestReachabilityMaxNodes(t *testing.T) {
+	reachability := NewReachability(ReachabilityConfig{TimeoutWeight: 1, Threshold: -1, MaxNodes: 2})
+
+	a := teststorj.NodeIDFromString("a")
+	b := teststorj.NodeIDFromString("b")
+	c := teststorj.NodeIDFromString("c")
+
+	reachability.Record(a, errs2.DialTimeout)
+	reachability.Record(b, errs2.DialTimeout)
+	reachability.Record(a, errs2.DialTimeout)
+	reachability.Record(c, errs2.DialTimeout)
+
+	// b is the least recently dialed node
+	assert.Equal(t, 2, reachability.Len())
+	assert.Equal(t, 0.0, reachability.Score(b))
+	assert.Equal(t, -2.0, reachability.Score(a))
+	assert.Equal(t, -1.0, reachability.Score(c))
+}
+
+func TestReachabilityWithoutThreshold(t *testing.T) {
+	reachability := NewReachability(ReachabilityConfig{TimeoutWeight: 1})
+
+	id := teststorj.NodeIDFromString("a")
+	for i := 0; i < 100; i++ {
+		reachability.Record(id, errs2.DialTimeout)
+	}
+	// canceled dials are ignored
+	reachability.Record(id, errs2.DialCanceled)
+	assert.Equal(t, -100.0, reachability.Score(id))
+	assert.False(t, reachability.Unreachable(id))
+}
@@ -4,17 +4,22 @@
 package overlay_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zeebo/errs"
+	"go.uber.org/zap"
 
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/testplanet"
 	"storj.io/storj/pkg/overlay"
+	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
+	"storj.io/storj/satellite"
 )
 
 func TestOffline(t *testing.T) {
@@ -215,3 +220,88 @@ func TestNodeSelection(t *testing.T) {
 		}
 	})
 }
+
+func TestUnreachableNodeSelection(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				config.Overlay.Node.Reachability = overlay.ReachabilityConfig{
+					SuccessWeight: 1,
+					TimeoutWeight: 2,
+					RefusedWeight: 1,
+					FailureWeight: 1,
+					HalfLife:      time.Hour,
+					Threshold:     -3,
+				}
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satellite := planet.Satellites[0]
+		paused := planet.StorageNodes[0]
+		require.NoError(t, planet.StopPeer(paused))
+
+		// dials made by any subsystem feed the score
+		node := paused.Local().Node
+		for satellite.Overlay.Service.Reachability().Score(paused.ID()) >= -3 {
+			dialCtx, cancel := context.WithTimeout(ctx, time.Second)
+			conn, err := satellite.Transport.DialNode(dialCtx, &node)
+			cancel()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			require.Error(t, err)
+		}
+
+		dossier, err := satellite.Overlay.Service.Get(ctx, paused.ID())
+		require.NoError(t, err)
+		assert.True(t, dossier.Reachability < -3)
+
+		nodes, err := satellite.Overlay.Service.FindStorageNodes(ctx, overlay.FindStorageNodesRequest{
+			RequestedCount: 4,
+		})
+		assert.True(t, overlay.ErrNotEnoughNodes.Has(err))
+		assert.Len(t, nodes, 3)
+		for _, node := range nodes {
+			assert.NotEqual(t, paused.ID(), node.Id)
+		}
+	})
+}
+
+func TestMinimumVersionSelection(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		satellite := planet.Satellites[0]
+		satellite.Discovery.Service.Refresh.Pause()
+
+		// the first node stays an unknown testplanet version
+		unknown := planet.StorageNodes[0].ID()
+		current := planet.StorageNodes[1].ID()
+		for i, reported := range []*pb.NodeVersion{
+			{Version: "v0.3.0", Release: true},
+			{Version: "v0.1.0", Release: true},
+			{Version: "v0.3.0"},
+		} {
+			reported.Timestamp = ptypes.TimestampNow()
+			_, err := satellite.Overlay.Service.UpdateNodeInfo(ctx, planet.StorageNodes[i+1].ID(), &pb.InfoResponse{
+				Type:    pb.NodeType_STORAGE,
+				Version: reported,
+			})
+			require.NoError(t, err)
+		}
+
+		// releases are compared with the minimum, development builds are
+		// only selected when their version is unknown
+		nodes, err := satellite.Overlay.Service.FindStorageNodesWithPreferences(ctx, overlay.FindStorageNodesRequest{
+			RequestedCount: 3,
+		}, &overlay.NodeSelectionConfig{OnlineWindow: time.Hour, MinimumVersion: "v0.2.0"})
+		assert.True(t, overlay.ErrNotEnoughNodes.Has(err))
+
+		var selected []storj.NodeID
+		for _, node := range nodes {
+			selected = append(selected, node.Id)
+		}
+		assert.ElementsMatch(t, []storj.NodeID{unknown, current}, selected)
+	})
+}
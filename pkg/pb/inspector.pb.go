@@ -217,6 +217,7 @@ type GetStatsResponse struct {
 	AuditRatio           float64  `protobuf:"fixed64,2,opt,name=audit_ratio,json=auditRatio,proto3" json:"audit_ratio,omitempty"`
 	UptimeCount          int64    `protobuf:"varint,3,opt,name=uptime_count,json=uptimeCount,proto3" json:"uptime_count,omitempty"`
 	UptimeRatio          float64  `protobuf:"fixed64,4,opt,name=uptime_ratio,json=uptimeRatio,proto3" json:"uptime_ratio,omitempty"`
+	Reachability         float64  `protobuf:"fixed64,5,opt,name=reachability,proto3" json:"reachability,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -274,6 +275,13 @@ func (m *GetStatsResponse) GetUptimeRatio() float64 {
 	return 0
 }
 
+func (m *GetStatsResponse) GetReachability() float64 {
+	if m != nil {
+		return m.Reachability
+	}
+	return 0
+}
+
 // CreateStats
 type CreateStatsRequest struct {
 	NodeId               NodeID   `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3,customtype=NodeID" json:"node_id"`
@@ -468,10 +476,11 @@ func (m *GetBucketListRequest) XXX_DiscardUnknown() {
 var xxx_messageInfo_GetBucketListRequest proto.InternalMessageInfo
 
 type GetBucketListResponse struct {
-	Buckets              []*GetBucketListResponse_Bucket `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                        `json:"-"`
-	XXX_unrecognized     []byte                          `json:"-"`
-	XXX_sizecache        int32                           `json:"-"`
+	Buckets              []*GetBucketListResponse_Bucket     `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	PinnedNodes          []*GetBucketListResponse_PinnedNode `protobuf:"bytes,2,rep,name=pinned_nodes,json=pinnedNodes,proto3" json:"pinned_nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                            `json:"-"`
+	XXX_unrecognized     []byte                              `json:"-"`
+	XXX_sizecache        int32                               `json:"-"`
 }
 
 func (m *GetBucketListResponse) Reset()         { *m = GetBucketListResponse{} }
@@ -505,6 +514,13 @@ func (m *GetBucketListResponse) GetBuckets() []*GetBucketListResponse_Bucket {
 	return nil
 }
 
+func (m *GetBucketListResponse) GetPinnedNodes() []*GetBucketListResponse_PinnedNode {
+	if m != nil {
+		return m.PinnedNodes
+	}
+	return nil
+}
+
 type GetBucketListResponse_Bucket struct {
 	BucketId             NodeID   `protobuf:"bytes,1,opt,name=bucket_id,json=bucketId,proto3,customtype=NodeID" json:"bucket_id"`
 	RoutingNodes         []*Node  `protobuf:"bytes,2,rep,name=routing_nodes,json=routingNodes,proto3" json:"routing_nodes,omitempty"`
@@ -552,6 +568,53 @@ func (m *GetBucketListResponse_Bucket) GetCachedNodes() []*Node {
 	return nil
 }
 
+// PinnedNode is a satellite or bootstrap node kept outside of the buckets
+type GetBucketListResponse_PinnedNode struct {
+	Node                 *Node    `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Type                 NodeType `protobuf:"varint,2,opt,name=type,proto3,enum=node.NodeType" json:"type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBucketListResponse_PinnedNode) Reset()         { *m = GetBucketListResponse_PinnedNode{} }
+func (m *GetBucketListResponse_PinnedNode) String() string { return proto.CompactTextString(m) }
+func (*GetBucketListResponse_PinnedNode) ProtoMessage()    {}
+func (*GetBucketListResponse_PinnedNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{10, 1}
+}
+func (m *GetBucketListResponse_PinnedNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBucketListResponse_PinnedNode.Unmarshal(m, b)
+}
+func (m *GetBucketListResponse_PinnedNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBucketListResponse_PinnedNode.Marshal(b, m, deterministic)
+}
+func (m *GetBucketListResponse_PinnedNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBucketListResponse_PinnedNode.Merge(m, src)
+}
+func (m *GetBucketListResponse_PinnedNode) XXX_Size() int {
+	return xxx_messageInfo_GetBucketListResponse_PinnedNode.Size(m)
+}
+func (m *GetBucketListResponse_PinnedNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBucketListResponse_PinnedNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBucketListResponse_PinnedNode proto.InternalMessageInfo
+
+func (m *GetBucketListResponse_PinnedNode) GetNode() *Node {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *GetBucketListResponse_PinnedNode) GetType() NodeType {
+	if m != nil {
+		return m.Type
+	}
+	return NodeType_INVALID
+}
+
 // GetBuckets
 type GetBucketsRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -1119,6 +1182,14 @@ func (m *FindNearResponse) GetNodes() []*Node {
 }
 
 type DumpNodesRequest struct {
+	// a chunked dump continues after this node of the snapshot of generation,
+	// the first request of a dump leaves both unset
+	After      NodeID `protobuf:"bytes,1,opt,name=after,proto3,customtype=NodeID" json:"after"`
+	Generation uint64 `protobuf:"varint,2,opt,name=generation,proto3" json:"generation,omitempty"`
+	// maximum number of nodes in the response, zero returns all the nodes
+	Limit int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	// optional features supported by the client, such as the compact encoding
+	Capabilities         uint64   `protobuf:"varint,4,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1148,8 +1219,36 @@ func (m *DumpNodesRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_DumpNodesRequest proto.InternalMessageInfo
 
+func (m *DumpNodesRequest) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+func (m *DumpNodesRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *DumpNodesRequest) GetCapabilities() uint64 {
+	if m != nil {
+		return m.Capabilities
+	}
+	return 0
+}
+
 type DumpNodesResponse struct {
-	Nodes                []*Node  `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Nodes      []*Node `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Generation uint64  `protobuf:"varint,2,opt,name=generation,proto3" json:"generation,omitempty"`
+	// whether more nodes follow, the last response has the checksum of all the nodes
+	More     bool   `protobuf:"varint,3,opt,name=more,proto3" json:"more,omitempty"`
+	Checksum []byte `protobuf:"bytes,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// the nodes in the compact encoding instead of nodes, when the client
+	// supports it
+	CompactNodes         []byte   `protobuf:"bytes,5,opt,name=compact_nodes,json=compactNodes,proto3" json:"compact_nodes,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1186,279 +1285,1726 @@ func (m *DumpNodesResponse) GetNodes() []*Node {
 	return nil
 }
 
-type StatsRequest struct {
+func (m *DumpNodesResponse) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+func (m *DumpNodesResponse) GetMore() bool {
+	if m != nil {
+		return m.More
+	}
+	return false
+}
+
+func (m *DumpNodesResponse) GetChecksum() []byte {
+	if m != nil {
+		return m.Checksum
+	}
+	return nil
+}
+
+func (m *DumpNodesResponse) GetCompactNodes() []byte {
+	if m != nil {
+		return m.CompactNodes
+	}
+	return nil
+}
+
+type GetContactHistoryRequest struct {
+	PeerId               NodeID   `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3,customtype=NodeID" json:"peer_id"`
+	Limit                int64    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *StatsRequest) Reset()         { *m = StatsRequest{} }
-func (m *StatsRequest) String() string { return proto.CompactTextString(m) }
-func (*StatsRequest) ProtoMessage()    {}
-func (*StatsRequest) Descriptor() ([]byte, []int) {
+func (m *GetContactHistoryRequest) Reset()         { *m = GetContactHistoryRequest{} }
+func (m *GetContactHistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*GetContactHistoryRequest) ProtoMessage()    {}
+func (*GetContactHistoryRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_a07d9034b2dd9d26, []int{27}
 }
-func (m *StatsRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StatsRequest.Unmarshal(m, b)
+func (m *GetContactHistoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetContactHistoryRequest.Unmarshal(m, b)
 }
-func (m *StatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StatsRequest.Marshal(b, m, deterministic)
+func (m *GetContactHistoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetContactHistoryRequest.Marshal(b, m, deterministic)
 }
-func (m *StatsRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StatsRequest.Merge(m, src)
+func (m *GetContactHistoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetContactHistoryRequest.Merge(m, src)
 }
-func (m *StatsRequest) XXX_Size() int {
-	return xxx_messageInfo_StatsRequest.Size(m)
+func (m *GetContactHistoryRequest) XXX_Size() int {
+	return xxx_messageInfo_GetContactHistoryRequest.Size(m)
 }
-func (m *StatsRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_StatsRequest.DiscardUnknown(m)
+func (m *GetContactHistoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetContactHistoryRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StatsRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetContactHistoryRequest proto.InternalMessageInfo
 
-type StatSummaryResponse struct {
-	UsedSpace            int64    `protobuf:"varint,1,opt,name=used_space,json=usedSpace,proto3" json:"used_space,omitempty"`
-	AvailableSpace       int64    `protobuf:"varint,2,opt,name=available_space,json=availableSpace,proto3" json:"available_space,omitempty"`
-	UsedIngress          int64    `protobuf:"varint,3,opt,name=used_ingress,json=usedIngress,proto3" json:"used_ingress,omitempty"`
-	UsedEgress           int64    `protobuf:"varint,4,opt,name=used_egress,json=usedEgress,proto3" json:"used_egress,omitempty"`
-	UsedBandwidth        int64    `protobuf:"varint,5,opt,name=used_bandwidth,json=usedBandwidth,proto3" json:"used_bandwidth,omitempty"`
-	AvailableBandwidth   int64    `protobuf:"varint,6,opt,name=available_bandwidth,json=availableBandwidth,proto3" json:"available_bandwidth,omitempty"`
+func (m *GetContactHistoryRequest) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type GetContactHistoryResponse struct {
+	NodeId NodeID          `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3,customtype=NodeID" json:"node_id"`
+	Events []*ContactEvent `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	// stats is what the routing table knows about the peer, unset when it
+	// doesn't know the peer
+	Stats *ContactStats `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
+	// contacts known for longer than tenure_threshold are evicted only after
+	// more than tenure_grace consecutive failed checks
+	TenureThreshold *duration.Duration `protobuf:"bytes,4,opt,name=tenure_threshold,json=tenureThreshold,proto3" json:"tenure_threshold,omitempty"`
+	TenureGrace     int32              `protobuf:"varint,5,opt,name=tenure_grace,json=tenureGrace,proto3" json:"tenure_grace,omitempty"`
+	// provenance is how the routing table learned the peer: verified,
+	// unverified when it is quarantined, or unknown
+	Provenance           string   `protobuf:"bytes,6,opt,name=provenance,proto3" json:"provenance,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *StatSummaryResponse) Reset()         { *m = StatSummaryResponse{} }
-func (m *StatSummaryResponse) String() string { return proto.CompactTextString(m) }
-func (*StatSummaryResponse) ProtoMessage()    {}
-func (*StatSummaryResponse) Descriptor() ([]byte, []int) {
+func (m *GetContactHistoryResponse) Reset()         { *m = GetContactHistoryResponse{} }
+func (m *GetContactHistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*GetContactHistoryResponse) ProtoMessage()    {}
+func (*GetContactHistoryResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_a07d9034b2dd9d26, []int{28}
 }
-func (m *StatSummaryResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StatSummaryResponse.Unmarshal(m, b)
+func (m *GetContactHistoryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetContactHistoryResponse.Unmarshal(m, b)
 }
-func (m *StatSummaryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StatSummaryResponse.Marshal(b, m, deterministic)
+func (m *GetContactHistoryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetContactHistoryResponse.Marshal(b, m, deterministic)
 }
-func (m *StatSummaryResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StatSummaryResponse.Merge(m, src)
+func (m *GetContactHistoryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetContactHistoryResponse.Merge(m, src)
 }
-func (m *StatSummaryResponse) XXX_Size() int {
-	return xxx_messageInfo_StatSummaryResponse.Size(m)
+func (m *GetContactHistoryResponse) XXX_Size() int {
+	return xxx_messageInfo_GetContactHistoryResponse.Size(m)
 }
-func (m *StatSummaryResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_StatSummaryResponse.DiscardUnknown(m)
+func (m *GetContactHistoryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetContactHistoryResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StatSummaryResponse proto.InternalMessageInfo
-
-func (m *StatSummaryResponse) GetUsedSpace() int64 {
-	if m != nil {
-		return m.UsedSpace
-	}
-	return 0
-}
+var xxx_messageInfo_GetContactHistoryResponse proto.InternalMessageInfo
 
-func (m *StatSummaryResponse) GetAvailableSpace() int64 {
+func (m *GetContactHistoryResponse) GetEvents() []*ContactEvent {
 	if m != nil {
-		return m.AvailableSpace
+		return m.Events
 	}
-	return 0
+	return nil
 }
 
-func (m *StatSummaryResponse) GetUsedIngress() int64 {
+func (m *GetContactHistoryResponse) GetStats() *ContactStats {
 	if m != nil {
-		return m.UsedIngress
+		return m.Stats
 	}
-	return 0
+	return nil
 }
 
-func (m *StatSummaryResponse) GetUsedEgress() int64 {
+func (m *GetContactHistoryResponse) GetTenureThreshold() *duration.Duration {
 	if m != nil {
-		return m.UsedEgress
+		return m.TenureThreshold
 	}
-	return 0
+	return nil
 }
 
-func (m *StatSummaryResponse) GetUsedBandwidth() int64 {
+func (m *GetContactHistoryResponse) GetTenureGrace() int32 {
 	if m != nil {
-		return m.UsedBandwidth
+		return m.TenureGrace
 	}
 	return 0
 }
 
-func (m *StatSummaryResponse) GetAvailableBandwidth() int64 {
+func (m *GetContactHistoryResponse) GetProvenance() string {
 	if m != nil {
-		return m.AvailableBandwidth
+		return m.Provenance
 	}
-	return 0
+	return ""
 }
 
-type DashboardRequest struct {
+type ContactStats struct {
+	FirstSeen           *timestamp.Timestamp `protobuf:"bytes,1,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
+	Successes           int64                `protobuf:"varint,2,opt,name=successes,proto3" json:"successes,omitempty"`
+	Failures            int64                `protobuf:"varint,3,opt,name=failures,proto3" json:"failures,omitempty"`
+	ConsecutiveFailures int32                `protobuf:"varint,4,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	Tenured             bool                 `protobuf:"varint,5,opt,name=tenured,proto3" json:"tenured,omitempty"`
+	// evicted is set when the peer was evicted and its stats are kept for
+	// when it reappears
+	Evicted              bool     `protobuf:"varint,6,opt,name=evicted,proto3" json:"evicted,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *DashboardRequest) Reset()         { *m = DashboardRequest{} }
-func (m *DashboardRequest) String() string { return proto.CompactTextString(m) }
-func (*DashboardRequest) ProtoMessage()    {}
-func (*DashboardRequest) Descriptor() ([]byte, []int) {
+func (m *ContactStats) Reset()         { *m = ContactStats{} }
+func (m *ContactStats) String() string { return proto.CompactTextString(m) }
+func (*ContactStats) ProtoMessage()    {}
+func (*ContactStats) Descriptor() ([]byte, []int) {
 	return fileDescriptor_a07d9034b2dd9d26, []int{29}
 }
-func (m *DashboardRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DashboardRequest.Unmarshal(m, b)
+func (m *ContactStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContactStats.Unmarshal(m, b)
 }
-func (m *DashboardRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DashboardRequest.Marshal(b, m, deterministic)
+func (m *ContactStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContactStats.Marshal(b, m, deterministic)
 }
-func (m *DashboardRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DashboardRequest.Merge(m, src)
+func (m *ContactStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContactStats.Merge(m, src)
 }
-func (m *DashboardRequest) XXX_Size() int {
-	return xxx_messageInfo_DashboardRequest.Size(m)
+func (m *ContactStats) XXX_Size() int {
+	return xxx_messageInfo_ContactStats.Size(m)
 }
-func (m *DashboardRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_DashboardRequest.DiscardUnknown(m)
+func (m *ContactStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContactStats.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DashboardRequest proto.InternalMessageInfo
+var xxx_messageInfo_ContactStats proto.InternalMessageInfo
 
-type DashboardResponse struct {
-	NodeId               NodeID               `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3,customtype=NodeID" json:"node_id"`
-	NodeConnections      int64                `protobuf:"varint,2,opt,name=node_connections,json=nodeConnections,proto3" json:"node_connections,omitempty"`
-	BootstrapAddress     string               `protobuf:"bytes,3,opt,name=bootstrap_address,json=bootstrapAddress,proto3" json:"bootstrap_address,omitempty"`
-	InternalAddress      string               `protobuf:"bytes,4,opt,name=internal_address,json=internalAddress,proto3" json:"internal_address,omitempty"`
-	ExternalAddress      string               `protobuf:"bytes,5,opt,name=external_address,json=externalAddress,proto3" json:"external_address,omitempty"`
-	Stats                *StatSummaryResponse `protobuf:"bytes,6,opt,name=stats,proto3" json:"stats,omitempty"`
-	Uptime               *duration.Duration   `protobuf:"bytes,7,opt,name=uptime,proto3" json:"uptime,omitempty"`
-	LastPinged           *timestamp.Timestamp `protobuf:"bytes,8,opt,name=last_pinged,json=lastPinged,proto3" json:"last_pinged,omitempty"`
-	LastQueried          *timestamp.Timestamp `protobuf:"bytes,9,opt,name=last_queried,json=lastQueried,proto3" json:"last_queried,omitempty"`
+func (m *ContactStats) GetFirstSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.FirstSeen
+	}
+	return nil
+}
+
+func (m *ContactStats) GetSuccesses() int64 {
+	if m != nil {
+		return m.Successes
+	}
+	return 0
+}
+
+func (m *ContactStats) GetFailures() int64 {
+	if m != nil {
+		return m.Failures
+	}
+	return 0
+}
+
+func (m *ContactStats) GetConsecutiveFailures() int32 {
+	if m != nil {
+		return m.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (m *ContactStats) GetTenured() bool {
+	if m != nil {
+		return m.Tenured
+	}
+	return false
+}
+
+func (m *ContactStats) GetEvicted() bool {
+	if m != nil {
+		return m.Evicted
+	}
+	return false
+}
+
+type ContactEvent struct {
+	Time                 *timestamp.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Operation            string               `protobuf:"bytes,2,opt,name=operation,proto3" json:"operation,omitempty"`
+	Direction            string               `protobuf:"bytes,3,opt,name=direction,proto3" json:"direction,omitempty"`
+	Address              string               `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	Duration             *duration.Duration   `protobuf:"bytes,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	ErrorClass           string               `protobuf:"bytes,6,opt,name=error_class,json=errorClass,proto3" json:"error_class,omitempty"`
+	Error                string               `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	ErrorCode            string               `protobuf:"bytes,8,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
 	XXX_unrecognized     []byte               `json:"-"`
 	XXX_sizecache        int32                `json:"-"`
 }
 
-func (m *DashboardResponse) Reset()         { *m = DashboardResponse{} }
-func (m *DashboardResponse) String() string { return proto.CompactTextString(m) }
-func (*DashboardResponse) ProtoMessage()    {}
-func (*DashboardResponse) Descriptor() ([]byte, []int) {
+func (m *ContactEvent) Reset()         { *m = ContactEvent{} }
+func (m *ContactEvent) String() string { return proto.CompactTextString(m) }
+func (*ContactEvent) ProtoMessage()    {}
+func (*ContactEvent) Descriptor() ([]byte, []int) {
 	return fileDescriptor_a07d9034b2dd9d26, []int{30}
 }
-func (m *DashboardResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DashboardResponse.Unmarshal(m, b)
+func (m *ContactEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContactEvent.Unmarshal(m, b)
 }
-func (m *DashboardResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DashboardResponse.Marshal(b, m, deterministic)
+func (m *ContactEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContactEvent.Marshal(b, m, deterministic)
 }
-func (m *DashboardResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DashboardResponse.Merge(m, src)
+func (m *ContactEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContactEvent.Merge(m, src)
 }
-func (m *DashboardResponse) XXX_Size() int {
-	return xxx_messageInfo_DashboardResponse.Size(m)
+func (m *ContactEvent) XXX_Size() int {
+	return xxx_messageInfo_ContactEvent.Size(m)
 }
-func (m *DashboardResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_DashboardResponse.DiscardUnknown(m)
+func (m *ContactEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContactEvent.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DashboardResponse proto.InternalMessageInfo
+var xxx_messageInfo_ContactEvent proto.InternalMessageInfo
 
-func (m *DashboardResponse) GetNodeConnections() int64 {
+func (m *ContactEvent) GetTime() *timestamp.Timestamp {
 	if m != nil {
-		return m.NodeConnections
+		return m.Time
 	}
-	return 0
+	return nil
 }
 
-func (m *DashboardResponse) GetBootstrapAddress() string {
+func (m *ContactEvent) GetOperation() string {
 	if m != nil {
-		return m.BootstrapAddress
+		return m.Operation
 	}
 	return ""
 }
 
-func (m *DashboardResponse) GetInternalAddress() string {
+func (m *ContactEvent) GetDirection() string {
 	if m != nil {
-		return m.InternalAddress
+		return m.Direction
 	}
 	return ""
 }
 
-func (m *DashboardResponse) GetExternalAddress() string {
+func (m *ContactEvent) GetAddress() string {
 	if m != nil {
-		return m.ExternalAddress
+		return m.Address
 	}
 	return ""
 }
 
-func (m *DashboardResponse) GetStats() *StatSummaryResponse {
+func (m *ContactEvent) GetDuration() *duration.Duration {
 	if m != nil {
-		return m.Stats
+		return m.Duration
 	}
 	return nil
 }
 
-func (m *DashboardResponse) GetUptime() *duration.Duration {
+func (m *ContactEvent) GetErrorClass() string {
 	if m != nil {
-		return m.Uptime
+		return m.ErrorClass
 	}
-	return nil
+	return ""
 }
 
-func (m *DashboardResponse) GetLastPinged() *timestamp.Timestamp {
+func (m *ContactEvent) GetError() string {
 	if m != nil {
-		return m.LastPinged
+		return m.Error
 	}
-	return nil
+	return ""
 }
 
-func (m *DashboardResponse) GetLastQueried() *timestamp.Timestamp {
+func (m *ContactEvent) GetErrorCode() string {
 	if m != nil {
-		return m.LastQueried
+		return m.ErrorCode
 	}
-	return nil
+	return ""
 }
 
-type SegmentHealthRequest struct {
-	Bucket               []byte   `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
-	EncryptedPath        []byte   `protobuf:"bytes,2,opt,name=encrypted_path,json=encryptedPath,proto3" json:"encrypted_path,omitempty"`
-	SegmentIndex         int64    `protobuf:"varint,3,opt,name=segment_index,json=segmentIndex,proto3" json:"segment_index,omitempty"`
-	ProjectId            []byte   `protobuf:"bytes,4,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+type LookupWithTraceRequest struct {
+	Target               NodeID   `protobuf:"bytes,1,opt,name=target,proto3,customtype=NodeID" json:"target"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SegmentHealthRequest) Reset()         { *m = SegmentHealthRequest{} }
-func (m *SegmentHealthRequest) String() string { return proto.CompactTextString(m) }
-func (*SegmentHealthRequest) ProtoMessage()    {}
-func (*SegmentHealthRequest) Descriptor() ([]byte, []int) {
+func (m *LookupWithTraceRequest) Reset()         { *m = LookupWithTraceRequest{} }
+func (m *LookupWithTraceRequest) String() string { return proto.CompactTextString(m) }
+func (*LookupWithTraceRequest) ProtoMessage()    {}
+func (*LookupWithTraceRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_a07d9034b2dd9d26, []int{31}
 }
-func (m *SegmentHealthRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SegmentHealthRequest.Unmarshal(m, b)
+func (m *LookupWithTraceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LookupWithTraceRequest.Unmarshal(m, b)
 }
-func (m *SegmentHealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SegmentHealthRequest.Marshal(b, m, deterministic)
+func (m *LookupWithTraceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LookupWithTraceRequest.Marshal(b, m, deterministic)
 }
-func (m *SegmentHealthRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SegmentHealthRequest.Merge(m, src)
+func (m *LookupWithTraceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LookupWithTraceRequest.Merge(m, src)
 }
-func (m *SegmentHealthRequest) XXX_Size() int {
-	return xxx_messageInfo_SegmentHealthRequest.Size(m)
+func (m *LookupWithTraceRequest) XXX_Size() int {
+	return xxx_messageInfo_LookupWithTraceRequest.Size(m)
 }
-func (m *SegmentHealthRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_SegmentHealthRequest.DiscardUnknown(m)
+func (m *LookupWithTraceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LookupWithTraceRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SegmentHealthRequest proto.InternalMessageInfo
-
-func (m *SegmentHealthRequest) GetBucket() []byte {
-	if m != nil {
-		return m.Bucket
-	}
-	return nil
-}
+var xxx_messageInfo_LookupWithTraceRequest proto.InternalMessageInfo
+
+type LookupWithTraceResponse struct {
+	// node is the node found, unset when the lookup failed with error
+	Node     *Node                `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Error    string               `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Start    *timestamp.Timestamp `protobuf:"bytes,3,opt,name=start,proto3" json:"start,omitempty"`
+	Duration *duration.Duration   `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	Hops     []*LookupHop         `protobuf:"bytes,5,rep,name=hops,proto3" json:"hops,omitempty"`
+	// dropped_hops is the number of hops not recorded, since the trace was full
+	DroppedHops          int64    `protobuf:"varint,6,opt,name=dropped_hops,json=droppedHops,proto3" json:"dropped_hops,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LookupWithTraceResponse) Reset()         { *m = LookupWithTraceResponse{} }
+func (m *LookupWithTraceResponse) String() string { return proto.CompactTextString(m) }
+func (*LookupWithTraceResponse) ProtoMessage()    {}
+func (*LookupWithTraceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{32}
+}
+func (m *LookupWithTraceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LookupWithTraceResponse.Unmarshal(m, b)
+}
+func (m *LookupWithTraceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LookupWithTraceResponse.Marshal(b, m, deterministic)
+}
+func (m *LookupWithTraceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LookupWithTraceResponse.Merge(m, src)
+}
+func (m *LookupWithTraceResponse) XXX_Size() int {
+	return xxx_messageInfo_LookupWithTraceResponse.Size(m)
+}
+func (m *LookupWithTraceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LookupWithTraceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LookupWithTraceResponse proto.InternalMessageInfo
+
+func (m *LookupWithTraceResponse) GetNode() *Node {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *LookupWithTraceResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *LookupWithTraceResponse) GetStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *LookupWithTraceResponse) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+func (m *LookupWithTraceResponse) GetHops() []*LookupHop {
+	if m != nil {
+		return m.Hops
+	}
+	return nil
+}
+
+func (m *LookupWithTraceResponse) GetDroppedHops() int64 {
+	if m != nil {
+		return m.DroppedHops
+	}
+	return 0
+}
+
+type LookupHop struct {
+	PeerId  NodeID `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3,customtype=NodeID" json:"peer_id"`
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// parent_id is the peer that returned this peer, zero when it was known before the lookup
+	ParentId             NodeID               `protobuf:"bytes,3,opt,name=parent_id,json=parentId,proto3,customtype=NodeID" json:"parent_id"`
+	Start                *timestamp.Timestamp `protobuf:"bytes,4,opt,name=start,proto3" json:"start,omitempty"`
+	Duration             *duration.Duration   `protobuf:"bytes,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	Learned              []NodeID             `protobuf:"bytes,6,rep,name=learned,proto3,customtype=NodeID" json:"learned"`
+	LearnedCount         int64                `protobuf:"varint,7,opt,name=learned_count,json=learnedCount,proto3" json:"learned_count,omitempty"`
+	Error                string               `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *LookupHop) Reset()         { *m = LookupHop{} }
+func (m *LookupHop) String() string { return proto.CompactTextString(m) }
+func (*LookupHop) ProtoMessage()    {}
+func (*LookupHop) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{33}
+}
+func (m *LookupHop) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LookupHop.Unmarshal(m, b)
+}
+func (m *LookupHop) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LookupHop.Marshal(b, m, deterministic)
+}
+func (m *LookupHop) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LookupHop.Merge(m, src)
+}
+func (m *LookupHop) XXX_Size() int {
+	return xxx_messageInfo_LookupHop.Size(m)
+}
+func (m *LookupHop) XXX_DiscardUnknown() {
+	xxx_messageInfo_LookupHop.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LookupHop proto.InternalMessageInfo
+
+func (m *LookupHop) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *LookupHop) GetStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *LookupHop) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+func (m *LookupHop) GetLearnedCount() int64 {
+	if m != nil {
+		return m.LearnedCount
+	}
+	return 0
+}
+
+func (m *LookupHop) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ReloadConfigRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadConfigRequest) Reset()         { *m = ReloadConfigRequest{} }
+func (m *ReloadConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*ReloadConfigRequest) ProtoMessage()    {}
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{34}
+}
+func (m *ReloadConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadConfigRequest.Unmarshal(m, b)
+}
+func (m *ReloadConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *ReloadConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadConfigRequest.Merge(m, src)
+}
+func (m *ReloadConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_ReloadConfigRequest.Size(m)
+}
+func (m *ReloadConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadConfigRequest proto.InternalMessageInfo
+
+type ReloadConfigResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadConfigResponse) Reset()         { *m = ReloadConfigResponse{} }
+func (m *ReloadConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*ReloadConfigResponse) ProtoMessage()    {}
+func (*ReloadConfigResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{35}
+}
+func (m *ReloadConfigResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadConfigResponse.Unmarshal(m, b)
+}
+func (m *ReloadConfigResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadConfigResponse.Marshal(b, m, deterministic)
+}
+func (m *ReloadConfigResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadConfigResponse.Merge(m, src)
+}
+func (m *ReloadConfigResponse) XXX_Size() int {
+	return xxx_messageInfo_ReloadConfigResponse.Size(m)
+}
+func (m *ReloadConfigResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadConfigResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadConfigResponse proto.InternalMessageInfo
+
+type NetworkSizeRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NetworkSizeRequest) Reset()         { *m = NetworkSizeRequest{} }
+func (m *NetworkSizeRequest) String() string { return proto.CompactTextString(m) }
+func (*NetworkSizeRequest) ProtoMessage()    {}
+func (*NetworkSizeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{36}
+}
+func (m *NetworkSizeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NetworkSizeRequest.Unmarshal(m, b)
+}
+func (m *NetworkSizeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NetworkSizeRequest.Marshal(b, m, deterministic)
+}
+func (m *NetworkSizeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NetworkSizeRequest.Merge(m, src)
+}
+func (m *NetworkSizeRequest) XXX_Size() int {
+	return xxx_messageInfo_NetworkSizeRequest.Size(m)
+}
+func (m *NetworkSizeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NetworkSizeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NetworkSizeRequest proto.InternalMessageInfo
+
+type NetworkSizeResponse struct {
+	Estimate int64 `protobuf:"varint,1,opt,name=estimate,proto3" json:"estimate,omitempty"`
+	// low and high bound the estimate with about 95% confidence
+	Low  int64 `protobuf:"varint,2,opt,name=low,proto3" json:"low,omitempty"`
+	High int64 `protobuf:"varint,3,opt,name=high,proto3" json:"high,omitempty"`
+	// exact is set when the routing table holds every node it has seen, the
+	// estimate is then its size
+	Exact                bool                 `protobuf:"varint,4,opt,name=exact,proto3" json:"exact,omitempty"`
+	Updated              *timestamp.Timestamp `protobuf:"bytes,5,opt,name=updated,proto3" json:"updated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *NetworkSizeResponse) Reset()         { *m = NetworkSizeResponse{} }
+func (m *NetworkSizeResponse) String() string { return proto.CompactTextString(m) }
+func (*NetworkSizeResponse) ProtoMessage()    {}
+func (*NetworkSizeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{37}
+}
+func (m *NetworkSizeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NetworkSizeResponse.Unmarshal(m, b)
+}
+func (m *NetworkSizeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NetworkSizeResponse.Marshal(b, m, deterministic)
+}
+func (m *NetworkSizeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NetworkSizeResponse.Merge(m, src)
+}
+func (m *NetworkSizeResponse) XXX_Size() int {
+	return xxx_messageInfo_NetworkSizeResponse.Size(m)
+}
+func (m *NetworkSizeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NetworkSizeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NetworkSizeResponse proto.InternalMessageInfo
+
+func (m *NetworkSizeResponse) GetEstimate() int64 {
+	if m != nil {
+		return m.Estimate
+	}
+	return 0
+}
+
+func (m *NetworkSizeResponse) GetLow() int64 {
+	if m != nil {
+		return m.Low
+	}
+	return 0
+}
+
+func (m *NetworkSizeResponse) GetHigh() int64 {
+	if m != nil {
+		return m.High
+	}
+	return 0
+}
+
+func (m *NetworkSizeResponse) GetExact() bool {
+	if m != nil {
+		return m.Exact
+	}
+	return false
+}
+
+func (m *NetworkSizeResponse) GetUpdated() *timestamp.Timestamp {
+	if m != nil {
+		return m.Updated
+	}
+	return nil
+}
+
+type AddressConflictsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddressConflictsRequest) Reset()         { *m = AddressConflictsRequest{} }
+func (m *AddressConflictsRequest) String() string { return proto.CompactTextString(m) }
+func (*AddressConflictsRequest) ProtoMessage()    {}
+func (*AddressConflictsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{38}
+}
+func (m *AddressConflictsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddressConflictsRequest.Unmarshal(m, b)
+}
+func (m *AddressConflictsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddressConflictsRequest.Marshal(b, m, deterministic)
+}
+func (m *AddressConflictsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddressConflictsRequest.Merge(m, src)
+}
+func (m *AddressConflictsRequest) XXX_Size() int {
+	return xxx_messageInfo_AddressConflictsRequest.Size(m)
+}
+func (m *AddressConflictsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddressConflictsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddressConflictsRequest proto.InternalMessageInfo
+
+type AddressConflictsResponse struct {
+	Conflicts            []*AddressConflict `protobuf:"bytes,1,rep,name=conflicts,proto3" json:"conflicts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *AddressConflictsResponse) Reset()         { *m = AddressConflictsResponse{} }
+func (m *AddressConflictsResponse) String() string { return proto.CompactTextString(m) }
+func (*AddressConflictsResponse) ProtoMessage()    {}
+func (*AddressConflictsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{39}
+}
+func (m *AddressConflictsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddressConflictsResponse.Unmarshal(m, b)
+}
+func (m *AddressConflictsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddressConflictsResponse.Marshal(b, m, deterministic)
+}
+func (m *AddressConflictsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddressConflictsResponse.Merge(m, src)
+}
+func (m *AddressConflictsResponse) XXX_Size() int {
+	return xxx_messageInfo_AddressConflictsResponse.Size(m)
+}
+func (m *AddressConflictsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddressConflictsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddressConflictsResponse proto.InternalMessageInfo
+
+func (m *AddressConflictsResponse) GetConflicts() []*AddressConflict {
+	if m != nil {
+		return m.Conflicts
+	}
+	return nil
+}
+
+// AddressConflict is a node verified at several addresses, because another
+// node uses its key or because it migrated
+type AddressConflict struct {
+	NodeId NodeID `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3,customtype=NodeID" json:"node_id"`
+	// preferred is the address in the routing table
+	Preferred            *VerifiedAddress   `protobuf:"bytes,2,opt,name=preferred,proto3" json:"preferred,omitempty"`
+	Alternates           []*VerifiedAddress `protobuf:"bytes,3,rep,name=alternates,proto3" json:"alternates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *AddressConflict) Reset()         { *m = AddressConflict{} }
+func (m *AddressConflict) String() string { return proto.CompactTextString(m) }
+func (*AddressConflict) ProtoMessage()    {}
+func (*AddressConflict) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{40}
+}
+func (m *AddressConflict) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddressConflict.Unmarshal(m, b)
+}
+func (m *AddressConflict) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddressConflict.Marshal(b, m, deterministic)
+}
+func (m *AddressConflict) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddressConflict.Merge(m, src)
+}
+func (m *AddressConflict) XXX_Size() int {
+	return xxx_messageInfo_AddressConflict.Size(m)
+}
+func (m *AddressConflict) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddressConflict.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddressConflict proto.InternalMessageInfo
+
+func (m *AddressConflict) GetPreferred() *VerifiedAddress {
+	if m != nil {
+		return m.Preferred
+	}
+	return nil
+}
+
+func (m *AddressConflict) GetAlternates() []*VerifiedAddress {
+	if m != nil {
+		return m.Alternates
+	}
+	return nil
+}
+
+type VerifiedAddress struct {
+	Address              *NodeAddress         `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	FirstVerified        *timestamp.Timestamp `protobuf:"bytes,2,opt,name=first_verified,json=firstVerified,proto3" json:"first_verified,omitempty"`
+	LastVerified         *timestamp.Timestamp `protobuf:"bytes,3,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *VerifiedAddress) Reset()         { *m = VerifiedAddress{} }
+func (m *VerifiedAddress) String() string { return proto.CompactTextString(m) }
+func (*VerifiedAddress) ProtoMessage()    {}
+func (*VerifiedAddress) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{41}
+}
+func (m *VerifiedAddress) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifiedAddress.Unmarshal(m, b)
+}
+func (m *VerifiedAddress) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifiedAddress.Marshal(b, m, deterministic)
+}
+func (m *VerifiedAddress) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifiedAddress.Merge(m, src)
+}
+func (m *VerifiedAddress) XXX_Size() int {
+	return xxx_messageInfo_VerifiedAddress.Size(m)
+}
+func (m *VerifiedAddress) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifiedAddress.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifiedAddress proto.InternalMessageInfo
+
+func (m *VerifiedAddress) GetAddress() *NodeAddress {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *VerifiedAddress) GetFirstVerified() *timestamp.Timestamp {
+	if m != nil {
+		return m.FirstVerified
+	}
+	return nil
+}
+
+func (m *VerifiedAddress) GetLastVerified() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastVerified
+	}
+	return nil
+}
+
+type QuarantinedNodesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QuarantinedNodesRequest) Reset()         { *m = QuarantinedNodesRequest{} }
+func (m *QuarantinedNodesRequest) String() string { return proto.CompactTextString(m) }
+func (*QuarantinedNodesRequest) ProtoMessage()    {}
+func (*QuarantinedNodesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{42}
+}
+func (m *QuarantinedNodesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QuarantinedNodesRequest.Unmarshal(m, b)
+}
+func (m *QuarantinedNodesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QuarantinedNodesRequest.Marshal(b, m, deterministic)
+}
+func (m *QuarantinedNodesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuarantinedNodesRequest.Merge(m, src)
+}
+func (m *QuarantinedNodesRequest) XXX_Size() int {
+	return xxx_messageInfo_QuarantinedNodesRequest.Size(m)
+}
+func (m *QuarantinedNodesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuarantinedNodesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuarantinedNodesRequest proto.InternalMessageInfo
+
+type QuarantinedNodesResponse struct {
+	Nodes                []*QuarantinedNode `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *QuarantinedNodesResponse) Reset()         { *m = QuarantinedNodesResponse{} }
+func (m *QuarantinedNodesResponse) String() string { return proto.CompactTextString(m) }
+func (*QuarantinedNodesResponse) ProtoMessage()    {}
+func (*QuarantinedNodesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{43}
+}
+func (m *QuarantinedNodesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QuarantinedNodesResponse.Unmarshal(m, b)
+}
+func (m *QuarantinedNodesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QuarantinedNodesResponse.Marshal(b, m, deterministic)
+}
+func (m *QuarantinedNodesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuarantinedNodesResponse.Merge(m, src)
+}
+func (m *QuarantinedNodesResponse) XXX_Size() int {
+	return xxx_messageInfo_QuarantinedNodesResponse.Size(m)
+}
+func (m *QuarantinedNodesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuarantinedNodesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuarantinedNodesResponse proto.InternalMessageInfo
+
+func (m *QuarantinedNodesResponse) GetNodes() []*QuarantinedNode {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+// QuarantinedNode is a node learned through an unverified dial, kept out of
+// the routing table until a verified dial reaches it
+type QuarantinedNode struct {
+	Node *Node `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// type is the type the node is pinned with once verified
+	Type                 NodeType             `protobuf:"varint,2,opt,name=type,proto3,enum=node.NodeType" json:"type,omitempty"`
+	Learned              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=learned,proto3" json:"learned,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *QuarantinedNode) Reset()         { *m = QuarantinedNode{} }
+func (m *QuarantinedNode) String() string { return proto.CompactTextString(m) }
+func (*QuarantinedNode) ProtoMessage()    {}
+func (*QuarantinedNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{44}
+}
+func (m *QuarantinedNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QuarantinedNode.Unmarshal(m, b)
+}
+func (m *QuarantinedNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QuarantinedNode.Marshal(b, m, deterministic)
+}
+func (m *QuarantinedNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuarantinedNode.Merge(m, src)
+}
+func (m *QuarantinedNode) XXX_Size() int {
+	return xxx_messageInfo_QuarantinedNode.Size(m)
+}
+func (m *QuarantinedNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuarantinedNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuarantinedNode proto.InternalMessageInfo
+
+func (m *QuarantinedNode) GetNode() *Node {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *QuarantinedNode) GetType() NodeType {
+	if m != nil {
+		return m.Type
+	}
+	return NodeType_INVALID
+}
+
+func (m *QuarantinedNode) GetLearned() *timestamp.Timestamp {
+	if m != nil {
+		return m.Learned
+	}
+	return nil
+}
+
+type RPCPoliciesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RPCPoliciesRequest) Reset()         { *m = RPCPoliciesRequest{} }
+func (m *RPCPoliciesRequest) String() string { return proto.CompactTextString(m) }
+func (*RPCPoliciesRequest) ProtoMessage()    {}
+func (*RPCPoliciesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{45}
+}
+func (m *RPCPoliciesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RPCPoliciesRequest.Unmarshal(m, b)
+}
+func (m *RPCPoliciesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RPCPoliciesRequest.Marshal(b, m, deterministic)
+}
+func (m *RPCPoliciesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RPCPoliciesRequest.Merge(m, src)
+}
+func (m *RPCPoliciesRequest) XXX_Size() int {
+	return xxx_messageInfo_RPCPoliciesRequest.Size(m)
+}
+func (m *RPCPoliciesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RPCPoliciesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RPCPoliciesRequest proto.InternalMessageInfo
+
+type RPCPoliciesResponse struct {
+	Policies             []*RPCPolicy `protobuf:"bytes,1,rep,name=policies,proto3" json:"policies,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *RPCPoliciesResponse) Reset()         { *m = RPCPoliciesResponse{} }
+func (m *RPCPoliciesResponse) String() string { return proto.CompactTextString(m) }
+func (*RPCPoliciesResponse) ProtoMessage()    {}
+func (*RPCPoliciesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{46}
+}
+func (m *RPCPoliciesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RPCPoliciesResponse.Unmarshal(m, b)
+}
+func (m *RPCPoliciesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RPCPoliciesResponse.Marshal(b, m, deterministic)
+}
+func (m *RPCPoliciesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RPCPoliciesResponse.Merge(m, src)
+}
+func (m *RPCPoliciesResponse) XXX_Size() int {
+	return xxx_messageInfo_RPCPoliciesResponse.Size(m)
+}
+func (m *RPCPoliciesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RPCPoliciesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RPCPoliciesResponse proto.InternalMessageInfo
+
+func (m *RPCPoliciesResponse) GetPolicies() []*RPCPolicy {
+	if m != nil {
+		return m.Policies
+	}
+	return nil
+}
+
+type RPCPolicy struct {
+	Rpc string `protobuf:"bytes,1,opt,name=rpc,proto3" json:"rpc,omitempty"`
+	// timeout of a single attempt, zero when left to the caller
+	Timeout              *duration.Duration `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Retries              int64              `protobuf:"varint,3,opt,name=retries,proto3" json:"retries,omitempty"`
+	Backoff              *duration.Duration `protobuf:"bytes,4,opt,name=backoff,proto3" json:"backoff,omitempty"`
+	Priority             string             `protobuf:"bytes,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *RPCPolicy) Reset()         { *m = RPCPolicy{} }
+func (m *RPCPolicy) String() string { return proto.CompactTextString(m) }
+func (*RPCPolicy) ProtoMessage()    {}
+func (*RPCPolicy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{47}
+}
+func (m *RPCPolicy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RPCPolicy.Unmarshal(m, b)
+}
+func (m *RPCPolicy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RPCPolicy.Marshal(b, m, deterministic)
+}
+func (m *RPCPolicy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RPCPolicy.Merge(m, src)
+}
+func (m *RPCPolicy) XXX_Size() int {
+	return xxx_messageInfo_RPCPolicy.Size(m)
+}
+func (m *RPCPolicy) XXX_DiscardUnknown() {
+	xxx_messageInfo_RPCPolicy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RPCPolicy proto.InternalMessageInfo
+
+func (m *RPCPolicy) GetRpc() string {
+	if m != nil {
+		return m.Rpc
+	}
+	return ""
+}
+
+func (m *RPCPolicy) GetTimeout() *duration.Duration {
+	if m != nil {
+		return m.Timeout
+	}
+	return nil
+}
+
+func (m *RPCPolicy) GetRetries() int64 {
+	if m != nil {
+		return m.Retries
+	}
+	return 0
+}
+
+func (m *RPCPolicy) GetBackoff() *duration.Duration {
+	if m != nil {
+		return m.Backoff
+	}
+	return nil
+}
+
+func (m *RPCPolicy) GetPriority() string {
+	if m != nil {
+		return m.Priority
+	}
+	return ""
+}
+
+type StatsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsRequest) Reset()         { *m = StatsRequest{} }
+func (m *StatsRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsRequest) ProtoMessage()    {}
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{48}
+}
+func (m *StatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsRequest.Unmarshal(m, b)
+}
+func (m *StatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsRequest.Merge(m, src)
+}
+func (m *StatsRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsRequest.Size(m)
+}
+func (m *StatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsRequest proto.InternalMessageInfo
+
+type StatSummaryResponse struct {
+	UsedSpace            int64    `protobuf:"varint,1,opt,name=used_space,json=usedSpace,proto3" json:"used_space,omitempty"`
+	AvailableSpace       int64    `protobuf:"varint,2,opt,name=available_space,json=availableSpace,proto3" json:"available_space,omitempty"`
+	UsedIngress          int64    `protobuf:"varint,3,opt,name=used_ingress,json=usedIngress,proto3" json:"used_ingress,omitempty"`
+	UsedEgress           int64    `protobuf:"varint,4,opt,name=used_egress,json=usedEgress,proto3" json:"used_egress,omitempty"`
+	UsedBandwidth        int64    `protobuf:"varint,5,opt,name=used_bandwidth,json=usedBandwidth,proto3" json:"used_bandwidth,omitempty"`
+	AvailableBandwidth   int64    `protobuf:"varint,6,opt,name=available_bandwidth,json=availableBandwidth,proto3" json:"available_bandwidth,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatSummaryResponse) Reset()         { *m = StatSummaryResponse{} }
+func (m *StatSummaryResponse) String() string { return proto.CompactTextString(m) }
+func (*StatSummaryResponse) ProtoMessage()    {}
+func (*StatSummaryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{49}
+}
+func (m *StatSummaryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatSummaryResponse.Unmarshal(m, b)
+}
+func (m *StatSummaryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatSummaryResponse.Marshal(b, m, deterministic)
+}
+func (m *StatSummaryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatSummaryResponse.Merge(m, src)
+}
+func (m *StatSummaryResponse) XXX_Size() int {
+	return xxx_messageInfo_StatSummaryResponse.Size(m)
+}
+func (m *StatSummaryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatSummaryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatSummaryResponse proto.InternalMessageInfo
+
+func (m *StatSummaryResponse) GetUsedSpace() int64 {
+	if m != nil {
+		return m.UsedSpace
+	}
+	return 0
+}
+
+func (m *StatSummaryResponse) GetAvailableSpace() int64 {
+	if m != nil {
+		return m.AvailableSpace
+	}
+	return 0
+}
+
+func (m *StatSummaryResponse) GetUsedIngress() int64 {
+	if m != nil {
+		return m.UsedIngress
+	}
+	return 0
+}
+
+func (m *StatSummaryResponse) GetUsedEgress() int64 {
+	if m != nil {
+		return m.UsedEgress
+	}
+	return 0
+}
+
+func (m *StatSummaryResponse) GetUsedBandwidth() int64 {
+	if m != nil {
+		return m.UsedBandwidth
+	}
+	return 0
+}
+
+func (m *StatSummaryResponse) GetAvailableBandwidth() int64 {
+	if m != nil {
+		return m.AvailableBandwidth
+	}
+	return 0
+}
+
+type DashboardRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DashboardRequest) Reset()         { *m = DashboardRequest{} }
+func (m *DashboardRequest) String() string { return proto.CompactTextString(m) }
+func (*DashboardRequest) ProtoMessage()    {}
+func (*DashboardRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{50}
+}
+func (m *DashboardRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DashboardRequest.Unmarshal(m, b)
+}
+func (m *DashboardRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DashboardRequest.Marshal(b, m, deterministic)
+}
+func (m *DashboardRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DashboardRequest.Merge(m, src)
+}
+func (m *DashboardRequest) XXX_Size() int {
+	return xxx_messageInfo_DashboardRequest.Size(m)
+}
+func (m *DashboardRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DashboardRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DashboardRequest proto.InternalMessageInfo
+
+type DashboardResponse struct {
+	NodeId           NodeID               `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3,customtype=NodeID" json:"node_id"`
+	NodeConnections  int64                `protobuf:"varint,2,opt,name=node_connections,json=nodeConnections,proto3" json:"node_connections,omitempty"`
+	BootstrapAddress string               `protobuf:"bytes,3,opt,name=bootstrap_address,json=bootstrapAddress,proto3" json:"bootstrap_address,omitempty"`
+	InternalAddress  string               `protobuf:"bytes,4,opt,name=internal_address,json=internalAddress,proto3" json:"internal_address,omitempty"`
+	ExternalAddress  string               `protobuf:"bytes,5,opt,name=external_address,json=externalAddress,proto3" json:"external_address,omitempty"`
+	Stats            *StatSummaryResponse `protobuf:"bytes,6,opt,name=stats,proto3" json:"stats,omitempty"`
+	Uptime           *duration.Duration   `protobuf:"bytes,7,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	LastPinged       *timestamp.Timestamp `protobuf:"bytes,8,opt,name=last_pinged,json=lastPinged,proto3" json:"last_pinged,omitempty"`
+	LastQueried      *timestamp.Timestamp `protobuf:"bytes,9,opt,name=last_queried,json=lastQueried,proto3" json:"last_queried,omitempty"`
+	LastCheckin      *timestamp.Timestamp `protobuf:"bytes,10,opt,name=last_checkin,json=lastCheckin,proto3" json:"last_checkin,omitempty"`
+	Version          *VersionStatus       `protobuf:"bytes,11,opt,name=version,proto3" json:"version,omitempty"`
+	// observed_address is the address peers observed the node connecting
+	// from, set when its host differs from the external address
+	ObservedAddress string               `protobuf:"bytes,12,opt,name=observed_address,json=observedAddress,proto3" json:"observed_address,omitempty"`
+	NetworkSize     *NetworkSizeResponse `protobuf:"bytes,13,opt,name=network_size,json=networkSize,proto3" json:"network_size,omitempty"`
+	// clock_skew is how far the clock of the node is ahead of the satellites,
+	// set when it is beyond the warning threshold
+	ClockSkew *duration.Duration `protobuf:"bytes,14,opt,name=clock_skew,json=clockSkew,proto3" json:"clock_skew,omitempty"`
+	// bandwidth is the traffic of the node itself, such as discovery, per
+	// component
+	Bandwidth []*LabelBandwidth `protobuf:"bytes,15,rep,name=bandwidth,proto3" json:"bandwidth,omitempty"`
+	// address_unreachable lists the satellites that couldn't reach the node
+	// at its address when it last checked in
+	AddressUnreachable   []NodeID `protobuf:"bytes,16,rep,name=address_unreachable,json=addressUnreachable,proto3,customtype=NodeID" json:"address_unreachable"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DashboardResponse) Reset()         { *m = DashboardResponse{} }
+func (m *DashboardResponse) String() string { return proto.CompactTextString(m) }
+func (*DashboardResponse) ProtoMessage()    {}
+func (*DashboardResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{51}
+}
+func (m *DashboardResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DashboardResponse.Unmarshal(m, b)
+}
+func (m *DashboardResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DashboardResponse.Marshal(b, m, deterministic)
+}
+func (m *DashboardResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DashboardResponse.Merge(m, src)
+}
+func (m *DashboardResponse) XXX_Size() int {
+	return xxx_messageInfo_DashboardResponse.Size(m)
+}
+func (m *DashboardResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DashboardResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DashboardResponse proto.InternalMessageInfo
+
+func (m *DashboardResponse) GetNodeConnections() int64 {
+	if m != nil {
+		return m.NodeConnections
+	}
+	return 0
+}
+
+func (m *DashboardResponse) GetBootstrapAddress() string {
+	if m != nil {
+		return m.BootstrapAddress
+	}
+	return ""
+}
+
+func (m *DashboardResponse) GetInternalAddress() string {
+	if m != nil {
+		return m.InternalAddress
+	}
+	return ""
+}
+
+func (m *DashboardResponse) GetExternalAddress() string {
+	if m != nil {
+		return m.ExternalAddress
+	}
+	return ""
+}
+
+func (m *DashboardResponse) GetStats() *StatSummaryResponse {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetUptime() *duration.Duration {
+	if m != nil {
+		return m.Uptime
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetLastPinged() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastPinged
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetLastQueried() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastQueried
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetLastCheckin() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastCheckin
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetVersion() *VersionStatus {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetObservedAddress() string {
+	if m != nil {
+		return m.ObservedAddress
+	}
+	return ""
+}
+
+func (m *DashboardResponse) GetNetworkSize() *NetworkSizeResponse {
+	if m != nil {
+		return m.NetworkSize
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetClockSkew() *duration.Duration {
+	if m != nil {
+		return m.ClockSkew
+	}
+	return nil
+}
+
+func (m *DashboardResponse) GetBandwidth() []*LabelBandwidth {
+	if m != nil {
+		return m.Bandwidth
+	}
+	return nil
+}
+
+type ReachabilityRequest struct {
+	// days is the number of most recent days returned, 0 for all the kept days
+	Days                 int32    `protobuf:"varint,1,opt,name=days,proto3" json:"days,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReachabilityRequest) Reset()         { *m = ReachabilityRequest{} }
+func (m *ReachabilityRequest) String() string { return proto.CompactTextString(m) }
+func (*ReachabilityRequest) ProtoMessage()    {}
+func (*ReachabilityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{52}
+}
+func (m *ReachabilityRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReachabilityRequest.Unmarshal(m, b)
+}
+func (m *ReachabilityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReachabilityRequest.Marshal(b, m, deterministic)
+}
+func (m *ReachabilityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReachabilityRequest.Merge(m, src)
+}
+func (m *ReachabilityRequest) XXX_Size() int {
+	return xxx_messageInfo_ReachabilityRequest.Size(m)
+}
+func (m *ReachabilityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReachabilityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReachabilityRequest proto.InternalMessageInfo
+
+func (m *ReachabilityRequest) GetDays() int32 {
+	if m != nil {
+		return m.Days
+	}
+	return 0
+}
+
+type ReachabilityResponse struct {
+	// rollups are sorted by day and satellite
+	Rollups              []*SatelliteReachability `protobuf:"bytes,1,rep,name=rollups,proto3" json:"rollups,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *ReachabilityResponse) Reset()         { *m = ReachabilityResponse{} }
+func (m *ReachabilityResponse) String() string { return proto.CompactTextString(m) }
+func (*ReachabilityResponse) ProtoMessage()    {}
+func (*ReachabilityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{53}
+}
+func (m *ReachabilityResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReachabilityResponse.Unmarshal(m, b)
+}
+func (m *ReachabilityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReachabilityResponse.Marshal(b, m, deterministic)
+}
+func (m *ReachabilityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReachabilityResponse.Merge(m, src)
+}
+func (m *ReachabilityResponse) XXX_Size() int {
+	return xxx_messageInfo_ReachabilityResponse.Size(m)
+}
+func (m *ReachabilityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReachabilityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReachabilityResponse proto.InternalMessageInfo
+
+func (m *ReachabilityResponse) GetRollups() []*SatelliteReachability {
+	if m != nil {
+		return m.Rollups
+	}
+	return nil
+}
+
+type SatelliteReachability struct {
+	SatelliteId NodeID `protobuf:"bytes,1,opt,name=satellite_id,json=satelliteId,proto3,customtype=NodeID" json:"satellite_id"`
+	// day is the UTC midnight the day starts at
+	Day       *timestamp.Timestamp `protobuf:"bytes,2,opt,name=day,proto3" json:"day,omitempty"`
+	Attempts  int64                `protobuf:"varint,3,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	Successes int64                `protobuf:"varint,4,opt,name=successes,proto3" json:"successes,omitempty"`
+	// downtime_windows is the number of downtime windows starting during the day
+	DowntimeWindows int64 `protobuf:"varint,5,opt,name=downtime_windows,json=downtimeWindows,proto3" json:"downtime_windows,omitempty"`
+	// observed is the time between the check-ins during the day, downtime the
+	// part of it that followed failed check-ins
+	Observed *duration.Duration `protobuf:"bytes,6,opt,name=observed,proto3" json:"observed,omitempty"`
+	Downtime *duration.Duration `protobuf:"bytes,7,opt,name=downtime,proto3" json:"downtime,omitempty"`
+	// availability is the percentage of the observed time the satellite was reachable
+	Availability         float64  `protobuf:"fixed64,8,opt,name=availability,proto3" json:"availability,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SatelliteReachability) Reset()         { *m = SatelliteReachability{} }
+func (m *SatelliteReachability) String() string { return proto.CompactTextString(m) }
+func (*SatelliteReachability) ProtoMessage()    {}
+func (*SatelliteReachability) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{54}
+}
+func (m *SatelliteReachability) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SatelliteReachability.Unmarshal(m, b)
+}
+func (m *SatelliteReachability) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SatelliteReachability.Marshal(b, m, deterministic)
+}
+func (m *SatelliteReachability) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SatelliteReachability.Merge(m, src)
+}
+func (m *SatelliteReachability) XXX_Size() int {
+	return xxx_messageInfo_SatelliteReachability.Size(m)
+}
+func (m *SatelliteReachability) XXX_DiscardUnknown() {
+	xxx_messageInfo_SatelliteReachability.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SatelliteReachability proto.InternalMessageInfo
+
+func (m *SatelliteReachability) GetDay() *timestamp.Timestamp {
+	if m != nil {
+		return m.Day
+	}
+	return nil
+}
+
+func (m *SatelliteReachability) GetAttempts() int64 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *SatelliteReachability) GetSuccesses() int64 {
+	if m != nil {
+		return m.Successes
+	}
+	return 0
+}
+
+func (m *SatelliteReachability) GetDowntimeWindows() int64 {
+	if m != nil {
+		return m.DowntimeWindows
+	}
+	return 0
+}
+
+func (m *SatelliteReachability) GetObserved() *duration.Duration {
+	if m != nil {
+		return m.Observed
+	}
+	return nil
+}
+
+func (m *SatelliteReachability) GetDowntime() *duration.Duration {
+	if m != nil {
+		return m.Downtime
+	}
+	return nil
+}
+
+func (m *SatelliteReachability) GetAvailability() float64 {
+	if m != nil {
+		return m.Availability
+	}
+	return 0
+}
+
+type LabelBandwidth struct {
+	Label                string   `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Ingress              int64    `protobuf:"varint,2,opt,name=ingress,proto3" json:"ingress,omitempty"`
+	Egress               int64    `protobuf:"varint,3,opt,name=egress,proto3" json:"egress,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LabelBandwidth) Reset()         { *m = LabelBandwidth{} }
+func (m *LabelBandwidth) String() string { return proto.CompactTextString(m) }
+func (*LabelBandwidth) ProtoMessage()    {}
+func (*LabelBandwidth) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{55}
+}
+func (m *LabelBandwidth) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LabelBandwidth.Unmarshal(m, b)
+}
+func (m *LabelBandwidth) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LabelBandwidth.Marshal(b, m, deterministic)
+}
+func (m *LabelBandwidth) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelBandwidth.Merge(m, src)
+}
+func (m *LabelBandwidth) XXX_Size() int {
+	return xxx_messageInfo_LabelBandwidth.Size(m)
+}
+func (m *LabelBandwidth) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelBandwidth.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelBandwidth proto.InternalMessageInfo
+
+func (m *LabelBandwidth) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *LabelBandwidth) GetIngress() int64 {
+	if m != nil {
+		return m.Ingress
+	}
+	return 0
+}
+
+func (m *LabelBandwidth) GetEgress() int64 {
+	if m != nil {
+		return m.Egress
+	}
+	return 0
+}
+
+type VersionStatus struct {
+	Current   string `protobuf:"bytes,1,opt,name=current,proto3" json:"current,omitempty"`
+	Suggested string `protobuf:"bytes,2,opt,name=suggested,proto3" json:"suggested,omitempty"`
+	Outdated  bool   `protobuf:"varint,3,opt,name=outdated,proto3" json:"outdated,omitempty"`
+	// unknown is whether it is unknown if the node is outdated: it is a
+	// development build or no version server responded yet
+	Unknown              bool     `protobuf:"varint,4,opt,name=unknown,proto3" json:"unknown,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VersionStatus) Reset()         { *m = VersionStatus{} }
+func (m *VersionStatus) String() string { return proto.CompactTextString(m) }
+func (*VersionStatus) ProtoMessage()    {}
+func (*VersionStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{56}
+}
+func (m *VersionStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VersionStatus.Unmarshal(m, b)
+}
+func (m *VersionStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VersionStatus.Marshal(b, m, deterministic)
+}
+func (m *VersionStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VersionStatus.Merge(m, src)
+}
+func (m *VersionStatus) XXX_Size() int {
+	return xxx_messageInfo_VersionStatus.Size(m)
+}
+func (m *VersionStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_VersionStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VersionStatus proto.InternalMessageInfo
+
+func (m *VersionStatus) GetCurrent() string {
+	if m != nil {
+		return m.Current
+	}
+	return ""
+}
+
+func (m *VersionStatus) GetSuggested() string {
+	if m != nil {
+		return m.Suggested
+	}
+	return ""
+}
+
+func (m *VersionStatus) GetOutdated() bool {
+	if m != nil {
+		return m.Outdated
+	}
+	return false
+}
+
+func (m *VersionStatus) GetUnknown() bool {
+	if m != nil {
+		return m.Unknown
+	}
+	return false
+}
+
+type SegmentHealthRequest struct {
+	Bucket               []byte   `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	EncryptedPath        []byte   `protobuf:"bytes,2,opt,name=encrypted_path,json=encryptedPath,proto3" json:"encrypted_path,omitempty"`
+	SegmentIndex         int64    `protobuf:"varint,3,opt,name=segment_index,json=segmentIndex,proto3" json:"segment_index,omitempty"`
+	ProjectId            []byte   `protobuf:"bytes,4,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SegmentHealthRequest) Reset()         { *m = SegmentHealthRequest{} }
+func (m *SegmentHealthRequest) String() string { return proto.CompactTextString(m) }
+func (*SegmentHealthRequest) ProtoMessage()    {}
+func (*SegmentHealthRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a07d9034b2dd9d26, []int{57}
+}
+func (m *SegmentHealthRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SegmentHealthRequest.Unmarshal(m, b)
+}
+func (m *SegmentHealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SegmentHealthRequest.Marshal(b, m, deterministic)
+}
+func (m *SegmentHealthRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SegmentHealthRequest.Merge(m, src)
+}
+func (m *SegmentHealthRequest) XXX_Size() int {
+	return xxx_messageInfo_SegmentHealthRequest.Size(m)
+}
+func (m *SegmentHealthRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SegmentHealthRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SegmentHealthRequest proto.InternalMessageInfo
+
+func (m *SegmentHealthRequest) GetBucket() []byte {
+	if m != nil {
+		return m.Bucket
+	}
+	return nil
+}
 
 func (m *SegmentHealthRequest) GetEncryptedPath() []byte {
 	if m != nil {
@@ -1493,7 +3039,7 @@ func (m *SegmentHealth) Reset()         { *m = SegmentHealth{} }
 func (m *SegmentHealth) String() string { return proto.CompactTextString(m) }
 func (*SegmentHealth) ProtoMessage()    {}
 func (*SegmentHealth) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a07d9034b2dd9d26, []int{32}
+	return fileDescriptor_a07d9034b2dd9d26, []int{58}
 }
 func (m *SegmentHealth) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SegmentHealth.Unmarshal(m, b)
@@ -1539,7 +3085,7 @@ func (m *SegmentHealthResponse) Reset()         { *m = SegmentHealthResponse{} }
 func (m *SegmentHealthResponse) String() string { return proto.CompactTextString(m) }
 func (*SegmentHealthResponse) ProtoMessage()    {}
 func (*SegmentHealthResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a07d9034b2dd9d26, []int{33}
+	return fileDescriptor_a07d9034b2dd9d26, []int{59}
 }
 func (m *SegmentHealthResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SegmentHealthResponse.Unmarshal(m, b)
@@ -1589,7 +3135,7 @@ func (m *ObjectHealthRequest) Reset()         { *m = ObjectHealthRequest{} }
 func (m *ObjectHealthRequest) String() string { return proto.CompactTextString(m) }
 func (*ObjectHealthRequest) ProtoMessage()    {}
 func (*ObjectHealthRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a07d9034b2dd9d26, []int{34}
+	return fileDescriptor_a07d9034b2dd9d26, []int{60}
 }
 func (m *ObjectHealthRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ObjectHealthRequest.Unmarshal(m, b)
@@ -1663,7 +3209,7 @@ func (m *ObjectHealthResponse) Reset()         { *m = ObjectHealthResponse{} }
 func (m *ObjectHealthResponse) String() string { return proto.CompactTextString(m) }
 func (*ObjectHealthResponse) ProtoMessage()    {}
 func (*ObjectHealthResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a07d9034b2dd9d26, []int{35}
+	return fileDescriptor_a07d9034b2dd9d26, []int{61}
 }
 func (m *ObjectHealthResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ObjectHealthResponse.Unmarshal(m, b)
@@ -1710,6 +3256,7 @@ func init() {
 	proto.RegisterType((*GetBucketListRequest)(nil), "inspector.GetBucketListRequest")
 	proto.RegisterType((*GetBucketListResponse)(nil), "inspector.GetBucketListResponse")
 	proto.RegisterType((*GetBucketListResponse_Bucket)(nil), "inspector.GetBucketListResponse.Bucket")
+	proto.RegisterType((*GetBucketListResponse_PinnedNode)(nil), "inspector.GetBucketListResponse.PinnedNode")
 	proto.RegisterType((*GetBucketsRequest)(nil), "inspector.GetBucketsRequest")
 	proto.RegisterType((*GetBucketsResponse)(nil), "inspector.GetBucketsResponse")
 	proto.RegisterType((*GetBucketRequest)(nil), "inspector.GetBucketRequest")
@@ -1726,10 +3273,36 @@ func init() {
 	proto.RegisterType((*FindNearResponse)(nil), "inspector.FindNearResponse")
 	proto.RegisterType((*DumpNodesRequest)(nil), "inspector.DumpNodesRequest")
 	proto.RegisterType((*DumpNodesResponse)(nil), "inspector.DumpNodesResponse")
+	proto.RegisterType((*GetContactHistoryRequest)(nil), "inspector.GetContactHistoryRequest")
+	proto.RegisterType((*GetContactHistoryResponse)(nil), "inspector.GetContactHistoryResponse")
+	proto.RegisterType((*ContactStats)(nil), "inspector.ContactStats")
+	proto.RegisterType((*ContactEvent)(nil), "inspector.ContactEvent")
+	proto.RegisterType((*LookupWithTraceRequest)(nil), "inspector.LookupWithTraceRequest")
+	proto.RegisterType((*LookupWithTraceResponse)(nil), "inspector.LookupWithTraceResponse")
+	proto.RegisterType((*LookupHop)(nil), "inspector.LookupHop")
+	proto.RegisterType((*ReloadConfigRequest)(nil), "inspector.ReloadConfigRequest")
+	proto.RegisterType((*ReloadConfigResponse)(nil), "inspector.ReloadConfigResponse")
+	proto.RegisterType((*NetworkSizeRequest)(nil), "inspector.NetworkSizeRequest")
+	proto.RegisterType((*NetworkSizeResponse)(nil), "inspector.NetworkSizeResponse")
+	proto.RegisterType((*AddressConflictsRequest)(nil), "inspector.AddressConflictsRequest")
+	proto.RegisterType((*AddressConflictsResponse)(nil), "inspector.AddressConflictsResponse")
+	proto.RegisterType((*AddressConflict)(nil), "inspector.AddressConflict")
+	proto.RegisterType((*VerifiedAddress)(nil), "inspector.VerifiedAddress")
+	proto.RegisterType((*QuarantinedNodesRequest)(nil), "inspector.QuarantinedNodesRequest")
+	proto.RegisterType((*QuarantinedNodesResponse)(nil), "inspector.QuarantinedNodesResponse")
+	proto.RegisterType((*QuarantinedNode)(nil), "inspector.QuarantinedNode")
+	proto.RegisterType((*RPCPoliciesRequest)(nil), "inspector.RPCPoliciesRequest")
+	proto.RegisterType((*RPCPoliciesResponse)(nil), "inspector.RPCPoliciesResponse")
+	proto.RegisterType((*RPCPolicy)(nil), "inspector.RPCPolicy")
 	proto.RegisterType((*StatsRequest)(nil), "inspector.StatsRequest")
 	proto.RegisterType((*StatSummaryResponse)(nil), "inspector.StatSummaryResponse")
 	proto.RegisterType((*DashboardRequest)(nil), "inspector.DashboardRequest")
 	proto.RegisterType((*DashboardResponse)(nil), "inspector.DashboardResponse")
+	proto.RegisterType((*ReachabilityRequest)(nil), "inspector.ReachabilityRequest")
+	proto.RegisterType((*ReachabilityResponse)(nil), "inspector.ReachabilityResponse")
+	proto.RegisterType((*SatelliteReachability)(nil), "inspector.SatelliteReachability")
+	proto.RegisterType((*LabelBandwidth)(nil), "inspector.LabelBandwidth")
+	proto.RegisterType((*VersionStatus)(nil), "inspector.VersionStatus")
 	proto.RegisterType((*SegmentHealthRequest)(nil), "inspector.SegmentHealthRequest")
 	proto.RegisterType((*SegmentHealth)(nil), "inspector.SegmentHealth")
 	proto.RegisterType((*SegmentHealthResponse)(nil), "inspector.SegmentHealthResponse")
@@ -1740,119 +3313,211 @@ func init() {
 func init() { proto.RegisterFile("inspector.proto", fileDescriptor_a07d9034b2dd9d26) }
 
 var fileDescriptor_a07d9034b2dd9d26 = []byte{
-	// 1782 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x4b, 0x93, 0x1b, 0x49,
-	0x11, 0x76, 0xeb, 0x35, 0x52, 0x4a, 0xa3, 0x47, 0x49, 0xf6, 0x0a, 0xad, 0x3d, 0x1a, 0x9a, 0x87,
-	0xbd, 0x36, 0xc8, 0x46, 0x98, 0xc3, 0xb2, 0xb1, 0x07, 0x8f, 0xcc, 0xae, 0x15, 0x6b, 0xec, 0xd9,
-	0x1e, 0xc3, 0x81, 0xd8, 0x40, 0x51, 0xea, 0xae, 0xd1, 0x34, 0x96, 0xba, 0x7a, 0xbb, 0x4b, 0x66,
-	0xe7, 0x0f, 0x10, 0x70, 0xe2, 0xc4, 0x01, 0xae, 0xfc, 0x09, 0x82, 0x2b, 0x17, 0x6e, 0xdc, 0x39,
-	0xf8, 0x42, 0x04, 0xdc, 0xb9, 0x71, 0x23, 0x2a, 0xab, 0xfa, 0x2d, 0x31, 0x13, 0xc0, 0xde, 0xd4,
-	0x5f, 0x7e, 0x95, 0x95, 0xf9, 0xd5, 0x23, 0xb3, 0x04, 0x1d, 0xd7, 0x0b, 0x7d, 0x66, 0x0b, 0x1e,
-	0x4c, 0xfc, 0x80, 0x0b, 0x4e, 0x1a, 0x31, 0x30, 0x82, 0x15, 0x5f, 0x71, 0x05, 0x8f, 0xc0, 0xe3,
-	0x0e, 0xd3, 0xbf, 0x3b, 0x3e, 0x77, 0x3d, 0xc1, 0x02, 0x67, 0xa9, 0x81, 0xa3, 0x15, 0xe7, 0xab,
-	0x35, 0x7b, 0x88, 0x5f, 0xcb, 0xed, 0xf9, 0x43, 0x67, 0x1b, 0x50, 0xe1, 0x72, 0x4f, 0xdb, 0xc7,
-	0x79, 0xbb, 0x70, 0x37, 0x2c, 0x14, 0x74, 0xe3, 0x2b, 0x82, 0xf9, 0x02, 0x8e, 0x9e, 0xbb, 0xa1,
-	0x98, 0x07, 0x01, 0xf3, 0x69, 0x40, 0x97, 0x6b, 0x76, 0xc6, 0x56, 0x1b, 0xe6, 0x89, 0xd0, 0x62,
-	0x9f, 0x6f, 0x59, 0x28, 0xc8, 0x00, 0xaa, 0x6b, 0x77, 0xe3, 0x8a, 0xa1, 0x71, 0x6c, 0xdc, 0xab,
-	0x5a, 0xea, 0x83, 0xdc, 0x82, 0x1a, 0x3f, 0x3f, 0x0f, 0x99, 0x18, 0x96, 0x10, 0xd6, 0x5f, 0xe6,
-	0xdf, 0x0d, 0x20, 0x45, 0x67, 0x84, 0x40, 0xc5, 0xa7, 0xe2, 0x02, 0x7d, 0xb4, 0x2c, 0xfc, 0x4d,
-	0xde, 0x87, 0x76, 0xa8, 0xcc, 0x0b, 0x87, 0x09, 0xea, 0xae, 0xd1, 0x55, 0x73, 0x4a, 0x26, 0x49,
-	0x96, 0xa7, 0xea, 0x97, 0x75, 0xa8, 0x99, 0x4f, 0x91, 0x48, 0xc6, 0xd0, 0x5c, 0xf3, 0x50, 0x2c,
-	0x7c, 0x97, 0xd9, 0x2c, 0x1c, 0x96, 0x31, 0x04, 0x90, 0xd0, 0x29, 0x22, 0x64, 0x02, 0xfd, 0x35,
-	0x0d, 0xc5, 0x42, 0x06, 0xe2, 0x06, 0x0b, 0x2a, 0x04, 0xdb, 0xf8, 0x62, 0x58, 0x39, 0x36, 0xee,
-	0x95, 0xad, 0x9e, 0x34, 0x59, 0x68, 0x79, 0xa2, 0x0c, 0xe4, 0x11, 0x0c, 0xb2, 0xd4, 0x85, 0xcd,
-	0xb7, 0x9e, 0x18, 0x56, 0x71, 0x00, 0x09, 0xd2, 0xe4, 0x99, 0xb4, 0x98, 0x9f, 0xc1, 0x78, 0xaf,
-	0x70, 0xa1, 0xcf, 0xbd, 0x90, 0x91, 0xf7, 0xa1, 0xae, 0xc3, 0x0e, 0x87, 0xc6, 0x71, 0xf9, 0x5e,
-	0x73, 0x7a, 0x67, 0x92, 0x2c, 0x7a, 0x71, 0xa4, 0x15, 0xd3, 0xcd, 0xef, 0x43, 0xe7, 0x63, 0x26,
-	0xce, 0x04, 0x4d, 0xd6, 0xe1, 0x2e, 0x1c, 0xc8, 0x9d, 0xb0, 0x70, 0x1d, 0xa5, 0xe2, 0x49, 0xfb,
-	0xcf, 0x6f, 0xc7, 0x37, 0xfe, 0xfa, 0x76, 0x5c, 0x7b, 0xc1, 0x1d, 0x36, 0x7f, 0x6a, 0xd5, 0xa4,
-	0x79, 0xee, 0x98, 0xbf, 0x33, 0xa0, 0x9b, 0x0c, 0xd6, 0xb1, 0x8c, 0xa1, 0x49, 0xb7, 0x8e, 0x1b,
-	0xe5, 0x65, 0x60, 0x5e, 0x80, 0x10, 0xe6, 0x93, 0x10, 0x70, 0xff, 0xe0, 0x52, 0x18, 0x9a, 0x60,
-	0x49, 0x84, 0x7c, 0x15, 0x5a, 0x5b, 0x5f, 0x6e, 0x1f, 0xed, 0xa2, 0x8c, 0x2e, 0x9a, 0x0a, 0x53,
-	0x3e, 0x12, 0x8a, 0x72, 0x52, 0x41, 0x27, 0x9a, 0x82, 0x5e, 0xcc, 0xbf, 0x19, 0x40, 0x66, 0x01,
-	0xa3, 0x82, 0xfd, 0x57, 0xc9, 0xe5, 0xf3, 0x28, 0x15, 0xf2, 0x98, 0x40, 0x5f, 0x11, 0xc2, 0xad,
-	0x6d, 0xb3, 0x30, 0xcc, 0x44, 0xdb, 0x43, 0xd3, 0x99, 0xb2, 0xe4, 0x63, 0x56, 0xc4, 0x4a, 0x31,
-	0xad, 0x47, 0x30, 0xd0, 0x94, 0xac, 0x4f, 0xbd, 0x39, 0x94, 0x2d, 0xed, 0xd4, 0xbc, 0x09, 0xfd,
-	0x4c, 0x92, 0x6a, 0x11, 0xcc, 0xfb, 0x40, 0xd0, 0x2e, 0x73, 0x4a, 0x96, 0x66, 0x00, 0xd5, 0xf4,
-	0xa2, 0xa8, 0x0f, 0xb3, 0x0f, 0xbd, 0x34, 0x17, 0x65, 0x32, 0x6f, 0xc1, 0xe0, 0x63, 0x26, 0x4e,
-	0xb6, 0xf6, 0x6b, 0x26, 0xe4, 0xee, 0x8b, 0xf0, 0x7f, 0x1a, 0x70, 0x33, 0x67, 0xd0, 0xce, 0x9f,
-	0xc0, 0xc1, 0x12, 0xd1, 0x68, 0x0b, 0xde, 0x4d, 0x6d, 0xc1, 0x9d, 0x43, 0x26, 0x0a, 0xb2, 0xa2,
-	0x71, 0xa3, 0xdf, 0x18, 0x50, 0x53, 0x18, 0x79, 0x00, 0x0d, 0x85, 0xee, 0x5f, 0xa8, 0xba, 0x22,
-	0xcc, 0x1d, 0xf2, 0x10, 0x0e, 0x03, 0xbe, 0x15, 0xae, 0xb7, 0x5a, 0xc8, 0xc5, 0x0b, 0x87, 0x25,
-	0x0c, 0x00, 0x26, 0x78, 0xa1, 0x49, 0xba, 0xd5, 0xd2, 0x04, 0x4c, 0x92, 0x7c, 0x1b, 0x5a, 0x36,
-	0xb5, 0x2f, 0x98, 0xa3, 0xf9, 0xe5, 0x02, 0xbf, 0xa9, 0xec, 0x48, 0x97, 0x0a, 0xc5, 0x09, 0xc4,
-	0x0a, 0x3d, 0x03, 0x92, 0x06, 0x13, 0x89, 0x05, 0x17, 0x74, 0x1d, 0x49, 0x8c, 0x1f, 0xe4, 0x36,
-	0x94, 0x5d, 0x47, 0x85, 0xd5, 0x3a, 0x81, 0x54, 0x0e, 0x12, 0x36, 0xa7, 0x78, 0x8a, 0xb4, 0x18,
-	0x7a, 0x9b, 0x1e, 0x41, 0x69, 0x6f, 0xe2, 0x25, 0xd7, 0x31, 0x7f, 0x94, 0x0a, 0x29, 0x9e, 0xfc,
-	0x8a, 0x41, 0xe4, 0x18, 0xaa, 0xfb, 0xf4, 0x51, 0x06, 0xf3, 0x7e, 0xbc, 0x00, 0x57, 0x73, 0x27,
-	0x00, 0xc9, 0x9a, 0x26, 0x7c, 0x63, 0x1f, 0xff, 0x13, 0xe8, 0x9c, 0xea, 0x15, 0xb8, 0x66, 0x96,
-	0x64, 0x08, 0x07, 0xd4, 0x71, 0x02, 0x16, 0x86, 0x78, 0xfe, 0x1a, 0x56, 0xf4, 0x69, 0x9a, 0xd0,
-	0x4d, 0x9c, 0xe9, 0xf4, 0xdb, 0x50, 0xe2, 0xaf, 0xd1, 0x5b, 0xdd, 0x2a, 0xf1, 0xd7, 0xe6, 0x87,
-	0xd0, 0x7b, 0xce, 0xf9, 0xeb, 0xad, 0x9f, 0x9e, 0xb2, 0x1d, 0x4f, 0xd9, 0xb8, 0x62, 0x8a, 0xcf,
-	0x80, 0xa4, 0x87, 0xc7, 0x1a, 0x57, 0x64, 0x3a, 0xe8, 0x21, 0x9b, 0x26, 0xe2, 0xe4, 0x9b, 0x50,
-	0xd9, 0x30, 0x41, 0xe3, 0x0a, 0x13, 0xdb, 0x7f, 0xc8, 0x04, 0x75, 0xa8, 0xa0, 0x16, 0xda, 0xcd,
-	0x9f, 0x42, 0x07, 0x13, 0xf5, 0xce, 0xf9, 0x75, 0xd5, 0x78, 0x90, 0x0d, 0xb5, 0x39, 0xed, 0x25,
-	0xde, 0x9f, 0x28, 0x43, 0x12, 0xfd, 0x9f, 0x0c, 0xe8, 0x26, 0x13, 0xe8, 0xe0, 0x4d, 0xa8, 0x88,
-	0x4b, 0x5f, 0x05, 0xdf, 0x9e, 0xb6, 0x93, 0xe1, 0xaf, 0x2e, 0x7d, 0x66, 0xa1, 0x8d, 0x4c, 0xa0,
-	0xce, 0x7d, 0x16, 0x50, 0xc1, 0x83, 0x62, 0x12, 0x2f, 0xb5, 0xc5, 0x8a, 0x39, 0x92, 0x6f, 0x53,
-	0x9f, 0xda, 0xae, 0xb8, 0xc4, 0xbb, 0x2f, 0xc3, 0x9f, 0x69, 0x8b, 0x15, 0x73, 0x64, 0x16, 0x6f,
-	0x58, 0x10, 0xba, 0xdc, 0xc3, 0x1b, 0x30, 0x93, 0xc5, 0x8f, 0x95, 0xc1, 0x8a, 0x18, 0xe6, 0x06,
-	0x3a, 0x1f, 0xb9, 0x9e, 0xf3, 0x82, 0xd1, 0xe0, 0xba, 0x2a, 0x7d, 0x1d, 0xaa, 0xa1, 0xa0, 0x81,
-	0xba, 0xb1, 0x8b, 0x14, 0x65, 0x4c, 0x7a, 0x0d, 0x75, 0x5d, 0xab, 0x0f, 0xf3, 0x31, 0x74, 0x93,
-	0xe9, 0xb4, 0x66, 0x57, 0x1f, 0x04, 0x02, 0xdd, 0xa7, 0xdb, 0x8d, 0x9f, 0xb9, 0x3f, 0xbf, 0x07,
-	0xbd, 0x14, 0x96, 0x77, 0xb5, 0xf7, 0x8c, 0xb4, 0xa1, 0x95, 0xae, 0x56, 0xe6, 0xbf, 0x0c, 0xe8,
-	0x4b, 0xe0, 0x6c, 0xbb, 0xd9, 0xd0, 0xe0, 0x32, 0xf6, 0x74, 0x07, 0x60, 0x1b, 0x32, 0x67, 0x11,
-	0xfa, 0xd4, 0x66, 0xfa, 0xae, 0x69, 0x48, 0xe4, 0x4c, 0x02, 0xe4, 0x2e, 0x74, 0xe8, 0x1b, 0xea,
-	0xae, 0x65, 0xc9, 0xd7, 0x1c, 0x55, 0xbf, 0xda, 0x31, 0xac, 0x88, 0xb2, 0x26, 0x49, 0x3f, 0xae,
-	0xb7, 0xc2, 0x7d, 0x15, 0x95, 0xda, 0x90, 0x39, 0x73, 0x05, 0xc9, 0x3a, 0x88, 0x14, 0xa6, 0x18,
-	0xaa, 0x6a, 0xe1, 0xec, 0x3f, 0x50, 0x84, 0x6f, 0x40, 0x1b, 0x09, 0x4b, 0xea, 0x39, 0x3f, 0x77,
-	0x1d, 0x71, 0xa1, 0xcb, 0xd5, 0xa1, 0x44, 0x4f, 0x22, 0x90, 0x3c, 0x84, 0x7e, 0x12, 0x53, 0xc2,
-	0xad, 0xa9, 0xd2, 0x16, 0x9b, 0xe2, 0x01, 0x28, 0x2b, 0x0d, 0x2f, 0x96, 0x9c, 0x06, 0x4e, 0xa4,
-	0xc7, 0x5f, 0xca, 0xd0, 0x4b, 0x81, 0x5a, 0x8d, 0x6b, 0xd7, 0xf4, 0xf7, 0xa0, 0x8b, 0x44, 0x9b,
-	0x7b, 0x1e, 0xb3, 0x65, 0xf7, 0x1a, 0x6a, 0x61, 0x3a, 0x12, 0x9f, 0x25, 0x30, 0x79, 0x00, 0xbd,
-	0x25, 0xe7, 0x22, 0x14, 0x01, 0xf5, 0x17, 0xd1, 0xb1, 0x2b, 0xe3, 0x0d, 0xd1, 0x8d, 0x0d, 0xfa,
-	0xd4, 0x49, 0xbf, 0xd8, 0x3d, 0x7a, 0x74, 0x1d, 0x73, 0x2b, 0xc8, 0xed, 0x44, 0x78, 0x8a, 0xca,
-	0xbe, 0xc8, 0x51, 0xab, 0x8a, 0x1a, 0xe1, 0x11, 0xf5, 0x31, 0xee, 0x64, 0x11, 0xa2, 0x46, 0xcd,
-	0xe9, 0x51, 0xaa, 0x9e, 0xee, 0xd8, 0x13, 0x96, 0x22, 0x93, 0xef, 0x40, 0x4d, 0xf5, 0x09, 0xc3,
-	0x03, 0x1c, 0xf6, 0x95, 0x89, 0xea, 0xcc, 0x27, 0x51, 0x67, 0x3e, 0x79, 0xaa, 0x3b, 0x77, 0x4b,
-	0x13, 0xc9, 0x07, 0xd0, 0xc4, 0x1e, 0xd6, 0x77, 0xbd, 0x15, 0x73, 0x86, 0x75, 0x1c, 0x37, 0x2a,
-	0x8c, 0x7b, 0x15, 0x75, 0xf4, 0x16, 0x48, 0xfa, 0x29, 0xb2, 0xc9, 0x87, 0xd0, 0xc2, 0xc1, 0x9f,
-	0x6f, 0x59, 0xe0, 0x32, 0x67, 0xd8, 0xb8, 0x72, 0x34, 0x4e, 0xf6, 0xa9, 0xa2, 0x9b, 0xbf, 0x35,
-	0x60, 0xa0, 0xbb, 0xd2, 0x67, 0x8c, 0xae, 0xc5, 0x45, 0x74, 0xce, 0x6f, 0x41, 0x4d, 0x15, 0x78,
-	0xdd, 0xca, 0xeb, 0x2f, 0xb9, 0xdd, 0x98, 0x67, 0x07, 0x97, 0xbe, 0x60, 0xce, 0x02, 0x5b, 0x7d,
-	0x3c, 0xe8, 0xd6, 0x61, 0x8c, 0x9e, 0xca, 0x9e, 0xff, 0x6b, 0x10, 0x75, 0xf2, 0x0b, 0xd7, 0x73,
-	0xd8, 0x17, 0x7a, 0x6b, 0xb7, 0x34, 0x38, 0x97, 0x98, 0x3c, 0x46, 0x7e, 0xc0, 0x7f, 0xc6, 0x6c,
-	0x6c, 0x33, 0x2a, 0xe8, 0xa7, 0xa1, 0x91, 0xb9, 0x63, 0x3e, 0x87, 0xc3, 0x4c, 0x68, 0xf2, 0xb8,
-	0x70, 0x6f, 0xed, 0x7a, 0x6c, 0x11, 0x9d, 0x63, 0xf9, 0x1c, 0x68, 0x2a, 0x4c, 0xb5, 0x16, 0x43,
-	0x38, 0xd0, 0x53, 0xe8, 0xb8, 0xa2, 0x4f, 0xf3, 0x17, 0x06, 0xdc, 0xcc, 0x65, 0xaa, 0xf7, 0xef,
-	0x23, 0xa8, 0x5d, 0x20, 0xa2, 0xab, 0xca, 0x30, 0xbd, 0xd2, 0x99, 0x11, 0x9a, 0x47, 0x3e, 0x00,
-	0x08, 0x98, 0xb3, 0xf5, 0x1c, 0xea, 0xd9, 0x97, 0xfa, 0x9a, 0x7e, 0x37, 0xf5, 0x9a, 0xb1, 0x62,
-	0xe3, 0x99, 0x7d, 0xc1, 0x36, 0xcc, 0x4a, 0xd1, 0xcd, 0x7f, 0x18, 0xd0, 0x7f, 0xb9, 0x94, 0x39,
-	0x66, 0x15, 0x2f, 0x2a, 0x6b, 0xec, 0x52, 0x36, 0x59, 0x98, 0x52, 0x66, 0x61, 0xb2, 0x62, 0x96,
-	0x73, 0x62, 0xca, 0x76, 0x19, 0xaf, 0xde, 0x05, 0x3d, 0x17, 0x2c, 0x58, 0x44, 0x22, 0xe9, 0x87,
-	0x12, 0x9a, 0x9e, 0x48, 0x4b, 0xf4, 0x90, 0xfb, 0x16, 0x10, 0xe6, 0x39, 0x8b, 0x25, 0x3b, 0xe7,
-	0x01, 0x8b, 0xe9, 0xea, 0x6a, 0xe9, 0x32, 0xcf, 0x39, 0x41, 0x43, 0xc4, 0x8e, 0xef, 0xf3, 0x5a,
-	0xea, 0xed, 0x68, 0xfe, 0xca, 0x80, 0x41, 0x36, 0x53, 0xad, 0xf8, 0xe3, 0xc2, 0x83, 0x69, 0xbf,
-	0xe6, 0x31, 0xf3, 0x7f, 0x52, 0x7d, 0xfa, 0xeb, 0x0a, 0xb4, 0x3e, 0xa1, 0xce, 0x3c, 0x9a, 0x85,
-	0xcc, 0x01, 0x92, 0xbe, 0x9b, 0xdc, 0x4e, 0xcd, 0x5f, 0x68, 0xc7, 0x47, 0x77, 0xf6, 0x58, 0x75,
-	0x3a, 0x33, 0xa8, 0x47, 0xdd, 0x10, 0x19, 0xa5, 0xa8, 0xb9, 0x7e, 0x6b, 0xf4, 0xee, 0x4e, 0x9b,
-	0x76, 0x32, 0x07, 0x48, 0xfa, 0x9d, 0x4c, 0x3c, 0x85, 0x2e, 0x2a, 0x13, 0xcf, 0x8e, 0x26, 0x69,
-	0x06, 0xf5, 0xa8, 0xf7, 0xc8, 0xc4, 0x93, 0xeb, 0x78, 0x32, 0xf1, 0x14, 0x9a, 0x95, 0x19, 0xd4,
-	0xa3, 0x62, 0x9c, 0x71, 0x92, 0x6b, 0x08, 0x32, 0x4e, 0x0a, 0xd5, 0xfb, 0x23, 0x68, 0xc4, 0x75,
-	0x98, 0xa4, 0x99, 0xf9, 0x8a, 0x3d, 0xba, 0xbd, 0xdb, 0xa8, 0xfd, 0x58, 0x70, 0x98, 0x79, 0xc3,
-	0x90, 0xf1, 0xfe, 0xd7, 0x8d, 0xf2, 0x77, 0x7c, 0xd5, 0xf3, 0x67, 0xfa, 0x87, 0x12, 0x74, 0x5f,
-	0xbe, 0x61, 0xc1, 0x9a, 0x5e, 0x7e, 0x29, 0xbb, 0xe2, 0xff, 0x95, 0xfb, 0x0c, 0xea, 0xd1, 0x2b,
-	0x3f, 0xb3, 0x10, 0xb9, 0xff, 0x0d, 0x32, 0x0b, 0x51, 0xf8, 0x5b, 0xe0, 0x39, 0x34, 0x53, 0x0f,
-	0x55, 0x92, 0x09, 0xbd, 0xf0, 0x4a, 0x1f, 0x1d, 0xed, 0x33, 0x6b, 0xe9, 0x7e, 0x6f, 0x40, 0x1f,
-	0xff, 0x80, 0x39, 0x13, 0x3c, 0x60, 0x89, 0x7a, 0x27, 0x50, 0x55, 0xfe, 0xdf, 0xc9, 0x15, 0xcb,
-	0x9d, 0x9e, 0x77, 0x54, 0x51, 0xf3, 0x06, 0x79, 0x06, 0x8d, 0xb8, 0xc5, 0xc8, 0xca, 0x96, 0xeb,
-	0x46, 0xb2, 0xb2, 0xe5, 0xbb, 0x12, 0xf3, 0xc6, 0xf4, 0x97, 0x06, 0x0c, 0x52, 0x7f, 0xbe, 0x24,
-	0x61, 0xfa, 0xf0, 0xce, 0x9e, 0xbf, 0x74, 0xc8, 0x7b, 0xe9, 0x93, 0xf5, 0x1f, 0xff, 0x2f, 0x1b,
-	0xdd, 0xbf, 0x0e, 0x55, 0x0b, 0xf6, 0x47, 0x03, 0x3a, 0xea, 0x3e, 0x4b, 0xa2, 0xf8, 0x14, 0x5a,
-	0xe9, 0xcb, 0x91, 0xa4, 0xa5, 0xd9, 0x51, 0x1f, 0x46, 0xe3, 0xbd, 0xf6, 0x58, 0xbb, 0x57, 0xf9,
-	0x8a, 0x39, 0xde, 0x7b, 0xad, 0xee, 0x38, 0x26, 0x3b, 0xab, 0xa3, 0x79, 0xe3, 0xa4, 0xf2, 0x93,
-	0x92, 0xbf, 0x5c, 0xd6, 0xb0, 0x95, 0xf8, 0xee, 0xbf, 0x03, 0x00, 0x00, 0xff, 0xff, 0x8f, 0x28,
-	0x82, 0xe5, 0xcf, 0x14, 0x00, 0x00,
+	// 3262 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x5a, 0xcf, 0x6f, 0x1b, 0xc7,
+	0xf5, 0xff, 0x2e, 0x49, 0x51, 0xe4, 0x13, 0x25, 0x52, 0x23, 0xd9, 0x5e, 0xd3, 0xb6, 0xe4, 0xac,
+	0xf3, 0x4d, 0xec, 0x38, 0x91, 0x1d, 0xc5, 0x45, 0xf3, 0x03, 0x41, 0x62, 0xcb, 0x89, 0x2d, 0xc4,
+	0xb5, 0x95, 0x95, 0xf3, 0xa3, 0x6d, 0x10, 0x62, 0xb4, 0x3b, 0x22, 0xb7, 0x5a, 0xee, 0x6c, 0x66,
+	0x87, 0x92, 0x15, 0xa0, 0xc7, 0x16, 0x2d, 0x7a, 0x2b, 0xda, 0x43, 0x8f, 0x45, 0x6f, 0x05, 0x0a,
+	0xf4, 0x54, 0xf4, 0xd2, 0x1e, 0xda, 0x4b, 0xfb, 0x2f, 0xf4, 0x90, 0x4b, 0x8b, 0x16, 0xe8, 0xa5,
+	0xb9, 0xf6, 0x56, 0xcc, 0xaf, 0xfd, 0x45, 0x52, 0x54, 0xd2, 0xf4, 0xc6, 0x79, 0xef, 0x33, 0x6f,
+	0xdf, 0xaf, 0x79, 0xf3, 0x66, 0x86, 0xd0, 0x0e, 0xa2, 0x24, 0x26, 0x1e, 0xa7, 0x6c, 0x23, 0x66,
+	0x94, 0x53, 0xd4, 0x4c, 0x09, 0x5d, 0xe8, 0xd3, 0x3e, 0x55, 0xe4, 0x2e, 0x44, 0xd4, 0x27, 0xfa,
+	0x77, 0x3b, 0xa6, 0x41, 0xc4, 0x09, 0xf3, 0xf7, 0x34, 0x61, 0xad, 0x4f, 0x69, 0x3f, 0x24, 0x37,
+	0xe4, 0x68, 0x6f, 0xb4, 0x7f, 0xc3, 0x1f, 0x31, 0xcc, 0x03, 0x1a, 0x69, 0xfe, 0x7a, 0x99, 0xcf,
+	0x83, 0x21, 0x49, 0x38, 0x1e, 0xc6, 0x0a, 0xe0, 0x3c, 0x84, 0xb5, 0x07, 0x41, 0xc2, 0xb7, 0x19,
+	0x23, 0x31, 0x66, 0x78, 0x2f, 0x24, 0xbb, 0xa4, 0x3f, 0x24, 0x11, 0x4f, 0x5c, 0xf2, 0xc9, 0x88,
+	0x24, 0x1c, 0xad, 0xc2, 0x5c, 0x18, 0x0c, 0x03, 0x6e, 0x5b, 0x97, 0xad, 0xab, 0x73, 0xae, 0x1a,
+	0xa0, 0xb3, 0x50, 0xa7, 0xfb, 0xfb, 0x09, 0xe1, 0x76, 0x45, 0x92, 0xf5, 0xc8, 0xf9, 0xbb, 0x05,
+	0x68, 0x5c, 0x18, 0x42, 0x50, 0x8b, 0x31, 0x1f, 0x48, 0x19, 0x2d, 0x57, 0xfe, 0x46, 0xaf, 0xc0,
+	0x52, 0xa2, 0xd8, 0x3d, 0x9f, 0x70, 0x1c, 0x84, 0x52, 0xd4, 0xc2, 0x26, 0xda, 0xc8, 0xac, 0xdc,
+	0x51, 0xbf, 0xdc, 0x45, 0x8d, 0xbc, 0x2b, 0x81, 0x68, 0x1d, 0x16, 0x42, 0x9a, 0xf0, 0x5e, 0x1c,
+	0x10, 0x8f, 0x24, 0x76, 0x55, 0xaa, 0x00, 0x82, 0xb4, 0x23, 0x29, 0x68, 0x03, 0x56, 0x42, 0x9c,
+	0xf0, 0x9e, 0x50, 0x24, 0x60, 0x3d, 0xcc, 0x39, 0x19, 0xc6, 0xdc, 0xae, 0x5d, 0xb6, 0xae, 0x56,
+	0xdd, 0x65, 0xc1, 0x72, 0x25, 0xe7, 0xb6, 0x62, 0xa0, 0x9b, 0xb0, 0x5a, 0x84, 0xf6, 0x3c, 0x3a,
+	0x8a, 0xb8, 0x3d, 0x27, 0x27, 0x20, 0x96, 0x07, 0x6f, 0x09, 0x8e, 0xf3, 0x11, 0xac, 0x4f, 0x75,
+	0x5c, 0x12, 0xd3, 0x28, 0x21, 0xe8, 0x15, 0x68, 0x68, 0xb5, 0x13, 0xdb, 0xba, 0x5c, 0xbd, 0xba,
+	0xb0, 0x79, 0x69, 0x23, 0x0b, 0xfa, 0xf8, 0x4c, 0x37, 0x85, 0x3b, 0xaf, 0x42, 0xfb, 0x1e, 0xe1,
+	0xbb, 0x1c, 0x67, 0x71, 0x78, 0x16, 0xe6, 0x45, 0x26, 0xf4, 0x02, 0x5f, 0x79, 0xf1, 0xce, 0xd2,
+	0x9f, 0x3e, 0x5b, 0xff, 0xbf, 0xbf, 0x7c, 0xb6, 0x5e, 0x7f, 0x48, 0x7d, 0xb2, 0x7d, 0xd7, 0xad,
+	0x0b, 0xf6, 0xb6, 0xef, 0xfc, 0xde, 0x82, 0x4e, 0x36, 0x59, 0xeb, 0xb2, 0x0e, 0x0b, 0x78, 0xe4,
+	0x07, 0xc6, 0x2e, 0x4b, 0xda, 0x05, 0x92, 0x24, 0xed, 0xc9, 0x00, 0x32, 0x7f, 0x64, 0x28, 0x2c,
+	0x0d, 0x70, 0x05, 0x05, 0x3d, 0x05, 0xad, 0x51, 0x2c, 0xd2, 0x47, 0x8b, 0xa8, 0x4a, 0x11, 0x0b,
+	0x8a, 0xa6, 0x64, 0x64, 0x10, 0x25, 0xa4, 0x26, 0x85, 0x68, 0x88, 0x92, 0xe2, 0x40, 0x8b, 0x11,
+	0xec, 0x0d, 0xf0, 0x5e, 0x10, 0x06, 0xfc, 0x58, 0x3a, 0xd8, 0x72, 0x0b, 0x34, 0xe7, 0xaf, 0x16,
+	0xa0, 0x2d, 0x46, 0x30, 0x27, 0x5f, 0xca, 0x01, 0x65, 0x5b, 0x2b, 0x63, 0xb6, 0x6e, 0xc0, 0x8a,
+	0x02, 0x24, 0x23, 0xcf, 0x23, 0x49, 0x52, 0xb0, 0x68, 0x59, 0xb2, 0x76, 0x15, 0xa7, 0x6c, 0x97,
+	0x02, 0xd6, 0xc6, 0x4d, 0xbf, 0x09, 0xab, 0x1a, 0x52, 0x94, 0xa9, 0x13, 0x48, 0xf1, 0xf2, 0x42,
+	0x9d, 0x33, 0xb0, 0x52, 0x30, 0x52, 0x05, 0xca, 0x79, 0x0e, 0x90, 0xe4, 0x0b, 0x9b, 0xb2, 0xf0,
+	0xad, 0xc2, 0x5c, 0x3e, 0x70, 0x6a, 0xe0, 0xac, 0xc0, 0x72, 0x1e, 0x2b, 0xdd, 0xe4, 0x9c, 0x85,
+	0xd5, 0x7b, 0x84, 0xdf, 0x19, 0x79, 0x07, 0x84, 0x8b, 0x0c, 0x35, 0xf4, 0x9f, 0x54, 0xe1, 0x4c,
+	0x89, 0xa1, 0x85, 0xdf, 0x86, 0xf9, 0x3d, 0x49, 0x35, 0x69, 0xfa, 0x6c, 0x2e, 0x4d, 0x27, 0x4e,
+	0xd9, 0x50, 0x24, 0xd7, 0xcc, 0x43, 0x0f, 0xa1, 0x15, 0x07, 0x51, 0x44, 0xfc, 0x9e, 0x88, 0x41,
+	0x62, 0x57, 0xa4, 0x9c, 0xeb, 0x33, 0xe5, 0xec, 0xc8, 0x49, 0x42, 0x7f, 0x77, 0x21, 0x4e, 0x7f,
+	0x27, 0xdd, 0x9f, 0x5a, 0x50, 0x57, 0x70, 0x74, 0x1d, 0x9a, 0xea, 0x2b, 0xd3, 0x03, 0xdf, 0x50,
+	0x80, 0x6d, 0x1f, 0xdd, 0x80, 0x45, 0x46, 0x47, 0x3c, 0x88, 0xfa, 0x05, 0x45, 0x60, 0x43, 0x8c,
+	0x36, 0xe4, 0x77, 0x5a, 0x1a, 0x20, 0x3f, 0x84, 0x5e, 0x80, 0x96, 0x87, 0xbd, 0x41, 0xaa, 0x78,
+	0x75, 0x0c, 0xbf, 0xa0, 0xf8, 0x4a, 0xaf, 0x1d, 0x80, 0x4c, 0x65, 0xb4, 0x06, 0x35, 0x81, 0x93,
+	0x5a, 0x15, 0x27, 0x49, 0x3a, 0x72, 0xa0, 0xc6, 0x8f, 0x63, 0x22, 0x33, 0x70, 0x69, 0x73, 0x29,
+	0xe3, 0x3f, 0x3e, 0x8e, 0x89, 0x2b, 0x79, 0x22, 0x86, 0xa9, 0x6b, 0xd2, 0x18, 0xde, 0x07, 0x94,
+	0x27, 0x66, 0x49, 0xc0, 0x29, 0xc7, 0xa1, 0x49, 0x02, 0x39, 0x40, 0x17, 0xa1, 0x1a, 0xf8, 0xca,
+	0xd0, 0xd6, 0x1d, 0xc8, 0x79, 0x45, 0x90, 0x9d, 0x4d, 0xe8, 0xa4, 0x92, 0xcc, 0x42, 0x5a, 0x83,
+	0xca, 0x54, 0x57, 0x56, 0x02, 0xdf, 0x79, 0x2f, 0xa7, 0x52, 0xfa, 0xf1, 0x19, 0x93, 0xd0, 0x65,
+	0x98, 0x9b, 0xe6, 0x71, 0xc5, 0x70, 0x9e, 0x4b, 0x43, 0x3a, 0x1b, 0xbb, 0x01, 0x90, 0x65, 0x4b,
+	0x86, 0xb7, 0xa6, 0xe1, 0xdf, 0x81, 0xf6, 0x8e, 0x8e, 0xe9, 0x29, 0xad, 0x44, 0x36, 0xcc, 0x63,
+	0xdf, 0x67, 0x24, 0x49, 0x64, 0x7c, 0x9a, 0xae, 0x19, 0x3a, 0x0e, 0x74, 0x32, 0x61, 0xda, 0xfc,
+	0x25, 0xa8, 0xd0, 0x03, 0x29, 0xad, 0xe1, 0x56, 0xe8, 0x81, 0xf3, 0x3a, 0x2c, 0x3f, 0xa0, 0xf4,
+	0x60, 0x14, 0xe7, 0x3f, 0xb9, 0x94, 0x7e, 0xb2, 0x39, 0xe3, 0x13, 0x1f, 0x01, 0xca, 0x4f, 0x4f,
+	0x7d, 0x7c, 0x72, 0x3e, 0x3d, 0x03, 0xb5, 0x21, 0xe1, 0x38, 0xdd, 0x27, 0x53, 0xfe, 0x37, 0x08,
+	0xc7, 0x3e, 0xe6, 0xd8, 0x95, 0x7c, 0xe7, 0x63, 0x68, 0x4b, 0x43, 0xa3, 0x7d, 0x7a, 0x5a, 0x6f,
+	0x5c, 0x2f, 0xaa, 0xba, 0xb0, 0xb9, 0x9c, 0x49, 0xbf, 0xad, 0x18, 0x99, 0xf6, 0x7f, 0xb4, 0xa0,
+	0x93, 0x7d, 0x40, 0x2b, 0x6f, 0x92, 0xdd, 0x9a, 0x9e, 0xec, 0x68, 0x03, 0x1a, 0x34, 0x26, 0x0c,
+	0x73, 0xca, 0xc6, 0x8d, 0x78, 0xa4, 0x39, 0x6e, 0x8a, 0x11, 0x78, 0x0f, 0xc7, 0xd8, 0x13, 0x3b,
+	0x45, 0xb5, 0x8c, 0xdf, 0xd2, 0x1c, 0x37, 0xc5, 0x08, 0x2b, 0x0e, 0x09, 0x4b, 0x02, 0x1a, 0xd9,
+	0xb5, 0xb2, 0x15, 0xef, 0x2b, 0x86, 0x6b, 0x10, 0xce, 0x10, 0xda, 0x6f, 0x07, 0x91, 0xff, 0x90,
+	0x60, 0x76, 0x5a, 0x2f, 0x3d, 0x0d, 0x73, 0x09, 0xc7, 0x4c, 0xed, 0x29, 0xe3, 0x10, 0xc5, 0xcc,
+	0x3a, 0x26, 0xb5, 0xa1, 0xa8, 0x81, 0x73, 0x0b, 0x3a, 0xd9, 0xe7, 0xb4, 0xcf, 0x66, 0x2f, 0x84,
+	0x1f, 0x5b, 0xd0, 0xb9, 0x3b, 0x1a, 0xc6, 0xf9, 0x12, 0x2f, 0xd4, 0xc0, 0xfb, 0x9c, 0xb0, 0x29,
+	0x9a, 0x2a, 0x26, 0x5a, 0x03, 0xe8, 0x93, 0x88, 0xa8, 0x7e, 0x50, 0x6a, 0x5c, 0x73, 0x73, 0x94,
+	0xa2, 0x9a, 0x69, 0x63, 0xe7, 0x88, 0x82, 0x18, 0xab, 0xad, 0x38, 0x20, 0x89, 0xf4, 0x63, 0xcd,
+	0x2d, 0xd0, 0x9c, 0x5f, 0x5a, 0xb0, 0x9c, 0x53, 0xaa, 0x6c, 0xcc, 0xb4, 0x55, 0x3a, 0x53, 0x23,
+	0x04, 0xb5, 0x21, 0x65, 0x44, 0x2a, 0xd4, 0x70, 0xe5, 0x6f, 0xd4, 0x85, 0x86, 0x37, 0x20, 0xde,
+	0x41, 0x32, 0x1a, 0x4a, 0x5d, 0x5a, 0x6e, 0x3a, 0x46, 0x57, 0x60, 0xd1, 0xa3, 0xc3, 0x18, 0x7b,
+	0x5c, 0x57, 0xef, 0x39, 0x09, 0x68, 0x69, 0xa2, 0x54, 0xcf, 0xf9, 0x26, 0xd8, 0xf7, 0x08, 0xdf,
+	0xa2, 0x11, 0xc7, 0x1e, 0xbf, 0x1f, 0x24, 0x9c, 0xb2, 0xe3, 0x5c, 0x4b, 0x11, 0x13, 0xc2, 0x4e,
+	0x68, 0x29, 0x04, 0x7b, 0xdb, 0xcf, 0x7c, 0x55, 0xc9, 0x87, 0xf4, 0x57, 0x15, 0x38, 0x3f, 0x41,
+	0xb6, 0xf6, 0xc7, 0xa9, 0xfb, 0x95, 0x1b, 0x50, 0x27, 0x87, 0xb2, 0x4b, 0x54, 0x69, 0x70, 0x2e,
+	0xb7, 0x6d, 0x6a, 0xd9, 0x6f, 0x09, 0xbe, 0xab, 0x61, 0xe8, 0x05, 0x99, 0x86, 0x3c, 0xd1, 0x6b,
+	0x62, 0x02, 0x5e, 0xf5, 0x14, 0x0a, 0x85, 0xee, 0x42, 0x87, 0x93, 0x68, 0xc4, 0x48, 0x8f, 0x0f,
+	0x18, 0x49, 0x06, 0x34, 0xf4, 0xf5, 0xf2, 0x38, 0xbf, 0xa1, 0xce, 0x07, 0x1b, 0xe6, 0x7c, 0xb0,
+	0x71, 0x57, 0x9f, 0x1f, 0xdc, 0xb6, 0x9a, 0xf2, 0xd8, 0xcc, 0x10, 0x4d, 0x90, 0x96, 0xd2, 0x67,
+	0xd8, 0x23, 0xd2, 0xd7, 0x73, 0xee, 0x82, 0xa2, 0xdd, 0x13, 0x24, 0x11, 0xdf, 0x98, 0xd1, 0x43,
+	0x12, 0xe1, 0xc8, 0x23, 0x76, 0x5d, 0x96, 0xbc, 0x1c, 0xc5, 0xf9, 0xa7, 0x05, 0xad, 0xbc, 0x82,
+	0xe8, 0x15, 0x80, 0xfd, 0x80, 0x25, 0xbc, 0x97, 0x10, 0x12, 0xe9, 0xb2, 0xd7, 0x1d, 0xd3, 0xe9,
+	0xb1, 0x39, 0xb3, 0xb8, 0x4d, 0x89, 0xde, 0x25, 0x24, 0x42, 0x17, 0xa1, 0xa9, 0x3b, 0x2d, 0x92,
+	0xe8, 0xa8, 0x64, 0x04, 0x91, 0x35, 0xfb, 0x38, 0x08, 0x47, 0x4c, 0x9f, 0x0e, 0xaa, 0x6e, 0x3a,
+	0x46, 0x2f, 0xc2, 0xaa, 0x27, 0x02, 0xe4, 0x8d, 0x78, 0x70, 0x48, 0x7a, 0x29, 0xae, 0x26, 0x0d,
+	0x5a, 0xc9, 0xf1, 0xde, 0x36, 0x53, 0x6c, 0x98, 0x57, 0x76, 0xfa, 0xd2, 0xec, 0x86, 0x6b, 0x86,
+	0x82, 0x43, 0x0e, 0x03, 0x8f, 0x13, 0x5f, 0xda, 0xdb, 0x70, 0xcd, 0xd0, 0xf9, 0x79, 0x05, 0x5a,
+	0xf9, 0xe8, 0xa1, 0x0d, 0xa8, 0x89, 0x26, 0xf0, 0x14, 0x66, 0x4a, 0x9c, 0xb0, 0x90, 0xc6, 0xf9,
+	0xc5, 0xd2, 0x74, 0x33, 0x82, 0xe0, 0xfa, 0x01, 0x23, 0x9e, 0xe4, 0x56, 0x15, 0x37, 0x25, 0xe4,
+	0x77, 0x9e, 0x5a, 0x61, 0xe7, 0x41, 0x5f, 0x83, 0x86, 0x39, 0x23, 0xda, 0x73, 0xb3, 0x92, 0x20,
+	0x85, 0x8a, 0x9e, 0x9a, 0x30, 0x46, 0x59, 0xcf, 0x0b, 0x71, 0x92, 0x98, 0xd8, 0x4a, 0xd2, 0x96,
+	0xa0, 0x88, 0x15, 0x22, 0x47, 0xf6, 0xbc, 0x64, 0xa9, 0x01, 0xba, 0x04, 0xa0, 0xa7, 0x89, 0x7d,
+	0xad, 0xa1, 0xd4, 0x54, 0xb3, 0xa8, 0x4f, 0x9c, 0x37, 0xe1, 0xac, 0xda, 0x06, 0x3f, 0x08, 0xf8,
+	0xe0, 0xb1, 0xc8, 0x21, 0xb3, 0x32, 0x9f, 0x81, 0x3a, 0xc7, 0xac, 0x4f, 0xf8, 0xb4, 0xb5, 0xa3,
+	0xb8, 0xce, 0xf7, 0x2a, 0x70, 0x6e, 0x4c, 0xc4, 0x29, 0xb7, 0xd3, 0x54, 0xe5, 0x4a, 0x5e, 0xe5,
+	0x9b, 0xa6, 0xc6, 0x57, 0x67, 0xc6, 0x49, 0x01, 0x0b, 0x2e, 0xad, 0x9d, 0xde, 0xa5, 0x57, 0xa1,
+	0x36, 0xa0, 0xb1, 0x28, 0x5a, 0x62, 0xd1, 0xaf, 0xe6, 0x16, 0xb1, 0x32, 0xe8, 0x3e, 0x8d, 0x5d,
+	0x89, 0x10, 0x4b, 0xcf, 0x67, 0x34, 0x8e, 0x89, 0xdf, 0x93, 0x33, 0xea, 0xea, 0xfc, 0xa1, 0x69,
+	0xf7, 0x69, 0x9c, 0x38, 0x7f, 0xae, 0x40, 0x33, 0x9d, 0x76, 0xfa, 0xba, 0x36, 0xb5, 0x43, 0x11,
+	0x6d, 0x77, 0x8c, 0x99, 0x38, 0x9c, 0x07, 0xbe, 0x5d, 0x9d, 0x28, 0xa4, 0xa1, 0x00, 0xdb, 0x7e,
+	0xe6, 0xb3, 0xda, 0x97, 0xf1, 0xd9, 0xdc, 0x17, 0xf1, 0xd9, 0x7c, 0x48, 0x30, 0x8b, 0xe4, 0x72,
+	0xab, 0x4e, 0xd0, 0xc9, 0xb0, 0xc5, 0xde, 0xa0, 0x7f, 0xea, 0x93, 0xd8, 0xbc, 0x74, 0x5a, 0x4b,
+	0x13, 0xd5, 0xa9, 0x2d, 0xcd, 0x80, 0x46, 0x2e, 0x03, 0xc4, 0xc9, 0xcc, 0x25, 0x21, 0xc5, 0xfe,
+	0x16, 0x8d, 0xf6, 0x83, 0x7e, 0xee, 0x60, 0x55, 0x24, 0xeb, 0x13, 0xdb, 0x2a, 0xa0, 0x87, 0x84,
+	0x1f, 0x51, 0x76, 0xb0, 0x1b, 0x7c, 0x6a, 0x12, 0xd8, 0xf9, 0x85, 0x05, 0x2b, 0x05, 0xb2, 0x4e,
+	0xca, 0x2e, 0x34, 0x48, 0xc2, 0x83, 0x21, 0xe6, 0x44, 0xf7, 0xf1, 0xe9, 0x18, 0x75, 0xa0, 0x1a,
+	0xd2, 0x23, 0x5d, 0xcd, 0xc4, 0x4f, 0xb1, 0x23, 0x0e, 0x82, 0xfe, 0x40, 0xd7, 0x30, 0xf9, 0x5b,
+	0x2a, 0xfd, 0x04, 0x7b, 0xca, 0xd9, 0x0d, 0x57, 0x0d, 0xd0, 0x2d, 0x98, 0x1f, 0xc5, 0x3e, 0xe6,
+	0xba, 0x44, 0x9d, 0x1c, 0x04, 0x03, 0x75, 0xce, 0xc3, 0x39, 0xdd, 0xdd, 0x09, 0xa3, 0xc2, 0xc0,
+	0xcb, 0xce, 0x20, 0x8f, 0xc1, 0x1e, 0x67, 0x69, 0x23, 0x5e, 0x86, 0xa6, 0x67, 0x88, 0x7a, 0xbb,
+	0xef, 0xe6, 0xf2, 0xb7, 0x34, 0xcf, 0xcd, 0xc0, 0xce, 0xaf, 0x2d, 0x68, 0x97, 0xd8, 0xa7, 0xdf,
+	0x28, 0x5f, 0x86, 0x66, 0xcc, 0xc8, 0x3e, 0x61, 0xa2, 0x10, 0x57, 0x2e, 0x5b, 0xa5, 0xcf, 0xbe,
+	0x4f, 0x58, 0xb0, 0x1f, 0x10, 0x5f, 0xcb, 0x77, 0x33, 0x30, 0x7a, 0x15, 0x00, 0x87, 0x9c, 0xb0,
+	0x08, 0xf3, 0xf4, 0x90, 0x77, 0xd2, 0xd4, 0x1c, 0xda, 0xf9, 0x83, 0x05, 0xed, 0x12, 0x3f, 0xdf,
+	0x2e, 0x5b, 0xb3, 0xda, 0x65, 0x74, 0x1b, 0x96, 0xd4, 0x2e, 0x77, 0xa8, 0xa5, 0xd8, 0x95, 0x99,
+	0x11, 0x5a, 0x94, 0x33, 0xcc, 0x67, 0xd1, 0x1b, 0xb0, 0x18, 0xe2, 0xbc, 0x84, 0xd9, 0xc5, 0xa9,
+	0x15, 0xe2, 0x4c, 0x80, 0x08, 0xf4, 0xbb, 0x23, 0xcc, 0x70, 0xc4, 0x03, 0x73, 0xc8, 0x36, 0x81,
+	0x7e, 0x00, 0xf6, 0x38, 0x4b, 0x07, 0xfa, 0x66, 0xb1, 0xa7, 0xcb, 0xbb, 0xac, 0x34, 0xc7, 0x34,
+	0xac, 0x3f, 0xb2, 0xa0, 0x5d, 0x62, 0x7d, 0x15, 0xe7, 0x64, 0x91, 0xdf, 0x66, 0xe5, 0xcf, 0xb6,
+	0xdd, 0x40, 0xc5, 0xda, 0x74, 0x77, 0xb6, 0x76, 0x68, 0x18, 0x78, 0x41, 0x66, 0xf1, 0x3d, 0x58,
+	0x29, 0x50, 0x53, 0x63, 0x1b, 0xb1, 0xa6, 0xd9, 0xd6, 0x58, 0x51, 0x36, 0x33, 0x8e, 0xdd, 0x14,
+	0xe5, 0xfc, 0xce, 0x82, 0x66, 0x4a, 0x17, 0xcb, 0x97, 0xc5, 0x9e, 0x3e, 0xff, 0x89, 0x9f, 0xe8,
+	0x25, 0x98, 0x17, 0x5b, 0x39, 0x1d, 0x71, 0xbb, 0x32, 0xab, 0xc8, 0x19, 0xa4, 0xa8, 0xc9, 0x8c,
+	0x70, 0x16, 0xa4, 0xad, 0x8b, 0x19, 0x0a, 0x71, 0x7b, 0xd8, 0x3b, 0xa0, 0xfb, 0xfb, 0xb3, 0xf7,
+	0x19, 0x83, 0x14, 0x05, 0x27, 0x66, 0x01, 0x65, 0xe6, 0xb6, 0xad, 0xe9, 0xa6, 0x63, 0x67, 0x09,
+	0x5a, 0xf9, 0x2b, 0x36, 0xe7, 0xdf, 0x16, 0xac, 0x08, 0xc2, 0xee, 0x68, 0x38, 0xc4, 0xb9, 0x56,
+	0xf6, 0x12, 0xc0, 0x28, 0x21, 0x7e, 0x2f, 0x89, 0x45, 0xe7, 0xa7, 0xca, 0x56, 0x53, 0x50, 0x76,
+	0x05, 0x01, 0x3d, 0x0b, 0x6d, 0x7c, 0x88, 0x83, 0x50, 0xdc, 0x65, 0x6a, 0x8c, 0xaa, 0x61, 0x4b,
+	0x29, 0x59, 0x01, 0xc5, 0x45, 0x9a, 0x90, 0x13, 0x44, 0x7d, 0xb9, 0x76, 0xcc, 0x1d, 0x62, 0x42,
+	0xfc, 0x6d, 0x45, 0x12, 0x8d, 0x86, 0x84, 0x90, 0x7e, 0xda, 0xbd, 0x54, 0x5d, 0xf9, 0xf5, 0xb7,
+	0x14, 0xe0, 0xff, 0x61, 0x49, 0x02, 0xf6, 0x70, 0xe4, 0x1f, 0x05, 0x3e, 0x1f, 0xe8, 0x3b, 0xb6,
+	0x45, 0x41, 0xbd, 0x63, 0x88, 0xe8, 0x06, 0xac, 0x64, 0x3a, 0x65, 0x58, 0xb5, 0x75, 0xa2, 0x94,
+	0x95, 0x4e, 0x70, 0x10, 0x74, 0xee, 0xe2, 0x64, 0xb0, 0x47, 0x31, 0xf3, 0x8d, 0x3f, 0xfe, 0x56,
+	0x87, 0xe5, 0x1c, 0xf1, 0x8b, 0x36, 0xf6, 0xd7, 0xa0, 0x23, 0x81, 0x1e, 0x8d, 0x22, 0xd5, 0x98,
+	0x99, 0x56, 0xb5, 0x2d, 0xe8, 0x5b, 0x19, 0x19, 0x5d, 0x87, 0xe5, 0x3d, 0x4a, 0x79, 0xc2, 0x19,
+	0x8e, 0x7b, 0xa6, 0xb4, 0xa8, 0xb6, 0xae, 0x93, 0x32, 0x4c, 0xf5, 0xb9, 0x06, 0x1d, 0x79, 0x2d,
+	0x1e, 0xe1, 0xb0, 0x57, 0x6c, 0xf3, 0xda, 0x86, 0x9e, 0x83, 0x92, 0x27, 0x25, 0xa8, 0xca, 0x82,
+	0x36, 0x79, 0x52, 0x84, 0xde, 0x32, 0xa7, 0x8a, 0xba, 0xcc, 0xad, 0xb5, 0x5c, 0xee, 0x4f, 0xc8,
+	0x09, 0x73, 0xb8, 0x78, 0x11, 0xea, 0xea, 0x72, 0xd3, 0x9e, 0x9f, 0x95, 0x92, 0x1a, 0x88, 0x5e,
+	0x83, 0x05, 0x59, 0xcc, 0xe2, 0x20, 0xea, 0x13, 0xdf, 0x6e, 0xcc, 0x5c, 0xce, 0x20, 0xe0, 0x3b,
+	0x12, 0x8d, 0x5e, 0x07, 0x59, 0xd8, 0x7a, 0x9f, 0x8c, 0x08, 0x13, 0x85, 0xb0, 0x39, 0x73, 0xb6,
+	0xfc, 0xd8, 0xbb, 0x0a, 0x9e, 0x4e, 0x97, 0x67, 0xc8, 0x20, 0xb2, 0xe1, 0x74, 0xd3, 0xb7, 0x14,
+	0x1c, 0x6d, 0x66, 0x17, 0x0c, 0x0b, 0x72, 0xa6, 0x5d, 0xdc, 0x44, 0x04, 0x47, 0x38, 0x6b, 0x94,
+	0xa4, 0xf7, 0x0c, 0x22, 0x04, 0x74, 0x2f, 0x21, 0xec, 0x90, 0xf8, 0x69, 0x08, 0x5a, 0x2a, 0x04,
+	0x86, 0x7e, 0x3b, 0xdd, 0x29, 0x5a, 0x91, 0xea, 0x19, 0x7a, 0x49, 0xf0, 0x29, 0xb1, 0x17, 0xc7,
+	0x22, 0x31, 0xa1, 0xa5, 0x70, 0x17, 0xa2, 0x8c, 0x88, 0x5e, 0x06, 0xf0, 0x42, 0xea, 0x1d, 0xf4,
+	0x92, 0x03, 0x72, 0x64, 0x2f, 0xcd, 0x8a, 0x49, 0x53, 0x82, 0x77, 0x0f, 0xc8, 0x11, 0xfa, 0x3a,
+	0x34, 0xb3, 0x75, 0xd2, 0x96, 0xf5, 0xef, 0x7c, 0xbe, 0x29, 0xc5, 0x7b, 0x24, 0x4c, 0x97, 0x8b,
+	0x9b, 0x61, 0xd1, 0x1b, 0xb0, 0xa2, 0xed, 0xea, 0x8d, 0x22, 0x7d, 0x93, 0x1f, 0x12, 0xbb, 0x33,
+	0xb1, 0x41, 0x43, 0x1a, 0xfa, 0x5e, 0x86, 0x74, 0xae, 0x89, 0x86, 0x2b, 0x7b, 0x00, 0x30, 0x67,
+	0x00, 0x04, 0x35, 0x1f, 0x1f, 0x27, 0xfa, 0xe1, 0x49, 0xfe, 0x76, 0x5c, 0x58, 0x2d, 0x42, 0xf5,
+	0x9a, 0x7c, 0x15, 0xe6, 0x19, 0x0d, 0xc3, 0x51, 0x6c, 0x4a, 0xf7, 0xe5, 0x7c, 0xfa, 0x62, 0x4e,
+	0xc2, 0x30, 0xe0, 0xa4, 0x30, 0xd5, 0x4c, 0x70, 0xfe, 0x55, 0x81, 0x33, 0x13, 0x21, 0xe8, 0x45,
+	0x68, 0x25, 0x86, 0x31, 0x7d, 0xb9, 0x2f, 0xa4, 0x98, 0x6d, 0x1f, 0x3d, 0x0f, 0x55, 0x1f, 0x1f,
+	0x9f, 0x62, 0x87, 0x17, 0x30, 0x51, 0x9c, 0xf5, 0x83, 0x53, 0x7a, 0x4e, 0x35, 0xe3, 0xe2, 0x09,
+	0xb7, 0x56, 0x3e, 0xe1, 0x5e, 0x83, 0x8e, 0x4f, 0x8f, 0x22, 0xf9, 0xe4, 0x70, 0x14, 0x44, 0x3e,
+	0x3d, 0x4a, 0x74, 0x21, 0x6c, 0x1b, 0xfa, 0x07, 0x8a, 0x2c, 0x7a, 0x6d, 0x93, 0x68, 0x76, 0x7d,
+	0x56, 0x42, 0xa4, 0x50, 0x31, 0xcd, 0x48, 0x9a, 0xbd, 0xb6, 0x53, 0xa8, 0xb8, 0x40, 0xd2, 0xd5,
+	0x55, 0xbd, 0xf0, 0x34, 0xd4, 0x0b, 0x4f, 0x9e, 0xe6, 0x7c, 0x08, 0x4b, 0xc5, 0x74, 0x92, 0x17,
+	0x2c, 0x82, 0xa2, 0x77, 0x4f, 0x35, 0x10, 0x5b, 0xa1, 0xd9, 0x2a, 0x54, 0xdd, 0x34, 0x43, 0xf1,
+	0xfe, 0x48, 0xf2, 0x7b, 0x88, 0x1e, 0x39, 0xdf, 0x85, 0xc5, 0xc2, 0x32, 0x14, 0x22, 0xbc, 0x11,
+	0x63, 0x44, 0xbf, 0x9d, 0x34, 0x5d, 0x33, 0x54, 0xfe, 0xed, 0xf7, 0x49, 0xc2, 0x75, 0x47, 0xd6,
+	0x74, 0x33, 0x82, 0x88, 0x0c, 0x1d, 0x71, 0xd5, 0x50, 0xab, 0xfb, 0xa8, 0x74, 0x2c, 0x64, 0x8e,
+	0xa2, 0x83, 0x88, 0x1e, 0x45, 0xba, 0x07, 0x37, 0x43, 0xe7, 0x67, 0x16, 0xac, 0xea, 0xd7, 0xbc,
+	0xfb, 0x04, 0x87, 0x7c, 0x60, 0x72, 0xf9, 0x2c, 0xd4, 0xd5, 0x23, 0x85, 0x7e, 0x02, 0xd5, 0x23,
+	0xb1, 0x9b, 0x91, 0xc8, 0x63, 0xc7, 0x31, 0x27, 0x7e, 0x4f, 0x3e, 0x91, 0xca, 0xab, 0x45, 0x77,
+	0x31, 0xa5, 0xee, 0x88, 0xb7, 0xd2, 0x2b, 0x60, 0x5e, 0x40, 0x7b, 0x41, 0xe4, 0x93, 0x27, 0xda,
+	0xea, 0x96, 0x26, 0x6e, 0x0b, 0x9a, 0xd8, 0xa5, 0x63, 0x46, 0xbf, 0x43, 0x3c, 0x79, 0x66, 0x53,
+	0x97, 0x65, 0x4d, 0x4d, 0xd9, 0xf6, 0x9d, 0x07, 0xb0, 0x58, 0x50, 0x4d, 0xec, 0xc6, 0x34, 0x0a,
+	0x83, 0x88, 0xf4, 0x4c, 0x8f, 0x27, 0x6f, 0x74, 0x14, 0x4d, 0x3d, 0x8f, 0xd8, 0x30, 0xaf, 0x3f,
+	0xa1, 0xf5, 0x32, 0x43, 0xe7, 0xfb, 0x16, 0x9c, 0x29, 0x59, 0x9a, 0xb6, 0x51, 0xf5, 0x81, 0xa4,
+	0xd8, 0xd6, 0x58, 0x89, 0x2c, 0xce, 0xd0, 0x38, 0xf4, 0x1a, 0x00, 0x23, 0xfe, 0x28, 0xf2, 0x71,
+	0xe4, 0x99, 0xa5, 0x73, 0x21, 0xf7, 0x0a, 0xec, 0xa6, 0xcc, 0x5d, 0x6f, 0x40, 0x86, 0xc4, 0xcd,
+	0xc1, 0x9d, 0x7f, 0x58, 0xb0, 0xf2, 0x68, 0x4f, 0xd8, 0x58, 0xf4, 0xf8, 0xb8, 0x67, 0xad, 0x49,
+	0x9e, 0xcd, 0x02, 0x53, 0x29, 0x04, 0xa6, 0xe8, 0xcc, 0x6a, 0xc9, 0x99, 0xe2, 0x09, 0x51, 0x1e,
+	0x64, 0x7b, 0xf2, 0xae, 0xb5, 0x67, 0x9c, 0xa4, 0x1f, 0x98, 0x25, 0xeb, 0xb6, 0xe0, 0x68, 0x83,
+	0xd1, 0xf3, 0x80, 0x48, 0xe4, 0xf7, 0xf6, 0xc8, 0x3e, 0x65, 0x24, 0x85, 0xab, 0x05, 0xdb, 0x21,
+	0x91, 0x7f, 0x47, 0x32, 0x0c, 0x3a, 0xbd, 0x6e, 0xac, 0xe7, 0xae, 0x66, 0x9d, 0x1f, 0x5a, 0xb0,
+	0x5a, 0xb4, 0x54, 0x7b, 0xfc, 0xd6, 0xd8, 0x43, 0xf3, 0x74, 0x9f, 0xa7, 0xc8, 0xff, 0xca, 0xeb,
+	0x9b, 0x9f, 0x37, 0xa0, 0xf5, 0x0e, 0xf6, 0xb7, 0xcd, 0x57, 0xd0, 0x36, 0x40, 0xf6, 0x16, 0x89,
+	0x2e, 0x16, 0xae, 0x24, 0x4b, 0x4f, 0x94, 0xdd, 0x4b, 0x53, 0xb8, 0xda, 0x9c, 0x2d, 0x68, 0x98,
+	0xf7, 0x17, 0x94, 0x3f, 0x71, 0x94, 0x5e, 0x78, 0xba, 0x17, 0x26, 0xf2, 0xb4, 0x90, 0x6d, 0x80,
+	0xec, 0x85, 0xa5, 0xa0, 0xcf, 0xd8, 0xbb, 0x4d, 0xf7, 0xd2, 0x14, 0x6e, 0xa6, 0x8f, 0x79, 0xed,
+	0x28, 0xe8, 0x53, 0x7a, 0x63, 0xe9, 0x5e, 0x98, 0xc8, 0xcb, 0x84, 0x98, 0xeb, 0xff, 0x82, 0x90,
+	0xd2, 0x13, 0x44, 0xf7, 0xc2, 0x44, 0x9e, 0x16, 0xf2, 0x36, 0x34, 0xd3, 0x7b, 0x77, 0x94, 0x47,
+	0x96, 0x9f, 0x08, 0xba, 0x17, 0x27, 0x33, 0xb5, 0x1c, 0x17, 0x16, 0x0b, 0xef, 0xb1, 0x68, 0x7d,
+	0xfa, 0x4b, 0xad, 0x92, 0x77, 0x79, 0xd6, 0x53, 0x2e, 0xfa, 0x58, 0xbe, 0x1a, 0x16, 0xef, 0xc2,
+	0xd1, 0x95, 0xe2, 0xb4, 0x89, 0xb7, 0xf0, 0xdd, 0xa7, 0x4f, 0x06, 0x69, 0xf9, 0x1f, 0x42, 0xbb,
+	0x74, 0xd1, 0x87, 0x9e, 0x1a, 0x8b, 0x5b, 0xf9, 0x1e, 0xb1, 0xeb, 0x9c, 0x04, 0xd1, 0x92, 0x1f,
+	0x41, 0x2b, 0x7f, 0xb1, 0x83, 0xf2, 0xfd, 0xd6, 0x84, 0x8b, 0xa0, 0xee, 0xfa, 0x54, 0xbe, 0x16,
+	0xf8, 0x00, 0x16, 0x72, 0x7d, 0x1a, 0xba, 0x34, 0xad, 0x7f, 0x53, 0xe2, 0x66, 0xb4, 0x77, 0xe8,
+	0xdb, 0xd0, 0x29, 0x5f, 0xc4, 0x20, 0x67, 0xfa, 0x6d, 0x4b, 0x9a, 0x02, 0x57, 0x4e, 0xc4, 0x64,
+	0xaa, 0xe6, 0x8e, 0xc2, 0x05, 0x55, 0xc7, 0x0f, 0xce, 0xdd, 0xb5, 0x69, 0xec, 0x4c, 0xd5, 0xf2,
+	0x55, 0x42, 0x41, 0xd5, 0x29, 0x57, 0x10, 0xdd, 0x2b, 0x27, 0x62, 0x94, 0xf0, 0xcd, 0xdf, 0x56,
+	0xa0, 0xf3, 0xe8, 0x90, 0xb0, 0x10, 0x1f, 0xff, 0x4f, 0xca, 0xce, 0x57, 0xb5, 0xb8, 0xb6, 0xa0,
+	0x61, 0xfe, 0x7e, 0x53, 0x58, 0xe9, 0xa5, 0x3f, 0xf4, 0x74, 0x2f, 0x4c, 0xe4, 0x65, 0x71, 0xc9,
+	0xfd, 0x3b, 0xa4, 0x10, 0x97, 0xf1, 0xbf, 0xc6, 0x74, 0xd7, 0xa6, 0xb1, 0xb5, 0xeb, 0x3e, 0xb7,
+	0x60, 0x45, 0xfe, 0x33, 0x6a, 0x97, 0x53, 0x46, 0x32, 0xef, 0xbd, 0x09, 0x73, 0x4a, 0xfe, 0xb9,
+	0xd2, 0x61, 0x6f, 0xa2, 0xe4, 0x49, 0x37, 0x03, 0xc2, 0x69, 0xe6, 0x80, 0x5c, 0x74, 0x5a, 0xe9,
+	0x2c, 0xdd, 0xbd, 0x38, 0x99, 0x99, 0x5f, 0x83, 0xb9, 0xce, 0xbb, 0xb8, 0x06, 0xc7, 0xce, 0x06,
+	0xdd, 0xf5, 0xa9, 0x7c, 0x6d, 0xf2, 0x0f, 0x2c, 0x58, 0xcd, 0xfd, 0xc5, 0x2a, 0xb3, 0x39, 0x86,
+	0x73, 0x53, 0xfe, 0xb8, 0x85, 0xae, 0xe5, 0x8b, 0xc5, 0x89, 0xff, 0x8a, 0xeb, 0x3e, 0x77, 0x1a,
+	0xa8, 0x56, 0xe5, 0x37, 0x16, 0xb4, 0xd5, 0xee, 0x9b, 0x69, 0xf1, 0x08, 0x5a, 0xf9, 0xad, 0xbc,
+	0x60, 0xef, 0x84, 0x6e, 0xa6, 0xbb, 0x3e, 0x95, 0x9f, 0x95, 0xf4, 0x62, 0x77, 0xb7, 0x3e, 0xb5,
+	0x05, 0x98, 0x50, 0xd2, 0x27, 0x76, 0x72, 0x77, 0x6a, 0xdf, 0xaa, 0xc4, 0x7b, 0x7b, 0x75, 0xd9,
+	0xed, 0xbf, 0xf4, 0x9f, 0x01, 0x00, 0x1d, 0xa7, 0xd1, 0x61, 0xb1, 0x28, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1879,8 +3544,22 @@ type KadInspectorClient interface {
 	FindNear(ctx context.Context, in *FindNearRequest, opts ...grpc.CallOption) (*FindNearResponse, error)
 	// DumpNodes returns all the nodes in the node database
 	DumpNodes(ctx context.Context, in *DumpNodesRequest, opts ...grpc.CallOption) (*DumpNodesResponse, error)
-	// GetBucketList returns all the buckets with all their nodes
+	// GetBucketList returns all the buckets with all their nodes and the pinned nodes
 	GetBucketList(ctx context.Context, in *GetBucketListRequest, opts ...grpc.CallOption) (*GetBucketListResponse, error)
+	// GetContactHistory returns the recent contacts with a node
+	GetContactHistory(ctx context.Context, in *GetContactHistoryRequest, opts ...grpc.CallOption) (*GetContactHistoryResponse, error)
+	// LookupWithTrace triggers a Kademlia FindNode and returns the trace of the walk
+	LookupWithTrace(ctx context.Context, in *LookupWithTraceRequest, opts ...grpc.CallOption) (*LookupWithTraceResponse, error)
+	// ReloadConfig reads the configuration again and applies the settings that can change while running
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error)
+	// NetworkSize returns the number of nodes in the network estimated from the routing table
+	NetworkSize(ctx context.Context, in *NetworkSizeRequest, opts ...grpc.CallOption) (*NetworkSizeResponse, error)
+	// AddressConflicts returns the nodes recently verified at several addresses
+	AddressConflicts(ctx context.Context, in *AddressConflictsRequest, opts ...grpc.CallOption) (*AddressConflictsResponse, error)
+	// RPCPolicies returns the effective timeouts, retries and priorities of the RPCs made by the dialer
+	RPCPolicies(ctx context.Context, in *RPCPoliciesRequest, opts ...grpc.CallOption) (*RPCPoliciesResponse, error)
+	// QuarantinedNodes returns the nodes learned through unverified dials that weren't verified yet
+	QuarantinedNodes(ctx context.Context, in *QuarantinedNodesRequest, opts ...grpc.CallOption) (*QuarantinedNodesResponse, error)
 }
 
 type kadInspectorClient struct {
@@ -1954,6 +3633,69 @@ func (c *kadInspectorClient) GetBucketList(ctx context.Context, in *GetBucketLis
 	return out, nil
 }
 
+func (c *kadInspectorClient) GetContactHistory(ctx context.Context, in *GetContactHistoryRequest, opts ...grpc.CallOption) (*GetContactHistoryResponse, error) {
+	out := new(GetContactHistoryResponse)
+	err := c.cc.Invoke(ctx, "/inspector.KadInspector/GetContactHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kadInspectorClient) LookupWithTrace(ctx context.Context, in *LookupWithTraceRequest, opts ...grpc.CallOption) (*LookupWithTraceResponse, error) {
+	out := new(LookupWithTraceResponse)
+	err := c.cc.Invoke(ctx, "/inspector.KadInspector/LookupWithTrace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kadInspectorClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error) {
+	out := new(ReloadConfigResponse)
+	err := c.cc.Invoke(ctx, "/inspector.KadInspector/ReloadConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kadInspectorClient) NetworkSize(ctx context.Context, in *NetworkSizeRequest, opts ...grpc.CallOption) (*NetworkSizeResponse, error) {
+	out := new(NetworkSizeResponse)
+	err := c.cc.Invoke(ctx, "/inspector.KadInspector/NetworkSize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kadInspectorClient) AddressConflicts(ctx context.Context, in *AddressConflictsRequest, opts ...grpc.CallOption) (*AddressConflictsResponse, error) {
+	out := new(AddressConflictsResponse)
+	err := c.cc.Invoke(ctx, "/inspector.KadInspector/AddressConflicts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kadInspectorClient) RPCPolicies(ctx context.Context, in *RPCPoliciesRequest, opts ...grpc.CallOption) (*RPCPoliciesResponse, error) {
+	out := new(RPCPoliciesResponse)
+	err := c.cc.Invoke(ctx, "/inspector.KadInspector/RPCPolicies", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kadInspectorClient) QuarantinedNodes(ctx context.Context, in *QuarantinedNodesRequest, opts ...grpc.CallOption) (*QuarantinedNodesResponse, error) {
+	out := new(QuarantinedNodesResponse)
+	err := c.cc.Invoke(ctx, "/inspector.KadInspector/QuarantinedNodes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KadInspectorServer is the server API for KadInspector service.
 type KadInspectorServer interface {
 	// CountNodes returns the number of nodes in the routing table
@@ -1968,8 +3710,22 @@ type KadInspectorServer interface {
 	FindNear(context.Context, *FindNearRequest) (*FindNearResponse, error)
 	// DumpNodes returns all the nodes in the node database
 	DumpNodes(context.Context, *DumpNodesRequest) (*DumpNodesResponse, error)
-	// GetBucketList returns all the buckets with all their nodes
+	// GetBucketList returns all the buckets with all their nodes and the pinned nodes
 	GetBucketList(context.Context, *GetBucketListRequest) (*GetBucketListResponse, error)
+	// GetContactHistory returns the recent contacts with a node
+	GetContactHistory(context.Context, *GetContactHistoryRequest) (*GetContactHistoryResponse, error)
+	// LookupWithTrace triggers a Kademlia FindNode and returns the trace of the walk
+	LookupWithTrace(context.Context, *LookupWithTraceRequest) (*LookupWithTraceResponse, error)
+	// ReloadConfig reads the configuration again and applies the settings that can change while running
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error)
+	// NetworkSize returns the number of nodes in the network estimated from the routing table
+	NetworkSize(context.Context, *NetworkSizeRequest) (*NetworkSizeResponse, error)
+	// AddressConflicts returns the nodes recently verified at several addresses
+	AddressConflicts(context.Context, *AddressConflictsRequest) (*AddressConflictsResponse, error)
+	// RPCPolicies returns the effective timeouts, retries and priorities of the RPCs made by the dialer
+	RPCPolicies(context.Context, *RPCPoliciesRequest) (*RPCPoliciesResponse, error)
+	// QuarantinedNodes returns the nodes learned through unverified dials that weren't verified yet
+	QuarantinedNodes(context.Context, *QuarantinedNodesRequest) (*QuarantinedNodesResponse, error)
 }
 
 func RegisterKadInspectorServer(s *grpc.Server, srv KadInspectorServer) {
@@ -2102,6 +3858,132 @@ func _KadInspector_GetBucketList_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KadInspector_GetContactHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetContactHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KadInspectorServer).GetContactHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.KadInspector/GetContactHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KadInspectorServer).GetContactHistory(ctx, req.(*GetContactHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KadInspector_LookupWithTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupWithTraceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KadInspectorServer).LookupWithTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.KadInspector/LookupWithTrace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KadInspectorServer).LookupWithTrace(ctx, req.(*LookupWithTraceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KadInspector_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KadInspectorServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.KadInspector/ReloadConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KadInspectorServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KadInspector_NetworkSize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NetworkSizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KadInspectorServer).NetworkSize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.KadInspector/NetworkSize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KadInspectorServer).NetworkSize(ctx, req.(*NetworkSizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KadInspector_AddressConflicts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressConflictsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KadInspectorServer).AddressConflicts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.KadInspector/AddressConflicts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KadInspectorServer).AddressConflicts(ctx, req.(*AddressConflictsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KadInspector_RPCPolicies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RPCPoliciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KadInspectorServer).RPCPolicies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.KadInspector/RPCPolicies",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KadInspectorServer).RPCPolicies(ctx, req.(*RPCPoliciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KadInspector_QuarantinedNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuarantinedNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KadInspectorServer).QuarantinedNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.KadInspector/QuarantinedNodes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KadInspectorServer).QuarantinedNodes(ctx, req.(*QuarantinedNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _KadInspector_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "inspector.KadInspector",
 	HandlerType: (*KadInspectorServer)(nil),
@@ -2134,6 +4016,34 @@ var _KadInspector_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetBucketList",
 			Handler:    _KadInspector_GetBucketList_Handler,
 		},
+		{
+			MethodName: "GetContactHistory",
+			Handler:    _KadInspector_GetContactHistory_Handler,
+		},
+		{
+			MethodName: "LookupWithTrace",
+			Handler:    _KadInspector_LookupWithTrace_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _KadInspector_ReloadConfig_Handler,
+		},
+		{
+			MethodName: "NetworkSize",
+			Handler:    _KadInspector_NetworkSize_Handler,
+		},
+		{
+			MethodName: "AddressConflicts",
+			Handler:    _KadInspector_AddressConflicts_Handler,
+		},
+		{
+			MethodName: "RPCPolicies",
+			Handler:    _KadInspector_RPCPolicies_Handler,
+		},
+		{
+			MethodName: "QuarantinedNodes",
+			Handler:    _KadInspector_QuarantinedNodes_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "inspector.proto",
@@ -2318,6 +4228,8 @@ type PieceStoreInspectorClient interface {
 	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatSummaryResponse, error)
 	// Dashboard returns stats for a specific storagenode
 	Dashboard(ctx context.Context, in *DashboardRequest, opts ...grpc.CallOption) (*DashboardResponse, error)
+	// Reachability returns the daily reachability of each satellite
+	Reachability(ctx context.Context, in *ReachabilityRequest, opts ...grpc.CallOption) (*ReachabilityResponse, error)
 }
 
 type pieceStoreInspectorClient struct {
@@ -2346,12 +4258,23 @@ func (c *pieceStoreInspectorClient) Dashboard(ctx context.Context, in *Dashboard
 	return out, nil
 }
 
+func (c *pieceStoreInspectorClient) Reachability(ctx context.Context, in *ReachabilityRequest, opts ...grpc.CallOption) (*ReachabilityResponse, error) {
+	out := new(ReachabilityResponse)
+	err := c.cc.Invoke(ctx, "/inspector.PieceStoreInspector/Reachability", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PieceStoreInspectorServer is the server API for PieceStoreInspector service.
 type PieceStoreInspectorServer interface {
 	// Stats return space and bandwidth stats for a storagenode
 	Stats(context.Context, *StatsRequest) (*StatSummaryResponse, error)
 	// Dashboard returns stats for a specific storagenode
 	Dashboard(context.Context, *DashboardRequest) (*DashboardResponse, error)
+	// Reachability returns the daily reachability of each satellite
+	Reachability(context.Context, *ReachabilityRequest) (*ReachabilityResponse, error)
 }
 
 func RegisterPieceStoreInspectorServer(s *grpc.Server, srv PieceStoreInspectorServer) {
@@ -2394,6 +4317,24 @@ func _PieceStoreInspector_Dashboard_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PieceStoreInspector_Reachability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReachabilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PieceStoreInspectorServer).Reachability(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inspector.PieceStoreInspector/Reachability",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PieceStoreInspectorServer).Reachability(ctx, req.(*ReachabilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _PieceStoreInspector_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "inspector.PieceStoreInspector",
 	HandlerType: (*PieceStoreInspectorServer)(nil),
@@ -2406,6 +4347,10 @@ var _PieceStoreInspector_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Dashboard",
 			Handler:    _PieceStoreInspector_Dashboard_Handler,
 		},
+		{
+			MethodName: "Reachability",
+			Handler:    _PieceStoreInspector_Reachability_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "inspector.proto",
@@ -8,6 +8,8 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	grpc "google.golang.org/grpc"
 	math "math"
 )
@@ -54,7 +56,7 @@ func (x Restriction_Operator) String() string {
 }
 
 func (Restriction_Operator) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{8, 0}
+	return fileDescriptor_61fc82527fbe24ad, []int{12, 0}
 }
 
 type Restriction_Operand int32
@@ -79,17 +81,23 @@ func (x Restriction_Operand) String() string {
 }
 
 func (Restriction_Operand) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{8, 1}
+	return fileDescriptor_61fc82527fbe24ad, []int{12, 1}
 }
 
 type QueryRequest struct {
-	Sender               *Node    `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
-	Target               *Node    `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
-	Limit                int64    `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
-	Pingback             bool     `protobuf:"varint,4,opt,name=pingback,proto3" json:"pingback,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Sender    *Node `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Target    *Node `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Limit     int64 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Pingback  bool  `protobuf:"varint,4,opt,name=pingback,proto3" json:"pingback,omitempty"`
+	ChunkSize int64 `protobuf:"varint,5,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	// optional features supported by the sender, unknown bits are ignored
+	Capabilities uint64 `protobuf:"varint,6,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// restricts the nodes of the response, honored by responders with the
+	// query filter capability
+	Filter               *QueryFilter `protobuf:"bytes,7,opt,name=filter,proto3" json:"filter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *QueryRequest) Reset()         { *m = QueryRequest{} }
@@ -144,19 +152,97 @@ func (m *QueryRequest) GetPingback() bool {
 	return false
 }
 
+func (m *QueryRequest) GetChunkSize() int64 {
+	if m != nil {
+		return m.ChunkSize
+	}
+	return 0
+}
+
+func (m *QueryRequest) GetCapabilities() uint64 {
+	if m != nil {
+		return m.Capabilities
+	}
+	return 0
+}
+
+func (m *QueryRequest) GetFilter() *QueryFilter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
+// QueryFilter restricts the nodes a query responds with.
+type QueryFilter struct {
+	// the least free disk in bytes, 0 for any
+	MinFreeDisk int64 `protobuf:"varint,1,opt,name=min_free_disk,json=minFreeDisk,proto3" json:"min_free_disk,omitempty"`
+	// the allowed node types, empty for any
+	Types                []NodeType `protobuf:"varint,2,rep,packed,name=types,proto3,enum=node.NodeType" json:"types,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *QueryFilter) Reset()         { *m = QueryFilter{} }
+func (m *QueryFilter) String() string { return proto.CompactTextString(m) }
+func (*QueryFilter) ProtoMessage()    {}
+func (*QueryFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_61fc82527fbe24ad, []int{1}
+}
+func (m *QueryFilter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryFilter.Unmarshal(m, b)
+}
+func (m *QueryFilter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryFilter.Marshal(b, m, deterministic)
+}
+func (m *QueryFilter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryFilter.Merge(m, src)
+}
+func (m *QueryFilter) XXX_Size() int {
+	return xxx_messageInfo_QueryFilter.Size(m)
+}
+func (m *QueryFilter) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryFilter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryFilter proto.InternalMessageInfo
+
+func (m *QueryFilter) GetMinFreeDisk() int64 {
+	if m != nil {
+		return m.MinFreeDisk
+	}
+	return 0
+}
+
+func (m *QueryFilter) GetTypes() []NodeType {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
 type QueryResponse struct {
-	Sender               *Node    `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
-	Response             []*Node  `protobuf:"bytes,2,rep,name=response,proto3" json:"response,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Sender   *Node   `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Response []*Node `protobuf:"bytes,2,rep,name=response,proto3" json:"response,omitempty"`
+	// optional features supported by the responder, unknown bits are ignored
+	Capabilities uint64 `protobuf:"varint,3,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// the responder is still warming up and answered from a partial routing table
+	WarmingUp bool `protobuf:"varint,4,opt,name=warming_up,json=warmingUp,proto3" json:"warming_up,omitempty"`
+	// the clock of the responder when it answered, for estimating the clock offset between peers
+	Time *timestamp.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	// what a satellite suggests to a node checking in, unset otherwise
+	Hints                *ContactHints `protobuf:"bytes,6,opt,name=hints,proto3" json:"hints,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *QueryResponse) Reset()         { *m = QueryResponse{} }
 func (m *QueryResponse) String() string { return proto.CompactTextString(m) }
 func (*QueryResponse) ProtoMessage()    {}
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{1}
+	return fileDescriptor_61fc82527fbe24ad, []int{2}
 }
 func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_QueryResponse.Unmarshal(m, b)
@@ -190,6 +276,101 @@ func (m *QueryResponse) GetResponse() []*Node {
 	return nil
 }
 
+func (m *QueryResponse) GetCapabilities() uint64 {
+	if m != nil {
+		return m.Capabilities
+	}
+	return 0
+}
+
+func (m *QueryResponse) GetWarmingUp() bool {
+	if m != nil {
+		return m.WarmingUp
+	}
+	return false
+}
+
+func (m *QueryResponse) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *QueryResponse) GetHints() *ContactHints {
+	if m != nil {
+		return m.Hints
+	}
+	return nil
+}
+
+// ContactHints is what a satellite suggests to a node checking in, nodes
+// ignore the hints they don't know.
+type ContactHints struct {
+	// how often the node should check in, unset without a preference
+	CheckInInterval *duration.Duration `protobuf:"bytes,1,opt,name=check_in_interval,json=checkInInterval,proto3" json:"check_in_interval,omitempty"`
+	// whether the satellite reached the node at its address
+	AddressOk bool `protobuf:"varint,2,opt,name=address_ok,json=addressOk,proto3" json:"address_ok,omitempty"`
+	// the minimum node version the satellite will require from effective
+	MinimumVersion          string               `protobuf:"bytes,3,opt,name=minimum_version,json=minimumVersion,proto3" json:"minimum_version,omitempty"`
+	MinimumVersionEffective *timestamp.Timestamp `protobuf:"bytes,4,opt,name=minimum_version_effective,json=minimumVersionEffective,proto3" json:"minimum_version_effective,omitempty"`
+	XXX_NoUnkeyedLiteral    struct{}             `json:"-"`
+	XXX_unrecognized        []byte               `json:"-"`
+	XXX_sizecache           int32                `json:"-"`
+}
+
+func (m *ContactHints) Reset()         { *m = ContactHints{} }
+func (m *ContactHints) String() string { return proto.CompactTextString(m) }
+func (*ContactHints) ProtoMessage()    {}
+func (*ContactHints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_61fc82527fbe24ad, []int{3}
+}
+func (m *ContactHints) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContactHints.Unmarshal(m, b)
+}
+func (m *ContactHints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContactHints.Marshal(b, m, deterministic)
+}
+func (m *ContactHints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContactHints.Merge(m, src)
+}
+func (m *ContactHints) XXX_Size() int {
+	return xxx_messageInfo_ContactHints.Size(m)
+}
+func (m *ContactHints) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContactHints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContactHints proto.InternalMessageInfo
+
+func (m *ContactHints) GetCheckInInterval() *duration.Duration {
+	if m != nil {
+		return m.CheckInInterval
+	}
+	return nil
+}
+
+func (m *ContactHints) GetAddressOk() bool {
+	if m != nil {
+		return m.AddressOk
+	}
+	return false
+}
+
+func (m *ContactHints) GetMinimumVersion() string {
+	if m != nil {
+		return m.MinimumVersion
+	}
+	return ""
+}
+
+func (m *ContactHints) GetMinimumVersionEffective() *timestamp.Timestamp {
+	if m != nil {
+		return m.MinimumVersionEffective
+	}
+	return nil
+}
+
 type PingRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -200,7 +381,7 @@ func (m *PingRequest) Reset()         { *m = PingRequest{} }
 func (m *PingRequest) String() string { return proto.CompactTextString(m) }
 func (*PingRequest) ProtoMessage()    {}
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{2}
+	return fileDescriptor_61fc82527fbe24ad, []int{4}
 }
 func (m *PingRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_PingRequest.Unmarshal(m, b)
@@ -221,16 +402,22 @@ func (m *PingRequest) XXX_DiscardUnknown() {
 var xxx_messageInfo_PingRequest proto.InternalMessageInfo
 
 type PingResponse struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	// optional features supported by the responder, unknown bits are ignored
+	Capabilities uint64 `protobuf:"varint,1,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// the address the responder received the ping from, without a port when forwarded by a proxy
+	ObservedAddress string `protobuf:"bytes,2,opt,name=observed_address,json=observedAddress,proto3" json:"observed_address,omitempty"`
+	// the clock of the responder when it answered, for estimating the clock offset between peers
+	Time                 *timestamp.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *PingResponse) Reset()         { *m = PingResponse{} }
 func (m *PingResponse) String() string { return proto.CompactTextString(m) }
 func (*PingResponse) ProtoMessage()    {}
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{3}
+	return fileDescriptor_61fc82527fbe24ad, []int{5}
 }
 func (m *PingResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_PingResponse.Unmarshal(m, b)
@@ -250,6 +437,27 @@ func (m *PingResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_PingResponse proto.InternalMessageInfo
 
+func (m *PingResponse) GetCapabilities() uint64 {
+	if m != nil {
+		return m.Capabilities
+	}
+	return 0
+}
+
+func (m *PingResponse) GetObservedAddress() string {
+	if m != nil {
+		return m.ObservedAddress
+	}
+	return ""
+}
+
+func (m *PingResponse) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
 // TODO: add fields that validate who is requesting the info
 type InfoRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -261,7 +469,7 @@ func (m *InfoRequest) Reset()         { *m = InfoRequest{} }
 func (m *InfoRequest) String() string { return proto.CompactTextString(m) }
 func (*InfoRequest) ProtoMessage()    {}
 func (*InfoRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{4}
+	return fileDescriptor_61fc82527fbe24ad, []int{6}
 }
 func (m *InfoRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_InfoRequest.Unmarshal(m, b)
@@ -295,7 +503,7 @@ func (m *InfoResponse) Reset()         { *m = InfoResponse{} }
 func (m *InfoResponse) String() string { return proto.CompactTextString(m) }
 func (*InfoResponse) ProtoMessage()    {}
 func (*InfoResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{5}
+	return fileDescriptor_61fc82527fbe24ad, []int{7}
 }
 func (m *InfoResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_InfoResponse.Unmarshal(m, b)
@@ -343,6 +551,70 @@ func (m *InfoResponse) GetVersion() *NodeVersion {
 	return nil
 }
 
+// LeaveRequest announces that the sender is shutting down intentionally, so
+// that the receiver removes it from its routing table.
+type LeaveRequest struct {
+	// the leaving node, which must be the node sending the request
+	NodeId               NodeID   `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3,customtype=NodeID" json:"node_id"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LeaveRequest) Reset()         { *m = LeaveRequest{} }
+func (m *LeaveRequest) String() string { return proto.CompactTextString(m) }
+func (*LeaveRequest) ProtoMessage()    {}
+func (*LeaveRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_61fc82527fbe24ad, []int{8}
+}
+func (m *LeaveRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeaveRequest.Unmarshal(m, b)
+}
+func (m *LeaveRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeaveRequest.Marshal(b, m, deterministic)
+}
+func (m *LeaveRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeaveRequest.Merge(m, src)
+}
+func (m *LeaveRequest) XXX_Size() int {
+	return xxx_messageInfo_LeaveRequest.Size(m)
+}
+func (m *LeaveRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeaveRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeaveRequest proto.InternalMessageInfo
+
+type LeaveResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LeaveResponse) Reset()         { *m = LeaveResponse{} }
+func (m *LeaveResponse) String() string { return proto.CompactTextString(m) }
+func (*LeaveResponse) ProtoMessage()    {}
+func (*LeaveResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_61fc82527fbe24ad, []int{9}
+}
+func (m *LeaveResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeaveResponse.Unmarshal(m, b)
+}
+func (m *LeaveResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeaveResponse.Marshal(b, m, deterministic)
+}
+func (m *LeaveResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeaveResponse.Merge(m, src)
+}
+func (m *LeaveResponse) XXX_Size() int {
+	return xxx_messageInfo_LeaveResponse.Size(m)
+}
+func (m *LeaveResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeaveResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeaveResponse proto.InternalMessageInfo
+
 type GraphRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -353,7 +625,7 @@ func (m *GraphRequest) Reset()         { *m = GraphRequest{} }
 func (m *GraphRequest) String() string { return proto.CompactTextString(m) }
 func (*GraphRequest) ProtoMessage()    {}
 func (*GraphRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{6}
+	return fileDescriptor_61fc82527fbe24ad, []int{10}
 }
 func (m *GraphRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GraphRequest.Unmarshal(m, b)
@@ -384,7 +656,7 @@ func (m *GraphResponse) Reset()         { *m = GraphResponse{} }
 func (m *GraphResponse) String() string { return proto.CompactTextString(m) }
 func (*GraphResponse) ProtoMessage()    {}
 func (*GraphResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{7}
+	return fileDescriptor_61fc82527fbe24ad, []int{11}
 }
 func (m *GraphResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GraphResponse.Unmarshal(m, b)
@@ -424,7 +696,7 @@ func (m *Restriction) Reset()         { *m = Restriction{} }
 func (m *Restriction) String() string { return proto.CompactTextString(m) }
 func (*Restriction) ProtoMessage()    {}
 func (*Restriction) Descriptor() ([]byte, []int) {
-	return fileDescriptor_61fc82527fbe24ad, []int{8}
+	return fileDescriptor_61fc82527fbe24ad, []int{12}
 }
 func (m *Restriction) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Restriction.Unmarshal(m, b)
@@ -469,11 +741,15 @@ func init() {
 	proto.RegisterEnum("overlay.Restriction_Operator", Restriction_Operator_name, Restriction_Operator_value)
 	proto.RegisterEnum("overlay.Restriction_Operand", Restriction_Operand_name, Restriction_Operand_value)
 	proto.RegisterType((*QueryRequest)(nil), "overlay.QueryRequest")
+	proto.RegisterType((*QueryFilter)(nil), "overlay.QueryFilter")
 	proto.RegisterType((*QueryResponse)(nil), "overlay.QueryResponse")
+	proto.RegisterType((*ContactHints)(nil), "overlay.ContactHints")
 	proto.RegisterType((*PingRequest)(nil), "overlay.PingRequest")
 	proto.RegisterType((*PingResponse)(nil), "overlay.PingResponse")
 	proto.RegisterType((*InfoRequest)(nil), "overlay.InfoRequest")
 	proto.RegisterType((*InfoResponse)(nil), "overlay.InfoResponse")
+	proto.RegisterType((*LeaveRequest)(nil), "overlay.LeaveRequest")
+	proto.RegisterType((*LeaveResponse)(nil), "overlay.LeaveResponse")
 	proto.RegisterType((*GraphRequest)(nil), "overlay.GraphRequest")
 	proto.RegisterType((*GraphResponse)(nil), "overlay.GraphResponse")
 	proto.RegisterType((*Restriction)(nil), "overlay.Restriction")
@@ -482,40 +758,66 @@ func init() {
 func init() { proto.RegisterFile("overlay.proto", fileDescriptor_61fc82527fbe24ad) }
 
 var fileDescriptor_61fc82527fbe24ad = []byte{
-	// 514 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0xdf, 0x8a, 0xd3, 0x40,
-	0x14, 0xc6, 0x77, 0x9a, 0xfe, 0xf3, 0xf4, 0x0f, 0x71, 0xe8, 0x4a, 0x28, 0x0a, 0x21, 0xa0, 0x14,
-	0x94, 0x5c, 0x74, 0x65, 0x41, 0xef, 0x5c, 0x1b, 0x6b, 0x71, 0x59, 0xdd, 0x31, 0x28, 0xe8, 0x85,
-	0xa4, 0xcd, 0x18, 0x83, 0x75, 0x26, 0x4e, 0xa6, 0x85, 0xbc, 0x81, 0x8f, 0xe3, 0x1b, 0xf8, 0x5e,
-	0x5e, 0xc9, 0x4c, 0x26, 0x69, 0xac, 0x0a, 0x5e, 0x65, 0xce, 0xf9, 0x7e, 0x93, 0x9c, 0xef, 0xf0,
-	0x05, 0x46, 0x7c, 0x4f, 0xc5, 0x36, 0x2a, 0xfc, 0x4c, 0x70, 0xc9, 0x71, 0xcf, 0x94, 0x53, 0x48,
-	0x78, 0xc2, 0xcb, 0xe6, 0x14, 0x18, 0x8f, 0x69, 0x79, 0xf6, 0xbe, 0x21, 0x18, 0x5e, 0xef, 0xa8,
-	0x28, 0x08, 0xfd, 0xba, 0xa3, 0xb9, 0xc4, 0x1e, 0x74, 0x73, 0xca, 0x62, 0x2a, 0x1c, 0xe4, 0xa2,
-	0xd9, 0x60, 0x0e, 0xbe, 0xa6, 0xaf, 0x78, 0x4c, 0x89, 0x51, 0x14, 0x23, 0x23, 0x91, 0x50, 0xe9,
-	0xb4, 0xfe, 0x64, 0x4a, 0x05, 0x4f, 0xa0, 0xb3, 0x4d, 0xbf, 0xa4, 0xd2, 0xb1, 0x5c, 0x34, 0xb3,
-	0x48, 0x59, 0xe0, 0x29, 0xf4, 0xb3, 0x94, 0x25, 0xeb, 0x68, 0xf3, 0xd9, 0x69, 0xbb, 0x68, 0xd6,
-	0x27, 0x75, 0xed, 0xbd, 0x87, 0x91, 0x99, 0x24, 0xcf, 0x38, 0xcb, 0xe9, 0x7f, 0x8d, 0x72, 0x0f,
-	0xfa, 0xc2, 0xf0, 0x4e, 0xcb, 0xb5, 0x8e, 0xa8, 0x5a, 0xf3, 0x46, 0x30, 0x78, 0x95, 0xb2, 0xc4,
-	0xb8, 0xf4, 0xc6, 0x30, 0x2c, 0xcb, 0x83, 0xbc, 0x62, 0x1f, 0x79, 0x25, 0xff, 0x40, 0x30, 0x2c,
-	0xeb, 0x7a, 0x94, 0xb6, 0x2c, 0x32, 0xaa, 0xfd, 0x8e, 0xe7, 0xe3, 0xc3, 0x27, 0xc2, 0x22, 0xa3,
-	0x44, 0x6b, 0xd8, 0x87, 0x3e, 0xcf, 0xa8, 0x88, 0x24, 0x17, 0xda, 0xf4, 0x60, 0x8e, 0x0f, 0xdc,
-	0x4b, 0xa3, 0x90, 0x9a, 0x51, 0xfc, 0x26, 0xca, 0xa2, 0x4d, 0x2a, 0x0b, 0xbd, 0x8b, 0xdf, 0xf8,
-	0xa7, 0x46, 0x21, 0x35, 0x83, 0xef, 0x43, 0x6f, 0x4f, 0x45, 0x9e, 0x72, 0xe6, 0x74, 0x34, 0x7e,
-	0xf3, 0x80, 0xbf, 0x29, 0x05, 0x52, 0x11, 0xca, 0xe0, 0x52, 0x44, 0xd9, 0xa7, 0xca, 0xd1, 0x5d,
-	0x18, 0x99, 0xda, 0x38, 0x9a, 0x40, 0x27, 0x51, 0x0d, 0x07, 0xb9, 0xd6, 0x6c, 0x48, 0xca, 0xc2,
-	0xfb, 0x89, 0x60, 0x40, 0x68, 0x2e, 0x45, 0xba, 0x91, 0x29, 0x67, 0xf8, 0x51, 0xc3, 0x13, 0xd2,
-	0xde, 0xef, 0xf8, 0x55, 0xc2, 0x1a, 0x9c, 0xff, 0x17, 0x7b, 0xe7, 0xd0, 0xd3, 0x67, 0x16, 0x9b,
-	0xad, 0xdd, 0xfe, 0xf7, 0x4d, 0x16, 0x93, 0x0a, 0x56, 0x83, 0xed, 0xa3, 0xed, 0x8e, 0x56, 0xc1,
-	0xd1, 0x85, 0xf7, 0x10, 0xfa, 0xd5, 0x37, 0x70, 0x17, 0x5a, 0x97, 0xa1, 0x7d, 0xa2, 0x9e, 0xc1,
-	0xb5, 0x8d, 0xd4, 0x73, 0x19, 0xda, 0x2d, 0xdc, 0x03, 0xeb, 0x32, 0x0c, 0x6c, 0x4b, 0x1d, 0x96,
-	0x61, 0x60, 0xb7, 0xbd, 0x07, 0xd0, 0x33, 0xef, 0xc7, 0x18, 0xc6, 0xcf, 0x48, 0x10, 0x7c, 0xb8,
-	0x78, 0x72, 0xb5, 0x78, 0xbb, 0x5a, 0x84, 0xcf, 0xed, 0x13, 0x3c, 0x82, 0x1b, 0xba, 0xb7, 0x58,
-	0xbd, 0x7e, 0x61, 0xa3, 0xf9, 0x77, 0x04, 0x1d, 0xb5, 0xcc, 0x1c, 0x9f, 0x43, 0x47, 0x47, 0x11,
-	0x9f, 0xd6, 0x33, 0x37, 0x7f, 0x92, 0xe9, 0xad, 0xe3, 0xb6, 0x59, 0xea, 0x19, 0xb4, 0x55, 0xac,
-	0xf0, 0xa4, 0xd6, 0x1b, 0xa1, 0x9b, 0x9e, 0x1e, 0x75, 0xcd, 0xa5, 0xc7, 0x6a, 0xe5, 0x9a, 0x50,
-	0x91, 0x6b, 0xdc, 0x6d, 0x24, 0xb2, 0x71, 0xb7, 0x99, 0xcb, 0x8b, 0xf6, 0xbb, 0x56, 0xb6, 0x5e,
-	0x77, 0xf5, 0xbf, 0x7c, 0xf6, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xea, 0x68, 0x2a, 0x74, 0xfd, 0x03,
-	0x00, 0x00,
+	// 932 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xdd, 0x6e, 0x1b, 0x45,
+	0x14, 0xce, 0xfa, 0xdf, 0xc7, 0x3f, 0x71, 0x47, 0x49, 0xd9, 0x5a, 0x84, 0x58, 0x2b, 0xa0, 0x46,
+	0xad, 0x5c, 0x94, 0xa2, 0x20, 0xb8, 0x40, 0x6a, 0x6a, 0x27, 0xb5, 0x88, 0x5a, 0x3a, 0x31, 0xad,
+	0xc4, 0xcd, 0x6a, 0xed, 0x3d, 0x76, 0x46, 0xf6, 0xce, 0x2e, 0xb3, 0x63, 0x23, 0xf7, 0x1d, 0x78,
+	0x0f, 0xde, 0x82, 0x5b, 0x9e, 0x81, 0x8b, 0xbe, 0x05, 0x17, 0xc0, 0x0d, 0x9a, 0xd9, 0x59, 0x67,
+	0xe3, 0xa4, 0xa2, 0xbd, 0xda, 0x3d, 0xdf, 0xf9, 0xce, 0xcc, 0x9c, 0x6f, 0xbe, 0x39, 0xd0, 0x08,
+	0x57, 0x28, 0x16, 0xde, 0xba, 0x17, 0x89, 0x50, 0x86, 0xa4, 0x6c, 0xc2, 0x36, 0xcc, 0xc2, 0x59,
+	0x98, 0x80, 0x6d, 0xe0, 0xa1, 0x8f, 0xe6, 0xff, 0x93, 0x59, 0x18, 0xce, 0x16, 0xf8, 0x48, 0x47,
+	0xe3, 0xe5, 0xf4, 0x91, 0xbf, 0x14, 0x9e, 0x64, 0x21, 0x37, 0xf9, 0xc3, 0xed, 0xbc, 0x64, 0x01,
+	0xc6, 0xd2, 0x0b, 0xa2, 0x84, 0xe0, 0xfc, 0x6d, 0x41, 0xfd, 0xe5, 0x12, 0xc5, 0x9a, 0xe2, 0xcf,
+	0x4b, 0x8c, 0x25, 0x71, 0xa0, 0x14, 0x23, 0xf7, 0x51, 0xd8, 0x56, 0xc7, 0xea, 0xd6, 0x8e, 0xa0,
+	0xa7, 0xb7, 0x7b, 0x1e, 0xfa, 0x48, 0x4d, 0x46, 0x71, 0xa4, 0x27, 0x66, 0x28, 0xed, 0xdc, 0x4d,
+	0x4e, 0x92, 0x21, 0x7b, 0x50, 0x5c, 0xb0, 0x80, 0x49, 0x3b, 0xdf, 0xb1, 0xba, 0x79, 0x9a, 0x04,
+	0xa4, 0x0d, 0x95, 0x88, 0xf1, 0xd9, 0xd8, 0x9b, 0xcc, 0xed, 0x42, 0xc7, 0xea, 0x56, 0xe8, 0x26,
+	0x26, 0x07, 0x00, 0x93, 0xcb, 0x25, 0x9f, 0xbb, 0x31, 0x7b, 0x83, 0x76, 0x51, 0x97, 0x55, 0x35,
+	0x72, 0xc1, 0xde, 0x20, 0x71, 0xa0, 0x3e, 0xf1, 0x22, 0x6f, 0xcc, 0x16, 0x4c, 0x32, 0x8c, 0xed,
+	0x52, 0xc7, 0xea, 0x16, 0xe8, 0x35, 0x8c, 0x3c, 0x84, 0xd2, 0x94, 0x2d, 0x24, 0x0a, 0xbb, 0xac,
+	0x0f, 0xb6, 0xd7, 0x4b, 0xf5, 0xd4, 0x3d, 0x9e, 0xea, 0x1c, 0x35, 0x1c, 0xe7, 0x35, 0xd4, 0x32,
+	0x30, 0x71, 0xa0, 0x11, 0x30, 0xee, 0x4e, 0x05, 0xa2, 0xeb, 0xb3, 0x78, 0xae, 0x05, 0xc8, 0xd3,
+	0x5a, 0xc0, 0xf8, 0xa9, 0x40, 0xec, 0xb3, 0x78, 0x4e, 0x3e, 0x85, 0xa2, 0x5c, 0x47, 0x18, 0xdb,
+	0xb9, 0x4e, 0xbe, 0xdb, 0x3c, 0x6a, 0x5e, 0x35, 0x3e, 0x5a, 0x47, 0x48, 0x93, 0xa4, 0xf3, 0xaf,
+	0x05, 0x0d, 0x23, 0x6a, 0x1c, 0x85, 0x3c, 0xc6, 0xf7, 0x52, 0xf5, 0x73, 0xa8, 0x08, 0xc3, 0xd7,
+	0xcb, 0x5f, 0x67, 0x6d, 0x72, 0x37, 0x84, 0xc8, 0xdf, 0x22, 0xc4, 0x01, 0xc0, 0x2f, 0x9e, 0x08,
+	0x18, 0x9f, 0xb9, 0xcb, 0xc8, 0x28, 0x5d, 0x35, 0xc8, 0x8f, 0x11, 0xe9, 0x41, 0x41, 0x19, 0x41,
+	0x8b, 0x5c, 0x3b, 0x6a, 0xf7, 0x12, 0x97, 0xf4, 0x52, 0x97, 0xf4, 0x46, 0xa9, 0x4b, 0xa8, 0xe6,
+	0x91, 0x07, 0x50, 0xbc, 0x64, 0x5c, 0x26, 0xa2, 0xd7, 0x8e, 0xf6, 0x37, 0xb2, 0x3e, 0x0d, 0xb9,
+	0xf4, 0x26, 0xf2, 0x99, 0x4a, 0xd2, 0x84, 0xe3, 0xfc, 0x65, 0x41, 0x3d, 0x8b, 0x93, 0x01, 0xdc,
+	0x99, 0x5c, 0xe2, 0x64, 0xee, 0x32, 0xee, 0x32, 0x2e, 0x51, 0xac, 0xbc, 0x85, 0xd1, 0xe1, 0xde,
+	0x8d, 0xad, 0xfb, 0xc6, 0xc0, 0x74, 0x57, 0xd7, 0x0c, 0xf9, 0xd0, 0x54, 0xa8, 0x9e, 0x3c, 0xdf,
+	0x17, 0x18, 0xc7, 0x6e, 0x38, 0xd7, 0xce, 0xab, 0xd0, 0xaa, 0x41, 0x5e, 0xcc, 0xc9, 0x7d, 0xd8,
+	0x0d, 0x18, 0x67, 0xc1, 0x32, 0x70, 0x57, 0x28, 0x62, 0x16, 0x72, 0xad, 0x4c, 0x95, 0x36, 0x0d,
+	0xfc, 0x2a, 0x41, 0xc9, 0x2b, 0xb8, 0xb7, 0x45, 0x74, 0x71, 0x3a, 0xc5, 0x89, 0x64, 0x2b, 0xb4,
+	0x0b, 0xff, 0xab, 0xc8, 0x47, 0xd7, 0x97, 0x1b, 0xa4, 0xa5, 0x4e, 0x03, 0x6a, 0x3f, 0x30, 0x3e,
+	0x33, 0x0f, 0xc9, 0xf9, 0xd5, 0x82, 0x7a, 0x12, 0xbf, 0xe3, 0xde, 0xac, 0x5b, 0xee, 0xed, 0x0b,
+	0x68, 0x85, 0xe3, 0x18, 0xc5, 0x0a, 0x7d, 0xd7, 0xb4, 0xa6, 0x3b, 0xad, 0xd2, 0xdd, 0x14, 0x7f,
+	0x92, 0xc0, 0x9b, 0x3b, 0xcc, 0xbf, 0xdf, 0x1d, 0xaa, 0xe3, 0x0d, 0xf9, 0x34, 0x4c, 0x8f, 0xf7,
+	0xbb, 0x05, 0xf5, 0x24, 0xde, 0x1c, 0xaf, 0xa0, 0xdc, 0xab, 0xb7, 0xbb, 0xe9, 0x6c, 0x9d, 0x23,
+	0x3d, 0xa8, 0x84, 0x11, 0x0a, 0x4f, 0x86, 0xc2, 0xec, 0x4b, 0xae, 0x78, 0x2f, 0x4c, 0x86, 0x6e,
+	0x38, 0x8a, 0xaf, 0xda, 0x9b, 0x30, 0xb9, 0xb6, 0x0b, 0xdb, 0xfc, 0xa7, 0x26, 0x43, 0x37, 0x1c,
+	0xf2, 0x00, 0xca, 0xe9, 0xdd, 0x25, 0xd6, 0xbc, 0x73, 0x45, 0x37, 0x7a, 0xd3, 0x94, 0xe1, 0x7c,
+	0x0d, 0xf5, 0x73, 0xf4, 0x56, 0x98, 0x4e, 0xae, 0xfb, 0x50, 0x56, 0x64, 0x97, 0xf9, 0x5a, 0xda,
+	0xfa, 0x49, 0xf3, 0x8f, 0xb7, 0x87, 0x3b, 0x7f, 0xbe, 0x3d, 0x2c, 0xa9, 0xf2, 0x61, 0x9f, 0x96,
+	0x54, 0x7a, 0xe8, 0x3b, 0xbb, 0xd0, 0x30, 0x85, 0x49, 0xeb, 0x4e, 0x13, 0xea, 0x67, 0xc2, 0x8b,
+	0x2e, 0x53, 0x6d, 0x3e, 0x83, 0x86, 0x89, 0x8d, 0x36, 0x7b, 0x50, 0x9c, 0x29, 0xc0, 0xb6, 0x3a,
+	0xf9, 0x6e, 0x9d, 0x26, 0x81, 0xf3, 0x8f, 0x05, 0x35, 0x8a, 0xb1, 0x14, 0x6c, 0xa2, 0x1c, 0x4b,
+	0xbe, 0xc9, 0xa8, 0x63, 0x69, 0x15, 0x0f, 0x36, 0x0f, 0x25, 0xc3, 0xeb, 0xdd, 0x22, 0xd4, 0x31,
+	0x94, 0xf5, 0x3f, 0xf7, 0x8d, 0xfe, 0x1f, 0xbf, 0xbb, 0x92, 0xfb, 0x34, 0x25, 0xab, 0x83, 0xad,
+	0xbc, 0xc5, 0x12, 0xd3, 0x29, 0xab, 0x03, 0xe7, 0x2b, 0xa8, 0xa4, 0x7b, 0x90, 0x12, 0xe4, 0xce,
+	0x47, 0xad, 0x1d, 0xf5, 0x1d, 0xbc, 0x6c, 0x59, 0xea, 0x7b, 0x36, 0x6a, 0xe5, 0x48, 0x19, 0xf2,
+	0xe7, 0xa3, 0x41, 0x2b, 0xaf, 0x7e, 0xce, 0x46, 0x83, 0x56, 0xc1, 0x79, 0x08, 0x65, 0xb3, 0x3e,
+	0x21, 0xd0, 0x3c, 0xa5, 0x83, 0x81, 0x7b, 0xf2, 0xe4, 0x79, 0xff, 0xf5, 0xb0, 0x3f, 0x7a, 0xd6,
+	0xda, 0x21, 0x0d, 0xa8, 0x6a, 0xac, 0x3f, 0xbc, 0xf8, 0xbe, 0x65, 0x1d, 0xfd, 0x96, 0x83, 0xa2,
+	0xd2, 0x35, 0x26, 0xc7, 0x50, 0xd4, 0xc3, 0x8e, 0xec, 0x5f, 0x9f, 0xb6, 0x46, 0xcd, 0xf6, 0xdd,
+	0x6d, 0xd8, 0x88, 0xfa, 0x9d, 0x19, 0xbf, 0x17, 0x52, 0xa0, 0x17, 0x7c, 0x60, 0xf5, 0x97, 0x16,
+	0x79, 0x0c, 0x05, 0xf5, 0xbe, 0xc8, 0xd5, 0x90, 0xcf, 0x3c, 0xbf, 0xf6, 0xfe, 0x16, 0x6a, 0x36,
+	0xfd, 0x16, 0x6a, 0x86, 0xa1, 0xcc, 0x9f, 0xa9, 0xcd, 0xbc, 0x8d, 0xf6, 0xfe, 0x16, 0x6a, 0x6a,
+	0x8f, 0xa1, 0xa8, 0x7d, 0x93, 0x39, 0x6a, 0xd6, 0x80, 0xed, 0xbb, 0xdb, 0x70, 0x52, 0x77, 0x52,
+	0xf8, 0x29, 0x17, 0x8d, 0xc7, 0x25, 0xfd, 0x32, 0x1f, 0xff, 0x37, 0x00, 0xaf, 0xdc, 0x5b, 0x08,
+	0xe3, 0x07, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -531,8 +833,10 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type NodesClient interface {
 	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+	QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Nodes_QueryStreamClient, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
 	RequestInfo(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	Leave(ctx context.Context, in *LeaveRequest, opts ...grpc.CallOption) (*LeaveResponse, error)
 }
 
 type nodesClient struct {
@@ -552,6 +856,38 @@ func (c *nodesClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.
 	return out, nil
 }
 
+func (c *nodesClient) QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Nodes_QueryStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Nodes_serviceDesc.Streams[0], "/overlay.Nodes/QueryStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodesQueryStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Nodes_QueryStreamClient interface {
+	Recv() (*QueryResponse, error)
+	grpc.ClientStream
+}
+
+type nodesQueryStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodesQueryStreamClient) Recv() (*QueryResponse, error) {
+	m := new(QueryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *nodesClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
 	out := new(PingResponse)
 	err := c.cc.Invoke(ctx, "/overlay.Nodes/Ping", in, out, opts...)
@@ -570,11 +906,22 @@ func (c *nodesClient) RequestInfo(ctx context.Context, in *InfoRequest, opts ...
 	return out, nil
 }
 
+func (c *nodesClient) Leave(ctx context.Context, in *LeaveRequest, opts ...grpc.CallOption) (*LeaveResponse, error) {
+	out := new(LeaveResponse)
+	err := c.cc.Invoke(ctx, "/overlay.Nodes/Leave", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodesServer is the server API for Nodes service.
 type NodesServer interface {
 	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+	QueryStream(*QueryRequest, Nodes_QueryStreamServer) error
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
 	RequestInfo(context.Context, *InfoRequest) (*InfoResponse, error)
+	Leave(context.Context, *LeaveRequest) (*LeaveResponse, error)
 }
 
 func RegisterNodesServer(s *grpc.Server, srv NodesServer) {
@@ -599,6 +946,27 @@ func _Nodes_Query_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Nodes_QueryStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodesServer).QueryStream(m, &nodesQueryStreamServer{stream})
+}
+
+type Nodes_QueryStreamServer interface {
+	Send(*QueryResponse) error
+	grpc.ServerStream
+}
+
+type nodesQueryStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodesQueryStreamServer) Send(m *QueryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Nodes_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PingRequest)
 	if err := dec(in); err != nil {
@@ -635,6 +1003,24 @@ func _Nodes_RequestInfo_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Nodes_Leave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodesServer).Leave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/overlay.Nodes/Leave",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodesServer).Leave(ctx, req.(*LeaveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Nodes_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "overlay.Nodes",
 	HandlerType: (*NodesServer)(nil),
@@ -651,7 +1037,17 @@ var _Nodes_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RequestInfo",
 			Handler:    _Nodes_RequestInfo_Handler,
 		},
+		{
+			MethodName: "Leave",
+			Handler:    _Nodes_Leave_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QueryStream",
+			Handler:       _Nodes_QueryStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "overlay.proto",
 }
@@ -9,9 +9,10 @@ import (
 
 // Config holds tls configuration parameters
 type Config struct {
-	RevocationDBURL     string `default:"bolt://$CONFDIR/revocations.db" help:"url for revocation database (e.g. bolt://some.db OR redis://127.0.0.1:6378?db=2&password=abc123)"`
+	RevocationDBURL     string `default:"bolt://$CONFDIR/revocations.db" help:"url for revocation database (e.g. bolt://some.db OR redis://127.0.0.1:6378?db=2&password=abc123)" secret:"true"`
 	PeerCAWhitelistPath string `help:"path to the CA cert whitelist (peer identities must be signed by one these to be verified). this will override the default peer whitelist"`
 	UsePeerCAWhitelist  bool   `default:"false" help:"if true, uses peer ca whitelist checking"`
-	PeerIDVersions      string `default:"latest" help:"identity version(s) the server will be allowed to talk to"`
+	PeerIDVersions      string `default:"latest" help:"identity version(s) the server will be allowed to talk to, such as 0,2-3, latest or *"`
+	PeerIDMinDifficulty uint   `default:"0" help:"minimum difficulty of the node IDs connected to by node ID, zero disables the check"`
 	Extensions          extensions.Config
 }
@@ -16,6 +16,7 @@ import (
 	"storj.io/storj/pkg/peertls"
 	"storj.io/storj/pkg/peertls/extensions"
 	"storj.io/storj/pkg/pkcrypto"
+	"storj.io/storj/pkg/storj"
 )
 
 var (
@@ -30,6 +31,7 @@ type Options struct {
 	Ident             *identity.FullIdentity
 	RevDB             *identity.RevocationDB
 	PeerCAWhitelist   []*x509.Certificate
+	PeerIDVersions    storj.IDVersionSet
 	VerificationFuncs *VerificationFuncs
 	Cert              *tls.Certificate
 }
@@ -105,6 +107,14 @@ func (opts *Options) configure() (err error) {
 		}
 	}
 
+	if opts.Config.PeerIDVersions != "" {
+		opts.PeerIDVersions, err = storj.ParseIDVersions(opts.Config.PeerIDVersions)
+		if err != nil {
+			return Error.Wrap(err)
+		}
+		opts.VerificationFuncs.Add(verifyIDVersion(opts.PeerIDVersions))
+	}
+
 	opts.handleExtensions(extensions.AllHandlers)
 
 	opts.Cert, err = peertls.TLSCert(opts.Ident.RawChain(), opts.Ident.Leaf, opts.Ident.Key)
@@ -7,6 +7,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 
+	"github.com/zeebo/errs"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
@@ -15,6 +16,10 @@ import (
 	"storj.io/storj/pkg/storj"
 )
 
+// ErrSelfDial is the class of errors for connections between a node and itself,
+// such as when a node dials its own address under a stale node ID.
+var ErrSelfDial = errs.Class("connected to self")
+
 // ServerOption returns a grpc `ServerOption` for incoming connections
 // to the node with this full identity.
 func (opts *Options) ServerOption() grpc.ServerOption {
@@ -34,7 +39,7 @@ func (opts *Options) DialOption(id storj.NodeID) (grpc.DialOption, error) {
 
 // DialUnverifiedIDOption returns a grpc `DialUnverifiedIDOption`
 func (opts *Options) DialUnverifiedIDOption() grpc.DialOption {
-	tlsConfig := opts.tlsConfig(false)
+	tlsConfig := opts.UnverifiedClientTLSConfig()
 	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
 }
 
@@ -45,13 +50,30 @@ func (opts *Options) ServerTLSConfig() *tls.Config {
 
 // ClientTLSConfig returns a TSLConfig for use as a client in handshaking with a peer.
 func (opts *Options) ClientTLSConfig(id storj.NodeID) *tls.Config {
-	return opts.tlsConfig(false, verifyIdentity(id))
+	return opts.tlsConfig(false, verifyIdentity(id, opts.Config.PeerIDMinDifficulty))
+}
+
+// UnverifiedClientTLSConfig returns a TLSConfig for use as a client in
+// handshaking with a peer of any ID.
+func (opts *Options) UnverifiedClientTLSConfig() *tls.Config {
+	return opts.tlsConfig(false)
+}
+
+// SelfHandshakeTLSConfigs returns the server and client TLS configurations of
+// a handshake of this node with itself, such as to check its setup. Both
+// verify the certificate chain of this node like the one of any other peer.
+func (opts *Options) SelfHandshakeTLSConfigs() (server, client *tls.Config) {
+	self := *opts
+	// connections to self are otherwise rejected
+	self.Ident = nil
+	return self.tlsConfig(true), self.tlsConfig(false, verifyIdentity(opts.Ident.ID, opts.Config.PeerIDMinDifficulty))
 }
 
 func (opts *Options) tlsConfig(isServer bool, verificationFuncs ...peertls.PeerCertVerificationFunc) *tls.Config {
 	verificationFuncs = append(
 		[]peertls.PeerCertVerificationFunc{
 			peertls.VerifyPeerCertChains,
+			opts.verifyNotSelf(isServer),
 		},
 		verificationFuncs...,
 	)
@@ -84,7 +106,19 @@ func (opts *Options) tlsConfig(isServer bool, verificationFuncs ...peertls.PeerC
 	return config
 }
 
-func verifyIdentity(id storj.NodeID) peertls.PeerCertVerificationFunc {
+// ErrPeerIDMismatch is the class of errors for handshakes with a peer having
+// another ID than requested.
+var ErrPeerIDMismatch = errs.Class("peer ID did not match requested ID")
+
+// IsPeerIDMismatch returns whether err means that the peer of a handshake
+// had another ID than requested.
+func IsPeerIDMismatch(err error) bool {
+	return ErrPeerIDMismatch.Has(err)
+}
+
+// verifyIdentity rejects peers other than the node with id, and with a
+// difficulty below minDifficulty.
+func verifyIdentity(id storj.NodeID, minDifficulty uint) peertls.PeerCertVerificationFunc {
 	return func(_ [][]byte, parsedChains [][]*x509.Certificate) (err error) {
 		defer mon.TaskNamed("verifyIdentity")(nil)(&err)
 		peer, err := identity.PeerIdentityFromChain(parsedChains[0])
@@ -92,10 +126,56 @@ func verifyIdentity(id storj.NodeID) peertls.PeerCertVerificationFunc {
 			return err
 		}
 
-		if peer.ID.String() != id.String() {
-			return Error.New("peer ID did not match requested ID")
+		if peer.ID != id {
+			return Error.Wrap(ErrPeerIDMismatch.New("requested %s, got %s", id, peer.ID))
+		}
+
+		if minDifficulty > 0 {
+			difficulty, err := peer.ID.Difficulty()
+			if err != nil {
+				return Error.Wrap(err)
+			}
+			if uint(difficulty) < minDifficulty {
+				return Error.New("peer ID difficulty %d is below the minimum of %d", difficulty, minDifficulty)
+			}
 		}
 
 		return nil
 	}
 }
+
+// verifyIDVersion rejects peers with an identity version outside of versions.
+// The version of the node ID is the one of the CA certificate, V0 for legacy
+// certificates without a version extension.
+func verifyIDVersion(versions storj.IDVersionSet) peertls.PeerCertVerificationFunc {
+	return func(_ [][]byte, parsedChains [][]*x509.Certificate) error {
+		peer, err := identity.PeerIdentityFromChain(parsedChains[0])
+		if err != nil {
+			return err
+		}
+		if number := peer.ID.VersionNumber(); !versions.Allows(number) {
+			return Error.New("peer %s has identity version %d, allowed versions are %q", peer.ID, number, versions)
+		}
+		return nil
+	}
+}
+
+// verifyNotSelf rejects peers with the identity of this node.
+func (opts *Options) verifyNotSelf(isServer bool) peertls.PeerCertVerificationFunc {
+	return func(_ [][]byte, parsedChains [][]*x509.Certificate) error {
+		if opts.Ident == nil {
+			return nil
+		}
+		peer, err := identity.PeerIdentityFromChain(parsedChains[0])
+		if err != nil {
+			return err
+		}
+		if peer.ID != opts.Ident.ID {
+			return nil
+		}
+		if isServer {
+			mon.Counter("self_dial_inbound").Inc(1)
+		}
+		return ErrSelfDial.New("peer %s is this node", peer.ID)
+	}
+}
@@ -4,12 +4,15 @@
 package tlsopts_test
 
 import (
+	"crypto/tls"
 	"crypto/x509"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
 
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/testidentity"
@@ -27,7 +30,7 @@ func TestVerifyIdentity_success(t *testing.T) {
 		ident, err := testidentity.PregeneratedIdentity(i, storj.LatestIDVersion())
 		require.NoError(t, err)
 
-		err = tlsopts.VerifyIdentity(ident.ID)(nil, identity.ToChains(ident.Chain()))
+		err = tlsopts.VerifyIdentity(ident.ID, 0)(nil, identity.ToChains(ident.Chain()))
 		assert.NoError(t, err)
 	}
 }
@@ -37,7 +40,7 @@ func TestVerifyIdentity_success_signed(t *testing.T) {
 		ident, err := testidentity.PregeneratedSignedIdentity(i, storj.LatestIDVersion())
 		require.NoError(t, err)
 
-		err = tlsopts.VerifyIdentity(ident.ID)(nil, identity.ToChains(ident.Chain()))
+		err = tlsopts.VerifyIdentity(ident.ID, 0)(nil, identity.ToChains(ident.Chain()))
 		assert.NoError(t, err)
 	}
 }
@@ -60,7 +63,7 @@ func TestVerifyIdentity_error(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.test, func(t *testing.T) {
-			err := tlsopts.VerifyIdentity(c.nodeID)(nil, identity.ToChains(ident.Chain()))
+			err := tlsopts.VerifyIdentity(c.nodeID, 0)(nil, identity.ToChains(ident.Chain()))
 			assert.Error(t, err)
 		})
 	}
@@ -153,3 +156,66 @@ func TestExtensionMap_HandleExtensions_error(t *testing.T) {
 		assert.Errorf(t, err, extensions.ErrRevocationTimestamp.Error())
 	})
 }
+
+func TestVerifyIDVersion(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	versionV1, unregister := testidentity.RegisterIDVersion(1)
+	defer unregister()
+
+	serverIdent, err := identity.NewFullIdentity(ctx, identity.NewCAOptions{
+		VersionNumber: versionV1.Number,
+		Difficulty:    8,
+		Concurrency:   4,
+	})
+	require.NoError(t, err)
+	serverOpts, err := tlsopts.NewOptions(serverIdent, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	clientIdent, err := testidentity.PregeneratedIdentity(0, storj.IDVersions[storj.V0])
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ctx.Check(listener.Close)
+
+	for _, testcase := range []struct {
+		versions string
+		allowed  bool
+	}{
+		{"0", false},
+		{"0-1", true},
+		{"*", true},
+	} {
+		clientOpts, err := tlsopts.NewOptions(clientIdent, tlsopts.Config{PeerIDVersions: testcase.versions})
+		require.NoError(t, err)
+
+		var group errgroup.Group
+		group.Go(func() error {
+			serverConn, err := listener.Accept()
+			if err != nil {
+				return err
+			}
+			defer func() { _ = serverConn.Close() }()
+			return tls.Server(serverConn, serverOpts.ServerTLSConfig()).Handshake()
+		})
+
+		clientConn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		err = tls.Client(clientConn, clientOpts.ClientTLSConfig(serverIdent.ID)).Handshake()
+		serverErr := group.Wait()
+		_ = clientConn.Close()
+
+		if testcase.allowed {
+			assert.NoError(t, err, testcase.versions)
+			assert.NoError(t, serverErr, testcase.versions)
+			continue
+		}
+		require.Error(t, err, testcase.versions)
+		assert.Contains(t, err.Error(), "identity version 1")
+	}
+
+	_, err = tlsopts.NewOptions(clientIdent, tlsopts.Config{PeerIDVersions: "0-"})
+	assert.Error(t, err)
+}
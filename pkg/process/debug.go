@@ -19,8 +19,17 @@ import (
 
 var (
 	debugAddr = flag.String("debug.addr", "127.0.0.1:0", "address to listen on for debug endpoints")
+
+	// debugMux serves the debug endpoints, including the ones added by commands
+	debugMux = http.NewServeMux()
 )
 
+// DebugHandle adds handler for pattern to the debug endpoints, patterns
+// should start with /debug/.
+func DebugHandle(pattern string, handler http.Handler) {
+	debugMux.Handle(pattern, handler)
+}
+
 func init() {
 	// zero out the http.DefaultServeMux net/http/pprof so unhelpfully
 	// side-effected.
@@ -28,7 +37,7 @@ func init() {
 }
 
 func initDebug(logger *zap.Logger, r *monkit.Registry) (err error) {
-	var mux http.ServeMux
+	mux := debugMux
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
 	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
@@ -37,6 +46,9 @@ func initDebug(logger *zap.Logger, r *monkit.Registry) (err error) {
 
 	mux.Handle("/version/", http.StripPrefix("/version", http.HandlerFunc(version.DebugHandler)))
 	mux.Handle("/mon/", http.StripPrefix("/mon", present.HTTP(r)))
+	if *debugPrometheus {
+		mux.Handle("/metrics", PrometheusHandler(r))
+	}
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = fmt.Fprintln(w, "OK")
 	})
@@ -46,7 +58,7 @@ func initDebug(logger *zap.Logger, r *monkit.Registry) (err error) {
 	}
 	go func() {
 		logger.Debug(fmt.Sprintf("debug server listening on %s", ln.Addr().String()))
-		err := (&http.Server{Handler: &mux}).Serve(ln)
+		err := (&http.Server{Handler: mux}).Serve(ln)
 		if err != nil {
 			logger.Error("debug server died", zap.Error(err))
 		}
@@ -173,15 +173,31 @@ func cleanup(cmd *cobra.Command) {
 		vip.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
 		vip.AutomaticEnv()
 
+		var renamed map[string]string
+		var lastRunErr error
 		cfgFlag := cmd.Flags().Lookup("config-dir")
 		if cfgFlag != nil && cfgFlag.Value.String() != "" {
-			path := filepath.Join(os.ExpandEnv(cfgFlag.Value.String()), "config.yaml")
+			dir := os.ExpandEnv(cfgFlag.Value.String())
+			path := filepath.Join(dir, "config.yaml")
 			if cmd.Annotations["type"] != "setup" || fileExists(path) {
 				vip.SetConfigFile(path)
 				err = vip.ReadInConfig()
 				if err != nil {
 					return err
 				}
+
+				var previous version.SemVer
+				previous, _, lastRunErr = version.LoadLastRun(dir)
+				if lastRunErr != nil {
+					// a broken record shouldn't stop the process, treat it as no record
+					previous = version.SemVer{}
+				}
+				renamed, err = migrateConfig(vip, previous, ConfigMigrations, func(key string) bool {
+					return cmd.Flags().Lookup(key) != nil
+				})
+				if err != nil {
+					return err
+				}
 			}
 		}
 
@@ -189,6 +205,9 @@ func cleanup(cmd *cobra.Command) {
 		var brokenKeys []string
 		var brokenVals []string
 		for _, key := range vip.AllKeys() {
+			if _, ok := renamed[key]; ok {
+				continue
+			}
 			if cmd.Flags().Lookup(key) == nil {
 				// flag couldn't be found
 				brokenKeys = append(brokenKeys, key)
@@ -223,7 +242,13 @@ func cleanup(cmd *cobra.Command) {
 		defer zap.ReplaceGlobals(logger)()
 		defer zap.RedirectStdLog(logger)()
 
-		// okay now that logging is working, inform about the broken keys
+		// okay now that logging is working, inform about the renamed and broken keys
+		if lastRunErr != nil {
+			logger.Warn("Unable to load the last run version, treating the configuration as the oldest version", zap.Error(lastRunErr))
+		}
+		for _, key := range sortedKeys(renamed) {
+			logger.Sugar().Warnf("Configuration file key %s has been renamed to %s, please update the configuration", key, renamed[key])
+		}
 		if cmd.Annotations["type"] != "helper" {
 			for _, key := range brokenKeys {
 				logger.Sugar().Infof("Invalid configuration file key: %s", key)
@@ -284,7 +309,7 @@ func cmdVersion(cmd *cobra.Command, args []string) (err error) {
 		fmt.Println("Development build")
 	}
 
-	if version.Build.Version != (version.SemVer{}) {
+	if !version.Build.Version.IsZero() {
 		fmt.Println("Version:", version.Build.Version.String())
 	}
 	if !version.Build.Timestamp.IsZero() {
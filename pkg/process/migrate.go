@@ -0,0 +1,85 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package process
+
+import (
+	"sort"
+
+	"github.com/spf13/viper"
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/internal/version"
+)
+
+// ConfigMigration describes the configuration keys that were renamed or
+// removed after the versions in a range.
+type ConfigMigration struct {
+	// Versions are the previously run versions still using the old keys
+	Versions version.Range
+	// Renamed maps old keys to their new names
+	Renamed map[string]string
+	// Removed maps removed keys to the key replacing them, empty when there is none
+	Removed map[string]string
+}
+
+// ConfigMigrations are applied to configuration files last used by an older
+// version. A file without a recorded version is treated as the oldest version.
+var ConfigMigrations = []ConfigMigration{
+	{
+		// only satellites keep a routing table store
+		Versions: version.Range{Max: version.SemVer{Minor: 12}},
+		Renamed: map[string]string{
+			"kademlia.store": "routing-table.store",
+		},
+	},
+}
+
+// migrateConfig maps the renamed keys in vip to their new names and fails on
+// removed keys, for the migrations applying to the previously run version.
+// Keys renamed to a key the process doesn't know, as reported by known, are
+// left alone. It returns the renamed keys mapped to their new names.
+func migrateConfig(vip *viper.Viper, previous version.SemVer, migrations []ConfigMigration, known func(key string) bool) (renamed map[string]string, err error) {
+	renamed = map[string]string{}
+	for _, migration := range migrations {
+		if !migration.Versions.Contains(previous) {
+			continue
+		}
+
+		// old keys aren't flags anymore, so they can only be set by the configuration
+		for _, key := range sortedKeys(migration.Removed) {
+			if !vip.IsSet(key) {
+				continue
+			}
+			if replacement := migration.Removed[key]; replacement != "" {
+				return nil, errs.New("configuration key %q was removed, use %q instead", key, replacement)
+			}
+			return nil, errs.New("configuration key %q was removed", key)
+		}
+
+		for _, key := range sortedKeys(migration.Renamed) {
+			if !vip.IsSet(key) {
+				continue
+			}
+			newKey := migration.Renamed[key]
+			if !known(newKey) {
+				continue
+			}
+			if !vip.InConfig(newKey) {
+				vip.Set(newKey, vip.Get(key))
+			}
+			renamed[key] = newKey
+		}
+	}
+	return renamed, nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package process
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/version"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	migrations := []ConfigMigration{
+		{
+			Versions: version.Range{Max: version.SemVer{Minor: 12}},
+			Renamed: map[string]string{
+				"kademlia.alpha": "kademlia.concurrency",
+			},
+			Removed: map[string]string{
+				"kademlia.old-setting": "kademlia.new-setting",
+				"kademlia.gone":        "",
+			},
+		},
+	}
+
+	known := func(string) bool { return true }
+
+	load := func(t *testing.T, config string) *viper.Viper {
+		vip := viper.New()
+		vip.SetConfigType("yaml")
+		require.NoError(t, vip.ReadConfig(strings.NewReader(config)))
+		return vip
+	}
+
+	t.Run("renamed", func(t *testing.T) {
+		vip := load(t, "kademlia.alpha: 7\nkademlia.bootstrap-addr: \"127.0.0.1:8888\"\n")
+
+		renamed, err := migrateConfig(vip, version.SemVer{Minor: 11}, migrations, known)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"kademlia.alpha": "kademlia.concurrency"}, renamed)
+		assert.Equal(t, 7, vip.GetInt("kademlia.concurrency"))
+		assert.Equal(t, "127.0.0.1:8888", vip.GetString("kademlia.bootstrap-addr"))
+	})
+
+	t.Run("new key wins", func(t *testing.T) {
+		vip := load(t, "kademlia.alpha: 7\nkademlia.concurrency: 3\n")
+
+		renamed, err := migrateConfig(vip, version.SemVer{}, migrations, known)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"kademlia.alpha": "kademlia.concurrency"}, renamed)
+		assert.Equal(t, 3, vip.GetInt("kademlia.concurrency"))
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		vip := load(t, "kademlia.old-setting: true\n")
+
+		_, err := migrateConfig(vip, version.SemVer{Minor: 11}, migrations, known)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"kademlia.old-setting" was removed, use "kademlia.new-setting" instead`)
+
+		vip = load(t, "kademlia.gone: 1\n")
+		_, err = migrateConfig(vip, version.SemVer{Minor: 11}, migrations, known)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"kademlia.gone" was removed`)
+	})
+
+	t.Run("already migrated", func(t *testing.T) {
+		vip := load(t, "kademlia.alpha: 7\nkademlia.old-setting: true\n")
+
+		renamed, err := migrateConfig(vip, version.SemVer{Minor: 12}, migrations, known)
+		require.NoError(t, err)
+		assert.Empty(t, renamed)
+		assert.False(t, vip.IsSet("kademlia.concurrency"))
+	})
+
+	t.Run("unknown new key", func(t *testing.T) {
+		vip := load(t, "kademlia.alpha: 7\n")
+
+		renamed, err := migrateConfig(vip, version.SemVer{Minor: 11}, migrations, func(string) bool { return false })
+		require.NoError(t, err)
+		assert.Empty(t, renamed)
+		assert.False(t, vip.IsSet("kademlia.concurrency"))
+	})
+}
+
+func TestConfigMigrations(t *testing.T) {
+	known := func(key string) bool { return key == "routing-table.store" }
+
+	vip := viper.New()
+	vip.SetConfigType("yaml")
+	require.NoError(t, vip.ReadConfig(strings.NewReader("kademlia.store: memory\n")))
+
+	// a configuration without a recorded version is treated as the oldest version
+	renamed, err := migrateConfig(vip, version.SemVer{}, ConfigMigrations, known)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kademlia.store": "routing-table.store"}, renamed)
+	assert.Equal(t, "memory", vip.GetString("routing-table.store"))
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package process
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/version"
+)
+
+var (
+	debugPrometheus = flag.Bool("debug.prometheus", false, "expose selected metrics in Prometheus format on /metrics of the debug endpoint")
+)
+
+const (
+	transportScope = "storj.io/storj/pkg/transport"
+	serverScope    = "storj.io/storj/pkg/server"
+	kademliaScope  = "storj.io/storj/pkg/kademlia"
+)
+
+// PrometheusHandler returns a handler exposing a fixed set of monkit series
+// in the Prometheus text format.
+//
+// The metric names are part of the public interface and must not be changed:
+//
+//	storj_build_info{version,release}                    gauge, always 1
+//	storj_transport_dials_total{target,result}           counter of dials
+//	storj_transport_dial_duration_seconds{target}        summary of successful dials
+//	storj_transport_deadline_shed_total                  counter of shed requests
+//	storj_kademlia_routing_table_size                    gauge of nodes in the routing table
+//	storj_kademlia_refreshes_total{result}               counter of bucket refreshes
+//
+// target is either "node" or "address" and result is either "success" or
+// "failure". No labels identify peers, which keeps the cardinality bounded.
+// The dial series mirror the existing DialNode and DialAddress monkit funcs,
+// so these must keep their names.
+func PrometheusHandler(r *monkit.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		writePrometheus(&buf, r)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// writePrometheus writes the exposed metrics of r to buf.
+func writePrometheus(buf *bytes.Buffer, r *monkit.Registry) {
+	transport := r.ScopeNamed(transportScope)
+	server := r.ScopeNamed(serverScope)
+	kademlia := r.ScopeNamed(kademliaScope)
+
+	build := version.Build
+	writeFamily(buf, "storj_build_info", "gauge", "build information of the running binary")
+	writeSample(buf, "storj_build_info", 1,
+		"version", build.Version.String(),
+		"release", strconv.FormatBool(build.Release))
+
+	dials := map[string]*monkit.Func{
+		"node":    transport.FuncNamed("DialNode"),
+		"address": transport.FuncNamed("DialAddress"),
+	}
+	targets := []string{"node", "address"}
+
+	writeFamily(buf, "storj_transport_dials_total", "counter", "number of dials to other peers")
+	for _, target := range targets {
+		dial := dials[target]
+		writeSample(buf, "storj_transport_dials_total", float64(dial.Success()), "target", target, "result", "success")
+		writeSample(buf, "storj_transport_dials_total", float64(failures(dial)), "target", target, "result", "failure")
+	}
+
+	writeFamily(buf, "storj_transport_dial_duration_seconds", "summary", "duration of successful dials")
+	for _, target := range targets {
+		times := dials[target].SuccessTimes()
+		writeSample(buf, "storj_transport_dial_duration_seconds_sum", times.Sum.Seconds(), "target", target)
+		writeSample(buf, "storj_transport_dial_duration_seconds_count", float64(times.Count), "target", target)
+	}
+
+	writeFamily(buf, "storj_transport_deadline_shed_total", "counter", "number of requests aborted before their deadline could be met")
+	writeSample(buf, "storj_transport_deadline_shed_total", float64(server.Counter("deadline_shed").Current()))
+
+	writeFamily(buf, "storj_kademlia_routing_table_size", "gauge", "number of nodes in the routing table")
+	writeSample(buf, "storj_kademlia_routing_table_size", recent(kademlia.IntVal("routing_table_size")))
+
+	refresh := kademlia.FuncNamed("refresh")
+	writeFamily(buf, "storj_kademlia_refreshes_total", "counter", "number of routing table bucket refreshes")
+	writeSample(buf, "storj_kademlia_refreshes_total", float64(refresh.Success()), "result", "success")
+	writeSample(buf, "storj_kademlia_refreshes_total", float64(failures(refresh)), "result", "failure")
+}
+
+// failures returns the number of failed calls to f.
+func failures(f *monkit.Func) int64 {
+	total := f.Panics()
+	for _, count := range f.Errors() {
+		total += count
+	}
+	return total
+}
+
+// recent returns the most recently observed value of v.
+func recent(v *monkit.IntVal) (value float64) {
+	v.Stats(func(name string, val float64) {
+		if name == "recent" {
+			value = val
+		}
+	})
+	return value
+}
+
+// labelEscaper escapes label values as required by the text format, which
+// differs from Go string quoting.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeFamily writes the metadata of a metric family.
+func writeFamily(buf *bytes.Buffer, name, typ, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, typ)
+}
+
+// writeSample writes a single sample, labels are given as name, value pairs.
+func writeSample(buf *bytes.Buffer, name string, value float64, labels ...string) {
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+		}
+		buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	buf.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
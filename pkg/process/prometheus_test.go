@@ -0,0 +1,92 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package process_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/process"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.New(t, 1, 4, 0)
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	// drive a few dials
+	self := planet.StorageNodes[0]
+	for _, peer := range planet.StorageNodes[1:] {
+		node := peer.Local().Node
+		conn, err := self.Transport.DialNode(ctx, &node)
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	}
+
+	server := httptest.NewServer(process.PrometheusHandler(monkit.Default))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer ctx.Check(resp.Body.Close)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	families := map[string]string{}
+	samples := map[string]float64{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			require.Len(t, fields, 4, line)
+			families[fields[2]] = fields[3]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		split := strings.LastIndexByte(line, ' ')
+		require.True(t, split > 0, line)
+		value, err := strconv.ParseFloat(line[split+1:], 64)
+		require.NoError(t, err, line)
+		samples[line[:split]] = value
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Equal(t, map[string]string{
+		"storj_build_info":                      "gauge",
+		"storj_transport_dials_total":           "counter",
+		"storj_transport_dial_duration_seconds": "summary",
+		"storj_transport_deadline_shed_total":   "counter",
+		"storj_kademlia_routing_table_size":     "gauge",
+		"storj_kademlia_refreshes_total":        "counter",
+	}, families)
+
+	dials := samples[`storj_transport_dials_total{target="node",result="success"}`]
+	require.True(t, dials >= 3, "dials %v", dials)
+	require.Equal(t, dials, samples[`storj_transport_dial_duration_seconds_count{target="node"}`])
+	require.True(t, samples[`storj_transport_dial_duration_seconds_sum{target="node"}`] > 0)
+
+	require.True(t, samples["storj_kademlia_routing_table_size"] >= 1)
+
+	for name, value := range samples {
+		if strings.HasPrefix(name, "storj_build_info{") {
+			require.Equal(t, 1.0, value)
+		}
+	}
+}
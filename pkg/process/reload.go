@@ -0,0 +1,72 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package process
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/pkg/cfgstruct"
+)
+
+// ReloadConfig reads the configuration of cmd again into config, which must
+// have the type of the configuration bound to the flags of cmd with opts.
+// Flags set on the command line keep their values, the other values are
+// read from the configuration file and the environment as on start, without
+// migrating renamed keys.
+func ReloadConfig(cmd *cobra.Command, config interface{}, opts ...cfgstruct.BindOpt) error {
+	flags := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	cfgstruct.Bind(flags, config, opts...)
+
+	vip := viper.New()
+	vip.SetEnvPrefix("storj")
+	vip.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	vip.AutomaticEnv()
+
+	cfgFlag := cmd.Flags().Lookup("config-dir")
+	if cfgFlag != nil && cfgFlag.Value.String() != "" {
+		vip.SetConfigFile(filepath.Join(os.ExpandEnv(cfgFlag.Value.String()), "config.yaml"))
+		if err := vip.ReadInConfig(); err != nil {
+			return err
+		}
+	}
+
+	var group errs.Group
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if current := cmd.Flags().Lookup(flag.Name); current != nil && current.Changed {
+			group.Add(flags.Set(flag.Name, current.Value.String()))
+			return
+		}
+		if vip.IsSet(flag.Name) {
+			group.Add(flags.Set(flag.Name, vip.GetString(flag.Name)))
+		}
+	})
+	return group.Err()
+}
+
+// OnReload calls reload whenever the process receives SIGHUP, until ctx is done.
+func OnReload(ctx context.Context, reload func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(c)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c:
+				reload()
+			}
+		}
+	}()
+}
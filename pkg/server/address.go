@@ -0,0 +1,76 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package server
+
+import (
+	"net"
+	"strconv"
+)
+
+// ExternalAddr returns the address other peers can use to reach the public listener.
+//
+// When the listener is bound to an unspecified address, such as ":7777", a public
+// address of the host is used instead, preferring IPv4 when it is available.
+func (p *Server) ExternalAddr() string {
+	candidates, err := net.InterfaceAddrs()
+	if err != nil {
+		return p.Addr().String()
+	}
+	return externalAddress(p.Addr(), candidates)
+}
+
+// privateNetworks are the global unicast networks that aren't reachable over the
+// internet: the private networks of RFC 1918 and the unique local addresses of RFC 4193.
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// isPublic returns whether ip is reachable over the internet.
+func isPublic(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() {
+		return false
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// externalAddress picks a routable address for listener from the candidate interface addresses.
+func externalAddress(listener net.Addr, candidates []net.Addr) string {
+	tcp, ok := listener.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return listener.String()
+	}
+	ipv4Only := tcp.IP.To4() != nil
+
+	var ipv6 net.IP
+	for _, candidate := range candidates {
+		ipnet, ok := candidate.(*net.IPNet)
+		if !ok || !isPublic(ipnet.IP) {
+			continue
+		}
+		if ipnet.IP.To4() != nil {
+			return net.JoinHostPort(ipnet.IP.String(), strconv.Itoa(tcp.Port))
+		}
+		if ipv6 == nil && !ipv4Only {
+			ipv6 = ipnet.IP
+		}
+	}
+
+	if ipv6 != nil {
+		return net.JoinHostPort(ipv6.String(), strconv.Itoa(tcp.Port))
+	}
+	return listener.String()
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalAddress(t *testing.T) {
+	loopback4 := &net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)}
+	loopback6 := &net.IPNet{IP: net.ParseIP("::1"), Mask: net.CIDRMask(128, 128)}
+	linklocal6 := &net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)}
+	private4 := &net.IPNet{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(24, 32)}
+	private6 := &net.IPNet{IP: net.ParseIP("fd00::2"), Mask: net.CIDRMask(64, 128)}
+	global4 := &net.IPNet{IP: net.ParseIP("203.0.113.2"), Mask: net.CIDRMask(24, 32)}
+	global6 := &net.IPNet{IP: net.ParseIP("2001:db8::2"), Mask: net.CIDRMask(64, 128)}
+
+	listener := func(host string) net.Addr {
+		return &net.TCPAddr{IP: net.ParseIP(host), Port: 7777}
+	}
+
+	for _, test := range []struct {
+		listener   net.Addr
+		candidates []net.Addr
+		expected   string
+	}{
+		{listener("127.0.0.1"), []net.Addr{global4}, "127.0.0.1:7777"},
+		{listener("::1"), []net.Addr{global4}, "[::1]:7777"},
+		{listener("2001:db8::3"), []net.Addr{global4}, "[2001:db8::3]:7777"},
+		{listener("::"), []net.Addr{loopback4, loopback6, global6, global4}, "203.0.113.2:7777"},
+		{listener("::"), []net.Addr{private4, private6, global6}, "[2001:db8::2]:7777"},
+		{listener("::"), []net.Addr{private4, global4}, "203.0.113.2:7777"},
+		{listener("::"), []net.Addr{private4, private6}, "[::]:7777"},
+		{listener("::"), []net.Addr{loopback4, loopback6, linklocal6, global6}, "[2001:db8::2]:7777"},
+		{listener("::"), []net.Addr{loopback4, loopback6}, "[::]:7777"},
+		{listener("0.0.0.0"), []net.Addr{global6}, "0.0.0.0:7777"},
+	} {
+		assert.Equal(t, test.expected, externalAddress(test.listener, test.candidates), test.listener.String())
+	}
+}
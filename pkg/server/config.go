@@ -5,6 +5,7 @@ package server
 
 import (
 	"context"
+	"net"
 
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
@@ -19,6 +20,9 @@ type Config struct {
 	tlsopts.Config
 	Address        string `user:"true" help:"public address to listen on" default:":7777"`
 	PrivateAddress string `user:"true" help:"private address to listen on" default:"127.0.0.1:7778"`
+
+	// WrapListener wraps the public listener when set, such as for simulating a slow network in tests
+	WrapListener func(net.Listener) net.Listener `internal:"true"`
 }
 
 // Run will run the given responsibilities with the configured identity.
@@ -31,10 +35,13 @@ func (sc Config) Run(ctx context.Context, identity *identity.FullIdentity, inter
 	}
 	defer func() { err = errs.Combine(err, opts.RevDB.Close()) }()
 
-	server, err := New(opts, sc.Address, sc.PrivateAddress, interceptor, services...)
+	server, err := New(zap.L(), opts, sc.Address, sc.PrivateAddress, interceptor, services...)
 	if err != nil {
 		return err
 	}
+	if sc.WrapListener != nil {
+		server.WrapListener(sc.WrapListener)
+	}
 
 	go func() {
 		<-ctx.Done()
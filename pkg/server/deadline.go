@@ -0,0 +1,87 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package server
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// deadlineKey is the metadata key the transport client sends the request
+// deadline with, it must match the key in pkg/transport.
+const deadlineKey = "storj-deadline"
+
+const (
+	// default allowance for the clock difference between client and server
+	defaultClockSkew = 50 * time.Millisecond
+	// default minimum time a handler needs to do any useful work
+	defaultMinBudget = 5 * time.Millisecond
+)
+
+// incomingDeadline returns the deadline sent by the client.
+func incomingDeadline(ctx context.Context) (time.Time, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return time.Time{}, false
+	}
+	values := md.Get(deadlineKey)
+	if len(values) == 0 {
+		return time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
+
+// DeadlineShedder aborts requests that cannot finish before the client deadline.
+type DeadlineShedder struct {
+	// ClockSkew is the allowed clock difference between client and server.
+	ClockSkew time.Duration
+	// MinBudget is the minimum time needed by a handler to do useful work.
+	MinBudget time.Duration
+	// Methods are the full method names that may be shed, all methods when nil.
+	Methods map[string]bool
+}
+
+// NewDeadlineShedder returns a shedder with default allowances for the specified methods.
+func NewDeadlineShedder(methods ...string) DeadlineShedder {
+	shedder := DeadlineShedder{
+		ClockSkew: defaultClockSkew,
+		MinBudget: defaultMinBudget,
+		Methods:   map[string]bool{},
+	}
+	for _, method := range methods {
+		shedder.Methods[method] = true
+	}
+	return shedder
+}
+
+// Intercept returns DeadlineExceeded without calling the handler when the
+// remaining budget of the request is below the minimum.
+func (shedder DeadlineShedder) Intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if shedder.Methods != nil && !shedder.Methods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	deadline, ok := incomingDeadline(ctx)
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	budget := time.Until(deadline) + shedder.ClockSkew
+	if budget < shedder.MinBudget {
+		mon.Counter("deadline_shed").Inc(1)
+		return nil, status.Errorf(codes.DeadlineExceeded, "request budget %v exhausted before handling", budget)
+	}
+
+	return handler(ctx, req)
+}
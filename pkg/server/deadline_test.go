@@ -0,0 +1,114 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/server"
+	"storj.io/storj/pkg/transport"
+)
+
+func TestDeadlineShedder(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.New(t, 0, 2, 0)
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	self, target := planet.StorageNodes[0], planet.StorageNodes[1]
+
+	tlsOpts, err := tlsopts.NewOptions(self.Identity, tlsopts.Config{
+		PeerIDVersions: "*",
+	})
+	require.NoError(t, err)
+
+	// every request spends more time in transit than its whole budget
+	network := &transport.SimulatedNetwork{
+		Latency: 200 * time.Millisecond,
+	}
+	client := network.NewClient(transport.NewClientWithTimeout(tlsOpts, 20*time.Millisecond))
+
+	node := target.Local().Node
+	conn, err := client.DialNode(ctx, &node)
+	require.NoError(t, err)
+	defer ctx.Check(conn.Close)
+
+	shed := monkit.ScopeNamed("storj.io/storj/pkg/server").Counter("deadline_shed")
+	before := shed.Current()
+
+	_, err = pb.NewNodesClient(conn).Ping(ctx, &pb.PingRequest{})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// the server handles the request after the client has given up
+	deadline := time.Now().Add(5 * time.Second)
+	for shed.Current() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not shed the request")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDeadlineShedder_Intercept(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	shedder := server.NewDeadlineShedder("/test/Shed")
+
+	handled := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handled = true
+		return req, nil
+	}
+
+	// serve sends a request with the specified remaining budget through
+	// the client side interceptor and the shedder.
+	serve := func(method string, budget time.Duration) error {
+		handled = false
+
+		timeout := transport.InvokeTimeout{Timeout: budget}
+		return timeout.Intercept(ctx, method, nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				_, err := shedder.Intercept(outgoingToIncoming(ctx), req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+				return err
+			})
+	}
+
+	require.NoError(t, serve("/test/Shed", time.Second))
+	require.True(t, handled)
+
+	// negative budget is shed, unless within the clock skew allowance
+	require.Equal(t, codes.DeadlineExceeded, status.Code(serve("/test/Shed", -time.Second)))
+	require.False(t, handled)
+
+	require.NoError(t, serve("/test/Shed", -shedder.ClockSkew/2))
+	require.True(t, handled)
+
+	// methods that have not adopted shedding are always handled
+	require.NoError(t, serve("/test/Other", -time.Second))
+	require.True(t, handled)
+}
+
+// outgoingToIncoming passes the outgoing metadata to the server side.
+func outgoingToIncoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return metadata.NewIncomingContext(ctx, md)
+}
@@ -5,16 +5,27 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"io"
+	"runtime/debug"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"storj.io/storj/pkg/identity"
 	"storj.io/storj/storage"
 )
 
+// shedMethods are the methods that are aborted when the client deadline
+// cannot be met, see DeadlineShedder.
+var shedMethods = []string{
+	"/overlay.Nodes/Query",
+	"/overlay.Nodes/Ping",
+}
+
 func streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
 	err = handler(srv, ss)
 	if err != nil {
@@ -52,3 +63,60 @@ func combineInterceptors(a, b grpc.UnaryServerInterceptor) grpc.UnaryServerInter
 		})
 	}
 }
+
+func combineStreamInterceptors(a, b grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return a(srv, ss, info, func(asrv interface{}, ass grpc.ServerStream) error {
+			return b(asrv, ass, info, handler)
+		})
+	}
+}
+
+// recoverUnaryInterceptor returns an interceptor that turns a panic in a
+// handler into an Internal error and logs it to log.
+func recoverUnaryInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = panicError(ctx, log, info.FullMethod, recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// recoverStreamInterceptor returns an interceptor that turns a panic in a
+// handler into an Internal error and logs it to log.
+func recoverStreamInterceptor(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = panicError(ss.Context(), log, info.FullMethod, recovered)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// panicError logs a recovered panic and returns an Internal error
+// with an id to find the log entry.
+func panicError(ctx context.Context, log *zap.Logger, method string, recovered interface{}) error {
+	mon.Counter("grpc_handler_panics").Inc(1)
+
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	correlationID := hex.EncodeToString(id[:])
+
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("correlation id", correlationID),
+		zap.Any("panic", recovered),
+		zap.ByteString("stack", debug.Stack()),
+	}
+	if peer, err := identity.PeerIdentityFromContext(ctx); err == nil {
+		fields = append(fields, zap.Stringer("peer", peer.ID))
+	}
+	log.Error("panic in grpc handler", fields...)
+
+	return status.Errorf(codes.Internal, "internal error (correlation id %s)", correlationID)
+}
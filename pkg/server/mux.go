@@ -0,0 +1,145 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package server
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// http2Preface is the start of the connection preface sent by gRPC clients
+var http2Preface = []byte("PRI * HTTP/2")
+
+// prefaceTimeout is how long a connection may take to send enough bytes to be routed
+const prefaceTimeout = 10 * time.Second
+
+// privateMux splits the connections of the private listener between gRPC and plain HTTP.
+type privateMux struct {
+	listener net.Listener
+	grpc     *muxListener
+	http     *muxListener
+}
+
+func newPrivateMux(listener net.Listener) *privateMux {
+	return &privateMux{
+		listener: listener,
+		grpc:     newMuxListener(listener.Addr()),
+		http:     newMuxListener(listener.Addr()),
+	}
+}
+
+// Run routes accepted connections until the underlying listener is closed.
+func (mux *privateMux) Run() error {
+	defer func() {
+		_ = mux.grpc.Close()
+		_ = mux.http.Close()
+	}()
+	for {
+		conn, err := mux.listener.Accept()
+		if err != nil {
+			if isClosedErr(err) {
+				return nil
+			}
+			return err
+		}
+		go mux.route(conn)
+	}
+}
+
+// route peeks at the first bytes of conn to pick its server.
+func (mux *privateMux) route(conn net.Conn) {
+	prefix := make([]byte, len(http2Preface))
+	_ = conn.SetReadDeadline(time.Now().Add(prefaceTimeout))
+	n, err := io.ReadFull(conn, prefix)
+	_ = conn.SetReadDeadline(time.Time{})
+	prefix = prefix[:n]
+
+	// short non-gRPC requests may fit in fewer bytes than the preface
+	if err != nil && (n == 0 || bytes.HasPrefix(http2Preface, prefix)) {
+		_ = conn.Close()
+		return
+	}
+
+	target := mux.http
+	if bytes.Equal(prefix, http2Preface) {
+		target = mux.grpc
+	}
+	target.deliver(&prefixedConn{Conn: conn, prefix: prefix})
+}
+
+// muxListener is a net.Listener for connections routed by privateMux.
+type muxListener struct {
+	addr  net.Addr
+	conns chan net.Conn
+
+	once sync.Once
+	done chan struct{}
+}
+
+func newMuxListener(addr net.Addr) *muxListener {
+	return &muxListener{
+		addr:  addr,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (listener *muxListener) deliver(conn net.Conn) {
+	select {
+	case listener.conns <- conn:
+	case <-listener.done:
+		_ = conn.Close()
+	}
+}
+
+// Accept waits for the next routed connection.
+func (listener *muxListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-listener.conns:
+		return conn, nil
+	case <-listener.done:
+		return nil, Error.New("listener closed")
+	}
+}
+
+// Close stops accepting connections.
+func (listener *muxListener) Close() error {
+	listener.once.Do(func() { close(listener.done) })
+	return nil
+}
+
+// Addr returns the address of the underlying listener.
+func (listener *muxListener) Addr() net.Addr { return listener.addr }
+
+// prefixedConn replays the bytes read while routing the connection.
+type prefixedConn struct {
+	net.Conn
+	prefix []byte
+}
+
+func (conn *prefixedConn) Read(p []byte) (int, error) {
+	if len(conn.prefix) > 0 {
+		n := copy(p, conn.prefix)
+		conn.prefix = conn.prefix[n:]
+		return n, nil
+	}
+	return conn.Conn.Read(p)
+}
+
+// isClosedErr returns whether err is from using a closed listener.
+func isClosedErr(err error) bool {
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Err.Error() == "use of closed network connection"
+}
+
+// ignoreClosed drops errors from closing an already closed listener.
+func ignoreClosed(err error) error {
+	if err != nil && isClosedErr(err) {
+		return nil
+	}
+	return err
+}
@@ -6,10 +6,13 @@ package server
 import (
 	"context"
 	"net"
+	"net/http"
 
 	"github.com/zeebo/errs"
+	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // registers gzip for compressed kademlia responses
 
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/peertls/tlsopts"
@@ -29,7 +32,10 @@ type public struct {
 
 type private struct {
 	listener net.Listener
+	mux      *privateMux
 	grpc     *grpc.Server
+	http     *http.Server
+	handlers *http.ServeMux
 }
 
 // Server represents a bundle of services defined by a specific ID.
@@ -43,11 +49,12 @@ type Server struct {
 
 // New creates a Server out of an Identity, a net.Listener,
 // a UnaryServerInterceptor, and a set of services.
-func New(opts *tlsopts.Options, publicAddr, privateAddr string, interceptor grpc.UnaryServerInterceptor, services ...Service) (*Server, error) {
-	unaryInterceptor := unaryInterceptor
+func New(log *zap.Logger, opts *tlsopts.Options, publicAddr, privateAddr string, interceptor grpc.UnaryServerInterceptor, services ...Service) (*Server, error) {
+	unaryInterceptor := combineInterceptors(NewDeadlineShedder(shedMethods...).Intercept, unaryInterceptor)
 	if interceptor != nil {
 		unaryInterceptor = combineInterceptors(unaryInterceptor, interceptor)
 	}
+	unaryInterceptor = combineInterceptors(recoverUnaryInterceptor(log), unaryInterceptor)
 
 	publicListener, err := net.Listen("tcp", publicAddr)
 	if err != nil {
@@ -56,7 +63,7 @@ func New(opts *tlsopts.Options, publicAddr, privateAddr string, interceptor grpc
 	public := public{
 		listener: publicListener,
 		grpc: grpc.NewServer(
-			grpc.StreamInterceptor(streamInterceptor),
+			grpc.StreamInterceptor(combineStreamInterceptors(recoverStreamInterceptor(log), streamInterceptor)),
 			grpc.UnaryInterceptor(unaryInterceptor),
 			opts.ServerOption(),
 		),
@@ -66,9 +73,16 @@ func New(opts *tlsopts.Options, publicAddr, privateAddr string, interceptor grpc
 	if err != nil {
 		return nil, errs.Combine(err, publicListener.Close())
 	}
+	handlers := http.NewServeMux()
 	private := private{
 		listener: privateListener,
-		grpc:     grpc.NewServer(),
+		mux:      newPrivateMux(privateListener),
+		grpc: grpc.NewServer(
+			grpc.StreamInterceptor(recoverStreamInterceptor(log)),
+			grpc.UnaryInterceptor(recoverUnaryInterceptor(log)),
+		),
+		http:     &http.Server{Handler: handlers},
+		handlers: handlers,
 	}
 
 	return &Server{
@@ -85,6 +99,12 @@ func (p *Server) Identity() *identity.FullIdentity { return p.identity }
 // Addr returns the server's public listener address
 func (p *Server) Addr() net.Addr { return p.public.listener.Addr() }
 
+// WrapListener replaces the public listener with the one returned by wrap,
+// it must be called before Run.
+func (p *Server) WrapListener(wrap func(net.Listener) net.Listener) {
+	p.public.listener = wrap(p.public.listener)
+}
+
 // PrivateAddr returns the server's private listener address
 func (p *Server) PrivateAddr() net.Addr { return p.private.listener.Addr() }
 
@@ -94,11 +114,17 @@ func (p *Server) GRPC() *grpc.Server { return p.public.grpc }
 // PrivateGRPC returns the server's gRPC handle for registration purposes
 func (p *Server) PrivateGRPC() *grpc.Server { return p.private.grpc }
 
+// PrivateHTTP returns the server's HTTP handlers served on the private listener
+func (p *Server) PrivateHTTP() *http.ServeMux { return p.private.handlers }
+
 // Close shuts down the server
 func (p *Server) Close() error {
 	p.public.grpc.GracefulStop()
 	p.private.grpc.GracefulStop()
-	return nil
+	return errs.Combine(
+		p.private.http.Close(),
+		ignoreClosed(p.private.listener.Close()),
+	)
 }
 
 // Run will run the server and all of its services
@@ -125,7 +151,19 @@ func (p *Server) Run(ctx context.Context) (err error) {
 	})
 	group.Go(func() error {
 		defer cancel()
-		return p.private.grpc.Serve(p.private.listener)
+		return p.private.grpc.Serve(p.private.mux.grpc)
+	})
+	group.Go(func() error {
+		defer cancel()
+		err := p.private.http.Serve(p.private.mux.http)
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	})
+	group.Go(func() error {
+		defer cancel()
+		return p.private.mux.Run()
 	})
 
 	return group.Wait()
@@ -0,0 +1,122 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"storj.io/storj/internal/memory"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/cfgstruct"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/server"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storagenode"
+)
+
+func TestDebugConfig(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Kademlia.Alpha = 7
+				config.Kademlia.Compression.Threshold = 3 * memory.MiB
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		node := planet.StorageNodes[0]
+
+		resp, err := http.Get("http://" + node.PrivateAddr() + "/debug/config")
+		require.NoError(t, err)
+		defer ctx.Check(resp.Body.Close)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var described map[string]map[string]cfgstruct.Setting
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&described))
+
+		kademlia := described["kademlia"]
+		assert.Equal(t, cfgstruct.Setting{Value: 7.0, Default: false}, kademlia["alpha"])
+		assert.Equal(t, cfgstruct.Setting{Value: "3.0 MiB", Default: false}, kademlia["compression.threshold"])
+		assert.Equal(t, cfgstruct.Setting{Value: false, Default: false}, kademlia["compression.enabled"])
+		assert.Equal(t, cfgstruct.Setting{Value: "500ms", Default: false}, kademlia["bootstrap-backoff-base"])
+
+		assert.Equal(t, cfgstruct.Setting{Value: "*", Default: false}, described["server"]["peer-id-versions"])
+		assert.Equal(t, true, described["server"]["revocation-dburl"].Value)
+		// testplanet passes identities directly, so the paths are unset
+		assert.Equal(t, cfgstruct.Setting{Value: false, Default: false}, described["identity"]["key-path"])
+
+		// gRPC keeps working on the same listener
+		conn, err := grpc.DialContext(ctx, node.PrivateAddr(), grpc.WithInsecure())
+		require.NoError(t, err)
+		defer ctx.Check(conn.Close)
+
+		_, err = pb.NewKadInspectorClient(conn).CountNodes(ctx, &pb.CountNodesRequest{})
+		require.NoError(t, err)
+	})
+}
+
+func TestRecoverPanics(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	serverIdent, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	serverOptions, err := tlsopts.NewOptions(serverIdent, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	srv, err := server.New(zap.New(core), serverOptions, "127.0.0.1:0", "127.0.0.1:0", nil)
+	require.NoError(t, err)
+	pb.RegisterNodesServer(srv.GRPC(), panickingNodes{})
+
+	runCtx, cancel := context.WithCancel(ctx)
+	ctx.Go(func() error { return srv.Run(runCtx) })
+	defer cancel()
+
+	clientIdent, err := testidentity.NewTestIdentity(ctx)
+	require.NoError(t, err)
+	clientOptions, err := tlsopts.NewOptions(clientIdent, tlsopts.Config{PeerIDVersions: "*"})
+	require.NoError(t, err)
+
+	conn, err := transport.NewClient(clientOptions).DialNode(ctx, &pb.Node{
+		Id:      serverIdent.ID,
+		Address: &pb.NodeAddress{Address: srv.Addr().String()},
+	})
+	require.NoError(t, err)
+	defer ctx.Check(conn.Close)
+	client := pb.NewNodesClient(conn)
+
+	// the connection survives panics in the handlers
+	for i := 0; i < 2; i++ {
+		_, err = client.Ping(ctx, &pb.PingRequest{})
+		require.Equal(t, codes.Internal, status.Code(err), "%v", err)
+		require.Contains(t, err.Error(), "correlation id")
+
+		stream, err := client.QueryStream(ctx, &pb.QueryRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.Internal, status.Code(err), "%v", err)
+	}
+
+	// the panics are logged to the server's logger
+	require.Equal(t, 4, logs.FilterMessage("panic in grpc handler").Len())
+}
+
+// panickingNodes panics on every call, since it doesn't implement any methods.
+type panickingNodes struct{ pb.NodesServer }
@@ -16,7 +16,6 @@ import (
 	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
 	"storj.io/storj/internal/sync2"
-	"storj.io/storj/pkg/auth/signing"
 	"storj.io/storj/pkg/eestream"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/ranger"
@@ -51,16 +50,7 @@ func NewClient(tc transport.Client, memoryLimit int) Client {
 }
 
 func (ec *ecClient) newPSClient(ctx context.Context, n *pb.Node) (*piecestore.Client, error) {
-	conn, err := ec.transport.DialNode(ctx, n)
-	if err != nil {
-		return nil, err
-	}
-	return piecestore.NewClient(
-		zap.L().Named(n.Id.String()),
-		signing.SignerFromFullIdentity(ec.transport.Identity()),
-		conn,
-		piecestore.DefaultConfig,
-	), nil
+	return piecestore.Dial(ctx, ec.transport, n, zap.L().Named(n.Id.String()), piecestore.DefaultConfig)
 }
 
 func (ec *ecClient) Put(ctx context.Context, limits []*pb.AddressedOrderLimit, rs eestream.RedundancyStrategy, data io.Reader, expiration time.Time) (successfulNodes []*pb.Node, successfulHashes []*pb.PieceHash, err error) {
@@ -56,15 +56,21 @@ func init() {
 func GetIDVersion(number IDVersionNumber) (IDVersion, error) {
 	version, ok := IDVersions[number]
 	if !ok {
-		return IDVersion{}, ErrVersion.New("unknown version")
+		return IDVersion{}, ErrVersion.New("unknown version %d", number)
 	}
 
 	return version, nil
 }
 
-// LatestIDVersion returns the last IDVersion registered.
+// LatestIDVersion returns the registered IDVersion with the highest number.
 func LatestIDVersion() IDVersion {
-	return IDVersions[IDVersionNumber(len(IDVersions)-1)]
+	var latest IDVersionNumber
+	for number := range IDVersions {
+		if number > latest {
+			latest = number
+		}
+	}
+	return IDVersions[latest]
 }
 
 // IDVersionFromCert parsed the IDVersion from the passed certificate's IDVersion extension.
@@ -86,51 +92,86 @@ func IDVersionFromCert(cert *x509.Certificate) (IDVersion, error) {
 
 // IDVersionInVersions returns an error if the given version is in the given string of version(s)/range(s).
 func IDVersionInVersions(versionNumber IDVersionNumber, versionsStr string) error {
+	versions, err := ParseIDVersions(versionsStr)
+	if err != nil {
+		return err
+	}
+	if !versions.Allows(versionNumber) {
+		return ErrVersion.New("version %d not in versions %s", versionNumber, versionsStr)
+	}
+	return nil
+}
+
+// IDVersionSet is a set of identity versions, such as the versions of the
+// peers a node is allowed to talk to.
+type IDVersionSet struct {
+	any      bool
+	numbers  map[IDVersionNumber]bool
+	versions string
+}
+
+// ParseIDVersions parses a string of version(s)/range(s), such as "0,2-3",
+// "latest" for the latest registered version or "*" for any version.
+func ParseIDVersions(versionsStr string) (IDVersionSet, error) {
+	set := IDVersionSet{
+		numbers:  make(map[IDVersionNumber]bool),
+		versions: versionsStr,
+	}
+
 	switch versionsStr {
 	case "":
-		return ErrVersion.New("no allowed peer identity versions specified")
+		return IDVersionSet{}, ErrVersion.New("no allowed peer identity versions specified")
 	case "*":
-		return nil
+		set.any = true
+		return set, nil
 	case "latest":
-		if versionNumber == LatestIDVersion().Number {
-			return nil
+		set.numbers[LatestIDVersion().Number] = true
+		return set, nil
+	}
+
+	for _, versionRange := range strings.Split(versionsStr, ",") {
+		versionLimits := strings.Split(versionRange, "-")
+		if len(versionLimits) > 2 {
+			return IDVersionSet{}, ErrVersion.New("malformed PeerIDVersions string: %s", versionsStr)
 		}
-	default:
-		versionRanges := strings.Split(versionsStr, ",")
-		for _, versionRange := range versionRanges {
-			if strings.Contains(versionRange, "-") {
-				versionLimits := strings.Split(versionRange, "-")
-				if len(versionLimits) != 2 {
-					return ErrVersion.New("malformed PeerIDVersions string: %s", versionsStr)
-				}
-
-				begin, err := strconv.Atoi(versionLimits[0])
-				if err != nil {
-					return ErrVersion.Wrap(err)
-				}
-
-				end, err := strconv.Atoi(versionLimits[1])
-				if err != nil {
-					return ErrVersion.Wrap(err)
-				}
-
-				for i := begin; i <= end; i++ {
-					if versionNumber == IDVersionNumber(i) {
-						return nil
-					}
-				}
-			} else {
-				versionInt, err := strconv.Atoi(versionRange)
-				if err != nil {
-					return ErrVersion.Wrap(err)
-				}
-				if versionNumber == IDVersionNumber(versionInt) {
-					return nil
-				}
+
+		begin, err := parseIDVersionNumber(versionLimits[0])
+		if err != nil {
+			return IDVersionSet{}, err
+		}
+		end := begin
+		if len(versionLimits) == 2 {
+			end, err = parseIDVersionNumber(versionLimits[1])
+			if err != nil {
+				return IDVersionSet{}, err
 			}
 		}
+
+		for number := int(begin); number <= int(end); number++ {
+			set.numbers[IDVersionNumber(number)] = true
+		}
 	}
-	return ErrVersion.New("version %d not in versions %s", versionNumber, versionsStr)
+	return set, nil
+}
+
+// parseIDVersionNumber parses a single version number of a versions string.
+func parseIDVersionNumber(s string) (IDVersionNumber, error) {
+	number, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, ErrVersion.Wrap(err)
+	}
+	return IDVersionNumber(number), nil
+}
+
+// Allows returns whether the version with number is in the set, whether or
+// not it is registered.
+func (set IDVersionSet) Allows(number IDVersionNumber) bool {
+	return set.any || set.numbers[number]
+}
+
+// String returns the version(s)/range(s) the set was parsed from.
+func (set IDVersionSet) String() string {
+	return set.versions
 }
 
 func idVersionHandler(opts *extensions.Options) extensions.HandlerFunc {
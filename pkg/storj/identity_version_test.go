@@ -122,3 +122,32 @@ func TestV0LegacyCertCompatibility(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, signed.ID, storj.NodeID{14, 210, 138, 187, 40, 19, 225, 132, 161, 233, 139, 15, 102, 5, 196, 145, 30, 164, 104, 199, 232, 67, 62, 181, 131, 224, 252, 167, 206, 172, 48, 0})
 }
+
+func TestParseIDVersions(t *testing.T) {
+	for _, testcase := range []struct {
+		versions string
+		allowed  []storj.IDVersionNumber
+		denied   []storj.IDVersionNumber
+	}{
+		{"0", []storj.IDVersionNumber{0}, []storj.IDVersionNumber{1}},
+		{"0,2-3", []storj.IDVersionNumber{0, 2, 3}, []storj.IDVersionNumber{1, 4}},
+		{"latest", []storj.IDVersionNumber{storj.LatestIDVersion().Number}, []storj.IDVersionNumber{storj.LatestIDVersion().Number + 1}},
+		{"*", []storj.IDVersionNumber{0, 1, 255}, nil},
+	} {
+		versions, err := storj.ParseIDVersions(testcase.versions)
+		require.NoError(t, err, testcase.versions)
+		assert.Equal(t, testcase.versions, versions.String())
+
+		for _, number := range testcase.allowed {
+			assert.True(t, versions.Allows(number), "%s allows %d", testcase.versions, number)
+		}
+		for _, number := range testcase.denied {
+			assert.False(t, versions.Allows(number), "%s denies %d", testcase.versions, number)
+		}
+	}
+
+	for _, malformed := range []string{"", "0-", "a", "0-1-2", "256"} {
+		_, err := storj.ParseIDVersions(malformed)
+		assert.Error(t, err, malformed)
+	}
+}
@@ -60,8 +60,8 @@ func NodeIDFromString(s string) (NodeID, error) {
 		return NodeID{}, err
 	}
 
-	version := IDVersions[IDVersionNumber(versionNumber)]
-	return NewVersionedID(unversionedID, version), nil
+	// NB: the version may not be registered by this node, keep it as is
+	return NewVersionedID(unversionedID, IDVersion{Number: IDVersionNumber(versionNumber)}), nil
 }
 
 // NodeIDsFromBytes converts a 2d byte slice into a list of nodes
@@ -97,8 +97,8 @@ func NodeIDFromBytes(b []byte) (NodeID, error) {
 
 // String returns NodeID as base58 encoded string with checksum and version bytes
 func (id NodeID) String() string {
-	unversionedID := id.unversioned()
-	return base58.CheckEncode(unversionedID[:], byte(id.Version().Number))
+	unversionedID := id.Unversioned()
+	return base58.CheckEncode(unversionedID[:], byte(id.VersionNumber()))
 }
 
 // IsZero returns whether NodeID is unassigned
@@ -121,14 +121,15 @@ func (id NodeID) Less(b NodeID) bool {
 	return false
 }
 
-// Version returns the version of the identity format
+// Version returns the version of the identity format, V0 when the version
+// isn't registered.
 func (id NodeID) Version() IDVersion {
-	versionNumber := id.versionByte()
+	versionNumber := id.VersionNumber()
 	if versionNumber == 0 {
 		return IDVersions[V0]
 	}
 
-	version, err := GetIDVersion(IDVersionNumber(versionNumber))
+	version, err := GetIDVersion(versionNumber)
 	// NB: when in doubt, use V0
 	if err != nil {
 		return IDVersions[V0]
@@ -231,14 +232,16 @@ func (n NodeIDList) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
 // Less implements sort.Interface.Less()
 func (n NodeIDList) Less(i, j int) bool { return n[i].Less(n[j]) }
 
-func (id NodeID) versionByte() byte {
-	return id[NodeIDSize-1]
+// VersionNumber returns the number of the identity version in the last byte
+// of the node ID, which may not be a registered version.
+func (id NodeID) VersionNumber() IDVersionNumber {
+	return IDVersionNumber(id[NodeIDSize-1])
 }
 
-// unversioned returns the node ID with the version byte replaced with `0`.
+// Unversioned returns the node ID with the version byte replaced with `0`.
 // NB: Legacy node IDs (i.e. pre-identity-versions) with a difficulty less
 // than `8` are unsupported.
-func (id NodeID) unversioned() NodeID {
+func (id NodeID) Unversioned() NodeID {
 	unversionedID := NodeID{}
 	copy(unversionedID[:], id[:NodeIDSize-1])
 	return unversionedID
@@ -154,3 +154,23 @@ func TestNewVersionedID(t *testing.T) {
 		assert.Equal(t, versionNumber, storj.IDVersionNumber(versionedNodeID[storj.NodeIDSize-1]))
 	}
 }
+
+func TestNodeID_UnknownVersion(t *testing.T) {
+	nodeID := storj.NodeID{}
+	_, err := rand.Read(nodeID[:])
+	require.NoError(t, err)
+	nodeID[storj.NodeIDSize-1] = 0xfe
+
+	_, err = storj.GetIDVersion(nodeID.VersionNumber())
+	require.Error(t, err)
+
+	assert.Equal(t, storj.IDVersionNumber(0xfe), nodeID.VersionNumber())
+	assert.Equal(t, storj.V0, nodeID.Version().Number)
+	assert.Equal(t, nodeID[:storj.NodeIDSize-1], nodeID.Unversioned().Bytes()[:storj.NodeIDSize-1])
+	assert.Equal(t, storj.V0, nodeID.Unversioned().VersionNumber())
+
+	// nodes that don't know the version keep the node ID intact
+	decoded, err := storj.NodeIDFromString(nodeID.String())
+	require.NoError(t, err)
+	assert.Equal(t, nodeID, decoded)
+}
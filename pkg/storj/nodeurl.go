@@ -0,0 +1,53 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package storj
+
+import (
+	"strings"
+
+	"github.com/zeebo/errs"
+)
+
+// ErrNodeURL is used when something goes wrong with a node url.
+var ErrNodeURL = errs.Class("node URL error")
+
+// NodeURL is a node id and address, formatted as "id@address".
+type NodeURL struct {
+	ID      NodeID
+	Address string
+}
+
+// ParseNodeURL parses "id@address" or a bare address into a NodeURL.
+func ParseNodeURL(s string) (NodeURL, error) {
+	if s == "" {
+		return NodeURL{}, ErrNodeURL.New("empty node url")
+	}
+
+	var url NodeURL
+	if at := strings.LastIndex(s, "@"); at >= 0 {
+		id, err := NodeIDFromString(s[:at])
+		if err != nil {
+			return NodeURL{}, ErrNodeURL.Wrap(err)
+		}
+		url.ID, s = id, s[at+1:]
+	}
+	if s == "" {
+		return NodeURL{}, ErrNodeURL.New("missing address")
+	}
+	url.Address = s
+	return url, nil
+}
+
+// IsZero returns whether the url is empty.
+func (url NodeURL) IsZero() bool {
+	return url.ID.IsZero() && url.Address == ""
+}
+
+// String returns the url formatted as "id@address", or just the address when the id is unknown.
+func (url NodeURL) String() string {
+	if url.ID.IsZero() {
+		return url.Address
+	}
+	return url.ID.String() + "@" + url.Address
+}
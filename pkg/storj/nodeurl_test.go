@@ -0,0 +1,33 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package storj_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestNodeURL(t *testing.T) {
+	id := testidentity.MustPregeneratedIdentity(0, storj.LatestIDVersion()).ID
+
+	url, err := storj.ParseNodeURL(id.String() + "@127.0.0.1:7777")
+	require.NoError(t, err)
+	assert.Equal(t, storj.NodeURL{ID: id, Address: "127.0.0.1:7777"}, url)
+	assert.Equal(t, id.String()+"@127.0.0.1:7777", url.String())
+
+	url, err = storj.ParseNodeURL("example.test:7777")
+	require.NoError(t, err)
+	assert.Equal(t, storj.NodeURL{Address: "example.test:7777"}, url)
+	assert.Equal(t, "example.test:7777", url.String())
+
+	for _, invalid := range []string{"", "invalid@127.0.0.1:7777", id.String() + "@"} {
+		_, err := storj.ParseNodeURL(invalid)
+		assert.True(t, storj.ErrNodeURL.Has(err), invalid)
+	}
+}
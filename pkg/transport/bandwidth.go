@@ -0,0 +1,252 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc"
+
+	"storj.io/storj/internal/memory"
+)
+
+// DefaultLabel is the label of the connections dialed without a label.
+const DefaultLabel = "other"
+
+type labelKey struct{}
+
+// WithLabel labels the connections dialed and the requests made with ctx, the
+// bytes they transfer are attributed to label, see Bandwidth.
+func WithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
+
+// WithDefaultLabel labels ctx with label unless it already has a label, for
+// components dialing on behalf of others.
+func WithDefaultLabel(ctx context.Context, label string) context.Context {
+	if _, ok := labelOf(ctx); ok {
+		return ctx
+	}
+	return WithLabel(ctx, label)
+}
+
+// labelOf returns the label of ctx.
+func labelOf(ctx context.Context) (string, bool) {
+	label, ok := ctx.Value(labelKey{}).(string)
+	return label, ok && label != ""
+}
+
+// BandwidthUsage is the number of bytes transferred for a label.
+type BandwidthUsage struct {
+	Label string
+	In    memory.Size
+	Out   memory.Size
+}
+
+// Bandwidth counts the bytes transferred by the connections of a transport,
+// per label.
+//
+// The bytes of a connection, including the TLS and HTTP/2 framing, are
+// attributed to the label of the dial. The payload of a request made with a
+// different label, such as one on a connection reused from another
+// component, is attributed to the label of the request instead.
+type Bandwidth struct {
+	mu     sync.Mutex
+	labels map[string]*labelBandwidth
+}
+
+// labelBandwidth are the bytes transferred for a label.
+type labelBandwidth struct {
+	in  memory.AtomicSize
+	out memory.AtomicSize
+}
+
+// NewBandwidth returns an empty Bandwidth, whose totals are reported to
+// monkit as transport.bandwidth.
+func NewBandwidth() *Bandwidth {
+	bandwidth := &Bandwidth{labels: make(map[string]*labelBandwidth)}
+	mon.Chain("bandwidth", bandwidth)
+	return bandwidth
+}
+
+// label returns the counters of label.
+func (bandwidth *Bandwidth) label(label string) *labelBandwidth {
+	bandwidth.mu.Lock()
+	defer bandwidth.mu.Unlock()
+
+	counters, ok := bandwidth.labels[label]
+	if !ok {
+		counters = &labelBandwidth{}
+		bandwidth.labels[label] = counters
+	}
+	return counters
+}
+
+// Usage returns the bytes transferred for each label, sorted by label.
+func (bandwidth *Bandwidth) Usage() []BandwidthUsage {
+	bandwidth.mu.Lock()
+	defer bandwidth.mu.Unlock()
+
+	usage := make([]BandwidthUsage, 0, len(bandwidth.labels))
+	for label, counters := range bandwidth.labels {
+		usage = append(usage, BandwidthUsage{
+			Label: label,
+			In:    counters.in.Load(),
+			Out:   counters.out.Load(),
+		})
+	}
+	sort.Slice(usage, func(i, k int) bool { return usage[i].Label < usage[k].Label })
+	return usage
+}
+
+// Stats implements monkit.StatSource.
+func (bandwidth *Bandwidth) Stats(cb func(name string, val float64)) {
+	for _, usage := range bandwidth.Usage() {
+		cb(usage.Label+"_bytes_in", usage.In.Float64())
+		cb(usage.Label+"_bytes_out", usage.Out.Float64())
+	}
+}
+
+// counter counts the bytes of a connection dialed with owner as the label.
+type counter struct {
+	bandwidth *Bandwidth
+	owner     string
+	// next dials the connections whose bytes are counted
+	next func(ctx context.Context, address string) (net.Conn, error)
+	// dialed is 1 once the connection was dialed by dial, rather than by a
+	// dialer in the options of the caller, whose bytes aren't counted
+	dialed int32
+}
+
+// newCounter returns the counter for a connection dialed with ctx by next.
+func (bandwidth *Bandwidth) newCounter(ctx context.Context, next func(ctx context.Context, address string) (net.Conn, error)) *counter {
+	owner, ok := labelOf(ctx)
+	if !ok {
+		owner = DefaultLabel
+	}
+	return &counter{bandwidth: bandwidth, owner: owner, next: next}
+}
+
+// dialOptions returns the options counting the bytes of the connection, the
+// interceptors wrap unary and stream.
+func (counter *counter) dialOptions(unary grpc.UnaryClientInterceptor, stream grpc.StreamClientInterceptor) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(counter.dial),
+		grpc.WithUnaryInterceptor(counter.unary(unary)),
+		grpc.WithStreamInterceptor(counter.stream(stream)),
+	}
+}
+
+// dial dials address with a connection counting its bytes.
+func (counter *counter) dial(ctx context.Context, address string) (net.Conn, error) {
+	conn, err := counter.next(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	atomic.StoreInt32(&counter.dialed, 1)
+	return &countingConn{Conn: conn, counters: counter.bandwidth.label(counter.owner)}, nil
+}
+
+// attribute attributes the payload of a request made with ctx.
+func (counter *counter) attribute(ctx context.Context, in, out memory.Size) {
+	label, ok := labelOf(ctx)
+	if !ok {
+		label = counter.owner
+	}
+	dialed := atomic.LoadInt32(&counter.dialed) == 1
+	if dialed && label == counter.owner {
+		// already counted by the connection
+		return
+	}
+	if dialed {
+		owner := counter.bandwidth.label(counter.owner)
+		owner.in.Add(-in)
+		owner.out.Add(-out)
+	}
+	counters := counter.bandwidth.label(label)
+	counters.in.Add(in)
+	counters.out.Add(out)
+}
+
+// unary returns next attributing the payloads of the requests.
+func (counter *counter) unary(next grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := next(ctx, method, req, reply, cc, invoker, opts...)
+		var in memory.Size
+		if err == nil {
+			in = payloadSize(reply)
+		}
+		counter.attribute(ctx, in, payloadSize(req))
+		return err
+	}
+}
+
+// stream returns next attributing the payloads of the messages of the streams.
+func (counter *counter) stream(next grpc.StreamClientInterceptor) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := next(ctx, desc, cc, method, streamer, opts...)
+		if err != nil {
+			return stream, err
+		}
+		return &countingStream{ClientStream: stream, ctx: ctx, counter: counter}, nil
+	}
+}
+
+// payloadSize returns the encoded size of msg.
+func payloadSize(msg interface{}) memory.Size {
+	if msg, ok := msg.(proto.Message); ok {
+		return memory.Size(proto.Size(msg))
+	}
+	return 0
+}
+
+// countingConn counts the bytes read and written.
+type countingConn struct {
+	net.Conn
+	counters *labelBandwidth
+}
+
+// Read reads from the connection.
+func (conn *countingConn) Read(p []byte) (int, error) {
+	n, err := conn.Conn.Read(p)
+	conn.counters.in.Add(memory.Size(n))
+	return n, err
+}
+
+// Write writes to the connection.
+func (conn *countingConn) Write(p []byte) (int, error) {
+	n, err := conn.Conn.Write(p)
+	conn.counters.out.Add(memory.Size(n))
+	return n, err
+}
+
+// countingStream attributes the payloads of the messages of a stream.
+type countingStream struct {
+	grpc.ClientStream
+	ctx     context.Context
+	counter *counter
+}
+
+// SendMsg sends m.
+func (stream *countingStream) SendMsg(m interface{}) error {
+	err := stream.ClientStream.SendMsg(m)
+	if err == nil {
+		stream.counter.attribute(stream.ctx, 0, payloadSize(m))
+	}
+	return err
+}
+
+// RecvMsg receives m.
+func (stream *countingStream) RecvMsg(m interface{}) error {
+	err := stream.ClientStream.RecvMsg(m)
+	if err == nil {
+		stream.counter.attribute(stream.ctx, payloadSize(m), 0)
+	}
+	return err
+}
@@ -8,14 +8,35 @@ import (
 
 	"github.com/zeebo/errs"
 	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/pkg/peertls/extensions"
+	"storj.io/storj/pkg/peertls/tlsopts"
 )
 
 var (
 	mon = monkit.Package()
 	//Error is the errs class of standard Transport Client errors
 	Error = errs.Class("transport error")
+	// SelfDial is the errs class of dials that reached this node itself
+	SelfDial = errs.Class("self dial")
 )
 
+// classifySelfDial wraps err as a SelfDial error when the handshake found
+// this node on the other end.
+func classifySelfDial(err error) error {
+	if !tlsopts.ErrSelfDial.Has(err) {
+		return err
+	}
+	mon.Counter("self_dial").Inc(1)
+	return SelfDial.Wrap(err)
+}
+
+// IsVerificationError returns whether err means that the peer failed identity
+// verification, such as having another ID than dialed or a revoked certificate.
+func IsVerificationError(err error) bool {
+	return extensions.ErrRevocation.Has(err) || tlsopts.Error.Has(err)
+}
+
 const (
 	// default time to wait for a connection to be established
 	defaultDialTimeout = 20 * time.Second
@@ -0,0 +1,28 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// deadlineKey is the metadata key used to send the request deadline to the server.
+//
+// Unlike the grpc-timeout header, which the server measures from the moment
+// the request arrives, the deadline is an absolute time, which allows the
+// server to notice requests that spent their whole budget in transit,
+// see server.DeadlineShedder.
+const deadlineKey = "storj-deadline"
+
+// withDeadline attaches the deadline of ctx as outgoing metadata.
+func withDeadline(ctx context.Context) context.Context {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, deadlineKey, strconv.FormatInt(deadline.UnixNano(), 10))
+}
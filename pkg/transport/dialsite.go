@@ -0,0 +1,60 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// maxSiteFrames is the number of frames of the call stack kept for a dial site.
+const maxSiteFrames = 16
+
+type dialSiteKey struct{}
+
+// dialSite is where the dials made with a context come from.
+type dialSite struct {
+	name  string
+	stack []uintptr
+}
+
+// WithDialSite annotates the dials made with ctx with site, the component and
+// operation dialing, and with the call stack of the caller. Annotations nest:
+// an operation dialing on behalf of another is annotated with both sites.
+//
+// Tests use the annotation to tell where unexpected dials come from, see
+// DialSite.
+func WithDialSite(ctx context.Context, site string) context.Context {
+	if outer, ok := ctx.Value(dialSiteKey{}).(*dialSite); ok {
+		site = outer.name + " > " + site
+	}
+	stack := make([]uintptr, maxSiteFrames)
+	stack = stack[:runtime.Callers(2, stack)]
+	return context.WithValue(ctx, dialSiteKey{}, &dialSite{name: site, stack: stack})
+}
+
+// DialSite returns the site the dials made with ctx were annotated with and
+// the call stack where the innermost annotation was made, one frame per line.
+// Both are empty when ctx wasn't annotated.
+func DialSite(ctx context.Context) (site, stack string) {
+	annotation, ok := ctx.Value(dialSiteKey{}).(*dialSite)
+	if !ok {
+		return "", ""
+	}
+
+	var lines []string
+	frames := runtime.CallersFrames(annotation.stack)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			lines = append(lines, fmt.Sprintf("%s\n\t%s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return annotation.name, strings.Join(lines, "\n")
+}
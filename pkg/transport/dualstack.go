@@ -0,0 +1,194 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+const (
+	// maxAttemptDelay is the longest a dial waits for a connection attempt
+	// before starting the next one, as recommended by RFC 8305
+	maxAttemptDelay = 250 * time.Millisecond
+	// minAttemptDelay is the shortest a dial waits for a connection attempt
+	// before starting the next one, however close the deadline
+	minAttemptDelay = 10 * time.Millisecond
+)
+
+// ipResolver resolves host names, such as net.Resolver.
+type ipResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// dualStackDialer dials TCP connections to host names with both IPv6 and
+// IPv4 addresses like Happy Eyeballs, RFC 8305: the addresses are attempted
+// alternating between the families, each after the previous attempt failed
+// or a short delay passed, and the first connection wins. A host name whose
+// addresses of one family are unreachable connects with the other family
+// instead of timing out.
+type dualStackDialer struct {
+	resolver ipResolver
+	dial     func(ctx context.Context, network, address string) (net.Conn, error)
+	// timeout limits the whole dial, the delay between the attempts is
+	// derived from it
+	timeout time.Duration
+}
+
+// defaultDialer is the dialer of the connections of transports.
+var defaultDialer = &dualStackDialer{
+	resolver: net.DefaultResolver,
+	dial:     (&net.Dialer{}).DialContext,
+	timeout:  defaultDialTimeout,
+}
+
+// dialResult is the outcome of a connection attempt.
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// DialContext dials address, a host and a port, with TCP. The timeout of the
+// dialer and the deadline of ctx apply to the whole dial, not to every attempt.
+func (dialer *dualStackDialer) DialContext(ctx context.Context, address string) (_ net.Conn, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if dialer.timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, dialer.timeout)
+		defer cancel()
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return dialer.dial(ctx, "tcp", address)
+	}
+
+	addrs, err := dialer.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs = interleaveFamilies(addrs)
+	switch len(addrs) {
+	case 0:
+		return nil, Error.New("no addresses for %q", host)
+	case 1:
+		return dialer.dial(ctx, "tcp", net.JoinHostPort(addrs[0].String(), port))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	delay := attemptDelay(ctx, len(addrs))
+	results := make(chan dialResult, len(addrs))
+	started, pending := 0, 0
+	next := func() {
+		address := net.JoinHostPort(addrs[started].String(), port)
+		started++
+		pending++
+		go func() {
+			conn, err := dialer.dial(ctx, "tcp", address)
+			results <- dialResult{conn: conn, err: err}
+		}()
+	}
+
+	next()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var firstErr error
+	for pending > 0 {
+		fallback := timer.C
+		if started == len(addrs) {
+			fallback = nil
+		}
+
+		select {
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				if started > 1 {
+					mon.Counter("dial_attempts_raced").Inc(1)
+				}
+				cancel()
+				go closeLosers(results, pending)
+				return result.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			// the next address doesn't wait for the delay after a failure
+			if started < len(addrs) {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				next()
+				timer.Reset(delay)
+			}
+		case <-fallback:
+			next()
+			timer.Reset(delay)
+		}
+	}
+	return nil, firstErr
+}
+
+// closeLosers closes the connections of the pending attempts, which lost to
+// another attempt, once they complete.
+func closeLosers(results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		if result := <-results; result.conn != nil {
+			_ = result.conn.Close()
+		}
+	}
+}
+
+// interleaveFamilies returns addrs alternating between IPv6 and IPv4
+// addresses, starting with IPv6, otherwise in the order of the resolver.
+func interleaveFamilies(addrs []net.IPAddr) []net.IPAddr {
+	var ipv6, ipv4 []net.IPAddr
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			ipv4 = append(ipv4, addr)
+		} else {
+			ipv6 = append(ipv6, addr)
+		}
+	}
+
+	interleaved := make([]net.IPAddr, 0, len(addrs))
+	for len(ipv6) > 0 || len(ipv4) > 0 {
+		if len(ipv6) > 0 {
+			interleaved = append(interleaved, ipv6[0])
+			ipv6 = ipv6[1:]
+		}
+		if len(ipv4) > 0 {
+			interleaved = append(interleaved, ipv4[0])
+			ipv4 = ipv4[1:]
+		}
+	}
+	return interleaved
+}
+
+// attemptDelay returns how long a dial of attempts addresses with ctx waits
+// for an attempt before starting the next one. The attempts all start within
+// half the time until the deadline of ctx, which is at most the timeout of the
+// dialer, so that the last one has at least the other half to connect.
+func attemptDelay(ctx context.Context, attempts int) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return maxAttemptDelay
+	}
+	delay := time.Until(deadline) / time.Duration(2*attempts)
+	switch {
+	case delay > maxAttemptDelay:
+		return maxAttemptDelay
+	case delay < minAttemptDelay:
+		return minAttemptDelay
+	}
+	return delay
+}
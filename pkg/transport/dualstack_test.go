@@ -0,0 +1,105 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+)
+
+// staticResolver resolves every host name to its addresses.
+type staticResolver []net.IPAddr
+
+func (addrs staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return addrs, nil
+}
+
+func TestDualStackDial(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ctx.Check(listener.Close)
+	ctx.Go(func() error {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return nil
+			}
+			_ = conn.Close()
+		}
+	})
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	// the IPv6 address of the host is unroutable, its dials hang like ones
+	// to a black hole until they are canceled
+	canceled := make(chan error, 2)
+	dialer := &dualStackDialer{
+		resolver: staticResolver{{IP: net.ParseIP("100::1")}, {IP: net.ParseIP("127.0.0.1")}},
+		dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			if net.ParseIP(host).To4() == nil {
+				<-ctx.Done()
+				canceled <- ctx.Err()
+				return nil, ctx.Err()
+			}
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		},
+		timeout: 10 * time.Second,
+	}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, net.JoinHostPort("node.example.com", port))
+	require.NoError(t, err)
+	assert.True(t, time.Since(start) < 4*maxAttemptDelay, time.Since(start))
+	assert.Equal(t, "127.0.0.1:"+port, conn.RemoteAddr().String())
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-canceled:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the IPv6 attempt wasn't canceled")
+	}
+
+	// without a working address the dial fails within the timeout
+	dialer.resolver = staticResolver{{IP: net.ParseIP("100::1")}, {IP: net.ParseIP("100::2")}}
+	dialer.timeout = 200 * time.Millisecond
+	start = time.Now()
+	_, err = dialer.DialContext(ctx, net.JoinHostPort("node.example.com", port))
+	require.Error(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second, time.Since(start))
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, context.DeadlineExceeded, <-canceled)
+	}
+}
+
+func TestInterleaveFamilies(t *testing.T) {
+	addrs := func(ips ...string) (addrs []net.IPAddr) {
+		for _, ip := range ips {
+			addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		return addrs
+	}
+
+	assert.Equal(t,
+		addrs("2001:db8::1", "192.0.2.1", "2001:db8::2", "192.0.2.2", "192.0.2.3"),
+		interleaveFamilies(addrs("192.0.2.1", "192.0.2.2", "2001:db8::1", "192.0.2.3", "2001:db8::2")))
+	assert.Equal(t,
+		addrs("192.0.2.1", "192.0.2.2"),
+		interleaveFamilies(addrs("192.0.2.1", "192.0.2.2")))
+	assert.Empty(t, interleaveFamilies(nil))
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testdial"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storagenode"
+)
+
+func TestEgressPolicy(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	policy, err := transport.NewEgressPolicy(transport.EgressConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+	assert.NoError(t, policy.Check(ctx, "10.0.0.1:7777"))
+
+	policy, err = transport.NewEgressPolicy(transport.EgressConfig{
+		Ports: "7777, 28967-28970",
+		Deny:  "203.0.113.0/24,2001:db8::/32",
+	})
+	require.NoError(t, err)
+
+	for address, allowed := range map[string]bool{
+		"198.51.100.1:7777":         true,
+		"198.51.100.1:28968":        true,
+		"198.51.100.1:28971":        false,
+		"198.51.100.1:80":           false,
+		"203.0.113.7:7777":          false,
+		"[2001:db8::1]:7777":        false,
+		"[2001:db9::1]:7777":        true,
+		"[::ffff:203.0.113.7]:7777": false,
+		"198.51.100.1":              false,
+	} {
+		err := policy.Check(ctx, address)
+		if allowed {
+			assert.NoError(t, err, address)
+		} else {
+			assert.True(t, transport.PolicyDenied.Has(err), address)
+		}
+	}
+
+	// the private preset includes loopback unless it's allowed
+	for _, allowLoopback := range []bool{false, true} {
+		policy, err = transport.NewEgressPolicy(transport.EgressConfig{Deny: "private", AllowLoopback: allowLoopback})
+		require.NoError(t, err)
+
+		assert.True(t, transport.PolicyDenied.Has(policy.Check(ctx, "192.168.1.1:7777")))
+		assert.True(t, transport.PolicyDenied.Has(policy.Check(ctx, "[fd00::1]:7777")))
+		assert.NoError(t, policy.Check(ctx, "198.51.100.1:7777"))
+		for _, loopback := range []string{"127.0.0.1:7777", "[::1]:7777", "localhost:7777"} {
+			err := policy.Check(ctx, loopback)
+			assert.Equal(t, !allowLoopback, transport.PolicyDenied.Has(err), loopback)
+		}
+	}
+
+	for _, invalid := range []transport.EgressConfig{
+		{Ports: "0"},
+		{Ports: "70000"},
+		{Ports: "20-10"},
+		{Ports: "http"},
+		{Deny: "10.0.0.1"},
+		{Deny: "public"},
+	} {
+		_, err := transport.NewEgressPolicy(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEgressPolicyDials(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 0, StorageNodeCount: 2, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Egress = transport.EgressConfig{Deny: "private", AllowLoopback: true}
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		target := planet.StorageNodes[1].Local().Node
+
+		// the planet runs on loopback, which is allowed
+		recorder := testdial.WithDialRecorder(t, planet.StorageNodes[0].Transport)
+		conn, err := recorder.Client.DialNode(ctx, &target)
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+		recorder.ExpectOnly(target.Id)
+
+		// without allowing loopback the dial fails before connecting
+		policy, err := transport.NewEgressPolicy(transport.EgressConfig{Deny: "private"})
+		require.NoError(t, err)
+		recorder = testdial.WithDialRecorder(t, planet.StorageNodes[0].Transport.WithEgressPolicy(policy))
+
+		_, err = recorder.Client.DialNode(ctx, &target)
+		assert.True(t, transport.PolicyDenied.Has(err))
+		_, err = recorder.Client.DialAddress(ctx, target.Address.Address)
+		assert.True(t, transport.PolicyDenied.Has(err))
+		recorder.ExpectNone()
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+
+	"storj.io/storj/pkg/peertls"
+)
+
+// handshakeCredentials records the error of the failed client handshakes of
+// a dial. grpc only keeps the message of handshake errors, the recorded one
+// keeps its class, such as for a peer failing verification.
+type handshakeCredentials struct {
+	credentials.TransportCredentials
+	failure *handshakeFailure
+}
+
+// handshakeFailure is the error of the last failed handshake of a dial.
+type handshakeFailure struct {
+	mu  sync.Mutex
+	err error
+}
+
+// newHandshakeCredentials returns credentials recording the handshake errors of creds.
+func newHandshakeCredentials(creds credentials.TransportCredentials) *handshakeCredentials {
+	return &handshakeCredentials{
+		TransportCredentials: creds,
+		failure:              &handshakeFailure{},
+	}
+}
+
+// ClientHandshake does the handshake of conn and records its error.
+func (creds *handshakeCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := creds.TransportCredentials.ClientHandshake(ctx, authority, conn)
+	if err != nil && ctx.Err() == nil {
+		// grpc needs to know that verification errors aren't temporary,
+		// the callers need their class
+		failure := err
+		if nonTemporary, ok := err.(peertls.NonTemporaryError); ok {
+			failure = nonTemporary.Err()
+		}
+		creds.failure.mu.Lock()
+		creds.failure.err = failure
+		creds.failure.mu.Unlock()
+	}
+	return conn, info, err
+}
+
+// Clone returns a copy of the credentials recording to the same dial.
+func (creds *handshakeCredentials) Clone() credentials.TransportCredentials {
+	return &handshakeCredentials{
+		TransportCredentials: creds.TransportCredentials.Clone(),
+		failure:              creds.failure,
+	}
+}
+
+// dialError returns the error of a failed dial with creds: the error of the
+// handshake when one failed, otherwise the error of grpc.
+func (creds *handshakeCredentials) dialError(err error) error {
+	creds.failure.mu.Lock()
+	defer creds.failure.mu.Unlock()
+	if creds.failure.err != nil {
+		return creds.failure.err
+	}
+	return err
+}
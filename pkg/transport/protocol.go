@@ -0,0 +1,16 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package transport
+
+import (
+	"context"
+
+	"storj.io/storj/internal/version"
+)
+
+// withProtocol sends the protocol version of this build as outgoing metadata,
+// so servers can reject requests they would handle differently for old peers.
+func withProtocol(ctx context.Context) context.Context {
+	return version.WithProtocol(ctx, version.CurrentProtocol)
+}
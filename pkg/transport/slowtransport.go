@@ -6,19 +6,30 @@ package transport
 import (
 	"context"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
 
 	"storj.io/storj/internal/memory"
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/pb"
 )
 
 // SimulatedNetwork allows creating connections that try to simulated realistic network conditions.
+//
+// Clients and listeners each slow down their own side of a connection, so
+// a slow client can be combined with a slow server.
 type SimulatedNetwork struct {
 	DialLatency    time.Duration
+	Latency        time.Duration
 	BytesPerSecond memory.Size
+
+	// AcceptLatency and HandshakeLatency only apply to listeners
+	AcceptLatency    time.Duration
+	HandshakeLatency time.Duration
 }
 
 // NewClient wraps an exiting client with the simulated network params.
@@ -45,6 +56,19 @@ func (client *slowTransport) DialAddress(ctx context.Context, address string, op
 	return client.client.DialAddress(ctx, address, append(client.network.DialOptions(), opts...)...)
 }
 
+// DialTCP dials a TCP connection with latency
+func (client *slowTransport) DialTCP(ctx context.Context, address string) (net.Conn, error) {
+	timer := time.NewTimer(client.network.DialLatency)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	}
+	return client.client.DialTCP(ctx, address)
+}
+
 // Identity for slowTransport
 func (client *slowTransport) Identity() *identity.FullIdentity {
 	return client.client.Identity()
@@ -55,6 +79,26 @@ func (client *slowTransport) WithObservers(obs ...Observer) Client {
 	return &slowTransport{client.client.WithObservers(obs...), client.network}
 }
 
+// WithEvents calls WithEvents for slowTransport
+func (client *slowTransport) WithEvents(events *eventlog.Sink) Client {
+	return &slowTransport{client.client.WithEvents(events), client.network}
+}
+
+// WithEgressPolicy calls WithEgressPolicy for slowTransport
+func (client *slowTransport) WithEgressPolicy(policy *EgressPolicy) Client {
+	return &slowTransport{client.client.WithEgressPolicy(policy), client.network}
+}
+
+// WithProxy calls WithProxy for slowTransport
+func (client *slowTransport) WithProxy(proxy *Proxy) Client {
+	return &slowTransport{client.client.WithProxy(proxy), client.network}
+}
+
+// WithBandwidth calls WithBandwidth for slowTransport
+func (client *slowTransport) WithBandwidth(bandwidth *Bandwidth) Client {
+	return &slowTransport{client.client.WithBandwidth(bandwidth), client.network}
+}
+
 // DialOptions returns options such that it will use simulated network parameters
 func (network *SimulatedNetwork) DialOptions() []grpc.DialOption {
 	return []grpc.DialOption{grpc.WithContextDialer(network.GRPCDialContext)}
@@ -76,23 +120,70 @@ func (network *SimulatedNetwork) GRPCDialContext(ctx context.Context, address st
 		return conn, err
 	}
 
-	if network.BytesPerSecond == 0 {
+	if network.Latency == 0 && network.BytesPerSecond == 0 {
 		return conn, err
 	}
 
-	return &simulatedConn{network, conn}, nil
+	return &simulatedConn{network: network, Conn: conn}, nil
+}
+
+// NewListener wraps inner such that accepted connections use the simulated network params.
+func (network *SimulatedNetwork) NewListener(inner net.Listener) net.Listener {
+	return &simulatedListener{network, inner}
+}
+
+// simulatedListener implements slow accepting of connections
+//
+// Connections are accepted one at a time, each is delayed by the accept latency.
+type simulatedListener struct {
+	network *SimulatedNetwork
+	net.Listener
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (listener *simulatedListener) Accept() (net.Conn, error) {
+	conn, err := listener.Listener.Accept()
+	if err != nil {
+		return conn, err
+	}
+	time.Sleep(listener.network.AcceptLatency)
+
+	network := listener.network
+	if network.Latency == 0 && network.BytesPerSecond == 0 && network.HandshakeLatency == 0 {
+		return conn, nil
+	}
+
+	return &simulatedConn{network: network, Conn: conn, handshakeLatency: network.HandshakeLatency}, nil
 }
 
 // simulatedConn implements slow reading and writing to the connection
 //
+// The first read or write is delayed by the handshake latency. The network
+// latency is added once per message, a message being the writes between
+// reads, so a request or a response split into several writes is only
+// delayed once.
 // This does not handle read deadline and write deadline properly.
 type simulatedConn struct {
 	network *SimulatedNetwork
 	net.Conn
+
+	handshakeLatency time.Duration
+	handshake        sync.Once
+
+	// sending is 1 while writing a message that has already been delayed
+	sending int32
+}
+
+// delayHandshake sleeps the handshake latency once per connection
+func (conn *simulatedConn) delayHandshake() {
+	conn.handshake.Do(func() { time.Sleep(conn.handshakeLatency) })
 }
 
 // delay sleeps specified amount of time
 func (conn *simulatedConn) delay(actualWait time.Duration, bytes int) {
+	if conn.network.BytesPerSecond == 0 {
+		return
+	}
 	expectedWait := time.Duration(bytes * int(time.Second) / conn.network.BytesPerSecond.Int())
 	if actualWait < expectedWait {
 		time.Sleep(expectedWait - actualWait)
@@ -101,8 +192,11 @@ func (conn *simulatedConn) delay(actualWait time.Duration, bytes int) {
 
 // Read reads data from the connection.
 func (conn *simulatedConn) Read(b []byte) (n int, err error) {
+	conn.delayHandshake()
+
 	start := time.Now()
 	n, err = conn.Conn.Read(b)
+	atomic.StoreInt32(&conn.sending, 0)
 	if err == context.Canceled {
 		return n, err
 	}
@@ -112,6 +206,11 @@ func (conn *simulatedConn) Read(b []byte) (n int, err error) {
 
 // Write writes data to the connection.
 func (conn *simulatedConn) Write(b []byte) (n int, err error) {
+	conn.delayHandshake()
+	if atomic.CompareAndSwapInt32(&conn.sending, 0, 1) {
+		time.Sleep(conn.network.Latency)
+	}
+
 	start := time.Now()
 	n, err = conn.Conn.Write(b)
 	if err == context.Canceled {
@@ -16,12 +16,13 @@ type InvokeTimeout struct {
 	Timeout time.Duration
 }
 
-// Intercept adds a context timeout to a method call
+// Intercept adds a context timeout to a method call and sends the resulting
+// deadline and the protocol version to the server
 func (it InvokeTimeout) Intercept(ctx context.Context, method string, req interface{}, reply interface{},
 	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	timedCtx, cancel := context.WithTimeout(ctx, it.Timeout)
 	defer cancel()
-	return invoker(timedCtx, method, req, reply, cc, opts...)
+	return invoker(withProtocol(withDeadline(timedCtx)), method, req, reply, cc, opts...)
 }
 
 // InvokeStreamTimeout enables timeouts for send/recv/close stream requests
@@ -83,12 +84,12 @@ func (wrapper *clientStreamWrapper) withTimeout(f func() error) error {
 	return f()
 }
 
-// Intercept adds a timeout to a stream requests
+// Intercept adds a timeout to a stream requests and sends the protocol version to the server
 func (it InvokeStreamTimeout) Intercept(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (_ grpc.ClientStream, err error) {
 	wrapper := &clientStreamWrapper{timeout: it.Timeout}
 	ctx, wrapper.cancel = context.WithCancel(ctx)
 
-	wrapper.stream, err = streamer(ctx, desc, cc, method, opts...)
+	wrapper.stream, err = streamer(withProtocol(withDeadline(ctx)), desc, cc, method, opts...)
 	if err != nil {
 		return wrapper.stream, err
 	}
@@ -5,13 +5,17 @@ package transport
 
 import (
 	"context"
+	"net"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/storj"
 )
 
 // Observer implements the ConnSuccess and ConnFailure methods
@@ -25,14 +29,23 @@ type Observer interface {
 type Client interface {
 	DialNode(ctx context.Context, node *pb.Node, opts ...grpc.DialOption) (*grpc.ClientConn, error)
 	DialAddress(ctx context.Context, address string, opts ...grpc.DialOption) (*grpc.ClientConn, error)
+	DialTCP(ctx context.Context, address string) (net.Conn, error)
 	Identity() *identity.FullIdentity
 	WithObservers(obs ...Observer) Client
+	WithEvents(events *eventlog.Sink) Client
+	WithEgressPolicy(policy *EgressPolicy) Client
+	WithBandwidth(bandwidth *Bandwidth) Client
+	WithProxy(proxy *Proxy) Client
 }
 
 // Transport interface structure
 type Transport struct {
 	tlsOpts        *tlsopts.Options
 	observers      []Observer
+	events         *eventlog.Sink
+	egress         *EgressPolicy
+	bandwidth      *Bandwidth
+	proxy          *Proxy
 	requestTimeout time.Duration
 }
 
@@ -57,22 +70,26 @@ func NewClientWithTimeout(tlsOpts *tlsopts.Options, requestTimeout time.Duration
 // target node has the private key for the requested node ID.
 func (transport *Transport) DialNode(ctx context.Context, node *pb.Node, opts ...grpc.DialOption) (conn *grpc.ClientConn, err error) {
 	defer mon.Task()(&ctx)(&err)
+	defer transport.record("dial-node", node.Id, node.GetAddress().GetAddress(), time.Now())(&err)
 
 	if node.Address == nil || node.Address.Address == "" {
 		return nil, Error.New("no address")
 	}
-	dialOption, err := transport.tlsOpts.DialOption(node.Id)
-	if err != nil {
+	if err := transport.egress.Check(ctx, node.Address.Address); err != nil {
+		alertFail(ctx, transport.observers, node, err)
 		return nil, err
 	}
+	if node.Id.IsZero() {
+		return nil, Error.New("no node ID")
+	}
+	creds := newHandshakeCredentials(credentials.NewTLS(transport.tlsOpts.ClientTLSConfig(node.Id)))
 
 	options := append([]grpc.DialOption{
-		dialOption,
+		grpc.WithTransportCredentials(creds),
 		grpc.WithBlock(),
 		grpc.FailOnNonTempDialError(true),
-		grpc.WithUnaryInterceptor(InvokeTimeout{transport.requestTimeout}.Intercept),
-		grpc.WithStreamInterceptor(InvokeStreamTimeout{transport.requestTimeout}.Intercept),
-	}, opts...)
+	}, transport.interceptors(ctx)...)
+	options = append(options, opts...)
 
 	timedCtx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
 	defer cancel()
@@ -82,6 +99,7 @@ func (transport *Transport) DialNode(ctx context.Context, node *pb.Node, opts ..
 		if err == context.Canceled {
 			return nil, err
 		}
+		err = classifySelfDial(creds.dialError(err))
 		alertFail(timedCtx, transport.observers, node, err)
 		return nil, Error.Wrap(err)
 	}
@@ -98,23 +116,44 @@ func (transport *Transport) DialNode(ctx context.Context, node *pb.Node, opts ..
 // DialAddress.
 func (transport *Transport) DialAddress(ctx context.Context, address string, opts ...grpc.DialOption) (conn *grpc.ClientConn, err error) {
 	defer mon.Task()(&ctx)(&err)
+	defer transport.record("dial-address", storj.NodeID{}, address, time.Now())(&err)
+
+	if err := transport.egress.Check(ctx, address); err != nil {
+		return nil, err
+	}
+
+	creds := newHandshakeCredentials(credentials.NewTLS(transport.tlsOpts.UnverifiedClientTLSConfig()))
 
 	options := append([]grpc.DialOption{
-		transport.tlsOpts.DialUnverifiedIDOption(),
+		grpc.WithTransportCredentials(creds),
 		grpc.WithBlock(),
 		grpc.FailOnNonTempDialError(true),
-		grpc.WithUnaryInterceptor(InvokeTimeout{transport.requestTimeout}.Intercept),
-		grpc.WithStreamInterceptor(InvokeStreamTimeout{transport.requestTimeout}.Intercept),
-	}, opts...)
+	}, transport.interceptors(ctx)...)
+	options = append(options, opts...)
 
 	timedCtx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
 	defer cancel()
 
 	conn, err = grpc.DialContext(timedCtx, address, options...)
-	if err == context.Canceled {
+	if err != nil {
+		if err == context.Canceled {
+			return nil, err
+		}
+		return nil, Error.Wrap(classifySelfDial(creds.dialError(err)))
+	}
+	return conn, nil
+}
+
+// DialTCP dials a TCP connection to address like the connections of the grpc
+// dials, through the proxy of the transport if it has one, without TLS. It is
+// for diagnostics, such as checking whether a node is reachable at all.
+func (transport *Transport) DialTCP(ctx context.Context, address string) (conn net.Conn, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if err := transport.egress.Check(ctx, address); err != nil {
 		return nil, err
 	}
-	return conn, Error.Wrap(err)
+	return transport.dial(ctx, address)
 }
 
 // Identity is a getter for the transport's identity
@@ -124,12 +163,77 @@ func (transport *Transport) Identity() *identity.FullIdentity {
 
 // WithObservers returns a new transport including the listed observers.
 func (transport *Transport) WithObservers(obs ...Observer) Client {
-	tr := &Transport{tlsOpts: transport.tlsOpts, requestTimeout: transport.requestTimeout}
+	tr := &Transport{tlsOpts: transport.tlsOpts, events: transport.events, egress: transport.egress, bandwidth: transport.bandwidth, proxy: transport.proxy, requestTimeout: transport.requestTimeout}
 	tr.observers = append(tr.observers, transport.observers...)
 	tr.observers = append(tr.observers, obs...)
 	return tr
 }
 
+// WithEvents returns a new transport recording dials to events.
+func (transport *Transport) WithEvents(events *eventlog.Sink) Client {
+	tr := *transport
+	tr.events = events
+	return &tr
+}
+
+// WithEgressPolicy returns a new transport denying the dials policy doesn't allow.
+func (transport *Transport) WithEgressPolicy(policy *EgressPolicy) Client {
+	tr := *transport
+	tr.egress = policy
+	return &tr
+}
+
+// WithBandwidth returns a new transport counting the bytes of its connections in bandwidth.
+func (transport *Transport) WithBandwidth(bandwidth *Bandwidth) Client {
+	tr := *transport
+	tr.bandwidth = bandwidth
+	return &tr
+}
+
+// WithProxy returns a new transport dialing its connections through proxy,
+// nil dials directly.
+func (transport *Transport) WithProxy(proxy *Proxy) Client {
+	tr := *transport
+	tr.proxy = proxy
+	return &tr
+}
+
+// interceptors returns the options with the dialer and the interceptors of
+// connections dialed with ctx, which count their bytes when the transport has
+// a Bandwidth.
+func (transport *Transport) interceptors(ctx context.Context) []grpc.DialOption {
+	unary := InvokeTimeout{transport.requestTimeout}.Intercept
+	stream := InvokeStreamTimeout{transport.requestTimeout}.Intercept
+	if transport.bandwidth == nil {
+		return []grpc.DialOption{
+			grpc.WithContextDialer(transport.dial),
+			grpc.WithUnaryInterceptor(unary),
+			grpc.WithStreamInterceptor(stream),
+		}
+	}
+	return transport.bandwidth.newCounter(ctx, transport.dial).dialOptions(unary, stream)
+}
+
+// dial dials the TCP connection to address of a grpc connection, through the
+// proxy of the transport if it has one. Otherwise host names with both IPv6
+// and IPv4 addresses are dialed with both families, see dualStackDialer.
+func (transport *Transport) dial(ctx context.Context, address string) (net.Conn, error) {
+	if transport.proxy != nil {
+		return transport.proxy.DialContext(ctx, address)
+	}
+	return defaultDialer.DialContext(ctx, address)
+}
+
+// record records the outcome of a dial started at start.
+func (transport *Transport) record(operation string, id storj.NodeID, address string, start time.Time) func(*error) {
+	if transport.events == nil {
+		return func(*error) {}
+	}
+	return func(errptr *error) {
+		transport.events.Record(eventlog.NewEvent(operation, id, address, start, *errptr))
+	}
+}
+
 func alertFail(ctx context.Context, obs []Observer, node *pb.Node, err error) {
 	for _, o := range obs {
 		o.ConnFailure(ctx, node, err)
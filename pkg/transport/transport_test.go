@@ -13,14 +13,24 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/memory"
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/testidentity"
 	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/internal/transporttest"
+	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/peertls/tlsopts"
 	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storagenode"
 )
 
+func TestConformance(t *testing.T) {
+	transporttest.Run(t, transport.NewClient)
+}
+
 func TestDialNode(t *testing.T) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
@@ -159,6 +169,27 @@ func TestDialNode(t *testing.T) {
 		assert.NoError(t, conn.Close())
 	})
 
+	t.Run("DialNode with another ID", func(t *testing.T) {
+		target := &pb.Node{
+			Id: storj.NodeID{123},
+			Address: &pb.NodeAddress{
+				Transport: pb.NodeTransport_TCP_TLS_GRPC,
+				Address:   planet.StorageNodes[1].Addr(),
+			},
+		}
+
+		timedCtx, cancel := context.WithTimeout(ctx, time.Second)
+		conn, err := client.DialNode(timedCtx, target)
+		cancel()
+
+		assert.Nil(t, conn)
+		require.Error(t, err)
+		// the handshake error keeps its class through grpc
+		assert.True(t, transport.Error.Has(err), err)
+		assert.True(t, tlsopts.ErrPeerIDMismatch.Has(err), err)
+		assert.True(t, transport.IsVerificationError(err), err)
+	})
+
 	t.Run("DialAddress with unsigned identity", func(t *testing.T) {
 		timedCtx, cancel := context.WithTimeout(ctx, time.Second)
 		dialOption := unsignedClientOpts.DialUnverifiedIDOption()
@@ -252,3 +283,87 @@ func TestDialNode_BadServerCertificate(t *testing.T) {
 		assert.Contains(t, err.Error(), "not signed by any CA in the whitelist")
 	})
 }
+
+func TestSlowServer(t *testing.T) {
+	slow := &transport.SimulatedNetwork{HandshakeLatency: 2 * time.Second}
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 0, StorageNodeCount: 3, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				if index == 0 {
+					config.Server.WrapListener = slow.NewListener
+				}
+			},
+		},
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		client := planet.StorageNodes[1].Transport
+
+		dial := func(client transport.Client, target *storagenode.Peer) error {
+			timedCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+
+			node := target.Local().Node
+			conn, err := client.DialNode(timedCtx, &node)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}
+
+		// only the server is slow, the client gives up during the handshake
+		err := dial(client, planet.StorageNodes[0])
+		require.Error(t, err)
+		assert.True(t, transport.Error.Has(err))
+		assert.Equal(t, errs2.DialTimeout, errs2.ClassifyDial(err))
+
+		// other servers are unaffected
+		require.NoError(t, dial(client, planet.StorageNodes[2]))
+
+		// a slow client composes with a slow server
+		slowClient := (&transport.SimulatedNetwork{DialLatency: 100 * time.Millisecond}).NewClient(client)
+		require.NoError(t, dial(slowClient, planet.StorageNodes[2]))
+		err = dial(slowClient, planet.StorageNodes[0])
+		assert.Equal(t, errs2.DialTimeout, errs2.ClassifyDial(err))
+	})
+}
+
+func TestBandwidth(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 4, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		node := planet.StorageNodes[0]
+
+		usage := func(label string) (in, out memory.Size) {
+			for _, usage := range node.Bandwidth.Usage() {
+				if usage.Label == label {
+					return usage.In, usage.Out
+				}
+			}
+			return 0, 0
+		}
+
+		const lookups = 10
+		beforeIn, beforeOut := usage("kademlia")
+		for i := 0; i < lookups; i++ {
+			target := planet.StorageNodes[1+i%3]
+			_, err := node.Kademlia.Service.FindNode(kademlia.WithFreshLookup(ctx), target.ID())
+			require.NoError(t, err)
+		}
+		afterIn, afterOut := usage("kademlia")
+
+		in, out := afterIn-beforeIn, afterOut-beforeOut
+		t.Logf("%d lookups received %v and sent %v", lookups, in, out)
+		assert.True(t, in > 0 && out > 0)
+		// the lookups query a handful of nodes each, with the TLS handshakes
+		// of the dials being the bulk of the bytes, the background refreshes
+		// of kademlia are counted as well
+		assert.True(t, in < lookups*memory.MiB && out < lookups*memory.MiB)
+
+		// the bytes of a request on behalf of another component are attributed to it
+		labeled := transport.WithLabel(ctx, "census")
+		_, err := node.Kademlia.Service.Ping(labeled, planet.Satellites[0].Local().Node)
+		require.NoError(t, err)
+		censusIn, censusOut := usage("census")
+		assert.True(t, censusIn > 0 && censusOut > 0)
+	})
+}
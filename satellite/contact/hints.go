@@ -0,0 +1,73 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package contact records the storage nodes checking in with the satellite
+// and hints them how they should adapt.
+package contact
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/pb"
+)
+
+// Error is the default error class for contact hint errors
+var Error = errs.Class("contact")
+
+// Config defines the hints for the storage nodes checking in.
+type Config struct {
+	CheckInInterval         time.Duration `help:"how often storage nodes are asked to check in, 0 doesn't ask" default:"0s"`
+	MinimumVersion          string        `help:"the minimum storage node version that will be required, empty for none" default:""`
+	MinimumVersionEffective string        `help:"when the minimum storage node version will be required, in RFC 3339" default:""`
+}
+
+// Hinter hints the nodes checking in, it implements kademlia.ContactHinter.
+type Hinter struct {
+	checkInInterval *duration.Duration
+	minimumVersion  string
+	effective       *timestamp.Timestamp
+}
+
+// NewHinter returns the hinter of config.
+func NewHinter(config Config) (*Hinter, error) {
+	hinter := &Hinter{}
+	if config.CheckInInterval > 0 {
+		hinter.checkInInterval = ptypes.DurationProto(config.CheckInInterval)
+	}
+	if config.MinimumVersion != "" {
+		minimum, err := version.NewSemVer(config.MinimumVersion)
+		if err != nil {
+			return nil, Error.New("invalid minimum version: %v", err)
+		}
+		hinter.minimumVersion = minimum.String()
+	}
+	if config.MinimumVersionEffective != "" {
+		effective, err := time.Parse(time.RFC3339, config.MinimumVersionEffective)
+		if err != nil {
+			return nil, Error.New("invalid minimum version date: %v", err)
+		}
+		hinter.effective, err = ptypes.TimestampProto(effective)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+	}
+	return hinter, nil
+}
+
+// ContactHints returns the hints for node, reachable is whether the satellite
+// reached it at its address.
+func (hinter *Hinter) ContactHints(ctx context.Context, node pb.Node, reachable bool) *pb.ContactHints {
+	return &pb.ContactHints{
+		CheckInInterval:         hinter.checkInInterval,
+		AddressOk:               reachable,
+		MinimumVersion:          hinter.minimumVersion,
+		MinimumVersionEffective: hinter.effective,
+	}
+}
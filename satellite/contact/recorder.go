@@ -0,0 +1,40 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"storj.io/storj/pkg/overlay"
+	"storj.io/storj/pkg/pb"
+)
+
+// Recorder records the check-ins of the nodes in the overlay, it implements
+// kademlia.CheckInRecorder.
+type Recorder struct {
+	log     *zap.Logger
+	overlay *overlay.Cache
+}
+
+// NewRecorder returns a recorder of the check-ins in cache.
+func NewRecorder(log *zap.Logger, cache *overlay.Cache) *Recorder {
+	return &Recorder{log: log, overlay: cache}
+}
+
+// RecordCheckIn records that node checked in, reachable is whether the
+// satellite reached it at its address. The node is seen when it was reachable,
+// only then its address is updated.
+func (recorder *Recorder) RecordCheckIn(ctx context.Context, node pb.Node, reachable bool) {
+	if reachable {
+		if err := recorder.overlay.Put(ctx, node.Id, node); err != nil {
+			recorder.log.Debug("could not update checked in node", zap.String("nodeID", node.Id.String()), zap.Error(err))
+			return
+		}
+	}
+	if _, err := recorder.overlay.UpdateUptime(ctx, node.Id, reachable); err != nil {
+		recorder.log.Debug("could not record check-in", zap.String("nodeID", node.Id.String()), zap.Error(err))
+	}
+}
@@ -18,8 +18,10 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/lifecycle"
 	"storj.io/storj/internal/post"
 	"storj.io/storj/internal/post/oauth2"
+	"storj.io/storj/internal/sync2"
 	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/accounting"
 	"storj.io/storj/pkg/accounting/rollup"
@@ -34,6 +36,7 @@ import (
 	"storj.io/storj/pkg/datarepair/queue"
 	"storj.io/storj/pkg/datarepair/repairer"
 	"storj.io/storj/pkg/discovery"
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/overlay"
@@ -45,6 +48,7 @@ import (
 	"storj.io/storj/satellite/console"
 	"storj.io/storj/satellite/console/consoleauth"
 	"storj.io/storj/satellite/console/consoleweb"
+	"storj.io/storj/satellite/contact"
 	"storj.io/storj/satellite/inspector"
 	"storj.io/storj/satellite/mailservice"
 	"storj.io/storj/satellite/mailservice/simulate"
@@ -52,6 +56,7 @@ import (
 	"storj.io/storj/satellite/orders"
 	"storj.io/storj/storage"
 	"storj.io/storj/storage/boltdb"
+	"storj.io/storj/storage/memorykv"
 )
 
 // DB is the master database for the satellite
@@ -90,10 +95,14 @@ type Config struct {
 
 	// TODO: switch to using server.Config when Identity has been removed from it
 	Server server.Config
+	Egress transport.EgressConfig
+	Events eventlog.Config
 
-	Kademlia  kademlia.Config
-	Overlay   overlay.Config
-	Discovery discovery.Config
+	Kademlia     kademlia.Config
+	RoutingTable RoutingTableConfig
+	Overlay      overlay.Config
+	Discovery    discovery.Config
+	Contact      contact.Config
 
 	Metainfo    metainfo.Config
 	BwAgreement bwagreement.Config // TODO: decide whether to keep empty configs for consistency
@@ -111,6 +120,17 @@ type Config struct {
 	Version version.Config
 }
 
+// Routing table stores selectable with RoutingTableConfig.Store.
+const (
+	RoutingTableBolt   = "bolt"
+	RoutingTableMemory = "memory"
+)
+
+// RoutingTableConfig defines where the satellite keeps the kademlia routing table
+type RoutingTableConfig struct {
+	Store string `help:"routing table store, bolt or memory" default:"bolt"`
+}
+
 // Peer is the satellite
 type Peer struct {
 	// core dependencies
@@ -119,14 +139,20 @@ type Peer struct {
 	DB       DB
 
 	Transport transport.Client
+	Bandwidth *transport.Bandwidth
+	Events    *eventlog.Sink
 
 	Server *server.Server
 
 	Version *version.Service
+	// NextRuns reports the next runs of the periodic loops
+	NextRuns sync2.NextRuns
+	// Services are the background services, run by Run and closed by Close
+	Services *lifecycle.Group
 
 	// services and endpoints
 	Kademlia struct {
-		kdb, ndb storage.KeyValueStore // TODO: move these into DB
+		kdb, ndb, idb, pdb, fdb storage.KeyValueStore // TODO: move these into DB
 
 		RoutingTable *kademlia.RoutingTable
 		Service      *kademlia.Kademlia
@@ -134,6 +160,11 @@ type Peer struct {
 		Inspector    *kademlia.Inspector
 	}
 
+	Contact struct {
+		Hinter   *contact.Hinter
+		Recorder *contact.Recorder
+	}
+
 	Overlay struct {
 		Service   *overlay.Cache
 		Inspector *overlay.Inspector
@@ -193,6 +224,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config *Config, ve
 		Log:      log,
 		Identity: full,
 		DB:       db,
+		Services: lifecycle.NewGroup(log.Named("services")),
 	}
 
 	var err error
@@ -203,7 +235,10 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config *Config, ve
 			peer.Log.Sugar().Debugf("Binary Version: %s with CommitHash %s, built at %s as Release %v",
 				versionInfo.Version.String(), versionInfo.CommitHash, versionInfo.Timestamp.String(), versionInfo.Release)
 		}
-		peer.Version = version.NewService(config.Version, versionInfo, "Satellite")
+		peer.Version = version.NewService(log.Named("version"), config.Version, versionInfo, "Satellite")
+		peer.Version.Loop.SetSplay(sync2.NewSplay(peer.Identity.ID.Bytes(), "version"))
+		peer.NextRuns.Add("version", peer.Version.Loop)
+		peer.Services.Add("version", peer.Version)
 	}
 
 	{ // setup listener and server
@@ -214,12 +249,29 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config *Config, ve
 			return nil, errs.Combine(err, peer.Close())
 		}
 
-		peer.Transport = transport.NewClient(options)
+		peer.Events, err = eventlog.Open(peer.Log.Named("events"), config.Events)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+
+		egress, err := transport.NewEgressPolicy(config.Egress)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		proxy, err := transport.NewProxy(config.Egress.ProxyURL)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.Bandwidth = transport.NewBandwidth()
+		peer.Transport = transport.NewClient(options).WithEvents(peer.Events).WithEgressPolicy(egress).WithProxy(proxy).WithBandwidth(peer.Bandwidth)
 
-		peer.Server, err = server.New(options, sc.Address, sc.PrivateAddress, grpcauth.NewAPIKeyInterceptor())
+		peer.Server, err = server.New(peer.Log.Named("server"), options, sc.Address, sc.PrivateAddress, grpcauth.NewAPIKeyInterceptor())
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		if sc.WrapListener != nil {
+			peer.Server.WrapListener(sc.WrapListener)
+		}
 	}
 
 	{ // setup overlay
@@ -235,10 +287,12 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config *Config, ve
 
 	{ // setup kademlia
 		log.Debug("Setting up Kademlia")
+		rtConfig := config.RoutingTable
+		contactConfig := config.Contact
 		config := config.Kademlia
 		// TODO: move this setup logic into kademlia package
 		if config.ExternalAddress == "" {
-			config.ExternalAddress = peer.Addr()
+			config.ExternalAddress = peer.Server.ExternalAddr()
 		}
 
 		pbVersion, err := versionInfo.Proto()
@@ -263,33 +317,66 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config *Config, ve
 		{ // setup routing table
 			// TODO: clean this up, should be part of database
 			log.Debug("Setting up routing table")
-			bucketIdentifier := peer.ID().String()[:5] // need a way to differentiate between nodes if running more than one simultaneously
-			dbpath := filepath.Join(config.DBPath, fmt.Sprintf("kademlia_%s.db", bucketIdentifier))
-
-			if err := os.MkdirAll(config.DBPath, 0777); err != nil && !os.IsExist(err) {
-				return nil, err
-			}
-
-			dbs, err := boltdb.NewShared(dbpath, kademlia.KademliaBucket, kademlia.NodeBucket)
-			if err != nil {
-				return nil, errs.Combine(err, peer.Close())
+			switch store := rtConfig.Store; store {
+			case RoutingTableMemory:
+				// overlay is the source of truth for satellites, so the routing table
+				// doesn't need to survive restarts; verified identities, pinned nodes and dial failures aren't persisted
+				peer.Kademlia.kdb, peer.Kademlia.ndb = memorykv.New(), memorykv.New()
+			case "", RoutingTableBolt:
+				bucketIdentifier := peer.ID().String()[:5] // need a way to differentiate between nodes if running more than one simultaneously
+				dbpath := filepath.Join(config.DBPath, fmt.Sprintf("kademlia_%s.db", bucketIdentifier))
+
+				if err := os.MkdirAll(config.DBPath, 0777); err != nil && !os.IsExist(err) {
+					return nil, err
+				}
+
+				dbs, err := boltdb.NewShared(dbpath, kademlia.KademliaBucket, kademlia.NodeBucket, kademlia.IdentityBucket, kademlia.PinnedBucket, kademlia.FailureBucket)
+				if err != nil {
+					return nil, errs.Combine(err, peer.Close())
+				}
+				peer.Kademlia.kdb, peer.Kademlia.ndb, peer.Kademlia.idb, peer.Kademlia.pdb, peer.Kademlia.fdb = dbs[0], dbs[1], dbs[2], dbs[3], dbs[4]
+			default:
+				return nil, errs.Combine(errs.New("unknown routing table store %q", store), peer.Close())
 			}
-			peer.Kademlia.kdb, peer.Kademlia.ndb = dbs[0], dbs[1]
 
 			peer.Kademlia.RoutingTable, err = kademlia.NewRoutingTable(peer.Log.Named("routing"), self, peer.Kademlia.kdb, peer.Kademlia.ndb, &config.RoutingTableConfig)
 			if err != nil {
 				return nil, errs.Combine(err, peer.Close())
 			}
+			if peer.Kademlia.pdb != nil {
+				if err := peer.Kademlia.RoutingTable.LoadPinned(peer.Kademlia.pdb); err != nil {
+					return nil, errs.Combine(err, peer.Close())
+				}
+			}
 
 			peer.Transport = peer.Transport.WithObservers(peer.Kademlia.RoutingTable)
 		}
 
-		peer.Kademlia.Service, err = kademlia.NewService(peer.Log.Named("kademlia"), peer.Transport, peer.Kademlia.RoutingTable, config)
+		peer.Kademlia.Service, err = kademlia.NewService(peer.Log.Named("kademlia"), peer.Transport, peer.Kademlia.RoutingTable, peer.Kademlia.idb, config)
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		peer.NextRuns.AddFunc(peer.Kademlia.Service.NextRuns)
+		peer.Services.Add("kademlia", peer.Kademlia.Service)
+		peer.Services.Add("kademlia bootstrap", lifecycle.Func(peer.Kademlia.Service.Bootstrap))
+		if peer.Kademlia.fdb != nil {
+			if err := peer.Kademlia.Service.LoadDialFailures(peer.Kademlia.fdb); err != nil {
+				return nil, errs.Combine(err, peer.Close())
+			}
+		}
+		peer.Kademlia.Service.SetEventSink(peer.Events)
+		peer.Kademlia.Service.SetFeatures(peer.Version)
 
 		peer.Kademlia.Endpoint = kademlia.NewEndpoint(peer.Log.Named("kademlia:endpoint"), peer.Kademlia.Service, peer.Kademlia.RoutingTable)
+
+		peer.Contact.Hinter, err = contact.NewHinter(contactConfig)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.Kademlia.Endpoint.SetContactHinter(peer.Contact.Hinter)
+		peer.Contact.Recorder = contact.NewRecorder(peer.Log.Named("contact:recorder"), peer.Overlay.Service)
+		peer.Kademlia.Endpoint.SetCheckInRecorder(peer.Contact.Recorder)
+		peer.Kademlia.Endpoint.SetNodeMetadata(peer.Overlay.Service)
 		pb.RegisterNodesServer(peer.Server.GRPC(), peer.Kademlia.Endpoint)
 
 		peer.Kademlia.Inspector = kademlia.NewInspector(peer.Kademlia.Service, peer.Identity)
@@ -300,6 +387,8 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config *Config, ve
 		log.Debug("Setting up discovery")
 		config := config.Discovery
 		peer.Discovery.Service = discovery.New(peer.Log.Named("discovery"), peer.Overlay.Service, peer.Kademlia.Service, config)
+		peer.NextRuns.AddFunc(peer.Discovery.Service.NextRuns)
+		peer.Services.Add("discovery", peer.Discovery.Service)
 	}
 
 	{ // setup orders
@@ -516,18 +605,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config *Config, ve
 func (peer *Peer) Run(ctx context.Context) error {
 	group, ctx := errgroup.WithContext(ctx)
 
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Version.Run(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Kademlia.Service.Bootstrap(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Kademlia.Service.Run(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Discovery.Service.Run(ctx))
-	})
+	peer.Services.Start(ctx, group)
 	group.Go(func() error {
 		return errs2.IgnoreCanceled(peer.Repair.Checker.Run(ctx))
 	})
@@ -593,14 +671,8 @@ func (peer *Peer) Close() error {
 		errlist.Add(peer.Metainfo.Database.Close())
 	}
 
-	if peer.Discovery.Service != nil {
-		errlist.Add(peer.Discovery.Service.Close())
-	}
-
 	// TODO: add kademlia.Endpoint for consistency
-	if peer.Kademlia.Service != nil {
-		errlist.Add(peer.Kademlia.Service.Close())
-	}
+	errlist.Add(peer.Services.Close())
 	if peer.Kademlia.RoutingTable != nil {
 		errlist.Add(peer.Kademlia.RoutingTable.Close())
 	}
@@ -609,9 +681,14 @@ func (peer *Peer) Close() error {
 		errlist.Add(peer.Overlay.Service.Close())
 	}
 
-	if peer.Kademlia.ndb != nil || peer.Kademlia.kdb != nil {
-		errlist.Add(peer.Kademlia.kdb.Close())
-		errlist.Add(peer.Kademlia.ndb.Close())
+	for _, db := range []storage.KeyValueStore{peer.Kademlia.kdb, peer.Kademlia.ndb, peer.Kademlia.idb, peer.Kademlia.pdb, peer.Kademlia.fdb} {
+		if db != nil {
+			errlist.Add(db.Close())
+		}
+	}
+
+	if peer.Events != nil {
+		errlist.Add(peer.Events.Close())
 	}
 
 	return errlist.Err()
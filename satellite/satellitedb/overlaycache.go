@@ -50,19 +50,31 @@ func (cache *overlaycache) SelectStorageNodes(ctx context.Context, count int, cr
 		time.Now().Add(-criteria.OnlineWindow))
 
 	if criteria.MinimumVersion != "" {
-		v, err := version.NewSemVer(criteria.MinimumVersion)
+		condition, versionArgs, err := minimumVersionCondition(criteria.MinimumVersion)
 		if err != nil {
-			return nil, Error.New("invalid node selection criteria version: %v", err)
+			return nil, err
 		}
-		safeQuery += `
-			AND major > ? OR (major = ? AND (minor > ? OR (minor = ? AND patch >= ?)))
-			AND release`
-		args = append(args, v.Major, v.Major, v.Minor, v.Minor, v.Patch)
+		safeQuery += condition
+		args = append(args, versionArgs...)
 	}
 
 	return cache.queryFilteredNodes(ctx, criteria.Excluded, count, safeQuery, args...)
 }
 
+// minimumVersionCondition returns the condition selecting the releases of at
+// least the minimum version, and the development builds of an unknown version,
+// which aren't compared.
+func minimumVersionCondition(minimum string) (string, []interface{}, error) {
+	v, err := version.NewSemVer(minimum)
+	if err != nil {
+		return "", nil, Error.New("invalid node selection criteria version: %v", err)
+	}
+	condition := `
+		AND ((release AND (major > ? OR (major = ? AND (minor > ? OR (minor = ? AND patch >= ?)))))
+		  OR (NOT release AND major = 0 AND minor = 0 AND patch = 0))`
+	return condition, []interface{}{v.Major, v.Major, v.Minor, v.Minor, v.Patch}, nil
+}
+
 func (cache *overlaycache) SelectNewStorageNodes(ctx context.Context, count int, criteria *overlay.NodeCriteria) ([]*pb.Node, error) {
 	nodeType := int(pb.NodeType_STORAGE)
 
@@ -75,14 +87,12 @@ func (cache *overlaycache) SelectNewStorageNodes(ctx context.Context, count int,
 		nodeType, criteria.FreeBandwidth, criteria.FreeDisk, criteria.AuditCount, criteria.AuditSuccessRatio, time.Now().Add(-criteria.OnlineWindow))
 
 	if criteria.MinimumVersion != "" {
-		v, err := version.NewSemVer(criteria.MinimumVersion)
+		condition, versionArgs, err := minimumVersionCondition(criteria.MinimumVersion)
 		if err != nil {
-			return nil, Error.New("invalid node selection criteria version: %v", err)
+			return nil, err
 		}
-		safeQuery += `
-			AND major > ? OR (major = ? AND (minor > ? OR (minor = ? AND patch >= ?)))
-			AND release`
-		args = append(args, v.Major, v.Major, v.Minor, v.Minor, v.Patch)
+		safeQuery += condition
+		args = append(args, versionArgs...)
 	}
 
 	return cache.queryFilteredNodes(ctx, criteria.Excluded, count, safeQuery, args...)
@@ -412,20 +422,17 @@ func (cache *overlaycache) UpdateNodeInfo(ctx context.Context, nodeID storj.Node
 			updateFields.FreeBandwidth = dbx.Node_FreeBandwidth(nodeInfo.GetCapacity().GetFreeBandwidth())
 		}
 		if nodeInfo.GetVersion() != nil {
-			semVer, err := version.NewSemVer(nodeInfo.GetVersion().GetVersion())
-			if err != nil {
-				return nil, errs.New("unable to convert version to semVer")
-			}
-			pbts, err := ptypes.Timestamp(nodeInfo.GetVersion().GetTimestamp())
+			// a node without version is stored with the unknown version
+			info, err := version.FromProto(nodeInfo.GetVersion())
 			if err != nil {
-				return nil, errs.New("unable to convert version timestamp")
+				return nil, errs.New("unable to convert version: %v", err)
 			}
-			updateFields.Major = dbx.Node_Major(semVer.Major)
-			updateFields.Minor = dbx.Node_Minor(semVer.Minor)
-			updateFields.Patch = dbx.Node_Patch(semVer.Patch)
-			updateFields.Hash = dbx.Node_Hash(nodeInfo.GetVersion().GetCommitHash())
-			updateFields.Timestamp = dbx.Node_Timestamp(pbts)
-			updateFields.Release = dbx.Node_Release(nodeInfo.GetVersion().GetRelease())
+			updateFields.Major = dbx.Node_Major(info.Version.Major)
+			updateFields.Minor = dbx.Node_Minor(info.Version.Minor)
+			updateFields.Patch = dbx.Node_Patch(info.Version.Patch)
+			updateFields.Hash = dbx.Node_Hash(info.CommitHash)
+			updateFields.Timestamp = dbx.Node_Timestamp(info.Timestamp)
+			updateFields.Release = dbx.Node_Release(info.Release)
 		}
 	}
 
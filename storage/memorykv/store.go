@@ -0,0 +1,206 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package memorykv implements a key/value store that keeps the items in memory.
+package memorykv
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"storj.io/storj/storage"
+)
+
+// Client implements an in-memory key/value store.
+//
+// Unlike teststore it has no hooks for tests, and iteration works on a
+// snapshot of the items, so the store can be used while iterating.
+type Client struct {
+	mu    sync.RWMutex
+	items []storage.ListItem
+}
+
+// New creates an empty in-memory key/value store.
+func New() *Client { return &Client{} }
+
+// indexOf finds the index of key or where it could be inserted, store.mu must be held.
+func (store *Client) indexOf(key storage.Key) (int, bool) {
+	i := sort.Search(len(store.items), func(k int) bool {
+		return !store.items[k].Key.Less(key)
+	})
+	return i, i < len(store.items) && store.items[i].Key.Equal(key)
+}
+
+// prefixEnd finds the index after the last key with prefix, store.mu must be held.
+func (store *Client) prefixEnd(prefix storage.Key) int {
+	return sort.Search(len(store.items), func(k int) bool {
+		key := store.items[k].Key
+		return !key.Less(prefix) && !bytes.HasPrefix(key, prefix)
+	})
+}
+
+// Put adds a value to store
+func (store *Client) Put(key storage.Key, value storage.Value) error {
+	if key.IsZero() {
+		return storage.ErrEmptyKey.New("")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	i, found := store.indexOf(key)
+	if found {
+		// values are replaced rather than modified, since snapshots may refer to them
+		store.items[i].Value = storage.CloneValue(value)
+		return nil
+	}
+
+	store.items = append(store.items, storage.ListItem{})
+	copy(store.items[i+1:], store.items[i:])
+	store.items[i] = storage.ListItem{
+		Key:   storage.CloneKey(key),
+		Value: storage.CloneValue(value),
+	}
+	return nil
+}
+
+// Get gets a value to store
+func (store *Client) Get(key storage.Key) (storage.Value, error) {
+	if key.IsZero() {
+		return nil, storage.ErrEmptyKey.New("")
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	i, found := store.indexOf(key)
+	if !found {
+		return nil, storage.ErrKeyNotFound.New(key.String())
+	}
+	return storage.CloneValue(store.items[i].Value), nil
+}
+
+// GetAll gets all values from the store
+func (store *Client) GetAll(keys storage.Keys) (storage.Values, error) {
+	if len(keys) > storage.LookupLimit {
+		return nil, storage.ErrLimitExceeded
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	values := make(storage.Values, 0, len(keys))
+	for _, key := range keys {
+		i, found := store.indexOf(key)
+		if !found {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, storage.CloneValue(store.items[i].Value))
+	}
+	return values, nil
+}
+
+// Delete deletes key and the value
+func (store *Client) Delete(key storage.Key) error {
+	if key.IsZero() {
+		return storage.ErrEmptyKey.New("")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	i, found := store.indexOf(key)
+	if !found {
+		return storage.ErrKeyNotFound.New(key.String())
+	}
+
+	copy(store.items[i:], store.items[i+1:])
+	store.items[len(store.items)-1] = storage.ListItem{}
+	store.items = store.items[:len(store.items)-1]
+	return nil
+}
+
+// List lists all keys starting from start and upto limit items
+func (store *Client) List(first storage.Key, limit int) (storage.Keys, error) {
+	return storage.ListKeys(store, first, limit)
+}
+
+// Close closes the store
+func (store *Client) Close() error { return nil }
+
+// Iterate iterates over items based on opts
+func (store *Client) Iterate(opts storage.IterateOptions, fn func(storage.Iterator) error) error {
+	snapshot := store.snapshot(opts)
+
+	var lastPrefix storage.Key
+	return fn(storage.IteratorFunc(func(item *storage.ListItem) bool {
+		for len(snapshot) > 0 {
+			next := snapshot[0]
+			snapshot = snapshot[1:]
+
+			if !opts.Recurse {
+				if p := bytes.IndexByte(next.Key[len(opts.Prefix):], storage.Delimiter); p >= 0 {
+					prefix := next.Key[:len(opts.Prefix)+p+1]
+					if lastPrefix != nil && prefix.Equal(lastPrefix) {
+						continue
+					}
+					lastPrefix = prefix
+
+					item.Key = append(item.Key[:0], prefix...)
+					item.Value = item.Value[:0]
+					item.IsPrefix = true
+					return true
+				}
+			}
+
+			item.Key = append(item.Key[:0], next.Key...)
+			item.Value = append(item.Value[:0], next.Value...)
+			item.IsPrefix = false
+			return true
+		}
+		return false
+	}))
+}
+
+// snapshot returns the items to iterate over in order. Keys and values are
+// never modified in place, so only the list is copied.
+func (store *Client) snapshot(opts storage.IterateOptions) []storage.ListItem {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	start, _ := store.indexOf(opts.Prefix)
+	end := store.prefixEnd(opts.Prefix)
+
+	if !opts.Reverse {
+		if !opts.First.IsZero() {
+			if i, _ := store.indexOf(opts.First); i > start {
+				start = i
+			}
+		}
+		if start >= end {
+			return nil
+		}
+		return append([]storage.ListItem(nil), store.items[start:end]...)
+	}
+
+	if !opts.First.IsZero() {
+		// position on first or the item before it
+		i, found := store.indexOf(opts.First)
+		if found {
+			i++
+		}
+		if i < end {
+			end = i
+		}
+	}
+	if start >= end {
+		return nil
+	}
+	snapshot := make([]storage.ListItem, 0, end-start)
+	for i := end - 1; i >= start; i-- {
+		snapshot = append(snapshot, store.items[i])
+	}
+	return snapshot
+}
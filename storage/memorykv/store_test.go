@@ -0,0 +1,47 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package memorykv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/storage"
+	"storj.io/storj/storage/testsuite"
+)
+
+func TestSuite(t *testing.T)      { testsuite.RunTests(t, New()) }
+func BenchmarkSuite(b *testing.B) { testsuite.RunBenchmarks(b, New()) }
+
+func TestModifyWhileIterating(t *testing.T) {
+	store := New()
+	require.NoError(t, storage.PutAll(store,
+		storage.ListItem{Key: storage.Key("a"), Value: storage.Value("1")},
+		storage.ListItem{Key: storage.Key("b"), Value: storage.Value("2")},
+	))
+
+	var keys []string
+	err := store.Iterate(storage.IterateOptions{Recurse: true}, func(it storage.Iterator) error {
+		var item storage.ListItem
+		for it.Next(&item) {
+			keys = append(keys, string(item.Key))
+			if err := store.Delete(item.Key); err != nil {
+				return err
+			}
+			if err := store.Put(storage.Key("c"), storage.Value("3")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the iteration sees the items as they were when it started
+	assert.Equal(t, []string{"a", "b"}, keys)
+	value, err := store.Get(storage.Key("c"))
+	require.NoError(t, err)
+	assert.Equal(t, storage.Value("3"), value)
+}
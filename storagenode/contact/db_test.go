@@ -0,0 +1,98 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storagenode"
+	"storj.io/storj/storagenode/contact"
+	"storj.io/storj/storagenode/storagenodedb/storagenodedbtest"
+)
+
+func TestDB(t *testing.T) {
+	storagenodedbtest.Run(t, func(t *testing.T, db storagenode.DB) {
+		ctx := testcontext.New(t)
+		defer ctx.Cleanup()
+
+		contactdb := db.Contact()
+
+		satellite0 := testidentity.MustPregeneratedSignedIdentity(0, storj.LatestIDVersion()).ID
+		satellite1 := testidentity.MustPregeneratedSignedIdentity(1, storj.LatestIDVersion()).ID
+
+		all, err := contactdb.List(ctx)
+		require.NoError(t, err)
+		require.Empty(t, all)
+
+		now := time.Now().UTC()
+
+		require.NoError(t, contactdb.Update(ctx, satellite0, contact.Status{LastSuccess: now}))
+		require.NoError(t, contactdb.Update(ctx, satellite1, contact.Status{LastFailure: now, Failures: 1}))
+		require.NoError(t, contactdb.Update(ctx, satellite1, contact.Status{LastFailure: now.Add(time.Minute), Failures: 2}))
+
+		all, err = contactdb.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+
+		require.True(t, now.Equal(all[satellite0].LastSuccess))
+		require.Equal(t, 0, all[satellite0].Failures)
+
+		require.True(t, now.Add(time.Minute).Equal(all[satellite1].LastFailure))
+		require.Equal(t, 2, all[satellite1].Failures)
+	})
+}
+
+func TestRollupsDB(t *testing.T) {
+	storagenodedbtest.Run(t, func(t *testing.T, db storagenode.DB) {
+		ctx := testcontext.New(t)
+		defer ctx.Cleanup()
+
+		contactdb := db.Contact()
+
+		satellite0 := testidentity.MustPregeneratedSignedIdentity(0, storj.LatestIDVersion()).ID
+		satellite1 := testidentity.MustPregeneratedSignedIdentity(1, storj.LatestIDVersion()).ID
+
+		today := time.Date(2019, 6, 2, 0, 0, 0, 0, time.UTC)
+		yesterday := today.Add(-24 * time.Hour)
+
+		require.NoError(t, contactdb.AddRollup(ctx, contact.Rollup{SatelliteID: satellite1, Day: today, Attempts: 1, Successes: 1}))
+		require.NoError(t, contactdb.AddRollup(ctx, contact.Rollup{SatelliteID: satellite0, Day: today, Attempts: 1, Windows: 1}))
+		require.NoError(t, contactdb.AddRollup(ctx, contact.Rollup{SatelliteID: satellite0, Day: today, Observed: time.Hour, Downtime: time.Hour}))
+		require.NoError(t, contactdb.AddRollup(ctx, contact.Rollup{SatelliteID: satellite0, Day: yesterday, Attempts: 1, Successes: 1, Observed: time.Minute}))
+
+		rollups, err := contactdb.Rollups(ctx, yesterday)
+		require.NoError(t, err)
+		require.Len(t, rollups, 3)
+
+		// sorted by day, the rollups of the same day are added up
+		require.True(t, yesterday.Equal(rollups[0].Day))
+		require.Equal(t, satellite0, rollups[0].SatelliteID)
+		require.Equal(t, time.Minute, rollups[0].Observed)
+
+		for _, rollup := range rollups[1:] {
+			require.True(t, today.Equal(rollup.Day))
+			if rollup.SatelliteID == satellite0 {
+				require.Equal(t, 1, rollup.Attempts)
+				require.Equal(t, 0, rollup.Successes)
+				require.Equal(t, 1, rollup.Windows)
+				require.Equal(t, time.Hour, rollup.Observed)
+				require.Equal(t, time.Hour, rollup.Downtime)
+			} else {
+				require.Equal(t, satellite1, rollup.SatelliteID)
+				require.Equal(t, 1, rollup.Successes)
+			}
+		}
+
+		require.NoError(t, contactdb.DeleteRollupsBefore(ctx, today))
+		rollups, err = contactdb.Rollups(ctx, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, rollups, 2)
+	})
+}
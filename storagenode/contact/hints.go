@@ -0,0 +1,105 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"go.uber.org/zap"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// Hints returns the hints of the satellite from its last successful check-in.
+func (service *Service) Hints(satelliteID storj.NodeID) (*pb.ContactHints, bool) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	hints, ok := service.hints[satelliteID]
+	return hints, ok
+}
+
+// AddressUnreachable returns the satellites that couldn't reach the node at
+// its address when it last checked in, sorted by ID.
+func (service *Service) AddressUnreachable() []storj.NodeID {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	var unreachable []storj.NodeID
+	for satelliteID, hints := range service.hints {
+		if !hints.AddressOk {
+			unreachable = append(unreachable, satelliteID)
+		}
+	}
+	sort.Slice(unreachable, func(i, k int) bool { return unreachable[i].Less(unreachable[k]) })
+	return unreachable
+}
+
+// updateHints records the hints of the satellite from a successful check-in,
+// warning about the changes the operator should know of. service.mu must be
+// held.
+func (service *Service) updateHints(satelliteID storj.NodeID, hints *pb.ContactHints) {
+	previous := service.hints[satelliteID]
+	if hints == nil {
+		delete(service.hints, satelliteID)
+		return
+	}
+	service.hints[satelliteID] = hints
+
+	if !hints.AddressOk && (previous == nil || previous.AddressOk) {
+		service.log.Warn("satellite could not reach the node at its address",
+			zap.Stringer("satellite", satelliteID))
+	}
+
+	if hints.MinimumVersion != "" && hints.MinimumVersion != previous.GetMinimumVersion() {
+		minimum, err := version.NewSemVer(hints.MinimumVersion)
+		if err != nil {
+			service.log.Debug("invalid minimum version hint", zap.Stringer("satellite", satelliteID), zap.Error(err))
+			return
+		}
+		if service.version.IsDev() {
+			mon.Counter("minimum_version_unknown").Inc(1)
+			service.log.Debug("development build of unknown version, ignoring the minimum version",
+				zap.Stringer("satellite", satelliteID),
+				zap.String("minimum", minimum.String()))
+			return
+		}
+		if service.version.Version.Compare(*minimum) >= 0 {
+			return
+		}
+		effective, err := ptypes.Timestamp(hints.MinimumVersionEffective)
+		if err != nil {
+			effective = time.Time{}
+		}
+		service.log.Warn("satellite will require a newer version, please upgrade",
+			zap.Stringer("satellite", satelliteID),
+			zap.String("current", service.version.Version.String()),
+			zap.String("minimum", minimum.String()),
+			zap.Time("effective", effective))
+	}
+}
+
+// hintedInterval returns the check-in interval hinted by the satellite,
+// within the configured bounds. service.mu must be held.
+func (service *Service) hintedInterval(satelliteID storj.NodeID) (time.Duration, bool) {
+	hints, ok := service.hints[satelliteID]
+	if !ok || hints.CheckInInterval == nil {
+		return 0, false
+	}
+	interval, err := ptypes.Duration(hints.CheckInInterval)
+	if err != nil || interval <= 0 {
+		return 0, false
+	}
+	if min := service.config.MinHintedInterval; min > 0 && interval < min {
+		interval = min
+	}
+	if max := service.config.MaxHintedInterval; max > 0 && interval > max {
+		interval = max
+	}
+	return interval, true
+}
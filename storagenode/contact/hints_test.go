@@ -0,0 +1,42 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestMinimumVersionHint(t *testing.T) {
+	const warning = "satellite will require a newer version, please upgrade"
+	hints := &pb.ContactHints{AddressOk: true, MinimumVersion: "v0.15.0"}
+
+	for _, tt := range []struct {
+		name     string
+		current  version.Info
+		warnings int
+	}{
+		{"outdated", version.Info{Version: version.SemVer{Minor: 14}, Release: true}, 1},
+		{"current", version.Info{Version: version.SemVer{Minor: 15}, Release: true}, 0},
+		{"outdated development build", version.Info{Version: version.SemVer{Minor: 14}}, 1},
+		{"unknown", version.Info{}, 0},
+	} {
+		core, logs := observer.New(zapcore.DebugLevel)
+		service := &Service{
+			log:     zap.New(core),
+			version: tt.current,
+			hints:   map[storj.NodeID]*pb.ContactHints{},
+		}
+		service.updateHints(storj.NodeID{1}, hints)
+		assert.Equal(t, tt.warnings, logs.FilterMessage(warning).Len(), tt.name)
+	}
+}
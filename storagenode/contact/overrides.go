@@ -0,0 +1,109 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"strings"
+	"time"
+
+	"storj.io/storj/pkg/storj"
+)
+
+// Override replaces the check-in interval and timeout of a single satellite,
+// zero values keep the global defaults.
+type Override struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// ParseOverrides parses a comma separated list of overrides, each as
+// "<satellite>=<interval>/<timeout>", where the satellite is a node ID or a
+// node URL and either duration may be empty, e.g. "1abc...@example.com:7777=2h/3m".
+func ParseOverrides(s string) (map[storj.NodeID]Override, error) {
+	overrides := map[storj.NodeID]Override{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eq := strings.LastIndex(entry, "=")
+		if eq < 0 {
+			return nil, Error.New("invalid override %q: missing '='", entry)
+		}
+		satelliteID, err := parseSatellite(entry[:eq])
+		if err != nil {
+			return nil, Error.New("invalid override %q: %v", entry, err)
+		}
+		if _, ok := overrides[satelliteID]; ok {
+			return nil, Error.New("duplicate override for satellite %s", satelliteID)
+		}
+
+		values := strings.Split(entry[eq+1:], "/")
+		if len(values) > 2 {
+			return nil, Error.New("invalid override %q: expected <interval>/<timeout>", entry)
+		}
+		var override Override
+		if override.Interval, err = parseDuration(values[0]); err != nil {
+			return nil, Error.New("invalid interval in override %q: %v", entry, err)
+		}
+		if len(values) == 2 {
+			if override.Timeout, err = parseDuration(values[1]); err != nil {
+				return nil, Error.New("invalid timeout in override %q: %v", entry, err)
+			}
+		}
+		overrides[satelliteID] = override
+	}
+	return overrides, nil
+}
+
+// parseSatellite parses a node ID or the ID of a node URL.
+func parseSatellite(s string) (storj.NodeID, error) {
+	if !strings.Contains(s, "@") {
+		return storj.NodeIDFromString(s)
+	}
+	url, err := storj.ParseNodeURL(s)
+	if err != nil {
+		return storj.NodeID{}, err
+	}
+	if url.ID.IsZero() {
+		return storj.NodeID{}, Error.New("node url without id")
+	}
+	return url.ID, nil
+}
+
+// parseDuration parses a positive duration, an empty string is zero.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, Error.New("%v is not positive", duration)
+	}
+	return duration, nil
+}
+
+// interval returns the check-in interval of the satellite, an override takes
+// precedence over the interval the satellite hinted. service.mu must be held.
+func (service *Service) interval(satelliteID storj.NodeID) time.Duration {
+	if override := service.overrides[satelliteID]; override.Interval > 0 {
+		return override.Interval
+	}
+	if hinted, ok := service.hintedInterval(satelliteID); ok {
+		return hinted
+	}
+	return service.config.Interval
+}
+
+// timeout returns the check-in timeout of the satellite.
+func (service *Service) timeout(satelliteID storj.NodeID) time.Duration {
+	if override := service.overrides[satelliteID]; override.Timeout > 0 {
+		return override.Timeout
+	}
+	return service.config.Timeout
+}
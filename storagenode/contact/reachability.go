@@ -0,0 +1,124 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/pkg/storj"
+)
+
+// day is the length of a rollup.
+const day = 24 * time.Hour
+
+// Rollup is the reachability of a satellite during a day, as seen from the
+// check-ins of the node.
+type Rollup struct {
+	SatelliteID storj.NodeID
+	// Day is the UTC midnight the day starts at.
+	Day time.Time
+
+	Attempts  int
+	Successes int
+	// Windows is the number of downtime windows starting during the day.
+	Windows int
+
+	// Observed is the time between the check-ins during the day, Downtime
+	// the part of it that followed failed check-ins.
+	Observed time.Duration
+	Downtime time.Duration
+}
+
+// Availability returns the percentage of the observed time the satellite was
+// reachable, or of the check-ins that succeeded when no time was observed.
+func (rollup Rollup) Availability() float64 {
+	switch {
+	case rollup.Observed > 0:
+		return 100 * float64(rollup.Observed-rollup.Downtime) / float64(rollup.Observed)
+	case rollup.Attempts > 0:
+		return 100 * float64(rollup.Successes) / float64(rollup.Attempts)
+	default:
+		return 0
+	}
+}
+
+// startOfDay returns the UTC midnight starting the day of t.
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// attempt is the time and outcome of a check-in.
+type attempt struct {
+	at time.Time
+	ok bool
+}
+
+// attemptRollups returns what a check-in adds to the rollups, previous being
+// the previous check-in with the satellite, if any. The time since the
+// previous check-in is split at midnight into the rollups of its days, it's
+// downtime when the previous check-in failed.
+func attemptRollups(satelliteID storj.NodeID, previous *attempt, current attempt) []Rollup {
+	rollup := Rollup{SatelliteID: satelliteID, Day: startOfDay(current.at), Attempts: 1}
+	if current.ok {
+		rollup.Successes = 1
+	} else if previous == nil || previous.ok {
+		rollup.Windows = 1
+	}
+	rollups := []Rollup{rollup}
+
+	if previous == nil {
+		return rollups
+	}
+	for from := previous.at; from.Before(current.at); {
+		to := startOfDay(from).Add(day)
+		if to.After(current.at) {
+			to = current.at
+		}
+		observed := Rollup{SatelliteID: satelliteID, Day: startOfDay(from), Observed: to.Sub(from)}
+		if !previous.ok {
+			observed.Downtime = observed.Observed
+		}
+		rollups = append(rollups, observed)
+		from = to
+	}
+	return rollups
+}
+
+// monotonicClock returns a clock advancing with the monotonic clock from the
+// current time, so that changing the wall clock doesn't move check-ins into
+// other days or make time go backwards.
+func monotonicClock() func() time.Time {
+	start := time.Now()
+	return func() time.Time {
+		return start.Add(time.Since(start))
+	}
+}
+
+// rollup adds the check-in with the satellite at now to the rollups and
+// deletes the rollups older than the retention.
+func (service *Service) rollup(ctx context.Context, satelliteID storj.NodeID, now time.Time, ok bool) error {
+	current := attempt{at: now, ok: ok}
+
+	service.mu.Lock()
+	var previous *attempt
+	if last, found := service.attempts[satelliteID]; found {
+		previous = &last
+	}
+	service.attempts[satelliteID] = current
+	service.mu.Unlock()
+
+	var group errs.Group
+	for _, rollup := range attemptRollups(satelliteID, previous, current) {
+		group.Add(service.db.AddRollup(ctx, rollup))
+	}
+	if service.config.RollupDays > 0 {
+		oldest := startOfDay(now).Add(-time.Duration(service.config.RollupDays-1) * day)
+		group.Add(service.db.DeleteRollupsBefore(ctx, oldest))
+	}
+	return Error.Wrap(group.Err())
+}
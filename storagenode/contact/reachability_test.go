@@ -0,0 +1,185 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+// memoryDB is a DB in memory.
+type memoryDB struct {
+	mu      sync.Mutex
+	status  map[storj.NodeID]Status
+	rollups map[storj.NodeID]map[time.Time]Rollup
+}
+
+func newMemoryDB() *memoryDB {
+	return &memoryDB{
+		status:  map[storj.NodeID]Status{},
+		rollups: map[storj.NodeID]map[time.Time]Rollup{},
+	}
+}
+
+func (db *memoryDB) Update(ctx context.Context, satelliteID storj.NodeID, status Status) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.status[satelliteID] = status
+	return nil
+}
+
+func (db *memoryDB) List(ctx context.Context) (map[storj.NodeID]Status, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	all := map[storj.NodeID]Status{}
+	for id, status := range db.status {
+		all[id] = status
+	}
+	return all, nil
+}
+
+func (db *memoryDB) AddRollup(ctx context.Context, rollup Rollup) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	days, ok := db.rollups[rollup.SatelliteID]
+	if !ok {
+		days = map[time.Time]Rollup{}
+		db.rollups[rollup.SatelliteID] = days
+	}
+	stored := days[rollup.Day]
+	stored.SatelliteID, stored.Day = rollup.SatelliteID, rollup.Day
+	stored.Attempts += rollup.Attempts
+	stored.Successes += rollup.Successes
+	stored.Windows += rollup.Windows
+	stored.Observed += rollup.Observed
+	stored.Downtime += rollup.Downtime
+	days[rollup.Day] = stored
+	return nil
+}
+
+func (db *memoryDB) Rollups(ctx context.Context, since time.Time) ([]Rollup, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var rollups []Rollup
+	for _, days := range db.rollups {
+		for day, rollup := range days {
+			if !day.Before(since) {
+				rollups = append(rollups, rollup)
+			}
+		}
+	}
+	sort.Slice(rollups, func(i, k int) bool {
+		if !rollups[i].Day.Equal(rollups[k].Day) {
+			return rollups[i].Day.Before(rollups[k].Day)
+		}
+		return rollups[i].SatelliteID.Less(rollups[k].SatelliteID)
+	})
+	return rollups, nil
+}
+
+func (db *memoryDB) DeleteRollupsBefore(ctx context.Context, before time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, days := range db.rollups {
+		for day := range days {
+			if day.Before(before) {
+				delete(days, day)
+			}
+		}
+	}
+	return nil
+}
+
+func TestRollupPausedSatellite(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	config := Config{
+		Interval:    time.Hour,
+		BackoffBase: 15 * time.Minute,
+		BackoffMax:  time.Hour,
+		RollupDays:  2,
+	}
+	db := newMemoryDB()
+	start := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	service := &Service{
+		log:      zaptest.NewLogger(t),
+		config:   config,
+		db:       db,
+		backoff:  &backoff.Exponential{Base: config.BackoffBase, Max: config.BackoffMax},
+		now:      func() time.Time { return now },
+		status:   map[storj.NodeID]*Status{},
+		hints:    map[storj.NodeID]*pb.ContactHints{},
+		attempts: map[storj.NodeID]attempt{},
+	}
+
+	satelliteID := storj.NodeID{1}
+	pausedFrom, pausedUntil := start.Add(6*time.Hour+30*time.Minute), start.Add(9*time.Hour)
+
+	// check in as scheduled until the first check-in of the next day
+	for now.Before(start.Add(24 * time.Hour)) {
+		now = now.Add(service.schedule(satelliteID, now))
+
+		var err error
+		if !now.Before(pausedFrom) && now.Before(pausedUntil) {
+			err = errors.New("satellite paused")
+		}
+		require.NoError(t, service.checkedIn(ctx, satelliteID, now, nil, err))
+	}
+	require.Equal(t, start.Add(24*time.Hour+45*time.Minute), now)
+
+	rollups, err := db.Rollups(ctx, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, rollups, 2)
+
+	// 00:00 to 06:00 hourly, failures at 07:00, 07:15, 07:45 and 08:45
+	// backing off, 09:45 to 23:45 hourly
+	paused := rollups[0]
+	assert.Equal(t, start, paused.Day)
+	assert.Equal(t, 26, paused.Attempts)
+	assert.Equal(t, 22, paused.Successes)
+	assert.Equal(t, 1, paused.Windows)
+	assert.Equal(t, 24*time.Hour, paused.Observed)
+	assert.Equal(t, 2*time.Hour+45*time.Minute, paused.Downtime)
+	assert.InDelta(t, 100*(24-2.75)/24, paused.Availability(), 1e-9)
+
+	// the time since 23:45 is split at midnight
+	next := rollups[1]
+	assert.Equal(t, start.Add(24*time.Hour), next.Day)
+	assert.Equal(t, 1, next.Attempts)
+	assert.Equal(t, 45*time.Minute, next.Observed)
+	assert.Zero(t, next.Downtime)
+	assert.Equal(t, 100.0, next.Availability())
+
+	// rollups older than the retention are deleted
+	now = start.Add(48*time.Hour + time.Hour)
+	require.NoError(t, service.checkedIn(ctx, satelliteID, now, nil, nil))
+	rollups, err = db.Rollups(ctx, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, rollups, 2)
+	assert.Equal(t, start.Add(24*time.Hour), rollups[0].Day)
+	assert.Equal(t, start.Add(48*time.Hour), rollups[1].Day)
+}
+
+func TestMonotonicClock(t *testing.T) {
+	now := monotonicClock()
+	first := now()
+	second := now()
+	assert.False(t, second.Before(first))
+	assert.WithinDuration(t, time.Now(), second, time.Second)
+}
@@ -0,0 +1,379 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/lifecycle"
+	"storj.io/storj/internal/sync2"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
+	"storj.io/storj/storagenode/trust"
+)
+
+var (
+	mon = monkit.Package()
+
+	// Error is the default error class for contact service errors
+	Error = errs.Class("contact")
+)
+
+// Config defines parameters for checking in with satellites.
+type Config struct {
+	Interval    time.Duration `help:"how frequently the node should check in with each satellite" default:"1h0m0s"`
+	Jitter      time.Duration `help:"maximum random delay added to each check-in" default:"5m0s"`
+	Splay       float64       `help:"fraction of the interval the first check-in with each satellite is delayed by at most, spreading the check-ins of nodes restarting together" default:"0.5"`
+	BackoffBase time.Duration `help:"the base interval to wait when retrying a failed check-in" default:"1m0s"`
+	BackoffMax  time.Duration `help:"the maximum amount of time to wait when retrying a failed check-in" default:"1h0m0s"`
+	Timeout     time.Duration `help:"timeout for a single check-in" default:"1m0s"`
+
+	MinHintedInterval time.Duration `help:"the shortest check-in interval a satellite can ask for, 0 for no bound" default:"5m0s"`
+	MaxHintedInterval time.Duration `help:"the longest check-in interval a satellite can ask for, 0 for no bound" default:"24h0m0s"`
+
+	RefreshInterval time.Duration `help:"how frequently the list of trusted satellites is re-read, 0 reads it only once" default:"1m0s"`
+
+	Overrides string `help:"per-satellite interval and timeout overrides separated by commas, as <node id or url>=<interval>/<timeout>" default:""`
+
+	RollupDays int `help:"number of days the daily reachability of each satellite is kept, 0 keeps it forever" default:"90"`
+}
+
+// Status describes the check-in state of a single satellite.
+type Status struct {
+	LastSuccess time.Time
+	LastFailure time.Time
+	// Failures is the number of consecutive failed check-ins.
+	Failures int
+	// NextAttempt is the time when the next check-in is scheduled.
+	NextAttempt time.Time
+}
+
+// DB stores the results of satellite check-ins.
+type DB interface {
+	// Update records the check-in status of a satellite.
+	Update(ctx context.Context, satelliteID storj.NodeID, status Status) error
+	// List returns the check-in status of all satellites.
+	List(ctx context.Context) (map[storj.NodeID]Status, error)
+
+	// AddRollup adds the counts and durations of rollup to the stored rollup
+	// of its satellite and day.
+	AddRollup(ctx context.Context, rollup Rollup) error
+	// Rollups returns the rollups of the days starting at since or later,
+	// sorted by day and satellite.
+	Rollups(ctx context.Context, since time.Time) ([]Rollup, error)
+	// DeleteRollupsBefore deletes the rollups of the days starting before before.
+	DeleteRollupsBefore(ctx context.Context, before time.Time) error
+}
+
+// Service periodically checks in with every trusted satellite.
+type Service struct {
+	log      *zap.Logger
+	config   Config
+	kademlia *kademlia.Kademlia
+	trust    *trust.Pool
+	db       DB
+	backoff  backoff.Strategy
+	version  version.Info
+
+	overrides map[storj.NodeID]Override
+	splay     *sync2.Splay
+	now       func() time.Time
+
+	mu     sync.Mutex
+	status map[storj.NodeID]*Status
+	hints  map[storj.NodeID]*pb.ContactHints
+	// attempts are the last check-ins since starting, for the rollups
+	attempts map[storj.NodeID]attempt
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewService creates a new contact service, the overridden satellites must be
+// trusted. current is the version of the node, compared with the minimum
+// versions the satellites hint unless it's unknown.
+func NewService(ctx context.Context, log *zap.Logger, kademlia *kademlia.Kademlia, trust *trust.Pool, db DB, current version.Info, config Config) (*Service, error) {
+	overrides, err := ParseOverrides(config.Overrides)
+	if err != nil {
+		return nil, err
+	}
+	for satelliteID := range overrides {
+		if err := trust.VerifySatelliteID(ctx, satelliteID); err != nil {
+			return nil, Error.New("override for untrusted satellite: %v", err)
+		}
+	}
+
+	splay := sync2.NewSplay(kademlia.Local().Id.Bytes(), "contact")
+	splay.Initial = config.Splay
+
+	return &Service{
+		log:       log,
+		config:    config,
+		kademlia:  kademlia,
+		trust:     trust,
+		db:        db,
+		backoff:   &backoff.Exponential{Base: config.BackoffBase, Max: config.BackoffMax},
+		version:   current,
+		overrides: overrides,
+		splay:     splay,
+		now:       monotonicClock(),
+		status:    map[storj.NodeID]*Status{},
+		hints:     map[storj.NodeID]*pb.ContactHints{},
+		attempts:  map[storj.NodeID]attempt{},
+		closed:    make(chan struct{}),
+	}, nil
+}
+
+// Run checks in with every satellite on its own schedule.
+func (service *Service) Run(ctx context.Context) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	stored, err := service.db.List(ctx)
+	if err != nil {
+		// the database may be busy, the statuses are read again on the next run
+		return lifecycle.Transient.Wrap(Error.Wrap(err))
+	}
+
+	service.mu.Lock()
+	for id, status := range stored {
+		status := status
+		service.status[id] = &status
+	}
+	service.mu.Unlock()
+
+	// the check-ins are attributed to contact rather than to kademlia
+	ctx, cancel := context.WithCancel(transport.WithLabel(ctx, "contact"))
+	defer cancel()
+
+	var group errgroup.Group
+	group.Go(func() error {
+		select {
+		case <-service.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+		return nil
+	})
+
+	// satellites can only be found once the routing table has been populated
+	if service.kademlia.WaitForBootstrapContext(ctx) == nil {
+		service.runSatellites(ctx, &group)
+	}
+	cancel()
+	_ = group.Wait() // doesn't return errors
+
+	return ctx.Err()
+}
+
+// runSatellites starts checking in with the trusted satellites and re-reads
+// them until ctx is canceled, since satellites can be trusted or distrusted
+// while running, e.g. when all satellites are trusted.
+func (service *Service) runSatellites(ctx context.Context, group *errgroup.Group) {
+	running := map[storj.NodeID]context.CancelFunc{}
+	for {
+		trusted := map[storj.NodeID]bool{}
+		for _, satelliteID := range service.trust.GetSatellites(ctx) {
+			trusted[satelliteID] = true
+			if _, ok := running[satelliteID]; ok {
+				continue
+			}
+
+			satelliteID := satelliteID
+			satelliteCtx, cancel := context.WithCancel(ctx)
+			running[satelliteID] = cancel
+			group.Go(func() error {
+				service.runSatellite(satelliteCtx, satelliteID)
+				return nil
+			})
+		}
+		for satelliteID, cancel := range running {
+			if !trusted[satelliteID] {
+				cancel()
+				delete(running, satelliteID)
+			}
+		}
+		// including the satellites pinned before a restart
+		for _, pinned := range service.kademlia.PinnedNodes() {
+			if pinned.Type == pb.NodeType_SATELLITE && !trusted[pinned.Node.Id] {
+				service.kademlia.Unpin(pinned.Node.Id)
+			}
+		}
+
+		if service.config.RefreshInterval <= 0 {
+			<-ctx.Done()
+			return
+		}
+		if !sync2.Sleep(ctx, service.config.RefreshInterval) {
+			return
+		}
+	}
+}
+
+// runSatellite checks in with a single satellite until ctx is canceled.
+func (service *Service) runSatellite(ctx context.Context, satelliteID storj.NodeID) {
+	for {
+		delay := service.schedule(satelliteID, service.now())
+		if !sync2.Sleep(ctx, delay) {
+			return
+		}
+		err := service.CheckIn(ctx, satelliteID)
+		if err != nil && ctx.Err() == nil {
+			service.log.Warn("check-in failed", zap.Stringer("satellite", satelliteID), zap.Error(err))
+		}
+	}
+}
+
+// schedule computes the delay until the next check-in and records it in the status.
+func (service *Service) schedule(satelliteID storj.NodeID, now time.Time) time.Duration {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	status := service.getStatus(satelliteID)
+
+	var next time.Time
+	if status.Failures > 0 {
+		// the backoff isn't jittered, config.Jitter already spreads check-ins
+		delay, _ := service.backoff.Delay(status.Failures, 0)
+		next = status.LastFailure.Add(delay)
+	} else {
+		next = status.LastSuccess.Add(service.interval(satelliteID))
+	}
+	if status.NextAttempt.IsZero() && next.Before(now) {
+		// the first check-in since starting is overdue for every node
+		// restarting at the same time, the splay spreads them
+		next = now.Add(service.splay.First(service.interval(satelliteID)))
+	}
+	next = next.Add(service.splay.Random(service.config.Jitter))
+	if next.Before(now) {
+		next = now
+	}
+
+	status.NextAttempt = next
+	return next.Sub(now)
+}
+
+// CheckIn contacts the satellite and records the result.
+func (service *Service) CheckIn(ctx context.Context, satelliteID storj.NodeID) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	hints, err := service.checkIn(ctx, satelliteID)
+	return errs.Combine(err, service.checkedIn(ctx, satelliteID, service.now(), hints, err))
+}
+
+// checkedIn records the result of the check-in with the satellite that ended
+// at now, checkInErr being its error.
+func (service *Service) checkedIn(ctx context.Context, satelliteID storj.NodeID, now time.Time, hints *pb.ContactHints, checkInErr error) error {
+	service.mu.Lock()
+	status := service.getStatus(satelliteID)
+	if checkInErr == nil {
+		status.LastSuccess = now
+		status.Failures = 0
+		service.updateHints(satelliteID, hints)
+	} else {
+		status.LastFailure = now
+		status.Failures++
+	}
+	updated := *status
+	service.mu.Unlock()
+
+	if checkInErr == nil {
+		mon.Event("satellite_checkin_success")
+	} else {
+		mon.Event("satellite_checkin_failure")
+	}
+
+	return errs.Combine(
+		Error.Wrap(service.db.Update(ctx, satelliteID, updated)),
+		service.rollup(ctx, satelliteID, now, checkInErr == nil),
+	)
+}
+
+// checkIn finds the satellite and announces the node to it, returning the
+// hints of the satellite.
+func (service *Service) checkIn(ctx context.Context, satelliteID storj.NodeID) (*pb.ContactHints, error) {
+	ctx = transport.WithDialSite(ctx, "contact check-in")
+
+	// the deadline also bounds the dials to the satellite
+	if timeout := service.timeout(satelliteID); timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	satellite, err := service.kademlia.FindNode(kademlia.WithFreshLookup(ctx), satelliteID)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	// satellites are always known through trust, they don't need a bucket slot
+	if err := service.kademlia.Pin(satellite, pb.NodeType_SATELLITE); err != nil {
+		service.log.Debug("could not pin satellite", zap.Stringer("satellite", satelliteID), zap.Error(err))
+	}
+	hints, err := service.kademlia.CheckIn(ctx, satellite)
+	return hints, Error.Wrap(err)
+}
+
+// Status returns the check-in status of the specified satellite.
+func (service *Service) Status(satelliteID storj.NodeID) (Status, bool) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	status, ok := service.status[satelliteID]
+	if !ok {
+		return Status{}, false
+	}
+	return *status, true
+}
+
+// All returns the check-in status of all known satellites.
+func (service *Service) All() map[storj.NodeID]Status {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	all := make(map[storj.NodeID]Status, len(service.status))
+	for id, status := range service.status {
+		all[id] = *status
+	}
+	return all
+}
+
+// NextRuns returns when the node checks in next with each satellite, see
+// sync2.NextRuns.
+func (service *Service) NextRuns() map[string]time.Time {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	runs := make(map[string]time.Time, len(service.status))
+	for id, status := range service.status {
+		runs["contact "+id.String()] = status.NextAttempt
+	}
+	return runs
+}
+
+// getStatus returns the status for the satellite, creating it when missing.
+// service.mu must be held.
+func (service *Service) getStatus(satelliteID storj.NodeID) *Status {
+	status, ok := service.status[satelliteID]
+	if !ok {
+		status = &Status{}
+		service.status[satelliteID] = status
+	}
+	return status
+}
+
+// Close stops the contact service.
+func (service *Service) Close() error {
+	service.closeOnce.Do(func() { close(service.closed) })
+	return nil
+}
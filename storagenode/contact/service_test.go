@@ -0,0 +1,241 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/satellite"
+	"storj.io/storj/storagenode"
+	"storj.io/storj/storagenode/contact"
+)
+
+func TestCheckIn(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.NewCustom(zaptest.NewLogger(t), testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Contact.Interval = 200 * time.Millisecond
+				config.Contact.BackoffBase = 100 * time.Millisecond
+				config.Contact.BackoffMax = 400 * time.Millisecond
+				config.Contact.Timeout = time.Second
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	satellite := planet.Satellites[0]
+	node := planet.StorageNodes[0]
+	service := node.Contact.Service
+
+	// wait for a successful check-in
+	var first time.Time
+	waitFor(t, 10*time.Second, func() bool {
+		status, ok := service.Status(satellite.ID())
+		first = status.LastSuccess
+		return ok && !first.IsZero()
+	})
+
+	dossier, err := satellite.Overlay.Service.Get(ctx, node.ID())
+	require.NoError(t, err)
+	seen := dossier.Reputation.LastContactSuccess
+
+	// the satellite records the node as seen on every check-in
+	waitFor(t, 10*time.Second, func() bool {
+		status, _ := service.Status(satellite.ID())
+		return status.LastSuccess.After(first)
+	})
+
+	dossier, err = satellite.Overlay.Service.Get(ctx, node.ID())
+	require.NoError(t, err)
+	require.True(t, dossier.Reputation.LastContactSuccess.After(seen),
+		"last contact %v not after %v", dossier.Reputation.LastContactSuccess, seen)
+
+	stored, err := node.DB.Contact().List(ctx)
+	require.NoError(t, err)
+	require.Contains(t, stored, satellite.ID())
+
+	// pause the satellite and ensure check-ins back off
+	require.NoError(t, planet.StopPeer(satellite))
+
+	var status contact.Status
+	waitFor(t, 15*time.Second, func() bool {
+		status, _ = service.Status(satellite.ID())
+		return status.Failures >= 3 && status.NextAttempt.After(status.LastFailure)
+	})
+
+	// after three failures the backoff has doubled twice and reached the cap
+	backoff := status.NextAttempt.Sub(status.LastFailure)
+	require.True(t, backoff >= 400*time.Millisecond, "backoff %v too short", backoff)
+	require.True(t, backoff <= 500*time.Millisecond, "backoff %v exceeds the cap", backoff)
+}
+
+func TestCheckInOverrides(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.NewCustom(zaptest.NewLogger(t), testplanet.Config{
+		SatelliteCount: 2, StorageNodeCount: 1, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				satellites := strings.Split(config.Storage.WhitelistedSatelliteIDs, ",")
+				config.Contact.Interval = 100 * time.Millisecond
+				config.Contact.Timeout = time.Second
+				config.Contact.Overrides = satellites[1] + "=1h/2s"
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	fast, slow := planet.Satellites[0].ID(), planet.Satellites[1].ID()
+	service := planet.StorageNodes[0].Contact.Service
+
+	var first time.Time
+	waitFor(t, 10*time.Second, func() bool {
+		fastStatus, _ := service.Status(fast)
+		slowStatus, _ := service.Status(slow)
+		if fastStatus.LastSuccess.IsZero() || slowStatus.LastSuccess.IsZero() {
+			return false
+		}
+		if first.IsZero() {
+			first = slowStatus.LastSuccess
+		}
+		return fastStatus.LastSuccess.After(first.Add(300 * time.Millisecond))
+	})
+
+	// the overridden satellite hasn't checked in again
+	slowStatus, _ := service.Status(slow)
+	assert.Equal(t, first, slowStatus.LastSuccess)
+	assert.Equal(t, time.Hour, slowStatus.NextAttempt.Sub(slowStatus.LastSuccess))
+
+	fastStatus, _ := service.Status(fast)
+	assert.True(t, fastStatus.NextAttempt.Sub(fastStatus.LastSuccess) < time.Second)
+}
+
+func TestCheckInHints(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	planet, err := testplanet.NewCustom(zaptest.NewLogger(t), testplanet.Config{
+		SatelliteCount: 2, StorageNodeCount: 1, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			Satellite: func(log *zap.Logger, index int, config *satellite.Config) {
+				if index == 0 {
+					// shorter than the bound of the node
+					config.Contact.CheckInInterval = 10 * time.Millisecond
+					config.Contact.MinimumVersion = "v100.0.0"
+					config.Contact.MinimumVersionEffective = "2030-01-01T00:00:00Z"
+				}
+			},
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Contact.Interval = time.Hour
+				config.Contact.Timeout = time.Second
+				config.Contact.MinHintedInterval = 100 * time.Millisecond
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	hinting, silent := planet.Satellites[0].ID(), planet.Satellites[1].ID()
+	service := planet.StorageNodes[0].Contact.Service
+
+	// the node checks in with the hinting satellite at its cadence
+	var first time.Time
+	waitFor(t, 10*time.Second, func() bool {
+		hintingStatus, _ := service.Status(hinting)
+		silentStatus, _ := service.Status(silent)
+		if hintingStatus.LastSuccess.IsZero() || silentStatus.LastSuccess.IsZero() {
+			return false
+		}
+		if first.IsZero() {
+			first = hintingStatus.LastSuccess
+		}
+		return hintingStatus.LastSuccess.After(first.Add(300 * time.Millisecond))
+	})
+
+	hintingStatus, _ := service.Status(hinting)
+	assert.Equal(t, 100*time.Millisecond, hintingStatus.NextAttempt.Sub(hintingStatus.LastSuccess))
+	silentStatus, _ := service.Status(silent)
+	assert.Equal(t, time.Hour, silentStatus.NextAttempt.Sub(silentStatus.LastSuccess))
+
+	hints, ok := service.Hints(hinting)
+	require.True(t, ok)
+	assert.True(t, hints.AddressOk)
+	assert.Equal(t, "v100.0.0", hints.MinimumVersion)
+	assert.Empty(t, service.AddressUnreachable())
+}
+
+func TestContactHintsForwardCompatible(t *testing.T) {
+	data, err := proto.Marshal(&pb.ContactHints{AddressOk: true, MinimumVersion: "v1.2.3"})
+	require.NoError(t, err)
+	// a varint field 15 the node doesn't know
+	data = append(data, 15<<3, 1)
+
+	var hints pb.ContactHints
+	require.NoError(t, proto.Unmarshal(data, &hints))
+	assert.True(t, hints.AddressOk)
+	assert.Equal(t, "v1.2.3", hints.MinimumVersion)
+}
+
+func TestParseOverrides(t *testing.T) {
+	id := storj.NodeID{1, 2, 3}
+
+	overrides, err := contact.ParseOverrides(id.String() + "=2h/3m, " + storj.NodeID{4}.String() + "@127.0.0.1:7777=/5s")
+	require.NoError(t, err)
+	assert.Equal(t, map[storj.NodeID]contact.Override{
+		id:              {Interval: 2 * time.Hour, Timeout: 3 * time.Minute},
+		storj.NodeID{4}: {Timeout: 5 * time.Second},
+	}, overrides)
+
+	overrides, err = contact.ParseOverrides("")
+	require.NoError(t, err)
+	assert.Empty(t, overrides)
+
+	for _, invalid := range []string{
+		id.String(),
+		id.String() + "=1h/2m/3s",
+		id.String() + "=-1h",
+		id.String() + "=1h," + id.String() + "=2h",
+		"127.0.0.1:7777=1h",
+		"@127.0.0.1:7777=1h",
+	} {
+		_, err := contact.ParseOverrides(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// waitFor polls fn until it returns true or the timeout expires.
+func waitFor(t *testing.T, timeout time.Duration, fn func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package contact
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/sync2"
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/pkg/backoff"
+	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+)
+
+func TestScheduleSplay(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	config := Config{
+		Interval:    time.Hour,
+		Jitter:      time.Minute,
+		Splay:       0.5,
+		BackoffBase: time.Minute,
+		BackoffMax:  time.Hour,
+	}
+	start := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+
+	// newService returns a service configured like every other one, but for
+	// the node ID
+	newService := func(nodeID storj.NodeID) *Service {
+		splay := sync2.NewSplay(nodeID.Bytes(), "contact")
+		splay.Initial = config.Splay
+		return &Service{
+			log:      zaptest.NewLogger(t),
+			config:   config,
+			db:       newMemoryDB(),
+			backoff:  &backoff.Exponential{Base: config.BackoffBase, Max: config.BackoffMax},
+			splay:    splay,
+			now:      func() time.Time { return now },
+			status:   map[storj.NodeID]*Status{},
+			hints:    map[storj.NodeID]*pb.ContactHints{},
+			attempts: map[storj.NodeID]attempt{},
+		}
+	}
+	a, b := newService(storj.NodeID{1}), newService(storj.NodeID{2})
+	satelliteID := storj.NodeID{0xff}
+
+	maxFirst := time.Duration(config.Splay*float64(config.Interval)) + config.Jitter
+	first := map[*Service]time.Duration{}
+	for _, service := range []*Service{a, b} {
+		delay := service.schedule(satelliteID, now)
+		assert.True(t, delay >= 0 && delay < maxFirst, "first check-in in %v", delay)
+		first[service] = delay
+
+		// the check-ins after the first one follow the interval
+		now = start.Add(delay)
+		require.NoError(t, service.checkedIn(ctx, satelliteID, now, nil, nil))
+		next := service.schedule(satelliteID, now)
+		assert.True(t, next >= config.Interval && next < config.Interval+config.Jitter, "next check-in in %v", next)
+		now = start
+	}
+	assert.NotEqual(t, first[a], first[b], "both nodes check in at the same offset")
+
+	// a restart keeps the offset, apart from the jitter
+	restarted := newService(storj.NodeID{1})
+	delay := restarted.schedule(satelliteID, now)
+	assert.InDelta(t, float64(first[a]), float64(delay), float64(config.Jitter))
+
+	runs := restarted.NextRuns()
+	assert.Equal(t, now.Add(delay), runs["contact "+satelliteID.String()])
+}
@@ -6,17 +6,22 @@ package inspector
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
 	monkit "gopkg.in/spacemonkeygo/monkit.v2"
 
+	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/pb"
 	"storj.io/storj/pkg/storj"
+	"storj.io/storj/pkg/transport"
 	"storj.io/storj/storagenode/bandwidth"
+	"storj.io/storj/storagenode/contact"
 	"storj.io/storj/storagenode/pieces"
 	"storj.io/storj/storagenode/piecestore"
 )
@@ -34,23 +39,44 @@ type Endpoint struct {
 	pieceInfo pieces.DB
 	kademlia  *kademlia.Kademlia
 	usageDB   bandwidth.DB
+	contactDB contact.DB
+	checkins  *contact.Service
+	version   *version.Service
+	traffic   *transport.Bandwidth
 
 	startTime time.Time
 	config    piecestore.OldConfig
+
+	mu           sync.Mutex
+	versionState version.StateChange
 }
 
 // NewEndpoint creates piecestore inspector instance
-func NewEndpoint(log *zap.Logger, pieceInfo pieces.DB, kademlia *kademlia.Kademlia, usageDB bandwidth.DB, config piecestore.OldConfig) *Endpoint {
+func NewEndpoint(log *zap.Logger, pieceInfo pieces.DB, kademlia *kademlia.Kademlia, usageDB bandwidth.DB, contactDB contact.DB, checkins *contact.Service, version *version.Service, traffic *transport.Bandwidth, config piecestore.OldConfig) *Endpoint {
 	return &Endpoint{
 		log:       log,
 		pieceInfo: pieceInfo,
 		kademlia:  kademlia,
 		usageDB:   usageDB,
+		contactDB: contactDB,
+		checkins:  checkins,
+		version:   version,
+		traffic:   traffic,
 		config:    config,
 		startTime: time.Now(),
 	}
 }
 
+// Run follows the version state shown on the dashboard until ctx is canceled.
+func (inspector *Endpoint) Run(ctx context.Context) error {
+	for change := range inspector.version.Subscribe(ctx) {
+		inspector.mu.Lock()
+		inspector.versionState = change
+		inspector.mu.Unlock()
+	}
+	return ctx.Err()
+}
+
 func (inspector *Endpoint) retrieveStats(ctx context.Context) (*pb.StatSummaryResponse, error) {
 
 	// Space Usage
@@ -111,6 +137,10 @@ func (inspector *Endpoint) getDashboardData(ctx context.Context) (*pb.DashboardR
 		bsNodes[i] = node.Address.Address
 	}
 
+	// the contacts that just ended are shown
+	if err := inspector.kademlia.Events().Flush(ctx); err != nil {
+		return &pb.DashboardResponse{}, Error.Wrap(err)
+	}
 	pinged, err := ptypes.TimestampProto(inspector.kademlia.LastPinged())
 	if err != nil {
 		inspector.log.Warn("last ping time bad", zap.Error(err))
@@ -122,16 +152,80 @@ func (inspector *Endpoint) getDashboardData(ctx context.Context) (*pb.DashboardR
 		queried = nil
 	}
 
+	checkins, err := inspector.contactDB.List(ctx)
+	if err != nil {
+		return &pb.DashboardResponse{}, Error.Wrap(err)
+	}
+
+	var lastCheckin time.Time
+	for _, status := range checkins {
+		if status.LastSuccess.After(lastCheckin) {
+			lastCheckin = status.LastSuccess
+		}
+	}
+
+	checkedIn, err := ptypes.TimestampProto(lastCheckin)
+	if err != nil {
+		inspector.log.Warn("last check-in time bad", zap.Error(err))
+		checkedIn = nil
+	}
+
+	inspector.mu.Lock()
+	versionState := inspector.versionState
+	inspector.mu.Unlock()
+	var suggested string
+	if !versionState.Suggested.IsZero() {
+		suggested = versionState.Suggested.String()
+	}
+
+	var observedAddress string
+	if observed, mismatch := inspector.kademlia.ObservedAddress(); mismatch {
+		observedAddress = observed
+	}
+
+	var clockSkew *duration.Duration
+	if skew, skewed := inspector.kademlia.ClockSkew(); skewed {
+		clockSkew = ptypes.DurationProto(skew)
+	}
+
+	var traffic []*pb.LabelBandwidth
+	if inspector.traffic != nil {
+		for _, usage := range inspector.traffic.Usage() {
+			traffic = append(traffic, &pb.LabelBandwidth{
+				Label:   usage.Label,
+				Ingress: usage.In.Int64(),
+				Egress:  usage.Out.Int64(),
+			})
+		}
+	}
+
+	var unreachable []storj.NodeID
+	if inspector.checkins != nil {
+		unreachable = inspector.checkins.AddressUnreachable()
+	}
+
 	return &pb.DashboardResponse{
-		NodeId:           inspector.kademlia.Local().Id,
-		NodeConnections:  int64(len(nodes)),
-		BootstrapAddress: strings.Join(bsNodes[:], ", "),
-		InternalAddress:  "",
-		ExternalAddress:  inspector.kademlia.Local().Address.Address,
-		LastPinged:       pinged,
-		LastQueried:      queried,
-		Uptime:           ptypes.DurationProto(time.Since(inspector.startTime)),
-		Stats:            statsSummary,
+		NodeId:             inspector.kademlia.Local().Id,
+		NodeConnections:    int64(len(nodes)),
+		BootstrapAddress:   strings.Join(bsNodes[:], ", "),
+		InternalAddress:    "",
+		ExternalAddress:    inspector.kademlia.Local().Address.Address,
+		ObservedAddress:    observedAddress,
+		NetworkSize:        inspector.kademlia.EstimateNetworkSize().Response(),
+		ClockSkew:          clockSkew,
+		LastPinged:         pinged,
+		LastQueried:        queried,
+		LastCheckin:        checkedIn,
+		Uptime:             ptypes.DurationProto(time.Since(inspector.startTime)),
+		Stats:              statsSummary,
+		Bandwidth:          traffic,
+		AddressUnreachable: unreachable,
+		Version: &pb.VersionStatus{
+			Current:   versionState.Current.String(),
+			Suggested: suggested,
+			Outdated:  versionState.New == version.StateOutdated,
+			Unknown:   versionState.New == version.StateUnknown,
+		},
 	}, nil
 }
 
@@ -146,3 +240,38 @@ func (inspector *Endpoint) Dashboard(ctx context.Context, in *pb.DashboardReques
 	}
 	return data, nil
 }
+
+// Reachability returns the daily reachability of each satellite, as seen from the check-ins of the node.
+func (inspector *Endpoint) Reachability(ctx context.Context, in *pb.ReachabilityRequest) (out *pb.ReachabilityResponse, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var since time.Time
+	if in.Days > 0 {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		since = today.Add(-time.Duration(in.Days-1) * 24 * time.Hour)
+	}
+
+	rollups, err := inspector.contactDB.Rollups(ctx, since)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+
+	out = &pb.ReachabilityResponse{}
+	for _, rollup := range rollups {
+		day, err := ptypes.TimestampProto(rollup.Day)
+		if err != nil {
+			return nil, Error.Wrap(err)
+		}
+		out.Rollups = append(out.Rollups, &pb.SatelliteReachability{
+			SatelliteId:     rollup.SatelliteID,
+			Day:             day,
+			Attempts:        int64(rollup.Attempts),
+			Successes:       int64(rollup.Successes),
+			DowntimeWindows: int64(rollup.Windows),
+			Observed:        ptypes.DurationProto(rollup.Observed),
+			Downtime:        ptypes.DurationProto(rollup.Downtime),
+			Availability:    rollup.Availability(),
+		})
+	}
+	return out, nil
+}
@@ -4,18 +4,28 @@
 package inspector_test
 
 import (
+	"encoding/json"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"storj.io/storj/internal/memory"
 	"storj.io/storj/internal/testcontext"
 	"storj.io/storj/internal/testplanet"
+	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storagenode"
+	"storj.io/storj/storagenode/contact"
 	"storj.io/storj/uplink"
 )
 
@@ -136,3 +146,122 @@ func TestInspectorDashboard(t *testing.T) {
 		}
 	})
 }
+
+func TestInspectorReachability(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		node := planet.StorageNodes[0]
+
+		old := time.Now().UTC().Truncate(24 * time.Hour).Add(-10 * 24 * time.Hour)
+		satelliteID := storj.NodeID{1}
+		require.NoError(t, node.DB.Contact().AddRollup(ctx, contact.Rollup{
+			SatelliteID: satelliteID,
+			Day:         old,
+			Attempts:    4,
+			Successes:   3,
+			Windows:     1,
+			Observed:    4 * time.Hour,
+			Downtime:    time.Hour,
+		}))
+
+		response, err := node.Storage2.Inspector.Reachability(ctx, &pb.ReachabilityRequest{})
+		require.NoError(t, err)
+		require.NotEmpty(t, response.Rollups)
+
+		rollup := response.Rollups[0]
+		day, err := ptypes.Timestamp(rollup.Day)
+		require.NoError(t, err)
+		assert.Equal(t, satelliteID, rollup.SatelliteId)
+		assert.True(t, old.Equal(day))
+		assert.Equal(t, int64(1), rollup.DowntimeWindows)
+		assert.Equal(t, 75.0, rollup.Availability)
+
+		// only the recent days are returned when requested
+		response, err = node.Storage2.Inspector.Reachability(ctx, &pb.ReachabilityRequest{Days: 2})
+		require.NoError(t, err)
+		for _, rollup := range response.Rollups {
+			assert.NotEqual(t, satelliteID, rollup.SatelliteId)
+		}
+	})
+}
+
+func TestDashboardVersion(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	versions := version.AllowedVersions{
+		Storagenode: []version.SemVer{{Major: 0, Minor: 0, Patch: 1}, {Major: 0, Minor: 2, Patch: 0}, {Major: 0, Minor: 1, Patch: 5}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(versions)
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	planet, err := testplanet.NewCustom(zap.New(core), testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 1, UplinkCount: 0,
+		Reconfigure: testplanet.Reconfigure{
+			StorageNode: func(index int, config *storagenode.Config) {
+				config.Version.ServerAddress = server.URL
+				config.Version.CheckDevBuilds = true
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer ctx.Check(planet.Shutdown)
+
+	planet.Start(ctx)
+
+	node := planet.StorageNodes[0]
+	for i := 0; i < 3; i++ {
+		node.Version.Loop.TriggerWait()
+	}
+
+	// the dashboard follows the version state changes
+	var dashboard *pb.DashboardResponse
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		dashboard, err = node.Storage2.Inspector.Dashboard(ctx, &pb.DashboardRequest{})
+		require.NoError(t, err)
+		if dashboard.Version.Suggested != "" {
+			break
+		}
+	}
+	// testplanet nodes are of an unknown version, which is never outdated
+	assert.Equal(t, &pb.VersionStatus{Current: "v0.0.0", Suggested: "v0.2.0", Unknown: true}, dashboard.Version)
+	assert.Equal(t, 0, logs.FilterMessage("a newer version is available, please update").Len())
+}
+
+func TestDashboardClockSkew(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 3, StorageNodeCount: 1, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		node := planet.StorageNodes[0]
+
+		// the node is an hour ahead, and a single satellite is far behind
+		node.Kademlia.Service.SetClock(func() time.Time { return time.Now().Add(time.Hour) })
+		behind := planet.Satellites[0]
+		behind.Kademlia.Service.SetClock(func() time.Time { return time.Now().Add(-72 * time.Hour) })
+
+		for _, satellite := range planet.Satellites {
+			require.NoError(t, node.Contact.Service.CheckIn(ctx, satellite.ID()))
+		}
+
+		skew, skewed := node.Kademlia.Service.ClockSkew()
+		assert.True(t, skewed)
+		assert.InDelta(t, float64(time.Hour), float64(skew), float64(time.Minute))
+
+		dashboard, err := node.Storage2.Inspector.Dashboard(ctx, &pb.DashboardRequest{})
+		require.NoError(t, err)
+		require.NotNil(t, dashboard.ClockSkew)
+		reported, err := ptypes.Duration(dashboard.ClockSkew)
+		require.NoError(t, err)
+		assert.Equal(t, skew, reported)
+
+		// orders of the satellite that is behind expire on its own clock
+		expiration, err := ptypes.TimestampProto(time.Now().Add(-71 * time.Hour))
+		require.NoError(t, err)
+		assert.False(t, node.Storage2.Endpoint.IsExpired(behind.ID(), expiration))
+		assert.True(t, node.Storage2.Endpoint.IsExpired(planet.Satellites[1].ID(), expiration))
+	})
+}
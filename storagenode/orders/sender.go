@@ -133,7 +133,7 @@ func (sender *Sender) Settle(ctx context.Context, satelliteID storj.NodeID, orde
 	log.Info("sending", zap.Int("count", len(orders)))
 	defer log.Info("finished")
 
-	satellite, err := sender.kademlia.FindNode(ctx, satelliteID)
+	satellite, err := sender.kademlia.FindNode(kademlia.WithFreshLookup(ctx), satelliteID)
 	if err != nil {
 		log.Error("unable to find satellite on the network", zap.Error(err))
 		return
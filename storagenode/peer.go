@@ -11,8 +11,11 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"storj.io/storj/internal/errs2"
+	"storj.io/storj/internal/lifecycle"
+	"storj.io/storj/internal/sync2"
 	"storj.io/storj/internal/version"
 	"storj.io/storj/pkg/auth/signing"
+	"storj.io/storj/pkg/eventlog"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/kademlia"
 	"storj.io/storj/pkg/overlay"
@@ -23,11 +26,13 @@ import (
 	"storj.io/storj/pkg/transport"
 	"storj.io/storj/storage"
 	"storj.io/storj/storagenode/bandwidth"
+	"storj.io/storj/storagenode/contact"
 	"storj.io/storj/storagenode/inspector"
 	"storj.io/storj/storagenode/monitor"
 	"storj.io/storj/storagenode/orders"
 	"storj.io/storj/storagenode/pieces"
 	"storj.io/storj/storagenode/piecestore"
+	"storj.io/storj/storagenode/selftest"
 	"storj.io/storj/storagenode/trust"
 )
 
@@ -45,9 +50,13 @@ type DB interface {
 	CertDB() trust.CertDB
 	Bandwidth() bandwidth.DB
 	UsedSerials() piecestore.UsedSerials
+	Contact() contact.DB
 
 	// TODO: use better interfaces
 	RoutingTable() (kdb, ndb storage.KeyValueStore)
+	PeerIdentities() storage.KeyValueStore
+	PinnedNodes() storage.KeyValueStore
+	DialFailures() storage.KeyValueStore
 }
 
 // Config is all the configuration parameters for a Storage Node
@@ -55,12 +64,18 @@ type Config struct {
 	Identity identity.Config
 
 	Server   server.Config
+	Egress   transport.EgressConfig
+	Events   eventlog.Config
 	Kademlia kademlia.Config
 
 	Storage  piecestore.OldConfig
 	Storage2 piecestore.Config
 
+	Contact contact.Config
+
 	Version version.Config
+
+	SelfTest selftest.Config
 }
 
 // Verify verifies whether configuration is consistent and acceptable.
@@ -76,10 +91,16 @@ type Peer struct {
 	DB       DB
 
 	Transport transport.Client
+	Bandwidth *transport.Bandwidth
+	Events    *eventlog.Sink
 
 	Server *server.Server
 
 	Version *version.Service
+	// NextRuns reports the next runs of the periodic loops
+	NextRuns sync2.NextRuns
+	// Services are the background services, run by Run and closed by Close
+	Services *lifecycle.Group
 
 	// services and endpoints
 	// TODO: similar grouping to satellite.Peer
@@ -98,6 +119,10 @@ type Peer struct {
 		Monitor   *monitor.Service
 		Sender    *orders.Sender
 	}
+
+	Contact struct {
+		Service *contact.Service
+	}
 }
 
 // New creates a new Storage Node.
@@ -106,6 +131,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 		Log:      log,
 		Identity: full,
 		DB:       db,
+		Services: lifecycle.NewGroup(log.Named("services")),
 	}
 
 	var err error
@@ -116,7 +142,10 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 			peer.Log.Sugar().Debugf("Binary Version: %s with CommitHash %s, built at %s as Release %v",
 				versionInfo.Version.String(), versionInfo.CommitHash, versionInfo.Timestamp.String(), versionInfo.Release)
 		}
-		peer.Version = version.NewService(config.Version, versionInfo, "Storagenode")
+		peer.Version = version.NewService(log.Named("version"), config.Version, versionInfo, "Storagenode")
+		peer.Version.Loop.SetSplay(sync2.NewSplay(peer.Identity.ID.Bytes(), "version"))
+		peer.NextRuns.Add("version", peer.Version.Loop)
+		peer.Services.Add("version", peer.Version)
 	}
 
 	{ // setup listener and server
@@ -126,19 +155,36 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 			return nil, errs.Combine(err, peer.Close())
 		}
 
-		peer.Transport = transport.NewClient(options)
+		peer.Events, err = eventlog.Open(peer.Log.Named("events"), config.Events)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
 
-		peer.Server, err = server.New(options, sc.Address, sc.PrivateAddress, nil)
+		egress, err := transport.NewEgressPolicy(config.Egress)
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		proxy, err := transport.NewProxy(config.Egress.ProxyURL)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.Bandwidth = transport.NewBandwidth()
+		peer.Transport = transport.NewClient(options).WithEvents(peer.Events).WithEgressPolicy(egress).WithProxy(proxy).WithBandwidth(peer.Bandwidth)
+
+		peer.Server, err = server.New(peer.Log.Named("server"), options, sc.Address, sc.PrivateAddress, nil)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		if sc.WrapListener != nil {
+			peer.Server.WrapListener(sc.WrapListener)
+		}
 	}
 
 	{ // setup kademlia
 		config := config.Kademlia
 		// TODO: move this setup logic into kademlia package
 		if config.ExternalAddress == "" {
-			config.ExternalAddress = peer.Addr()
+			config.ExternalAddress = peer.Server.ExternalAddr()
 		}
 
 		pbVersion, err := versionInfo.Proto()
@@ -166,13 +212,24 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		if err := peer.Kademlia.RoutingTable.LoadPinned(peer.DB.PinnedNodes()); err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
 
 		peer.Transport = peer.Transport.WithObservers(peer.Kademlia.RoutingTable)
 
-		peer.Kademlia.Service, err = kademlia.NewService(peer.Log.Named("kademlia"), peer.Transport, peer.Kademlia.RoutingTable, config)
+		peer.Kademlia.Service, err = kademlia.NewService(peer.Log.Named("kademlia"), peer.Transport, peer.Kademlia.RoutingTable, peer.DB.PeerIdentities(), config)
 		if err != nil {
 			return nil, errs.Combine(err, peer.Close())
 		}
+		peer.NextRuns.AddFunc(peer.Kademlia.Service.NextRuns)
+		peer.Services.Add("kademlia", peer.Kademlia.Service)
+		peer.Services.Add("kademlia bootstrap", lifecycle.Func(peer.Kademlia.Service.Bootstrap))
+		if err := peer.Kademlia.Service.LoadDialFailures(peer.DB.DialFailures()); err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.Kademlia.Service.SetEventSink(peer.Events)
+		peer.Kademlia.Service.SetFeatures(peer.Version)
 
 		peer.Kademlia.Endpoint = kademlia.NewEndpoint(peer.Log.Named("kademlia:endpoint"), peer.Kademlia.Service, peer.Kademlia.RoutingTable)
 		pb.RegisterNodesServer(peer.Server.GRPC(), peer.Kademlia.Endpoint)
@@ -218,23 +275,49 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 			return nil, errs.Combine(err, peer.Close())
 		}
 		pb.RegisterPiecestoreServer(peer.Server.GRPC(), peer.Storage2.Endpoint)
+		peer.Services.Add("piecestore monitor", lifecycle.Func(peer.Storage2.Monitor.Run))
+
+		peer.Storage2.Sender = orders.NewSender(
+			log.Named("piecestore:orderssender"),
+			peer.Transport,
+			peer.Kademlia.Service,
+			peer.DB.Orders(),
+			config.Storage2.Sender,
+		)
+		peer.Services.Add("orders sender", lifecycle.Func(peer.Storage2.Sender.Run))
+	}
 
+	{ // setup contact
+		peer.Contact.Service, err = contact.NewService(
+			context.Background(),
+			peer.Log.Named("contact"),
+			peer.Kademlia.Service,
+			peer.Storage2.Trust,
+			peer.DB.Contact(),
+			versionInfo,
+			config.Contact,
+		)
+		if err != nil {
+			return nil, errs.Combine(err, peer.Close())
+		}
+		peer.NextRuns.AddFunc(peer.Contact.Service.NextRuns)
+		peer.Services.Add("contact", peer.Contact.Service)
+	}
+
+	{ // setup inspector
 		peer.Storage2.Inspector = inspector.NewEndpoint(
 			peer.Log.Named("pieces:inspector"),
 			peer.DB.PieceInfo(),
 			peer.Kademlia.Service,
 			peer.DB.Bandwidth(),
+			peer.DB.Contact(),
+			peer.Contact.Service,
+			peer.Version,
+			peer.Bandwidth,
 			config.Storage,
 		)
 		pb.RegisterPieceStoreInspectorServer(peer.Server.PrivateGRPC(), peer.Storage2.Inspector)
-
-		peer.Storage2.Sender = orders.NewSender(
-			log.Named("piecestore:orderssender"),
-			peer.Transport,
-			peer.Kademlia.Service,
-			peer.DB.Orders(),
-			config.Storage2.Sender,
-		)
+		peer.Services.Add("inspector", lifecycle.Func(peer.Storage2.Inspector.Run))
 	}
 
 	return peer, nil
@@ -244,21 +327,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB, config Config, ver
 func (peer *Peer) Run(ctx context.Context) error {
 	group, ctx := errgroup.WithContext(ctx)
 
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Version.Run(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Kademlia.Service.Bootstrap(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Kademlia.Service.Run(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Storage2.Sender.Run(ctx))
-	})
-	group.Go(func() error {
-		return errs2.IgnoreCanceled(peer.Storage2.Monitor.Run(ctx))
-	})
+	peer.Services.Start(ctx, group)
 	group.Go(func() error {
 		// TODO: move the message into Server instead
 		// Don't change the format of this comment, it is used to figure out the node id.
@@ -283,13 +352,15 @@ func (peer *Peer) Close() error {
 	}
 
 	// close services in reverse initialization order
-	if peer.Kademlia.Service != nil {
-		errlist.Add(peer.Kademlia.Service.Close())
-	}
+	errlist.Add(peer.Services.Close())
 	if peer.Kademlia.RoutingTable != nil {
 		errlist.Add(peer.Kademlia.RoutingTable.Close())
 	}
 
+	if peer.Events != nil {
+		errlist.Add(peer.Events.Close())
+	}
+
 	return errlist.Err()
 }
 
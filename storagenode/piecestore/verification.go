@@ -6,7 +6,6 @@ package piecestore
 import (
 	"bytes"
 	"context"
-	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/timestamp"
@@ -15,6 +14,7 @@ import (
 	"storj.io/storj/pkg/auth/signing"
 	"storj.io/storj/pkg/identity"
 	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/storj"
 )
 
 var (
@@ -35,9 +35,9 @@ func (endpoint *Endpoint) VerifyOrderLimit(ctx context.Context, limit *pb.OrderL
 		return ErrProtocol.New("order limit is negative")
 	case endpoint.signer.ID() != limit.StorageNodeId:
 		return ErrProtocol.New("order intended for other storagenode: %v", limit.StorageNodeId)
-	case limit.PieceExpiration != nil && endpoint.IsExpired(limit.PieceExpiration):
+	case limit.PieceExpiration != nil && endpoint.IsExpired(limit.SatelliteId, limit.PieceExpiration):
 		return ErrProtocol.New("piece expired: %v", limit.PieceExpiration)
-	case endpoint.IsExpired(limit.OrderExpiration):
+	case endpoint.IsExpired(limit.SatelliteId, limit.OrderExpiration):
 		return ErrProtocol.New("order expired: %v", limit.OrderExpiration)
 
 	case limit.SatelliteId.IsZero():
@@ -140,8 +140,11 @@ func (endpoint *Endpoint) VerifyOrderLimitSignature(ctx context.Context, limit *
 	return nil
 }
 
-// IsExpired checks whether the date has already expired (with a threshold) at the time of calling this function.
-func (endpoint *Endpoint) IsExpired(expiration *timestamp.Timestamp) bool {
+// IsExpired checks whether the date set by the satellite has already expired
+// (with a threshold) at the time of calling this function. The time is the
+// one on the clock of the satellite, so that clock skew doesn't expire its
+// orders early.
+func (endpoint *Endpoint) IsExpired(satellite storj.NodeID, expiration *timestamp.Timestamp) bool {
 	if expiration == nil {
 		return true
 	}
@@ -153,5 +156,5 @@ func (endpoint *Endpoint) IsExpired(expiration *timestamp.Timestamp) bool {
 	}
 
 	// TODO: return specific error about either exceeding the expiration completely or just the grace period
-	return expirationTime.Before(time.Now().Add(-endpoint.config.ExpirationGracePeriod))
+	return expirationTime.Before(endpoint.trust.Now(satellite).Add(-endpoint.config.ExpirationGracePeriod))
 }
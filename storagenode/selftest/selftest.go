@@ -0,0 +1,278 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+// Package selftest checks the setup of a storage node, such as its identity
+// and TLS configuration, so that problems are reported before the node starts
+// rather than as failing dials.
+package selftest
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zeebo/errs"
+	"go.uber.org/zap"
+	monkit "gopkg.in/spacemonkeygo/monkit.v2"
+
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/identity"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/peertls"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/pkg/pkcrypto"
+)
+
+var (
+	// Error is the class of errors of failed checks.
+	Error = errs.Class("selftest error")
+	mon   = monkit.Package()
+)
+
+// Config configures the self-test.
+type Config struct {
+	AtStartup     bool          `help:"run the self-test before starting and refuse to start when it fails" default:"false"`
+	MinDifficulty uint          `help:"minimum difficulty of the identity of the node" default:"30"`
+	Timeout       time.Duration `help:"timeout for each step of the self-test" default:"10s"`
+}
+
+// defaultTimeout is the timeout of each step when Config.Timeout isn't set.
+const defaultTimeout = 10 * time.Second
+
+// Steps are the steps of a self-test, in order.
+var Steps = []string{"identity", "tls", "handshake", "config", "version"}
+
+// Step is the result of a single step of a self-test.
+type Step struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Advice   string        `json:"advice,omitempty"`
+}
+
+// Setup is the configuration of the storage node checked by a self-test.
+type Setup struct {
+	Identity    identity.Config
+	TLS         tlsopts.Config
+	Kademlia    kademlia.Config
+	Version     version.Config
+	VersionInfo version.Info
+}
+
+// Failure is a failed check with advice on how to fix the setup.
+type Failure struct {
+	Err    error
+	Advice string
+}
+
+// Error returns the error of the check.
+func (failure *Failure) Error() string { return failure.Err.Error() }
+
+// fail returns the failure of a check with err.
+func fail(err error, advice string) error {
+	return &Failure{Err: err, Advice: advice}
+}
+
+// Run runs the steps of a self-test of setup, each limited to
+// config.Timeout. The TLS steps are skipped when the identity can't be
+// loaded, the other steps run regardless of the failures.
+func Run(ctx context.Context, log *zap.Logger, config Config, setup Setup) (steps []Step) {
+	defer mon.Task()(&ctx)(nil)
+
+	var ident *identity.FullIdentity
+	var options *tlsopts.Options
+	defer func() {
+		if options != nil && options.RevDB != nil {
+			if err := options.RevDB.Close(); err != nil {
+				log.Debug("closing the revocation database failed", zap.Error(err))
+			}
+		}
+	}()
+
+	checks := map[string]func(ctx context.Context) (detail string, err error){
+		"identity": func(ctx context.Context) (_ string, err error) {
+			ident, err = CheckIdentity(setup.Identity, config.MinDifficulty)
+			if err != nil {
+				return "", err
+			}
+			return ident.ID.String(), nil
+		},
+		"tls": func(ctx context.Context) (string, error) {
+			var err error
+			options, err = CheckTLS(ident, setup.TLS)
+			return "", err
+		},
+		"handshake": func(ctx context.Context) (string, error) {
+			return "", CheckHandshake(ctx, options)
+		},
+		"config": func(ctx context.Context) (string, error) {
+			return "", CheckConfig(log, setup.Kademlia)
+		},
+		"version": func(ctx context.Context) (string, error) {
+			return CheckVersion(ctx, log, setup.Version, setup.VersionInfo)
+		},
+	}
+	requires := map[string]string{"tls": "identity", "handshake": "tls"}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	passed := map[string]bool{}
+	for _, name := range Steps {
+		step := Step{Name: name}
+		if required, ok := requires[name]; ok && !passed[required] {
+			step.Skipped = true
+			steps = append(steps, step)
+			continue
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		detail, err := checks[name](stepCtx)
+		step.Duration = time.Since(start)
+		cancel()
+
+		step.Passed, step.Detail = err == nil, detail
+		if err != nil {
+			step.Error = err.Error()
+			if failure, ok := err.(*Failure); ok {
+				step.Advice = failure.Advice
+			}
+		}
+		passed[name] = step.Passed
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// Err returns an error naming the failed steps, nil when none failed.
+func Err(steps []Step) error {
+	var failed []string
+	for _, step := range steps {
+		if !step.Passed && !step.Skipped {
+			failed = append(failed, step.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return Error.New("%s failed", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// CheckIdentity loads the identity of config and verifies that the key
+// belongs to the certificate, that the certificate chain is valid, and that
+// the difficulty of the node ID is at least minDifficulty.
+func CheckIdentity(config identity.Config, minDifficulty uint) (*identity.FullIdentity, error) {
+	for _, path := range []string{config.CertPath, config.KeyPath} {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fail(err, "the identity files are missing or unreadable, check identity.cert-path and identity.key-path, or create an identity with the identity tool")
+		}
+	}
+
+	ident, err := config.Load()
+	if err != nil {
+		return nil, fail(err, "the identity files are corrupt, restore them from a backup or create a new identity")
+	}
+
+	if !pkcrypto.PublicKeyEqual(pkcrypto.PublicKeyFromPrivate(ident.Key), ident.Leaf.PublicKey) {
+		return nil, fail(Error.New("the private key %s doesn't belong to the certificate %s", config.KeyPath, config.CertPath),
+			"the identity directory mixes the files of different identities, use the key and certificate created together")
+	}
+
+	if err := peertls.VerifyPeerCertChains(nil, identity.ToChains(ident.Chain())); err != nil {
+		return nil, fail(err, "the certificate chain is invalid, the certificate may not be signed by the certificate authority of the identity")
+	}
+
+	difficulty, err := ident.ID.Difficulty()
+	if err != nil {
+		return nil, fail(err, "the node ID can't be checked, create a new identity")
+	}
+	if uint(difficulty) < minDifficulty {
+		return nil, fail(Error.New("identity difficulty %d is below the minimum of %d", difficulty, minDifficulty),
+			"other nodes reject the identity, create a new one with the identity tool and the default difficulty")
+	}
+	return ident, nil
+}
+
+// CheckTLS returns the TLS options of ident configured by config, loading
+// the whitelist and opening the revocation database. The revocation
+// database of the options must be closed.
+func CheckTLS(ident *identity.FullIdentity, config tlsopts.Config) (*tlsopts.Options, error) {
+	options, err := tlsopts.NewOptions(ident, config)
+	if err != nil {
+		return nil, fail(err, "check the peer CA whitelist and revocation database settings of the server")
+	}
+	return options, nil
+}
+
+// CheckHandshake makes a TLS handshake of the node of options with itself
+// over loopback, verifying its certificate chain like other nodes would.
+func CheckHandshake(ctx context.Context, options *tlsopts.Options) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	serverConfig, clientConfig := options.SelfHandshakeTLSConfigs()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fail(err, "unable to listen on loopback for the handshake")
+	}
+	defer func() { _ = listener.Close() }()
+
+	accepted := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			accepted <- err
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		accepted <- tls.Server(conn, serverConfig).Handshake()
+	}()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", listener.Addr().String())
+	if err != nil {
+		return fail(err, "unable to connect over loopback for the handshake")
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	err = errs.Combine(tls.Client(conn, clientConfig).Handshake(), <-accepted)
+	if err != nil {
+		return fail(err, "other nodes would reject the identity, check that it is signed by a whitelisted CA, not revoked, and of an identity version the server allows")
+	}
+	return nil
+}
+
+// CheckConfig verifies the kademlia configuration.
+func CheckConfig(log *zap.Logger, config kademlia.Config) error {
+	if err := config.Verify(log); err != nil {
+		return fail(err, "fix the kademlia settings of the configuration file")
+	}
+	return nil
+}
+
+// CheckVersion queries the version servers of config and returns the server
+// that responded.
+func CheckVersion(ctx context.Context, log *zap.Logger, config version.Config, info version.Info) (string, error) {
+	server, err := version.NewService(log.Named("version"), config, info, "Storagenode").CheckServers(ctx)
+	if err != nil {
+		if server != "" {
+			return server, fail(err, "the running release is outdated, update the storage node")
+		}
+		return "", fail(err, "no version server responded, check version.server-address and the network connection")
+	}
+	return server, nil
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package selftest_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"storj.io/storj/internal/testcontext"
+	"storj.io/storj/internal/testidentity"
+	"storj.io/storj/internal/version"
+	"storj.io/storj/pkg/identity"
+	"storj.io/storj/pkg/kademlia"
+	"storj.io/storj/pkg/peertls/tlsopts"
+	"storj.io/storj/storagenode/selftest"
+)
+
+func TestSelfTest(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	versions := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(version.AllowedVersions{})
+	}))
+	defer versions.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	// healthy returns a setup that passes the self-test, with its identity saved in dir
+	healthy := func(dir string) selftest.Setup {
+		ident, err := testidentity.NewTestIdentity(ctx)
+		require.NoError(t, err)
+		identConfig := identity.Config{
+			CertPath: ctx.File(dir, "identity.cert"),
+			KeyPath:  ctx.File(dir, "identity.key"),
+		}
+		require.NoError(t, identConfig.Save(ident))
+
+		return selftest.Setup{
+			Identity: identConfig,
+			TLS: tlsopts.Config{
+				RevocationDBURL: "bolt://" + ctx.File(dir, "revocations.db"),
+				PeerIDVersions:  "*",
+			},
+			Kademlia: kademlia.Config{
+				Alpha:                5,
+				BootstrapBackoffBase: time.Second,
+				BootstrapBackoffMax:  30 * time.Second,
+				Dialer:               kademlia.DialerOptions{AllowPrivateAddresses: true},
+				Operator:             kademlia.OperatorConfig{Wallet: "0x" + strings.Repeat("00", 20)},
+			},
+			Version: version.Config{ServerAddress: versions.URL, RequestTimeout: 5 * time.Second},
+		}
+	}
+	config := selftest.Config{Timeout: 10 * time.Second}
+
+	// check runs the self-test of setup and returns the steps that failed
+	check := func(setup selftest.Setup) map[string]selftest.Step {
+		steps := selftest.Run(ctx, zaptest.NewLogger(t), config, setup)
+		require.Len(t, steps, len(selftest.Steps))
+		failed := map[string]selftest.Step{}
+		for i, step := range steps {
+			assert.Equal(t, selftest.Steps[i], step.Name)
+			if !step.Passed {
+				failed[step.Name] = step
+			}
+		}
+		if len(failed) == 0 {
+			assert.NoError(t, selftest.Err(steps))
+		} else {
+			assert.Error(t, selftest.Err(steps))
+		}
+		return failed
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		failed := check(healthy("healthy"))
+		assert.Empty(t, failed)
+	})
+
+	t.Run("mismatched key", func(t *testing.T) {
+		setup := healthy("mismatched")
+		other := healthy("other")
+		key, err := ioutil.ReadFile(other.Identity.KeyPath)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(setup.Identity.KeyPath, key, 0600))
+
+		failed := check(setup)
+		require.Contains(t, failed, "identity")
+		assert.Contains(t, failed["identity"].Error, "doesn't belong to the certificate")
+		assert.NotEmpty(t, failed["identity"].Advice)
+		assert.True(t, failed["tls"].Skipped)
+		assert.True(t, failed["handshake"].Skipped)
+		assert.Len(t, failed, 3)
+	})
+
+	t.Run("missing cert", func(t *testing.T) {
+		setup := healthy("missing")
+		require.NoError(t, os.Remove(setup.Identity.CertPath))
+
+		failed := check(setup)
+		require.Contains(t, failed, "identity")
+		assert.Contains(t, failed["identity"].Advice, "identity.cert-path")
+		assert.Len(t, failed, 3)
+	})
+
+	t.Run("low difficulty", func(t *testing.T) {
+		config := config
+		config.MinDifficulty = 256
+		steps := selftest.Run(ctx, zaptest.NewLogger(t), config, healthy("difficulty"))
+		require.False(t, steps[0].Passed)
+		assert.Contains(t, steps[0].Error, "difficulty")
+	})
+
+	t.Run("unreachable version server", func(t *testing.T) {
+		setup := healthy("unreachable")
+		setup.Version.ServerAddress = unreachable.URL
+
+		failed := check(setup)
+		require.Contains(t, failed, "version")
+		assert.Contains(t, failed["version"].Advice, "version.server-address")
+		assert.Len(t, failed, 1)
+	})
+
+	t.Run("invalid kademlia config", func(t *testing.T) {
+		setup := healthy("config")
+		setup.Kademlia.Operator.Wallet = "wallet"
+
+		failed := check(setup)
+		require.Contains(t, failed, "config")
+		assert.Len(t, failed, 1)
+	})
+
+	t.Run("missing whitelist", func(t *testing.T) {
+		setup := healthy("whitelist")
+		setup.TLS.UsePeerCAWhitelist = true
+		setup.TLS.PeerCAWhitelistPath = ctx.File("whitelist", "missing.pem")
+
+		failed := check(setup)
+		require.Contains(t, failed, "tls")
+		assert.True(t, failed["handshake"].Skipped)
+		assert.Len(t, failed, 2)
+	})
+}
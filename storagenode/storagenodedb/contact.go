@@ -0,0 +1,135 @@
+// Copyright (C) 2019 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package storagenodedb
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/storj/pkg/storj"
+	"storj.io/storj/storagenode/contact"
+)
+
+type contactdb struct {
+	*InfoDB
+}
+
+// Contact returns database for satellite check-ins.
+func (db *DB) Contact() contact.DB { return db.info.Contact() }
+
+// Contact returns database for satellite check-ins.
+func (db *InfoDB) Contact() contact.DB { return &contactdb{db} }
+
+// Update records the check-in status of a satellite.
+func (db *contactdb) Update(ctx context.Context, satelliteID storj.NodeID, status contact.Status) error {
+	defer db.locked()()
+
+	_, err := db.db.Exec(`
+		INSERT OR REPLACE INTO
+			satellite_contact(satellite_id, last_success, last_failure, failures)
+		VALUES(?, ?, ?, ?)`, satelliteID, status.LastSuccess.UTC(), status.LastFailure.UTC(), status.Failures)
+
+	return ErrInfo.Wrap(err)
+}
+
+// List returns the check-in status of all satellites.
+func (db *contactdb) List(ctx context.Context) (_ map[storj.NodeID]contact.Status, err error) {
+	defer db.locked()()
+
+	rows, err := db.db.Query(`SELECT satellite_id, last_success, last_failure, failures FROM satellite_contact`)
+	if err != nil {
+		return nil, ErrInfo.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, ErrInfo.Wrap(rows.Close())) }()
+
+	all := map[storj.NodeID]contact.Status{}
+	for rows.Next() {
+		var satelliteID storj.NodeID
+		var lastSuccess, lastFailure time.Time
+		var failures int
+
+		err := rows.Scan(&satelliteID, &lastSuccess, &lastFailure, &failures)
+		if err != nil {
+			return nil, ErrInfo.Wrap(err)
+		}
+
+		all[satelliteID] = contact.Status{
+			LastSuccess: lastSuccess,
+			LastFailure: lastFailure,
+			Failures:    failures,
+		}
+	}
+
+	return all, ErrInfo.Wrap(rows.Err())
+}
+
+// AddRollup adds the counts and durations of rollup to the stored rollup of its satellite and day.
+func (db *contactdb) AddRollup(ctx context.Context, rollup contact.Rollup) error {
+	defer db.locked()()
+
+	day := rollup.Day.UTC()
+	_, err := db.db.Exec(`
+		INSERT OR IGNORE INTO
+			satellite_reachability(satellite_id, day, attempts, successes, windows, observed, downtime)
+		VALUES(?, ?, 0, 0, 0, 0, 0)`, rollup.SatelliteID, day)
+	if err != nil {
+		return ErrInfo.Wrap(err)
+	}
+
+	_, err = db.db.Exec(`
+		UPDATE satellite_reachability SET
+			attempts = attempts + ?,
+			successes = successes + ?,
+			windows = windows + ?,
+			observed = observed + ?,
+			downtime = downtime + ?
+		WHERE satellite_id = ? AND day = ?`,
+		rollup.Attempts, rollup.Successes, rollup.Windows,
+		int64(rollup.Observed), int64(rollup.Downtime),
+		rollup.SatelliteID, day)
+
+	return ErrInfo.Wrap(err)
+}
+
+// Rollups returns the rollups of the days starting at since or later, sorted by day and satellite.
+func (db *contactdb) Rollups(ctx context.Context, since time.Time) (_ []contact.Rollup, err error) {
+	defer db.locked()()
+
+	rows, err := db.db.Query(`
+		SELECT satellite_id, day, attempts, successes, windows, observed, downtime
+		FROM satellite_reachability
+		WHERE day >= ?
+		ORDER BY day, satellite_id`, since.UTC())
+	if err != nil {
+		return nil, ErrInfo.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, ErrInfo.Wrap(rows.Close())) }()
+
+	var rollups []contact.Rollup
+	for rows.Next() {
+		var rollup contact.Rollup
+		var observed, downtime int64
+
+		err := rows.Scan(&rollup.SatelliteID, &rollup.Day, &rollup.Attempts, &rollup.Successes, &rollup.Windows, &observed, &downtime)
+		if err != nil {
+			return nil, ErrInfo.Wrap(err)
+		}
+		rollup.Observed = time.Duration(observed)
+		rollup.Downtime = time.Duration(downtime)
+
+		rollups = append(rollups, rollup)
+	}
+
+	return rollups, ErrInfo.Wrap(rows.Err())
+}
+
+// DeleteRollupsBefore deletes the rollups of the days starting before before.
+func (db *contactdb) DeleteRollupsBefore(ctx context.Context, before time.Time) error {
+	defer db.locked()()
+
+	_, err := db.db.Exec(`DELETE FROM satellite_reachability WHERE day < ?`, before.UTC())
+	return ErrInfo.Wrap(err)
+}
@@ -39,7 +39,7 @@ type DB struct {
 
 	info *InfoDB
 
-	kdb, ndb storage.KeyValueStore
+	kdb, ndb, idb, pdb, fdb storage.KeyValueStore
 }
 
 // New creates a new master database for storage node
@@ -55,7 +55,7 @@ func New(log *zap.Logger, config Config) (*DB, error) {
 		return nil, err
 	}
 
-	dbs, err := boltdb.NewShared(config.Kademlia, kademlia.KademliaBucket, kademlia.NodeBucket)
+	dbs, err := boltdb.NewShared(config.Kademlia, kademlia.KademliaBucket, kademlia.NodeBucket, kademlia.IdentityBucket, kademlia.PinnedBucket, kademlia.FailureBucket)
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +69,9 @@ func New(log *zap.Logger, config Config) (*DB, error) {
 
 		kdb: dbs[0],
 		ndb: dbs[1],
+		idb: dbs[2],
+		pdb: dbs[3],
+		fdb: dbs[4],
 	}, nil
 }
 
@@ -94,6 +97,9 @@ func NewInMemory(log *zap.Logger, storageDir string) (*DB, error) {
 
 		kdb: teststore.New(),
 		ndb: teststore.New(),
+		idb: teststore.New(),
+		pdb: teststore.New(),
+		fdb: teststore.New(),
 	}, nil
 }
 
@@ -107,6 +113,9 @@ func (db *DB) Close() error {
 	return errs.Combine(
 		db.kdb.Close(),
 		db.ndb.Close(),
+		db.idb.Close(),
+		db.pdb.Close(),
+		db.fdb.Close(),
 
 		db.pieces.Close(),
 		db.info.Close(),
@@ -122,3 +131,18 @@ func (db *DB) Pieces() storage.Blobs {
 func (db *DB) RoutingTable() (kdb, ndb storage.KeyValueStore) {
 	return db.kdb, db.ndb
 }
+
+// PeerIdentities returns the database for verified peer identities
+func (db *DB) PeerIdentities() storage.KeyValueStore {
+	return db.idb
+}
+
+// PinnedNodes returns the database for pinned nodes
+func (db *DB) PinnedNodes() storage.KeyValueStore {
+	return db.pdb
+}
+
+// DialFailures returns the database for dial failure statistics
+func (db *DB) DialFailures() storage.KeyValueStore {
+	return db.fdb
+}
@@ -163,6 +163,37 @@ func (db *InfoDB) Migration() *migrate.Migration {
 					`CREATE INDEX idx_order_archive_status ON order_archive(status)`,
 				},
 			},
+			{
+				Description: "Add satellite check-in status",
+				Version:     1,
+				Action: migrate.SQL{
+					// table for keeping the last check-in results for each satellite
+					`CREATE TABLE satellite_contact (
+						satellite_id BLOB      NOT NULL,
+						last_success TIMESTAMP NOT NULL,
+						last_failure TIMESTAMP NOT NULL,
+						failures     INTEGER   NOT NULL, -- consecutive failures
+						PRIMARY KEY (satellite_id)
+					)`,
+				},
+			},
+			{
+				Description: "Add daily satellite reachability",
+				Version:     2,
+				Action: migrate.SQL{
+					// table for keeping the daily check-in rollups for each satellite
+					`CREATE TABLE satellite_reachability (
+						satellite_id BLOB      NOT NULL,
+						day          TIMESTAMP NOT NULL, -- UTC midnight
+						attempts     INTEGER   NOT NULL,
+						successes    INTEGER   NOT NULL,
+						windows      INTEGER   NOT NULL, -- downtime windows starting during the day
+						observed     INTEGER   NOT NULL, -- nanoseconds between check-ins
+						downtime     INTEGER   NOT NULL, -- nanoseconds after failed check-ins
+						PRIMARY KEY (satellite_id, day)
+					)`,
+				},
+			},
 		},
 	}
 }
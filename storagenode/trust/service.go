@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/zeebo/errs"
 
@@ -94,6 +95,26 @@ func (pool *Pool) VerifyUplinkID(ctx context.Context, id storj.NodeID) error {
 	return nil
 }
 
+// Now returns the current time on the clock of the satellite with id, as
+// estimated from its responses, for validating the timestamps it signed.
+func (pool *Pool) Now(id storj.NodeID) time.Time {
+	return pool.kademlia.PeerNow(id)
+}
+
+// GetSatellites returns the list of satellites known to the pool.
+//
+// When all satellites are trusted, only satellites that have been seen so far are returned.
+func (pool *Pool) GetSatellites(ctx context.Context) []storj.NodeID {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	satellites := make([]storj.NodeID, 0, len(pool.trustedSatellites))
+	for id := range pool.trustedSatellites {
+		satellites = append(satellites, id)
+	}
+	return satellites
+}
+
 // GetSignee gets the corresponding signee for verifying signatures.
 // It ignores passed in ctx cancellation to avoid miscaching between concurrent requests.
 func (pool *Pool) GetSignee(ctx context.Context, id storj.NodeID) (signing.Signee, error) {
@@ -14,6 +14,7 @@ import (
 	"storj.io/storj/internal/memory"
 	"storj.io/storj/pkg/auth/signing"
 	"storj.io/storj/pkg/pb"
+	"storj.io/storj/pkg/transport"
 )
 
 // Error is the default error class for piecestore client.
@@ -57,6 +58,20 @@ func NewClient(log *zap.Logger, signer signing.Signer, conn *grpc.ClientConn, co
 	}
 }
 
+// Dial dials the target storage node and returns a client for it, signing
+// with the identity of transport.
+//
+// The connection is verified the same way as those of the kademlia dialer:
+// the node must prove that it has the target ID, and its identity must pass
+// the checks of transport, such as revocations.
+func Dial(ctx context.Context, transport transport.Client, target *pb.Node, log *zap.Logger, config Config) (*Client, error) {
+	conn, err := transport.DialNode(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(log, signing.SignerFromFullIdentity(transport.Identity()), conn, config), nil
+}
+
 // Delete uses delete order limit to delete a piece on piece store.
 func (client *Client) Delete(ctx context.Context, limit *pb.OrderLimit2) error {
 	_, err := client.client.Delete(ctx, &pb.PieceDeleteRequest{
@@ -5,6 +5,7 @@ package versioncontrol
 
 import (
 	"context"
+	"crypto"
 	"encoding/json"
 	"net"
 	"net/http"
@@ -20,8 +21,10 @@ import (
 
 // Config is all the configuration parameters for a Version Control Server
 type Config struct {
-	Address  string `user:"true" help:"public address to listen on" default:":8080"`
-	Versions ServiceVersions
+	Address    string `user:"true" help:"public address to listen on" default:":8080"`
+	SigningKey string `user:"true" help:"path to the private key signing the responses, responses are not signed when empty" default:""`
+	Versions   ServiceVersions
+	Features   string `user:"true" help:"features enabled for every service, as <name>=<true, false or rollout percentage> separated by commas" default:"kademlia.query-stream=true"`
 }
 
 // ServiceVersions provides a list of allowed Versions per Service
@@ -48,6 +51,8 @@ type Peer struct {
 
 	// response contains the byte version of current allowed versions
 	response []byte
+	// signingKey signs the response, when set
+	signingKey crypto.PrivateKey
 }
 
 // HandleGet contains the request handler for the version control web server
@@ -65,6 +70,13 @@ func (peer *Peer) HandleGet(w http.ResponseWriter, r *http.Request) {
 	zap.S().Debugf("Request from: %s for %s", r.RemoteAddr, xfor)
 
 	w.Header().Set("Content-Type", "application/json")
+	if peer.signingKey != nil {
+		if err := version.SignResponse(w.Header(), peer.signingKey, peer.response); err != nil {
+			zap.S().Errorf("error signing response: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
 	_, err := w.Write(peer.response)
 	if err != nil {
 		zap.S().Errorf("error writing response to client: %v", err)
@@ -96,6 +108,11 @@ func New(log *zap.Logger, config *Config) (peer *Peer, err error) {
 	identityVersions := strings.Split(config.Versions.Identity, ",")
 	peer.Versions.Identity, err = version.StrToSemVerList(identityVersions)
 
+	peer.Versions.Features, err = version.ParseFeatures(config.Features)
+	if err != nil {
+		return nil, err
+	}
+
 	peer.response, err = json.Marshal(peer.Versions)
 
 	if err != nil {
@@ -104,6 +121,13 @@ func New(log *zap.Logger, config *Config) (peer *Peer, err error) {
 
 	peer.Log.Sugar().Debugf("setting version info to: %v", string(peer.response))
 
+	if config.SigningKey != "" {
+		peer.signingKey, err = version.LoadPrivateKey(config.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", peer.HandleGet)
 	peer.Server.Endpoint = http.Server{